@@ -0,0 +1,220 @@
+// Package alerts evaluates user-defined alert rules such as "broken_links > 5" after every crawl and
+// uptime ping, and notifies the rule's channels when a rule starts or stops matching a URL.
+package alerts
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"sykell-analyze/backend/config"
+	"sykell-analyze/backend/urlstatus"
+)
+
+// Events of a Notification
+const (
+	EventFiring   = "firing"
+	EventResolved = "resolved"
+)
+
+// rule is an alert rule applying to the URL being evaluated
+type rule struct {
+	id        int
+	name      string
+	condition Condition
+	channels  []Channel
+}
+
+// StatusChanged evaluates the crawl rules of a URL whose crawl finished; subscribe it with urlstatus.Subscribe
+func StatusChanged(ctx context.Context, change urlstatus.Change) {
+	if change.To == urlstatus.Completed || change.To == urlstatus.Error {
+		EvaluateCrawl(ctx, change.UrlID)
+	}
+}
+
+// EvaluateCrawl evaluates the crawl rules of a URL against its latest crawl. After a failed crawl only
+// status is known; the link counts still describe the previous crawl and are not evaluated. Nothing is
+// evaluated while the URL has not finished crawling. content_change is the percentage of the page text
+// that changed since the previous crawl, unknown after the first one. The URL is looked up in the
+// database of ctx's tenant.
+func EvaluateCrawl(ctx context.Context, urlID int) {
+	db := config.DBFor(ctx)
+	var status string
+	var brokenLinks, internalLinks, externalLinks, h1Count int
+	err := db.QueryRow(
+		"SELECT status, broken_links, internal_links, external_links, h1_count FROM urls WHERE id = ?", urlID,
+	).Scan(&status, &brokenLinks, &internalLinks, &externalLinks, &h1Count)
+	if err != nil {
+		fmt.Printf("DEBUG: Failed to load crawl facts for alerts of URL ID %d: %v\n", urlID, err)
+		return
+	}
+
+	if status != "completed" && status != "error" {
+		return
+	}
+
+	facts := Facts{"status": status}
+	if status == "completed" {
+		facts["broken_links"] = brokenLinks
+		facts["internal_links"] = internalLinks
+		facts["external_links"] = externalLinks
+		facts["h1_count"] = h1Count
+
+		var contentChange sql.NullFloat64
+		err := db.QueryRow(
+			"SELECT change_percent FROM content_snapshots WHERE url_id = ? ORDER BY id DESC LIMIT 1", urlID,
+		).Scan(&contentChange)
+		if err != nil && err != sql.ErrNoRows {
+			fmt.Printf("DEBUG: Failed to load content change for alerts of URL ID %d: %v\n", urlID, err)
+		}
+		if contentChange.Valid {
+			facts["content_change"] = contentChange.Float64
+		}
+	}
+	evaluate(context.WithoutCancel(ctx), urlID, SourceCrawl, facts)
+}
+
+// EvaluatePing evaluates the monitor rules of a URL against an uptime ping. statusCode is 0 when the
+// request failed, in which case only up is known.
+func EvaluatePing(ctx context.Context, urlID int, up bool, statusCode int, responseTime time.Duration) {
+	facts := Facts{"up": up}
+	if statusCode != 0 {
+		facts["status_code"] = statusCode
+		facts["response_time"] = responseTime
+	}
+	evaluate(context.WithoutCancel(ctx), urlID, SourceMonitor, facts)
+}
+
+// evaluate notifies the channels of every rule of source whose outcome changed for the URL
+func evaluate(ctx context.Context, urlID int, source string, facts Facts) {
+	rules, pageURL, err := loadRules(ctx, urlID, source)
+	if err != nil {
+		fmt.Printf("DEBUG: Failed to load alert rules for URL ID %d: %v\n", urlID, err)
+		return
+	}
+
+	for _, r := range rules {
+		matched, ok := r.condition.Matches(facts)
+		if !ok {
+			continue
+		}
+		changed, err := setState(ctx, r.id, urlID, matched)
+		if err != nil {
+			fmt.Printf("DEBUG: Failed to update state of alert rule %d for URL ID %d: %v\n", r.id, urlID, err)
+			continue
+		}
+		if !changed {
+			continue
+		}
+
+		n := newNotification(r, urlID, pageURL, matched, facts[r.condition.Metric])
+		for _, ch := range r.channels {
+			if err := deliver(ctx, ch, n); err != nil {
+				fmt.Printf("DEBUG: Failed to deliver alert rule %d to %s channel: %v\n", r.id, ch.Type, err)
+			}
+		}
+	}
+}
+
+// newNotification describes a rule that started (matched) or stopped matching a URL
+func newNotification(r rule, urlID int, pageURL string, matched bool, value interface{}) Notification {
+	n := Notification{
+		Event:     EventFiring,
+		RuleID:    r.id,
+		RuleName:  r.name,
+		Condition: r.condition.String(),
+		UrlID:     urlID,
+		Url:       pageURL,
+		Value:     formatValue(value),
+		At:        time.Now(),
+	}
+	n.Message = fmt.Sprintf("%s: %s is %s on %s (%s)", r.name, r.condition.Metric, n.Value, pageURL, n.Condition)
+	if !matched {
+		n.Event = EventResolved
+		n.Message = fmt.Sprintf("Resolved %s: %s is %s on %s", r.name, r.condition.Metric, n.Value, pageURL)
+	}
+	return n
+}
+
+// formatValue prints a fact the way conditions are written
+func formatValue(value interface{}) string {
+	switch v := value.(type) {
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case time.Duration:
+		return v.Round(time.Millisecond).String()
+	}
+	return fmt.Sprint(value)
+}
+
+// loadRules returns the account-wide and URL-specific rules of source that apply to the URL, with the URL itself
+func loadRules(ctx context.Context, urlID int, source string) ([]rule, string, error) {
+	rows, err := config.DBFor(ctx).Query(`
+		SELECT r.id, r.name, r.expression, r.channels, u.url
+		FROM alert_rules r
+		JOIN urls u ON u.user_id = r.user_id
+		WHERE u.id = ? AND (r.url_id IS NULL OR r.url_id = u.id)
+	`, urlID)
+	if err != nil {
+		return nil, "", err
+	}
+	defer rows.Close()
+
+	var rules []rule
+	var pageURL string
+	for rows.Next() {
+		var r rule
+		var expression string
+		var channels []byte
+		if err := rows.Scan(&r.id, &r.name, &expression, &channels, &pageURL); err != nil {
+			return nil, "", err
+		}
+		if r.condition, err = ParseCondition(expression); err != nil || r.condition.Source() != source {
+			continue // rules are validated when saved; skip any the current metrics no longer support
+		}
+		if err := json.Unmarshal(channels, &r.channels); err != nil {
+			continue
+		}
+		rules = append(rules, r)
+	}
+	return rules, pageURL, rows.Err()
+}
+
+// setState records whether the rule matches the URL and reports whether that changed. A rule seen for
+// the first time only counts as changed when it matches. Conditional updates make sure that of several
+// processes evaluating at once, only one notifies.
+func setState(ctx context.Context, ruleID, urlID int, firing bool) (bool, error) {
+	db := config.DBFor(ctx)
+	now := time.Now()
+	var current bool
+	err := db.QueryRow("SELECT firing FROM alert_states WHERE rule_id = ? AND url_id = ?", ruleID, urlID).Scan(&current)
+	if err == sql.ErrNoRows {
+		res, err := db.Exec(
+			"INSERT IGNORE INTO alert_states (rule_id, url_id, firing, changed_at) VALUES (?, ?, ?, ?)",
+			ruleID, urlID, firing, now,
+		)
+		if err != nil {
+			return false, err
+		}
+		inserted, _ := res.RowsAffected()
+		return inserted == 1 && firing, nil
+	} else if err != nil {
+		return false, err
+	}
+	if current == firing {
+		return false, nil
+	}
+
+	res, err := db.Exec(
+		"UPDATE alert_states SET firing = ?, changed_at = ? WHERE rule_id = ? AND url_id = ? AND firing = ?",
+		firing, now, ruleID, urlID, current,
+	)
+	if err != nil {
+		return false, err
+	}
+	updated, _ := res.RowsAffected()
+	return updated == 1, nil
+}
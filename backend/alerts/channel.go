@@ -0,0 +1,240 @@
+package alerts
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net"
+	"net/http"
+	"net/mail"
+	"net/smtp"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"sykell-analyze/backend/config"
+	"sykell-analyze/backend/dnscache"
+	"sykell-analyze/backend/version"
+)
+
+// Channel types
+const (
+	ChannelEmail   = "email"
+	ChannelWebhook = "webhook"
+	ChannelSlack   = "slack"
+)
+
+// Channel is where a rule's notifications are delivered: an email address, a webhook URL receiving
+// the Notification as JSON, or a Slack incoming webhook URL
+type Channel struct {
+	Type   string `json:"type"`
+	Target string `json:"target"`
+	// Secret signs webhook deliveries; channels stored before signing was added have none and are
+	// delivered unsigned
+	Secret string `json:"secret,omitempty"`
+}
+
+// settings are the delivery settings given to Configure
+var settings = config.Default().Alerts
+
+// Configure applies the delivery settings
+func Configure(cfg config.AlertsConfig) {
+	settings = cfg
+	if cfg.SMTPHost != "" {
+		fmt.Println("✅ Email alerts enabled.")
+	}
+}
+
+// Normalize validates the channel and returns it in canonical form, with email targets reduced to
+// the bare address. Email channels also need an SMTP server to be configured. Webhook targets must
+// be allowed crawl targets, so they cannot reach private networks or cloud metadata addresses, and
+// webhook channels without a secret get a random one.
+func (ch Channel) Normalize() (Channel, error) {
+	ch.Type = strings.ToLower(strings.TrimSpace(ch.Type))
+	ch.Target = strings.TrimSpace(ch.Target)
+	switch ch.Type {
+	case ChannelEmail:
+		if settings.SMTPHost == "" {
+			return ch, errors.New("email alerts are not configured on this server")
+		}
+		address, err := mail.ParseAddress(ch.Target)
+		if err != nil {
+			return ch, fmt.Errorf("invalid email address %q", ch.Target)
+		}
+		ch.Target = address.Address
+	case ChannelWebhook:
+		u, err := url.Parse(ch.Target)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return ch, fmt.Errorf("webhook target must be an http(s) URL, got %q", ch.Target)
+		}
+		if err := config.App.Crawler.CheckTarget(ch.Target); err != nil {
+			return ch, fmt.Errorf("webhook target is not allowed: %w", err)
+		}
+		if ch.Secret == "" {
+			secret, err := newSecret()
+			if err != nil {
+				return ch, err
+			}
+			ch.Secret = secret
+		}
+	case ChannelSlack:
+		u, err := url.Parse(ch.Target)
+		if err != nil || u.Scheme != "https" || u.Host != "hooks.slack.com" {
+			return ch, fmt.Errorf("slack target must be an incoming webhook URL on https://hooks.slack.com, got %q", ch.Target)
+		}
+	default:
+		return ch, fmt.Errorf("unknown channel type %q; use email, webhook or slack", ch.Type)
+	}
+	return ch, nil
+}
+
+// Notification is an alert that started or stopped firing; webhooks receive it as JSON, signed with
+// the channel's secret
+type Notification struct {
+	// Event is firing or resolved
+	Event     string    `json:"event"`
+	RuleID    int       `json:"rule_id"`
+	RuleName  string    `json:"rule_name"`
+	Condition string    `json:"condition"`
+	UrlID     int       `json:"url_id"`
+	Url       string    `json:"url"`
+	Value     string    `json:"value"`
+	Message   string    `json:"message"`
+	At        time.Time `json:"at"`
+}
+
+// deliver sends the notification through one channel
+func deliver(ctx context.Context, ch Channel, n Notification) error {
+	ctx, cancel := context.WithTimeout(ctx, settings.Timeout)
+	defer cancel()
+
+	switch ch.Type {
+	case ChannelEmail:
+		return sendEmail(ctx, ch.Target, emailMessage(settings.From, ch.Target, n))
+	case ChannelWebhook:
+		return postJSON(ctx, ch.Target, ch.Secret, n)
+	case ChannelSlack:
+		return postJSON(ctx, ch.Target, "", map[string]string{"text": n.Message})
+	}
+	return fmt.Errorf("unknown channel type %q", ch.Type)
+}
+
+// postJSON posts body as JSON, signed when secret is set, and treats any non-2xx answer as a failure.
+// It goes through the crawler's guarded client, so a target that resolves or redirects to a private
+// address is refused like a crawl would be.
+func postJSON(ctx context.Context, target, secret string, body interface{}) error {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "sykell-analyze/"+version.Version)
+	if secret != "" {
+		if err := sign(req.Header, secret, payload, time.Now()); err != nil {
+			return err
+		}
+	}
+
+	res, err := config.App.Crawler.HTTPClient(dnscache.Default()).Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return fmt.Errorf("%s answered %s", target, res.Status)
+	}
+	return nil
+}
+
+// SendEmail sends a plain text email through the configured SMTP server, for other notifications
+// such as digests. It fails when no SMTP server is configured.
+func SendEmail(ctx context.Context, to, subject, body string) error {
+	if settings.SMTPHost == "" {
+		return errors.New("email is not configured on this server")
+	}
+	ctx, cancel := context.WithTimeout(ctx, settings.Timeout)
+	defer cancel()
+	return sendEmail(ctx, to, plainMessage(settings.From, to, subject, body, time.Now()))
+}
+
+// sendEmail delivers a message over SMTP, upgrading to TLS when the server offers it
+func sendEmail(ctx context.Context, to string, msg []byte) error {
+	addr := net.JoinHostPort(settings.SMTPHost, strconv.Itoa(settings.SMTPPort))
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	client, err := smtp.NewClient(conn, settings.SMTPHost)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(nil); err != nil {
+			return err
+		}
+	}
+	if settings.SMTPUsername != "" {
+		if err := client.Auth(smtp.PlainAuth("", settings.SMTPUsername, settings.SMTPPassword, settings.SMTPHost)); err != nil {
+			return err
+		}
+	}
+	if err := client.Mail(settings.From); err != nil {
+		return err
+	}
+	if err := client.Rcpt(to); err != nil {
+		return err
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+// emailMessage formats the notification as a plain text email
+func emailMessage(from, to string, n Notification) []byte {
+	subject := "[Alert] " + n.RuleName
+	if n.Event == EventResolved {
+		subject = "[Resolved] " + n.RuleName
+	}
+	body := fmt.Sprintf("%s\n\nRule: %s (%s)\nURL: %s\nValue: %s\n", n.Message, n.RuleName, n.Condition, n.Url, n.Value)
+	return plainMessage(from, to, subject, body, n.At)
+}
+
+// plainMessage formats a plain text email, with the body's line endings converted to CRLF
+func plainMessage(from, to, subject, body string, at time.Time) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", from)
+	fmt.Fprintf(&b, "To: %s\r\n", to)
+	fmt.Fprintf(&b, "Subject: %s\r\n", mimeHeader(subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", at.Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\nContent-Type: text/plain; charset=utf-8\r\n\r\n")
+	b.WriteString(strings.ReplaceAll(strings.ReplaceAll(body, "\r\n", "\n"), "\n", "\r\n"))
+	return []byte(b.String())
+}
+
+// mimeHeader encodes non-ASCII header values and drops line breaks that would start a new header
+func mimeHeader(value string) string {
+	return mime.QEncoding.Encode("utf-8", strings.NewReplacer("\r", " ", "\n", " ").Replace(value))
+}
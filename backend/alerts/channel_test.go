@@ -0,0 +1,153 @@
+package alerts
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"sykell-analyze/backend/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChannelNormalize(t *testing.T) {
+	t.Run("webhook", func(t *testing.T) {
+		ch, err := Channel{Type: " Webhook ", Target: "https://example.com/hook"}.Normalize()
+		require.NoError(t, err)
+		assert.Equal(t, ChannelWebhook, ch.Type)
+		assert.Equal(t, "https://example.com/hook", ch.Target)
+		assert.Len(t, ch.Secret, 64, "a secret is generated")
+
+		ch, err = Channel{Type: "webhook", Target: "https://example.com/hook", Secret: "shared-secret"}.Normalize()
+		require.NoError(t, err)
+		assert.Equal(t, "shared-secret", ch.Secret)
+
+		_, err = Channel{Type: "webhook", Target: "ftp://example.com"}.Normalize()
+		assert.Error(t, err)
+	})
+
+	t.Run("webhook cannot target private networks", func(t *testing.T) {
+		for _, target := range []string{"http://127.0.0.1:8080/hook", "http://169.254.169.254/latest/meta-data", "http://[::1]/hook", "https://10.0.0.5/hook"} {
+			_, err := Channel{Type: "webhook", Target: target}.Normalize()
+			if assert.Error(t, err, target) {
+				assert.Contains(t, err.Error(), "webhook target is not allowed")
+			}
+		}
+	})
+
+	t.Run("slack needs an incoming webhook", func(t *testing.T) {
+		_, err := Channel{Type: "slack", Target: "https://hooks.slack.com/services/T0/B0/x"}.Normalize()
+		assert.NoError(t, err)
+
+		_, err = Channel{Type: "slack", Target: "https://example.com/services/T0"}.Normalize()
+		assert.Error(t, err)
+	})
+
+	t.Run("email needs an SMTP server", func(t *testing.T) {
+		saved := settings
+		defer func() { settings = saved }()
+
+		settings.SMTPHost = ""
+		_, err := Channel{Type: "email", Target: "ops@example.com"}.Normalize()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "not configured")
+
+		settings.SMTPHost = "smtp.example.com"
+		ch, err := Channel{Type: "email", Target: "Ops Team <ops@example.com>"}.Normalize()
+		require.NoError(t, err)
+		assert.Equal(t, "ops@example.com", ch.Target)
+
+		_, err = Channel{Type: "email", Target: "not an address"}.Normalize()
+		assert.Error(t, err)
+	})
+
+	t.Run("unknown type", func(t *testing.T) {
+		_, err := Channel{Type: "sms", Target: "+4912345"}.Normalize()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "unknown channel type")
+	})
+}
+
+// allowPrivateNetworks lets deliveries reach the local test servers
+func allowPrivateNetworks(t *testing.T) {
+	original := config.App
+	t.Cleanup(func() { config.App = original })
+
+	cfg := *config.App
+	cfg.Crawler.AllowPrivateNetworks = true
+	config.App = &cfg
+}
+
+func TestDeliver(t *testing.T) {
+	n := Notification{
+		Event:     EventFiring,
+		RuleID:    7,
+		RuleName:  "Broken links",
+		Condition: "broken_links > 5",
+		UrlID:     3,
+		Url:       "https://example.com",
+		Value:     "8",
+		Message:   "Broken links: broken_links is 8 on https://example.com (broken_links > 5)",
+		At:        time.Now(),
+	}
+
+	t.Run("private addresses are refused", func(t *testing.T) {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			t.Error("the webhook was delivered to a private address")
+		}))
+		defer srv.Close()
+
+		assert.Error(t, deliver(context.Background(), Channel{Type: ChannelWebhook, Target: srv.URL}, n))
+	})
+
+	allowPrivateNetworks(t)
+
+	t.Run("webhook receives the notification", func(t *testing.T) {
+		var got Notification
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+			json.NewDecoder(r.Body).Decode(&got)
+		}))
+		defer srv.Close()
+
+		require.NoError(t, deliver(context.Background(), Channel{Type: ChannelWebhook, Target: srv.URL}, n))
+		assert.Equal(t, EventFiring, got.Event)
+		assert.Equal(t, 7, got.RuleID)
+		assert.Equal(t, "8", got.Value)
+	})
+
+	t.Run("slack receives the message as text", func(t *testing.T) {
+		var got map[string]string
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			json.NewDecoder(r.Body).Decode(&got)
+		}))
+		defer srv.Close()
+
+		require.NoError(t, deliver(context.Background(), Channel{Type: ChannelSlack, Target: srv.URL}, n))
+		assert.Equal(t, map[string]string{"text": n.Message}, got)
+	})
+
+	t.Run("error status fails delivery", func(t *testing.T) {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusGone)
+		}))
+		defer srv.Close()
+
+		err := deliver(context.Background(), Channel{Type: ChannelWebhook, Target: srv.URL}, n)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "410")
+	})
+
+	t.Run("email message", func(t *testing.T) {
+		resolved := n
+		resolved.Event = EventResolved
+		msg := string(emailMessage("alerts@example.com", "ops@example.com", resolved))
+		assert.Contains(t, msg, "Subject: [Resolved] Broken links\r\n")
+		assert.Contains(t, msg, "To: ops@example.com\r\n")
+		assert.Contains(t, msg, "URL: https://example.com\r\n")
+	})
+}
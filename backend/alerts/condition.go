@@ -0,0 +1,175 @@
+package alerts
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Sources of the facts a condition is evaluated against
+const (
+	SourceCrawl   = "crawl"   // evaluated after every crawl
+	SourceMonitor = "monitor" // evaluated after every uptime ping
+)
+
+// metricKind decides which values and operators a metric accepts
+type metricKind int
+
+const (
+	kindNumber metricKind = iota
+	kindDuration
+	kindString
+	kindBool
+)
+
+// metric describes a value conditions can test
+type metric struct {
+	kind   metricKind
+	source string
+	// values lists the accepted values of string metrics
+	values []string
+}
+
+// metrics are the values conditions can test, by name
+var metrics = map[string]metric{
+	"status":         {kind: kindString, source: SourceCrawl, values: []string{"completed", "error"}},
+	"broken_links":   {kind: kindNumber, source: SourceCrawl},
+	"internal_links": {kind: kindNumber, source: SourceCrawl},
+	"external_links": {kind: kindNumber, source: SourceCrawl},
+	"h1_count":       {kind: kindNumber, source: SourceCrawl},
+	"content_change": {kind: kindNumber, source: SourceCrawl},
+	"up":             {kind: kindBool, source: SourceMonitor},
+	"status_code":    {kind: kindNumber, source: SourceMonitor},
+	"response_time":  {kind: kindDuration, source: SourceMonitor},
+}
+
+// MetricNames lists the metrics conditions can test, sorted
+func MetricNames() []string {
+	names := make([]string, 0, len(metrics))
+	for name := range metrics {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// conditionPattern is "<metric> <operator> <value>", spaces optional
+var conditionPattern = regexp.MustCompile(`^\s*([a-z_0-9]+)\s*(>=|<=|==|!=|>|<)\s*(.+?)\s*$`)
+
+// Condition is a parsed alert condition such as broken_links > 5
+type Condition struct {
+	Metric   string
+	Operator string
+	// number holds numeric values and durations in seconds; text holds string and bool values
+	number float64
+	text   string
+}
+
+// ParseCondition parses expressions such as "broken_links > 5", "status == error" and "response_time > 3s"
+func ParseCondition(expr string) (Condition, error) {
+	match := conditionPattern.FindStringSubmatch(expr)
+	if match == nil {
+		return Condition{}, errors.New(`condition must look like "<metric> <operator> <value>", e.g. broken_links > 5`)
+	}
+	c := Condition{Metric: match[1], Operator: match[2]}
+	m, ok := metrics[c.Metric]
+	if !ok {
+		return Condition{}, fmt.Errorf("unknown metric %q; use one of %s", c.Metric, strings.Join(MetricNames(), ", "))
+	}
+	value := strings.Trim(match[3], `"'`)
+
+	switch m.kind {
+	case kindNumber:
+		n, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return Condition{}, fmt.Errorf("%s needs a number, got %q", c.Metric, value)
+		}
+		c.number = n
+	case kindDuration:
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return Condition{}, fmt.Errorf("%s needs a duration with a unit such as 3s or 500ms, got %q", c.Metric, value)
+		}
+		c.number = d.Seconds()
+	case kindString, kindBool:
+		if c.Operator != "==" && c.Operator != "!=" {
+			return Condition{}, fmt.Errorf("%s can only be compared with == or !=", c.Metric)
+		}
+		allowed := m.values
+		if m.kind == kindBool {
+			allowed = []string{"true", "false"}
+		}
+		value = strings.ToLower(value)
+		if !slices.Contains(allowed, value) {
+			return Condition{}, fmt.Errorf("%s must be one of %s", c.Metric, strings.Join(allowed, ", "))
+		}
+		c.text = value
+	}
+	return c, nil
+}
+
+// Source is where the condition's metric comes from, SourceCrawl or SourceMonitor
+func (c Condition) Source() string {
+	return metrics[c.Metric].source
+}
+
+// String formats the condition the way ParseCondition reads it
+func (c Condition) String() string {
+	return fmt.Sprintf("%s %s %s", c.Metric, c.Operator, c.value())
+}
+
+// value formats the condition's operand
+func (c Condition) value() string {
+	switch metrics[c.Metric].kind {
+	case kindDuration:
+		return time.Duration(c.number * float64(time.Second)).String()
+	case kindNumber:
+		return strconv.FormatFloat(c.number, 'f', -1, 64)
+	}
+	return c.text
+}
+
+// Facts are the current values of the metrics of one source. Numbers are float64 or int, durations are
+// time.Duration, strings are string and booleans are bool.
+type Facts map[string]interface{}
+
+// Matches reports whether facts satisfy the condition. ok is false when facts lack the metric.
+func (c Condition) Matches(facts Facts) (matched, ok bool) {
+	switch value := facts[c.Metric].(type) {
+	case float64:
+		return compare(value, c.Operator, c.number), true
+	case int:
+		return compare(float64(value), c.Operator, c.number), true
+	case time.Duration:
+		return compare(value.Seconds(), c.Operator, c.number), true
+	case string:
+		return (value == c.text) == (c.Operator == "=="), true
+	case bool:
+		return (strconv.FormatBool(value) == c.text) == (c.Operator == "=="), true
+	}
+	return false, false
+}
+
+// compare applies a numeric operator
+func compare(a float64, operator string, b float64) bool {
+	switch operator {
+	case ">":
+		return a > b
+	case ">=":
+		return a >= b
+	case "<":
+		return a < b
+	case "<=":
+		return a <= b
+	case "==":
+		return a == b
+	case "!=":
+		return a != b
+	}
+	return false
+}
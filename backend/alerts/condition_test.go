@@ -0,0 +1,100 @@
+package alerts
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCondition(t *testing.T) {
+	t.Run("numeric metric", func(t *testing.T) {
+		c, err := ParseCondition("broken_links > 5")
+		require.NoError(t, err)
+		assert.Equal(t, "broken_links", c.Metric)
+		assert.Equal(t, ">", c.Operator)
+		assert.Equal(t, SourceCrawl, c.Source())
+		assert.Equal(t, "broken_links > 5", c.String())
+	})
+
+	t.Run("spaces are optional", func(t *testing.T) {
+		c, err := ParseCondition("h1_count!=1")
+		require.NoError(t, err)
+		assert.Equal(t, "h1_count != 1", c.String())
+	})
+
+	t.Run("duration metric", func(t *testing.T) {
+		c, err := ParseCondition("response_time > 3s")
+		require.NoError(t, err)
+		assert.Equal(t, SourceMonitor, c.Source())
+		assert.Equal(t, "response_time > 3s", c.String())
+	})
+
+	t.Run("string metric is case insensitive and may be quoted", func(t *testing.T) {
+		c, err := ParseCondition(`status == "Error"`)
+		require.NoError(t, err)
+		assert.Equal(t, "status == error", c.String())
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		for expr, want := range map[string]string{
+			"broken links":        "must look like",
+			"pagerank > 5":        "unknown metric",
+			"broken_links > many": "needs a number",
+			"response_time > 3":   "needs a duration",
+			"status > error":      "only be compared",
+			"status == pending":   "must be one of",
+			"up == maybe":         "must be one of",
+			"broken_links => 5":   "must look like",
+		} {
+			_, err := ParseCondition(expr)
+			require.Error(t, err, expr)
+			assert.Contains(t, err.Error(), want, expr)
+		}
+	})
+}
+
+func TestConditionMatches(t *testing.T) {
+	match := func(expr string, facts Facts) (bool, bool) {
+		c, err := ParseCondition(expr)
+		require.NoError(t, err)
+		return c.Matches(facts)
+	}
+
+	t.Run("numbers", func(t *testing.T) {
+		matched, ok := match("broken_links > 5", Facts{"broken_links": 8})
+		assert.True(t, ok)
+		assert.True(t, matched)
+
+		matched, _ = match("broken_links > 5", Facts{"broken_links": 5})
+		assert.False(t, matched)
+
+		matched, _ = match("broken_links >= 5", Facts{"broken_links": 5})
+		assert.True(t, matched)
+	})
+
+	t.Run("durations", func(t *testing.T) {
+		matched, _ := match("response_time > 3s", Facts{"response_time": 3500 * time.Millisecond})
+		assert.True(t, matched)
+
+		matched, _ = match("response_time > 3s", Facts{"response_time": 800 * time.Millisecond})
+		assert.False(t, matched)
+	})
+
+	t.Run("strings and booleans", func(t *testing.T) {
+		matched, _ := match("status == error", Facts{"status": "error"})
+		assert.True(t, matched)
+
+		matched, _ = match("status != error", Facts{"status": "error"})
+		assert.False(t, matched)
+
+		matched, _ = match("up == false", Facts{"up": false})
+		assert.True(t, matched)
+	})
+
+	t.Run("missing fact", func(t *testing.T) {
+		_, ok := match("broken_links > 5", Facts{"status": "error"})
+		assert.False(t, ok)
+	})
+}
@@ -0,0 +1,73 @@
+package alerts
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerifySignature(t *testing.T) {
+	body := []byte(`{"event":"firing"}`)
+	now := time.Now()
+	header := http.Header{}
+	require.NoError(t, sign(header, "secret", body, now))
+
+	t.Run("accepts a fresh signed delivery", func(t *testing.T) {
+		assert.NoError(t, VerifySignature("secret", header, body, now.Add(time.Minute)))
+	})
+
+	t.Run("rejects another secret or body", func(t *testing.T) {
+		assert.Error(t, VerifySignature("other", header, body, now))
+		assert.Error(t, VerifySignature("secret", header, []byte(`{"event":"resolved"}`), now))
+	})
+
+	t.Run("rejects a changed timestamp or nonce", func(t *testing.T) {
+		tampered := header.Clone()
+		tampered.Set(HeaderTimestamp, "1")
+		assert.Error(t, VerifySignature("secret", tampered, body, now))
+
+		tampered = header.Clone()
+		tampered.Set(HeaderNonce, "0000")
+		assert.Error(t, VerifySignature("secret", tampered, body, now))
+	})
+
+	t.Run("rejects old deliveries", func(t *testing.T) {
+		err := VerifySignature("secret", header, body, now.Add(SignatureTolerance+time.Second))
+		assert.ErrorContains(t, err, "tolerance")
+	})
+
+	t.Run("rejects unsigned deliveries", func(t *testing.T) {
+		assert.ErrorContains(t, VerifySignature("secret", http.Header{}, body, now), "not signed")
+	})
+
+	t.Run("every delivery has its own nonce", func(t *testing.T) {
+		again := http.Header{}
+		require.NoError(t, sign(again, "secret", body, now))
+		assert.NotEqual(t, header.Get(HeaderNonce), again.Get(HeaderNonce))
+	})
+}
+
+func TestDeliverSigned(t *testing.T) {
+	allowPrivateNetworks(t)
+	var header http.Header
+	var body []byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header
+		body, _ = io.ReadAll(r.Body)
+	}))
+	defer srv.Close()
+
+	n := Notification{Event: EventFiring, RuleID: 7, At: time.Now()}
+
+	require.NoError(t, deliver(context.Background(), Channel{Type: ChannelWebhook, Target: srv.URL, Secret: "secret"}, n))
+	assert.NoError(t, VerifySignature("secret", header, body, time.Now()))
+
+	require.NoError(t, deliver(context.Background(), Channel{Type: ChannelWebhook, Target: srv.URL}, n))
+	assert.Empty(t, header.Get(HeaderSignature), "channels without a secret are delivered unsigned")
+}
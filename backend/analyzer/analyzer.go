@@ -0,0 +1,495 @@
+package analyzer
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// Analyzer analyzes web pages with a fixed set of options
+type Analyzer struct {
+	opts Options
+}
+
+// New creates an Analyzer; options are applied in order over the defaults
+func New(opts ...Option) *Analyzer {
+	var o Options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return &Analyzer{opts: o.withDefaults()}
+}
+
+// Options returns the effective settings, defaults included
+func (a *Analyzer) Options() Options {
+	return a.opts
+}
+
+// Analyze fetches target and analyzes it with the given options
+func Analyze(ctx context.Context, target string, opts ...Option) (*Result, error) {
+	return New(opts...).Analyze(ctx, target)
+}
+
+// Analyze downloads and analyses a web page. Cancelling ctx stops the fetch and any link checks.
+func (a *Analyzer) Analyze(ctx context.Context, target string) (*Result, error) {
+	opts := a.opts
+	startedAt := time.Now()
+	tracker := newProgressTracker(opts.Progress)
+	tracker.update(func(p *Progress) { p.Stage = StageFetching })
+
+	// Bound the entire operation, link checks included
+	ctx, cancel := context.WithTimeout(ctx, opts.PageTimeout)
+	defer cancel()
+
+	base, err := url.Parse(target)
+	if err != nil {
+		return nil, &Error{Kind: ErrorInvalidURL, URL: target, Err: err, message: fmt.Sprintf("failed to parse base URL: %v", err)}
+	}
+	if opts.AllowTarget != nil {
+		if err := opts.AllowTarget(base); err != nil {
+			return nil, notAllowedError(target, err)
+		}
+	}
+
+	// Create request with proper User-Agent header
+	req, err := http.NewRequestWithContext(ctx, "GET", target, nil)
+	if err != nil {
+		return nil, &Error{Kind: ErrorInvalidURL, URL: target, Err: err, message: fmt.Sprintf("failed to create request: %v", err)}
+	}
+
+	// Set User-Agent to appear as a regular browser
+	req.Header.Set("User-Agent", opts.UserAgent)
+	req.Header.Set("Accept", "text/html,application/xhtml+xml,application/xml;q=0.9,image/webp,*/*;q=0.8")
+	req.Header.Set("Accept-Language", "en-US,en;q=0.5")
+	req.Header.Set("Accept-Encoding", "gzip, deflate")
+	req.Header.Set("Connection", "keep-alive")
+	req.Header.Set("Upgrade-Insecure-Requests", "1")
+
+	// Remember which server answered; across redirects the last connection wins
+	var remoteAddr net.Addr
+	req = req.WithContext(httptrace.WithClientTrace(ctx, &httptrace.ClientTrace{
+		GotConn: func(info httptrace.GotConnInfo) { remoteAddr = info.Conn.RemoteAddr() },
+	}))
+
+	res, err := a.pageClient(opts.RequestTimeout).Do(req)
+	if err != nil {
+		var denied *deniedRedirect
+		if errors.As(err, &denied) {
+			return nil, notAllowedError(target, denied.err)
+		}
+		// Provide more informative error messages
+		return nil, fetchError(ctx, target, opts.RequestTimeout, err)
+	}
+	defer res.Body.Close()
+
+	// Check if the response is successful
+	if res.StatusCode >= 400 {
+		return nil, &Error{
+			Kind:       ErrorHTTPStatus,
+			URL:        target,
+			StatusCode: res.StatusCode,
+			message:    fmt.Sprintf("website error: %s returned %d %s", target, res.StatusCode, res.Status),
+		}
+	}
+
+	// Handle GZIP decompression manually, counting the bytes transferred
+	body := &countingReader{r: res.Body}
+	var reader io.Reader = body
+	if res.Header.Get("Content-Encoding") == "gzip" {
+		gzipReader, err := gzip.NewReader(body)
+		if err != nil {
+			return nil, &Error{Kind: ErrorParse, URL: target, Err: err, message: fmt.Sprintf("failed to create gzip reader: %v", err)}
+		}
+		defer gzipReader.Close()
+		reader = gzipReader
+	}
+
+	// Pages above Options.StreamAbove are tokenized as they arrive rather than parsed into a DOM
+	var streamed io.Reader
+	if opts.StreamAbove > 0 {
+		head, err := io.ReadAll(io.LimitReader(reader, opts.StreamAbove+1))
+		if err != nil {
+			return nil, parseError(target, err)
+		}
+		if int64(len(head)) > opts.StreamAbove {
+			streamed = io.MultiReader(bytes.NewReader(head), reader)
+		} else {
+			reader = bytes.NewReader(head)
+		}
+	}
+
+	var doc *goquery.Document
+	if streamed == nil {
+		if doc, err = goquery.NewDocumentFromReader(reader); err != nil {
+			return nil, parseError(target, err)
+		}
+	}
+
+	tracker.update(func(p *Progress) { p.Stage = StageParsing })
+
+	result := &Result{
+		URL:         target,
+		FinalURL:    res.Request.URL.String(),
+		StatusCode:  res.StatusCode,
+		HTMLVersion: "HTML5", // default
+		FetchedAt:   startedAt,
+		Streamed:    doc == nil,
+	}
+
+	// Title, headings and links, collecting the links for broken link checking
+	var page streamedPage
+	scope := newLinkScope(base, opts)
+	if doc == nil {
+		if page, err = streamPage(streamed, base, scope, opts, result); err != nil {
+			return nil, parseError(target, err)
+		}
+	} else {
+		result.Title = strings.TrimSpace(doc.Find("title").First().Text())
+		result.Headings = Headings{
+			H1: doc.Find("h1").Length(),
+			H2: doc.Find("h2").Length(),
+			H3: doc.Find("h3").Length(),
+		}
+		page.linksToCheck = collectLinks(doc, base, scope, opts, result)
+	}
+	linksToCheck := page.linksToCheck
+
+	// Look the page and its external links up in threat lists before link checks use up the time budget
+	if opts.ThreatChecker != nil {
+		result.Safety = checkSafety(ctx, opts.ThreatChecker, result, base, linksToCheck)
+	}
+
+	// DNS records of the host the page ended up on
+	result.DNS = inspectDNS(ctx, opts.Resolver, res.Request.URL.Hostname())
+
+	// Negotiated HTTP version and advertised alternatives such as HTTP/3
+	result.Performance = detectProtocols(res)
+
+	// Compression and caching headers of the page and its stylesheets and scripts, and its embeds;
+	// a streamed page only has its own
+	if doc == nil {
+		result.Performance.Resources = []ResourceAudit{auditResponse(res.Request.URL.String(), ResourceHTML, res, body.n)}
+		result.Performance.Findings = resourceFindings(result.Performance.Resources, res.Request.URL)
+	} else {
+		result.Performance.Resources = a.auditResources(ctx, doc, res, body.n)
+		result.Performance.Findings = resourceFindings(result.Performance.Resources, res.Request.URL)
+		embeds, embedFindings := auditEmbeds(doc, res.Request.URL)
+		result.Performance.Embeds = embeds
+		result.Performance.Findings = append(result.Performance.Findings, embedFindings...)
+	}
+	for _, audit := range result.Performance.Resources {
+		result.BytesDownloaded += audit.Bytes
+	}
+
+	// Server address and the network hosting it
+	result.Hosting = locateServer(opts.IPLocator, remoteAddr)
+
+	// Registrar and expiry date of the domain
+	if opts.Registry != nil {
+		result.Registration = lookupRegistration(ctx, opts.Registry, res.Request.URL.Hostname(), time.Now())
+	}
+
+	// Real-user Core Web Vitals of the origin the page ended up on
+	if opts.FieldData != nil {
+		result.WebVitals = fetchWebVitals(ctx, opts.FieldData, res.Request.URL)
+	}
+
+	// Check broken links with proper concurrency control
+	if opts.SkipLinkChecks {
+		linksToCheck = nil
+	}
+	tracker.update(func(p *Progress) {
+		p.Stage = StageCheckingLinks
+		p.LinksDiscovered = len(linksToCheck)
+	})
+	result.BrokenLinks = a.checkBrokenLinks(ctx, linksToCheck, &result.Links, tracker)
+
+	// Images, scripts and linked files, checked like links when asked for
+	if opts.CheckAssets && doc != nil {
+		result.BrokenAssets = a.checkAssets(ctx, doc, res.Request.URL)
+	}
+
+	// Linked PDFs and office documents, checked when asked for
+	if doc != nil {
+		result.Documents = a.auditDocuments(ctx, doc, base)
+	}
+
+	// Working alternatives of the broken links
+	a.suggestReplacements(ctx, result.BrokenLinks)
+	tracker.update(func(p *Progress) { p.Stage = StageDone })
+
+	// The login form and text of a streamed page were gathered while tokenizing; the audits below
+	// need the DOM
+	if doc == nil {
+		result.Robots.Noindex, result.Robots.Nofollow = parseRobots(page.robotsMetas, res.Header)
+		result.Duration = time.Since(startedAt)
+		return result, nil
+	}
+
+	// Check for login form
+	result.HasLoginForm = doc.Find(`form input[type="password"]`).Length() > 0
+
+	// Every form with its fields, anti-CSRF token and where it submits to
+	result.Forms = inventoryForms(doc, res.Request.URL)
+
+	// Robots directives from meta tags and the X-Robots-Tag header
+	result.Robots.Noindex, result.Robots.Nofollow = robotsDirectives(doc, res.Header)
+
+	// Language annotations; return links are checked across pages by CheckHreflangReturnLinks
+	result.Hreflang = auditHreflang(doc, res.Request.URL)
+
+	// Script, data and redirector links and links to suspicious domains
+	result.LinkHygiene = auditLinkHygiene(doc, base)
+
+	// Links to missing elements of the page; links to other pages are checked by CheckFragmentLinks
+	result.Fragments, result.anchors = auditFragments(doc, res.Request.URL)
+
+	// Syntax of mailto and tel links
+	result.ContactLinks = auditContactLinks(doc)
+
+	// Twitter/X, LinkedIn, Facebook, Instagram and GitHub accounts
+	result.SocialProfiles = extractSocialProfiles(doc, base)
+
+	// Cookies set by the response and known trackers
+	result.Privacy = auditPrivacy(doc, res)
+
+	// Cookie consent banners and consent management platforms
+	result.Consent = detectConsent(doc)
+
+	// User-defined selector rules
+	result.Rules = evaluateRules(doc, opts.Rules)
+
+	// Target keyword occurrences and density
+	result.Keywords = analyzeKeywords(doc, opts.Keywords)
+
+	// Text snapshot for change detection
+	result.Content = normalizedText(doc)
+
+	result.Duration = time.Since(startedAt)
+	return result, nil
+}
+
+// client returns the configured client with the given timeout
+func (a *Analyzer) client(timeout time.Duration) *http.Client {
+	client := *a.opts.HTTPClient
+	client.Timeout = timeout
+	return &client
+}
+
+// deniedRedirect stops a page fetch redirected to a target Options.AllowTarget rejects
+type deniedRedirect struct {
+	err error
+}
+
+func (d *deniedRedirect) Error() string {
+	return d.err.Error()
+}
+
+// pageClient returns the client fetching the page itself, which checks every redirect against
+// Options.AllowTarget
+func (a *Analyzer) pageClient(timeout time.Duration) *http.Client {
+	client := a.client(timeout)
+	if a.opts.AllowTarget == nil {
+		return client
+	}
+	next := client.CheckRedirect
+	client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if err := a.opts.AllowTarget(req.URL); err != nil {
+			return &deniedRedirect{err: err}
+		}
+		if next != nil {
+			return next(req, via)
+		}
+		if len(via) >= 10 {
+			return errors.New("stopped after 10 redirects")
+		}
+		return nil
+	}
+	return client
+}
+
+// parseError reports a page that could not be read or parsed
+func parseError(target string, err error) *Error {
+	return &Error{Kind: ErrorParse, URL: target, Err: err, message: fmt.Sprintf("parsing error: failed to parse HTML from %s: %v", target, err)}
+}
+
+// notAllowedError reports a page Options.AllowTarget rejected
+func notAllowedError(target string, err error) *Error {
+	return &Error{Kind: ErrorNotAllowed, URL: target, Err: err, message: fmt.Sprintf("crawl target not allowed: %v", err)}
+}
+
+// collectLinks counts the page's http(s) links into result and returns them for checking, each
+// canonical link once. InternalPages only lists pages on the page's own host, whatever the scope.
+func collectLinks(doc *goquery.Document, base *url.URL, scope linkScope, opts Options, result *Result) []string {
+	links := newLinkCollector(base, scope, opts, result)
+	doc.Find("a[href]").Each(func(_ int, s *goquery.Selection) {
+		href, _ := s.Attr("href")
+		links.add(href, s.AttrOr("rel", ""))
+	})
+	return links.toCheck
+}
+
+// linkCollector counts links one at a time, for both the parsed and the streamed page
+type linkCollector struct {
+	base   *url.URL
+	scope  linkScope
+	opts   Options
+	result *Result
+
+	seenPages map[string]bool
+	seenLinks map[string]bool
+	toCheck   []string
+}
+
+func newLinkCollector(base *url.URL, scope linkScope, opts Options, result *Result) *linkCollector {
+	return &linkCollector{
+		base:      base,
+		scope:     scope,
+		opts:      opts,
+		result:    result,
+		seenPages: make(map[string]bool),
+		seenLinks: make(map[string]bool),
+	}
+}
+
+// add counts the link of an <a> element with the given href and rel attributes
+func (c *linkCollector) add(href, relAttr string) {
+	if href == "" {
+		return
+	}
+	stats := &c.result.Links
+
+	// Resolve relative URLs
+	link, err := url.Parse(href)
+	if err != nil {
+		return
+	}
+
+	// Make absolute URL
+	absoluteURL := canonicalURL(c.base.ResolveReference(link), c.opts)
+
+	// Skip non-HTTP links; mailto and tel links are checked by auditContactLinks
+	if absoluteURL.Scheme != "http" && absoluteURL.Scheme != "https" {
+		return
+	}
+
+	// Classify as internal or external, tracking links search engines won't follow
+	rel := parseRel(relAttr)
+	notFollowed := rel["nofollow"] || rel["sponsored"] || rel["ugc"]
+	if rel["sponsored"] {
+		stats.Sponsored++
+	}
+	if rel["ugc"] {
+		stats.UGC++
+	}
+
+	if c.scope.isInternal(absoluteURL) {
+		stats.Internal++
+		if notFollowed {
+			stats.InternalNofollow++
+		}
+		if absoluteURL.Host == strings.ToLower(c.base.Host) {
+			if pageURL := absoluteURL.String(); !c.seenPages[pageURL] {
+				c.seenPages[pageURL] = true
+				c.result.InternalPages = append(c.result.InternalPages, pageURL)
+			}
+		}
+	} else {
+		stats.External++
+		if notFollowed {
+			stats.ExternalNofollow++
+		}
+	}
+
+	// Add to links to check for broken status
+	if linkURL := absoluteURL.String(); !c.seenLinks[linkURL] {
+		c.seenLinks[linkURL] = true
+		c.toCheck = append(c.toCheck, linkURL)
+	}
+}
+
+// parseRel splits a rel attribute into a set of lower-cased values
+func parseRel(rel string) map[string]bool {
+	values := make(map[string]bool)
+	for _, v := range strings.Fields(strings.ToLower(rel)) {
+		values[v] = true
+	}
+	return values
+}
+
+// robotsDirectives reports whether the page asks robots not to index it or follow its links
+func robotsDirectives(doc *goquery.Document, header http.Header) (noindex bool, nofollow bool) {
+	var metas []string
+	doc.Find("meta[name]").Each(func(_ int, s *goquery.Selection) {
+		if isRobotsMeta(s.AttrOr("name", "")) {
+			metas = append(metas, s.AttrOr("content", ""))
+		}
+	})
+	return parseRobots(metas, header)
+}
+
+// isRobotsMeta reports whether a meta element of this name holds directives robots honour
+func isRobotsMeta(name string) bool {
+	name = strings.ToLower(strings.TrimSpace(name))
+	return name == "robots" || name == "googlebot"
+}
+
+// parseRobots combines the content of robots meta elements and the X-Robots-Tag header
+func parseRobots(metas []string, header http.Header) (noindex bool, nofollow bool) {
+	apply := func(content string) {
+		for _, directive := range strings.Split(strings.ToLower(content), ",") {
+			switch strings.TrimSpace(directive) {
+			case "noindex":
+				noindex = true
+			case "nofollow":
+				nofollow = true
+			case "none":
+				noindex = true
+				nofollow = true
+			}
+		}
+	}
+
+	for _, content := range metas {
+		apply(content)
+	}
+
+	for _, value := range header.Values("X-Robots-Tag") {
+		// Values may be scoped to a bot ("otherbot: noindex"); only honour unscoped and Googlebot ones
+		if idx := strings.Index(value, ":"); idx >= 0 && !strings.Contains(value[:idx], ",") {
+			switch strings.ToLower(strings.TrimSpace(value[:idx])) {
+			case "googlebot":
+				value = value[idx+1:]
+			case "unavailable_after":
+			default:
+				continue
+			}
+		}
+		apply(value)
+	}
+
+	return noindex, nofollow
+}
+
+// countingReader counts the bytes read through it
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,705 @@
+package analyzer
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAnalyze(t *testing.T) {
+	// Create test server
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		html := `
+<!DOCTYPE html>
+<html>
+<head>
+    <title>Test Page</title>
+</head>
+<body>
+    <h1>Main Heading</h1>
+    <h2>Sub Heading 1</h2>
+    <h2>Sub Heading 2</h2>
+    <h3>Sub Sub Heading</h3>
+    
+    <form action="/login" method="post">
+        <input type="password" name="password">
+        <input type="submit" value="Login">
+    </form>
+    
+    <a href="/internal">Internal Link</a>
+    <a href="https://external.com">External Link</a>
+    <a href="/broken">Broken Link</a>
+</body>
+</html>`
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(html))
+	}))
+	defer server.Close()
+
+	t.Run("successful crawl", func(t *testing.T) {
+		result, err := Analyze(context.Background(), server.URL)
+
+		assert.NoError(t, err)
+		assert.NotNil(t, result)
+		assert.Equal(t, "Test Page", result.Title)
+		assert.Equal(t, 1, result.Headings.H1)
+		assert.Equal(t, 2, result.Headings.H2)
+		assert.Equal(t, 1, result.Headings.H3)
+		assert.True(t, result.HasLoginForm)
+		assert.Greater(t, result.Links.Internal, 0)
+		assert.Greater(t, result.Links.External, 0)
+	})
+
+	t.Run("invalid URL", func(t *testing.T) {
+		result, err := Analyze(context.Background(), "invalid-url")
+
+		assert.Error(t, err)
+		assert.Nil(t, result)
+	})
+
+	t.Run("unreachable URL", func(t *testing.T) {
+		result, err := Analyze(context.Background(), "http://unreachable-domain-12345.com")
+
+		assert.Error(t, err)
+		assert.Nil(t, result)
+	})
+}
+
+func TestAnalyzeWithTimeout(t *testing.T) {
+	// Create slow server
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(2 * time.Second)
+		w.Write([]byte("<html><body>Slow response</body></html>"))
+	}))
+	defer server.Close()
+
+	t.Run("timeout handling", func(t *testing.T) {
+		// This test would need to be adjusted based on the actual timeout implementation
+		// For now, we'll test that it doesn't hang indefinitely
+		start := time.Now()
+		result, err := Analyze(context.Background(), server.URL)
+		duration := time.Since(start)
+
+		// Should complete within reasonable time (including timeout)
+		assert.Less(t, duration, 2*time.Minute)
+
+		// Could be success or timeout error
+		if err != nil {
+			assert.Contains(t, err.Error(), "timeout")
+		} else {
+			assert.NotNil(t, result)
+		}
+	})
+}
+
+func TestHTMLParsing(t *testing.T) {
+	testCases := []struct {
+		name          string
+		html          string
+		expectedH1    int
+		expectedH2    int
+		expectedH3    int
+		expectedTitle string
+		hasLoginForm  bool
+	}{
+		{
+			name:          "basic HTML",
+			html:          `<html><head><title>Test</title></head><body><h1>Title</h1><h2>Subtitle</h2></body></html>`,
+			expectedH1:    1,
+			expectedH2:    1,
+			expectedH3:    0,
+			expectedTitle: "Test",
+			hasLoginForm:  false,
+		},
+		{
+			name:          "multiple headers",
+			html:          `<html><body><h1>H1-1</h1><h1>H1-2</h1><h2>H2-1</h2><h2>H2-2</h2><h3>H3-1</h3></body></html>`,
+			expectedH1:    2,
+			expectedH2:    2,
+			expectedH3:    1,
+			expectedTitle: "",
+			hasLoginForm:  false,
+		},
+		{
+			name:          "with login form",
+			html:          `<html><body><form><input type="password"><input type="submit"></form></body></html>`,
+			expectedH1:    0,
+			expectedH2:    0,
+			expectedH3:    0,
+			expectedTitle: "",
+			hasLoginForm:  true,
+		},
+		{
+			name:          "no headers",
+			html:          `<html><body><p>Just text</p></body></html>`,
+			expectedH1:    0,
+			expectedH2:    0,
+			expectedH3:    0,
+			expectedTitle: "",
+			hasLoginForm:  false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "text/html")
+				w.Write([]byte(tc.html))
+			}))
+			defer server.Close()
+
+			result, err := Analyze(context.Background(), server.URL)
+
+			assert.NoError(t, err)
+			assert.NotNil(t, result)
+			assert.Equal(t, tc.expectedH1, result.Headings.H1)
+			assert.Equal(t, tc.expectedH2, result.Headings.H2)
+			assert.Equal(t, tc.expectedH3, result.Headings.H3)
+			assert.Equal(t, tc.expectedTitle, result.Title)
+			assert.Equal(t, tc.hasLoginForm, result.HasLoginForm)
+		})
+	}
+}
+
+func TestLinkExtraction(t *testing.T) {
+	testCases := []struct {
+		name              string
+		html              string
+		baseURL           string
+		expectedInternal  int
+		expectedExternal  int
+		expectedBrokenMin int
+	}{
+		{
+			name: "mixed links",
+			html: `<html><body>
+				<a href="/internal1">Internal 1</a>
+				<a href="/internal2">Internal 2</a>
+				<a href="https://external.com">External</a>
+				<a href="https://another-external.com">Another External</a>
+				<a href="/broken-link">Broken</a>
+			</body></html>`,
+			baseURL:           "https://example.com",
+			expectedInternal:  3,
+			expectedExternal:  2,
+			expectedBrokenMin: 1,
+		},
+		{
+			name:              "no links",
+			html:              `<html><body><p>No links here</p></body></html>`,
+			baseURL:           "https://example.com",
+			expectedInternal:  0,
+			expectedExternal:  0,
+			expectedBrokenMin: 0,
+		},
+		{
+			name: "only external links",
+			html: `<html><body>
+				<a href="https://external1.com">External 1</a>
+				<a href="https://external2.com">External 2</a>
+			</body></html>`,
+			baseURL:           "https://example.com",
+			expectedInternal:  0,
+			expectedExternal:  2,
+			expectedBrokenMin: 0,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if strings.Contains(r.URL.Path, "broken") {
+					w.WriteHeader(http.StatusNotFound)
+					return
+				}
+
+				w.Header().Set("Content-Type", "text/html")
+				w.Write([]byte(tc.html))
+			}))
+			defer server.Close()
+
+			result, err := Analyze(context.Background(), server.URL)
+
+			assert.NoError(t, err)
+			assert.NotNil(t, result)
+			assert.Equal(t, tc.expectedInternal, result.Links.Internal)
+			assert.Equal(t, tc.expectedExternal, result.Links.External)
+			assert.GreaterOrEqual(t, len(result.BrokenLinks), tc.expectedBrokenMin)
+		})
+	}
+}
+
+func TestInternalPages(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(`<html><body>
+			<a href="/about">About</a>
+			<a href="/about#team">Team</a>
+			<a href="/contact?ref=nav">Contact</a>
+			<a href="https://external.invalid/page">External</a>
+			<a href="mailto:hi@example.com">Mail</a>
+		</body></html>`))
+	}))
+	defer server.Close()
+
+	result, err := Analyze(context.Background(), server.URL)
+	require.NoError(t, err)
+	assert.Equal(t, []string{server.URL + "/about", server.URL + "/contact?ref=nav"}, result.InternalPages)
+}
+
+func TestBrokenLinkDetection(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/":
+			html := `<html><body>
+				<a href="/working">Working Link</a>
+				<a href="/not-found">Not Found Link</a>
+				<a href="/server-error">Server Error Link</a>
+			</body></html>`
+			w.Write([]byte(html))
+		case "/working":
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte("OK"))
+		case "/not-found":
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte("Not Found"))
+		case "/server-error":
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte("Server Error"))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	t.Run("broken link detection", func(t *testing.T) {
+		result, err := Analyze(context.Background(), server.URL)
+
+		assert.NoError(t, err)
+		assert.NotNil(t, result)
+		assert.Greater(t, len(result.BrokenLinks), 0)
+
+		// Check that broken links have proper error information
+		for _, brokenLink := range result.BrokenLinks {
+			assert.NotEmpty(t, brokenLink.URL)
+			assert.True(t, brokenLink.StatusCode != 0 || brokenLink.Error != "")
+		}
+	})
+}
+
+func TestHTMLVersionDetection(t *testing.T) {
+	testCases := []struct {
+		name            string
+		html            string
+		expectedVersion string
+	}{
+		{
+			name:            "HTML5",
+			html:            `<!DOCTYPE html><html><body></body></html>`,
+			expectedVersion: "HTML5",
+		},
+		{
+			name:            "HTML 4.01 Strict",
+			html:            `<!DOCTYPE HTML PUBLIC "-//W3C//DTD HTML 4.01//EN" "http://www.w3.org/TR/html4/strict.dtd"><html><body></body></html>`,
+			expectedVersion: "HTML 4.01 Strict",
+		},
+		{
+			name:            "XHTML 1.0 Strict",
+			html:            `<!DOCTYPE html PUBLIC "-//W3C//DTD XHTML 1.0 Strict//EN" "http://www.w3.org/TR/xhtml1/DTD/xhtml1-strict.dtd"><html><body></body></html>`,
+			expectedVersion: "XHTML 1.0 Strict",
+		},
+		{
+			name:            "No DOCTYPE",
+			html:            `<html><body></body></html>`,
+			expectedVersion: "Unknown",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "text/html")
+				w.Write([]byte(tc.html))
+			}))
+			defer server.Close()
+
+			result, err := Analyze(context.Background(), server.URL)
+
+			assert.NoError(t, err)
+			assert.NotNil(t, result)
+			assert.Equal(t, tc.expectedVersion, result.HTMLVersion)
+		})
+	}
+}
+
+func TestUserAgentAndHeaders(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userAgent := r.Header.Get("User-Agent")
+		accept := r.Header.Get("Accept")
+
+		// Verify proper headers are set
+		assert.Contains(t, userAgent, "Chrome")
+		assert.Contains(t, accept, "text/html")
+
+		w.Write([]byte(`<html><body><h1>Test</h1></body></html>`))
+	}))
+	defer server.Close()
+
+	t.Run("proper headers set", func(t *testing.T) {
+		result, err := Analyze(context.Background(), server.URL)
+
+		assert.NoError(t, err)
+		assert.NotNil(t, result)
+	})
+}
+
+func TestConcurrentLinkChecking(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Simulate slow response for some links
+		if strings.Contains(r.URL.Path, "slow") {
+			time.Sleep(100 * time.Millisecond)
+		}
+
+		if r.URL.Path == "/" {
+			html := `<html><body>`
+			// Generate many links to test concurrency
+			for i := 0; i < 20; i++ {
+				html += `<a href="/link` + strconv.Itoa(i) + `">Link ` + strconv.Itoa(i) + `</a>`
+			}
+			html += `</body></html>`
+			w.Write([]byte(html))
+		} else {
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte("OK"))
+		}
+	}))
+	defer server.Close()
+
+	t.Run("concurrent link checking", func(t *testing.T) {
+		start := time.Now()
+		result, err := Analyze(context.Background(), server.URL)
+		duration := time.Since(start)
+
+		assert.NoError(t, err)
+		assert.NotNil(t, result)
+
+		// With proper concurrency, should complete reasonably quickly
+		// even with many links
+		assert.Less(t, duration, 10*time.Second)
+		assert.Greater(t, result.Links.Internal, 10)
+	})
+}
+
+func TestErrorHandling(t *testing.T) {
+	testCases := []struct {
+		name          string
+		url           string
+		expectedError string
+	}{
+		{
+			name:          "invalid URL format",
+			url:           "invalid-url",
+			expectedError: "network error",
+		},
+		{
+			name:          "unreachable host",
+			url:           "http://unreachable-host-12345.com",
+			expectedError: "not found",
+		},
+		{
+			name:          "connection refused",
+			url:           "http://localhost:99999",
+			expectedError: "network error",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := Analyze(context.Background(), tc.url)
+
+			assert.Error(t, err)
+			assert.Nil(t, result)
+			assert.Contains(t, err.Error(), tc.expectedError)
+		})
+	}
+}
+
+func TestContextCancellation(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Simulate very slow response
+		time.Sleep(5 * time.Second)
+		w.Write([]byte(`<html><body>Slow</body></html>`))
+	}))
+	defer server.Close()
+
+	t.Run("context cancellation", func(t *testing.T) {
+		// Uses the default page timeout; TestAnalyzeCancellation covers a cancelled context
+		start := time.Now()
+		result, err := Analyze(context.Background(), server.URL)
+		duration := time.Since(start)
+
+		// Should timeout within reasonable time
+		assert.Less(t, duration, 2*time.Minute)
+
+		// Should either succeed or have timeout error
+		if err != nil {
+			assert.Contains(t, err.Error(), "timeout")
+		} else {
+			assert.NotNil(t, result)
+		}
+	})
+}
+
+func TestAnalyzeWithExclusions(t *testing.T) {
+	var logoutRequested bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/":
+			w.Write([]byte(`<html><body>
+				<a href="/logout">Logout</a>
+				<a href="/missing">Missing</a>
+			</body></html>`))
+		case "/logout":
+			logoutRequested = true
+			w.WriteHeader(http.StatusNotFound)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	excluder, err := NewLinkExcluder([]LinkExclusion{{Pattern: "*/logout", PatternType: "glob"}})
+	assert.NoError(t, err)
+
+	result, err := Analyze(context.Background(), server.URL, WithExclusions(excluder))
+
+	assert.NoError(t, err)
+	assert.Equal(t, 2, result.Links.Internal)
+	assert.Len(t, result.BrokenLinks, 1)
+	assert.Equal(t, server.URL+"/missing", result.BrokenLinks[0].URL)
+	assert.False(t, logoutRequested)
+}
+
+func TestNofollowAndRobotsDetection(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		w.Header().Set("X-Robots-Tag", "otherbot: noindex")
+		w.Write([]byte(`<html><head>
+			<meta name="robots" content="NOINDEX, follow">
+		</head><body>
+			<a href="/followed">Followed</a>
+			<a href="/private" rel="nofollow">Private</a>
+			<a href="https://ads.invalid/" rel="sponsored noopener">Ad</a>
+			<a href="https://forum.invalid/" rel="UGC">Comment</a>
+			<a href="https://partner.invalid/">Partner</a>
+		</body></html>`))
+	}))
+	defer server.Close()
+
+	result, err := Analyze(context.Background(), server.URL)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 2, result.Links.Internal)
+	assert.Equal(t, 3, result.Links.External)
+	assert.Equal(t, 1, result.Links.InternalNofollow)
+	assert.Equal(t, 2, result.Links.ExternalNofollow)
+	assert.Equal(t, 1, result.Links.Sponsored)
+	assert.Equal(t, 1, result.Links.UGC)
+	assert.True(t, result.Robots.Noindex)
+	assert.False(t, result.Robots.Nofollow)
+}
+
+func TestRobotsHeaderDirectives(t *testing.T) {
+	testCases := []struct {
+		name             string
+		header           string
+		expectedNoindex  bool
+		expectedNofollow bool
+	}{
+		{name: "unscoped none", header: "none", expectedNoindex: true, expectedNofollow: true},
+		{name: "googlebot scoped", header: "googlebot: nofollow", expectedNofollow: true},
+		{name: "other bot scoped", header: "bingbot: noindex"},
+		{name: "with unavailable_after", header: "noindex, unavailable_after: 25 Jun 2010 15:00:00 PST", expectedNoindex: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("X-Robots-Tag", tc.header)
+				w.Write([]byte(`<html><body></body></html>`))
+			}))
+			defer server.Close()
+
+			result, err := Analyze(context.Background(), server.URL)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedNoindex, result.Robots.Noindex)
+			assert.Equal(t, tc.expectedNofollow, result.Robots.Nofollow)
+		})
+	}
+}
+
+func TestAnalyzeCancellation(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	start := time.Now()
+	result, err := Analyze(ctx, server.URL)
+
+	assert.Nil(t, result)
+	assert.Less(t, time.Since(start), 5*time.Second)
+	var analyzeErr *Error
+	require.ErrorAs(t, err, &analyzeErr)
+	assert.Equal(t, ErrorCanceled, analyzeErr.Kind)
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestSkipLinkChecks(t *testing.T) {
+	var linkRequests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			linkRequests.Add(1)
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(`<html><body><a href="/missing">Missing</a></body></html>`))
+	}))
+	defer server.Close()
+
+	result, err := Analyze(context.Background(), server.URL, WithOptions(Options{SkipLinkChecks: true}))
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Links.Internal)
+	assert.Empty(t, result.BrokenLinks)
+	assert.Zero(t, linkRequests.Load())
+}
+
+func TestErrorKinds(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow" {
+			time.Sleep(200 * time.Millisecond)
+		}
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	t.Run("HTTP error status", func(t *testing.T) {
+		_, err := Analyze(context.Background(), server.URL)
+
+		var analyzeErr *Error
+		require.ErrorAs(t, err, &analyzeErr)
+		assert.Equal(t, ErrorHTTPStatus, analyzeErr.Kind)
+		assert.Equal(t, http.StatusServiceUnavailable, analyzeErr.StatusCode)
+		assert.Equal(t, server.URL, analyzeErr.URL)
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		_, err := Analyze(context.Background(), server.URL+"/slow", WithRequestTimeout(20*time.Millisecond))
+
+		var analyzeErr *Error
+		require.ErrorAs(t, err, &analyzeErr)
+		assert.Equal(t, ErrorTimeout, analyzeErr.Kind)
+	})
+
+	t.Run("target not allowed", func(t *testing.T) {
+		redirect := httptest.NewServer(http.RedirectHandler(server.URL+"/elsewhere", http.StatusFound))
+		defer redirect.Close()
+		onlyRedirect := func(u *url.URL) error {
+			if u.Host != strings.TrimPrefix(redirect.URL, "http://") {
+				return errors.New(u.Host + " is not allowed")
+			}
+			return nil
+		}
+
+		_, err := Analyze(context.Background(), server.URL, WithOptions(Options{AllowTarget: onlyRedirect}))
+		var analyzeErr *Error
+		require.ErrorAs(t, err, &analyzeErr)
+		assert.Equal(t, ErrorNotAllowed, analyzeErr.Kind)
+
+		_, err = Analyze(context.Background(), redirect.URL, WithOptions(Options{AllowTarget: onlyRedirect}))
+		require.ErrorAs(t, err, &analyzeErr)
+		assert.Equal(t, ErrorNotAllowed, analyzeErr.Kind, "redirects are checked too")
+		assert.Contains(t, analyzeErr.Error(), "is not allowed")
+	})
+}
+
+func TestOptions(t *testing.T) {
+	t.Run("defaults", func(t *testing.T) {
+		opts := New().Options()
+		assert.Equal(t, 90*time.Second, opts.PageTimeout)
+		assert.Equal(t, 10, opts.MaxConcurrentLinkChecks)
+		assert.Equal(t, DefaultUserAgent, opts.UserAgent)
+		assert.NotNil(t, opts.HTTPClient)
+	})
+
+	t.Run("options apply in order", func(t *testing.T) {
+		opts := New(
+			WithOptions(Options{UserAgent: "first", MaxConcurrentLinkChecks: 3}),
+			WithUserAgent("second"),
+		).Options()
+		assert.Equal(t, "second", opts.UserAgent)
+		assert.Equal(t, 3, opts.MaxConcurrentLinkChecks)
+	})
+
+	t.Run("custom HTTP client transport is used", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/" {
+				w.Write([]byte(`<html><body><a href="/a">A</a></body></html>`))
+			}
+		}))
+		defer server.Close()
+
+		transport := &countingTransport{next: http.DefaultTransport}
+		_, err := Analyze(context.Background(), server.URL, WithHTTPClient(&http.Client{Transport: transport}))
+		require.NoError(t, err)
+		assert.Equal(t, int32(2), transport.requests.Load()) // the page and its link
+	})
+}
+
+func TestLinkCheckCounts(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/" {
+			w.Write([]byte(`<html><body><a href="/a">A</a><a href="/b">B</a><a href="/logout">Out</a></body></html>`))
+		}
+	}))
+	defer server.Close()
+
+	excluder, err := NewLinkExcluder([]LinkExclusion{{Pattern: "*/logout"}})
+	require.NoError(t, err)
+
+	result, err := Analyze(context.Background(), server.URL, WithExclusions(excluder))
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Links.Excluded)
+	assert.Equal(t, 2, result.Links.Checked)
+	assert.Equal(t, server.URL, result.FinalURL)
+	assert.Equal(t, http.StatusOK, result.StatusCode)
+	assert.NotNil(t, result.BrokenLinks)
+}
+
+// countingTransport counts the requests sent through it
+type countingTransport struct {
+	next     http.RoundTripper
+	requests atomic.Int32
+}
+
+func (c *countingTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	c.requests.Add(1)
+	return c.next.RoundTrip(r)
+}
@@ -0,0 +1,173 @@
+package analyzer
+
+import (
+	"context"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// maxCheckedAssets caps how many images, scripts and linked files are checked per page
+const maxCheckedAssets = 100
+
+// Asset types of a BrokenAsset besides ResourceCSS and ResourceJS
+const (
+	ResourceImage = "image"
+	// ResourceOther is a file referenced by a <link> other than a stylesheet, such as an icon,
+	// a preload or a web app manifest
+	ResourceOther = "other"
+)
+
+// assetLinkRels are the <link> rel values that reference a file the browser downloads
+var assetLinkRels = map[string]string{
+	"stylesheet":       ResourceCSS,
+	"icon":             ResourceOther,
+	"apple-touch-icon": ResourceOther,
+	"preload":          ResourceOther,
+	"modulepreload":    ResourceJS,
+	"manifest":         ResourceOther,
+}
+
+// BrokenAsset is an image, script or linked file of the page that failed its check
+type BrokenAsset struct {
+	URL string `json:"url"`
+	// Type is ResourceImage, ResourceCSS, ResourceJS or ResourceOther
+	Type string `json:"type"`
+	// StatusCode is the HTTP status of the response, or 0 when no response arrived
+	StatusCode int `json:"status_code,omitempty"`
+	// ContentType is the media type the server answered with, without parameters
+	ContentType string `json:"content_type,omitempty"`
+	Error       string `json:"error"`
+}
+
+// checkAssets checks the page's images, scripts and linked files with HEAD requests, skipping any
+// matched by the exclusions. Unlike auditResources it includes assets on other sites: a missing CDN
+// script breaks the page all the same.
+func (a *Analyzer) checkAssets(ctx context.Context, doc *goquery.Document, page *url.URL) []BrokenAsset {
+	var assets []asset
+	for _, asset := range collectCheckedAssets(doc, page, a.opts) {
+		if a.opts.Exclusions == nil || !a.opts.Exclusions.Matches(asset.url) {
+			assets = append(assets, asset)
+		}
+	}
+
+	results := make([]*BrokenAsset, len(assets))
+	client := a.client(a.opts.LinkCheckTimeout)
+	semaphore := make(chan struct{}, a.opts.MaxConcurrentLinkChecks)
+	var wg sync.WaitGroup
+	for i, asset := range assets {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			select {
+			case semaphore <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			defer func() { <-semaphore }()
+			results[i] = checkSingleAsset(ctx, client, asset, a.opts.UserAgent)
+		}()
+	}
+	wg.Wait()
+
+	brokenAssets := make([]BrokenAsset, 0)
+	for _, broken := range results {
+		if broken != nil {
+			brokenAssets = append(brokenAssets, *broken)
+		}
+	}
+	return brokenAssets
+}
+
+// collectCheckedAssets lists the distinct http(s) images, scripts and linked files of the page, in
+// document order and in canonical form
+func collectCheckedAssets(doc *goquery.Document, page *url.URL, opts Options) []asset {
+	var assets []asset
+	seen := make(map[string]bool)
+	add := func(ref, kind string) {
+		ref = strings.TrimSpace(ref)
+		link, err := url.Parse(ref)
+		if err != nil || ref == "" || len(assets) >= maxCheckedAssets {
+			return
+		}
+		resolved := canonicalURL(page.ResolveReference(link), opts)
+		if resolved.Scheme != "http" && resolved.Scheme != "https" {
+			return
+		}
+		if target := resolved.String(); !seen[target] {
+			seen[target] = true
+			assets = append(assets, asset{url: target, kind: kind})
+		}
+	}
+
+	doc.Find("img[src], script[src], link[href]").Each(func(_ int, s *goquery.Selection) {
+		switch goquery.NodeName(s) {
+		case "img":
+			add(s.AttrOr("src", ""), ResourceImage)
+		case "script":
+			add(s.AttrOr("src", ""), ResourceJS)
+		case "link":
+			for _, token := range strings.Fields(strings.ToLower(s.AttrOr("rel", ""))) {
+				if kind, ok := assetLinkRels[token]; ok {
+					add(s.AttrOr("href", ""), kind)
+					return
+				}
+			}
+		}
+	})
+	return assets
+}
+
+// checkSingleAsset checks if an asset is broken: unreachable, an error status, or an HTML page
+// served in place of an image, stylesheet or script, as soft 404s are
+func checkSingleAsset(ctx context.Context, client *http.Client, item asset, userAgent string) *BrokenAsset {
+	req, err := http.NewRequestWithContext(ctx, "HEAD", item.url, nil)
+	if err != nil {
+		return &BrokenAsset{URL: item.url, Type: item.kind, Error: fmt.Sprintf("Request creation failed: %v", err)}
+	}
+	req.Header.Set("User-Agent", userAgent)
+	req.Header.Set("Accept", "*/*")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		// Skip context cancellation errors
+		if ctx.Err() != nil {
+			return nil
+		}
+		return &BrokenAsset{URL: item.url, Type: item.kind, Error: checkErrorMessage(err)}
+	}
+	defer resp.Body.Close()
+
+	contentType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if resp.StatusCode >= 400 {
+		return &BrokenAsset{URL: item.url, Type: item.kind, StatusCode: resp.StatusCode, ContentType: contentType, Error: resp.Status}
+	}
+	if contentType == "text/html" && item.kind != ResourceOther {
+		return &BrokenAsset{
+			URL:         item.url,
+			Type:        item.kind,
+			StatusCode:  resp.StatusCode,
+			ContentType: contentType,
+			Error:       fmt.Sprintf("Served an HTML page instead of %s", assetNoun(item.kind)),
+		}
+	}
+	return nil
+}
+
+// assetNoun names an asset type in messages
+func assetNoun(kind string) string {
+	switch kind {
+	case ResourceImage:
+		return "an image"
+	case ResourceCSS:
+		return "a stylesheet"
+	case ResourceJS:
+		return "a script"
+	}
+	return "the file"
+}
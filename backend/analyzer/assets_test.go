@@ -0,0 +1,63 @@
+package analyzer
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckAssets(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/":
+			w.Header().Set("Content-Type", "text/html")
+			w.Write([]byte(`<html><head><title>Assets</title>
+				<link rel="stylesheet" href="/app.css"><link rel="canonical" href="/gone">
+				<link rel="icon" href="/favicon.ico"><script src="/main.js"></script>
+				</head><body>
+				<img src="/logo.png"><img src="/logo.png#top"><img src="/missing.png">
+				<img src="/soft-404.jpg"><img src="data:image/gif;base64,R0lGODlhAQABAAAAACw=">
+				</body></html>`))
+		case "/app.css":
+			w.Header().Set("Content-Type", "text/css")
+		case "/main.js":
+			w.Header().Set("Content-Type", "text/javascript")
+		case "/logo.png":
+			w.Header().Set("Content-Type", "image/png")
+		case "/soft-404.jpg":
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	t.Run("off by default", func(t *testing.T) {
+		result, err := Analyze(context.Background(), server.URL)
+		require.NoError(t, err)
+		assert.Nil(t, result.BrokenAssets)
+	})
+
+	t.Run("reports missing assets and HTML served in their place", func(t *testing.T) {
+		result, err := Analyze(context.Background(), server.URL, WithCheckAssets(true))
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []BrokenAsset{
+			{URL: server.URL + "/favicon.ico", Type: ResourceOther, StatusCode: 404, ContentType: "text/plain", Error: "404 Not Found"},
+			{URL: server.URL + "/missing.png", Type: ResourceImage, StatusCode: 404, ContentType: "text/plain", Error: "404 Not Found"},
+			{URL: server.URL + "/soft-404.jpg", Type: ResourceImage, StatusCode: 200, ContentType: "text/html", Error: "Served an HTML page instead of an image"},
+		}, result.BrokenAssets)
+	})
+
+	t.Run("nothing broken is an empty list", func(t *testing.T) {
+		exclusions, err := NewLinkExcluder([]LinkExclusion{{Pattern: "*.png"}, {Pattern: "*.jpg"}, {Pattern: "*.ico"}})
+		require.NoError(t, err)
+		result, err := Analyze(context.Background(), server.URL, WithCheckAssets(true), WithExclusions(exclusions))
+		require.NoError(t, err)
+		assert.NotNil(t, result.BrokenAssets)
+		assert.Empty(t, result.BrokenAssets)
+	})
+}
@@ -0,0 +1,117 @@
+package analyzer
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// benchPage builds a page of sections sections, each with a heading, a paragraph and internal,
+// external and relative links, roughly as dense as a large article or category page
+func benchPage(sections int) string {
+	var b strings.Builder
+	b.WriteString("<!DOCTYPE html><html><head><title>Benchmark</title>")
+	b.WriteString(`<meta name="robots" content="index,follow"><link rel="stylesheet" href="/style.css">`)
+	b.WriteString("</head><body><nav>")
+	for i := 0; i < 20; i++ {
+		fmt.Fprintf(&b, `<a href="/nav/%d">Menu %d</a>`, i, i)
+	}
+	b.WriteString("</nav><main>")
+	for i := 0; i < sections; i++ {
+		fmt.Fprintf(&b, "<h2>Section %d</h2><p>Text of section %d with ", i, i)
+		fmt.Fprintf(&b, `<a href="/articles/%d?utm_source=bench">an internal link</a>, `, i)
+		fmt.Fprintf(&b, `<a href="https://external-%d.example.org/page" rel="nofollow">an external one</a> and `, i%50)
+		fmt.Fprintf(&b, `<a href="../related/%d#top">a relative one</a>.</p>`, i)
+		fmt.Fprintf(&b, `<img src="/images/%d.png" alt="Image %d">`, i, i)
+	}
+	b.WriteString("</main></body></html>")
+	return b.String()
+}
+
+// benchSizes are the page sizes the benchmarks run at
+var benchSizes = []int{100, 1000, 10000}
+
+func BenchmarkParse(b *testing.B) {
+	for _, sections := range benchSizes {
+		page := benchPage(sections)
+		b.Run(fmt.Sprintf("sections=%d", sections), func(b *testing.B) {
+			b.SetBytes(int64(len(page)))
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := goquery.NewDocumentFromReader(strings.NewReader(page)); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkCollectLinks(b *testing.B) {
+	base, _ := url.Parse("https://www.example.com/blog/post")
+	opts := Options{}.withDefaults()
+	scope := newLinkScope(base, opts)
+	for _, sections := range benchSizes {
+		doc, err := goquery.NewDocumentFromReader(strings.NewReader(benchPage(sections)))
+		if err != nil {
+			b.Fatal(err)
+		}
+		b.Run(fmt.Sprintf("sections=%d", sections), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				collectLinks(doc, base, scope, opts, &Result{})
+			}
+		})
+	}
+}
+
+// BenchmarkAnalyze runs whole analyses of a local page without link checks, so it measures the
+// analyzer itself rather than the network
+func BenchmarkAnalyze(b *testing.B) {
+	for _, sections := range benchSizes {
+		page := benchPage(sections)
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != "/" {
+				http.NotFound(w, r)
+				return
+			}
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			w.Write([]byte(page))
+		}))
+		a := New(WithOptions(Options{SkipLinkChecks: true}))
+
+		b.Run(fmt.Sprintf("sections=%d", sections), func(b *testing.B) {
+			b.SetBytes(int64(len(page)))
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := a.Analyze(context.Background(), server.URL+"/"); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+		server.Close()
+	}
+}
+
+func BenchmarkStreamPage(b *testing.B) {
+	base, _ := url.Parse("https://www.example.com/blog/post")
+	opts := Options{}.withDefaults()
+	scope := newLinkScope(base, opts)
+	for _, sections := range benchSizes {
+		page := benchPage(sections)
+		b.Run(fmt.Sprintf("sections=%d", sections), func(b *testing.B) {
+			b.SetBytes(int64(len(page)))
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := streamPage(strings.NewReader(page), base, scope, opts, &Result{}); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
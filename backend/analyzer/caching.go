@@ -0,0 +1,308 @@
+package analyzer
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	"golang.org/x/net/publicsuffix"
+)
+
+const (
+	// maxAuditedResources caps how many stylesheets and scripts are fetched per page
+	maxAuditedResources = 20
+	// minCompressibleBytes is the size below which compression is not worth a finding
+	minCompressibleBytes = 1024
+	// maxResourceBytes caps how much of a resource is downloaded to measure it
+	maxResourceBytes = 5 << 20
+	// shortAssetLifetime is the cache lifetime below which stylesheets and scripts are reported
+	shortAssetLifetime = time.Hour
+	// longPageLifetime is the cache lifetime above which the page itself is reported as likely stale
+	longPageLifetime = 24 * time.Hour
+)
+
+// Resource types of a ResourceAudit
+const (
+	ResourceHTML = "html"
+	ResourceCSS  = "css"
+	ResourceJS   = "js"
+)
+
+// ResourceAudit holds the compression and caching headers of the page or one of its assets
+type ResourceAudit struct {
+	URL        string `json:"url"`
+	Type       string `json:"type"`
+	StatusCode int    `json:"status_code,omitempty"`
+	// ContentEncoding is gzip, br, deflate or empty when the response is not compressed
+	ContentEncoding string `json:"content_encoding,omitempty"`
+	// Bytes is the size transferred, capped at 5 MB
+	Bytes        int64  `json:"bytes"`
+	CacheControl string `json:"cache_control,omitempty"`
+	Expires      string `json:"expires,omitempty"`
+	ETag         bool   `json:"etag"`
+	LastModified bool   `json:"last_modified"`
+	// LifetimeSeconds is how long browsers may reuse the response without asking the server,
+	// from max-age or Expires; nil when neither is set
+	LifetimeSeconds *int64 `json:"lifetime_seconds,omitempty"`
+	Error           string `json:"error,omitempty"`
+}
+
+// cacheDirectives are the Cache-Control directives relevant to browsers
+type cacheDirectives struct {
+	noStore   bool
+	noCache   bool
+	immutable bool
+	maxAge    *int64 // seconds
+}
+
+// auditResources fetches the page's same-site stylesheets and scripts and audits their headers along
+// with the page's own response. Assets on other sites are skipped: their owners, not the page's, can fix them.
+func (a *Analyzer) auditResources(ctx context.Context, doc *goquery.Document, page *http.Response, pageBytes int64) []ResourceAudit {
+	audits := []ResourceAudit{auditResponse(page.Request.URL.String(), ResourceHTML, page, pageBytes)}
+
+	assets := collectAssets(doc, page.Request.URL)
+	results := make([]ResourceAudit, len(assets))
+	client := a.client(a.opts.LinkCheckTimeout)
+	semaphore := make(chan struct{}, a.opts.MaxConcurrentLinkChecks)
+	var wg sync.WaitGroup
+	for i, asset := range assets {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			select {
+			case semaphore <- struct{}{}:
+			case <-ctx.Done():
+				results[i] = ResourceAudit{URL: asset.url, Type: asset.kind, Error: "not checked: time budget exhausted"}
+				return
+			}
+			defer func() { <-semaphore }()
+			results[i] = a.fetchResource(ctx, client, asset.url, asset.kind)
+		}()
+	}
+	wg.Wait()
+	return append(audits, results...)
+}
+
+// asset is a stylesheet or script referenced by the page
+type asset struct {
+	url  string
+	kind string
+}
+
+// collectAssets lists the distinct same-site stylesheets and scripts of the page, in document order
+func collectAssets(doc *goquery.Document, page *url.URL) []asset {
+	site := registrableDomain(page.Hostname())
+	var assets []asset
+	seen := make(map[string]bool)
+	add := func(ref, kind string) {
+		link, err := url.Parse(strings.TrimSpace(ref))
+		if err != nil || ref == "" || len(assets) >= maxAuditedResources {
+			return
+		}
+		resolved := page.ResolveReference(link)
+		resolved.Fragment = ""
+		if (resolved.Scheme != "http" && resolved.Scheme != "https") || registrableDomain(resolved.Hostname()) != site {
+			return
+		}
+		if target := resolved.String(); !seen[target] {
+			seen[target] = true
+			assets = append(assets, asset{url: target, kind: kind})
+		}
+	}
+
+	doc.Find(`link[href]`).Each(func(_ int, s *goquery.Selection) {
+		rel, _ := s.Attr("rel")
+		for _, token := range strings.Fields(strings.ToLower(rel)) {
+			if token == "stylesheet" {
+				href, _ := s.Attr("href")
+				add(href, ResourceCSS)
+				return
+			}
+		}
+	})
+	doc.Find(`script[src]`).Each(func(_ int, s *goquery.Selection) {
+		src, _ := s.Attr("src")
+		add(src, ResourceJS)
+	})
+	return assets
+}
+
+// registrableDomain returns the eTLD+1 of host, or host itself for IP addresses and unknown suffixes
+func registrableDomain(host string) string {
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	if _, err := netip.ParseAddr(host); err == nil {
+		return host
+	}
+	if domain, err := publicsuffix.EffectiveTLDPlusOne(host); err == nil {
+		return domain
+	}
+	return host
+}
+
+// fetchResource downloads an asset the way a browser would request it and audits the response
+func (a *Analyzer) fetchResource(ctx context.Context, client *http.Client, resourceURL, kind string) ResourceAudit {
+	req, err := http.NewRequestWithContext(ctx, "GET", resourceURL, nil)
+	if err != nil {
+		return ResourceAudit{URL: resourceURL, Type: kind, Error: err.Error()}
+	}
+	req.Header.Set("User-Agent", a.opts.UserAgent)
+	req.Header.Set("Accept", "*/*")
+	// Set explicitly so the transport leaves the body encoded and the header visible
+	req.Header.Set("Accept-Encoding", "gzip, deflate, br")
+
+	res, err := client.Do(req)
+	if err != nil {
+		return ResourceAudit{URL: resourceURL, Type: kind, Error: err.Error()}
+	}
+	defer res.Body.Close()
+
+	size, err := io.Copy(io.Discard, io.LimitReader(res.Body, maxResourceBytes))
+	if err != nil {
+		return ResourceAudit{URL: resourceURL, Type: kind, StatusCode: res.StatusCode, Error: err.Error()}
+	}
+	return auditResponse(resourceURL, kind, res, size)
+}
+
+// auditResponse records the compression and caching headers of a response of size bytes
+func auditResponse(resourceURL, kind string, res *http.Response, size int64) ResourceAudit {
+	audit := ResourceAudit{
+		URL:             resourceURL,
+		Type:            kind,
+		StatusCode:      res.StatusCode,
+		ContentEncoding: strings.ToLower(strings.TrimSpace(res.Header.Get("Content-Encoding"))),
+		Bytes:           size,
+		CacheControl:    strings.Join(res.Header.Values("Cache-Control"), ", "),
+		Expires:         res.Header.Get("Expires"),
+		ETag:            res.Header.Get("ETag") != "",
+		LastModified:    res.Header.Get("Last-Modified") != "",
+	}
+	if audit.ContentEncoding == "identity" {
+		audit.ContentEncoding = ""
+	}
+
+	// max-age wins over Expires, which is relative to the server's Date. An invalid Expires, such as 0,
+	// means already expired.
+	if maxAge := parseCacheControl(audit.CacheControl).maxAge; maxAge != nil {
+		audit.LifetimeSeconds = maxAge
+	} else if audit.Expires != "" {
+		var seconds int64
+		if at, err := http.ParseTime(audit.Expires); err == nil {
+			now := time.Now()
+			if date, err := http.ParseTime(res.Header.Get("Date")); err == nil {
+				now = date
+			}
+			seconds = max(int64(at.Sub(now).Seconds()), 0)
+		}
+		audit.LifetimeSeconds = &seconds
+	}
+	return audit
+}
+
+// parseCacheControl reads the Cache-Control directives relevant to browsers
+func parseCacheControl(value string) cacheDirectives {
+	var d cacheDirectives
+	for _, directive := range strings.Split(value, ",") {
+		name, arg, _ := strings.Cut(strings.TrimSpace(directive), "=")
+		switch strings.ToLower(name) {
+		case "no-store":
+			d.noStore = true
+		case "no-cache":
+			d.noCache = true
+		case "immutable":
+			d.immutable = true
+		case "max-age":
+			if seconds, err := strconv.ParseInt(strings.Trim(arg, `"`), 10, 64); err == nil && seconds >= 0 {
+				d.maxAge = &seconds
+			}
+		}
+	}
+	return d
+}
+
+// resourceFindings turns the audits into actionable findings, e.g. "enable gzip or brotli compression on /main.js"
+func resourceFindings(audits []ResourceAudit, page *url.URL) []string {
+	findings := []string{}
+	for _, audit := range audits {
+		if audit.Error != "" || audit.StatusCode < 200 || audit.StatusCode >= 300 {
+			continue
+		}
+		name := displayPath(audit.URL, page)
+		if audit.ContentEncoding == "" && audit.Bytes >= minCompressibleBytes {
+			findings = append(findings, fmt.Sprintf("enable gzip or brotli compression on %s (%s uncompressed)", name, formatBytes(audit.Bytes)))
+		}
+
+		cache := parseCacheControl(audit.CacheControl)
+		lifetime := audit.LifetimeSeconds
+		validator := audit.ETag || audit.LastModified
+		if audit.Type == ResourceHTML {
+			switch {
+			case audit.CacheControl == "" && audit.Expires == "" && !validator:
+				findings = append(findings, fmt.Sprintf("%s has no caching headers; send Cache-Control: no-cache with an ETag so browsers revalidate it", name))
+			case cache.noStore || cache.noCache:
+			case cache.immutable:
+				findings = append(findings, fmt.Sprintf("%s is marked immutable; visitors can see a stale page after updates", name))
+			case lifetime != nil && *lifetime > int64(longPageLifetime.Seconds()):
+				findings = append(findings, fmt.Sprintf("%s may be cached for %s; visitors can see a stale page after updates", name, formatLifetime(*lifetime)))
+			}
+			continue
+		}
+
+		switch {
+		case cache.noStore:
+			findings = append(findings, fmt.Sprintf("%s is sent with Cache-Control: no-store, so it is downloaded on every visit; allow caching", name))
+		case lifetime == nil && !cache.noCache && !validator:
+			findings = append(findings, fmt.Sprintf("set Cache-Control: max-age on %s; it has no caching headers", name))
+		case lifetime == nil || cache.noCache || *lifetime == 0:
+			findings = append(findings, fmt.Sprintf("%s is revalidated on every use; fingerprint its file name and cache it with a long max-age", name))
+		case *lifetime < int64(shortAssetLifetime.Seconds()):
+			findings = append(findings, fmt.Sprintf("%s is cached for only %s; fingerprint its file name and cache it with a long max-age", name, formatLifetime(*lifetime)))
+		}
+	}
+	return findings
+}
+
+// displayPath shortens same-host URLs to their path for findings
+func displayPath(resourceURL string, page *url.URL) string {
+	u, err := url.Parse(resourceURL)
+	if err != nil || u.Host != page.Host {
+		return resourceURL
+	}
+	return u.RequestURI()
+}
+
+// formatBytes prints a size in bytes, kilobytes or megabytes
+func formatBytes(n int64) string {
+	if n < 1024 {
+		return fmt.Sprintf("%d B", n)
+	}
+	if n >= 1<<20 {
+		return fmt.Sprintf("%.1f MB", float64(n)/(1<<20))
+	}
+	return fmt.Sprintf("%.1f KB", float64(n)/1024)
+}
+
+// formatLifetime prints a cache lifetime in its largest whole unit, e.g. 7 days
+func formatLifetime(seconds int64) string {
+	units := []struct {
+		name    string
+		seconds int64
+	}{{"day", 86400}, {"hour", 3600}, {"minute", 60}, {"second", 1}}
+	for _, unit := range units {
+		if seconds >= unit.seconds || unit.seconds == 1 {
+			n := seconds / unit.seconds
+			if n == 1 {
+				return "1 " + unit.name
+			}
+			return fmt.Sprintf("%d %ss", n, unit.name)
+		}
+	}
+	return ""
+}
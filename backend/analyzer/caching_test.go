@@ -0,0 +1,124 @@
+package analyzer
+
+import (
+	"compress/gzip"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuditResources(t *testing.T) {
+	script := strings.Repeat("console.log('hello');\n", 200)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/":
+			w.Header().Set("Content-Type", "text/html")
+			w.Write([]byte(`<html><head><title>Assets</title>
+				<link rel="stylesheet" href="/app.css"><link rel="preload stylesheet" href="/app.css">
+				<link rel="icon" href="/favicon.ico">
+				<script src="/main.js"></script><script src="https://cdn.other-site.test/lib.js"></script>
+				</head><body></body></html>`))
+		case "/app.css":
+			w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+			w.Header().Set("Content-Encoding", "gzip")
+			gz := gzip.NewWriter(w)
+			gz.Write([]byte(strings.Repeat("body { color: red; }\n", 200)))
+			gz.Close()
+		case "/main.js":
+			w.Write([]byte(script))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	result, err := Analyze(context.Background(), server.URL)
+	require.NoError(t, err)
+	resources := result.Performance.Resources
+	require.Len(t, resources, 3)
+
+	t.Run("the page comes first", func(t *testing.T) {
+		assert.Equal(t, ResourceHTML, resources[0].Type)
+		assert.Equal(t, http.StatusOK, resources[0].StatusCode)
+		assert.Positive(t, resources[0].Bytes)
+	})
+
+	t.Run("same-site stylesheets and scripts are fetched once", func(t *testing.T) {
+		assert.Equal(t, server.URL+"/app.css", resources[1].URL)
+		assert.Equal(t, ResourceCSS, resources[1].Type)
+		assert.Equal(t, "gzip", resources[1].ContentEncoding)
+		require.NotNil(t, resources[1].LifetimeSeconds)
+		assert.Equal(t, int64(31536000), *resources[1].LifetimeSeconds)
+
+		assert.Equal(t, server.URL+"/main.js", resources[2].URL)
+		assert.Equal(t, int64(len(script)), resources[2].Bytes)
+		assert.Empty(t, resources[2].ContentEncoding)
+	})
+
+	t.Run("findings name the fix", func(t *testing.T) {
+		assert.Contains(t, result.Performance.Findings, "enable gzip or brotli compression on /main.js (4.3 KB uncompressed)")
+		assert.Contains(t, result.Performance.Findings, "set Cache-Control: max-age on /main.js; it has no caching headers")
+		assert.Contains(t, result.Performance.Findings, "/ has no caching headers; send Cache-Control: no-cache with an ETag so browsers revalidate it")
+		for _, finding := range result.Performance.Findings {
+			assert.NotContains(t, finding, "app.css")
+		}
+	})
+}
+
+func TestResourceFindings(t *testing.T) {
+	page, _ := url.Parse("https://example.com/")
+	lifetime := func(seconds int64) *int64 { return &seconds }
+	audit := func(a ResourceAudit) []string {
+		a.StatusCode = http.StatusOK
+		if a.Type == "" {
+			a.Type = ResourceJS
+		}
+		return resourceFindings([]ResourceAudit{a}, page)
+	}
+
+	t.Run("small responses need no compression", func(t *testing.T) {
+		assert.Empty(t, audit(ResourceAudit{URL: "https://example.com/a.js", Bytes: 200, LifetimeSeconds: lifetime(86400)}))
+	})
+
+	t.Run("short lifetimes", func(t *testing.T) {
+		findings := audit(ResourceAudit{URL: "https://example.com/a.js", CacheControl: "max-age=300", LifetimeSeconds: lifetime(300)})
+		assert.Equal(t, []string{"/a.js is cached for only 5 minutes; fingerprint its file name and cache it with a long max-age"}, findings)
+	})
+
+	t.Run("no-store and revalidation", func(t *testing.T) {
+		findings := audit(ResourceAudit{URL: "https://example.com/a.css", Type: ResourceCSS, CacheControl: "no-store"})
+		assert.Contains(t, findings[0], "no-store")
+		findings = audit(ResourceAudit{URL: "https://example.com/a.css", Type: ResourceCSS, ETag: true})
+		assert.Contains(t, findings[0], "revalidated on every use")
+	})
+
+	t.Run("pages cached for long may go stale", func(t *testing.T) {
+		findings := audit(ResourceAudit{URL: "https://example.com/", Type: ResourceHTML, CacheControl: "max-age=604800", LifetimeSeconds: lifetime(604800)})
+		assert.Equal(t, []string{"/ may be cached for 7 days; visitors can see a stale page after updates"}, findings)
+		assert.Empty(t, audit(ResourceAudit{URL: "https://example.com/", Type: ResourceHTML, CacheControl: "no-cache", ETag: true}))
+	})
+
+	t.Run("failed fetches are skipped", func(t *testing.T) {
+		assert.Empty(t, resourceFindings([]ResourceAudit{{URL: "https://example.com/a.js", Type: ResourceJS, Error: "timeout"}}, page))
+	})
+}
+
+func TestAuditResponseExpires(t *testing.T) {
+	res := &http.Response{StatusCode: http.StatusOK, Header: http.Header{
+		"Date":    {"Fri, 16 Oct 2026 12:00:00 GMT"},
+		"Expires": {"Fri, 16 Oct 2026 13:00:00 GMT"},
+	}}
+	audit := auditResponse("https://example.com/a.js", ResourceJS, res, 10)
+	require.NotNil(t, audit.LifetimeSeconds)
+	assert.Equal(t, int64(3600), *audit.LifetimeSeconds)
+
+	res.Header.Set("Expires", "0")
+	audit = auditResponse("https://example.com/a.js", ResourceJS, res, 10)
+	assert.Equal(t, int64(0), *audit.LifetimeSeconds)
+}
@@ -0,0 +1,69 @@
+package analyzer
+
+import (
+	"net/url"
+	"strings"
+)
+
+// Canonical returns link in the form used to tell pages apart: without fragment, with a lower-case
+// scheme and host, without the query parameters matched by Options.IgnoreQueryParams and, with
+// Options.IgnoreTrailingSlash, without a trailing slash after the last path segment. Links that do
+// not parse are returned unchanged.
+func (a *Analyzer) Canonical(link string) string {
+	u, err := url.Parse(link)
+	if err != nil {
+		return link
+	}
+	return canonicalURL(u, a.opts).String()
+}
+
+// canonicalURL returns a canonical copy of u, see Analyzer.Canonical
+func canonicalURL(u *url.URL, opts Options) *url.URL {
+	c := *u
+	c.Fragment = ""
+	c.RawFragment = ""
+	c.Scheme = strings.ToLower(c.Scheme)
+	c.Host = strings.ToLower(c.Host)
+
+	if opts.IgnoreTrailingSlash && len(c.Path) > 1 && strings.HasSuffix(c.Path, "/") {
+		c.Path = strings.TrimRight(c.Path, "/")
+		if c.Path == "" {
+			c.Path = "/"
+		}
+		c.RawPath = ""
+	}
+
+	if len(opts.IgnoreQueryParams) > 0 && c.RawQuery != "" {
+		// Kept parameters stay in their order and encoding
+		var kept []string
+		for _, param := range strings.Split(c.RawQuery, "&") {
+			name, _, _ := strings.Cut(param, "=")
+			if decoded, err := url.QueryUnescape(name); err == nil {
+				name = decoded
+			}
+			if param != "" && !ignoredParam(name, opts.IgnoreQueryParams) {
+				kept = append(kept, param)
+			}
+		}
+		c.RawQuery = strings.Join(kept, "&")
+		c.ForceQuery = false
+	}
+	return &c
+}
+
+// ignoredParam reports whether a query parameter name matches one of the patterns, compared
+// case-insensitively. A trailing * matches any suffix, as in utm_*.
+func ignoredParam(name string, patterns []string) bool {
+	name = strings.ToLower(name)
+	for _, pattern := range patterns {
+		pattern = strings.ToLower(strings.TrimSpace(pattern))
+		if prefix, ok := strings.CutSuffix(pattern, "*"); ok {
+			if strings.HasPrefix(name, prefix) {
+				return true
+			}
+		} else if name == pattern {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,60 @@
+package analyzer
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCanonical(t *testing.T) {
+	t.Run("fragments and case of scheme and host", func(t *testing.T) {
+		a := New()
+		assert.Equal(t, "https://example.com/Page?b=1&a=2", a.Canonical("HTTPS://Example.COM/Page?b=1&a=2#top"))
+		assert.Equal(t, "https://example.com/page/", a.Canonical("https://example.com/page/"))
+		assert.Equal(t, "://bad", a.Canonical("://bad"))
+	})
+
+	t.Run("ignored query parameters", func(t *testing.T) {
+		a := New(WithIgnoreQueryParams("utm_*", "FBCLID"))
+		assert.Equal(t, "https://example.com/p?id=7&sort=asc",
+			a.Canonical("https://example.com/p?utm_source=news&id=7&fbclid=x&sort=asc&UTM_Medium=mail"))
+		assert.Equal(t, "https://example.com/p", a.Canonical("https://example.com/p?utm_campaign=spring"))
+		assert.Equal(t, "https://example.com/p?utmost=1", a.Canonical("https://example.com/p?utmost=1&utm_=2"))
+	})
+
+	t.Run("trailing slash", func(t *testing.T) {
+		a := New(WithIgnoreTrailingSlash(true))
+		assert.Equal(t, "https://example.com/page", a.Canonical("https://example.com/page/"))
+		assert.Equal(t, "https://example.com/a/b?x=1", a.Canonical("https://example.com/a/b/?x=1"))
+		assert.Equal(t, "https://example.com/", a.Canonical("https://example.com/"))
+	})
+}
+
+func TestCanonicalLinksAreCheckedOnce(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(`<html><body>
+			<a href="/pricing">Pricing</a>
+			<a href="/pricing/">Pricing</a>
+			<a href="/pricing?utm_source=footer">Pricing</a>
+			<a href="/pricing#plans">Plans</a>
+		</body></html>`))
+	}))
+	defer server.Close()
+
+	result, err := Analyze(context.Background(), server.URL)
+	require.NoError(t, err)
+	assert.Equal(t, 4, result.Links.Internal)
+	assert.Len(t, result.InternalPages, 3)
+	assert.Equal(t, 3, result.Links.Checked)
+
+	result, err = Analyze(context.Background(), server.URL, WithIgnoreTrailingSlash(true), WithIgnoreQueryParams("utm_*"))
+	require.NoError(t, err)
+	assert.Equal(t, 4, result.Links.Internal)
+	assert.Equal(t, []string{server.URL + "/pricing"}, result.InternalPages)
+	assert.Equal(t, 1, result.Links.Checked)
+}
@@ -0,0 +1,128 @@
+package analyzer
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// Consent reports whether the page shows a cookie consent banner. Detection is heuristic: it looks
+// for the scripts and markup of consent management platforms (CMPs) and for generic cookie banners.
+type Consent struct {
+	Detected bool `json:"detected"`
+	// Platforms lists the CMPs recognized on the page
+	Platforms []string `json:"platforms"`
+	// TCF is true when the page exposes the IAB Transparency and Consent Framework API (__tcfapi)
+	TCF bool `json:"tcf"`
+	// Evidence lists what gave the banner away, such as a script URL or an element id
+	Evidence []string `json:"evidence"`
+}
+
+// cmpSignature recognizes a consent management platform by script URLs or page markup
+type cmpSignature struct {
+	name    string
+	sources []string // substrings of script URLs
+	markup  string   // CSS selector of elements the platform renders or configures
+}
+
+// cmpSignatures are the consent management platforms detected, in reporting order
+var cmpSignatures = []cmpSignature{
+	{"OneTrust", []string{"cdn.cookielaw.org", "optanon", "onetrust.com"}, "#onetrust-consent-sdk, #onetrust-banner-sdk"},
+	{"Cookiebot", []string{"consent.cookiebot.com", "consentcdn.cookiebot.com"}, "#CybotCookiebotDialog, script#Cookiebot"},
+	{"Usercentrics", []string{"app.usercentrics.eu", "web.cmp.usercentrics.eu"}, "#usercentrics-root, #usercentrics-cmp-ui"},
+	{"Didomi", []string{"sdk.privacy-center.org"}, "#didomi-host"},
+	{"Quantcast Choice", []string{"cmp.quantcast.com", "quantcast.mgr.consensu.org"}, ".qc-cmp2-container"},
+	{"TrustArc", []string{"consent.trustarc.com", "consent-pref.trustarc.com"}, "#truste-consent-track, #consent_blackbar"},
+	{"Osano", []string{"cmp.osano.com"}, ".osano-cm-window"},
+	{"CookieYes", []string{"cdn-cookieyes.com"}, ".cky-consent-container"},
+	{"iubenda", []string{"cdn.iubenda.com/cs"}, "#iubenda-cs-banner"},
+	{"Termly", []string{"app.termly.io"}, "#termly-code-snippet-support"},
+	{"Axeptio", []string{"static.axept.io"}, "#axeptio_overlay"},
+	{"Sourcepoint", []string{"cdn.privacy-mgmt.com"}, ""},
+	{"Complianz", []string{"complianz-gdpr"}, "#cmplz-cookiebanner-container, .cmplz-cookiebanner"},
+	{"Borlabs Cookie", []string{"borlabs-cookie"}, "#BorlabsCookieBox"},
+	{"Klaro", []string{"klaro.js", "klaro.min.js", "cdn.kiprotect.com/klaro"}, ".klaro"},
+	{"Cookie Consent (Osano open source)", []string{"cookieconsent.min.js", "cookieconsent.js"}, ".cc-window"},
+}
+
+// bannerSelector matches generic cookie banners built without a CMP
+const bannerSelector = `[id*="cookie-banner" i], [class*="cookie-banner" i], [id*="cookie-consent" i], [class*="cookie-consent" i],
+	[id*="cookie-notice" i], [class*="cookie-notice" i], [id*="cookiebar" i], [class*="cookiebar" i],
+	[id*="consent-banner" i], [class*="consent-banner" i], [id*="gdpr" i], [class*="gdpr" i]`
+
+// bannerText matches the wording of cookie banners
+var bannerText = regexp.MustCompile(`(?i)(we use cookies|this (web)?site uses cookies|accept (all )?cookies|cookie (settings|preferences))`)
+
+// tcfAPI matches inline code that defines or calls the IAB TCF API
+var tcfAPI = regexp.MustCompile(`__tcfapi`)
+
+// detectConsent looks for consent management platforms and cookie banners
+func detectConsent(doc *goquery.Document) Consent {
+	c := Consent{Platforms: []string{}, Evidence: []string{}}
+
+	var sources []string
+	doc.Find("script[src]").Each(func(_ int, s *goquery.Selection) {
+		sources = append(sources, s.AttrOr("src", ""))
+	})
+
+	for _, sig := range cmpSignatures {
+		evidence := ""
+		for _, src := range sources {
+			lower := strings.ToLower(src)
+			for _, pattern := range sig.sources {
+				if strings.Contains(lower, pattern) {
+					evidence = truncateURL(src)
+					break
+				}
+			}
+			if evidence != "" {
+				break
+			}
+		}
+		if evidence == "" && sig.markup != "" {
+			if el := doc.Find(sig.markup).First(); el.Length() > 0 {
+				evidence = describeElement(el)
+			}
+		}
+		if evidence != "" {
+			c.Platforms = append(c.Platforms, sig.name)
+			c.Evidence = append(c.Evidence, evidence)
+		}
+	}
+
+	doc.Find("script:not([src])").EachWithBreak(func(_ int, s *goquery.Selection) bool {
+		if tcfAPI.MatchString(s.Text()) {
+			c.TCF = true
+			c.Evidence = append(c.Evidence, "inline __tcfapi")
+			return false
+		}
+		return true
+	})
+
+	// Fall back to generic banners, which need both banner-like markup and banner wording
+	if len(c.Platforms) == 0 {
+		doc.Find(bannerSelector).EachWithBreak(func(_ int, s *goquery.Selection) bool {
+			if bannerText.MatchString(s.Text()) {
+				c.Evidence = append(c.Evidence, describeElement(s))
+				return false
+			}
+			return true
+		})
+	}
+
+	c.Detected = len(c.Evidence) > 0
+	return c
+}
+
+// describeElement names an element by tag, id and first class, e.g. div#cookie-banner.notice
+func describeElement(s *goquery.Selection) string {
+	name := goquery.NodeName(s)
+	if id := s.AttrOr("id", ""); id != "" {
+		name += "#" + id
+	}
+	if classes := strings.Fields(s.AttrOr("class", "")); len(classes) > 0 {
+		name += "." + classes[0]
+	}
+	return name
+}
@@ -0,0 +1,56 @@
+package analyzer
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetectConsent(t *testing.T) {
+	detect := func(html string) Consent {
+		doc, err := goquery.NewDocumentFromReader(strings.NewReader(html))
+		require.NoError(t, err)
+		return detectConsent(doc)
+	}
+
+	t.Run("CMP script", func(t *testing.T) {
+		c := detect(`<html><head>
+			<script src="https://cdn.cookielaw.org/scripttemplates/otSDKStub.js" data-domain-script="abc"></script>
+			<script>window.__tcfapi = function() {};</script>
+		</head></html>`)
+		assert.True(t, c.Detected)
+		assert.Equal(t, []string{"OneTrust"}, c.Platforms)
+		assert.True(t, c.TCF)
+		assert.Equal(t, []string{"https://cdn.cookielaw.org/scripttemplates/otSDKStub.js", "inline __tcfapi"}, c.Evidence)
+	})
+
+	t.Run("CMP markup", func(t *testing.T) {
+		c := detect(`<html><body><div id="CybotCookiebotDialog">Consent</div></body></html>`)
+		assert.Equal(t, []string{"Cookiebot"}, c.Platforms)
+		assert.Equal(t, []string{"div#CybotCookiebotDialog"}, c.Evidence)
+	})
+
+	t.Run("generic banner", func(t *testing.T) {
+		c := detect(`<html><body><div class="Site-Cookie-Banner fixed">
+			This website uses cookies to improve your experience. <button>Accept all cookies</button>
+		</div></body></html>`)
+		assert.True(t, c.Detected)
+		assert.Empty(t, c.Platforms)
+		assert.Equal(t, []string{"div.Site-Cookie-Banner"}, c.Evidence)
+	})
+
+	t.Run("banner markup without banner wording", func(t *testing.T) {
+		c := detect(`<html><body><section class="gdpr-info">Read our policy</section></body></html>`)
+		assert.False(t, c.Detected)
+	})
+
+	t.Run("no banner", func(t *testing.T) {
+		c := detect(`<html><body><p>We bake cookies every day.</p></body></html>`)
+		assert.False(t, c.Detected)
+		assert.Empty(t, c.Platforms)
+		assert.Empty(t, c.Evidence)
+	})
+}
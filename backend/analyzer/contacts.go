@@ -0,0 +1,138 @@
+package analyzer
+
+import (
+	"fmt"
+	"net/mail"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// Codes of contact link findings
+const (
+	ContactInvalidEmail = "invalid_email" // a mailto: link without a valid address
+	ContactInvalidPhone = "invalid_phone" // a tel: link that is not a plausible phone number
+)
+
+// maxContactFindings caps how many malformed contact links are listed
+const maxContactFindings = 100
+
+// phoneSeparators are the visual separators RFC 3966 allows between digits
+var phoneSeparators = strings.NewReplacer(" ", "", "-", "", ".", "", "(", "", ")", "")
+
+// phoneNumber matches a number of 3 to 15 digits, the E.164 maximum, optionally in international form
+var phoneNumber = regexp.MustCompile(`^\+?[0-9]{3,15}$`)
+
+// ContactLinks counts the page's mailto: and tel: links and lists the malformed ones
+type ContactLinks struct {
+	Mailto int `json:"mailto"`
+	Tel    int `json:"tel"`
+	// Findings lists each distinct malformed link once, up to maxContactFindings
+	Findings []ContactLinkFinding `json:"findings"`
+}
+
+// ContactLinkFinding is a mailto: or tel: link visitors cannot use
+type ContactLinkFinding struct {
+	URL      string `json:"url"`
+	Code     string `json:"code"`
+	Severity string `json:"severity"`
+	Message  string `json:"message"`
+}
+
+// auditContactLinks checks the syntax of the page's mailto: and tel: links. Malformed ones are
+// reported with SeverityInfo: they break one click, not the page.
+func auditContactLinks(doc *goquery.Document) ContactLinks {
+	c := ContactLinks{Findings: []ContactLinkFinding{}}
+	seen := make(map[string]bool)
+	add := func(href, code, message string) {
+		if seen[href] || len(c.Findings) >= maxContactFindings {
+			return
+		}
+		seen[href] = true
+		c.Findings = append(c.Findings, ContactLinkFinding{URL: truncateURL(href), Code: code, Severity: SeverityInfo, Message: message})
+	}
+
+	doc.Find("a[href], area[href]").Each(func(_ int, s *goquery.Selection) {
+		href := strings.TrimSpace(s.AttrOr("href", ""))
+		scheme := strings.ToLower(href)
+		switch {
+		case strings.HasPrefix(scheme, "mailto:"):
+			c.Mailto++
+			if problem := mailtoProblem(href[len("mailto:"):]); problem != "" {
+				add(href, ContactInvalidEmail, problem)
+			}
+		case strings.HasPrefix(scheme, "tel:"):
+			c.Tel++
+			if problem := telProblem(href[len("tel:"):]); problem != "" {
+				add(href, ContactInvalidPhone, problem)
+			}
+		}
+	})
+	return c
+}
+
+// mailtoProblem describes what is wrong with the part of a mailto: link after the scheme, or returns
+// "" when every address is valid. Addresses may be comma-separated and may also come from a to= field.
+func mailtoProblem(value string) string {
+	to, query, _ := strings.Cut(value, "?")
+	var addresses []string
+	if to != "" {
+		addresses = append(addresses, strings.Split(to, ",")...)
+	}
+	if fields, err := url.ParseQuery(query); err == nil {
+		for _, extra := range fields["to"] {
+			addresses = append(addresses, strings.Split(extra, ",")...)
+		}
+	}
+	if len(addresses) == 0 {
+		return "mailto link has no address"
+	}
+
+	for _, address := range addresses {
+		address, err := url.PathUnescape(strings.TrimSpace(address))
+		if err != nil {
+			return "mailto link has invalid percent-encoding"
+		}
+		if problem := emailProblem(address); problem != "" {
+			return problem
+		}
+	}
+	return ""
+}
+
+// emailProblem describes why address is not a plain RFC 5322 address on a domain with a dot
+func emailProblem(address string) string {
+	if address == "" {
+		return "mailto link has an empty address"
+	}
+	parsed, err := mail.ParseAddress(address)
+	// A display name or angle brackets parse too, but mailto: only allows the bare address
+	if err != nil || parsed.Address != address {
+		return fmt.Sprintf("%q is not a valid email address", address)
+	}
+	domain := address[strings.LastIndex(address, "@")+1:]
+	if !strings.Contains(domain, ".") || strings.HasPrefix(domain, ".") || strings.HasSuffix(domain, ".") {
+		return fmt.Sprintf("%q has no valid domain", address)
+	}
+	return ""
+}
+
+// telProblem describes what is wrong with the part of a tel: link after the scheme, or returns "" for
+// a plausible number. Parameters such as ;ext=123 are ignored.
+func telProblem(value string) string {
+	number, _, _ := strings.Cut(value, ";")
+	number, err := url.PathUnescape(number)
+	if err != nil {
+		return "tel link has invalid percent-encoding"
+	}
+	number = strings.TrimSpace(number)
+	if number == "" {
+		return "tel link has no number"
+	}
+	if !phoneNumber.MatchString(phoneSeparators.Replace(number)) {
+		return fmt.Sprintf("%q is not a plausible phone number", number)
+	}
+	return ""
+}
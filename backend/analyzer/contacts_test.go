@@ -0,0 +1,48 @@
+package analyzer
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuditContactLinks(t *testing.T) {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(`<html><body>
+		<a href="mailto:info@example.com">Mail</a>
+		<a href="MAILTO:sales@example.com,support@example.co.uk?subject=Hello%20there">Sales</a>
+		<a href="mailto:?to=jobs@example.com">Jobs</a>
+		<a href="mailto:first%2Blast@example.com">Encoded</a>
+		<a href="mailto:info@example">No TLD</a>
+		<a href="mailto:info@example">No TLD again</a>
+		<a href="mailto:John Doe <john@example.com>">Display name</a>
+		<a href="mailto:">Empty</a>
+		<a href="tel:+1-555-010-9999">Call</a>
+		<a href="tel:+44 (0)20 7946 0000;ext=12">London</a>
+		<a href="tel:0301234567">Local</a>
+		<a href="tel:call-us">Words</a>
+		<a href="tel:12">Short</a>
+		<a href="tel:">Nothing</a>
+	</body></html>`))
+	require.NoError(t, err)
+
+	c := auditContactLinks(doc)
+
+	t.Run("counts every contact link", func(t *testing.T) {
+		assert.Equal(t, 8, c.Mailto)
+		assert.Equal(t, 6, c.Tel)
+	})
+
+	t.Run("reports each malformed link once", func(t *testing.T) {
+		assert.Equal(t, []ContactLinkFinding{
+			{URL: "mailto:info@example", Code: ContactInvalidEmail, Severity: SeverityInfo, Message: `"info@example" has no valid domain`},
+			{URL: "mailto:John Doe <john@example.com>", Code: ContactInvalidEmail, Severity: SeverityInfo, Message: `"John Doe <john@example.com>" is not a valid email address`},
+			{URL: "mailto:", Code: ContactInvalidEmail, Severity: SeverityInfo, Message: "mailto link has no address"},
+			{URL: "tel:call-us", Code: ContactInvalidPhone, Severity: SeverityInfo, Message: `"call-us" is not a plausible phone number`},
+			{URL: "tel:12", Code: ContactInvalidPhone, Severity: SeverityInfo, Message: `"12" is not a plausible phone number`},
+			{URL: "tel:", Code: ContactInvalidPhone, Severity: SeverityInfo, Message: "tel link has no number"},
+		}, c.Findings)
+	})
+}
@@ -0,0 +1,87 @@
+package analyzer
+
+import (
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"golang.org/x/net/html"
+)
+
+// maxContentBytes caps Result.Content; longer pages are cut at a line boundary
+const maxContentBytes = 256 << 10
+
+// blockElements start a new line of Result.Content
+var blockElements = map[string]bool{
+	"address": true, "article": true, "aside": true, "blockquote": true, "br": true, "dd": true,
+	"details": true, "div": true, "dl": true, "dt": true, "fieldset": true, "figcaption": true,
+	"figure": true, "footer": true, "form": true, "h1": true, "h2": true, "h3": true, "h4": true,
+	"h5": true, "h6": true, "header": true, "hr": true, "li": true, "main": true, "nav": true,
+	"ol": true, "option": true, "p": true, "pre": true, "section": true, "summary": true,
+	"table": true, "td": true, "th": true, "tr": true, "ul": true,
+}
+
+// normalizedText returns the visible text of the page body with one line per block element and
+// whitespace collapsed, so that markup and formatting changes do not count as content changes
+func normalizedText(doc *goquery.Document) string {
+	var text textLines
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode {
+			switch n.Data {
+			case "script", "style", "noscript", "template":
+				return
+			}
+			if blockElements[n.Data] {
+				text.flush()
+				defer text.flush()
+			}
+		}
+		if n.Type == html.TextNode {
+			text.write(n.Data)
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	for _, n := range doc.Find("body").Nodes {
+		walk(n)
+	}
+	return text.String()
+}
+
+// textLines builds Result.Content one block element at a time
+type textLines struct {
+	lines []string
+	line  strings.Builder
+	size  int
+}
+
+// write adds text to the current line
+func (t *textLines) write(text string) {
+	if t.size >= maxContentBytes {
+		return
+	}
+	t.line.WriteString(text)
+	t.line.WriteByte(' ')
+}
+
+// flush ends the current line, dropping it when it is blank or would exceed maxContentBytes
+func (t *textLines) flush() {
+	text := strings.Join(strings.Fields(t.line.String()), " ")
+	t.line.Reset()
+	if text == "" {
+		return
+	}
+	if t.size+len(text)+1 > maxContentBytes {
+		t.size = maxContentBytes // keep later lines out too
+		return
+	}
+	t.lines = append(t.lines, text)
+	t.size += len(text) + 1
+}
+
+// String ends the current line and returns the text
+func (t *textLines) String() string {
+	t.flush()
+	return strings.Join(t.lines, "\n")
+}
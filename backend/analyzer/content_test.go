@@ -0,0 +1,43 @@
+package analyzer
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizedText(t *testing.T) {
+	parse := func(html string) *goquery.Document {
+		doc, err := goquery.NewDocumentFromReader(strings.NewReader(html))
+		require.NoError(t, err)
+		return doc
+	}
+
+	t.Run("one line per block element", func(t *testing.T) {
+		doc := parse(`<html><head><title>Ignored</title></head><body>
+			<h1>Welcome   to <em>our</em> shop</h1>
+			<p>First<br>second</p>
+			<ul><li>One</li><li>Two</li></ul>
+			<script>var hidden = 1;</script><style>p { color: red }</style>
+			<div><span>Nested</span> <a href="/">link</a></div>
+		</body></html>`)
+		assert.Equal(t, "Welcome to our shop\nFirst\nsecond\nOne\nTwo\nNested link", normalizedText(doc))
+	})
+
+	t.Run("markup changes keep the text", func(t *testing.T) {
+		a := parse(`<body><p class="a">Same   text</p></body>`)
+		b := parse(`<body><div id="x"><p>Same text</p></div></body>`)
+		assert.Equal(t, normalizedText(a), normalizedText(b))
+	})
+
+	t.Run("capped", func(t *testing.T) {
+		line := strings.Repeat("x", 1000)
+		doc := parse("<body>" + strings.Repeat("<p>"+line+"</p>", 300) + "</body>")
+		text := normalizedText(doc)
+		assert.LessOrEqual(t, len(text), maxContentBytes)
+		assert.True(t, strings.HasSuffix(text, line))
+	})
+}
@@ -0,0 +1,142 @@
+package analyzer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"time"
+
+	"golang.org/x/net/publicsuffix"
+)
+
+// dnsTimeout bounds the DNS inspection so a slow name server cannot eat the link check budget
+const dnsTimeout = 5 * time.Second
+
+// Resolver is the subset of *net.Resolver used to inspect DNS records
+type Resolver interface {
+	LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error)
+	LookupCNAME(ctx context.Context, host string) (string, error)
+	LookupMX(ctx context.Context, name string) ([]*net.MX, error)
+	LookupTXT(ctx context.Context, name string) ([]string, error)
+}
+
+// DNS holds the DNS records of the page's host and of its registrable domain
+type DNS struct {
+	Host string `json:"host"`
+	// Domain is the registrable domain (e.g. example.co.uk), where mail records usually live
+	Domain string   `json:"domain"`
+	A      []string `json:"a"`
+	AAAA   []string `json:"aaaa"`
+	// CNAME is the canonical name of the host when it is an alias
+	CNAME string     `json:"cname,omitempty"`
+	MX    []MXRecord `json:"mx"`
+	TXT   []string   `json:"txt"`
+	// SPF and DMARC are the domain's sender policies, empty when missing
+	SPF   string `json:"spf,omitempty"`
+	DMARC string `json:"dmarc,omitempty"`
+	// ResolutionMs is how long resolving the host's addresses took
+	ResolutionMs float64 `json:"resolution_ms"`
+	// Findings are problems with the records, such as a missing DMARC policy
+	Findings []string `json:"findings"`
+	// Errors are lookups that failed for reasons other than the record not existing
+	Errors []string `json:"errors,omitempty"`
+}
+
+// MXRecord is a mail exchanger of the domain
+type MXRecord struct {
+	Host       string `json:"host"`
+	Preference uint16 `json:"preference"`
+}
+
+// inspectDNS looks up the records of host. It returns nil for IP address hosts, which have no records.
+func inspectDNS(ctx context.Context, resolver Resolver, host string) *DNS {
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	if net.ParseIP(host) != nil {
+		return nil
+	}
+	domain, err := publicsuffix.EffectiveTLDPlusOne(host)
+	if err != nil {
+		domain = host
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, dnsTimeout)
+	defer cancel()
+
+	d := &DNS{Host: host, Domain: domain, A: []string{}, AAAA: []string{}, MX: []MXRecord{}, TXT: []string{}, Findings: []string{}}
+	failed := func(lookup string, err error) bool {
+		var dnsErr *net.DNSError
+		if err == nil || (errors.As(err, &dnsErr) && dnsErr.IsNotFound) {
+			return false
+		}
+		d.Errors = append(d.Errors, fmt.Sprintf("%s lookup failed: %v", lookup, err))
+		return true
+	}
+
+	started := time.Now()
+	addrs, err := resolver.LookupIPAddr(ctx, host)
+	d.ResolutionMs = float64(time.Since(started).Microseconds()) / 1000
+	if !failed("A/AAAA", err) {
+		for _, addr := range addrs {
+			if addr.IP.To4() != nil {
+				d.A = append(d.A, addr.IP.String())
+			} else {
+				d.AAAA = append(d.AAAA, addr.IP.String())
+			}
+		}
+	}
+
+	if cname, err := resolver.LookupCNAME(ctx, host); !failed("CNAME", err) {
+		if cname = strings.TrimSuffix(strings.ToLower(cname), "."); cname != "" && cname != host {
+			d.CNAME = cname
+		}
+	}
+
+	if mxs, err := resolver.LookupMX(ctx, domain); !failed("MX", err) {
+		for _, mx := range mxs {
+			d.MX = append(d.MX, MXRecord{Host: strings.TrimSuffix(mx.Host, "."), Preference: mx.Pref})
+		}
+		sort.SliceStable(d.MX, func(i, j int) bool { return d.MX[i].Preference < d.MX[j].Preference })
+	}
+
+	txtFailed := false
+	if txts, err := resolver.LookupTXT(ctx, domain); !failed("TXT", err) {
+		d.TXT = append(d.TXT, txts...)
+		var spf []string
+		for _, txt := range txts {
+			if strings.HasPrefix(strings.ToLower(txt), "v=spf1") {
+				spf = append(spf, txt)
+			}
+		}
+		if len(spf) > 0 {
+			d.SPF = spf[0]
+		}
+		if len(spf) > 1 {
+			d.Findings = append(d.Findings, fmt.Sprintf("%s has %d SPF records; receivers treat more than one as an error", domain, len(spf)))
+		}
+	} else {
+		txtFailed = true
+	}
+
+	dmarcFailed := false
+	if txts, err := resolver.LookupTXT(ctx, "_dmarc."+domain); !failed("DMARC", err) {
+		for _, txt := range txts {
+			if strings.HasPrefix(strings.ToLower(txt), "v=dmarc1") {
+				d.DMARC = txt
+				break
+			}
+		}
+	} else {
+		dmarcFailed = true
+	}
+
+	if d.SPF == "" && !txtFailed {
+		d.Findings = append(d.Findings, fmt.Sprintf("%s has no SPF record, so anyone can send mail in its name", domain))
+	}
+	if d.DMARC == "" && !dmarcFailed {
+		d.Findings = append(d.Findings, fmt.Sprintf("%s has no DMARC policy", domain))
+	}
+	return d
+}
@@ -0,0 +1,117 @@
+package analyzer
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeResolver answers from fixed records; names without records are not found
+type fakeResolver struct {
+	addrs map[string][]string
+	cname map[string]string
+	mx    map[string][]*net.MX
+	txt   map[string][]string
+	err   error // returned by every TXT lookup when set
+}
+
+func notFound(name string) error {
+	return &net.DNSError{Err: "no such host", Name: name, IsNotFound: true}
+}
+
+func (f *fakeResolver) LookupIPAddr(_ context.Context, host string) ([]net.IPAddr, error) {
+	var addrs []net.IPAddr
+	for _, ip := range f.addrs[host] {
+		addrs = append(addrs, net.IPAddr{IP: net.ParseIP(ip)})
+	}
+	if len(addrs) == 0 {
+		return nil, notFound(host)
+	}
+	return addrs, nil
+}
+
+func (f *fakeResolver) LookupCNAME(_ context.Context, host string) (string, error) {
+	if cname, ok := f.cname[host]; ok {
+		return cname, nil
+	}
+	return host + ".", nil
+}
+
+func (f *fakeResolver) LookupMX(_ context.Context, name string) ([]*net.MX, error) {
+	if mx, ok := f.mx[name]; ok {
+		return mx, nil
+	}
+	return nil, notFound(name)
+}
+
+func (f *fakeResolver) LookupTXT(_ context.Context, name string) ([]string, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	if txt, ok := f.txt[name]; ok {
+		return txt, nil
+	}
+	return nil, notFound(name)
+}
+
+func TestInspectDNS(t *testing.T) {
+	resolver := &fakeResolver{
+		addrs: map[string][]string{"www.example.co.uk": {"93.184.216.34", "2606:2800:220:1::1"}},
+		cname: map[string]string{"www.example.co.uk": "example.cdn.net."},
+		mx: map[string][]*net.MX{"example.co.uk": {
+			{Host: "mx2.example.co.uk.", Pref: 20},
+			{Host: "mx1.example.co.uk.", Pref: 10},
+		}},
+		txt: map[string][]string{
+			"example.co.uk":        {"google-site-verification=abc", "v=spf1 include:_spf.google.com ~all"},
+			"_dmarc.example.co.uk": {"v=DMARC1; p=reject"},
+		},
+	}
+
+	t.Run("records of the host and its registrable domain", func(t *testing.T) {
+		d := inspectDNS(context.Background(), resolver, "WWW.Example.co.uk.")
+		require.NotNil(t, d)
+
+		assert.Equal(t, "www.example.co.uk", d.Host)
+		assert.Equal(t, "example.co.uk", d.Domain)
+		assert.Equal(t, []string{"93.184.216.34"}, d.A)
+		assert.Equal(t, []string{"2606:2800:220:1::1"}, d.AAAA)
+		assert.Equal(t, "example.cdn.net", d.CNAME)
+		assert.Equal(t, []MXRecord{{"mx1.example.co.uk", 10}, {"mx2.example.co.uk", 20}}, d.MX)
+		assert.Equal(t, "v=spf1 include:_spf.google.com ~all", d.SPF)
+		assert.Equal(t, "v=DMARC1; p=reject", d.DMARC)
+		assert.Empty(t, d.Findings)
+		assert.Empty(t, d.Errors)
+	})
+
+	t.Run("missing and duplicate policies are findings", func(t *testing.T) {
+		r := &fakeResolver{
+			addrs: map[string][]string{"shop.example": {"10.0.0.1"}},
+			txt:   map[string][]string{"shop.example": {"v=spf1 -all", "v=spf1 mx -all"}},
+		}
+		d := inspectDNS(context.Background(), r, "shop.example")
+		require.NotNil(t, d)
+
+		assert.Empty(t, d.CNAME)
+		assert.Len(t, d.Findings, 2)
+		assert.Contains(t, d.Findings[0], "2 SPF records")
+		assert.Contains(t, d.Findings[1], "no DMARC policy")
+	})
+
+	t.Run("failed lookups are errors, not findings", func(t *testing.T) {
+		r := &fakeResolver{addrs: map[string][]string{"example.com": {"10.0.0.1"}}, err: errors.New("i/o timeout")}
+		d := inspectDNS(context.Background(), r, "example.com")
+		require.NotNil(t, d)
+
+		assert.Len(t, d.Errors, 2)
+		assert.Empty(t, d.Findings)
+	})
+
+	t.Run("IP address hosts have no records", func(t *testing.T) {
+		assert.Nil(t, inspectDNS(context.Background(), resolver, "127.0.0.1"))
+	})
+}
@@ -0,0 +1,23 @@
+// Package analyzer fetches a web page and reports its structure: HTML version, title, headings,
+// internal and external links with their follow attributes, broken links, login forms, robots
+// directives and hreflang annotations, and optionally the origin's field Core Web Vitals. It has
+// no dependency on the web server, database or configuration of this module, so other Go
+// programs can import it directly:
+//
+//	a := analyzer.New(
+//		analyzer.WithPageTimeout(30*time.Second),
+//		analyzer.WithMaxConcurrentLinkChecks(5),
+//	)
+//	result, err := a.Analyze(ctx, "https://example.com")
+//
+// An Analyzer is safe for concurrent use. Failures to fetch or parse the page are returned as
+// *Error, whose Kind tells timeouts, DNS failures, refused connections, TLS problems and HTTP
+// error statuses apart.
+//
+// # Compatibility
+//
+// The exported API follows semantic versioning together with the backend module. Within a major
+// version, types, functions and options are only ever added. Fields are never removed or
+// renamed, and Result keeps its JSON field names. Error message texts are meant for people and
+// may change, so match on Error.Kind instead.
+package analyzer
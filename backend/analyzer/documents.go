@@ -0,0 +1,204 @@
+package analyzer
+
+import (
+	"context"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"sync"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// maxCheckedDocuments caps how many document links are checked per page
+const maxCheckedDocuments = 50
+
+// Codes of document findings
+const (
+	DocumentMislabeled = "content_type_mismatch" // the server does not answer with the document's media type
+	DocumentOversized  = "oversized"             // the document is larger than Options.MaxDocumentBytes
+)
+
+// documentTypes are the media types expected for each document extension. Servers may also answer
+// application/octet-stream, which makes browsers download the file.
+var documentTypes = map[string][]string{
+	"pdf":  {"application/pdf", "application/x-pdf"},
+	"doc":  {"application/msword"},
+	"docx": {"application/vnd.openxmlformats-officedocument.wordprocessingml.document"},
+	"xls":  {"application/vnd.ms-excel"},
+	"xlsx": {"application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"},
+	"ppt":  {"application/vnd.ms-powerpoint"},
+	"pptx": {"application/vnd.openxmlformats-officedocument.presentationml.presentation"},
+	"odt":  {"application/vnd.oasis.opendocument.text"},
+	"ods":  {"application/vnd.oasis.opendocument.spreadsheet"},
+	"odp":  {"application/vnd.oasis.opendocument.presentation"},
+	"rtf":  {"application/rtf", "text/rtf"},
+}
+
+// Documents counts the page's links to PDFs and office documents and lists the problems found with them
+type Documents struct {
+	// Linked is the number of distinct document links; Checked is how many were fetched, 0 unless
+	// Options.CheckDocuments is set
+	Linked   int               `json:"linked"`
+	Checked  int               `json:"checked"`
+	Findings []DocumentFinding `json:"findings"`
+}
+
+// DocumentFinding is a mislabeled or oversized document. Unreachable documents are broken links
+// and reported there.
+type DocumentFinding struct {
+	URL      string `json:"url"`
+	Code     string `json:"code"`
+	Severity string `json:"severity"`
+	// Type is the document's file extension, such as pdf or docx
+	Type        string `json:"type"`
+	ContentType string `json:"content_type,omitempty"`
+	// Bytes is the advertised Content-Length, 0 when unknown
+	Bytes   int64  `json:"bytes,omitempty"`
+	Message string `json:"message"`
+}
+
+// document is a link to a PDF or office document
+type document struct {
+	url  string
+	kind string
+}
+
+// auditDocuments counts the page's document links and, when Options.CheckDocuments is set, checks
+// the media type and size of those not matched by the exclusions with HEAD requests
+func (a *Analyzer) auditDocuments(ctx context.Context, doc *goquery.Document, base *url.URL) Documents {
+	documents := collectDocuments(doc, base, a.opts)
+	d := Documents{Linked: len(documents), Findings: []DocumentFinding{}}
+	if !a.opts.CheckDocuments {
+		return d
+	}
+	if a.opts.Exclusions != nil {
+		var included []document
+		for _, document := range documents {
+			if !a.opts.Exclusions.Matches(document.url) {
+				included = append(included, document)
+			}
+		}
+		documents = included
+	}
+	if len(documents) > maxCheckedDocuments {
+		documents = documents[:maxCheckedDocuments]
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	client := a.client(a.opts.LinkCheckTimeout)
+	semaphore := make(chan struct{}, a.opts.MaxConcurrentLinkChecks)
+	findings := make([][]DocumentFinding, len(documents))
+	for i, document := range documents {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			select {
+			case semaphore <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			defer func() { <-semaphore }()
+			checked, problems := checkDocument(ctx, client, document, a.opts)
+			mu.Lock()
+			if checked {
+				d.Checked++
+			}
+			mu.Unlock()
+			findings[i] = problems
+		}()
+	}
+	wg.Wait()
+
+	for _, problems := range findings {
+		d.Findings = append(d.Findings, problems...)
+	}
+	return d
+}
+
+// collectDocuments lists the distinct http(s) links whose path ends in a document extension, in
+// document order and in canonical form
+func collectDocuments(doc *goquery.Document, base *url.URL, opts Options) []document {
+	var documents []document
+	seen := make(map[string]bool)
+	doc.Find("a[href]").Each(func(_ int, s *goquery.Selection) {
+		link, err := url.Parse(strings.TrimSpace(s.AttrOr("href", "")))
+		if err != nil {
+			return
+		}
+		resolved := canonicalURL(base.ResolveReference(link), opts)
+		if resolved.Scheme != "http" && resolved.Scheme != "https" {
+			return
+		}
+		kind := strings.TrimPrefix(strings.ToLower(path.Ext(resolved.Path)), ".")
+		if _, ok := documentTypes[kind]; !ok {
+			return
+		}
+		if target := resolved.String(); !seen[target] {
+			seen[target] = true
+			documents = append(documents, document{url: target, kind: kind})
+		}
+	})
+	return documents
+}
+
+// checkDocument fetches the headers of a document and reports a media type that does not match its
+// extension and a size above the limit. It reports whether a response arrived.
+func checkDocument(ctx context.Context, client *http.Client, d document, opts Options) (bool, []DocumentFinding) {
+	req, err := http.NewRequestWithContext(ctx, "HEAD", d.url, nil)
+	if err != nil {
+		return false, nil
+	}
+	req.Header.Set("User-Agent", opts.UserAgent)
+	req.Header.Set("Accept", "*/*")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return false, nil
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 400 {
+		return true, nil
+	}
+
+	var findings []DocumentFinding
+	contentType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if contentType != "" && !documentTypeMatches(d.kind, contentType) {
+		severity := SeverityWarning
+		message := fmt.Sprintf("%s document is served as %s", strings.ToUpper(d.kind), contentType)
+		// An HTML page in place of a document is usually an error page or a login wall
+		if contentType == "text/html" {
+			severity = SeverityError
+			message = fmt.Sprintf("%s link leads to an HTML page instead of the document", strings.ToUpper(d.kind))
+		}
+		findings = append(findings, DocumentFinding{
+			URL: d.url, Code: DocumentMislabeled, Severity: severity, Type: d.kind, ContentType: contentType, Message: message,
+		})
+	}
+	if resp.ContentLength > opts.MaxDocumentBytes {
+		findings = append(findings, DocumentFinding{
+			URL: d.url, Code: DocumentOversized, Severity: SeverityWarning, Type: d.kind, ContentType: contentType,
+			Bytes: resp.ContentLength,
+			Message: fmt.Sprintf("%s document is %s, above the %s limit", strings.ToUpper(d.kind),
+				formatBytes(resp.ContentLength), formatBytes(opts.MaxDocumentBytes)),
+		})
+	}
+	return true, findings
+}
+
+// documentTypeMatches reports whether contentType is acceptable for a document with the extension
+func documentTypeMatches(kind, contentType string) bool {
+	if contentType == "application/octet-stream" || contentType == "binary/octet-stream" {
+		return true
+	}
+	for _, expected := range documentTypes[kind] {
+		if contentType == expected {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,75 @@
+package analyzer
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuditDocuments(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/":
+			w.Header().Set("Content-Type", "text/html")
+			w.Write([]byte(`<html><body>
+				<a href="/report.pdf">Report</a><a href="/report.pdf#page=2">Page 2</a>
+				<a href="/brochure.PDF">Brochure</a><a href="/login-wall.pdf">Members</a>
+				<a href="/sheet.xlsx">Sheet</a><a href="/download.docx">Download</a>
+				<a href="/missing.pdf">Missing</a><a href="/page.html">Page</a>
+				</body></html>`))
+		case "/report.pdf":
+			w.Header().Set("Content-Type", "application/pdf")
+			w.Header().Set("Content-Length", "2048")
+		case "/brochure.PDF":
+			w.Header().Set("Content-Type", "application/pdf")
+			w.Header().Set("Content-Length", "52428800")
+		case "/login-wall.pdf":
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		case "/sheet.xlsx":
+			w.Header().Set("Content-Type", "application/zip")
+		case "/download.docx":
+			w.Header().Set("Content-Type", "application/octet-stream")
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	t.Run("documents are counted without being checked by default", func(t *testing.T) {
+		result, err := Analyze(context.Background(), server.URL)
+		require.NoError(t, err)
+		assert.Equal(t, 6, result.Documents.Linked)
+		assert.Zero(t, result.Documents.Checked)
+		assert.Empty(t, result.Documents.Findings)
+	})
+
+	t.Run("mislabeled and oversized documents are findings", func(t *testing.T) {
+		result, err := Analyze(context.Background(), server.URL, WithCheckDocuments(true))
+		require.NoError(t, err)
+		assert.Equal(t, 6, result.Documents.Checked)
+		assert.Equal(t, []DocumentFinding{
+			{URL: server.URL + "/brochure.PDF", Code: DocumentOversized, Severity: SeverityWarning, Type: "pdf",
+				ContentType: "application/pdf", Bytes: 52428800, Message: "PDF document is 50.0 MB, above the 10.0 MB limit"},
+			{URL: server.URL + "/login-wall.pdf", Code: DocumentMislabeled, Severity: SeverityError, Type: "pdf",
+				ContentType: "text/html", Message: "PDF link leads to an HTML page instead of the document"},
+			{URL: server.URL + "/sheet.xlsx", Code: DocumentMislabeled, Severity: SeverityWarning, Type: "xlsx",
+				ContentType: "application/zip", Message: "XLSX document is served as application/zip"},
+		}, result.Documents.Findings)
+	})
+
+	t.Run("the size limit is configurable", func(t *testing.T) {
+		result, err := Analyze(context.Background(), server.URL, WithCheckDocuments(true), WithMaxDocumentBytes(1024))
+		require.NoError(t, err)
+		var oversized []string
+		for _, f := range result.Documents.Findings {
+			if f.Code == DocumentOversized {
+				oversized = append(oversized, f.URL)
+			}
+		}
+		assert.Equal(t, []string{server.URL + "/report.pdf", server.URL + "/brochure.PDF"}, oversized)
+	})
+}
@@ -0,0 +1,48 @@
+package analyzer
+
+import (
+	"net/url"
+	"strings"
+)
+
+// linkScope decides which links count as internal: links to the page's own host, plus those
+// allowed by Options.TreatSubdomainsAsInternal and Options.InternalDomains
+type linkScope struct {
+	host string
+	// domain is the page's registrable domain when subdomains count as internal, empty otherwise
+	domain  string
+	domains []string
+}
+
+// newLinkScope builds the scope of a page at base
+func newLinkScope(base *url.URL, opts Options) linkScope {
+	scope := linkScope{host: strings.ToLower(base.Host)}
+	if opts.TreatSubdomainsAsInternal {
+		scope.domain = registrableDomain(base.Hostname())
+	}
+	for _, domain := range opts.InternalDomains {
+		domain = strings.TrimPrefix(strings.ToLower(strings.TrimSpace(domain)), "*.")
+		domain = strings.Trim(domain, ".")
+		if domain != "" {
+			scope.domains = append(scope.domains, domain)
+		}
+	}
+	return scope
+}
+
+// isInternal reports whether a link to u stays within the site
+func (s linkScope) isInternal(u *url.URL) bool {
+	if u.Host == s.host {
+		return true
+	}
+	host := strings.TrimSuffix(strings.ToLower(u.Hostname()), ".")
+	if s.domain != "" && registrableDomain(host) == s.domain {
+		return true
+	}
+	for _, domain := range s.domains {
+		if host == domain || strings.HasSuffix(host, "."+domain) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,47 @@
+package analyzer
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLinkScope(t *testing.T) {
+	base, _ := url.Parse("https://www.example.com/")
+	link := func(raw string) *url.URL {
+		u, _ := url.Parse(raw)
+		return u
+	}
+
+	t.Run("only the same host by default", func(t *testing.T) {
+		scope := newLinkScope(base, Options{})
+		assert.True(t, scope.isInternal(link("https://www.example.com/about")))
+		assert.False(t, scope.isInternal(link("https://blog.example.com/")))
+		assert.False(t, scope.isInternal(link("https://example.com/")))
+	})
+
+	t.Run("subdomains of the registrable domain", func(t *testing.T) {
+		scope := newLinkScope(base, Options{TreatSubdomainsAsInternal: true})
+		assert.True(t, scope.isInternal(link("https://blog.example.com/")))
+		assert.True(t, scope.isInternal(link("https://example.com/")))
+		assert.True(t, scope.isInternal(link("https://APP.Example.com:8443/")))
+		assert.False(t, scope.isInternal(link("https://example.org/")))
+		assert.False(t, scope.isInternal(link("https://notexample.com/")))
+	})
+
+	t.Run("public suffixes are not one site", func(t *testing.T) {
+		pages, _ := url.Parse("https://alice.github.io/")
+		scope := newLinkScope(pages, Options{TreatSubdomainsAsInternal: true})
+		assert.False(t, scope.isInternal(link("https://bob.github.io/")))
+	})
+
+	t.Run("internal domains and their subdomains", func(t *testing.T) {
+		scope := newLinkScope(base, Options{InternalDomains: []string{" Example-CDN.net ", "*.shop.example.org", ""}})
+		assert.True(t, scope.isInternal(link("https://example-cdn.net/a.css")))
+		assert.True(t, scope.isInternal(link("https://static.example-cdn.net/")))
+		assert.True(t, scope.isInternal(link("https://eu.shop.example.org/")))
+		assert.False(t, scope.isInternal(link("https://example.org/")))
+		assert.False(t, scope.isInternal(link("https://blog.example.com/")))
+	})
+}
@@ -0,0 +1,109 @@
+package analyzer
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// maxEmbeds caps how many iframes and embedded objects are listed per page
+const maxEmbeds = 50
+
+// heavyEmbed recognizes a third-party widget that downloads far more than its visible size suggests
+type heavyEmbed struct {
+	name       string
+	hosts      []string // registrable domains or hosts the widget is served from
+	pathPrefix string   // required path prefix, for hosts serving other content too
+}
+
+// heavyEmbeds are third-party widgets worth loading lazily or behind a click-to-load placeholder
+var heavyEmbeds = []heavyEmbed{
+	{name: "YouTube", hosts: []string{"youtube.com", "youtube-nocookie.com"}},
+	{name: "Vimeo", hosts: []string{"vimeo.com"}},
+	{name: "Google Maps", hosts: []string{"maps.google.com", "maps.googleapis.com"}},
+	{name: "Google Maps", hosts: []string{"google.com"}, pathPrefix: "/maps"},
+	{name: "Facebook", hosts: []string{"facebook.com"}, pathPrefix: "/plugins"},
+	{name: "X (Twitter)", hosts: []string{"twitter.com", "x.com"}},
+	{name: "Instagram", hosts: []string{"instagram.com"}},
+	{name: "Spotify", hosts: []string{"open.spotify.com"}},
+}
+
+// Embed is an iframe, embed or object element of the page
+type Embed struct {
+	// Tag is iframe, embed or object
+	Tag string `json:"tag"`
+	// URL is the absolute source; it is empty for an iframe with inline srcdoc content
+	URL   string `json:"url"`
+	Title string `json:"title,omitempty"`
+	// Lazy is true when the element has loading="lazy"
+	Lazy bool `json:"lazy"`
+	// Insecure is true when an https page embeds a plain http source, which browsers block
+	Insecure bool `json:"insecure"`
+	// Provider names a known heavy third-party widget, such as YouTube or Google Maps
+	Provider string `json:"provider,omitempty"`
+}
+
+// auditEmbeds lists the page's iframes and embedded objects and returns findings for insecure
+// sources, iframes without a title and heavy third-party widgets loaded eagerly. page is the URL
+// the page was served from.
+func auditEmbeds(doc *goquery.Document, page *url.URL) ([]Embed, []string) {
+	embeds := []Embed{}
+	var findings []string
+	doc.Find("iframe, embed, object").EachWithBreak(func(_ int, s *goquery.Selection) bool {
+		tag := goquery.NodeName(s)
+		source := "src"
+		if tag == "object" {
+			source = "data"
+		}
+		ref := strings.TrimSpace(s.AttrOr(source, ""))
+		if ref == "" && (tag != "iframe" || s.AttrOr("srcdoc", "") == "") {
+			return true
+		}
+
+		embed := Embed{
+			Tag:   tag,
+			Title: strings.TrimSpace(s.AttrOr("title", "")),
+			Lazy:  strings.EqualFold(s.AttrOr("loading", ""), "lazy"),
+		}
+		name := fmt.Sprintf("%s with inline content", tag)
+		if ref != "" {
+			link, err := url.Parse(ref)
+			if err != nil {
+				return true
+			}
+			resolved := page.ResolveReference(link)
+			embed.URL = resolved.String()
+			embed.Insecure = page.Scheme == "https" && resolved.Scheme == "http"
+			embed.Provider = embedProvider(resolved)
+			name = fmt.Sprintf("%s %s", tag, truncateURL(embed.URL))
+		}
+
+		if embed.Insecure {
+			findings = append(findings, fmt.Sprintf("%s is loaded over http on an https page; browsers block it, serve it over https", name))
+		}
+		if tag == "iframe" && embed.Title == "" {
+			findings = append(findings, fmt.Sprintf("%s has no title; add one so screen readers can describe it", name))
+		}
+		if embed.Provider != "" && !embed.Lazy {
+			findings = append(findings, fmt.Sprintf("%s embeds %s, which downloads far more than it shows; add loading=\"lazy\" or load it on click", name, embed.Provider))
+		}
+		embeds = append(embeds, embed)
+		return len(embeds) < maxEmbeds
+	})
+	return embeds, findings
+}
+
+// embedProvider names the heavy third-party widget served from u, or returns ""
+func embedProvider(u *url.URL) string {
+	host := strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+	for _, widget := range heavyEmbeds {
+		for _, domain := range widget.hosts {
+			if (host == domain || strings.HasSuffix(host, "."+domain)) && strings.HasPrefix(u.Path, widget.pathPrefix) {
+				return widget.name
+			}
+		}
+	}
+	return ""
+}
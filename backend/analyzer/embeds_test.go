@@ -0,0 +1,50 @@
+package analyzer
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuditEmbeds(t *testing.T) {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(`<html><body>
+		<iframe src="https://www.youtube.com/embed/abc" title="Product video"></iframe>
+		<iframe src="https://www.google.com/maps/embed?pb=1" title="Map" loading="lazy"></iframe>
+		<iframe src="http://widgets.example.net/chat"></iframe>
+		<iframe srcdoc="<p>Hello</p>" title="Greeting"></iframe>
+		<iframe></iframe>
+		<embed src="/media/intro.swf"><object data="/docs/terms.pdf"></object>
+	</body></html>`))
+	require.NoError(t, err)
+	page, _ := url.Parse("https://example.com/")
+
+	embeds, findings := auditEmbeds(doc, page)
+
+	t.Run("lists every embed with a source", func(t *testing.T) {
+		assert.Equal(t, []Embed{
+			{Tag: "iframe", URL: "https://www.youtube.com/embed/abc", Title: "Product video", Provider: "YouTube"},
+			{Tag: "iframe", URL: "https://www.google.com/maps/embed?pb=1", Title: "Map", Lazy: true, Provider: "Google Maps"},
+			{Tag: "iframe", URL: "http://widgets.example.net/chat", Insecure: true},
+			{Tag: "iframe", Title: "Greeting"},
+			{Tag: "embed", URL: "https://example.com/media/intro.swf"},
+			{Tag: "object", URL: "https://example.com/docs/terms.pdf"},
+		}, embeds)
+	})
+
+	t.Run("flags insecure, untitled and eager heavy embeds", func(t *testing.T) {
+		assert.Equal(t, []string{
+			`iframe https://www.youtube.com/embed/abc embeds YouTube, which downloads far more than it shows; add loading="lazy" or load it on click`,
+			"iframe http://widgets.example.net/chat is loaded over http on an https page; browsers block it, serve it over https",
+			"iframe http://widgets.example.net/chat has no title; add one so screen readers can describe it",
+		}, findings)
+	})
+
+	t.Run("google.com outside maps is not a widget", func(t *testing.T) {
+		search, _ := url.Parse("https://www.google.com/search?q=x")
+		assert.Empty(t, embedProvider(search))
+	})
+}
@@ -0,0 +1,77 @@
+package analyzer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ErrorKind classifies why a page could not be analyzed
+type ErrorKind string
+
+const (
+	ErrorInvalidURL        ErrorKind = "invalid_url"
+	ErrorTimeout           ErrorKind = "timeout"
+	ErrorCanceled          ErrorKind = "canceled"
+	ErrorHostNotFound      ErrorKind = "host_not_found"
+	ErrorConnectionRefused ErrorKind = "connection_refused"
+	ErrorTLS               ErrorKind = "tls"
+	ErrorNetwork           ErrorKind = "network"
+	ErrorHTTPStatus        ErrorKind = "http_status"
+	ErrorParse             ErrorKind = "parse"
+	// ErrorNotAllowed is returned when Options.AllowTarget rejects the page or a redirect, or
+	// the transport refuses its address (ErrPrivateAddress)
+	ErrorNotAllowed ErrorKind = "not_allowed"
+)
+
+// Error reports a page that could not be fetched or parsed
+type Error struct {
+	Kind ErrorKind
+	URL  string
+	// StatusCode is set for ErrorHTTPStatus
+	StatusCode int
+	// Err is the underlying error, if any
+	Err error
+
+	message string
+}
+
+func (e *Error) Error() string {
+	return e.message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// fetchError classifies a failed page request
+func fetchError(ctx context.Context, target string, requestTimeout time.Duration, err error) *Error {
+	e := &Error{URL: target, Err: err}
+	msg := err.Error()
+	switch {
+	case errors.Is(ctx.Err(), context.Canceled):
+		e.Kind = ErrorCanceled
+		e.message = fmt.Sprintf("analysis cancelled: %s", target)
+	case errors.Is(err, ErrPrivateAddress):
+		e.Kind = ErrorNotAllowed
+		e.message = fmt.Sprintf("crawl target not allowed: %s resolves to a private network address", target)
+	case strings.Contains(msg, "context deadline exceeded"):
+		e.Kind = ErrorTimeout
+		e.message = fmt.Sprintf("website timeout: %s took too long to respond (>%s)", target, requestTimeout)
+	case strings.Contains(msg, "no such host"):
+		e.Kind = ErrorHostNotFound
+		e.message = fmt.Sprintf("website not found: %s does not exist", target)
+	case strings.Contains(msg, "connection refused"):
+		e.Kind = ErrorConnectionRefused
+		e.message = fmt.Sprintf("connection refused: %s is not accepting connections", target)
+	case strings.Contains(msg, "certificate"):
+		e.Kind = ErrorTLS
+		e.message = fmt.Sprintf("SSL certificate error: %s has invalid certificate", target)
+	default:
+		e.Kind = ErrorNetwork
+		e.message = fmt.Sprintf("network error: %v", err)
+	}
+	return e
+}
@@ -0,0 +1,30 @@
+package analyzer_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"sykell-analyze/backend/analyzer"
+)
+
+func Example() {
+	a := analyzer.New(
+		analyzer.WithPageTimeout(30*time.Second),
+		analyzer.WithMaxConcurrentLinkChecks(5),
+	)
+
+	result, err := a.Analyze(context.Background(), "https://example.com")
+	var analyzeErr *analyzer.Error
+	if errors.As(err, &analyzeErr) && analyzeErr.Kind == analyzer.ErrorHostNotFound {
+		fmt.Println("no such site")
+		return
+	} else if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	fmt.Printf("%s: %d internal, %d external, %d broken links\n",
+		result.Title, result.Links.Internal, result.Links.External, len(result.BrokenLinks))
+}
@@ -0,0 +1,83 @@
+package analyzer
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// LinkExclusion is a user-defined pattern for links that should never be checked
+type LinkExclusion struct {
+	Pattern     string
+	PatternType string // "glob" or "regex"
+}
+
+// LinkExcluder matches links against a compiled set of exclusion patterns
+type LinkExcluder struct {
+	patterns []*regexp.Regexp
+}
+
+// globToRegexp converts a glob (* and ? wildcards) into an anchored regular expression
+func globToRegexp(glob string) string {
+	var sb strings.Builder
+	sb.WriteString("^")
+	for _, r := range glob {
+		switch r {
+		case '*':
+			sb.WriteString(".*")
+		case '?':
+			sb.WriteString(".")
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	sb.WriteString("$")
+	return sb.String()
+}
+
+// CompileLinkExclusion validates and compiles a single exclusion pattern
+func CompileLinkExclusion(exclusion LinkExclusion) (*regexp.Regexp, error) {
+	pattern := strings.TrimSpace(exclusion.Pattern)
+	if pattern == "" {
+		return nil, fmt.Errorf("pattern is required")
+	}
+
+	switch exclusion.PatternType {
+	case "", "glob":
+		return regexp.Compile(globToRegexp(pattern))
+	case "regex":
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid regular expression: %v", err)
+		}
+		return re, nil
+	default:
+		return nil, fmt.Errorf("unknown pattern type %q (expected glob or regex)", exclusion.PatternType)
+	}
+}
+
+// NewLinkExcluder compiles exclusion patterns, failing on the first invalid one
+func NewLinkExcluder(exclusions []LinkExclusion) (*LinkExcluder, error) {
+	excluder := &LinkExcluder{}
+	for _, exclusion := range exclusions {
+		re, err := CompileLinkExclusion(exclusion)
+		if err != nil {
+			return nil, fmt.Errorf("exclusion %q: %v", exclusion.Pattern, err)
+		}
+		excluder.patterns = append(excluder.patterns, re)
+	}
+	return excluder, nil
+}
+
+// Matches reports whether the link matches any exclusion pattern
+func (e *LinkExcluder) Matches(link string) bool {
+	if e == nil {
+		return false
+	}
+	for _, re := range e.patterns {
+		if re.MatchString(link) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,63 @@
+package analyzer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLinkExcluder(t *testing.T) {
+	excluder, err := NewLinkExcluder([]LinkExclusion{
+		{Pattern: "*/logout*", PatternType: "glob"},
+		{Pattern: `[?&]utm_source=`, PatternType: "regex"},
+		{Pattern: "https://paywalled.example/*"},
+	})
+	assert.NoError(t, err)
+
+	testCases := []struct {
+		link     string
+		excluded bool
+	}{
+		{link: "https://example.com/logout", excluded: true},
+		{link: "https://example.com/account/logout?next=/", excluded: true},
+		{link: "https://example.com/page?utm_source=newsletter", excluded: true},
+		{link: "https://paywalled.example/article/1", excluded: true},
+		{link: "https://example.com/login", excluded: false},
+		{link: "https://paywalled.example.org/article", excluded: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.link, func(t *testing.T) {
+			assert.Equal(t, tc.excluded, excluder.Matches(tc.link))
+		})
+	}
+}
+
+func TestCompileLinkExclusion(t *testing.T) {
+	t.Run("glob special characters are literal", func(t *testing.T) {
+		re, err := CompileLinkExclusion(LinkExclusion{Pattern: "https://example.com/a+b", PatternType: "glob"})
+		assert.NoError(t, err)
+		assert.True(t, re.MatchString("https://example.com/a+b"))
+		assert.False(t, re.MatchString("https://example.com/aab"))
+	})
+
+	t.Run("invalid regex", func(t *testing.T) {
+		_, err := CompileLinkExclusion(LinkExclusion{Pattern: "(unclosed", PatternType: "regex"})
+		assert.Error(t, err)
+	})
+
+	t.Run("empty pattern", func(t *testing.T) {
+		_, err := CompileLinkExclusion(LinkExclusion{Pattern: "  "})
+		assert.Error(t, err)
+	})
+
+	t.Run("unknown type", func(t *testing.T) {
+		_, err := CompileLinkExclusion(LinkExclusion{Pattern: "*", PatternType: "xpath"})
+		assert.Error(t, err)
+	})
+}
+
+func TestNilLinkExcluder(t *testing.T) {
+	var excluder *LinkExcluder
+	assert.False(t, excluder.Matches("https://example.com"))
+}
@@ -0,0 +1,89 @@
+package analyzer
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// maxForms caps how many forms are listed per page
+const maxForms = 50
+
+// Codes of form findings
+const (
+	FormMissingCSRFToken = "missing_csrf_token" // a POST form without a hidden field that looks like an anti-CSRF token
+	FormInsecureAction   = "insecure_action"    // a form on an https page submits over plain http
+)
+
+// csrfFieldName matches the names frameworks give their anti-CSRF token fields, such as
+// csrf_token, _csrf, authenticity_token, __RequestVerificationToken or _token
+var csrfFieldName = regexp.MustCompile(`(?i)(csrf|xsrf|authenticity_token|verificationtoken|^_?token$|nonce)`)
+
+// Form is one <form> of the page
+type Form struct {
+	// Method is GET or POST, upper-cased; forms without a method submit with GET
+	Method string `json:"method"`
+	// Action is the absolute URL the form submits to; forms without an action submit to the page
+	Action string `json:"action"`
+	// Inputs counts the fields a visitor fills in: inputs other than hidden ones and buttons,
+	// selects and text areas
+	Inputs       int  `json:"inputs"`
+	HiddenInputs int  `json:"hidden_inputs"`
+	HasPassword  bool `json:"has_password"`
+	// HasCSRFToken is a heuristic: a hidden field is named like an anti-CSRF token
+	HasCSRFToken bool     `json:"has_csrf_token"`
+	Findings     []string `json:"findings"`
+}
+
+// inventoryForms lists the page's forms with their fields and flags POST forms without an anti-CSRF
+// token and forms submitting over http from an https page. page is the URL the page was served from.
+func inventoryForms(doc *goquery.Document, page *url.URL) []Form {
+	forms := []Form{}
+	doc.Find("form").EachWithBreak(func(_ int, s *goquery.Selection) bool {
+		form := Form{
+			Method:   strings.ToUpper(strings.TrimSpace(s.AttrOr("method", ""))),
+			Action:   page.String(),
+			Findings: []string{},
+		}
+		if form.Method != "POST" {
+			form.Method = "GET"
+		}
+		if action := strings.TrimSpace(s.AttrOr("action", "")); action != "" {
+			if link, err := url.Parse(action); err == nil {
+				form.Action = page.ResolveReference(link).String()
+			}
+		}
+
+		s.Find("input, select, textarea").Each(func(_ int, field *goquery.Selection) {
+			if goquery.NodeName(field) != "input" {
+				form.Inputs++
+				return
+			}
+			switch strings.ToLower(field.AttrOr("type", "text")) {
+			case "hidden":
+				form.HiddenInputs++
+				if csrfFieldName.MatchString(field.AttrOr("name", "")) {
+					form.HasCSRFToken = true
+				}
+			case "submit", "button", "reset", "image":
+			case "password":
+				form.HasPassword = true
+				form.Inputs++
+			default:
+				form.Inputs++
+			}
+		})
+
+		if form.Method == "POST" && !form.HasCSRFToken {
+			form.Findings = append(form.Findings, FormMissingCSRFToken)
+		}
+		if page.Scheme == "https" && strings.HasPrefix(form.Action, "http:") {
+			form.Findings = append(form.Findings, FormInsecureAction)
+		}
+		forms = append(forms, form)
+		return len(forms) < maxForms
+	})
+	return forms
+}
@@ -0,0 +1,54 @@
+package analyzer
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInventoryForms(t *testing.T) {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(`<html><body>
+		<form action="/search"><input name="q"><button>Search</button></form>
+		<form method="post" action="/login">
+			<input type="hidden" name="authenticity_token" value="x">
+			<input name="user"><input type="password" name="pass"><input type="submit">
+		</form>
+		<form method="POST" action="http://example.com/subscribe">
+			<input type="email" name="email"><select name="topic"></select><textarea name="note"></textarea>
+			<input type="hidden" name="source" value="footer">
+		</form>
+		<form method="dialog"></form>
+	</body></html>`))
+	require.NoError(t, err)
+	page, _ := url.Parse("https://example.com/page")
+
+	forms := inventoryForms(doc, page)
+	require.Len(t, forms, 4)
+
+	t.Run("search form", func(t *testing.T) {
+		assert.Equal(t, Form{Method: "GET", Action: "https://example.com/search", Inputs: 1, Findings: []string{}}, forms[0])
+	})
+
+	t.Run("login form with a token", func(t *testing.T) {
+		assert.Equal(t, Form{
+			Method: "POST", Action: "https://example.com/login", Inputs: 2, HiddenInputs: 1,
+			HasPassword: true, HasCSRFToken: true, Findings: []string{},
+		}, forms[1])
+	})
+
+	t.Run("insecure form without a token", func(t *testing.T) {
+		assert.Equal(t, 3, forms[2].Inputs)
+		assert.Equal(t, 1, forms[2].HiddenInputs)
+		assert.False(t, forms[2].HasCSRFToken)
+		assert.Equal(t, []string{FormMissingCSRFToken, FormInsecureAction}, forms[2].Findings)
+	})
+
+	t.Run("forms without action submit to the page", func(t *testing.T) {
+		assert.Equal(t, "GET", forms[3].Method)
+		assert.Equal(t, "https://example.com/page", forms[3].Action)
+	})
+}
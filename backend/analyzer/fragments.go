@@ -0,0 +1,131 @@
+package analyzer
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// maxFragmentLinks caps how many fragment links to other pages are kept per page
+const maxFragmentLinks = 200
+
+// Fragments holds the page's same-host links with a #fragment and those whose target element is missing
+type Fragments struct {
+	// Links are the distinct fragment links to other pages of the host, up to maxFragmentLinks. Their
+	// targets are only known once those pages are analyzed; see CheckFragmentLinks.
+	Links []FragmentLink `json:"links"`
+	// Findings are the dangling fragment links: to the page itself from every analysis, and to other
+	// pages once CheckFragmentLinks ran
+	Findings []FragmentFinding `json:"findings"`
+}
+
+// FragmentLink is a link to an element of another page
+type FragmentLink struct {
+	// URL is the linked page, without the fragment
+	URL      string `json:"url"`
+	Fragment string `json:"fragment"`
+}
+
+// FragmentFinding is a link to an element that does not exist on its page
+type FragmentFinding struct {
+	URL      string `json:"url"`
+	Fragment string `json:"fragment"`
+	Message  string `json:"message"`
+}
+
+// auditFragments checks the page's links to its own elements against the ids and anchor names of
+// the page and collects its fragment links to other same-host pages. page is the URL the page was
+// served from.
+func auditFragments(doc *goquery.Document, page *url.URL) (Fragments, map[string]bool) {
+	anchors := pageAnchors(doc)
+	f := Fragments{Links: []FragmentLink{}, Findings: []FragmentFinding{}}
+	seen := make(map[FragmentLink]bool)
+
+	doc.Find("a[href], area[href]").Each(func(_ int, s *goquery.Selection) {
+		link, err := url.Parse(strings.TrimSpace(s.AttrOr("href", "")))
+		if err != nil || !checkableFragment(link.Fragment) {
+			return
+		}
+		resolved := page.ResolveReference(link)
+		if (resolved.Scheme != "http" && resolved.Scheme != "https") || !strings.EqualFold(resolved.Host, page.Host) {
+			return
+		}
+		resolved.Fragment = ""
+		key := FragmentLink{URL: resolved.String(), Fragment: link.Fragment}
+		if seen[key] {
+			return
+		}
+		seen[key] = true
+
+		if sameDocument(key.URL, page) {
+			if !anchors[key.Fragment] {
+				f.Findings = append(f.Findings, FragmentFinding{
+					URL:      key.URL + "#" + key.Fragment,
+					Fragment: key.Fragment,
+					Message:  fmt.Sprintf("no element with id %q on this page", key.Fragment),
+				})
+			}
+		} else if len(f.Links) < maxFragmentLinks {
+			f.Links = append(f.Links, key)
+		}
+	})
+	return f, anchors
+}
+
+// pageAnchors returns the targets a fragment can point to: element ids and <a name> values
+func pageAnchors(doc *goquery.Document) map[string]bool {
+	anchors := make(map[string]bool)
+	doc.Find("[id]").Each(func(_ int, s *goquery.Selection) {
+		anchors[s.AttrOr("id", "")] = true
+	})
+	doc.Find("a[name]").Each(func(_ int, s *goquery.Selection) {
+		anchors[s.AttrOr("name", "")] = true
+	})
+	return anchors
+}
+
+// checkableFragment reports whether a fragment should name an element. Empty fragments and #top
+// scroll to the top in every browser, and #/ or #! fragments are routes of single-page applications.
+func checkableFragment(fragment string) bool {
+	return fragment != "" && !strings.EqualFold(fragment, "top") &&
+		!strings.HasPrefix(fragment, "/") && !strings.HasPrefix(fragment, "!")
+}
+
+// CheckFragmentLinks adds a finding to each result linking to an element missing from another page
+// that was also analyzed. Links to pages outside results are not checked, so pass every page of a
+// site crawl at once.
+func CheckFragmentLinks(results []*Result) {
+	for _, r := range results {
+		if r == nil {
+			continue
+		}
+		for _, link := range r.Fragments.Links {
+			target := analyzedPage(results, link.URL)
+			if target == nil || target.anchors[link.Fragment] {
+				continue
+			}
+			r.Fragments.Findings = append(r.Fragments.Findings, FragmentFinding{
+				URL:      link.URL + "#" + link.Fragment,
+				Fragment: link.Fragment,
+				Message:  fmt.Sprintf("no element with id %q on %s", link.Fragment, link.URL),
+			})
+		}
+	}
+}
+
+// analyzedPage returns the result of the page at link, or nil when it was not analyzed
+func analyzedPage(results []*Result, link string) *Result {
+	for _, r := range results {
+		if r == nil {
+			continue
+		}
+		for _, candidate := range []string{r.FinalURL, r.URL} {
+			if page, err := url.Parse(candidate); err == nil && candidate != "" && sameDocument(link, page) {
+				return r
+			}
+		}
+	}
+	return nil
+}
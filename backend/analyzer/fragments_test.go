@@ -0,0 +1,69 @@
+package analyzer
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFragmentLinks(t *testing.T) {
+	pages := map[string]string{
+		"/": `<html><body><h2 id="intro">Intro</h2><a name="legacy"></a>
+			<a href="#intro">Intro</a><a href="#legacy">Legacy</a><a href="#missing">Missing</a>
+			<a href="#">Top</a><a href="#top">Top</a><a href="#/settings">App route</a>
+			<a href="/docs#install">Install</a><a href="/docs#uninstall">Uninstall</a><a href="/docs#uninstall">Again</a>
+			<a href="/other#anything">Not crawled</a><a href="https://elsewhere.test/#x">Other host</a>
+			</body></html>`,
+		"/docs": `<html><body><section id="install"></section><a href="/#intro">Back</a></body></html>`,
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(pages[r.URL.Path]))
+	}))
+	defer server.Close()
+
+	a := New(WithExclusions(mustExcluder(t, "https://elsewhere.test/*")))
+	home, err := a.Analyze(context.Background(), server.URL+"/")
+	require.NoError(t, err)
+	docs, err := a.Analyze(context.Background(), server.URL+"/docs")
+	require.NoError(t, err)
+
+	t.Run("links to the page itself are checked right away", func(t *testing.T) {
+		assert.Equal(t, []FragmentFinding{
+			{URL: server.URL + "/#missing", Fragment: "missing", Message: `no element with id "missing" on this page`},
+		}, home.Fragments.Findings)
+	})
+
+	t.Run("links to other pages are collected once", func(t *testing.T) {
+		assert.Equal(t, []FragmentLink{
+			{URL: server.URL + "/docs", Fragment: "install"},
+			{URL: server.URL + "/docs", Fragment: "uninstall"},
+			{URL: server.URL + "/other", Fragment: "anything"},
+		}, home.Fragments.Links)
+	})
+
+	t.Run("site crawls check them against the analyzed pages", func(t *testing.T) {
+		CheckFragmentLinks([]*Result{home, docs, nil})
+		require.Len(t, home.Fragments.Findings, 2)
+		assert.Equal(t, FragmentFinding{
+			URL:      server.URL + "/docs#uninstall",
+			Fragment: "uninstall",
+			Message:  `no element with id "uninstall" on ` + server.URL + "/docs",
+		}, home.Fragments.Findings[1])
+		assert.Empty(t, docs.Fragments.Findings)
+	})
+}
+
+func mustExcluder(t *testing.T, patterns ...string) *LinkExcluder {
+	var exclusions []LinkExclusion
+	for _, pattern := range patterns {
+		exclusions = append(exclusions, LinkExclusion{Pattern: pattern})
+	}
+	excluder, err := NewLinkExcluder(exclusions)
+	require.NoError(t, err)
+	return excluder
+}
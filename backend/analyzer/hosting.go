@@ -0,0 +1,60 @@
+package analyzer
+
+import (
+	"net"
+	"net/netip"
+)
+
+// IPLocator maps IP addresses to the network that announces them, such as from a GeoIP database
+type IPLocator interface {
+	// LocateIP returns the network of ip, or false when the database does not cover it
+	LocateIP(ip netip.Addr) (IPNetwork, bool)
+}
+
+// IPNetwork is the autonomous system an address belongs to
+type IPNetwork struct {
+	ASN    uint32 `json:"asn"`
+	ASName string `json:"as_name"`
+	// Provider is a friendly name for well-known hosting and CDN networks, otherwise the AS name
+	Provider string `json:"provider"`
+	// Country is the ISO 3166 code of the country the network is registered in
+	Country string `json:"country"`
+}
+
+// Hosting is where the page was served from
+type Hosting struct {
+	// IP is the address of the server that answered the final request
+	IP string `json:"ip"`
+	// The network fields are set when Options.IPLocator covers the address
+	ASN      uint32 `json:"asn,omitempty"`
+	ASName   string `json:"as_name,omitempty"`
+	Provider string `json:"provider,omitempty"`
+	Country  string `json:"country,omitempty"`
+}
+
+// locateServer describes the server at addr, the remote address of the page's connection
+func locateServer(locator IPLocator, addr net.Addr) *Hosting {
+	if addr == nil {
+		return nil
+	}
+	host, _, err := net.SplitHostPort(addr.String())
+	if err != nil {
+		return nil
+	}
+	ip, err := netip.ParseAddr(host)
+	if err != nil {
+		return nil
+	}
+	ip = ip.Unmap().WithZone("")
+
+	hosting := &Hosting{IP: ip.String()}
+	if locator != nil {
+		if network, ok := locator.LocateIP(ip); ok {
+			hosting.ASN = network.ASN
+			hosting.ASName = network.ASName
+			hosting.Provider = network.Provider
+			hosting.Country = network.Country
+		}
+	}
+	return hosting
+}
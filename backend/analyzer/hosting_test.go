@@ -0,0 +1,68 @@
+package analyzer
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeLocator answers from a fixed table of addresses
+type fakeLocator map[netip.Addr]IPNetwork
+
+func (f fakeLocator) LocateIP(ip netip.Addr) (IPNetwork, bool) {
+	network, ok := f[ip]
+	return network, ok
+}
+
+func TestLocateServer(t *testing.T) {
+	locator := fakeLocator{
+		netip.MustParseAddr("104.16.1.1"): {ASN: 13335, ASName: "CLOUDFLARENET", Provider: "Cloudflare", Country: "US"},
+	}
+
+	t.Run("adds the network of known addresses", func(t *testing.T) {
+		h := locateServer(locator, &net.TCPAddr{IP: net.ParseIP("104.16.1.1"), Port: 443})
+		require.NotNil(t, h)
+		assert.Equal(t, "104.16.1.1", h.IP)
+		assert.Equal(t, uint32(13335), h.ASN)
+		assert.Equal(t, "Cloudflare", h.Provider)
+		assert.Equal(t, "US", h.Country)
+	})
+
+	t.Run("unknown addresses keep only the IP", func(t *testing.T) {
+		h := locateServer(locator, &net.TCPAddr{IP: net.ParseIP("2001:db8::1"), Port: 443})
+		require.NotNil(t, h)
+		assert.Equal(t, "2001:db8::1", h.IP)
+		assert.Zero(t, h.ASN)
+	})
+
+	t.Run("IPv4-mapped addresses are unmapped", func(t *testing.T) {
+		h := locateServer(locator, &net.TCPAddr{IP: net.ParseIP("::ffff:104.16.1.1"), Port: 443})
+		assert.Equal(t, "104.16.1.1", h.IP)
+		assert.Equal(t, "Cloudflare", h.Provider)
+	})
+
+	t.Run("no connection means no hosting", func(t *testing.T) {
+		assert.Nil(t, locateServer(locator, nil))
+	})
+
+	t.Run("the analysis reports the server that answered", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("<html><head><title>Hosted</title></head></html>"))
+		}))
+		defer server.Close()
+
+		loopback := fakeLocator{netip.MustParseAddr("127.0.0.1"): {ASN: 64512, Provider: "Test Network", Country: "DE"}}
+		result, err := Analyze(context.Background(), server.URL, WithIPLocator(loopback))
+		require.NoError(t, err)
+		require.NotNil(t, result.Hosting)
+		assert.Equal(t, "127.0.0.1", result.Hosting.IP)
+		assert.Equal(t, "Test Network", result.Hosting.Provider)
+		assert.Equal(t, "DE", result.Hosting.Country)
+	})
+}
@@ -0,0 +1,213 @@
+package analyzer
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// Severities of hreflang, document and contact link findings
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+	SeverityInfo    = "info"
+)
+
+// Codes of hreflang findings
+const (
+	HreflangInvalidCode      = "invalid_code"      // the value is not a language[-script][-region] code or x-default
+	HreflangInvalidURL       = "invalid_url"       // the href is missing or cannot be parsed
+	HreflangRelativeURL      = "relative_url"      // the href is relative; search engines expect absolute URLs
+	HreflangConflict         = "conflict"          // the same value points to different URLs
+	HreflangMissingSelf      = "missing_self"      // no alternate points back to the page itself
+	HreflangMissingXDefault  = "missing_x_default" // alternates exist but none is x-default
+	HreflangMissingLang      = "missing_lang"      // the html element has no lang attribute
+	HreflangLangMismatch     = "lang_mismatch"     // the html lang differs from the page's own hreflang
+	HreflangMissingReturnTag = "missing_return"    // an alternate page does not link back (site crawls only)
+)
+
+// hreflangCode matches language[-script][-region] codes such as en, en-GB, zh-Hant-TW or es-419
+var hreflangCode = regexp.MustCompile(`^[a-z]{2,3}(-[a-z]{4})?(-([a-z]{2}|[0-9]{3}))?$`)
+
+// Hreflang holds the page's language annotations and the problems found with them
+type Hreflang struct {
+	// Lang is the lang attribute of the html element
+	Lang       string              `json:"lang"`
+	Alternates []HreflangAlternate `json:"alternates"`
+	Findings   []HreflangFinding   `json:"findings"`
+}
+
+// HreflangAlternate is one <link rel="alternate" hreflang="..."> of the page, with its href resolved
+type HreflangAlternate struct {
+	Hreflang string `json:"hreflang"`
+	URL      string `json:"url"`
+}
+
+// HreflangFinding is a missing or conflicting language annotation
+type HreflangFinding struct {
+	Code     string `json:"code"`
+	Severity string `json:"severity"`
+	Hreflang string `json:"hreflang,omitempty"`
+	URL      string `json:"url,omitempty"`
+	Message  string `json:"message"`
+}
+
+// auditHreflang collects the page's hreflang alternates and checks them against each other and
+// against the page itself. page is the URL the page was served from.
+func auditHreflang(doc *goquery.Document, page *url.URL) Hreflang {
+	h := Hreflang{
+		Lang:       strings.TrimSpace(doc.Find("html").First().AttrOr("lang", "")),
+		Alternates: []HreflangAlternate{},
+		Findings:   []HreflangFinding{},
+	}
+	finding := func(code, severity, hreflang, link, message string) {
+		h.Findings = append(h.Findings, HreflangFinding{Code: code, Severity: severity, Hreflang: hreflang, URL: link, Message: message})
+	}
+
+	byCode := make(map[string]string)
+	doc.Find("link[hreflang]").Each(func(_ int, s *goquery.Selection) {
+		if !parseRel(s.AttrOr("rel", ""))["alternate"] {
+			return
+		}
+		value := strings.TrimSpace(s.AttrOr("hreflang", ""))
+		code := strings.ToLower(value)
+		href := strings.TrimSpace(s.AttrOr("href", ""))
+
+		if code != "x-default" && !hreflangCode.MatchString(code) {
+			finding(HreflangInvalidCode, SeverityError, value, href,
+				fmt.Sprintf("%q is not a valid hreflang value; use a language code with an optional region, such as en or en-GB", value))
+			return
+		}
+		if region := code[strings.LastIndex(code, "-")+1:]; region == "uk" && code != "uk" {
+			finding(HreflangInvalidCode, SeverityError, value, href,
+				fmt.Sprintf("%q uses UK, which is not a region code; the United Kingdom is GB", value))
+			return
+		}
+
+		link, err := url.Parse(href)
+		if href == "" || err != nil {
+			finding(HreflangInvalidURL, SeverityError, value, href, fmt.Sprintf("hreflang %q has no usable href", value))
+			return
+		}
+		if !link.IsAbs() {
+			finding(HreflangRelativeURL, SeverityWarning, value, href,
+				fmt.Sprintf("hreflang %q uses a relative URL; search engines expect absolute URLs", value))
+		}
+		resolved := page.ResolveReference(link)
+		resolved.Fragment = ""
+		target := resolved.String()
+
+		if previous, ok := byCode[code]; ok {
+			if previous != target {
+				finding(HreflangConflict, SeverityError, value, target,
+					fmt.Sprintf("hreflang %q points to both %s and %s", value, previous, target))
+			}
+			return
+		}
+		byCode[code] = target
+		h.Alternates = append(h.Alternates, HreflangAlternate{Hreflang: code, URL: target})
+	})
+
+	if h.Lang == "" {
+		finding(HreflangMissingLang, SeverityWarning, "", "", "the html element has no lang attribute")
+	}
+	if len(h.Alternates) == 0 {
+		return h
+	}
+
+	// The page should list itself among its alternates, in the language it declares
+	self := ""
+	for _, alt := range h.Alternates {
+		if alt.Hreflang != "x-default" && sameDocument(alt.URL, page) {
+			self = alt.Hreflang
+			break
+		}
+	}
+	if self == "" {
+		finding(HreflangMissingSelf, SeverityError, "", page.String(), "no hreflang alternate points to the page itself")
+	} else if h.Lang != "" && !langMatches(h.Lang, self) {
+		finding(HreflangLangMismatch, SeverityWarning, self, page.String(),
+			fmt.Sprintf("the html lang %q does not match the page's own hreflang %q", h.Lang, self))
+	}
+	if _, ok := byCode["x-default"]; !ok {
+		finding(HreflangMissingXDefault, SeverityInfo, "", "", "no x-default alternate for users whose language is not listed")
+	}
+	return h
+}
+
+// sameDocument reports whether link addresses page, ignoring the fragment and a trailing slash
+func sameDocument(link string, page *url.URL) bool {
+	u, err := url.Parse(link)
+	if err != nil {
+		return false
+	}
+	p := *page
+	p.Fragment = ""
+	return strings.EqualFold(u.Host, p.Host) && u.Scheme == p.Scheme &&
+		strings.TrimSuffix(u.Path, "/") == strings.TrimSuffix(p.Path, "/") && u.RawQuery == p.RawQuery
+}
+
+// langMatches reports whether an html lang attribute agrees with an hreflang value. A bare
+// language matches any region of it, so lang="en" agrees with hreflang="en-GB".
+func langMatches(lang, hreflang string) bool {
+	lang = strings.ToLower(strings.ReplaceAll(lang, "_", "-"))
+	primary := func(code string) string { return strings.SplitN(code, "-", 2)[0] }
+	if primary(lang) != primary(hreflang) {
+		return false
+	}
+	return lang == primary(lang) || hreflang == primary(hreflang) || lang == hreflang
+}
+
+// CheckHreflangReturnLinks adds a finding to each result whose hreflang alternates were also
+// analyzed but do not link back to it. Alternates pointing to pages outside results are not
+// checked, so pass every page of a site crawl at once.
+func CheckHreflangReturnLinks(results []*Result) {
+	byURL := make(map[string]*Result)
+	for _, r := range results {
+		if r == nil {
+			continue
+		}
+		byURL[r.URL] = r
+		if r.FinalURL != "" {
+			byURL[r.FinalURL] = r
+		}
+	}
+
+	for _, r := range results {
+		if r == nil {
+			continue
+		}
+		page, err := url.Parse(r.FinalURL)
+		if err != nil || r.FinalURL == "" {
+			page, err = url.Parse(r.URL)
+			if err != nil {
+				continue
+			}
+		}
+		for _, alt := range r.Hreflang.Alternates {
+			other, ok := byURL[alt.URL]
+			if !ok || other == r {
+				continue
+			}
+			linksBack := false
+			for _, back := range other.Hreflang.Alternates {
+				if sameDocument(back.URL, page) {
+					linksBack = true
+					break
+				}
+			}
+			if !linksBack {
+				r.Hreflang.Findings = append(r.Hreflang.Findings, HreflangFinding{
+					Code:     HreflangMissingReturnTag,
+					Severity: SeverityError,
+					Hreflang: alt.Hreflang,
+					URL:      alt.URL,
+					Message:  fmt.Sprintf("%s does not link back to this page with hreflang", alt.URL),
+				})
+			}
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package analyzer
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// hreflangAudit audits an HTML document served from page
+func hreflangAudit(t *testing.T, page, html string) Hreflang {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(html))
+	require.NoError(t, err)
+	u, err := url.Parse(page)
+	require.NoError(t, err)
+	return auditHreflang(doc, u)
+}
+
+// findingCodes lists the codes of the findings in order
+func findingCodes(h Hreflang) []string {
+	codes := []string{}
+	for _, f := range h.Findings {
+		codes = append(codes, f.Code)
+	}
+	return codes
+}
+
+func TestAuditHreflang(t *testing.T) {
+	t.Run("valid annotations", func(t *testing.T) {
+		h := hreflangAudit(t, "https://example.com/en/", `<html lang="en"><head>
+			<link rel="alternate" hreflang="en" href="https://example.com/en/">
+			<link rel="alternate" hreflang="de-DE" href="https://example.com/de/">
+			<link rel="alternate" hreflang="x-default" href="https://example.com/">
+		</head></html>`)
+
+		assert.Equal(t, "en", h.Lang)
+		assert.Equal(t, []HreflangAlternate{
+			{Hreflang: "en", URL: "https://example.com/en/"},
+			{Hreflang: "de-de", URL: "https://example.com/de/"},
+			{Hreflang: "x-default", URL: "https://example.com/"},
+		}, h.Alternates)
+		assert.Empty(t, h.Findings)
+	})
+
+	t.Run("no annotations", func(t *testing.T) {
+		h := hreflangAudit(t, "https://example.com/", `<html><body>hi</body></html>`)
+		assert.Empty(t, h.Alternates)
+		assert.Equal(t, []string{HreflangMissingLang}, findingCodes(h))
+	})
+
+	t.Run("invalid codes and URLs", func(t *testing.T) {
+		h := hreflangAudit(t, "https://example.com/en", `<html lang="en"><head>
+			<link rel="alternate" hreflang="en" href="/en">
+			<link rel="alternate" hreflang="en_US" href="https://example.com/us">
+			<link rel="alternate" hreflang="en-UK" href="https://example.com/uk">
+			<link rel="alternate" hreflang="uk" href="https://example.com/ua">
+			<link rel="alternate" hreflang="fr" href="">
+			<link rel="stylesheet" hreflang="de" href="/style.css">
+		</head></html>`)
+
+		assert.Equal(t, []string{
+			HreflangRelativeURL,
+			HreflangInvalidCode,
+			HreflangInvalidCode,
+			HreflangInvalidURL,
+			HreflangMissingXDefault,
+		}, findingCodes(h))
+		assert.Len(t, h.Alternates, 2) // en (resolved) and uk (Ukrainian)
+		assert.Equal(t, "https://example.com/en", h.Alternates[0].URL)
+	})
+
+	t.Run("conflicts, missing self reference and language mismatch", func(t *testing.T) {
+		h := hreflangAudit(t, "https://example.com/fr", `<html lang="de"><head>
+			<link rel="alternate" hreflang="en" href="https://example.com/en">
+			<link rel="alternate" hreflang="EN" href="https://example.com/english">
+			<link rel="alternate" hreflang="x-default" href="https://example.com/en">
+		</head></html>`)
+		assert.Equal(t, []string{HreflangConflict, HreflangMissingSelf}, findingCodes(h))
+
+		h = hreflangAudit(t, "https://example.com/fr", `<html lang="de"><head>
+			<link rel="alternate" hreflang="fr-FR" href="https://example.com/fr/">
+			<link rel="alternate" hreflang="x-default" href="https://example.com/">
+		</head></html>`)
+		assert.Equal(t, []string{HreflangLangMismatch}, findingCodes(h))
+	})
+}
+
+func TestLangMatches(t *testing.T) {
+	assert.True(t, langMatches("en", "en-gb"))
+	assert.True(t, langMatches("en-GB", "en"))
+	assert.True(t, langMatches("en_GB", "en-gb"))
+	assert.False(t, langMatches("en-US", "en-gb"))
+	assert.False(t, langMatches("de", "en"))
+}
+
+func TestCheckHreflangReturnLinks(t *testing.T) {
+	en := &Result{URL: "https://example.com/en", FinalURL: "https://example.com/en", Hreflang: Hreflang{
+		Alternates: []HreflangAlternate{
+			{Hreflang: "en", URL: "https://example.com/en"},
+			{Hreflang: "de", URL: "https://example.com/de"},
+			{Hreflang: "fr", URL: "https://example.com/fr"},
+			{Hreflang: "es", URL: "https://other.example/es"},
+		},
+	}}
+	de := &Result{URL: "https://example.com/de", FinalURL: "https://example.com/de", Hreflang: Hreflang{
+		Alternates: []HreflangAlternate{
+			{Hreflang: "de", URL: "https://example.com/de"},
+			{Hreflang: "en", URL: "https://example.com/en/"},
+		},
+	}}
+	fr := &Result{URL: "https://example.com/fr", FinalURL: "https://example.com/fr", Hreflang: Hreflang{
+		Alternates: []HreflangAlternate{{Hreflang: "fr", URL: "https://example.com/fr"}},
+	}}
+
+	CheckHreflangReturnLinks([]*Result{en, de, fr, nil})
+
+	// fr does not link back to en; the es page was not crawled, so it is not judged
+	require.Len(t, en.Hreflang.Findings, 1)
+	assert.Equal(t, HreflangMissingReturnTag, en.Hreflang.Findings[0].Code)
+	assert.Equal(t, "https://example.com/fr", en.Hreflang.Findings[0].URL)
+	assert.Empty(t, de.Hreflang.Findings)
+	assert.Empty(t, fr.Hreflang.Findings)
+}
@@ -0,0 +1,162 @@
+package analyzer
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// Codes of link hygiene findings
+const (
+	LinkJavaScript    = "javascript_scheme" // href runs script instead of navigating
+	LinkData          = "data_scheme"       // href embeds a document, a common phishing trick
+	LinkRedirector    = "redirector"        // href passes another site's URL to a redirect endpoint
+	LinkShortener     = "url_shortener"     // target domain hides the final destination
+	LinkIPAddress     = "ip_address"        // target is a bare IP address instead of a domain
+	LinkPunycode      = "punycode"          // target domain is internationalized and may imitate another
+	LinkCredentials   = "credentials"       // href contains user:password@ before the host
+	LinkSuspiciousTLD = "suspicious_tld"    // target uses a top-level domain popular with abuse
+)
+
+// maxHygieneFindings caps how many suspicious links are listed
+const maxHygieneFindings = 100
+
+// redirectParams are query parameters that redirect endpoints commonly read their target from
+var redirectParams = map[string]bool{
+	"url": true, "u": true, "redirect": true, "redirect_url": true, "redirect_uri": true, "redirecturl": true,
+	"return": true, "return_to": true, "returnto": true, "returnurl": true, "next": true, "target": true,
+	"dest": true, "destination": true, "continue": true, "goto": true, "out": true, "link": true, "to": true,
+}
+
+// shortenerDomains are URL shorteners whose links hide where they lead
+var shortenerDomains = map[string]bool{
+	"bit.ly": true, "tinyurl.com": true, "goo.gl": true, "t.co": true, "ow.ly": true, "is.gd": true,
+	"buff.ly": true, "cutt.ly": true, "rebrand.ly": true, "shorturl.at": true, "tiny.cc": true, "rb.gy": true,
+}
+
+// suspiciousTLDs are top-level domains that are cheap or free to register or easily confused with file names
+var suspiciousTLDs = map[string]bool{
+	"zip": true, "mov": true, "tk": true, "ml": true, "ga": true, "cf": true, "gq": true,
+}
+
+// LinkHygiene counts the page's suspicious links by kind and lists them
+type LinkHygiene struct {
+	JavaScriptLinks   int `json:"javascript_links"`
+	DataLinks         int `json:"data_links"`
+	Redirectors       int `json:"redirectors"`
+	SuspiciousDomains int `json:"suspicious_domains"`
+	// Findings lists the first maxHygieneFindings suspicious links; the counts above include every link
+	Findings []LinkFinding `json:"findings"`
+}
+
+// LinkFinding is one suspicious link
+type LinkFinding struct {
+	URL     string `json:"url"`
+	Code    string `json:"code"`
+	Domain  string `json:"domain,omitempty"`
+	Message string `json:"message"`
+}
+
+// auditLinkHygiene flags script and data links, links through redirect endpoints and links to
+// domains in suspicious categories
+func auditLinkHygiene(doc *goquery.Document, base *url.URL) LinkHygiene {
+	h := LinkHygiene{Findings: []LinkFinding{}}
+	add := func(f LinkFinding) {
+		if len(h.Findings) < maxHygieneFindings {
+			h.Findings = append(h.Findings, f)
+		}
+	}
+
+	doc.Find("a[href], area[href]").Each(func(_ int, s *goquery.Selection) {
+		href := strings.TrimSpace(s.AttrOr("href", ""))
+		scheme := strings.ToLower(href)
+		switch {
+		case strings.HasPrefix(scheme, "javascript:"):
+			h.JavaScriptLinks++
+			add(LinkFinding{URL: truncateURL(href), Code: LinkJavaScript, Message: "link runs JavaScript instead of pointing to a page"})
+			return
+		case strings.HasPrefix(scheme, "data:"):
+			h.DataLinks++
+			add(LinkFinding{URL: truncateURL(href), Code: LinkData, Message: "link opens an embedded data: document"})
+			return
+		}
+
+		link, err := url.Parse(href)
+		if err != nil {
+			return
+		}
+		target := base.ResolveReference(link)
+		if target.Scheme != "http" && target.Scheme != "https" {
+			return
+		}
+
+		if dest := redirectTarget(target); dest != "" {
+			h.Redirectors++
+			add(LinkFinding{URL: target.String(), Code: LinkRedirector, Domain: target.Hostname(),
+				Message: fmt.Sprintf("link passes %s to a redirect endpoint", dest)})
+		}
+
+		if target.Host == base.Host {
+			return
+		}
+		if code, message := suspiciousDomain(target); code != "" {
+			h.SuspiciousDomains++
+			add(LinkFinding{URL: target.String(), Code: code, Domain: target.Hostname(), Message: message})
+		}
+	})
+
+	return h
+}
+
+// redirectTarget returns the URL of another site that link hands to a redirect parameter, if any
+func redirectTarget(link *url.URL) string {
+	for name, values := range link.Query() {
+		if !redirectParams[strings.ToLower(name)] {
+			continue
+		}
+		for _, value := range values {
+			dest, err := url.Parse(value)
+			if err != nil || (dest.Scheme != "http" && dest.Scheme != "https") {
+				continue
+			}
+			if dest.Hostname() != "" && !strings.EqualFold(dest.Hostname(), link.Hostname()) {
+				return dest.String()
+			}
+		}
+	}
+	return ""
+}
+
+// suspiciousDomain categorizes the target of an external link, returning an empty code for ordinary domains
+func suspiciousDomain(link *url.URL) (code, message string) {
+	host := strings.ToLower(link.Hostname())
+	switch {
+	case link.User != nil:
+		return LinkCredentials, "link embeds credentials before the host, which can disguise the real destination"
+	case net.ParseIP(host) != nil:
+		return LinkIPAddress, fmt.Sprintf("link points to the IP address %s instead of a domain", host)
+	case shortenerDomains[strings.TrimPrefix(host, "www.")]:
+		return LinkShortener, fmt.Sprintf("%s is a URL shortener that hides the final destination", host)
+	}
+	for _, label := range strings.Split(host, ".") {
+		if strings.HasPrefix(label, "xn--") || strings.IndexFunc(label, func(r rune) bool { return r > 127 }) >= 0 {
+			return LinkPunycode, fmt.Sprintf("%s is an internationalized domain that may imitate another", host)
+		}
+	}
+	if tld := host[strings.LastIndex(host, ".")+1:]; suspiciousTLDs[tld] {
+		return LinkSuspiciousTLD, fmt.Sprintf("%s uses the .%s top-level domain, which is popular with abuse", host, tld)
+	}
+	return "", ""
+}
+
+// truncateURL shortens long hrefs such as data: URIs for reporting
+func truncateURL(href string) string {
+	const limit = 200
+	if len(href) <= limit {
+		return href
+	}
+	return href[:limit] + "..."
+}
@@ -0,0 +1,64 @@
+package analyzer
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuditLinkHygiene(t *testing.T) {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(`<html><body>
+		<a href="/about">About</a>
+		<a href="https://partner.com/docs">Docs</a>
+		<a href="javascript:void(0)">Menu</a>
+		<a href="JavaScript:alert(1)">Alert</a>
+		<a href="data:text/html;base64,PHNjcmlwdD4=">Data</a>
+		<a href="/out?url=https%3A%2F%2Fevil.example%2Flogin">Redirect</a>
+		<a href="/login?next=/account">Same-site next</a>
+		<a href="https://www.google.com/url?q=x&url=https://elsewhere.com/">Google redirect</a>
+		<a href="https://bit.ly/abc">Short</a>
+		<a href="http://192.168.0.1/admin">IP</a>
+		<a href="https://xn--pple-43d.com/">Punycode</a>
+		<a href="https://paypal.com@login.example/">Credentials</a>
+		<a href="https://free-prizes.tk/">TLD</a>
+		<map><area href="https://t.co/xyz"></map>
+	</body></html>`))
+	require.NoError(t, err)
+	base, _ := url.Parse("https://example.com/")
+
+	h := auditLinkHygiene(doc, base)
+
+	assert.Equal(t, 2, h.JavaScriptLinks)
+	assert.Equal(t, 1, h.DataLinks)
+	assert.Equal(t, 2, h.Redirectors)
+	assert.Equal(t, 6, h.SuspiciousDomains)
+
+	codes := map[string]string{}
+	for _, f := range h.Findings {
+		codes[f.URL] = f.Code
+	}
+	assert.Equal(t, LinkRedirector, codes["https://example.com/out?url=https%3A%2F%2Fevil.example%2Flogin"])
+	assert.Equal(t, LinkShortener, codes["https://bit.ly/abc"])
+	assert.Equal(t, LinkShortener, codes["https://t.co/xyz"])
+	assert.Equal(t, LinkIPAddress, codes["http://192.168.0.1/admin"])
+	assert.Equal(t, LinkPunycode, codes["https://xn--pple-43d.com/"])
+	assert.Equal(t, LinkCredentials, codes["https://paypal.com@login.example/"])
+	assert.Equal(t, LinkSuspiciousTLD, codes["https://free-prizes.tk/"])
+	assert.NotContains(t, codes, "https://partner.com/docs")
+	assert.NotContains(t, codes, "https://example.com/login?next=/account")
+}
+
+func TestAuditLinkHygieneCapsFindings(t *testing.T) {
+	html := strings.Repeat(`<a href="javascript:void(0)">x</a>`, maxHygieneFindings+10)
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(html))
+	require.NoError(t, err)
+	base, _ := url.Parse("https://example.com/")
+
+	h := auditLinkHygiene(doc, base)
+	assert.Equal(t, maxHygieneFindings+10, h.JavaScriptLinks)
+	assert.Len(t, h.Findings, maxHygieneFindings)
+}
@@ -0,0 +1,109 @@
+package analyzer
+
+import (
+	"math"
+	"strings"
+	"unicode"
+
+	"github.com/PuerkitoBio/goquery"
+	"golang.org/x/net/html"
+)
+
+// KeywordResult reports where a target keyword occurs on the page. Matching ignores case and
+// punctuation and only counts whole words, so "go" does not match "google".
+type KeywordResult struct {
+	Keyword         string `json:"keyword"`
+	Title           int    `json:"title"`
+	Headings        int    `json:"headings"` // h1 to h6
+	MetaDescription int    `json:"meta_description"`
+	Body            int    `json:"body"` // visible body text, headings included
+	// Density is the percentage of the body's words taken up by the keyword
+	Density float64 `json:"density"`
+}
+
+// tokenize lower-cases s and splits it into words of letters and digits
+func tokenize(s string) []string {
+	return strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+}
+
+// countPhrase counts non-overlapping occurrences of phrase in words
+func countPhrase(words, phrase []string) int {
+	if len(phrase) == 0 {
+		return 0
+	}
+	count := 0
+	for i := 0; i+len(phrase) <= len(words); {
+		match := true
+		for j, w := range phrase {
+			if words[i+j] != w {
+				match = false
+				break
+			}
+		}
+		if match {
+			count++
+			i += len(phrase)
+		} else {
+			i++
+		}
+	}
+	return count
+}
+
+// visibleText joins the text nodes under the selection, skipping scripts, styles and templates.
+// Text nodes are separated by spaces so words in adjacent elements stay apart.
+func visibleText(s *goquery.Selection) string {
+	var sb strings.Builder
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode {
+			switch n.Data {
+			case "script", "style", "noscript", "template":
+				return
+			}
+		}
+		if n.Type == html.TextNode {
+			sb.WriteString(n.Data)
+			sb.WriteByte(' ')
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	for _, n := range s.Nodes {
+		walk(n)
+	}
+	return sb.String()
+}
+
+// analyzeKeywords counts each keyword in the title, headings, meta description and body
+func analyzeKeywords(doc *goquery.Document, keywords []string) []KeywordResult {
+	if len(keywords) == 0 {
+		return nil
+	}
+
+	title := tokenize(doc.Find("title").First().Text())
+	headings := tokenize(visibleText(doc.Find("h1, h2, h3, h4, h5, h6")))
+	description := tokenize(doc.Find(`meta[name="description" i]`).First().AttrOr("content", ""))
+	body := tokenize(visibleText(doc.Find("body")))
+
+	results := make([]KeywordResult, 0, len(keywords))
+	for _, keyword := range keywords {
+		phrase := tokenize(keyword)
+		r := KeywordResult{
+			Keyword:         keyword,
+			Title:           countPhrase(title, phrase),
+			Headings:        countPhrase(headings, phrase),
+			MetaDescription: countPhrase(description, phrase),
+			Body:            countPhrase(body, phrase),
+		}
+		if len(body) > 0 {
+			density := float64(r.Body*len(phrase)) / float64(len(body)) * 100
+			r.Density = math.Round(density*100) / 100
+		}
+		results = append(results, r)
+	}
+	return results
+}
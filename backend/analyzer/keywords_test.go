@@ -0,0 +1,67 @@
+package analyzer
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCountPhrase(t *testing.T) {
+	words := tokenize("Go tooling: go-to guide for Go developers, not Google")
+
+	assert.Equal(t, 3, countPhrase(words, tokenize("go")))
+	assert.Equal(t, 1, countPhrase(words, tokenize("Go Developers")))
+	assert.Equal(t, 0, countPhrase(words, tokenize("golang")))
+	assert.Equal(t, 0, countPhrase(words, nil))
+}
+
+func TestAnalyzeKeywords(t *testing.T) {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(`<html><head>
+		<title>Coffee Beans | Fresh Coffee</title>
+		<meta name="Description" content="Buy fresh coffee beans online.">
+		<script>var coffee = "coffee";</script>
+	</head><body>
+		<h1>Coffee beans</h1><h2>Roasted daily</h2>
+		<p>Our coffee is roasted daily.</p><p>Coffee<br>beans ship fast.</p>
+		<style>.coffee { color: brown }</style>
+	</body></html>`))
+	require.NoError(t, err)
+
+	results := analyzeKeywords(doc, []string{"coffee", "coffee beans", "tea"})
+	require.Len(t, results, 3)
+
+	coffee := results[0]
+	assert.Equal(t, "coffee", coffee.Keyword)
+	assert.Equal(t, 2, coffee.Title)
+	assert.Equal(t, 1, coffee.Headings)
+	assert.Equal(t, 1, coffee.MetaDescription)
+	assert.Equal(t, 3, coffee.Body) // scripts and styles are ignored
+	// 14 body words: coffee beans roasted daily our coffee is roasted daily coffee beans ship fast
+	assert.InDelta(t, 3.0/13*100, coffee.Density, 0.01)
+
+	beans := results[1]
+	assert.Equal(t, 2, beans.Body) // "Coffee<br>beans" still reads as a phrase
+	assert.InDelta(t, 4.0/13*100, beans.Density, 0.01)
+
+	assert.Zero(t, results[2].Body)
+	assert.Zero(t, results[2].Density)
+}
+
+func TestAnalyzeWithKeywords(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`<html><head><title>Hello world</title></head><body><p>hello</p></body></html>`))
+	}))
+	defer server.Close()
+
+	result, err := Analyze(context.Background(), server.URL, WithKeywords("hello"))
+	require.NoError(t, err)
+	require.Len(t, result.Keywords, 1)
+	assert.Equal(t, 1, result.Keywords[0].Title)
+	assert.Equal(t, 100.0, result.Keywords[0].Density)
+}
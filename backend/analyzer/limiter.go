@@ -0,0 +1,156 @@
+package analyzer
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Adaptive link check concurrency. Each host starts with Options.MaxConcurrentLinkChecks parallel
+// checks. A host answering quickly gets one more slot per round of fast answers, up to hostScaleUp
+// times the start; a host answering 429 or 503, or slower than the slow threshold, has its slots
+// halved, down to one. No more than totalScaleUp times the start run across all hosts.
+const (
+	hostScaleUp  = 2
+	totalScaleUp = 4
+	// slowLinkCheckShare is the part of Options.LinkCheckTimeout above which a check counts as slow
+	slowLinkCheckShare = 5
+)
+
+// checkOutcome is how a finished check changes its host's limit
+type checkOutcome int
+
+const (
+	outcomeNeutral checkOutcome = iota // cancelled or failed without an answer; the limit stays
+	outcomeFast                        // answered in time
+	outcomeBackOff                     // rate limited, unavailable or slow
+)
+
+// hostLimiter hands out check slots per host, adapting each host's limit to how it responds.
+// It is safe for concurrent use.
+type hostLimiter struct {
+	initial, max, total int
+	slow                time.Duration
+
+	mu      sync.Mutex
+	hosts   map[string]*hostState
+	active  int
+	changed chan struct{} // closed and replaced whenever a slot may have become free
+}
+
+// hostState tracks the checks of one host
+type hostState struct {
+	limit  int
+	active int
+	// fast counts fast answers since the limit last changed
+	fast int
+	// epoch increases on every back-off, so answers to checks started before it do not halve again
+	epoch int
+}
+
+// hostSlot is a slot handed out by acquire
+type hostSlot struct {
+	host  *hostState
+	epoch int
+}
+
+// newHostLimiter creates a limiter starting each host at initial parallel checks
+func newHostLimiter(initial int, slow time.Duration) *hostLimiter {
+	initial = max(initial, 1)
+	return &hostLimiter{
+		initial: initial,
+		max:     initial * hostScaleUp,
+		total:   initial * totalScaleUp,
+		slow:    slow,
+		hosts:   make(map[string]*hostState),
+		changed: make(chan struct{}),
+	}
+}
+
+// acquire waits for a free slot for the host of rawURL
+func (l *hostLimiter) acquire(ctx context.Context, rawURL string) (hostSlot, error) {
+	key := limiterKey(rawURL)
+	for {
+		l.mu.Lock()
+		h, ok := l.hosts[key]
+		if !ok {
+			h = &hostState{limit: l.initial}
+			l.hosts[key] = h
+		}
+		if h.active < h.limit && l.active < l.total {
+			h.active++
+			l.active++
+			slot := hostSlot{host: h, epoch: h.epoch}
+			l.mu.Unlock()
+			return slot, nil
+		}
+		changed := l.changed
+		l.mu.Unlock()
+
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return hostSlot{}, ctx.Err()
+		}
+	}
+}
+
+// release frees slot and adjusts its host's limit to the outcome of the check
+func (l *hostLimiter) release(slot hostSlot, outcome checkOutcome) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	h := slot.host
+	h.active--
+	l.active--
+	switch outcome {
+	case outcomeFast:
+		h.fast++
+		if h.fast >= h.limit && h.limit < l.max {
+			h.limit++
+			h.fast = 0
+		}
+	case outcomeBackOff:
+		if slot.epoch == h.epoch {
+			h.limit = max(h.limit/2, 1)
+			h.fast = 0
+			h.epoch++
+		}
+	}
+	close(l.changed)
+	l.changed = make(chan struct{})
+}
+
+// outcome classifies a finished check from its status code (0 without an answer) and duration
+func (l *hostLimiter) outcome(status int, elapsed time.Duration) checkOutcome {
+	switch {
+	case status == http.StatusTooManyRequests || status == http.StatusServiceUnavailable:
+		return outcomeBackOff
+	case elapsed >= l.slow:
+		return outcomeBackOff
+	case status == 0:
+		return outcomeNeutral
+	}
+	return outcomeFast
+}
+
+// limit returns the current limit of the host of rawURL
+func (l *hostLimiter) limit(rawURL string) int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if h, ok := l.hosts[limiterKey(rawURL)]; ok {
+		return h.limit
+	}
+	return l.initial
+}
+
+// limiterKey is the host, port included, that a check of rawURL connects to
+func limiterKey(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	return strings.ToLower(u.Host)
+}
@@ -0,0 +1,116 @@
+package analyzer
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHostLimiter(t *testing.T) {
+	ctx := context.Background()
+	const host = "https://example.com/page"
+
+	t.Run("fast hosts get more slots", func(t *testing.T) {
+		l := newHostLimiter(2, time.Second)
+		for i := 0; i < 20; i++ {
+			slot, err := l.acquire(ctx, host)
+			require.NoError(t, err)
+			l.release(slot, outcomeFast)
+		}
+		assert.Equal(t, 4, l.limit(host), "capped at twice the start")
+		assert.Equal(t, 2, l.limit("https://other.example/"), "hosts are independent")
+	})
+
+	t.Run("rate limited hosts back off once per round", func(t *testing.T) {
+		l := newHostLimiter(8, time.Second)
+		var slots []hostSlot
+		for i := 0; i < 8; i++ {
+			slot, err := l.acquire(ctx, host)
+			require.NoError(t, err)
+			slots = append(slots, slot)
+		}
+		// Answers to checks started before the first back-off do not halve the limit again
+		for _, slot := range slots {
+			l.release(slot, outcomeBackOff)
+		}
+		assert.Equal(t, 4, l.limit(host))
+
+		for i := 0; i < 5; i++ {
+			slot, err := l.acquire(ctx, host)
+			require.NoError(t, err)
+			l.release(slot, outcomeBackOff)
+		}
+		assert.Equal(t, 1, l.limit(host), "never below one")
+	})
+
+	t.Run("waits for a free slot", func(t *testing.T) {
+		l := newHostLimiter(1, time.Second)
+		slot, err := l.acquire(ctx, host)
+		require.NoError(t, err)
+
+		short, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+		defer cancel()
+		_, err = l.acquire(short, host)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+		go func() {
+			time.Sleep(10 * time.Millisecond)
+			l.release(slot, outcomeNeutral)
+		}()
+		_, err = l.acquire(ctx, host)
+		assert.NoError(t, err)
+	})
+
+	t.Run("total limit across hosts", func(t *testing.T) {
+		l := newHostLimiter(1, time.Second)
+		for i := 0; i < totalScaleUp; i++ {
+			_, err := l.acquire(ctx, "https://host"+strconv.Itoa(i)+".example/")
+			require.NoError(t, err)
+		}
+		short, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+		defer cancel()
+		_, err := l.acquire(short, "https://another.example/")
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+
+	t.Run("outcomes", func(t *testing.T) {
+		l := newHostLimiter(1, time.Second)
+		assert.Equal(t, outcomeFast, l.outcome(http.StatusNotFound, time.Millisecond))
+		assert.Equal(t, outcomeBackOff, l.outcome(http.StatusTooManyRequests, time.Millisecond))
+		assert.Equal(t, outcomeBackOff, l.outcome(http.StatusServiceUnavailable, time.Millisecond))
+		assert.Equal(t, outcomeBackOff, l.outcome(http.StatusOK, 2*time.Second))
+		assert.Equal(t, outcomeNeutral, l.outcome(0, time.Millisecond))
+	})
+}
+
+func TestLinkChecksBackOff(t *testing.T) {
+	var requests, active, latePeak atomic.Int32
+	links := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := active.Add(1)
+		defer active.Add(-1)
+		if requests.Add(1) > 24 {
+			for p := latePeak.Load(); n > p && !latePeak.CompareAndSwap(p, n); p = latePeak.Load() {
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+		w.Header().Set("Retry-After", "0")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer links.Close()
+
+	var urls []string
+	for i := 0; i < 40; i++ {
+		urls = append(urls, links.URL+"/"+strconv.Itoa(i))
+	}
+	a := New(WithMaxConcurrentLinkChecks(8))
+	broken := a.checkBrokenLinks(context.Background(), urls, &LinkStats{}, nil)
+	assert.Len(t, broken, 40, "rate limited links are still reported")
+	assert.LessOrEqual(t, latePeak.Load(), int32(2), "checks slow down to one at a time")
+}
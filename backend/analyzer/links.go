@@ -0,0 +1,289 @@
+package analyzer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// linkCheckWait caps how long the analysis waits for outstanding link checks
+const linkCheckWait = 30 * time.Second
+
+// A link answered 429 is checked once more after its Retry-After, or defaultRetryAfter without one.
+// Links asking for more than maxRetryAfter are reported rate limited straight away.
+const (
+	defaultRetryAfter = time.Second
+	maxRetryAfter     = 10 * time.Second
+)
+
+// linkCacheWait bounds storing the verdicts of a page in Options.LinkCache
+const linkCacheWait = 10 * time.Second
+
+// LinkCache keeps link check verdicts between analyses, so links checked recently, on this page or
+// another, are not checked again
+type LinkCache interface {
+	// LookupLinks returns the fresh verdicts of links: nil for a working link, its BrokenLink for a
+	// broken one. Links without a verdict are left out and get checked.
+	LookupLinks(ctx context.Context, links []string) (map[string]*BrokenLink, error)
+	// StoreLinks records the verdicts of links just checked
+	StoreLinks(ctx context.Context, verdicts map[string]*BrokenLink) error
+}
+
+// checkBrokenLinks checks multiple links concurrently with proper synchronization, skipping any
+// link matched by the exclusions and taking the verdicts Options.LinkCache has. It records the
+// excluded, checked and cached counts in stats.
+func (a *Analyzer) checkBrokenLinks(ctx context.Context, links []string, stats *LinkStats, tracker *progressTracker) []BrokenLink {
+	opts := a.opts
+	brokenLinks := make([]BrokenLink, 0)
+
+	if opts.Exclusions != nil {
+		var included []string
+		for _, link := range links {
+			if !opts.Exclusions.Matches(link) {
+				included = append(included, link)
+			}
+		}
+		stats.Excluded = len(links) - len(included)
+		links = included
+	}
+	tracker.update(func(p *Progress) { p.LinksToCheck = len(links) })
+
+	if opts.LinkCache != nil && len(links) > 0 {
+		var unchecked []string
+		cached, _ := opts.LinkCache.LookupLinks(ctx, links)
+		for _, link := range links {
+			verdict, ok := cached[link]
+			if !ok {
+				unchecked = append(unchecked, link)
+			} else if verdict != nil {
+				brokenLinks = append(brokenLinks, *verdict)
+			}
+		}
+		stats.Cached = len(links) - len(unchecked)
+		stats.Checked = stats.Cached
+		tracker.update(func(p *Progress) { p.LinksChecked += stats.Cached })
+		links = unchecked
+	}
+	if len(links) == 0 {
+		return brokenLinks
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	checked := stats.Checked
+	verdicts := make(map[string]*BrokenLink)
+
+	// Limit concurrent requests per host, backing off from hosts that struggle to keep up
+	limiter := newHostLimiter(opts.MaxConcurrentLinkChecks, opts.LinkCheckTimeout/slowLinkCheckShare)
+	client := a.client(opts.LinkCheckTimeout)
+
+	for _, linkURL := range links {
+		// Check if context is cancelled
+		if ctx.Err() != nil {
+			break
+		}
+
+		wg.Add(1)
+		go func(url string) {
+			defer wg.Done()
+
+			brokenDetail, ok := checkLimited(ctx, limiter, client, url, opts.UserAgent)
+			if !ok {
+				return
+			}
+			mu.Lock()
+			if brokenDetail != nil {
+				brokenLinks = append(brokenLinks, *brokenDetail)
+			}
+			// A check cut short by cancellation reports nothing and does not count
+			if brokenDetail != nil || ctx.Err() == nil {
+				checked++
+			}
+			if ctx.Err() == nil && cacheableVerdict(brokenDetail) {
+				verdicts[url] = brokenDetail
+			}
+			mu.Unlock()
+			tracker.update(func(p *Progress) { p.LinksChecked++ })
+		}(linkURL)
+	}
+
+	// Wait for all goroutines to complete or timeout
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		// All checks completed
+	case <-time.After(linkCheckWait):
+		// Timeout waiting for broken link checks; the result reports fewer checked links
+	case <-ctx.Done():
+		// Context cancelled
+	}
+
+	// Checks still running may finish later, so hand out a copy
+	mu.Lock()
+	stats.Checked = checked
+	result := append(make([]BrokenLink, 0, len(brokenLinks)), brokenLinks...)
+	var store map[string]*BrokenLink
+	if opts.LinkCache != nil && len(verdicts) > 0 {
+		store = make(map[string]*BrokenLink, len(verdicts))
+		for link, verdict := range verdicts {
+			store[link] = verdict
+		}
+	}
+	mu.Unlock()
+
+	if store != nil {
+		// Verdicts are worth keeping even when the analysis ran out of time
+		storeCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), linkCacheWait)
+		opts.LinkCache.StoreLinks(storeCtx, store)
+		cancel()
+	}
+	return result
+}
+
+// cacheableVerdict reports whether a check's verdict holds for a while: the link worked, or the
+// server answered it with a client error other than 408 Request Timeout, 425 Too Early and 429 Too
+// Many Requests. Server errors and failed connections may be gone by the next crawl.
+func cacheableVerdict(brokenDetail *BrokenLink) bool {
+	if brokenDetail == nil {
+		return true
+	}
+	switch code := brokenDetail.StatusCode; {
+	case code == http.StatusRequestTimeout, code == http.StatusTooEarly, code == http.StatusTooManyRequests:
+		return false
+	default:
+		return code >= 400 && code < 500
+	}
+}
+
+// checkLimited checks a link in a slot of limiter, checking it once more after its Retry-After when
+// it is answered 429. It returns false when ctx ends before the link could be checked.
+func checkLimited(ctx context.Context, limiter *hostLimiter, client *http.Client, linkURL, userAgent string) (*BrokenLink, bool) {
+	for retried := false; ; retried = true {
+		slot, err := limiter.acquire(ctx, linkURL)
+		if err != nil {
+			return nil, false
+		}
+
+		start := time.Now()
+		brokenDetail, retryAfter := checkLink(ctx, client, linkURL, userAgent)
+		outcome := outcomeNeutral
+		if ctx.Err() == nil {
+			status := http.StatusOK
+			if brokenDetail != nil {
+				status = brokenDetail.StatusCode
+			}
+			outcome = limiter.outcome(status, time.Since(start))
+		}
+		limiter.release(slot, outcome)
+
+		if brokenDetail == nil || !brokenDetail.RateLimited || retried || retryAfter > maxRetryAfter {
+			return brokenDetail, true
+		}
+		// The slot is given back while waiting, so other hosts' links go ahead
+		select {
+		case <-time.After(retryAfter):
+		case <-ctx.Done():
+			return nil, false
+		}
+	}
+}
+
+// checkSingleLink checks if a single link is broken
+func checkSingleLink(ctx context.Context, client *http.Client, linkURL, userAgent string) *BrokenLink {
+	brokenDetail, _ := checkLink(ctx, client, linkURL, userAgent)
+	return brokenDetail
+}
+
+// checkLink checks a link like checkSingleLink and, when it is answered 429, also returns how long
+// the server asked to wait
+func checkLink(ctx context.Context, client *http.Client, linkURL, userAgent string) (*BrokenLink, time.Duration) {
+	// Create HEAD request with context
+	req, err := http.NewRequestWithContext(ctx, "HEAD", linkURL, nil)
+	if err != nil {
+		return &BrokenLink{
+			URL:   linkURL,
+			Error: fmt.Sprintf("Request creation failed: %v", err),
+		}, 0
+	}
+
+	// Set User-Agent for broken link checks
+	req.Header.Set("User-Agent", userAgent)
+	req.Header.Set("Accept", "*/*")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		// Skip context cancellation errors
+		if ctx.Err() != nil {
+			return nil, 0
+		}
+		// Links into private networks are not probed, which does not make them broken
+		if errors.Is(err, ErrPrivateAddress) {
+			return nil, 0
+		}
+
+		return &BrokenLink{
+			URL:   linkURL,
+			Error: checkErrorMessage(err),
+		}, 0
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return &BrokenLink{
+			URL:         linkURL,
+			StatusCode:  resp.StatusCode,
+			Error:       resp.Status,
+			RateLimited: true,
+		}, retryAfter(resp.Header.Get("Retry-After"), time.Now())
+	}
+
+	// Consider 4xx and 5xx as broken links
+	if resp.StatusCode >= 400 {
+		return &BrokenLink{
+			URL:        linkURL,
+			StatusCode: resp.StatusCode,
+			Error:      resp.Status,
+		}, 0
+	}
+
+	// Link is working
+	return nil, 0
+}
+
+// retryAfter parses a Retry-After header, given in seconds or as an HTTP date
+func retryAfter(header string, now time.Time) time.Duration {
+	header = strings.TrimSpace(header)
+	if header == "" {
+		return defaultRetryAfter
+	}
+	if seconds, err := strconv.Atoi(header); err == nil {
+		return max(time.Duration(seconds)*time.Second, 0)
+	}
+	if date, err := http.ParseTime(header); err == nil {
+		return max(date.Sub(now), 0)
+	}
+	return defaultRetryAfter
+}
+
+// checkErrorMessage shortens the common reasons a link check got no response
+func checkErrorMessage(err error) string {
+	errorMsg := err.Error()
+	if strings.Contains(errorMsg, "context deadline exceeded") {
+		errorMsg = "Link check timeout"
+	} else if strings.Contains(errorMsg, "no such host") {
+		errorMsg = "Host not found"
+	} else if strings.Contains(errorMsg, "connection refused") {
+		errorMsg = "Connection refused"
+	}
+	return errorMsg
+}
@@ -0,0 +1,167 @@
+package analyzer
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRateLimitedLinks(t *testing.T) {
+	var requests atomic.Int32
+	var retryAfterHeader string
+	succeedOnRetry := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) > 1 && succeedOnRetry {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		if retryAfterHeader != "" {
+			w.Header().Set("Retry-After", retryAfterHeader)
+		}
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	check := func() []BrokenLink {
+		requests.Store(0)
+		a := New()
+		return a.checkBrokenLinks(context.Background(), []string{server.URL + "/page"}, &LinkStats{}, nil)
+	}
+
+	t.Run("retried once after Retry-After", func(t *testing.T) {
+		retryAfterHeader, succeedOnRetry = "0", true
+		assert.Empty(t, check())
+		assert.EqualValues(t, 2, requests.Load())
+	})
+
+	t.Run("still rate limited after the retry", func(t *testing.T) {
+		retryAfterHeader, succeedOnRetry = "0", false
+		broken := check()
+		require.Len(t, broken, 1)
+		assert.True(t, broken[0].RateLimited)
+		assert.Equal(t, http.StatusTooManyRequests, broken[0].StatusCode)
+		assert.EqualValues(t, 2, requests.Load())
+	})
+
+	t.Run("long waits are not retried", func(t *testing.T) {
+		retryAfterHeader, succeedOnRetry = "3600", true
+		broken := check()
+		require.Len(t, broken, 1)
+		assert.True(t, broken[0].RateLimited)
+		assert.EqualValues(t, 1, requests.Load())
+	})
+
+	t.Run("dead links are not rate limited", func(t *testing.T) {
+		missing := httptest.NewServer(http.NotFoundHandler())
+		defer missing.Close()
+		broken := New().checkBrokenLinks(context.Background(), []string{missing.URL}, &LinkStats{}, nil)
+		require.Len(t, broken, 1)
+		assert.False(t, broken[0].RateLimited)
+	})
+}
+
+func TestRetryAfter(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		header string
+		want   time.Duration
+	}{
+		{"", defaultRetryAfter},
+		{"5", 5 * time.Second},
+		{" 2 ", 2 * time.Second},
+		{"-3", 0},
+		{"Wed, 01 May 2024 12:00:30 GMT", 30 * time.Second},
+		{"Wed, 01 May 2024 11:00:00 GMT", 0},
+		{"soon", defaultRetryAfter},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, retryAfter(tt.header, now), tt.header)
+	}
+}
+
+// memoryLinkCache is a LinkCache holding verdicts in a map
+type memoryLinkCache struct {
+	mu       sync.Mutex
+	verdicts map[string]*BrokenLink
+	stored   map[string]*BrokenLink
+}
+
+func (c *memoryLinkCache) LookupLinks(ctx context.Context, links []string) (map[string]*BrokenLink, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	found := make(map[string]*BrokenLink)
+	for _, link := range links {
+		if verdict, ok := c.verdicts[link]; ok {
+			found[link] = verdict
+		}
+	}
+	return found, nil
+}
+
+func (c *memoryLinkCache) StoreLinks(ctx context.Context, verdicts map[string]*BrokenLink) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.stored = verdicts
+	return nil
+}
+
+func TestLinkCache(t *testing.T) {
+	var mu sync.Mutex
+	requested := map[string]bool{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requested[r.URL.Path] = true
+		mu.Unlock()
+		switch r.URL.Path {
+		case "/gone":
+			w.WriteHeader(http.StatusNotFound)
+		case "/down":
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	cache := &memoryLinkCache{verdicts: map[string]*BrokenLink{
+		server.URL + "/cached-ok":     nil,
+		server.URL + "/cached-broken": {URL: server.URL + "/cached-broken", StatusCode: http.StatusGone, Error: "410 Gone"},
+	}}
+	links := []string{
+		server.URL + "/cached-ok", server.URL + "/cached-broken",
+		server.URL + "/ok", server.URL + "/gone", server.URL + "/down",
+	}
+	stats := LinkStats{}
+	broken := New(WithLinkCache(cache)).checkBrokenLinks(context.Background(), links, &stats, nil)
+
+	var urls []string
+	for _, link := range broken {
+		urls = append(urls, link.URL)
+	}
+	assert.ElementsMatch(t, []string{server.URL + "/cached-broken", server.URL + "/gone", server.URL + "/down"}, urls)
+	assert.Equal(t, 2, stats.Cached)
+	assert.Equal(t, 5, stats.Checked)
+	assert.False(t, requested["/cached-ok"])
+	assert.False(t, requested["/cached-broken"])
+
+	// Server errors may be gone by the next crawl and are checked again
+	require.Len(t, cache.stored, 2)
+	assert.Nil(t, cache.stored[server.URL+"/ok"])
+	require.NotNil(t, cache.stored[server.URL+"/gone"])
+	assert.Equal(t, http.StatusNotFound, cache.stored[server.URL+"/gone"].StatusCode)
+}
+
+func TestCacheableVerdict(t *testing.T) {
+	assert.True(t, cacheableVerdict(nil))
+	assert.True(t, cacheableVerdict(&BrokenLink{StatusCode: http.StatusNotFound}))
+	assert.True(t, cacheableVerdict(&BrokenLink{StatusCode: http.StatusForbidden}))
+	assert.False(t, cacheableVerdict(&BrokenLink{StatusCode: http.StatusTooManyRequests, RateLimited: true}))
+	assert.False(t, cacheableVerdict(&BrokenLink{StatusCode: http.StatusRequestTimeout}))
+	assert.False(t, cacheableVerdict(&BrokenLink{StatusCode: http.StatusBadGateway}))
+	assert.False(t, cacheableVerdict(&BrokenLink{Error: "Host not found"}))
+}
@@ -0,0 +1,82 @@
+package analyzer
+
+import (
+	"errors"
+	"fmt"
+	"net/netip"
+	"syscall"
+)
+
+// ErrPrivateAddress is returned by a NewTransport that is public only for connections to a
+// PrivateAddr. The check runs on the address actually dialed, so host names resolving to internal
+// hosts, and redirects to them, are caught too. With HTTP_PROXY set the proxy is dialed instead, and
+// it must be on a public address.
+var ErrPrivateAddress = errors.New("private network address")
+
+// refusePrivate is a net.Dialer Control function failing connections to a PrivateAddr
+func refusePrivate(network, address string, _ syscall.RawConn) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return err
+	}
+	if PrivateAddr(addrPort.Addr()) {
+		return fmt.Errorf("%s: %w", addrPort.Addr(), ErrPrivateAddress)
+	}
+	return nil
+}
+
+var (
+	// nonPublic lists the special-purpose ranges netip.Addr has no method for
+	nonPublic = []netip.Prefix{
+		netip.MustParsePrefix("0.0.0.0/8"),       // "this network"
+		netip.MustParsePrefix("100.64.0.0/10"),   // carrier-grade NAT
+		netip.MustParsePrefix("192.0.0.0/24"),    // IETF protocol assignments
+		netip.MustParsePrefix("192.0.2.0/24"),    // documentation
+		netip.MustParsePrefix("198.18.0.0/15"),   // benchmarking
+		netip.MustParsePrefix("198.51.100.0/24"), // documentation
+		netip.MustParsePrefix("203.0.113.0/24"),  // documentation
+		netip.MustParsePrefix("240.0.0.0/4"),     // reserved, and the broadcast address
+		netip.MustParsePrefix("::/96"),           // IPv4-compatible (deprecated)
+		netip.MustParsePrefix("100::/64"),        // discard
+		netip.MustParsePrefix("2001:db8::/32"),   // documentation
+		netip.MustParsePrefix("fec0::/10"),       // site-local (deprecated)
+	}
+	nat64     = netip.MustParsePrefix("64:ff9b::/96")
+	sixToFour = netip.MustParsePrefix("2002::/16")
+)
+
+// PrivateAddr reports whether addr is not a public internet address: loopback (127.0.0.0/8, ::1),
+// private (RFC 1918, and fc00::/7 unique local), link-local (169.254.0.0/16, fe80::/10), multicast,
+// unspecified or another special-purpose range. IPv4 addresses carried in IPv6 (IPv4-mapped, NAT64
+// and 6to4) are judged by the IPv4 address.
+func PrivateAddr(addr netip.Addr) bool {
+	addr = embeddedIPv4(addr.WithZone(""))
+	if !addr.IsValid() || addr.IsLoopback() || addr.IsPrivate() || addr.IsUnspecified() ||
+		addr.IsLinkLocalUnicast() || addr.IsMulticast() {
+		return true
+	}
+	for _, prefix := range nonPublic {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// embeddedIPv4 returns the IPv4 address an IPv6 address reaches, or addr itself
+func embeddedIPv4(addr netip.Addr) netip.Addr {
+	if addr.Is4In6() {
+		return addr.Unmap()
+	}
+	if !addr.Is6() {
+		return addr
+	}
+	b := addr.As16()
+	switch {
+	case nat64.Contains(addr):
+		return netip.AddrFrom4([4]byte(b[12:16]))
+	case sixToFour.Contains(addr):
+		return netip.AddrFrom4([4]byte(b[2:6]))
+	}
+	return addr
+}
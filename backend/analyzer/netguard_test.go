@@ -0,0 +1,64 @@
+package analyzer
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrivateAddr(t *testing.T) {
+	private := []string{
+		"127.0.0.1", "10.1.2.3", "172.16.0.1", "192.168.1.1", "169.254.169.254", "100.64.0.1", "0.0.0.0",
+		"224.0.0.1", "255.255.255.255",
+		"::1", "::", "fd00::1", "fc00::1", "fe80::1", "fe80::1%eth0", "ff02::1", "fec0::1", "2001:db8::1",
+		"::ffff:127.0.0.1", "::ffff:10.0.0.1", "64:ff9b::a00:1", "2002:c0a8:101::1", "::127.0.0.1",
+	}
+	for _, addr := range private {
+		assert.True(t, PrivateAddr(netip.MustParseAddr(addr)), addr)
+	}
+
+	public := []string{
+		"8.8.8.8", "1.1.1.1", "172.32.0.1", "100.128.0.1",
+		"2001:4860:4860::8888", "2606:4700:4700::1111", "::ffff:8.8.8.8", "64:ff9b::808:808", "2002:808:808::1",
+	}
+	for _, addr := range public {
+		assert.False(t, PrivateAddr(netip.MustParseAddr(addr)), addr)
+	}
+
+	assert.True(t, PrivateAddr(netip.Addr{}), "the zero address")
+}
+
+func TestNewTransport(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(`<html><head><title>Internal</title></head></html>`))
+	}))
+	defer server.Close()
+	client := &http.Client{Transport: NewTransport(nil, true)}
+
+	t.Run("page on a private address is not allowed", func(t *testing.T) {
+		_, err := Analyze(context.Background(), server.URL, WithHTTPClient(client))
+		var analyzeErr *Error
+		require.ErrorAs(t, err, &analyzeErr)
+		assert.Equal(t, ErrorNotAllowed, analyzeErr.Kind)
+		assert.ErrorIs(t, err, ErrPrivateAddress)
+		assert.Contains(t, analyzeErr.Error(), "private network address")
+	})
+
+	t.Run("host names are checked on the address dialed", func(t *testing.T) {
+		u, err := url.Parse(server.URL)
+		require.NoError(t, err)
+		_, err = client.Get("http://localhost:" + u.Port())
+		assert.ErrorIs(t, err, ErrPrivateAddress)
+	})
+
+	t.Run("links into private networks are not reported broken", func(t *testing.T) {
+		assert.Nil(t, checkSingleLink(context.Background(), client, server.URL+"/missing", DefaultUserAgent))
+	})
+}
@@ -0,0 +1,240 @@
+package analyzer
+
+import (
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// DefaultUserAgent is sent when Options.UserAgent is empty
+const DefaultUserAgent = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36"
+
+// Options tunes how pages are analyzed. Zero values fall back to the documented defaults.
+type Options struct {
+	// PageTimeout bounds the whole analysis including link checks (default 90s)
+	PageTimeout time.Duration
+	// RequestTimeout bounds fetching the page itself (default 60s)
+	RequestTimeout time.Duration
+	// LinkCheckTimeout bounds each broken link check (default 15s)
+	LinkCheckTimeout time.Duration
+	// MaxConcurrentLinkChecks is the number of parallel broken link checks each host starts with
+	// (default 10). Hosts answering quickly get up to twice as many, hosts answering 429, 503 or
+	// slowly fewer, down to one; at most four times as many run across all hosts.
+	MaxConcurrentLinkChecks int
+	// UserAgent is sent with every request (default DefaultUserAgent)
+	UserAgent string
+	// TreatSubdomainsAsInternal counts links to other hosts of the page's registrable domain, such as
+	// www, app or blog subdomains, as internal
+	TreatSubdomainsAsInternal bool
+	// InternalDomains are further domains whose links, subdomains included, count as internal
+	InternalDomains []string
+	// IgnoreQueryParams names query parameters, such as utm_*, that do not change the page; they are
+	// removed from links before links are compared. A trailing * matches any suffix.
+	IgnoreQueryParams []string
+	// IgnoreTrailingSlash treats /page and /page/ as the same page
+	IgnoreTrailingSlash bool
+	// SkipLinkChecks counts links without checking them for broken status; Result.BrokenLinks stays empty
+	SkipLinkChecks bool
+	// CheckAssets also checks the page's images, scripts and <link> files; see Result.BrokenAssets
+	CheckAssets bool
+	// CheckDocuments fetches the headers of linked PDFs and office documents; see Result.Documents
+	CheckDocuments bool
+	// MaxDocumentBytes is the size above which a linked document is reported (default 10 MB)
+	MaxDocumentBytes int64
+	// StreamAbove is the page size in bytes above which the page is tokenized as it is read instead
+	// of parsed into a DOM, bounding the memory a huge page takes; see Result.Streamed (0 never does)
+	StreamAbove int64
+	// Exclusions matches links that are counted but never checked for broken status
+	Exclusions *LinkExcluder
+	// Rules are evaluated against the page; their outcomes are in Result.Rules
+	Rules []Rule
+	// Keywords are counted in the title, headings, meta description and body; see Result.Keywords
+	Keywords []string
+	// ThreatChecker, when set, looks up the page and its external links; see Result.Safety
+	ThreatChecker ThreatChecker
+	// FieldData, when set, looks up the Core Web Vitals of the page's origin; see Result.WebVitals
+	FieldData FieldDataSource
+	// Registry, when set, looks up the registration of the page's domain; see Result.Registration
+	Registry DomainRegistry
+	// IPLocator, when set, looks up the network of the server that answered; see Result.Hosting
+	IPLocator IPLocator
+	// Archive, when set, suggests archived copies of broken links; see BrokenLink.Suggestions
+	Archive Archive
+	// LinkCache, when set, answers links checked recently instead of checking them again
+	LinkCache LinkCache
+	// Progress, when set, is called as the analysis advances. Calls are serialized but may come
+	// from link-check goroutines, so the callback must be quick and must not block.
+	Progress func(Progress)
+	// Resolver looks up the DNS records of the page's host; see Result.DNS (default net.DefaultResolver)
+	Resolver Resolver
+	// HTTPClient supplies the transport, redirect policy and cookie jar used for every request
+	// (default: a client sharing one NewTransport with every other analysis). Its Timeout is ignored;
+	// the timeouts above apply.
+	HTTPClient *http.Client
+	// AllowTarget, when set, must accept the page's URL and every redirect followed to fetch it; link
+	// checks are not restricted
+	AllowTarget func(*url.URL) error
+}
+
+// withDefaults fills in unset values
+func (o Options) withDefaults() Options {
+	if o.PageTimeout <= 0 {
+		o.PageTimeout = 90 * time.Second
+	}
+	if o.RequestTimeout <= 0 {
+		o.RequestTimeout = 60 * time.Second
+	}
+	if o.LinkCheckTimeout <= 0 {
+		o.LinkCheckTimeout = 15 * time.Second
+	}
+	if o.MaxConcurrentLinkChecks <= 0 {
+		o.MaxConcurrentLinkChecks = 10
+	}
+	if o.MaxDocumentBytes <= 0 {
+		o.MaxDocumentBytes = 10 << 20
+	}
+	if o.UserAgent == "" {
+		o.UserAgent = DefaultUserAgent
+	}
+	if o.HTTPClient == nil {
+		o.HTTPClient = &http.Client{Transport: defaultTransport}
+	}
+	if o.Resolver == nil {
+		o.Resolver = net.DefaultResolver
+	}
+	return o
+}
+
+// Option changes one setting of an Analyzer
+type Option func(*Options)
+
+// WithOptions replaces every setting at once; later options still apply on top
+func WithOptions(opts Options) Option {
+	return func(o *Options) { *o = opts }
+}
+
+// WithPageTimeout bounds the whole analysis including link checks
+func WithPageTimeout(d time.Duration) Option {
+	return func(o *Options) { o.PageTimeout = d }
+}
+
+// WithRequestTimeout bounds fetching the page itself
+func WithRequestTimeout(d time.Duration) Option {
+	return func(o *Options) { o.RequestTimeout = d }
+}
+
+// WithLinkCheckTimeout bounds each broken link check
+func WithLinkCheckTimeout(d time.Duration) Option {
+	return func(o *Options) { o.LinkCheckTimeout = d }
+}
+
+// WithMaxConcurrentLinkChecks sets the parallel broken link checks each host starts with
+func WithMaxConcurrentLinkChecks(n int) Option {
+	return func(o *Options) { o.MaxConcurrentLinkChecks = n }
+}
+
+// WithUserAgent sets the User-Agent header sent with every request
+func WithUserAgent(userAgent string) Option {
+	return func(o *Options) { o.UserAgent = userAgent }
+}
+
+// WithSubdomainsAsInternal counts links to other subdomains of the page's domain as internal
+func WithSubdomainsAsInternal(enabled bool) Option {
+	return func(o *Options) { o.TreatSubdomainsAsInternal = enabled }
+}
+
+// WithInternalDomains counts links to the domains and their subdomains as internal
+func WithInternalDomains(domains ...string) Option {
+	return func(o *Options) { o.InternalDomains = domains }
+}
+
+// WithIgnoreQueryParams removes the named query parameters from links before they are compared
+func WithIgnoreQueryParams(params ...string) Option {
+	return func(o *Options) { o.IgnoreQueryParams = params }
+}
+
+// WithIgnoreTrailingSlash treats /page and /page/ as the same page
+func WithIgnoreTrailingSlash(enabled bool) Option {
+	return func(o *Options) { o.IgnoreTrailingSlash = enabled }
+}
+
+// WithCheckAssets also checks the page's images, scripts and stylesheets for broken assets
+func WithCheckAssets(enabled bool) Option {
+	return func(o *Options) { o.CheckAssets = enabled }
+}
+
+// WithCheckDocuments checks the media type and size of linked PDFs and office documents
+func WithCheckDocuments(enabled bool) Option {
+	return func(o *Options) { o.CheckDocuments = enabled }
+}
+
+// WithMaxDocumentBytes sets the size above which a linked document is reported
+func WithMaxDocumentBytes(n int64) Option {
+	return func(o *Options) { o.MaxDocumentBytes = n }
+}
+
+// WithStreamAbove tokenizes pages larger than n bytes without building a DOM
+func WithStreamAbove(n int64) Option {
+	return func(o *Options) { o.StreamAbove = n }
+}
+
+// WithExclusions skips broken link checks for matching links
+func WithExclusions(exclusions *LinkExcluder) Option {
+	return func(o *Options) { o.Exclusions = exclusions }
+}
+
+// WithRules evaluates user-defined selector rules against the page
+func WithRules(rules ...Rule) Option {
+	return func(o *Options) { o.Rules = rules }
+}
+
+// WithKeywords reports occurrences and density of target keywords or phrases
+func WithKeywords(keywords ...string) Option {
+	return func(o *Options) { o.Keywords = keywords }
+}
+
+// WithThreatChecker looks the page and its external links up in a threat list
+func WithThreatChecker(checker ThreatChecker) Option {
+	return func(o *Options) { o.ThreatChecker = checker }
+}
+
+// WithFieldData looks up the real-user Core Web Vitals of the page's origin
+func WithFieldData(source FieldDataSource) Option {
+	return func(o *Options) { o.FieldData = source }
+}
+
+// WithRegistry looks up who registered the page's domain and when it expires
+func WithRegistry(registry DomainRegistry) Option {
+	return func(o *Options) { o.Registry = registry }
+}
+
+// WithIPLocator looks up the ASN, hosting provider and country of the page's server
+func WithIPLocator(locator IPLocator) Option {
+	return func(o *Options) { o.IPLocator = locator }
+}
+
+// WithArchive suggests archived copies of broken links as replacements
+func WithArchive(archive Archive) Option {
+	return func(o *Options) { o.Archive = archive }
+}
+
+// WithLinkCache takes the verdicts of links checked recently from cache and stores new ones in it
+func WithLinkCache(cache LinkCache) Option {
+	return func(o *Options) { o.LinkCache = cache }
+}
+
+// WithResolver looks DNS records up through resolver instead of the system resolver
+func WithResolver(resolver Resolver) Option {
+	return func(o *Options) { o.Resolver = resolver }
+}
+
+// WithProgress reports progress as the analysis advances
+func WithProgress(fn func(Progress)) Option {
+	return func(o *Options) { o.Progress = fn }
+}
+
+// WithHTTPClient sends requests through the client's transport
+func WithHTTPClient(client *http.Client) Option {
+	return func(o *Options) { o.HTTPClient = client }
+}
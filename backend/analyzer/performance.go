@@ -0,0 +1,62 @@
+package analyzer
+
+import (
+	"net/http"
+	"strings"
+)
+
+// Performance describes how the page is delivered
+type Performance struct {
+	// Protocol is the HTTP version the final response was served over, e.g. HTTP/2.0
+	Protocol string `json:"protocol"`
+	// HTTP2 is true when the server negotiated HTTP/2 with the analyzer
+	HTTP2 bool `json:"http2"`
+	// HTTP3 is true when the server advertises HTTP/3 in its Alt-Svc header
+	HTTP3 bool `json:"http3"`
+	// AltSvc lists the alternative protocols the server advertises, e.g. h3
+	AltSvc []string `json:"alt_svc"`
+	// Resources are the compression and caching headers of the page and its same-site stylesheets and scripts
+	Resources []ResourceAudit `json:"resources"`
+	// Embeds are the page's iframes and embedded objects, up to 50
+	Embeds []Embed `json:"embeds"`
+	// Findings are actionable fixes, such as enabling compression on a script or loading a video
+	// embed lazily
+	Findings []string `json:"findings"`
+}
+
+// detectProtocols reports the negotiated protocol and the alternatives advertised by the server.
+// HTTP/2 over cleartext is not attempted, so plain http pages always report HTTP/1.x.
+func detectProtocols(res *http.Response) Performance {
+	p := Performance{
+		Protocol: res.Proto,
+		HTTP2:    res.ProtoMajor == 2,
+		AltSvc:   altSvcProtocols(res.Header.Values("Alt-Svc")),
+	}
+	for _, protocol := range p.AltSvc {
+		// Drafts were advertised as h3-29 and similar before RFC 9114
+		if protocol == "h3" || strings.HasPrefix(protocol, "h3-") {
+			p.HTTP3 = true
+		}
+	}
+	return p
+}
+
+// altSvcProtocols extracts the distinct protocol IDs of Alt-Svc header values (RFC 7838),
+// e.g. `h3=":443"; ma=86400, h3-29=":443"` gives h3 and h3-29
+func altSvcProtocols(values []string) []string {
+	protocols := []string{}
+	seen := make(map[string]bool)
+	for _, value := range values {
+		for _, alternative := range strings.Split(value, ",") {
+			id, _, _ := strings.Cut(alternative, "=")
+			id = strings.ToLower(strings.TrimSpace(id))
+			// "clear" withdraws all alternatives
+			if id == "" || id == "clear" || seen[id] {
+				continue
+			}
+			seen[id] = true
+			protocols = append(protocols, id)
+		}
+	}
+	return protocols
+}
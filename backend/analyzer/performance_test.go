@@ -0,0 +1,58 @@
+package analyzer
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAltSvcProtocols(t *testing.T) {
+	t.Run("lists distinct protocol IDs", func(t *testing.T) {
+		protocols := altSvcProtocols([]string{`h3=":443"; ma=86400, h3-29=":443"; ma=86400`, `h3=":8443", h2="alt.example.com:443"`})
+		assert.Equal(t, []string{"h3", "h3-29", "h2"}, protocols)
+	})
+
+	t.Run("clear advertises nothing", func(t *testing.T) {
+		assert.Empty(t, altSvcProtocols([]string{"clear"}))
+		assert.Empty(t, altSvcProtocols(nil))
+	})
+}
+
+func TestDetectProtocols(t *testing.T) {
+	page := func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Alt-Svc", `h3-29=":443"; ma=3600`)
+		w.Write([]byte("<html><head><title>Fast</title></head></html>"))
+	}
+
+	t.Run("HTTP/2 over TLS with HTTP/3 advertised", func(t *testing.T) {
+		server := httptest.NewUnstartedServer(http.HandlerFunc(page))
+		server.EnableHTTP2 = true
+		server.StartTLS()
+		defer server.Close()
+
+		result, err := Analyze(context.Background(), server.URL, WithHTTPClient(server.Client()))
+		require.NoError(t, err)
+		assert.Equal(t, "HTTP/2.0", result.Performance.Protocol)
+		assert.True(t, result.Performance.HTTP2)
+		assert.True(t, result.Performance.HTTP3)
+		assert.Equal(t, []string{"h3-29"}, result.Performance.AltSvc)
+	})
+
+	t.Run("plain HTTP/1.1", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("<html></html>"))
+		}))
+		defer server.Close()
+
+		result, err := Analyze(context.Background(), server.URL)
+		require.NoError(t, err)
+		assert.Equal(t, "HTTP/1.1", result.Performance.Protocol)
+		assert.False(t, result.Performance.HTTP2)
+		assert.False(t, result.Performance.HTTP3)
+		assert.Empty(t, result.Performance.AltSvc)
+	})
+}
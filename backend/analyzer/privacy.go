@@ -0,0 +1,164 @@
+package analyzer
+
+import (
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// Privacy lists the cookies the page sets and the trackers it loads
+type Privacy struct {
+	// Cookies come from the Set-Cookie headers of the page response. Cookies set by scripts or
+	// by third-party resources need a rendering browser and are not included.
+	Cookies           []Cookie  `json:"cookies"`
+	SessionCookies    int       `json:"session_cookies"`
+	PersistentCookies int       `json:"persistent_cookies"`
+	Trackers          []Tracker `json:"trackers"`
+}
+
+// Cookie describes a cookie without its value
+type Cookie struct {
+	Name   string `json:"name"`
+	Domain string `json:"domain,omitempty"`
+	Path   string `json:"path,omitempty"`
+	// Session cookies have neither Expires nor Max-Age and are dropped when the browser closes
+	Session  bool       `json:"session"`
+	Expires  *time.Time `json:"expires,omitempty"`
+	Secure   bool       `json:"secure"`
+	HttpOnly bool       `json:"http_only"`
+	SameSite string     `json:"same_site,omitempty"`
+}
+
+// Tracker is a known analytics or advertising script found on the page
+type Tracker struct {
+	Name     string `json:"name"`
+	Category string `json:"category"` // analytics, advertising or tag_manager
+	// Evidence is the script URL or the inline snippet that gave the tracker away
+	Evidence string `json:"evidence"`
+}
+
+// trackerSignature recognizes a tracker by the hosts and paths it loads from or by its inline code
+type trackerSignature struct {
+	name     string
+	category string
+	sources  []string       // substrings of script, img or iframe URLs
+	inline   *regexp.Regexp // matched against inline scripts
+}
+
+// trackerSignatures are the trackers detected, in reporting order
+var trackerSignatures = []trackerSignature{
+	{"Google Analytics", "analytics", []string{"google-analytics.com/analytics.js", "google-analytics.com/ga.js", "googletagmanager.com/gtag/js"},
+		regexp.MustCompile(`gtag\(\s*['"]config['"]\s*,\s*['"](G|UA)-|GoogleAnalyticsObject`)},
+	{"Google Tag Manager", "tag_manager", []string{"googletagmanager.com/gtm.js", "googletagmanager.com/ns.html"},
+		regexp.MustCompile(`GTM-[A-Z0-9]{4,}`)},
+	{"Google Ads", "advertising", []string{"googleadservices.com", "googlesyndication.com", "doubleclick.net"},
+		regexp.MustCompile(`gtag\(\s*['"]config['"]\s*,\s*['"]AW-`)},
+	{"Facebook Pixel", "advertising", []string{"connect.facebook.net/", "facebook.com/tr?", "facebook.com/tr/"},
+		regexp.MustCompile(`fbq\(\s*['"]init['"]`)},
+	{"LinkedIn Insight", "advertising", []string{"snap.licdn.com", "px.ads.linkedin.com"}, regexp.MustCompile(`_linkedin_partner_id`)},
+	{"TikTok Pixel", "advertising", []string{"analytics.tiktok.com"}, regexp.MustCompile(`ttq\.load\(`)},
+	{"X (Twitter) Pixel", "advertising", []string{"static.ads-twitter.com", "analytics.twitter.com"}, regexp.MustCompile(`twq\(\s*['"]init['"]`)},
+	{"Microsoft Advertising", "advertising", []string{"bat.bing.com"}, nil},
+	{"Microsoft Clarity", "analytics", []string{"clarity.ms/tag"}, regexp.MustCompile(`clarity\.ms/tag`)},
+	{"Hotjar", "analytics", []string{"static.hotjar.com"}, regexp.MustCompile(`static\.hotjar\.com|_hjSettings`)},
+	{"Matomo", "analytics", []string{"matomo.js", "piwik.js"}, regexp.MustCompile(`_paq\.push`)},
+	{"Segment", "analytics", []string{"cdn.segment.com"}, regexp.MustCompile(`analytics\.load\(`)},
+	{"Mixpanel", "analytics", []string{"cdn.mxpnl.com", "mixpanel.com/libs"}, regexp.MustCompile(`mixpanel\.init\(`)},
+}
+
+// auditPrivacy records the response's cookies and the trackers the page loads
+func auditPrivacy(doc *goquery.Document, res *http.Response) Privacy {
+	p := Privacy{Cookies: []Cookie{}, Trackers: []Tracker{}}
+
+	for _, c := range res.Cookies() {
+		cookie := Cookie{
+			Name:     c.Name,
+			Domain:   c.Domain,
+			Path:     c.Path,
+			Secure:   c.Secure,
+			HttpOnly: c.HttpOnly,
+			SameSite: sameSiteName(c.SameSite),
+		}
+		switch {
+		case c.MaxAge > 0:
+			t := fetchTime(res).Add(time.Duration(c.MaxAge) * time.Second)
+			cookie.Expires = &t
+		case c.MaxAge < 0:
+			// Max-Age=0 deletes the cookie; it is reported as expiring at once
+			t := fetchTime(res)
+			cookie.Expires = &t
+		case !c.Expires.IsZero():
+			t := c.Expires.UTC()
+			cookie.Expires = &t
+		default:
+			cookie.Session = true
+		}
+		if cookie.Session {
+			p.SessionCookies++
+		} else {
+			p.PersistentCookies++
+		}
+		p.Cookies = append(p.Cookies, cookie)
+	}
+
+	var sources []string
+	doc.Find("script[src], img[src], iframe[src], noscript img[src]").Each(func(_ int, s *goquery.Selection) {
+		sources = append(sources, s.AttrOr("src", ""))
+	})
+	var inline []string
+	doc.Find("script:not([src])").Each(func(_ int, s *goquery.Selection) {
+		inline = append(inline, s.Text())
+	})
+
+	for _, sig := range trackerSignatures {
+		if evidence := findTracker(sig, sources, inline); evidence != "" {
+			p.Trackers = append(p.Trackers, Tracker{Name: sig.name, Category: sig.category, Evidence: evidence})
+		}
+	}
+	return p
+}
+
+// findTracker returns the script URL or inline snippet that matches sig, or "" when it is absent
+func findTracker(sig trackerSignature, sources, inline []string) string {
+	for _, src := range sources {
+		lower := strings.ToLower(src)
+		for _, pattern := range sig.sources {
+			if strings.Contains(lower, pattern) {
+				return truncateURL(src)
+			}
+		}
+	}
+	if sig.inline == nil {
+		return ""
+	}
+	for _, script := range inline {
+		if loc := sig.inline.FindStringIndex(script); loc != nil {
+			return strings.TrimSpace(script[loc[0]:loc[1]])
+		}
+	}
+	return ""
+}
+
+// fetchTime is when the response was generated according to its Date header, or now
+func fetchTime(res *http.Response) time.Time {
+	if date, err := http.ParseTime(res.Header.Get("Date")); err == nil {
+		return date.UTC()
+	}
+	return time.Now().UTC()
+}
+
+// sameSiteName spells out a SameSite attribute, or "" when the cookie has none
+func sameSiteName(mode http.SameSite) string {
+	switch mode {
+	case http.SameSiteLaxMode:
+		return "Lax"
+	case http.SameSiteStrictMode:
+		return "Strict"
+	case http.SameSiteNoneMode:
+		return "None"
+	}
+	return ""
+}
@@ -0,0 +1,76 @@
+package analyzer
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrivacy(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Date", "Mon, 05 Jan 2026 10:00:00 GMT")
+		w.Header().Add("Set-Cookie", "session_id=secret; Path=/; Secure; HttpOnly; SameSite=Lax")
+		w.Header().Add("Set-Cookie", "prefs=dark; Max-Age=3600")
+		w.Header().Add("Set-Cookie", "visitor=1; Expires=Wed, 01 Jan 2027 00:00:00 GMT; Domain=example.com")
+		w.Write([]byte(`<html><head>
+			<script async src="https://www.googletagmanager.com/gtag/js?id=G-ABC123"></script>
+			<script>window.dataLayer = []; gtag('config', 'G-ABC123');</script>
+			<script>!function(f,b,e,v,n,t,s){}(window); fbq('init', '1234567890');</script>
+			<script src="/app.js"></script>
+		</head><body>
+			<noscript><img src="https://www.facebook.com/tr?id=1234567890&ev=PageView"></noscript>
+		</body></html>`))
+	}))
+	defer server.Close()
+
+	result, err := Analyze(context.Background(), server.URL)
+	require.NoError(t, err)
+	p := result.Privacy
+
+	require.Len(t, p.Cookies, 3)
+	assert.Equal(t, 1, p.SessionCookies)
+	assert.Equal(t, 2, p.PersistentCookies)
+
+	session := p.Cookies[0]
+	assert.Equal(t, "session_id", session.Name)
+	assert.True(t, session.Session)
+	assert.True(t, session.Secure)
+	assert.True(t, session.HttpOnly)
+	assert.Equal(t, "Lax", session.SameSite)
+	assert.Nil(t, session.Expires)
+
+	require.NotNil(t, p.Cookies[1].Expires)
+	assert.Equal(t, time.Date(2026, 1, 5, 11, 0, 0, 0, time.UTC), *p.Cookies[1].Expires)
+	assert.Equal(t, "example.com", p.Cookies[2].Domain)
+	assert.Equal(t, 2027, p.Cookies[2].Expires.Year())
+
+	names := []string{}
+	for _, tracker := range p.Trackers {
+		names = append(names, tracker.Name)
+	}
+	assert.Equal(t, []string{"Google Analytics", "Facebook Pixel"}, names)
+	assert.Equal(t, "https://www.googletagmanager.com/gtag/js?id=G-ABC123", p.Trackers[0].Evidence)
+
+	// Cookie values are never reported
+	encoded, err := json.Marshal(p)
+	require.NoError(t, err)
+	assert.NotContains(t, string(encoded), "secret")
+}
+
+func TestPrivacyWithoutTrackers(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`<html><body><script>console.log("hi")</script></body></html>`))
+	}))
+	defer server.Close()
+
+	result, err := Analyze(context.Background(), server.URL)
+	require.NoError(t, err)
+	assert.Empty(t, result.Privacy.Cookies)
+	assert.Empty(t, result.Privacy.Trackers)
+}
@@ -0,0 +1,59 @@
+package analyzer
+
+import "sync"
+
+// Analysis stages reported through Options.Progress
+const (
+	StageFetching      = "fetching"
+	StageParsing       = "parsing"
+	StageCheckingLinks = "checking_links"
+	StageDone          = "done"
+)
+
+// Progress is a snapshot of how far an analysis has got
+type Progress struct {
+	Stage           string
+	LinksDiscovered int // HTTP(S) links found on the page
+	LinksToCheck    int // links being checked for broken status (discovered minus exclusions)
+	LinksChecked    int
+}
+
+// Percent estimates completion: fetching and parsing make up the first 10%, link checks the rest
+func (p Progress) Percent() int {
+	switch p.Stage {
+	case StageFetching:
+		return 0
+	case StageParsing:
+		return 5
+	case StageCheckingLinks:
+		if p.LinksToCheck == 0 {
+			return 10
+		}
+		return 10 + 90*p.LinksChecked/p.LinksToCheck
+	case StageDone:
+		return 100
+	}
+	return 0
+}
+
+// progressTracker serializes updates so callbacks always see a monotonic sequence
+type progressTracker struct {
+	mu       sync.Mutex
+	progress Progress
+	report   func(Progress)
+}
+
+func newProgressTracker(report func(Progress)) *progressTracker {
+	return &progressTracker{report: report}
+}
+
+// update applies fn and reports the result. It is safe to call from link-check goroutines.
+func (t *progressTracker) update(fn func(p *Progress)) {
+	if t == nil || t.report == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	fn(&t.progress)
+	t.report(t.progress)
+}
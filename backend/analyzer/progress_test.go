@@ -0,0 +1,71 @@
+package analyzer
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProgressPercent(t *testing.T) {
+	testCases := []struct {
+		name     string
+		progress Progress
+		expected int
+	}{
+		{name: "fetching", progress: Progress{Stage: StageFetching}, expected: 0},
+		{name: "parsing", progress: Progress{Stage: StageParsing}, expected: 5},
+		{name: "no links to check", progress: Progress{Stage: StageCheckingLinks}, expected: 10},
+		{name: "half checked", progress: Progress{Stage: StageCheckingLinks, LinksToCheck: 10, LinksChecked: 5}, expected: 55},
+		{name: "all checked", progress: Progress{Stage: StageCheckingLinks, LinksToCheck: 4, LinksChecked: 4}, expected: 100},
+		{name: "done", progress: Progress{Stage: StageDone}, expected: 100},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, tc.progress.Percent())
+		})
+	}
+}
+
+func TestAnalyzeReportsProgress(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/" {
+			w.Write([]byte(`<html><body>
+				<a href="/a">A</a>
+				<a href="/b">B</a>
+				<a href="/skip">Skip</a>
+			</body></html>`))
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	excluder, err := NewLinkExcluder([]LinkExclusion{{Pattern: "*/skip", PatternType: "glob"}})
+	assert.NoError(t, err)
+
+	var updates []Progress
+	_, err = Analyze(context.Background(), server.URL,
+		WithExclusions(excluder),
+		WithProgress(func(p Progress) { updates = append(updates, p) }),
+	)
+	assert.NoError(t, err)
+
+	if assert.NotEmpty(t, updates) {
+		assert.Equal(t, StageFetching, updates[0].Stage)
+
+		last := updates[len(updates)-1]
+		assert.Equal(t, StageDone, last.Stage)
+		assert.Equal(t, 3, last.LinksDiscovered)
+		assert.Equal(t, 2, last.LinksToCheck)
+		assert.Equal(t, 2, last.LinksChecked)
+	}
+
+	// Percent never goes backwards
+	for i := 1; i < len(updates); i++ {
+		assert.GreaterOrEqual(t, updates[i].Percent(), updates[i-1].Percent())
+	}
+}
@@ -0,0 +1,56 @@
+package analyzer
+
+import (
+	"context"
+	"net"
+	"strings"
+	"time"
+
+	"golang.org/x/net/publicsuffix"
+)
+
+// ExpiryWarning is how close to its expiry date a domain is reported as expiring soon
+const ExpiryWarning = 30 * 24 * time.Hour
+
+// DomainRegistry looks up the registration of a domain, such as over RDAP or WHOIS
+type DomainRegistry interface {
+	// LookupDomain returns the domain's registration, or nil without error when the registry has no record
+	LookupDomain(ctx context.Context, domain string) (*Registration, error)
+}
+
+// Registration is who registered the page's domain and when it expires
+type Registration struct {
+	// Domain is the registrable domain of the final host, e.g. example.co.uk
+	Domain    string     `json:"domain"`
+	Registrar string     `json:"registrar,omitempty"`
+	CreatedAt *time.Time `json:"created_at,omitempty"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	// ExpiringSoon is true when the domain expires within ExpiryWarning or has already expired
+	ExpiringSoon bool   `json:"expiring_soon"`
+	Found        bool   `json:"found"`
+	Error        string `json:"error,omitempty"`
+}
+
+// lookupRegistration looks up the registrable domain of host. It returns nil for IP address hosts.
+func lookupRegistration(ctx context.Context, registry DomainRegistry, host string, now time.Time) *Registration {
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	if net.ParseIP(host) != nil {
+		return nil
+	}
+	domain, err := publicsuffix.EffectiveTLDPlusOne(host)
+	if err != nil {
+		domain = host
+	}
+
+	registration, err := registry.LookupDomain(ctx, domain)
+	if err != nil {
+		return &Registration{Domain: domain, Error: err.Error()}
+	}
+	if registration == nil {
+		return &Registration{Domain: domain}
+	}
+	registration.Domain = domain
+	registration.Found = true
+	registration.ExpiringSoon = registration.ExpiresAt != nil && registration.ExpiresAt.Before(now.Add(ExpiryWarning))
+	return registration
+}
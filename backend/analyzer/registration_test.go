@@ -0,0 +1,63 @@
+package analyzer
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeRegistry answers from fixed registrations and records the domain it was asked about
+type fakeRegistry struct {
+	registrations map[string]*Registration
+	err           error
+	domain        string
+}
+
+func (f *fakeRegistry) LookupDomain(_ context.Context, domain string) (*Registration, error) {
+	f.domain = domain
+	return f.registrations[domain], f.err
+}
+
+func TestLookupRegistration(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	soon := now.Add(10 * 24 * time.Hour)
+	later := now.Add(200 * 24 * time.Hour)
+	registry := &fakeRegistry{registrations: map[string]*Registration{
+		"example.co.uk": {Registrar: "Example Registrar Ltd", ExpiresAt: &soon},
+		"example.com":   {Registrar: "Example Registrar, Inc.", ExpiresAt: &later},
+	}}
+
+	t.Run("looks up the registrable domain", func(t *testing.T) {
+		r := lookupRegistration(context.Background(), registry, "www.example.co.uk", now)
+		require.NotNil(t, r)
+		assert.Equal(t, "example.co.uk", registry.domain)
+		assert.Equal(t, "example.co.uk", r.Domain)
+		assert.Equal(t, "Example Registrar Ltd", r.Registrar)
+		assert.True(t, r.Found)
+		assert.True(t, r.ExpiringSoon)
+	})
+
+	t.Run("distant expiry is not a warning", func(t *testing.T) {
+		r := lookupRegistration(context.Background(), registry, "example.com", now)
+		assert.False(t, r.ExpiringSoon)
+	})
+
+	t.Run("unknown domains are not found", func(t *testing.T) {
+		r := lookupRegistration(context.Background(), registry, "example.org", now)
+		assert.False(t, r.Found)
+		assert.Empty(t, r.Error)
+	})
+
+	t.Run("lookup errors are reported", func(t *testing.T) {
+		r := lookupRegistration(context.Background(), &fakeRegistry{err: errors.New("rate limited")}, "example.com", now)
+		assert.Equal(t, "rate limited", r.Error)
+	})
+
+	t.Run("IP address hosts are skipped", func(t *testing.T) {
+		assert.Nil(t, lookupRegistration(context.Background(), registry, "127.0.0.1", now))
+	})
+}
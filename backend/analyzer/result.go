@@ -0,0 +1,125 @@
+package analyzer
+
+import "time"
+
+// SchemaVersion is the layout version of Result. Raise it when an analyzer is added or changes what
+// it reports, so stored results of older versions can be told apart and crawled again.
+const SchemaVersion = 1
+
+// Result is the analysis of one page
+type Result struct {
+	// URL is the analyzed URL as requested; FinalURL is where redirects ended
+	URL        string `json:"url"`
+	FinalURL   string `json:"final_url"`
+	StatusCode int    `json:"status_code"`
+
+	HTMLVersion  string       `json:"html_version"`
+	Title        string       `json:"title"`
+	Headings     Headings     `json:"headings"`
+	Links        LinkStats    `json:"links"`
+	BrokenLinks  []BrokenLink `json:"broken_links"`
+	HasLoginForm bool         `json:"has_login_form"`
+	Forms        []Form       `json:"forms"`
+	Robots       Robots       `json:"robots"`
+	Hreflang     Hreflang     `json:"hreflang"`
+	LinkHygiene  LinkHygiene  `json:"link_hygiene"`
+	ContactLinks ContactLinks `json:"contact_links"`
+	Fragments    Fragments    `json:"fragments"`
+	Privacy      Privacy      `json:"privacy"`
+	Consent      Consent      `json:"consent"`
+	Performance  Performance  `json:"performance"`
+	Documents    Documents    `json:"documents"`
+	// SocialProfiles are the social network accounts the page links to, usually the site's own
+	SocialProfiles []SocialProfile `json:"social_profiles"`
+	// DNS holds the records of the final host; it is nil when the host is an IP address
+	DNS *DNS `json:"dns,omitempty"`
+	// Hosting is the server that answered, with its network when Options.IPLocator is set
+	Hosting *Hosting `json:"hosting,omitempty"`
+
+	// Rules holds the outcome of each Options.Rules entry, in the same order
+	Rules []RuleResult `json:"rules,omitempty"`
+
+	// Keywords holds the analysis of each Options.Keywords entry, in the same order
+	Keywords []KeywordResult `json:"keywords,omitempty"`
+
+	// Safety is the threat list verdict, present only when Options.ThreatChecker is set
+	Safety *Safety `json:"safety,omitempty"`
+
+	// Registration is the registrar and expiry of the page's domain, present only when Options.Registry
+	// is set and the host is not an IP address
+	Registration *Registration `json:"registration,omitempty"`
+
+	// WebVitals are the origin's field Core Web Vitals, present only when Options.FieldData is set
+	WebVitals *WebVitals `json:"web_vitals,omitempty"`
+
+	// Content is the visible text of the page, one line per block element with whitespace collapsed.
+	// It is kept for change detection between crawls and left out of JSON.
+	Content string `json:"-"`
+
+	// BrokenAssets are the images, scripts and linked files that failed their check; nil unless
+	// Options.CheckAssets is set
+	BrokenAssets []BrokenAsset `json:"broken_assets"`
+
+	// InternalPages lists the distinct same-host pages linked from the page, without fragments
+	InternalPages []string `json:"internal_pages"`
+
+	// BytesDownloaded is the size transferred for the page and its audited stylesheets and scripts.
+	// Link and asset checks are HEAD requests and add nothing.
+	BytesDownloaded int64 `json:"bytes_downloaded"`
+
+	// Streamed is set when the page was larger than Options.StreamAbove and tokenized without a DOM.
+	// Only the title, headings, links, login form, robots directives and text were examined; forms,
+	// hreflang, link hygiene, fragments, contact links, social profiles, privacy, consent, rules,
+	// keywords, embeds, documents, assets and the page's stylesheets and scripts are left empty.
+	Streamed bool `json:"streamed,omitempty"`
+
+	// anchors are the element ids and anchor names of the page, for CheckFragmentLinks
+	anchors map[string]bool
+
+	FetchedAt time.Time     `json:"fetched_at"`
+	Duration  time.Duration `json:"duration_ns"`
+}
+
+// Headings counts heading elements by level
+type Headings struct {
+	H1 int `json:"h1"`
+	H2 int `json:"h2"`
+	H3 int `json:"h3"`
+}
+
+// LinkStats counts the page's http(s) links
+type LinkStats struct {
+	Internal int `json:"internal"`
+	External int `json:"external"`
+	// Links marked rel="nofollow", "sponsored" or "ugc", which search engines won't follow
+	InternalNofollow int `json:"internal_nofollow"`
+	ExternalNofollow int `json:"external_nofollow"`
+	Sponsored        int `json:"sponsored"`
+	UGC              int `json:"ugc"`
+	// Excluded links were skipped by Options.Exclusions. Checked is lower than
+	// Internal+External-Excluded when link checks ran out of time.
+	Excluded int `json:"excluded"`
+	Checked  int `json:"checked"`
+	// Cached links, counted in Checked, took their verdict from Options.LinkCache instead of a request
+	Cached int `json:"cached"`
+}
+
+// BrokenLink is a link that failed its check
+type BrokenLink struct {
+	URL string `json:"url"`
+	// StatusCode is the HTTP status of the response, or 0 when no response arrived
+	StatusCode int    `json:"status_code,omitempty"`
+	Error      string `json:"error"`
+	// RateLimited is set when the link was still answered 429 Too Many Requests after waiting its
+	// Retry-After once; the host turned the check away, so the link is not necessarily dead
+	RateLimited bool `json:"rate_limited,omitempty"`
+	// Suggestions are working replacements: the link on https or with www added or removed, and its
+	// latest archived copy when Options.Archive is set
+	Suggestions []string `json:"suggestions,omitempty"`
+}
+
+// Robots holds the page's robots directives from meta tags and the X-Robots-Tag header
+type Robots struct {
+	Noindex  bool `json:"noindex"`
+	Nofollow bool `json:"nofollow"`
+}
@@ -0,0 +1,148 @@
+package analyzer
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/andybalholm/cascadia"
+)
+
+// Rule types
+const (
+	// RuleExists passes when the selector matches at least one element
+	RuleExists = "exists"
+	// RuleCount passes when the number of matches is between Min and Max (either may be unset)
+	RuleCount = "count"
+	// RuleContains passes when the text of a matched element contains Text, ignoring case
+	RuleContains = "contains"
+)
+
+// Rule is a user-defined assertion about the page, evaluated with a CSS selector
+type Rule struct {
+	Name     string `json:"name"`
+	Selector string `json:"selector"`
+	Type     string `json:"type"`
+	Min      *int   `json:"min,omitempty"`
+	Max      *int   `json:"max,omitempty"`
+	Text     string `json:"text,omitempty"`
+}
+
+// RuleResult is the outcome of one Rule
+type RuleResult struct {
+	Rule    Rule   `json:"rule"`
+	Passed  bool   `json:"passed"`
+	Matches int    `json:"matches"`
+	Message string `json:"message"`
+}
+
+// Validate reports whether the rule can be evaluated
+func (r Rule) Validate() error {
+	if _, err := r.compile(); err != nil {
+		return err
+	}
+	switch r.Type {
+	case RuleExists:
+	case RuleCount:
+		if r.Min == nil && r.Max == nil {
+			return fmt.Errorf("count rules need min, max or both")
+		}
+		if (r.Min != nil && *r.Min < 0) || (r.Max != nil && *r.Max < 0) {
+			return fmt.Errorf("min and max must not be negative")
+		}
+		if r.Min != nil && r.Max != nil && *r.Min > *r.Max {
+			return fmt.Errorf("min must not exceed max")
+		}
+	case RuleContains:
+		if strings.TrimSpace(r.Text) == "" {
+			return fmt.Errorf("contains rules need text")
+		}
+	default:
+		return fmt.Errorf("unknown rule type %q (expected exists, count or contains)", r.Type)
+	}
+	return nil
+}
+
+// compile parses the rule's selector
+func (r Rule) compile() (cascadia.Selector, error) {
+	if strings.TrimSpace(r.Selector) == "" {
+		return nil, fmt.Errorf("selector is required")
+	}
+	sel, err := cascadia.Compile(r.Selector)
+	if err != nil {
+		return nil, fmt.Errorf("invalid selector: %v", err)
+	}
+	return sel, nil
+}
+
+// evaluateRules checks every rule against the page, returning results in rule order
+func evaluateRules(doc *goquery.Document, rules []Rule) []RuleResult {
+	if len(rules) == 0 {
+		return nil
+	}
+	results := make([]RuleResult, 0, len(rules))
+	for _, rule := range rules {
+		results = append(results, evaluateRule(doc, rule))
+	}
+	return results
+}
+
+// evaluateRule checks a single rule; invalid rules fail with the reason
+func evaluateRule(doc *goquery.Document, rule Rule) RuleResult {
+	result := RuleResult{Rule: rule}
+	if err := rule.Validate(); err != nil {
+		result.Message = err.Error()
+		return result
+	}
+	sel, _ := rule.compile()
+	matches := doc.FindMatcher(sel)
+	result.Matches = matches.Length()
+
+	switch rule.Type {
+	case RuleExists:
+		result.Passed = result.Matches > 0
+		if result.Passed {
+			result.Message = fmt.Sprintf("Found %d matching element(s)", result.Matches)
+		} else {
+			result.Message = fmt.Sprintf("No element matches %s", rule.Selector)
+		}
+
+	case RuleCount:
+		result.Passed = (rule.Min == nil || result.Matches >= *rule.Min) &&
+			(rule.Max == nil || result.Matches <= *rule.Max)
+		result.Message = fmt.Sprintf("Found %d matching element(s), expected %s", result.Matches, countRange(rule.Min, rule.Max))
+
+	case RuleContains:
+		want := strings.ToLower(rule.Text)
+		found := 0
+		matches.Each(func(_ int, s *goquery.Selection) {
+			if strings.Contains(strings.ToLower(s.Text()), want) {
+				found++
+			}
+		})
+		result.Passed = found > 0
+		switch {
+		case result.Matches == 0:
+			result.Message = fmt.Sprintf("No element matches %s", rule.Selector)
+		case result.Passed:
+			result.Message = fmt.Sprintf("Text found in %d of %d matching element(s)", found, result.Matches)
+		default:
+			result.Message = fmt.Sprintf("None of %d matching element(s) contains %q", result.Matches, rule.Text)
+		}
+	}
+	return result
+}
+
+// countRange describes the accepted number of matches of a count rule
+func countRange(min, max *int) string {
+	switch {
+	case min != nil && max != nil && *min == *max:
+		return fmt.Sprintf("exactly %d", *min)
+	case min != nil && max != nil:
+		return fmt.Sprintf("between %d and %d", *min, *max)
+	case min != nil:
+		return fmt.Sprintf("at least %d", *min)
+	default:
+		return fmt.Sprintf("at most %d", *max)
+	}
+}
@@ -0,0 +1,97 @@
+package analyzer
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func intPtr(n int) *int { return &n }
+
+func TestRuleValidate(t *testing.T) {
+	testCases := []struct {
+		name    string
+		rule    Rule
+		wantErr string
+	}{
+		{name: "exists", rule: Rule{Selector: "h1", Type: RuleExists}},
+		{name: "count with min only", rule: Rule{Selector: "img[alt]", Type: RuleCount, Min: intPtr(1)}},
+		{name: "contains", rule: Rule{Selector: "footer", Type: RuleContains, Text: "©"}},
+		{name: "empty selector", rule: Rule{Type: RuleExists}, wantErr: "selector is required"},
+		{name: "invalid selector", rule: Rule{Selector: "div[", Type: RuleExists}, wantErr: "invalid selector"},
+		{name: "count without bounds", rule: Rule{Selector: "h1", Type: RuleCount}, wantErr: "min, max or both"},
+		{name: "min above max", rule: Rule{Selector: "h1", Type: RuleCount, Min: intPtr(3), Max: intPtr(1)}, wantErr: "must not exceed"},
+		{name: "negative bound", rule: Rule{Selector: "h1", Type: RuleCount, Max: intPtr(-1)}, wantErr: "negative"},
+		{name: "contains without text", rule: Rule{Selector: "h1", Type: RuleContains, Text: " "}, wantErr: "need text"},
+		{name: "unknown type", rule: Rule{Selector: "h1", Type: "regex"}, wantErr: "unknown rule type"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.rule.Validate()
+			if tc.wantErr == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorContains(t, err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestEvaluateRule(t *testing.T) {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(`<html><body>
+		<h1>Welcome</h1>
+		<img src="a.png" alt="A"><img src="b.png">
+		<footer><p>Contact us</p><p>© 2024 Example Ltd</p></footer>
+	</body></html>`))
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name    string
+		rule    Rule
+		passed  bool
+		matches int
+		message string
+	}{
+		{name: "exists passes", rule: Rule{Selector: "h1", Type: RuleExists}, passed: true, matches: 1},
+		{name: "exists fails", rule: Rule{Selector: "nav", Type: RuleExists}, message: "No element matches nav"},
+		{name: "exactly one h1", rule: Rule{Selector: "h1", Type: RuleCount, Min: intPtr(1), Max: intPtr(1)}, passed: true, matches: 1, message: "expected exactly 1"},
+		{name: "images without alt", rule: Rule{Selector: "img:not([alt])", Type: RuleCount, Max: intPtr(0)}, matches: 1, message: "expected at most 0"},
+		{name: "contains ignores case", rule: Rule{Selector: "footer p", Type: RuleContains, Text: "example ltd"}, passed: true, matches: 2, message: "1 of 2"},
+		{name: "contains fails", rule: Rule{Selector: "h1", Type: RuleContains, Text: "Goodbye"}, matches: 1, message: `contains "Goodbye"`},
+		{name: "invalid rule fails", rule: Rule{Selector: "div[", Type: RuleExists}, message: "invalid selector"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result := evaluateRule(doc, tc.rule)
+			assert.Equal(t, tc.passed, result.Passed)
+			assert.Equal(t, tc.matches, result.Matches)
+			assert.Contains(t, result.Message, tc.message)
+			assert.Equal(t, tc.rule, result.Rule)
+		})
+	}
+}
+
+func TestAnalyzeWithRules(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`<html><head><meta name="description" content="x"></head><body><h1>Hi</h1></body></html>`))
+	}))
+	defer server.Close()
+
+	result, err := Analyze(context.Background(), server.URL, WithRules(
+		Rule{Name: "has description", Selector: `meta[name="description"]`, Type: RuleExists},
+		Rule{Name: "has canonical", Selector: `link[rel="canonical"]`, Type: RuleExists},
+	))
+	require.NoError(t, err)
+	require.Len(t, result.Rules, 2)
+	assert.True(t, result.Rules[0].Passed)
+	assert.Equal(t, "has canonical", result.Rules[1].Rule.Name)
+	assert.False(t, result.Rules[1].Passed)
+}
@@ -0,0 +1,79 @@
+package analyzer
+
+import (
+	"context"
+	"net/url"
+)
+
+// Safety verdicts
+const (
+	VerdictSafe      = "safe"      // neither the page nor its external links are listed
+	VerdictDangerous = "dangerous" // the page itself is listed
+	VerdictWarning   = "warning"   // the page links to listed URLs
+	VerdictUnknown   = "unknown"   // the lookup failed
+)
+
+// ThreatChecker looks URLs up in a malware or phishing list such as Google Safe Browsing
+type ThreatChecker interface {
+	// CheckURLs returns the threat types of every listed URL; URLs that are not listed are omitted
+	CheckURLs(ctx context.Context, urls []string) (map[string][]string, error)
+}
+
+// Safety is the threat list verdict for the page and its external links
+type Safety struct {
+	Verdict string `json:"verdict"`
+	// Checked is how many distinct URLs were looked up, the page included
+	Checked int      `json:"checked"`
+	Threats []Threat `json:"threats"`
+	Error   string   `json:"error,omitempty"`
+}
+
+// Threat is a listed URL
+type Threat struct {
+	URL   string   `json:"url"`
+	Types []string `json:"types"`
+	// Page is true when the analyzed page itself is listed rather than one of its links
+	Page bool `json:"page"`
+}
+
+// checkSafety looks up the page and its external links. links are the page's http(s) links.
+func checkSafety(ctx context.Context, checker ThreatChecker, result *Result, base *url.URL, links []string) *Safety {
+	page := map[string]bool{result.URL: true, result.FinalURL: true}
+	var urls []string
+	seen := make(map[string]bool)
+	add := func(u string) {
+		if u != "" && !seen[u] {
+			seen[u] = true
+			urls = append(urls, u)
+		}
+	}
+	add(result.URL)
+	add(result.FinalURL)
+	for _, link := range links {
+		if u, err := url.Parse(link); err == nil && u.Host != base.Host {
+			add(link)
+		}
+	}
+
+	safety := &Safety{Verdict: VerdictSafe, Checked: len(urls), Threats: []Threat{}}
+	listed, err := checker.CheckURLs(ctx, urls)
+	if err != nil {
+		safety.Verdict = VerdictUnknown
+		safety.Error = err.Error()
+		return safety
+	}
+
+	for _, u := range urls {
+		types, ok := listed[u]
+		if !ok {
+			continue
+		}
+		safety.Threats = append(safety.Threats, Threat{URL: u, Types: types, Page: page[u]})
+		if page[u] {
+			safety.Verdict = VerdictDangerous
+		} else if safety.Verdict == VerdictSafe {
+			safety.Verdict = VerdictWarning
+		}
+	}
+	return safety
+}
@@ -0,0 +1,71 @@
+package analyzer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeThreats lists fixed URLs and records what it was asked
+type fakeThreats struct {
+	listed  map[string][]string
+	err     error
+	checked []string
+}
+
+func (f *fakeThreats) CheckURLs(_ context.Context, urls []string) (map[string][]string, error) {
+	f.checked = urls
+	return f.listed, f.err
+}
+
+func TestSafety(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `<html><body>
+			<a href="/about">About</a>
+			<a href="http://127.0.0.2:1/malware">Bad</a>
+			<a href="http://127.0.0.2:1/malware">Bad again</a>
+		</body></html>`)
+	}))
+	defer server.Close()
+
+	t.Run("not checked without a checker", func(t *testing.T) {
+		result, err := Analyze(context.Background(), server.URL, WithMaxConcurrentLinkChecks(1))
+		require.NoError(t, err)
+		assert.Nil(t, result.Safety)
+	})
+
+	t.Run("listed link is a warning", func(t *testing.T) {
+		checker := &fakeThreats{listed: map[string][]string{"http://127.0.0.2:1/malware": {"MALWARE"}}}
+		result, err := Analyze(context.Background(), server.URL, WithThreatChecker(checker))
+		require.NoError(t, err)
+
+		// The page once, external links once each; internal links are not looked up
+		assert.Equal(t, []string{server.URL, "http://127.0.0.2:1/malware"}, checker.checked)
+		require.NotNil(t, result.Safety)
+		assert.Equal(t, VerdictWarning, result.Safety.Verdict)
+		assert.Equal(t, 2, result.Safety.Checked)
+		assert.Equal(t, []Threat{{URL: "http://127.0.0.2:1/malware", Types: []string{"MALWARE"}}}, result.Safety.Threats)
+	})
+
+	t.Run("listed page is dangerous", func(t *testing.T) {
+		checker := &fakeThreats{listed: map[string][]string{server.URL: {"SOCIAL_ENGINEERING"}}}
+		result, err := Analyze(context.Background(), server.URL, WithThreatChecker(checker))
+		require.NoError(t, err)
+		assert.Equal(t, VerdictDangerous, result.Safety.Verdict)
+		assert.True(t, result.Safety.Threats[0].Page)
+	})
+
+	t.Run("failed lookup is unknown", func(t *testing.T) {
+		checker := &fakeThreats{err: errors.New("quota exceeded")}
+		result, err := Analyze(context.Background(), server.URL, WithThreatChecker(checker))
+		require.NoError(t, err)
+		assert.Equal(t, VerdictUnknown, result.Safety.Verdict)
+		assert.Equal(t, "quota exceeded", result.Safety.Error)
+	})
+}
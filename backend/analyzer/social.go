@@ -0,0 +1,106 @@
+package analyzer
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// Social networks of a SocialProfile
+const (
+	SocialTwitter   = "twitter" // twitter.com and x.com
+	SocialLinkedIn  = "linkedin"
+	SocialFacebook  = "facebook"
+	SocialInstagram = "instagram"
+	SocialGitHub    = "github"
+)
+
+// socialNetworks maps the registrable domains of social networks to their name
+var socialNetworks = map[string]string{
+	"twitter.com":   SocialTwitter,
+	"x.com":         SocialTwitter,
+	"linkedin.com":  SocialLinkedIn,
+	"facebook.com":  SocialFacebook,
+	"fb.com":        SocialFacebook,
+	"instagram.com": SocialInstagram,
+	"github.com":    SocialGitHub,
+}
+
+// socialNonProfiles are first path segments that are features of the network rather than profiles,
+// such as share buttons and posts
+var socialNonProfiles = map[string]map[string]bool{
+	SocialTwitter:   {"intent": true, "share": true, "home": true, "search": true, "hashtag": true, "i": true},
+	SocialFacebook:  {"sharer.php": true, "sharer": true, "share": true, "share.php": true, "dialog": true, "plugins": true, "tr": true, "login": true, "hashtag": true},
+	SocialInstagram: {"p": true, "reel": true, "reels": true, "explore": true, "accounts": true, "stories": true},
+	SocialGitHub:    {"features": true, "about": true, "pricing": true, "login": true, "join": true, "orgs": true, "sponsors": true, "topics": true, "marketplace": true},
+}
+
+// SocialProfile is a link to an account on a social network
+type SocialProfile struct {
+	Network string `json:"network"`
+	// Handle is the account name, such as a user name or a LinkedIn company slug
+	Handle string `json:"handle"`
+	// URL is the link as found on the page, without query and fragment except for numeric Facebook profiles
+	URL string `json:"url"`
+}
+
+// extractSocialProfiles lists the distinct social network accounts the page links to, skipping share
+// buttons and links to posts
+func extractSocialProfiles(doc *goquery.Document, base *url.URL) []SocialProfile {
+	profiles := []SocialProfile{}
+	seen := make(map[string]bool)
+	doc.Find("a[href]").Each(func(_ int, s *goquery.Selection) {
+		link, err := url.Parse(strings.TrimSpace(s.AttrOr("href", "")))
+		if err != nil {
+			return
+		}
+		profile, ok := socialProfile(base.ResolveReference(link))
+		if !ok {
+			return
+		}
+		if key := profile.Network + "/" + strings.ToLower(profile.Handle); !seen[key] {
+			seen[key] = true
+			profiles = append(profiles, profile)
+		}
+	})
+	return profiles
+}
+
+// socialProfile recognizes a link to a social network account
+func socialProfile(u *url.URL) (SocialProfile, bool) {
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return SocialProfile{}, false
+	}
+	network, ok := socialNetworks[registrableDomain(u.Hostname())]
+	if !ok {
+		return SocialProfile{}, false
+	}
+	segments := strings.FieldsFunc(u.Path, func(r rune) bool { return r == '/' })
+	if len(segments) == 0 {
+		return SocialProfile{}, false
+	}
+
+	profile := SocialProfile{Network: network, Handle: strings.TrimPrefix(segments[0], "@")}
+	clean := *u
+	clean.RawQuery = ""
+	clean.Fragment = ""
+	switch {
+	case network == SocialLinkedIn:
+		// Profiles are /in/name, /company/name or /school/name
+		if len(segments) < 2 || (segments[0] != "in" && segments[0] != "company" && segments[0] != "school") {
+			return SocialProfile{}, false
+		}
+		profile.Handle = segments[1]
+	case network == SocialFacebook && segments[0] == "profile.php":
+		profile.Handle = u.Query().Get("id")
+		clean.RawQuery = url.Values{"id": {profile.Handle}}.Encode()
+	case socialNonProfiles[network][strings.ToLower(segments[0])]:
+		return SocialProfile{}, false
+	}
+	if profile.Handle == "" {
+		return SocialProfile{}, false
+	}
+	profile.URL = clean.String()
+	return profile, true
+}
@@ -0,0 +1,39 @@
+package analyzer
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExtractSocialProfiles(t *testing.T) {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(`<html><body>
+		<a href="https://twitter.com/ExampleCo?ref=footer">Twitter</a>
+		<a href="https://x.com/exampleco">X</a>
+		<a href="https://twitter.com/intent/tweet?text=hi">Tweet this</a>
+		<a href="https://www.linkedin.com/company/example-co/">LinkedIn</a>
+		<a href="https://www.linkedin.com/shareArticle?url=x">Share</a>
+		<a href="https://www.facebook.com/sharer/sharer.php?u=x">Share</a>
+		<a href="https://www.facebook.com/profile.php?id=1234&ref=bookmarks">Facebook</a>
+		<a href="https://www.instagram.com/p/abc123/">A post</a>
+		<a href="https://www.instagram.com/example.co/">Instagram</a>
+		<a href="https://github.com/example/widgets">Repository</a>
+		<a href="https://github.com/example">GitHub</a>
+		<a href="https://github.com/features">Features</a>
+		<a href="https://mastodon.example/@example">Elsewhere</a>
+	</body></html>`))
+	require.NoError(t, err)
+	base, _ := url.Parse("https://example.com/")
+
+	assert.Equal(t, []SocialProfile{
+		{Network: SocialTwitter, Handle: "ExampleCo", URL: "https://twitter.com/ExampleCo"},
+		{Network: SocialLinkedIn, Handle: "example-co", URL: "https://www.linkedin.com/company/example-co/"},
+		{Network: SocialFacebook, Handle: "1234", URL: "https://www.facebook.com/profile.php?id=1234"},
+		{Network: SocialInstagram, Handle: "example.co", URL: "https://www.instagram.com/example.co/"},
+		{Network: SocialGitHub, Handle: "example", URL: "https://github.com/example/widgets"},
+	}, extractSocialProfiles(doc, base))
+}
@@ -0,0 +1,129 @@
+package analyzer
+
+import (
+	"io"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// streamedPage is what tokenizing a page gathers besides the fields it fills in on the result
+type streamedPage struct {
+	linksToCheck []string
+	robotsMetas  []string
+}
+
+// streamPage tokenizes a page too large for a DOM as it is read, filling in the title, headings,
+// link counts, login form and text of result. Nothing but the current token is held in memory, so
+// the audits that query the DOM are left out; see Result.Streamed.
+func streamPage(r io.Reader, base *url.URL, scope linkScope, opts Options, result *Result) (streamedPage, error) {
+	var page streamedPage
+	links := newLinkCollector(base, scope, opts, result)
+	var text textLines
+	var title strings.Builder
+
+	inTitle, titleDone := false, false
+	inBody := false
+	formDepth, hiddenDepth := 0, 0
+
+	z := html.NewTokenizer(r)
+	for {
+		tt := z.Next()
+		switch tt {
+		case html.ErrorToken:
+			if err := z.Err(); err != io.EOF {
+				return page, err
+			}
+			result.Title = strings.TrimSpace(title.String())
+			result.Content = text.String()
+			page.linksToCheck = links.toCheck
+			return page, nil
+
+		case html.TextToken:
+			if inTitle {
+				title.Write(z.Text())
+			} else if inBody && hiddenDepth == 0 {
+				text.write(string(z.Text()))
+			}
+
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, hasAttr := z.TagName()
+			tag := string(name)
+			var attrs map[string]string
+			if hasAttr && (tag == "a" || tag == "input" || tag == "meta") {
+				attrs = tagAttrs(z)
+			}
+
+			switch tag {
+			case "title":
+				inTitle = !titleDone && tt == html.StartTagToken
+			case "h1":
+				result.Headings.H1++
+			case "h2":
+				result.Headings.H2++
+			case "h3":
+				result.Headings.H3++
+			case "a":
+				if href, ok := attrs["href"]; ok {
+					links.add(href, attrs["rel"])
+				}
+			case "form":
+				if tt == html.StartTagToken {
+					formDepth++
+				}
+			case "input":
+				if formDepth > 0 && strings.EqualFold(attrs["type"], "password") {
+					result.HasLoginForm = true
+				}
+			case "meta":
+				if isRobotsMeta(attrs["name"]) {
+					page.robotsMetas = append(page.robotsMetas, attrs["content"])
+				}
+			case "body":
+				inBody = true
+			case "script", "style", "noscript", "template":
+				if tt == html.StartTagToken {
+					hiddenDepth++
+				}
+			}
+			// Text outside a <body> tag still belongs to the body once a block element shows up
+			if blockElements[tag] {
+				inBody = true
+				text.flush()
+			}
+
+		case html.EndTagToken:
+			name, _ := z.TagName()
+			tag := string(name)
+			switch tag {
+			case "title":
+				if inTitle {
+					inTitle, titleDone = false, true
+				}
+			case "form":
+				formDepth = max(formDepth-1, 0)
+			case "script", "style", "noscript", "template":
+				hiddenDepth = max(hiddenDepth-1, 0)
+			case "head":
+				inBody = true
+			}
+			if blockElements[tag] {
+				text.flush()
+			}
+		}
+	}
+}
+
+// tagAttrs returns the attributes of the current tag; the first of repeated attributes wins
+func tagAttrs(z *html.Tokenizer) map[string]string {
+	attrs := make(map[string]string)
+	for more := true; more; {
+		var key, value []byte
+		key, value, more = z.TagAttr()
+		if _, ok := attrs[string(key)]; !ok {
+			attrs[string(key)] = string(value)
+		}
+	}
+	return attrs
+}
@@ -0,0 +1,103 @@
+package analyzer
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStreamedPages(t *testing.T) {
+	page := `<!DOCTYPE html>
+<html>
+<head>
+  <title>Big &amp; Slow</title>
+  <meta name="Robots" content="noindex">
+  <script>var text = "not content";</script>
+</head>
+<body>
+  <h1>Catalogue</h1>
+  <h2>Shoes</h2><h2>Hats</h2><h3>Sale</h3>
+  <p>All the <b>products</b> we sell.</p>
+  <a href="/shoes">Shoes</a>
+  <a href="/shoes#sizes">Shoe sizes</a>
+  <a href="hats?utm_source=nav">Hats</a>
+  <a href="https://partner.example.org/" rel="sponsored nofollow">Partner</a>
+  <a href="mailto:shop@example.com">Mail us</a>
+  <a href="">Empty</a>
+  <noscript><p>Enable JavaScript</p></noscript>
+  <form action="/login"><input type="text" name="user"><input type="PASSWORD" name="pass"></form>
+  <ul><li>First</li><li>Second</li></ul>
+</body>
+</html>`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html")
+		w.Header().Set("X-Robots-Tag", "nofollow")
+		w.Write([]byte(page))
+	}))
+	defer server.Close()
+
+	analyze := func(streamAbove int64) *Result {
+		result, err := Analyze(context.Background(), server.URL+"/", WithOptions(Options{SkipLinkChecks: true}),
+			WithIgnoreQueryParams("utm_*"), WithStreamAbove(streamAbove))
+		require.NoError(t, err)
+		return result
+	}
+	parsed := analyze(0)
+	streamed := analyze(100)
+
+	t.Run("small pages are parsed", func(t *testing.T) {
+		assert.False(t, parsed.Streamed)
+		assert.False(t, analyze(int64(len(page))).Streamed)
+		assert.NotEmpty(t, parsed.Forms)
+	})
+
+	t.Run("large pages are streamed with the same counts", func(t *testing.T) {
+		assert.True(t, streamed.Streamed)
+		assert.Equal(t, "Big & Slow", streamed.Title)
+		assert.Equal(t, parsed.Title, streamed.Title)
+		assert.Equal(t, Headings{H1: 1, H2: 2, H3: 1}, streamed.Headings)
+		assert.Equal(t, parsed.Headings, streamed.Headings)
+		assert.Equal(t, parsed.Links, streamed.Links)
+		assert.Equal(t, parsed.InternalPages, streamed.InternalPages)
+		assert.True(t, streamed.HasLoginForm)
+		assert.Equal(t, Robots{Noindex: true, Nofollow: true}, streamed.Robots)
+		assert.Equal(t, parsed.Robots, streamed.Robots)
+	})
+
+	t.Run("streamed text matches the parsed text", func(t *testing.T) {
+		assert.Equal(t, parsed.Content, streamed.Content)
+		assert.NotContains(t, streamed.Content, "not content")
+		assert.NotContains(t, streamed.Content, "Enable JavaScript")
+	})
+
+	t.Run("DOM audits are left out", func(t *testing.T) {
+		assert.Empty(t, streamed.Forms)
+		assert.Equal(t, 1, parsed.ContactLinks.Mailto)
+		assert.Zero(t, streamed.ContactLinks.Mailto)
+		require.Len(t, streamed.Performance.Resources, 1)
+		assert.Equal(t, ResourceHTML, streamed.Performance.Resources[0].Type)
+		assert.EqualValues(t, len(page), streamed.BytesDownloaded)
+	})
+}
+
+func TestStreamPageWithoutBody(t *testing.T) {
+	result := &Result{}
+	base, _ := url.Parse("https://example.com/")
+	opts := Options{}.withDefaults()
+	page, err := streamPage(strings.NewReader(`<title>One</title><title>Two</title><p>Text <a href="/a">A</a></p>`),
+		base, newLinkScope(base, opts), opts, result)
+	require.NoError(t, err)
+	assert.Equal(t, "One", result.Title)
+	assert.Equal(t, "Text A", result.Content)
+	assert.Equal(t, []string{"https://example.com/a"}, page.linksToCheck)
+}
@@ -0,0 +1,104 @@
+package analyzer
+
+import (
+	"context"
+	"net"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// maxSuggestedLinks caps how many broken links get replacement suggestions
+const maxSuggestedLinks = 50
+
+// Archive finds archived copies of pages, such as the Wayback Machine
+type Archive interface {
+	// ClosestSnapshot returns the URL of the archived copy of pageURL closest to now, or "" when the
+	// page was never archived
+	ClosestSnapshot(ctx context.Context, pageURL string) (string, error)
+}
+
+// suggestReplacements fills in the Suggestions of broken links: working variants of the URL on https
+// or with www added or removed, then the latest archived copy when Options.Archive is set. Suggestions
+// are looked up concurrently for the first maxSuggestedLinks links within linkCheckWait.
+func (a *Analyzer) suggestReplacements(ctx context.Context, links []BrokenLink) {
+	if len(links) > maxSuggestedLinks {
+		links = links[:maxSuggestedLinks]
+	}
+	if len(links) == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, linkCheckWait)
+	defer cancel()
+
+	client := a.client(a.opts.LinkCheckTimeout)
+	semaphore := make(chan struct{}, a.opts.MaxConcurrentLinkChecks)
+	var wg sync.WaitGroup
+	for i := range links {
+		wg.Add(1)
+		go func(link *BrokenLink) {
+			defer wg.Done()
+			// A rate limited link is not known to be dead, and its host would turn the variants away too
+			if link.RateLimited {
+				return
+			}
+			select {
+			case semaphore <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			defer func() { <-semaphore }()
+
+			var suggestions []string
+			for _, variant := range linkVariants(link.URL) {
+				if checkSingleLink(ctx, client, variant, a.opts.UserAgent) == nil && ctx.Err() == nil {
+					suggestions = append(suggestions, variant)
+					break // the closest working variant is enough
+				}
+			}
+			if a.opts.Archive != nil {
+				snapshot, err := a.opts.Archive.ClosestSnapshot(ctx, link.URL)
+				if err == nil && snapshot != "" {
+					suggestions = append(suggestions, snapshot)
+				}
+			}
+			link.Suggestions = suggestions
+		}(&links[i])
+	}
+	wg.Wait()
+}
+
+// linkVariants returns the https and www variants of a link, most likely replacement first
+func linkVariants(link string) []string {
+	u, err := url.Parse(link)
+	if err != nil || u.Host == "" {
+		return nil
+	}
+
+	var schemes []string
+	if u.Scheme == "http" {
+		schemes = append(schemes, "https")
+	}
+	schemes = append(schemes, u.Scheme)
+
+	hosts := []string{u.Host}
+	if host, ok := strings.CutPrefix(u.Host, "www."); ok {
+		hosts = append(hosts, host)
+	} else if net.ParseIP(u.Hostname()) == nil {
+		hosts = append(hosts, "www."+u.Host)
+	}
+
+	var variants []string
+	for _, host := range hosts {
+		for _, scheme := range schemes {
+			if scheme == u.Scheme && host == u.Host {
+				continue
+			}
+			v := *u
+			v.Scheme, v.Host = scheme, host
+			variants = append(variants, v.String())
+		}
+	}
+	return variants
+}
@@ -0,0 +1,58 @@
+package analyzer
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubArchive maps pages to their archived copies
+type stubArchive map[string]string
+
+func (s stubArchive) ClosestSnapshot(ctx context.Context, pageURL string) (string, error) {
+	return s[pageURL], nil
+}
+
+func TestLinkVariants(t *testing.T) {
+	t.Run("http without www", func(t *testing.T) {
+		assert.Equal(t, []string{
+			"https://example.com/a?b=1",
+			"https://www.example.com/a?b=1",
+			"http://www.example.com/a?b=1",
+		}, linkVariants("http://example.com/a?b=1"))
+	})
+
+	t.Run("https with www", func(t *testing.T) {
+		assert.Equal(t, []string{"https://example.com/"}, linkVariants("https://www.example.com/"))
+	})
+
+	t.Run("IP addresses have no www variant", func(t *testing.T) {
+		assert.Equal(t, []string{"https://127.0.0.1:8080/x"}, linkVariants("http://127.0.0.1:8080/x"))
+		assert.Empty(t, linkVariants("https://[::1]/x"))
+	})
+}
+
+func TestSuggestReplacements(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	missing := server.URL + "/missing"
+	links := []BrokenLink{
+		{URL: missing, StatusCode: http.StatusNotFound},
+		{URL: server.URL + "/never-archived", StatusCode: http.StatusNotFound},
+	}
+
+	a := New(WithArchive(stubArchive{missing: "https://web.archive.org/web/2024/" + missing}))
+	a.suggestReplacements(context.Background(), links)
+
+	// The https variant of the plain http test server fails, so only the archived copy is suggested
+	require.Len(t, links[0].Suggestions, 1)
+	assert.Equal(t, "https://web.archive.org/web/2024/"+missing, links[0].Suggestions[0])
+	assert.Empty(t, links[1].Suggestions)
+}
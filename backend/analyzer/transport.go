@@ -0,0 +1,87 @@
+package analyzer
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"net/http"
+	"net/netip"
+	"time"
+)
+
+// Connection pool of NewTransport. Link checks send many requests at once to the same few hosts,
+// far beyond the two idle connections per host http.DefaultTransport keeps; connections closed
+// for lack of room would be set up again, TLS handshake included, for the next link.
+const (
+	maxIdleConns        = 512
+	maxIdleConnsPerHost = 32
+	idleConnTimeout     = 90 * time.Second
+	// tlsSessionCacheSize is the number of servers whose TLS sessions are kept for resumption, which
+	// skips most of the handshake when a connection has to be opened again
+	tlsSessionCacheSize = 1024
+)
+
+// defaultTransport is shared by every Analyzer without an Options.HTTPClient
+var defaultTransport = NewTransport(nil, false)
+
+// NewTransport returns a transport like http.DefaultTransport with a larger pool of kept-alive
+// connections and TLS session resumption. Share one across analyses, so links to the same sites reuse
+// connections. It looks host names up through resolver (nil for the system resolver) and, with
+// publicOnly, refuses to connect to a PrivateAddr.
+func NewTransport(resolver Resolver, publicOnly bool) *http.Transport {
+	d := &dialer{
+		Dialer:   net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second},
+		resolver: resolver,
+	}
+	if publicOnly {
+		d.Control = refusePrivate
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = d.DialContext
+	transport.MaxIdleConns = maxIdleConns
+	transport.MaxIdleConnsPerHost = maxIdleConnsPerHost
+	transport.IdleConnTimeout = idleConnTimeout
+	transport.TLSClientConfig = &tls.Config{ClientSessionCache: tls.NewLRUClientSessionCache(tlsSessionCacheSize)}
+	return transport
+}
+
+// dialer connects to the addresses its resolver returns, in order, until one answers
+type dialer struct {
+	net.Dialer
+	resolver Resolver
+}
+
+func (d *dialer) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	host, port, err := net.SplitHostPort(address)
+	if err != nil || d.resolver == nil {
+		return d.Dialer.DialContext(ctx, network, address)
+	}
+	if _, err := netip.ParseAddr(host); err == nil {
+		return d.Dialer.DialContext(ctx, network, address)
+	}
+
+	addrs, err := d.resolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return nil, &net.OpError{Op: "dial", Net: network, Err: err}
+	}
+	var firstErr error
+	for _, addr := range addrs {
+		if (network == "tcp4" && addr.IP.To4() == nil) || (network == "tcp6" && addr.IP.To4() != nil) {
+			continue
+		}
+		conn, err := d.Dialer.DialContext(ctx, network, net.JoinHostPort(addr.String(), port))
+		if err == nil {
+			return conn, nil
+		}
+		if firstErr == nil {
+			firstErr = err
+		}
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	if firstErr == nil {
+		firstErr = &net.OpError{Op: "dial", Net: network, Err: &net.DNSError{Err: "no suitable address found", Name: host}}
+	}
+	return nil, firstErr
+}
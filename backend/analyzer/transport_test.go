@@ -0,0 +1,113 @@
+package analyzer
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// staticResolver answers every address lookup with addrs
+type staticResolver struct {
+	net.Resolver
+	addrs   []net.IPAddr
+	lookups []string
+}
+
+func (r *staticResolver) LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error) {
+	r.lookups = append(r.lookups, host)
+	if len(r.addrs) == 0 {
+		return nil, &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+	}
+	return r.addrs, nil
+}
+
+func TestTransportResolver(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("hello " + r.Host))
+	}))
+	defer server.Close()
+	u, err := url.Parse(server.URL)
+	require.NoError(t, err)
+	target := "http://site.test:" + u.Port() + "/"
+
+	t.Run("host names are looked up through the resolver", func(t *testing.T) {
+		resolver := &staticResolver{addrs: []net.IPAddr{{IP: net.ParseIP("127.0.0.1")}}}
+		client := &http.Client{Transport: NewTransport(resolver, false)}
+		res, err := client.Get(target)
+		require.NoError(t, err)
+		body, _ := io.ReadAll(res.Body)
+		res.Body.Close()
+		assert.Equal(t, "hello site.test:"+u.Port(), string(body))
+		assert.Equal(t, []string{"site.test"}, resolver.lookups)
+	})
+
+	t.Run("later addresses are tried when one fails", func(t *testing.T) {
+		// The test server only listens on 127.0.0.1, so 127.0.0.2 refuses the connection
+		resolver := &staticResolver{addrs: []net.IPAddr{{IP: net.ParseIP("127.0.0.2")}, {IP: net.ParseIP("127.0.0.1")}}}
+		client := &http.Client{Transport: NewTransport(resolver, false)}
+		res, err := client.Get(target)
+		require.NoError(t, err)
+		res.Body.Close()
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+	})
+
+	t.Run("unknown hosts keep the resolver's error", func(t *testing.T) {
+		client := &http.Client{Transport: NewTransport(&staticResolver{}, false)}
+		_, err := client.Get(target)
+		assert.ErrorContains(t, err, "no such host")
+	})
+
+	t.Run("addresses resolved are checked when public only", func(t *testing.T) {
+		resolver := &staticResolver{addrs: []net.IPAddr{{IP: net.ParseIP("127.0.0.1")}}}
+		client := &http.Client{Transport: NewTransport(resolver, true)}
+		_, err := client.Get(target)
+		assert.ErrorIs(t, err, ErrPrivateAddress)
+	})
+}
+
+func TestTransportConnectionReuse(t *testing.T) {
+	var conns atomic.Int32
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	server.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			conns.Add(1)
+		}
+	}
+	server.Start()
+	defer server.Close()
+
+	transport := NewTransport(nil, false)
+	assert.Equal(t, maxIdleConnsPerHost, transport.MaxIdleConnsPerHost)
+	require.NotNil(t, transport.TLSClientConfig)
+	assert.NotNil(t, transport.TLSClientConfig.ClientSessionCache)
+
+	// Rounds of concurrent HEAD requests, as link checks send them, reuse the connections of the first
+	const concurrency = 16
+	client := &http.Client{Transport: transport}
+	for round := 0; round < 5; round++ {
+		var wg sync.WaitGroup
+		for i := 0; i < concurrency; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				res, err := client.Head(server.URL)
+				if assert.NoError(t, err) {
+					res.Body.Close()
+				}
+			}()
+		}
+		wg.Wait()
+	}
+	assert.LessOrEqual(t, conns.Load(), int32(concurrency))
+}
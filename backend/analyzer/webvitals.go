@@ -0,0 +1,102 @@
+package analyzer
+
+import (
+	"context"
+	"net/url"
+)
+
+// Core Web Vitals assessments of an origin
+const (
+	VitalsPassed  = "passed"  // every reported metric is good at the 75th percentile
+	VitalsFailed  = "failed"  // at least one reported metric is not good
+	VitalsNoData  = "no_data" // the origin has too little traffic for field data
+	VitalsUnknown = "unknown" // the lookup failed
+)
+
+// Ratings of a Core Web Vitals metric
+const (
+	RatingGood             = "good"
+	RatingNeedsImprovement = "needs_improvement"
+	RatingPoor             = "poor"
+)
+
+// vitalThresholds are the upper bounds of good and needs improvement for each metric, per web.dev
+var vitalThresholds = map[string][2]float64{
+	"lcp": {2500, 4000}, // milliseconds
+	"cls": {0.1, 0.25},  // unitless
+	"inp": {200, 500},   // milliseconds
+}
+
+// FieldDataSource looks up real-user Core Web Vitals of an origin, such as the Chrome UX Report
+type FieldDataSource interface {
+	// OriginVitals returns the origin's field data, or nil without error when there is none.
+	// Ratings and the assessment are filled in by the analyzer.
+	OriginVitals(ctx context.Context, origin string) (*WebVitals, error)
+}
+
+// WebVitals are the field Core Web Vitals of the page's origin, as experienced by real users
+type WebVitals struct {
+	Origin     string       `json:"origin"`
+	Assessment string       `json:"assessment"`
+	LCP        *VitalMetric `json:"lcp,omitempty"` // Largest Contentful Paint
+	CLS        *VitalMetric `json:"cls,omitempty"` // Cumulative Layout Shift
+	INP        *VitalMetric `json:"inp,omitempty"` // Interaction to Next Paint
+	// Period is the collection window of the data, e.g. 2026-09-18/2026-10-15
+	Period string `json:"period,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// VitalMetric is the distribution of one metric across page loads
+type VitalMetric struct {
+	// P75 is the 75th percentile, in milliseconds for LCP and INP
+	P75    float64 `json:"p75"`
+	Rating string  `json:"rating"`
+	// Good, NeedsImprovement and Poor are the shares of page loads in each range, from 0 to 1
+	Good             float64 `json:"good"`
+	NeedsImprovement float64 `json:"needs_improvement"`
+	Poor             float64 `json:"poor"`
+}
+
+// fetchWebVitals looks up the field data of the page's origin and assesses it
+func fetchWebVitals(ctx context.Context, source FieldDataSource, page *url.URL) *WebVitals {
+	origin := page.Scheme + "://" + page.Host
+	vitals, err := source.OriginVitals(ctx, origin)
+	if err != nil {
+		return &WebVitals{Origin: origin, Assessment: VitalsUnknown, Error: err.Error()}
+	}
+	if vitals == nil {
+		return &WebVitals{Origin: origin, Assessment: VitalsNoData}
+	}
+	vitals.Origin = origin
+	assessVitals(vitals)
+	return vitals
+}
+
+// assessVitals rates each reported metric and passes the origin when all of them are good
+func assessVitals(v *WebVitals) {
+	metrics := map[string]*VitalMetric{"lcp": v.LCP, "cls": v.CLS, "inp": v.INP}
+	v.Assessment = VitalsNoData
+	for name, metric := range metrics {
+		if metric == nil {
+			continue
+		}
+		metric.Rating = rateVital(name, metric.P75)
+		if metric.Rating != RatingGood {
+			v.Assessment = VitalsFailed
+		} else if v.Assessment == VitalsNoData {
+			v.Assessment = VitalsPassed
+		}
+	}
+}
+
+// rateVital rates a 75th percentile value of the named metric
+func rateVital(name string, p75 float64) string {
+	thresholds := vitalThresholds[name]
+	switch {
+	case p75 <= thresholds[0]:
+		return RatingGood
+	case p75 <= thresholds[1]:
+		return RatingNeedsImprovement
+	}
+	return RatingPoor
+}
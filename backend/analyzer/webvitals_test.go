@@ -0,0 +1,89 @@
+package analyzer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeFieldData answers with fixed vitals and records the origin it was asked about
+type fakeFieldData struct {
+	vitals *WebVitals
+	err    error
+	origin string
+}
+
+func (f *fakeFieldData) OriginVitals(_ context.Context, origin string) (*WebVitals, error) {
+	f.origin = origin
+	return f.vitals, f.err
+}
+
+func TestWebVitals(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `<html><body><p>Hello</p></body></html>`)
+	}))
+	defer server.Close()
+
+	t.Run("not looked up without a source", func(t *testing.T) {
+		result, err := Analyze(context.Background(), server.URL+"/page")
+		require.NoError(t, err)
+		assert.Nil(t, result.WebVitals)
+	})
+
+	t.Run("good metrics pass", func(t *testing.T) {
+		source := &fakeFieldData{vitals: &WebVitals{
+			LCP: &VitalMetric{P75: 2100},
+			CLS: &VitalMetric{P75: 0.05},
+			INP: &VitalMetric{P75: 180},
+		}}
+		result, err := Analyze(context.Background(), server.URL+"/page", WithFieldData(source))
+		require.NoError(t, err)
+
+		assert.Equal(t, server.URL, source.origin)
+		require.NotNil(t, result.WebVitals)
+		assert.Equal(t, server.URL, result.WebVitals.Origin)
+		assert.Equal(t, VitalsPassed, result.WebVitals.Assessment)
+		assert.Equal(t, RatingGood, result.WebVitals.INP.Rating)
+	})
+
+	t.Run("one slow metric fails", func(t *testing.T) {
+		source := &fakeFieldData{vitals: &WebVitals{
+			LCP: &VitalMetric{P75: 4500},
+			CLS: &VitalMetric{P75: 0.12},
+		}}
+		result, err := Analyze(context.Background(), server.URL, WithFieldData(source))
+		require.NoError(t, err)
+
+		assert.Equal(t, VitalsFailed, result.WebVitals.Assessment)
+		assert.Equal(t, RatingPoor, result.WebVitals.LCP.Rating)
+		assert.Equal(t, RatingNeedsImprovement, result.WebVitals.CLS.Rating)
+		assert.Nil(t, result.WebVitals.INP)
+	})
+
+	t.Run("origins without field data", func(t *testing.T) {
+		result, err := Analyze(context.Background(), server.URL, WithFieldData(&fakeFieldData{}))
+		require.NoError(t, err)
+		assert.Equal(t, VitalsNoData, result.WebVitals.Assessment)
+	})
+
+	t.Run("lookup errors do not fail the analysis", func(t *testing.T) {
+		result, err := Analyze(context.Background(), server.URL, WithFieldData(&fakeFieldData{err: errors.New("quota exceeded")}))
+		require.NoError(t, err)
+		assert.Equal(t, VitalsUnknown, result.WebVitals.Assessment)
+		assert.Equal(t, "quota exceeded", result.WebVitals.Error)
+	})
+}
+
+func TestRateVital(t *testing.T) {
+	assert.Equal(t, RatingGood, rateVital("lcp", 2500))
+	assert.Equal(t, RatingNeedsImprovement, rateVital("lcp", 2501))
+	assert.Equal(t, RatingPoor, rateVital("inp", 501))
+	assert.Equal(t, RatingGood, rateVital("cls", 0.1))
+	assert.Equal(t, RatingPoor, rateVital("cls", 0.3))
+}
@@ -0,0 +1,249 @@
+// Package archive moves the text of old content snapshots from MySQL to storage and reads it
+// back on demand, keeping content_snapshots small. Crawl runs stay in MySQL: their rows are small and
+// feed the trends, so each archived object only carries a copy of its run for reference.
+package archive
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"sykell-analyze/backend/config"
+	"sykell-analyze/backend/storage"
+)
+
+// batchSize is how many snapshots one pass loads to archive
+const batchSize = 500
+
+// ErrUnavailable is returned when archived text is requested without storage configured
+var ErrUnavailable = errors.New("snapshot text is archived but storage is not set up")
+
+// Key names the object holding a snapshot's text. The user ID leads so the objects of deleted URLs
+// can be removed by prefix without reaching another account's.
+func Key(userID, urlID int, snapshotID int64) string {
+	return fmt.Sprintf("snapshots/%d/%d/%d.json", userID, urlID, snapshotID)
+}
+
+// urlPrefix is the key prefix of every archived snapshot of a URL
+func urlPrefix(userID interface{}, urlID int) string {
+	return fmt.Sprintf("snapshots/%v/%d/", userID, urlID)
+}
+
+// Run is the crawl a snapshot was taken by, as copied into the archived object
+type Run struct {
+	ID          int64      `json:"id"`
+	Status      string     `json:"status"`
+	BrokenLinks int        `json:"broken_links"`
+	StartedAt   *time.Time `json:"started_at,omitempty"`
+	FinishedAt  *time.Time `json:"finished_at,omitempty"`
+}
+
+// Object is the stored form of an archived snapshot
+type Object struct {
+	SnapshotID  int64     `json:"snapshot_id"`
+	UrlID       int       `json:"url_id"`
+	Content     string    `json:"content"`
+	ContentHash string    `json:"content_hash"`
+	CreatedAt   time.Time `json:"created_at"`
+	Run         *Run      `json:"run,omitempty"`
+}
+
+// Content returns a snapshot's text: content itself, or the archived text when key is set
+func Content(ctx context.Context, content string, key sql.NullString) (string, error) {
+	if !key.Valid {
+		return content, nil
+	}
+	store := storage.For(ctx)
+	if store == nil {
+		return "", ErrUnavailable
+	}
+	data, err := store.Get(ctx, key.String)
+	if err != nil {
+		return "", fmt.Errorf("failed to load archived snapshot %s: %w", key.String, err)
+	}
+	var obj Object
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return "", fmt.Errorf("failed to read archived snapshot %s: %w", key.String, err)
+	}
+	if !obj.matches() {
+		return "", fmt.Errorf("archived snapshot %s does not match its hash", key.String)
+	}
+	return obj.Content, nil
+}
+
+// matches reports whether the object's text is the text it was archived with
+func (o Object) matches() bool {
+	sum := sha256.Sum256([]byte(o.Content))
+	return hex.EncodeToString(sum[:]) == o.ContentHash
+}
+
+// ForgetUrls deletes the archived snapshots of the user's deleted URLs. Failures are only logged: the
+// objects are unreachable once their rows are gone.
+func ForgetUrls(ctx context.Context, userID interface{}, urlIDs []int) {
+	store := storage.For(ctx)
+	if store == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), time.Minute)
+	defer cancel()
+	for _, urlID := range urlIDs {
+		if err := store.DeletePrefix(ctx, urlPrefix(userID, urlID)); err != nil {
+			fmt.Printf("DEBUG: Failed to delete archived snapshots of URL ID %d: %v\n", urlID, err)
+		}
+	}
+}
+
+// Forget deletes archived snapshot objects by key, logging failures
+func Forget(ctx context.Context, keys []string) {
+	store := storage.For(ctx)
+	if store == nil {
+		return
+	}
+	for _, key := range keys {
+		if err := store.Delete(ctx, key); err != nil {
+			fmt.Printf("DEBUG: Failed to delete archived snapshot %s: %v\n", key, err)
+		}
+	}
+}
+
+// Job archives old snapshots on an interval. Several processes may run one: a snapshot is only
+// marked archived once, and uploading the same object twice is harmless.
+type Job struct {
+	Config config.ArchiveConfig
+}
+
+// New creates an archival job writing to the configured storage of the tenant its Run context
+// carries
+func New(cfg config.ArchiveConfig) *Job {
+	return &Job{Config: cfg}
+}
+
+// Run archives old snapshots until ctx is cancelled
+func (j *Job) Run(ctx context.Context) {
+	fmt.Printf("📦 Snapshot archival started (every %s, after %s)\n", j.Config.Interval, j.Config.After)
+	ticker := time.NewTicker(j.Config.Interval)
+	defer ticker.Stop()
+
+	for {
+		archived, err := j.archive(ctx, time.Now().Add(-j.Config.After))
+		if err != nil {
+			fmt.Printf("DEBUG: Snapshot archival failed: %v\n", err)
+		}
+		if archived > 0 {
+			fmt.Printf("DEBUG: Archived %d snapshots to storage\n", archived)
+		}
+
+		select {
+		case <-ctx.Done():
+			fmt.Println("📦 Snapshot archival stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// pending is a snapshot due for archival
+type pending struct {
+	userID int
+	object Object
+}
+
+// archive moves snapshots created before cutoff until none are left, returning how many were moved.
+// The newest snapshot of each URL stays in MySQL, since the next crawl compares against it.
+func (j *Job) archive(ctx context.Context, cutoff time.Time) (int, error) {
+	total := 0
+	for ctx.Err() == nil {
+		batch, err := loadPending(ctx, cutoff)
+		if err != nil || len(batch) == 0 {
+			return total, err
+		}
+		for _, p := range batch {
+			if ctx.Err() != nil {
+				return total, nil
+			}
+			if err := j.archiveOne(ctx, p); err != nil {
+				return total, err
+			}
+			total++
+		}
+	}
+	return total, nil
+}
+
+// loadPending reads a batch of snapshots due for archival with their crawl runs
+func loadPending(ctx context.Context, cutoff time.Time) ([]pending, error) {
+	rows, err := config.DBFor(ctx).QueryContext(ctx, `
+		SELECT u.user_id, s.id, s.url_id, s.content, s.content_hash, s.created_at,
+			r.id, r.status, r.broken_links, r.started_at, r.finished_at
+		FROM content_snapshots s
+		JOIN urls u ON u.id = s.url_id
+		LEFT JOIN crawl_runs r ON r.id = s.run_id
+		WHERE s.archived_at IS NULL AND s.created_at < ?
+		  AND EXISTS (SELECT 1 FROM content_snapshots newer WHERE newer.url_id = s.url_id AND newer.id > s.id)
+		ORDER BY s.created_at
+		LIMIT ?
+	`, cutoff, batchSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var batch []pending
+	for rows.Next() {
+		var p pending
+		var runID sql.NullInt64
+		var runStatus sql.NullString
+		var runBrokenLinks sql.NullInt64
+		var runStartedAt, runFinishedAt sql.NullTime
+		err := rows.Scan(&p.userID, &p.object.SnapshotID, &p.object.UrlID, &p.object.Content, &p.object.ContentHash,
+			&p.object.CreatedAt, &runID, &runStatus, &runBrokenLinks, &runStartedAt, &runFinishedAt)
+		if err != nil {
+			return nil, err
+		}
+		if runID.Valid {
+			p.object.Run = &Run{
+				ID:          runID.Int64,
+				Status:      runStatus.String,
+				BrokenLinks: int(runBrokenLinks.Int64),
+			}
+			if runStartedAt.Valid {
+				p.object.Run.StartedAt = &runStartedAt.Time
+			}
+			if runFinishedAt.Valid {
+				p.object.Run.FinishedAt = &runFinishedAt.Time
+			}
+		}
+		batch = append(batch, p)
+	}
+	return batch, rows.Err()
+}
+
+// archiveOne uploads a snapshot and then empties its text in MySQL
+func (j *Job) archiveOne(ctx context.Context, p pending) error {
+	data, err := json.Marshal(p.object)
+	if err != nil {
+		return err
+	}
+	key := Key(p.userID, p.object.UrlID, p.object.SnapshotID)
+	store := storage.For(ctx)
+	if store == nil {
+		return ErrUnavailable
+	}
+	if err := store.Put(ctx, key, data); err != nil {
+		return fmt.Errorf("failed to upload snapshot %d: %w", p.object.SnapshotID, err)
+	}
+
+	_, err = config.DBFor(ctx).ExecContext(ctx,
+		"UPDATE content_snapshots SET content = '', archive_key = ?, archived_at = ? WHERE id = ? AND archived_at IS NULL",
+		key, time.Now(), p.object.SnapshotID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to mark snapshot %d archived: %w", p.object.SnapshotID, err)
+	}
+	return nil
+}
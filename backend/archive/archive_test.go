@@ -0,0 +1,35 @@
+package archive
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKey(t *testing.T) {
+	assert.Equal(t, "snapshots/7/42/1001.json", Key(7, 42, 1001))
+	assert.Equal(t, "snapshots/7/42/", urlPrefix(7, 42))
+}
+
+func TestContent(t *testing.T) {
+	t.Run("text still in MySQL", func(t *testing.T) {
+		content, err := Content(context.Background(), "page text", sql.NullString{})
+		assert.NoError(t, err)
+		assert.Equal(t, "page text", content)
+	})
+
+	t.Run("archived text without storage", func(t *testing.T) {
+		_, err := Content(context.Background(), "", sql.NullString{String: Key(1, 2, 3), Valid: true})
+		assert.ErrorIs(t, err, ErrUnavailable)
+	})
+}
+
+func TestObjectMatches(t *testing.T) {
+	obj := Object{Content: "", ContentHash: "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"}
+	assert.True(t, obj.matches())
+
+	obj.Content = "changed"
+	assert.False(t, obj.matches())
+}
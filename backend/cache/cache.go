@@ -0,0 +1,97 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"sykell-analyze/backend/config"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Client is nil when caching is disabled (cache.redis_url unset)
+var Client *redis.Client
+
+// TTL is how long cached responses live (cache.ttl)
+var TTL = 30 * time.Second
+
+// Connect enables the cache when a Redis URL is configured, e.g. redis://localhost:6379/0
+func Connect(cfg config.CacheConfig) error {
+	redisURL := cfg.RedisURL
+	if redisURL == "" {
+		return nil
+	}
+
+	opts, err := redis.ParseURL(redisURL)
+	if err != nil {
+		return fmt.Errorf("invalid cache.redis_url: %w", err)
+	}
+
+	client := redis.NewClient(opts)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := client.Ping(ctx).Err(); err != nil {
+		return fmt.Errorf("redis is unreachable: %w", err)
+	}
+
+	TTL = cfg.TTL
+	Client = client
+	fmt.Println("✅ Connected to Redis cache.")
+	return nil
+}
+
+// Enabled reports whether a Redis cache is configured
+func Enabled() bool {
+	return Client != nil
+}
+
+// userPrefix starts the keys of a user; users of tenants are told apart from the primary's users
+// of the same ID by the tenant's name
+func userPrefix(ctx context.Context, userID int) string {
+	if tenant := config.TenantName(ctx); tenant != "" {
+		return fmt.Sprintf("cache:tenant:%s:user:%d", tenant, userID)
+	}
+	return fmt.Sprintf("cache:user:%d", userID)
+}
+
+// userVersionKey holds a counter that is bumped to invalidate all of a user's cached responses
+func userVersionKey(ctx context.Context, userID int) string {
+	return userPrefix(ctx, userID) + ":version"
+}
+
+// UserKey builds a cache key scoped to the user's current cache version
+func UserKey(ctx context.Context, userID int, name string) (string, error) {
+	version, err := Client.Get(ctx, userVersionKey(ctx, userID)).Int64()
+	if err != nil && err != redis.Nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s:v%d:%s", userPrefix(ctx, userID), version, name), nil
+}
+
+// Get returns a cached value, reporting false on a miss or error
+func Get(ctx context.Context, key string) ([]byte, bool) {
+	value, err := Client.Get(ctx, key).Bytes()
+	if err != nil {
+		return nil, false
+	}
+	return value, true
+}
+
+// Set stores a value with the given TTL
+func Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	return Client.Set(ctx, key, value, ttl).Err()
+}
+
+// InvalidateUser drops every cached response for the user of ctx's tenant; stale entries expire
+// through their TTL
+func InvalidateUser(ctx context.Context, userID int) {
+	if !Enabled() {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 2*time.Second)
+	defer cancel()
+	if err := Client.Incr(ctx, userVersionKey(ctx, userID)).Err(); err != nil {
+		fmt.Printf("DEBUG: Failed to invalidate cache for user %d: %v\n", userID, err)
+	}
+}
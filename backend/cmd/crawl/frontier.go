@@ -0,0 +1,194 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"sync"
+	"time"
+)
+
+// queued is a page waiting in the frontier
+type queued struct {
+	URL   string `json:"url"`
+	Depth int    `json:"depth"`
+}
+
+// frontier is the breadth-first queue of a site crawl. It keeps up to memory pages in memory and
+// spills the rest to a temporary file, read back in order, so a large site does not hold every
+// discovered link in memory. Pages are told apart by a hash of their canonical URL.
+type frontier struct {
+	memory int
+	queue  []queued
+	seen   map[[16]byte]struct{}
+
+	// spill holds the pages queued after the in-memory ones, spilled of them not read back yet. It
+	// is written and read through separate handles, each with its own offset.
+	spill   *os.File
+	readEnd *os.File
+	writer  *bufio.Writer
+	reader  *bufio.Reader
+	spilled int
+}
+
+// newFrontier creates an empty frontier keeping up to memory pages in memory
+func newFrontier(memory int) *frontier {
+	return &frontier{memory: max(memory, 1), seen: make(map[[16]byte]struct{})}
+}
+
+// add queues page unless a page with the same canonical URL was queued before
+func (f *frontier) add(canonical string, page queued) error {
+	sum := sha256.Sum256([]byte(canonical))
+	key := [16]byte(sum[:16])
+	if _, ok := f.seen[key]; ok {
+		return nil
+	}
+	f.seen[key] = struct{}{}
+
+	// Once pages are spilled, later pages go after them
+	if f.spilled == 0 && len(f.queue) < f.memory {
+		f.queue = append(f.queue, page)
+		return nil
+	}
+	if f.spill == nil {
+		file, err := os.CreateTemp("", "crawl-frontier-*.jsonl")
+		if err != nil {
+			return fmt.Errorf("spilling the frontier: %w", err)
+		}
+		readEnd, err := os.Open(file.Name())
+		if err != nil {
+			file.Close()
+			os.Remove(file.Name())
+			return fmt.Errorf("spilling the frontier: %w", err)
+		}
+		f.spill, f.readEnd = file, readEnd
+		f.writer, f.reader = bufio.NewWriter(file), bufio.NewReader(readEnd)
+	}
+	line, _ := json.Marshal(page)
+	if _, err := f.writer.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("spilling the frontier: %w", err)
+	}
+	f.spilled++
+	return nil
+}
+
+// len is the number of pages waiting
+func (f *frontier) len() int {
+	return len(f.queue) + f.spilled
+}
+
+// next removes and returns the oldest page; the frontier must not be empty
+func (f *frontier) next() (queued, error) {
+	if len(f.queue) == 0 {
+		if err := f.readBack(); err != nil {
+			return queued{}, err
+		}
+	}
+	page := f.queue[0]
+	f.queue = f.queue[1:]
+	return page, nil
+}
+
+// readBack moves up to memory spilled pages back into memory
+func (f *frontier) readBack() error {
+	if err := f.writer.Flush(); err != nil {
+		return fmt.Errorf("reading the frontier back: %w", err)
+	}
+	for f.spilled > 0 && len(f.queue) < f.memory {
+		line, err := f.reader.ReadBytes('\n')
+		if err != nil {
+			return fmt.Errorf("reading the frontier back: %w", err)
+		}
+		var page queued
+		if err := json.Unmarshal(line, &page); err != nil {
+			return fmt.Errorf("reading the frontier back: %w", err)
+		}
+		f.queue = append(f.queue, page)
+		f.spilled--
+	}
+	return nil
+}
+
+// close removes the spill file
+func (f *frontier) close() {
+	if f.spill != nil {
+		f.spill.Close()
+		f.readEnd.Close()
+		os.Remove(f.spill.Name())
+	}
+}
+
+// hostGate keeps a site crawl polite: at most perHost pages of a host are analyzed at once, and
+// their analyses start at least delay apart
+type hostGate struct {
+	perHost int
+	delay   time.Duration
+
+	mu    sync.Mutex
+	hosts map[string]*gatedHost
+}
+
+// gatedHost is the state of one host
+type gatedHost struct {
+	slots chan struct{}
+	next  time.Time // earliest start of the host's next page
+}
+
+func newHostGate(perHost int, delay time.Duration) *hostGate {
+	return &hostGate{perHost: max(perHost, 1), delay: delay, hosts: make(map[string]*gatedHost)}
+}
+
+// host returns the state of the host of pageURL
+func (g *hostGate) host(pageURL string) *gatedHost {
+	var name string
+	if u, err := url.Parse(pageURL); err == nil {
+		name = u.Host
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	h, ok := g.hosts[name]
+	if !ok {
+		h = &gatedHost{slots: make(chan struct{}, g.perHost)}
+		g.hosts[name] = h
+	}
+	return h
+}
+
+// wait blocks until a page of pageURL's host may start; call done once it finished
+func (g *hostGate) wait(ctx context.Context, pageURL string) error {
+	h := g.host(pageURL)
+	select {
+	case h.slots <- struct{}{}:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	g.mu.Lock()
+	start := time.Now()
+	if h.next.After(start) {
+		start = h.next
+	}
+	h.next = start.Add(g.delay)
+	g.mu.Unlock()
+
+	if wait := time.Until(start); wait > 0 {
+		timer := time.NewTimer(wait)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			<-h.slots
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+// done frees the slot a page of pageURL's host took in wait
+func (g *hostGate) done(pageURL string) {
+	<-g.host(pageURL).slots
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFrontier(t *testing.T) {
+	t.Run("pages come out in order, spilled or not", func(t *testing.T) {
+		f := newFrontier(3)
+		defer f.close()
+		for i := 0; i < 10; i++ {
+			require.NoError(t, f.add(fmt.Sprint(i), queued{URL: fmt.Sprint(i), Depth: i % 2}))
+		}
+		assert.Len(t, f.queue, 3, "the rest is spilled")
+		assert.Equal(t, 10, f.len())
+
+		var got []string
+		for i := 0; i < 5; i++ {
+			page, err := f.next()
+			require.NoError(t, err)
+			got = append(got, page.URL)
+		}
+		// Pages added while some are still spilled go after them
+		require.NoError(t, f.add("10", queued{URL: "10"}))
+		for f.len() > 0 {
+			page, err := f.next()
+			require.NoError(t, err)
+			got = append(got, page.URL)
+		}
+		assert.Equal(t, []string{"0", "1", "2", "3", "4", "5", "6", "7", "8", "9", "10"}, got)
+	})
+
+	t.Run("pages are queued once", func(t *testing.T) {
+		f := newFrontier(10)
+		defer f.close()
+		f.add("https://example.com/a", queued{URL: "https://example.com/a"})
+		f.add("https://example.com/a", queued{URL: "https://example.com/a#top"})
+		assert.Equal(t, 1, f.len())
+
+		f.next()
+		f.add("https://example.com/a", queued{URL: "https://example.com/a"})
+		assert.Equal(t, 0, f.len(), "analyzed pages are not queued again")
+	})
+}
+
+func TestHostGate(t *testing.T) {
+	t.Run("pages per host", func(t *testing.T) {
+		gate := newHostGate(2, 0)
+		var active, peak atomic.Int32
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				require.NoError(t, gate.wait(context.Background(), "https://example.com/page"))
+				n := active.Add(1)
+				for p := peak.Load(); n > p && !peak.CompareAndSwap(p, n); p = peak.Load() {
+				}
+				time.Sleep(2 * time.Millisecond)
+				active.Add(-1)
+				gate.done("https://example.com/page")
+			}()
+		}
+		wg.Wait()
+		assert.EqualValues(t, 2, peak.Load())
+	})
+
+	t.Run("delay between pages of a host", func(t *testing.T) {
+		gate := newHostGate(5, 20*time.Millisecond)
+		start := time.Now()
+		for i := 0; i < 3; i++ {
+			require.NoError(t, gate.wait(context.Background(), "https://example.com/"))
+		}
+		assert.GreaterOrEqual(t, time.Since(start), 40*time.Millisecond)
+
+		// Other hosts do not wait
+		start = time.Now()
+		require.NoError(t, gate.wait(context.Background(), "https://other.example/"))
+		assert.Less(t, time.Since(start), 20*time.Millisecond)
+	})
+
+	t.Run("cancelled while waiting", func(t *testing.T) {
+		gate := newHostGate(1, 0)
+		require.NoError(t, gate.wait(context.Background(), "https://example.com/"))
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		assert.ErrorIs(t, gate.wait(ctx, "https://example.com/"), context.DeadlineExceeded)
+	})
+}
@@ -0,0 +1,672 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/netip"
+	"net/url"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"sykell-analyze/backend/analyzer"
+	"sykell-analyze/backend/config"
+	"sykell-analyze/backend/crux"
+	"sykell-analyze/backend/geoip"
+	"sykell-analyze/backend/rdap"
+	"sykell-analyze/backend/wayback"
+)
+
+// The crawl binary analyzes URLs from the command line, without a database or API server:
+//
+//	go run ./cmd/crawl -format json https://example.com
+//	go run ./cmd/crawl -depth 1 -max-pages 20 example.com
+//	go run ./cmd/crawl -depth 3 -max-duration 5m -max-bytes 50000000 example.com
+//	go run ./cmd/crawl -depth 5 -max-pages 5000 -parallel 8 -per-host 4 -host-delay 100ms example.com
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	code := run(ctx, os.Args[1:], os.Stdout, os.Stderr)
+	stop()
+	os.Exit(code)
+}
+
+// cliOptions holds the parsed command line
+type cliOptions struct {
+	Format string
+	Depth  int
+	Budget crawlBudget
+	Pace   crawlPace
+	Crawl  analyzer.Options
+	URLs   []string
+}
+
+// crawlBudget limits one site crawl. The duration is enforced by cancelling the page in progress;
+// the byte limit is checked between pages, so the last page may go over it.
+type crawlBudget struct {
+	MaxPages    int
+	MaxDuration time.Duration // 0 means unlimited
+	MaxBytes    int64         // 0 means unlimited
+}
+
+// crawlPace sets how fast a site crawl goes: pages analyzed at once in total and per host, the least
+// time between starting two pages of a host, and how many queued pages are kept in memory
+type crawlPace struct {
+	Parallel       int
+	PerHost        int
+	HostDelay      time.Duration
+	FrontierMemory int
+}
+
+// crawlUsage is what a site crawl consumed of its budget
+type crawlUsage struct {
+	URL      string
+	Pages    int
+	Bytes    int64
+	Duration time.Duration
+	// StoppedBy names the flag whose budget ended the crawl with pages left, empty otherwise
+	StoppedBy string
+}
+
+// errUsage reports a command line that was already explained on stderr
+var errUsage = errors.New("invalid usage")
+
+// parseArgs reads flags and target URLs; crawler defaults come from the server's defaults
+func parseArgs(args []string, stderr io.Writer) (cliOptions, error) {
+	defaults := config.Default().Crawler
+	cruxDefaults := config.Default().CrUX
+	rdapDefaults := config.Default().RDAP
+	waybackDefaults := config.Default().Wayback
+	opts := cliOptions{}
+
+	fs := flag.NewFlagSet("crawl", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: crawl [flags] URL...")
+		fs.PrintDefaults()
+	}
+	fs.StringVar(&opts.Format, "format", "table", "output format: table or json")
+	fs.IntVar(&opts.Depth, "depth", 0, "follow same-host links this many levels deep (0 analyzes only the given pages)")
+	fs.IntVar(&opts.Budget.MaxPages, "max-pages", 50, "stop after analyzing this many pages per URL")
+	fs.DurationVar(&opts.Budget.MaxDuration, "max-duration", 0, "stop each site crawl after this long (0 for no limit)")
+	fs.Int64Var(&opts.Budget.MaxBytes, "max-bytes", 0, "stop each site crawl once this many bytes were downloaded (0 for no limit)")
+	fs.IntVar(&opts.Pace.Parallel, "parallel", 4, "pages analyzed at once with -depth")
+	fs.IntVar(&opts.Pace.PerHost, "per-host", 2, "pages of one host analyzed at once")
+	fs.DurationVar(&opts.Pace.HostDelay, "host-delay", 0, "least time between starting two pages of one host")
+	fs.IntVar(&opts.Pace.FrontierMemory, "frontier-memory", 10000, "queued pages kept in memory; more are spilled to a temporary file")
+	fs.DurationVar(&opts.Crawl.PageTimeout, "timeout", defaults.PageTimeout, "time budget per page, including link checks")
+	fs.DurationVar(&opts.Crawl.LinkCheckTimeout, "link-timeout", defaults.LinkCheckTimeout, "timeout of each broken link check")
+	fs.IntVar(&opts.Crawl.MaxConcurrentLinkChecks, "concurrency", defaults.MaxConcurrentLinkChecks, "parallel broken link checks")
+	fs.StringVar(&opts.Crawl.UserAgent, "user-agent", analyzer.DefaultUserAgent, "User-Agent header sent with every request")
+	fs.BoolVar(&opts.Crawl.TreatSubdomainsAsInternal, "subdomains-internal", false, "count links to other subdomains of the page's domain as internal")
+	internalDomains := fs.String("internal-domains", "", "comma-separated domains whose links, subdomains included, count as internal")
+	ignoreParams := fs.String("ignore-params", "", "comma-separated query parameters, such as utm_*, that do not change the page")
+	fs.BoolVar(&opts.Crawl.IgnoreTrailingSlash, "ignore-trailing-slash", false, "treat /page and /page/ as the same page")
+	fs.BoolVar(&opts.Crawl.CheckAssets, "check-assets", false, "also check images, scripts and stylesheets for broken assets")
+	fs.BoolVar(&opts.Crawl.CheckDocuments, "check-documents", false, "check the media type and size of linked PDFs and office documents")
+	maxDocumentMB := fs.Int("max-document-mb", defaults.MaxDocumentMB, "report linked documents larger than this many megabytes")
+	streamAboveMB := fs.Int("stream-above-mb", defaults.StreamAboveMB, "tokenize pages larger than this many megabytes without the DOM audits (0 never does)")
+	keywords := fs.String("keywords", "", "comma-separated target keywords or phrases to count on each page (json output)")
+	cruxKey := fs.String("crux-key", os.Getenv("CRUX_API_KEY"), "Chrome UX Report API key for field Core Web Vitals of each origin (default $CRUX_API_KEY)")
+	registration := fs.Bool("rdap", false, "look up the registrar and expiry date of each domain over RDAP")
+	archive := fs.Bool("wayback", false, "suggest archived copies of broken links from the Wayback Machine")
+	geoipDatabase := fs.String("geoip", os.Getenv("GEOIP_DATABASE"), "ip2asn TSV database from iptoasn.com for the ASN, provider and country of each server (default $GEOIP_DATABASE)")
+	render := fs.String("render", "static", "render mode; only static is supported (pages are analyzed without running JavaScript)")
+
+	if err := fs.Parse(args); err != nil {
+		return opts, errUsage
+	}
+
+	var problems []string
+	if opts.Format != "table" && opts.Format != "json" {
+		problems = append(problems, "-format must be table or json")
+	}
+	if opts.Depth < 0 {
+		problems = append(problems, "-depth must not be negative")
+	}
+	if opts.Budget.MaxPages < 1 {
+		problems = append(problems, "-max-pages must be positive")
+	}
+	if opts.Budget.MaxDuration < 0 || opts.Budget.MaxBytes < 0 {
+		problems = append(problems, "-max-duration and -max-bytes must not be negative")
+	}
+	if opts.Pace.Parallel < 1 || opts.Pace.PerHost < 1 || opts.Pace.FrontierMemory < 1 {
+		problems = append(problems, "-parallel, -per-host and -frontier-memory must be positive")
+	}
+	if opts.Pace.HostDelay < 0 {
+		problems = append(problems, "-host-delay must not be negative")
+	}
+	if opts.Crawl.PageTimeout <= 0 || opts.Crawl.LinkCheckTimeout <= 0 {
+		problems = append(problems, "timeouts must be positive")
+	}
+	if opts.Crawl.MaxConcurrentLinkChecks < 1 {
+		problems = append(problems, "-concurrency must be positive")
+	}
+	if *maxDocumentMB < 1 {
+		problems = append(problems, "-max-document-mb must be positive")
+	}
+	if *streamAboveMB < 0 {
+		problems = append(problems, "-stream-above-mb must not be negative")
+	}
+	if *render != "static" {
+		problems = append(problems, "-render "+*render+" is not supported; only static rendering is available")
+	}
+	if *geoipDatabase != "" {
+		db, err := geoip.Open(*geoipDatabase)
+		if err != nil {
+			problems = append(problems, "-geoip: "+err.Error())
+		} else {
+			opts.Crawl.IPLocator = db
+		}
+	}
+	if fs.NArg() == 0 {
+		problems = append(problems, "at least one URL is required")
+	}
+	if len(problems) > 0 {
+		for _, p := range problems {
+			fmt.Fprintln(stderr, "crawl:", p)
+		}
+		fs.Usage()
+		return opts, errUsage
+	}
+
+	opts.Crawl.InternalDomains = splitList(*internalDomains)
+	opts.Crawl.IgnoreQueryParams = splitList(*ignoreParams)
+	opts.Crawl.MaxDocumentBytes = int64(*maxDocumentMB) << 20
+	opts.Crawl.StreamAbove = int64(*streamAboveMB) << 20
+
+	opts.Crawl.Keywords = splitList(*keywords)
+
+	if *cruxKey != "" {
+		opts.Crawl.FieldData = crux.New(*cruxKey, cruxDefaults.CacheTTL, cruxDefaults.Timeout)
+	}
+
+	if *registration {
+		opts.Crawl.Registry = rdap.New(rdapDefaults.BaseURL, rdapDefaults.CacheTTL, rdapDefaults.Timeout)
+	}
+
+	if *archive {
+		opts.Crawl.Archive = wayback.New(waybackDefaults.BaseURL, waybackDefaults.Timeout)
+	}
+
+	// The page fetch may use the whole page budget
+	opts.Crawl.RequestTimeout = opts.Crawl.PageTimeout
+	for _, arg := range fs.Args() {
+		opts.URLs = append(opts.URLs, normalizeURL(arg))
+	}
+	return opts, nil
+}
+
+// splitList splits a comma-separated flag value, dropping empty items
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// normalizeURL defaults to https like the API does
+func normalizeURL(input string) string {
+	input = strings.TrimSpace(input)
+	if !strings.HasPrefix(input, "http://") && !strings.HasPrefix(input, "https://") {
+		if addr, err := netip.ParseAddr(input); err == nil && addr.Is6() {
+			input = "[" + input + "]"
+		}
+		input = "https://" + input
+	}
+	return input
+}
+
+// run executes the CLI and returns its exit code: 0 on success, 1 when a given URL could not
+// be analyzed and 2 for usage errors
+func run(ctx context.Context, args []string, stdout, stderr io.Writer) int {
+	opts, err := parseArgs(args, stderr)
+	if err != nil {
+		return 2
+	}
+
+	var reports []pageReport
+	var usages []crawlUsage
+	exitCode := 0
+	for _, target := range opts.URLs {
+		pages, usage := crawlSite(ctx, analyzer.New(analyzer.WithOptions(opts.Crawl)), target, opts.Depth, opts.Budget, opts.Pace, stderr)
+		if len(pages) == 0 || pages[0].Error != "" {
+			exitCode = 1
+		}
+		if usage.StoppedBy != "" {
+			fmt.Fprintf(stderr, "crawl: stopped %s after %d pages: %s budget reached\n", target, usage.Pages, usage.StoppedBy)
+		}
+		reports = append(reports, pages...)
+		usages = append(usages, usage)
+	}
+
+	if opts.Format == "json" {
+		err = writeJSON(stdout, reports)
+	} else {
+		err = writeTable(stdout, reports, usages)
+	}
+	if err != nil {
+		fmt.Fprintln(stderr, "crawl: failed to write output:", err)
+		return 1
+	}
+	return exitCode
+}
+
+// brokenLinkReport is one broken link found on a page
+type brokenLinkReport struct {
+	URL         string   `json:"url"`
+	StatusCode  int      `json:"status_code,omitempty"`
+	Error       string   `json:"error,omitempty"`
+	RateLimited bool     `json:"rate_limited,omitempty"`
+	Suggestions []string `json:"suggestions,omitempty"`
+}
+
+// pageReport is the analysis of one page, or why it failed
+type pageReport struct {
+	URL                   string                   `json:"url"`
+	Depth                 int                      `json:"depth"`
+	Error                 string                   `json:"error,omitempty"`
+	Streamed              bool                     `json:"streamed,omitempty"`
+	HtmlVersion           string                   `json:"html_version,omitempty"`
+	Title                 string                   `json:"title,omitempty"`
+	H1Count               int                      `json:"h1_count"`
+	H2Count               int                      `json:"h2_count"`
+	H3Count               int                      `json:"h3_count"`
+	InternalLinks         int                      `json:"internal_links"`
+	ExternalLinks         int                      `json:"external_links"`
+	BrokenLinks           int                      `json:"broken_links"`
+	HasLoginForm          bool                     `json:"has_login_form"`
+	Forms                 []analyzer.Form          `json:"forms,omitempty"`
+	InternalNofollowLinks int                      `json:"internal_nofollow_links"`
+	ExternalNofollowLinks int                      `json:"external_nofollow_links"`
+	SponsoredLinks        int                      `json:"sponsored_links"`
+	UgcLinks              int                      `json:"ugc_links"`
+	IsNoindex             bool                     `json:"is_noindex"`
+	IsNofollow            bool                     `json:"is_nofollow"`
+	BrokenLinksDetails    []brokenLinkReport       `json:"broken_links_details,omitempty"`
+	BrokenAssets          []analyzer.BrokenAsset   `json:"broken_assets,omitempty"`
+	Keywords              []analyzer.KeywordResult `json:"keywords,omitempty"`
+	Hreflang              *analyzer.Hreflang       `json:"hreflang,omitempty"`
+	LinkHygiene           *analyzer.LinkHygiene    `json:"link_hygiene,omitempty"`
+	ContactLinks          *analyzer.ContactLinks   `json:"contact_links,omitempty"`
+	SocialProfiles        []analyzer.SocialProfile `json:"social_profiles,omitempty"`
+	Fragments             *analyzer.Fragments      `json:"fragments,omitempty"`
+	Privacy               *analyzer.Privacy        `json:"privacy,omitempty"`
+	Consent               *analyzer.Consent        `json:"consent,omitempty"`
+	Performance           *analyzer.Performance    `json:"performance,omitempty"`
+	Documents             *analyzer.Documents      `json:"documents,omitempty"`
+	DNS                   *analyzer.DNS            `json:"dns,omitempty"`
+	Hosting               *analyzer.Hosting        `json:"hosting,omitempty"`
+	Registration          *analyzer.Registration   `json:"registration,omitempty"`
+	WebVitals             *analyzer.WebVitals      `json:"web_vitals,omitempty"`
+	BytesDownloaded       int64                    `json:"bytes_downloaded"`
+	DurationMs            int64                    `json:"duration_ms"`
+}
+
+func newPageReport(depth int, r *analyzer.Result) pageReport {
+	report := pageReport{
+		URL:                   r.URL,
+		Depth:                 depth,
+		Streamed:              r.Streamed,
+		HtmlVersion:           r.HTMLVersion,
+		Title:                 r.Title,
+		H1Count:               r.Headings.H1,
+		H2Count:               r.Headings.H2,
+		H3Count:               r.Headings.H3,
+		InternalLinks:         r.Links.Internal,
+		ExternalLinks:         r.Links.External,
+		BrokenLinks:           len(r.BrokenLinks),
+		HasLoginForm:          r.HasLoginForm,
+		Forms:                 r.Forms,
+		InternalNofollowLinks: r.Links.InternalNofollow,
+		ExternalNofollowLinks: r.Links.ExternalNofollow,
+		SponsoredLinks:        r.Links.Sponsored,
+		UgcLinks:              r.Links.UGC,
+		IsNoindex:             r.Robots.Noindex,
+		IsNofollow:            r.Robots.Nofollow,
+		BrokenAssets:          r.BrokenAssets,
+		Keywords:              r.Keywords,
+		Hreflang:              &r.Hreflang,
+		LinkHygiene:           &r.LinkHygiene,
+		ContactLinks:          &r.ContactLinks,
+		SocialProfiles:        r.SocialProfiles,
+		Fragments:             &r.Fragments,
+		Privacy:               &r.Privacy,
+		Consent:               &r.Consent,
+		Performance:           &r.Performance,
+		Documents:             &r.Documents,
+		DNS:                   r.DNS,
+		Hosting:               r.Hosting,
+		Registration:          r.Registration,
+		WebVitals:             r.WebVitals,
+		BytesDownloaded:       r.BytesDownloaded,
+		DurationMs:            r.Duration.Milliseconds(),
+	}
+	for _, link := range r.BrokenLinks {
+		report.BrokenLinksDetails = append(report.BrokenLinksDetails, brokenLinkReport(link))
+	}
+	return report
+}
+
+// crawlSite analyzes start and, breadth first, the same-host pages it links to up to depth levels
+// away, until the budget is spent. Up to pace.Parallel pages are analyzed at once, each host's pages
+// spaced out by pace. Same-host hreflang alternates are followed like links, and once the crawl
+// ends every page's alternates are checked for return links and its fragment links for their
+// target elements.
+// Reports are in breadth-first order, so the first is always the start page. Cancelling ctx stops
+// the crawl, including the pages being analyzed.
+func crawlSite(ctx context.Context, a *analyzer.Analyzer, start string, depth int, budget crawlBudget, pace crawlPace, stderr io.Writer) ([]pageReport, crawlUsage) {
+	// Pages are told apart by their canonical URL
+	queue := newFrontier(pace.FrontierMemory)
+	defer queue.close()
+	queue.add(a.Canonical(start), queued{start, 0})
+	var visited []queued
+	var results []*analyzer.Result // nil for pages that failed
+	var errs []error
+
+	startedAt := time.Now()
+	usage := crawlUsage{URL: start}
+	crawlCtx := ctx
+	if budget.MaxDuration > 0 {
+		var cancel context.CancelFunc
+		crawlCtx, cancel = context.WithTimeout(ctx, budget.MaxDuration)
+		defer cancel()
+	}
+
+	type analyzed struct {
+		index  int
+		result *analyzer.Result
+		err    error
+	}
+	done := make(chan analyzed)
+	gate := newHostGate(pace.PerHost, pace.HostDelay)
+	inFlight := 0
+	failed := false // the frontier could not be spilled or read back
+
+	for {
+		// Start pages while the budget lasts; the start page is always attempted, so that it is the first report
+		for inFlight < pace.Parallel && queue.len() > 0 && usage.StoppedBy == "" && !failed && ctx.Err() == nil {
+			switch {
+			case len(visited) >= budget.MaxPages:
+				usage.StoppedBy = "-max-pages"
+			case len(visited) > 0 && crawlCtx.Err() != nil:
+				usage.StoppedBy = "-max-duration"
+			case budget.MaxBytes > 0 && usage.Bytes >= budget.MaxBytes:
+				usage.StoppedBy = "-max-bytes"
+			}
+			if usage.StoppedBy != "" {
+				break
+			}
+
+			page, err := queue.next()
+			if err != nil {
+				fmt.Fprintln(stderr, "crawl:", err)
+				failed = true
+				break
+			}
+			visited = append(visited, page)
+			results = append(results, nil)
+			errs = append(errs, nil)
+			inFlight++
+
+			fmt.Fprintf(stderr, "Analyzing %s\n", page.URL)
+			go func(index int, pageURL string) {
+				if err := gate.wait(crawlCtx, pageURL); err != nil {
+					done <- analyzed{index, nil, err}
+					return
+				}
+				result, err := a.Analyze(crawlCtx, pageURL)
+				gate.done(pageURL)
+				done <- analyzed{index, result, err}
+			}(len(visited)-1, page.URL)
+		}
+		if inFlight == 0 {
+			break
+		}
+
+		page := <-done
+		inFlight--
+		err := page.err
+		if err != nil && crawlCtx.Err() != nil && ctx.Err() == nil {
+			err = fmt.Errorf("not finished: -max-duration budget of %s reached", budget.MaxDuration)
+			usage.StoppedBy = "-max-duration"
+		}
+		results[page.index], errs[page.index] = page.result, err
+		if err != nil {
+			continue
+		}
+		usage.Bytes += page.result.BytesDownloaded
+
+		if from := visited[page.index]; from.Depth < depth && usage.StoppedBy == "" && !failed {
+			links := page.result.InternalPages
+			pageURL, _ := url.Parse(from.URL)
+			for _, alt := range page.result.Hreflang.Alternates {
+				if u, err := url.Parse(alt.URL); err == nil && u.Host == pageURL.Host {
+					links = append(links, alt.URL)
+				}
+			}
+			for _, link := range links {
+				if err := queue.add(a.Canonical(link), queued{link, from.Depth + 1}); err != nil {
+					fmt.Fprintln(stderr, "crawl:", err)
+					failed = true
+					break
+				}
+			}
+		}
+	}
+
+	analyzer.CheckHreflangReturnLinks(results)
+	analyzer.CheckFragmentLinks(results)
+
+	reports := make([]pageReport, len(visited))
+	for i, page := range visited {
+		if errs[i] != nil {
+			reports[i] = pageReport{URL: page.URL, Depth: page.Depth, Error: errs[i].Error()}
+			continue
+		}
+		reports[i] = newPageReport(page.Depth, results[i])
+	}
+	usage.Pages = len(visited)
+	usage.Duration = time.Since(startedAt)
+	return reports, usage
+}
+
+// writeJSON prints the reports as an indented JSON array
+func writeJSON(w io.Writer, reports []pageReport) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(reports)
+}
+
+// writeTable prints one row per page followed by the broken links, broken assets, document and form
+// findings, suspicious, dangling fragment and malformed contact links, hreflang and compression and
+// caching findings of every page, the Core Web Vitals of every origin and what each site crawl consumed
+func writeTable(w io.Writer, reports []pageReport, usages []crawlUsage) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "URL\tDEPTH\tTITLE\tHTML\tH1/H2/H3\tINTERNAL\tEXTERNAL\tBROKEN\tLOGIN\tTIME")
+	for _, r := range reports {
+		if r.Error != "" {
+			fmt.Fprintf(tw, "%s\t%d\tERROR: %s\t\t\t\t\t\t\t\n", r.URL, r.Depth, r.Error)
+			continue
+		}
+		fmt.Fprintf(tw, "%s\t%d\t%s\t%s\t%d/%d/%d\t%d\t%d\t%d\t%t\t%s\n",
+			r.URL, r.Depth, truncate(r.Title, 40), r.HtmlVersion, r.H1Count, r.H2Count, r.H3Count,
+			r.InternalLinks, r.ExternalLinks, r.BrokenLinks, r.HasLoginForm,
+			(time.Duration(r.DurationMs) * time.Millisecond).String())
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	for _, r := range reports {
+		if len(r.BrokenLinksDetails) == 0 {
+			continue
+		}
+		fmt.Fprintf(w, "\nBroken links on %s:\n", r.URL)
+		for _, link := range r.BrokenLinksDetails {
+			reason := link.Error
+			if link.StatusCode != 0 {
+				reason = fmt.Sprintf("HTTP %d", link.StatusCode)
+			}
+			fmt.Fprintf(w, "  %s (%s)\n", link.URL, reason)
+			for _, suggestion := range link.Suggestions {
+				fmt.Fprintf(w, "    try %s\n", suggestion)
+			}
+		}
+	}
+
+	for _, r := range reports {
+		if len(r.BrokenAssets) == 0 {
+			continue
+		}
+		fmt.Fprintf(w, "\nBroken assets on %s:\n", r.URL)
+		for _, asset := range r.BrokenAssets {
+			reason := asset.Error
+			if asset.StatusCode >= 400 {
+				reason = fmt.Sprintf("HTTP %d", asset.StatusCode)
+			}
+			if asset.ContentType != "" {
+				reason += ", " + asset.ContentType
+			}
+			fmt.Fprintf(w, "  %s %s (%s)\n", asset.Type, asset.URL, reason)
+		}
+	}
+
+	for _, r := range reports {
+		if r.Documents == nil || len(r.Documents.Findings) == 0 {
+			continue
+		}
+		fmt.Fprintf(w, "\nDocument findings on %s:\n", r.URL)
+		for _, f := range r.Documents.Findings {
+			fmt.Fprintf(w, "  [%s] %s (%s)\n", f.Severity, f.URL, f.Message)
+		}
+	}
+
+	for _, r := range reports {
+		if r.LinkHygiene == nil || len(r.LinkHygiene.Findings) == 0 {
+			continue
+		}
+		fmt.Fprintf(w, "\nSuspicious links on %s:\n", r.URL)
+		for _, f := range r.LinkHygiene.Findings {
+			fmt.Fprintf(w, "  %s (%s)\n", truncate(f.URL, 100), f.Message)
+		}
+	}
+
+	for _, r := range reports {
+		var flagged []analyzer.Form
+		for _, form := range r.Forms {
+			if len(form.Findings) > 0 {
+				flagged = append(flagged, form)
+			}
+		}
+		if len(flagged) == 0 {
+			continue
+		}
+		fmt.Fprintf(w, "\nForm findings on %s:\n", r.URL)
+		for _, form := range flagged {
+			fmt.Fprintf(w, "  %s %s (%s)\n", form.Method, truncate(form.Action, 100), strings.Join(form.Findings, ", "))
+		}
+	}
+
+	for _, r := range reports {
+		if r.Fragments == nil || len(r.Fragments.Findings) == 0 {
+			continue
+		}
+		fmt.Fprintf(w, "\nDangling fragment links on %s:\n", r.URL)
+		for _, f := range r.Fragments.Findings {
+			fmt.Fprintf(w, "  %s (%s)\n", truncate(f.URL, 100), f.Message)
+		}
+	}
+
+	for _, r := range reports {
+		if r.ContactLinks == nil || len(r.ContactLinks.Findings) == 0 {
+			continue
+		}
+		fmt.Fprintf(w, "\nMalformed contact links on %s:\n", r.URL)
+		for _, f := range r.ContactLinks.Findings {
+			fmt.Fprintf(w, "  %s (%s)\n", truncate(f.URL, 100), f.Message)
+		}
+	}
+
+	for _, r := range reports {
+		if r.Hreflang == nil || len(r.Hreflang.Findings) == 0 {
+			continue
+		}
+		fmt.Fprintf(w, "\nHreflang findings on %s:\n", r.URL)
+		for _, f := range r.Hreflang.Findings {
+			fmt.Fprintf(w, "  %s: %s\n", f.Severity, f.Message)
+		}
+	}
+
+	for _, r := range reports {
+		if r.Performance == nil || len(r.Performance.Findings) == 0 {
+			continue
+		}
+		fmt.Fprintf(w, "\nCompression and caching findings on %s:\n", r.URL)
+		for _, f := range r.Performance.Findings {
+			fmt.Fprintf(w, "  %s\n", f)
+		}
+	}
+
+	printedOrigins := make(map[string]bool)
+	for _, r := range reports {
+		if r.WebVitals == nil || printedOrigins[r.WebVitals.Origin] {
+			continue
+		}
+		printedOrigins[r.WebVitals.Origin] = true
+		v := r.WebVitals
+		fmt.Fprintf(w, "\nCore Web Vitals of %s: %s\n", v.Origin, v.Assessment)
+		if v.Error != "" {
+			fmt.Fprintf(w, "  %s\n", v.Error)
+		}
+		for _, m := range []struct {
+			name   string
+			metric *analyzer.VitalMetric
+			unit   string
+		}{{"LCP", v.LCP, "ms"}, {"CLS", v.CLS, ""}, {"INP", v.INP, "ms"}} {
+			if m.metric != nil {
+				fmt.Fprintf(w, "  %s p75 %g%s (%s)\n", m.name, m.metric.P75, m.unit, m.metric.Rating)
+			}
+		}
+	}
+
+	fmt.Fprintln(w)
+	for _, u := range usages {
+		fmt.Fprintf(w, "Crawl of %s: %d pages, %s downloaded in %s", u.URL, u.Pages, formatBytes(u.Bytes),
+			u.Duration.Round(time.Millisecond))
+		if u.StoppedBy != "" {
+			fmt.Fprintf(w, ", stopped by %s", u.StoppedBy)
+		}
+		fmt.Fprintln(w)
+	}
+	return nil
+}
+
+// formatBytes prints a size in bytes, kilobytes or megabytes
+func formatBytes(n int64) string {
+	switch {
+	case n < 1024:
+		return fmt.Sprintf("%d B", n)
+	case n < 1024*1024:
+		return fmt.Sprintf("%.1f KB", float64(n)/1024)
+	}
+	return fmt.Sprintf("%.1f MB", float64(n)/(1024*1024))
+}
+
+// truncate shortens s to at most n runes for table cells
+func truncate(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n-1]) + "…"
+}
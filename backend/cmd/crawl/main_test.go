@@ -0,0 +1,284 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testSite serves "/" linking to "/a", which links to "/b" and to a missing element of "/"; "/gone"
+// is broken and "/" has a script link
+func testSite() *httptest.Server {
+	pages := map[string]string{
+		"/":  `<html><head><title>Home</title></head><body><h1>Home</h1><img src="/logo.png"><a href="/a">A</a><a href="/gone">Gone</a><a href="javascript:void(0)">Menu</a><a href="mailto:info@example">Mail</a></body></html>`,
+		"/a": `<html><head><title>A</title></head><body><a href="/b">B</a><a href="/#footer">Home</a></body></html>`,
+		"/b": `<html><head><title>B</title></head><body></body></html>`,
+	}
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page, ok := pages[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprint(w, page)
+	}))
+}
+
+func TestParseArgs(t *testing.T) {
+	t.Run("defaults", func(t *testing.T) {
+		opts, err := parseArgs([]string{"example.com"}, io.Discard)
+		require.NoError(t, err)
+		assert.Equal(t, "table", opts.Format)
+		assert.Equal(t, 0, opts.Depth)
+		assert.Equal(t, []string{"https://example.com"}, opts.URLs)
+		assert.Equal(t, opts.Crawl.PageTimeout, opts.Crawl.RequestTimeout)
+	})
+
+	t.Run("rejects bad values", func(t *testing.T) {
+		var stderr bytes.Buffer
+		_, err := parseArgs([]string{"-format", "xml", "-depth", "-1", "example.com"}, &stderr)
+		assert.ErrorIs(t, err, errUsage)
+		assert.Contains(t, stderr.String(), "-format must be table or json")
+		assert.Contains(t, stderr.String(), "-depth must not be negative")
+	})
+
+	t.Run("crawl pace", func(t *testing.T) {
+		opts, err := parseArgs([]string{"-parallel", "8", "-per-host", "3", "-host-delay", "250ms", "-frontier-memory", "100", "example.com"}, io.Discard)
+		require.NoError(t, err)
+		assert.Equal(t, crawlPace{Parallel: 8, PerHost: 3, HostDelay: 250 * time.Millisecond, FrontierMemory: 100}, opts.Pace)
+
+		_, err = parseArgs([]string{"-parallel", "0", "example.com"}, io.Discard)
+		assert.ErrorIs(t, err, errUsage)
+		_, err = parseArgs([]string{"-host-delay", "-1s", "example.com"}, io.Discard)
+		assert.ErrorIs(t, err, errUsage)
+	})
+
+	t.Run("only static rendering", func(t *testing.T) {
+		_, err := parseArgs([]string{"-render", "js", "example.com"}, io.Discard)
+		assert.ErrorIs(t, err, errUsage)
+	})
+
+	t.Run("keywords", func(t *testing.T) {
+		opts, err := parseArgs([]string{"-keywords", "home, about us,,", "example.com"}, io.Discard)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"home", "about us"}, opts.Crawl.Keywords)
+	})
+
+	t.Run("internal domains", func(t *testing.T) {
+		opts, err := parseArgs([]string{"-subdomains-internal", "-internal-domains", "example-cdn.net, ,shop.example.org", "example.com"}, io.Discard)
+		require.NoError(t, err)
+		assert.True(t, opts.Crawl.TreatSubdomainsAsInternal)
+		assert.Equal(t, []string{"example-cdn.net", "shop.example.org"}, opts.Crawl.InternalDomains)
+	})
+
+	t.Run("canonicalization", func(t *testing.T) {
+		opts, err := parseArgs([]string{"-ignore-params", "utm_*,fbclid", "-ignore-trailing-slash", "example.com"}, io.Discard)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"utm_*", "fbclid"}, opts.Crawl.IgnoreQueryParams)
+		assert.True(t, opts.Crawl.IgnoreTrailingSlash)
+	})
+
+	t.Run("document checks", func(t *testing.T) {
+		opts, err := parseArgs([]string{"-check-documents", "-max-document-mb", "25", "example.com"}, io.Discard)
+		require.NoError(t, err)
+		assert.True(t, opts.Crawl.CheckDocuments)
+		assert.Equal(t, int64(25<<20), opts.Crawl.MaxDocumentBytes)
+
+		_, err = parseArgs([]string{"-max-document-mb", "0", "example.com"}, io.Discard)
+		assert.ErrorIs(t, err, errUsage)
+	})
+
+	t.Run("streaming large pages", func(t *testing.T) {
+		opts, err := parseArgs([]string{"example.com"}, io.Discard)
+		require.NoError(t, err)
+		assert.Equal(t, int64(5<<20), opts.Crawl.StreamAbove)
+
+		opts, err = parseArgs([]string{"-stream-above-mb", "0", "example.com"}, io.Discard)
+		require.NoError(t, err)
+		assert.Zero(t, opts.Crawl.StreamAbove)
+
+		_, err = parseArgs([]string{"-stream-above-mb", "-1", "example.com"}, io.Discard)
+		assert.ErrorIs(t, err, errUsage)
+	})
+
+	t.Run("crux key enables field data", func(t *testing.T) {
+		opts, err := parseArgs([]string{"-crux-key", "test-key", "example.com"}, io.Discard)
+		require.NoError(t, err)
+		assert.NotNil(t, opts.Crawl.FieldData)
+	})
+
+	t.Run("rdap enables registration lookups", func(t *testing.T) {
+		opts, err := parseArgs([]string{"-rdap", "example.com"}, io.Discard)
+		require.NoError(t, err)
+		assert.NotNil(t, opts.Crawl.Registry)
+	})
+
+	t.Run("geoip loads the database", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "ip2asn.tsv")
+		require.NoError(t, os.WriteFile(path, []byte("1.0.0.0\t1.0.0.255\t13335\tUS\tCLOUDFLARENET\n"), 0o600))
+		opts, err := parseArgs([]string{"-geoip", path, "example.com"}, io.Discard)
+		require.NoError(t, err)
+		assert.NotNil(t, opts.Crawl.IPLocator)
+
+		_, err = parseArgs([]string{"-geoip", filepath.Join(t.TempDir(), "missing.tsv"), "example.com"}, io.Discard)
+		assert.ErrorIs(t, err, errUsage)
+	})
+
+	t.Run("requires a URL", func(t *testing.T) {
+		_, err := parseArgs(nil, io.Discard)
+		assert.ErrorIs(t, err, errUsage)
+	})
+}
+
+func TestRun(t *testing.T) {
+	site := testSite()
+	defer site.Close()
+
+	t.Run("json output follows links to the requested depth", func(t *testing.T) {
+		var stdout bytes.Buffer
+		code := run(context.Background(), []string{"-format", "json", "-depth", "1", site.URL}, &stdout, io.Discard)
+		require.Equal(t, 0, code)
+
+		var reports []pageReport
+		require.NoError(t, json.Unmarshal(stdout.Bytes(), &reports))
+		require.Len(t, reports, 3) // "/", then "/a" and "/gone"; "/b" is two levels deep
+		assert.Equal(t, "Home", reports[0].Title)
+		assert.Equal(t, 1, reports[0].BrokenLinks)
+		assert.Positive(t, reports[0].BytesDownloaded)
+		assert.Equal(t, site.URL+"/a", reports[1].URL)
+		assert.Equal(t, 1, reports[1].Depth)
+		require.NotNil(t, reports[1].Fragments)
+		require.Len(t, reports[1].Fragments.Findings, 1)
+		assert.Equal(t, "footer", reports[1].Fragments.Findings[0].Fragment)
+		assert.NotEmpty(t, reports[2].Error)
+	})
+
+	t.Run("max pages caps the crawl", func(t *testing.T) {
+		var stdout bytes.Buffer
+		code := run(context.Background(), []string{"-format", "json", "-depth", "5", "-max-pages", "2", site.URL}, &stdout, io.Discard)
+		require.Equal(t, 0, code)
+
+		var reports []pageReport
+		require.NoError(t, json.Unmarshal(stdout.Bytes(), &reports))
+		assert.Len(t, reports, 2)
+	})
+
+	t.Run("byte budget stops the crawl and usage is reported", func(t *testing.T) {
+		var stdout, stderr bytes.Buffer
+		code := run(context.Background(), []string{"-depth", "5", "-max-bytes", "1", site.URL}, &stdout, &stderr)
+		require.Equal(t, 0, code)
+		assert.Contains(t, stdout.String(), "Crawl of "+site.URL+": 1 pages")
+		assert.Contains(t, stdout.String(), "stopped by -max-bytes")
+		assert.Contains(t, stderr.String(), "-max-bytes budget reached")
+	})
+
+	t.Run("duration budget stops the crawl", func(t *testing.T) {
+		var stdout bytes.Buffer
+		code := run(context.Background(), []string{"-format", "json", "-depth", "5", "-max-duration", "1ns", site.URL}, &stdout, io.Discard)
+		require.Equal(t, 1, code)
+
+		var reports []pageReport
+		require.NoError(t, json.Unmarshal(stdout.Bytes(), &reports))
+		require.Len(t, reports, 1)
+		assert.Contains(t, reports[0].Error, "-max-duration")
+	})
+
+	t.Run("table output lists broken and suspicious links", func(t *testing.T) {
+		var stdout bytes.Buffer
+		code := run(context.Background(), []string{site.URL}, &stdout, io.Discard)
+		require.Equal(t, 0, code)
+		assert.Contains(t, stdout.String(), "TITLE")
+		assert.Contains(t, stdout.String(), "Broken links on "+site.URL)
+		assert.Contains(t, stdout.String(), site.URL+"/gone (HTTP 404)")
+		assert.Contains(t, stdout.String(), "Suspicious links on "+site.URL)
+		assert.Contains(t, stdout.String(), `mailto:info@example ("info@example" has no valid domain)`)
+	})
+
+	t.Run("check assets lists broken images", func(t *testing.T) {
+		var stdout bytes.Buffer
+		code := run(context.Background(), []string{"-check-assets", site.URL}, &stdout, io.Discard)
+		require.Equal(t, 0, code)
+		assert.Contains(t, stdout.String(), "Broken assets on "+site.URL)
+		assert.Contains(t, stdout.String(), "image "+site.URL+"/logo.png (HTTP 404, text/plain)")
+	})
+
+	t.Run("pages analyzed one at a time keep the same order", func(t *testing.T) {
+		var parallel, serial bytes.Buffer
+		require.Equal(t, 0, run(context.Background(), []string{"-format", "json", "-depth", "2", site.URL}, &parallel, io.Discard))
+		require.Equal(t, 0, run(context.Background(), []string{"-format", "json", "-depth", "2", "-parallel", "1", "-frontier-memory", "1", site.URL}, &serial, io.Discard))
+
+		var a, b []pageReport
+		require.NoError(t, json.Unmarshal(parallel.Bytes(), &a))
+		require.NoError(t, json.Unmarshal(serial.Bytes(), &b))
+		require.Len(t, b, len(a))
+		require.Greater(t, len(a), 3)
+		for i := range a {
+			assert.Equal(t, a[i].URL, b[i].URL)
+			assert.Equal(t, a[i].Depth, b[i].Depth)
+		}
+	})
+
+	t.Run("failed start page exits with 1", func(t *testing.T) {
+		code := run(context.Background(), []string{site.URL + "/missing"}, io.Discard, io.Discard)
+		assert.Equal(t, 1, code)
+	})
+
+	t.Run("usage errors exit with 2", func(t *testing.T) {
+		assert.Equal(t, 2, run(context.Background(), []string{"-format", "xml", site.URL}, io.Discard, io.Discard))
+	})
+}
+
+func TestHreflangReturnLinks(t *testing.T) {
+	// "/en" lists "/de" as an alternate, but "/de" does not list "/en"
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		switch r.URL.Path {
+		case "/en":
+			fmt.Fprintf(w, `<html lang="en"><head>
+				<link rel="alternate" hreflang="en" href="%[1]s/en">
+				<link rel="alternate" hreflang="de" href="%[1]s/de">
+				<link rel="alternate" hreflang="x-default" href="%[1]s/en">
+			</head></html>`, server.URL)
+		case "/de":
+			fmt.Fprintf(w, `<html lang="de"><head>
+				<link rel="alternate" hreflang="de" href="%[1]s/de">
+				<link rel="alternate" hreflang="x-default" href="%[1]s/">
+			</head></html>`, server.URL)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	var stdout bytes.Buffer
+	code := run(context.Background(), []string{"-format", "json", "-depth", "1", server.URL + "/en"}, &stdout, io.Discard)
+	require.Equal(t, 0, code)
+
+	var reports []pageReport
+	require.NoError(t, json.Unmarshal(stdout.Bytes(), &reports))
+	require.Len(t, reports, 2) // the alternate is followed although no <a> links to it
+	require.NotNil(t, reports[0].Hreflang)
+	require.Len(t, reports[0].Hreflang.Findings, 1)
+	assert.Equal(t, "missing_return", reports[0].Hreflang.Findings[0].Code)
+	assert.Equal(t, server.URL+"/de", reports[0].Hreflang.Findings[0].URL)
+
+	t.Run("single pages are not checked for return links", func(t *testing.T) {
+		var stdout bytes.Buffer
+		code := run(context.Background(), []string{server.URL + "/en"}, &stdout, io.Discard)
+		require.Equal(t, 0, code)
+		assert.NotContains(t, stdout.String(), "does not link back")
+	})
+}
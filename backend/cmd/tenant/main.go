@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+
+	"sykell-analyze/backend/config"
+	"sykell-analyze/backend/tenancy"
+)
+
+// The tenant binary provisions the database of a new tenant and registers it in the primary
+// database. Running API servers and workers pick the tenant up within a minute and serve it on its
+// subdomain of tenancy.base_domain or with the tenancy.header header:
+//
+//	go run ./cmd/tenant acme
+//	go run ./cmd/tenant -schema ../sql/init.sql -admin-user root -prefix analyzer_ globex
+//
+// Administrators can do the same with POST /api/admin/tenants.
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// prefixPattern is what a database name prefix may hold
+var prefixPattern = regexp.MustCompile(`^[a-z0-9_]*$`)
+
+// errUsage reports a command line that was already explained on stderr
+var errUsage = errors.New("invalid usage")
+
+// tenantOptions holds the parsed command line
+type tenantOptions struct {
+	Tenant  string
+	Tenancy config.TenancyConfig
+}
+
+// Database is the name of the tenant's database
+func (o tenantOptions) Database() string {
+	return o.Tenancy.DatabasePrefix + o.Tenant
+}
+
+// parseArgs reads the command line; flags left out keep the tenancy settings of defaults
+func parseArgs(args []string, defaults config.TenancyConfig, stderr io.Writer) (tenantOptions, error) {
+	opts := tenantOptions{Tenancy: defaults}
+	if opts.Tenancy.AdminUser == "" {
+		opts.Tenancy.AdminUser = "root"
+		opts.Tenancy.AdminPassword = os.Getenv("MYSQL_ROOT_PASSWORD")
+	}
+	fs := flag.NewFlagSet("tenant", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: tenant [flags] NAME")
+		fs.PrintDefaults()
+	}
+	fs.StringVar(&opts.Tenancy.DatabasePrefix, "prefix", opts.Tenancy.DatabasePrefix, "prefix of the tenant's database name")
+	fs.StringVar(&opts.Tenancy.SchemaFile, "schema", opts.Tenancy.SchemaFile, "schema file to create the tables from")
+	fs.StringVar(&opts.Tenancy.AdminUser, "admin-user", opts.Tenancy.AdminUser, "MySQL user allowed to create databases and grant privileges")
+	fs.StringVar(&opts.Tenancy.AdminPassword, "admin-password", opts.Tenancy.AdminPassword, "password of -admin-user")
+	if err := fs.Parse(args); err != nil {
+		return opts, errUsage
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return opts, errUsage
+	}
+	opts.Tenant = fs.Arg(0)
+	if !tenancy.ValidName(opts.Tenant) {
+		fmt.Fprintln(stderr, tenancy.ErrInvalidName)
+		return opts, errUsage
+	}
+	if !prefixPattern.MatchString(opts.Tenancy.DatabasePrefix) {
+		fmt.Fprintln(stderr, "-prefix uses lowercase letters, digits and underscores")
+		return opts, errUsage
+	}
+	return opts, nil
+}
+
+func run(args []string, stdout, stderr io.Writer) int {
+	cfg, err := config.Load(config.FilePath())
+	if err != nil {
+		fmt.Fprintf(stderr, "Failed to load configuration: %v\n", err)
+		return 1
+	}
+	opts, err := parseArgs(args, cfg.Tenancy, stderr)
+	if err != nil {
+		return 2
+	}
+	cfg.Tenancy = opts.Tenancy
+	config.App = cfg
+
+	if err := config.ConnectDB(); err != nil {
+		fmt.Fprintf(stderr, "Failed to connect to database: %v\n", err)
+		return 1
+	}
+	if _, err := tenancy.Provision(context.Background(), opts.Tenant); err != nil {
+		fmt.Fprintf(stderr, "Failed to provision tenant %s: %v\n", opts.Tenant, err)
+		return 1
+	}
+	fmt.Fprintf(stdout, "✅ Tenant %s is ready in database %s\n", opts.Tenant, opts.Database())
+	return 0
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"io"
+	"testing"
+
+	"sykell-analyze/backend/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseArgs(t *testing.T) {
+	defaults := config.Default().Tenancy
+
+	t.Run("names the database after the tenant", func(t *testing.T) {
+		opts, err := parseArgs([]string{"-prefix", "analyzer_", "acme"}, defaults, io.Discard)
+		require.NoError(t, err)
+		assert.Equal(t, "analyzer_acme", opts.Database())
+	})
+
+	t.Run("defaults to the tenancy settings", func(t *testing.T) {
+		opts, err := parseArgs([]string{"acme"}, defaults, io.Discard)
+		require.NoError(t, err)
+		assert.Equal(t, "sykell_acme", opts.Database())
+		assert.Equal(t, "../sql/init.sql", opts.Tenancy.SchemaFile)
+		assert.Equal(t, "root", opts.Tenancy.AdminUser)
+	})
+
+	t.Run("rejects names unsafe in SQL", func(t *testing.T) {
+		for _, name := range []string{"Acme", "acme`; DROP DATABASE x", "", "_acme", "a-b"} {
+			_, err := parseArgs([]string{name}, defaults, io.Discard)
+			assert.ErrorIs(t, err, errUsage, name)
+		}
+		_, err := parseArgs([]string{"-prefix", "x`", "acme"}, defaults, io.Discard)
+		assert.ErrorIs(t, err, errUsage)
+	})
+
+	t.Run("requires exactly one tenant", func(t *testing.T) {
+		_, err := parseArgs([]string{"acme", "globex"}, defaults, io.Discard)
+		assert.ErrorIs(t, err, errUsage)
+	})
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"log"
+	"os/signal"
+	"sync"
+	"syscall"
+
+	"sykell-analyze/backend/alerts"
+	"sykell-analyze/backend/archive"
+	"sykell-analyze/backend/config"
+	"sykell-analyze/backend/crux"
+	"sykell-analyze/backend/digest"
+	"sykell-analyze/backend/dnscache"
+	"sykell-analyze/backend/geoip"
+	"sykell-analyze/backend/lighthouse"
+	"sykell-analyze/backend/monitor"
+	"sykell-analyze/backend/rdap"
+	"sykell-analyze/backend/retention"
+	"sykell-analyze/backend/safebrowsing"
+	"sykell-analyze/backend/storage"
+	"sykell-analyze/backend/tenancy"
+	"sykell-analyze/backend/urlstatus"
+	"sykell-analyze/backend/wayback"
+	"sykell-analyze/backend/worker"
+)
+
+// The worker binary processes crawl jobs from the shared MySQL queue.
+// Run as many as needed alongside API servers started with EMBEDDED_WORKER=false.
+func main() {
+	cfg, err := config.Load(config.FilePath())
+	if err != nil {
+		log.Fatalf("Failed to load configuration: %v", err)
+	}
+	config.App = cfg
+
+	if err := config.ConnectDB(); err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+
+	dnscache.Configure(cfg.DNS)
+	safebrowsing.Configure(cfg.SafeBrowsing)
+	crux.Configure(cfg.CrUX)
+	rdap.Configure(cfg.RDAP)
+	if err := geoip.Configure(cfg.GeoIP); err != nil {
+		log.Fatalf("Failed to load GeoIP database: %v", err)
+	}
+	wayback.Configure(cfg.Wayback)
+	lighthouse.Configure(cfg.Lighthouse)
+	if err := storage.Configure(cfg.Storage); err != nil {
+		log.Fatalf("Failed to set up storage: %v", err)
+	}
+	alerts.Configure(cfg.Alerts)
+	urlstatus.Subscribe(alerts.StatusChanged)
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	// SIGHUP reloads the crawler settings and the worker's concurrency
+	config.ReloadOnHangup(config.FilePath())
+
+	// One worker and one uptime monitor serve every database, sharing their concurrency; with
+	// tenancy, tenants provisioned while the worker runs are added as they appear
+	w := worker.New(cfg.Worker)
+	config.OnReload(func(cfg *config.Config) { w.Reconfigure(cfg.Worker) })
+	var m *monitor.Monitor
+	if cfg.Monitor.Enabled {
+		m = monitor.New(cfg.Monitor, cfg.Crawler.UserAgent)
+	}
+
+	var jobs sync.WaitGroup
+	runJobs(ctx, cfg, m, &jobs)
+
+	watchDone := make(chan struct{})
+	go func() {
+		defer close(watchDone)
+		if cfg.Tenancy.Enabled {
+			tenancy.Watch(ctx, func(t *config.Tenant) {
+				w.AddTenant(t)
+				runJobs(config.WithTenant(ctx, t), cfg, m, &jobs)
+			})
+		}
+	}()
+
+	w.Run(ctx)
+	<-watchDone
+	jobs.Wait()
+}
+
+// runJobs starts the background jobs for the database of ctx on wg; they stop with the worker when
+// ctx is cancelled
+func runJobs(ctx context.Context, cfg *config.Config, m *monitor.Monitor, wg *sync.WaitGroup) {
+	start := func(run func(context.Context)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			run(ctx)
+		}()
+	}
+
+	// The uptime monitor shares the process
+	if m != nil {
+		start(m.Run)
+	}
+	// Likewise the digest job
+	if cfg.Digest.Enabled {
+		start(digest.New(cfg.Digest, cfg.Crawler.StaleAfter).Run)
+	}
+	// And the retention cleanup
+	if cfg.Retention.Interval > 0 {
+		start(retention.New(cfg.Retention).Run)
+	}
+	// And the snapshot archival
+	if cfg.Archive.Enabled {
+		start(archive.New(cfg.Archive).Run)
+	}
+}
@@ -0,0 +1,911 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"net/url"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultJWTSecret is the development-only signing secret, rejected in release mode
+const DefaultJWTSecret = "your-secret-key-change-in-production"
+
+// Config holds every tunable setting of the backend
+type Config struct {
+	Server       ServerConfig       `yaml:"server"`
+	TLS          TLSConfig          `yaml:"tls"`
+	Database     DatabaseConfig     `yaml:"database"`
+	Cache        CacheConfig        `yaml:"cache"`
+	Crawler      CrawlerConfig      `yaml:"crawler"`
+	DNS          DNSConfig          `yaml:"dns"`
+	SafeBrowsing SafeBrowsingConfig `yaml:"safe_browsing"`
+	Lighthouse   LighthouseConfig   `yaml:"lighthouse"`
+	CrUX         CrUXConfig         `yaml:"crux"`
+	RDAP         RDAPConfig         `yaml:"rdap"`
+	GeoIP        GeoIPConfig        `yaml:"geoip"`
+	Wayback      WaybackConfig      `yaml:"wayback"`
+	Worker       WorkerConfig       `yaml:"worker"`
+	Monitor      MonitorConfig      `yaml:"monitor"`
+	Alerts       AlertsConfig       `yaml:"alerts"`
+	Digest       DigestConfig       `yaml:"digest"`
+	Demo         DemoConfig         `yaml:"demo"`
+	Retention    RetentionConfig    `yaml:"retention"`
+	Storage      StorageConfig      `yaml:"storage"`
+	Archive      ArchiveConfig      `yaml:"archive"`
+	CORS         CORSConfig         `yaml:"cors"`
+	JWT          JWTConfig          `yaml:"jwt"`
+	Tenancy      TenancyConfig      `yaml:"tenancy"`
+}
+
+// ServerConfig controls the HTTP server
+type ServerConfig struct {
+	Port           int    `yaml:"port"`
+	GinMode        string `yaml:"gin_mode"`
+	GzipMinSize    int    `yaml:"gzip_min_size"`
+	EmbeddedWorker bool   `yaml:"embedded_worker"`
+	BulkUrlMax     int    `yaml:"bulk_url_max"`
+	// RequestTimeout bounds handler execution; requests still running get a 504 (0 disables)
+	RequestTimeout time.Duration `yaml:"request_timeout"`
+	// MaxBodyBytes bounds request bodies; larger ones get a 413
+	MaxBodyBytes int `yaml:"max_body_bytes"`
+	// MaxUploadBytes is the larger bound of the import and bulk routes
+	MaxUploadBytes int `yaml:"max_upload_bytes"`
+	// ServeFrontend serves the React build embedded into the binary at /, when there is one
+	ServeFrontend bool `yaml:"serve_frontend"`
+	// ListenSocket is a Unix domain socket path to listen on instead of port. A socket passed by
+	// systemd socket activation takes precedence over both.
+	ListenSocket string `yaml:"listen_socket"`
+	// Pprof serves net/http/pprof profiles to administrators at /api/admin/debug/pprof/
+	Pprof bool `yaml:"pprof"`
+}
+
+// TLSConfig enables HTTPS on server.port, either with a certificate from files or one obtained from
+// Let's Encrypt. Setting neither keeps plain HTTP, for running behind a reverse proxy.
+type TLSConfig struct {
+	CertFile string `yaml:"cert_file"`
+	KeyFile  string `yaml:"key_file"`
+	// AutocertDomains are the host names to obtain Let's Encrypt certificates for
+	AutocertDomains []string `yaml:"autocert_domains"`
+	// AutocertEmail is given to Let's Encrypt for expiry notices
+	AutocertEmail string `yaml:"autocert_email"`
+	// AutocertCacheDir keeps certificates across restarts, so they are not requested again
+	AutocertCacheDir string `yaml:"autocert_cache_dir"`
+	// RedirectPort serves a plain HTTP listener redirecting to HTTPS (0 disables). Let's Encrypt
+	// HTTP-01 challenges are answered there too.
+	RedirectPort int `yaml:"redirect_port"`
+}
+
+// Enabled reports whether the server speaks HTTPS
+func (t TLSConfig) Enabled() bool {
+	return t.CertFile != "" || len(t.AutocertDomains) > 0
+}
+
+// DatabaseConfig describes the MySQL connection
+type DatabaseConfig struct {
+	Host            string        `yaml:"host"`
+	Port            int           `yaml:"port"`
+	User            string        `yaml:"user"`
+	Password        string        `yaml:"password"`
+	Name            string        `yaml:"name"`
+	MaxOpenConns    int           `yaml:"max_open_conns"`
+	MaxIdleConns    int           `yaml:"max_idle_conns"`
+	ConnMaxLifetime time.Duration `yaml:"conn_max_lifetime"`
+	// SlowQueryThreshold logs queries that take longer (0 disables)
+	SlowQueryThreshold time.Duration `yaml:"slow_query_threshold"`
+	// ReplicaHosts are read replicas ("host" or "host:port") for the URL list and stats (empty disables)
+	ReplicaHosts []string `yaml:"replica_hosts"`
+	// ReplicaReadAfterWrite keeps a user's reads on the primary this long after they change something
+	ReplicaReadAfterWrite time.Duration `yaml:"replica_read_after_write"`
+}
+
+// CacheConfig controls the optional Redis response cache
+type CacheConfig struct {
+	RedisURL string        `yaml:"redis_url"`
+	TTL      time.Duration `yaml:"ttl"`
+}
+
+// CrawlerConfig tunes page fetching and link checking
+type CrawlerConfig struct {
+	PageTimeout             time.Duration `yaml:"page_timeout"`
+	RequestTimeout          time.Duration `yaml:"request_timeout"`
+	LinkCheckTimeout        time.Duration `yaml:"link_check_timeout"`
+	MaxConcurrentLinkChecks int           `yaml:"max_concurrent_link_checks"`
+	UserAgent               string        `yaml:"user_agent"`
+	SharedCacheWindow       time.Duration `yaml:"shared_cache_window"`
+	StaleAfter              time.Duration `yaml:"stale_after"`
+	// DryRunTimeout bounds POST /api/analyze and the sitemap reading of POST /api/import/sitemap,
+	// which fetch while the client waits
+	DryRunTimeout time.Duration `yaml:"dry_run_timeout"`
+	// TreatSubdomainsAsInternal counts links to other subdomains of a page's domain as internal
+	TreatSubdomainsAsInternal bool `yaml:"treat_subdomains_as_internal"`
+	// InternalDomains lists further domains whose links, subdomains included, count as internal
+	InternalDomains []string `yaml:"internal_domains"`
+	// IgnoreQueryParams lists query parameters, such as utm_*, removed from links before they are compared
+	IgnoreQueryParams []string `yaml:"ignore_query_params"`
+	// IgnoreTrailingSlash treats /page and /page/ as the same page
+	IgnoreTrailingSlash bool `yaml:"ignore_trailing_slash"`
+	// CheckAssets also checks each page's images, scripts and stylesheets for broken assets
+	CheckAssets bool `yaml:"check_assets"`
+	// CheckDocuments checks the media type and size of linked PDFs and office documents
+	CheckDocuments bool `yaml:"check_documents"`
+	// MaxDocumentMB is the size in megabytes above which a linked document is reported
+	MaxDocumentMB int `yaml:"max_document_mb"`
+	// StreamAboveMB is the page size in megabytes above which pages are tokenized as they are read
+	// instead of parsed into a DOM, leaving the DOM audits out (0 always parses)
+	StreamAboveMB int `yaml:"stream_above_mb"`
+	// AllowDomains, when set, restricts crawl targets to these domains, subdomains included, or to
+	// host names matching "re:<regexp>" entries
+	AllowDomains []string `yaml:"allow_domains"`
+	// DenyDomains lists domains and "re:<regexp>" entries that are never crawled, even when allowed
+	DenyDomains []string `yaml:"deny_domains"`
+	// domains is AllowDomains and DenyDomains compiled by Load, see CheckTarget
+	domains *domainPolicy
+	// AllowPrivateNetworks lets crawls reach loopback, private and link-local addresses (IPv4 and
+	// IPv6). Leave it off on shared servers: users could otherwise probe the server's own network.
+	AllowPrivateNetworks bool `yaml:"allow_private_networks"`
+	// LinkCacheTTL is how long a link check verdict is reused by later crawls of any page (0 disables)
+	LinkCacheTTL time.Duration `yaml:"link_cache_ttl"`
+}
+
+// DNSConfig sets how crawls resolve host names
+type DNSConfig struct {
+	// Servers are name servers (host or host:port) asked instead of the system's
+	Servers []string `yaml:"servers"`
+	// DoHURL is a DNS over HTTPS endpoint (RFC 8484), e.g. https://cloudflare-dns.com/dns-query
+	DoHURL string `yaml:"doh_url"`
+	// CacheTTL is how long resolved addresses are kept in memory (0 disables the cache)
+	CacheTTL time.Duration `yaml:"cache_ttl"`
+	// Timeout bounds each query to Servers or DoHURL
+	Timeout time.Duration `yaml:"timeout"`
+}
+
+// SafeBrowsingConfig enables Google Safe Browsing lookups of analyzed pages and their external links
+type SafeBrowsingConfig struct {
+	// APIKey is a Google Cloud key with the Safe Browsing API enabled (empty disables lookups)
+	APIKey string `yaml:"api_key"`
+	// CacheTTL is how long a URL that is not listed stays cached; listed URLs use the API's cache duration
+	CacheTTL time.Duration `yaml:"cache_ttl"`
+	Timeout  time.Duration `yaml:"timeout"`
+}
+
+// LighthouseConfig enables Lighthouse scoring of analyzed pages through the PageSpeed Insights API
+type LighthouseConfig struct {
+	Enabled bool `yaml:"enabled"`
+	// APIKey is a Google Cloud key with the PageSpeed Insights API enabled. It is optional, but
+	// anonymous requests share a small quota.
+	APIKey string `yaml:"api_key"`
+	// Strategy is the device Lighthouse emulates: mobile or desktop
+	Strategy string `yaml:"strategy"`
+	// Timeout bounds one run; Lighthouse loads the page in a real browser, which often takes 20-60s
+	Timeout time.Duration `yaml:"timeout"`
+}
+
+// CrUXConfig enables Chrome UX Report lookups of the field Core Web Vitals of analyzed origins
+type CrUXConfig struct {
+	// APIKey is a Google Cloud key with the Chrome UX Report API enabled (empty disables lookups)
+	APIKey string `yaml:"api_key"`
+	// CacheTTL is how long an origin's record stays cached; CrUX publishes new data daily
+	CacheTTL time.Duration `yaml:"cache_ttl"`
+	Timeout  time.Duration `yaml:"timeout"`
+}
+
+// RDAPConfig enables registrar and expiry lookups of analyzed domains over RDAP
+type RDAPConfig struct {
+	Enabled bool `yaml:"enabled"`
+	// BaseURL is an RDAP service answering <base>/domain/<name>; the default redirects to each registry
+	BaseURL string `yaml:"base_url"`
+	// CacheTTL is how long a domain's registration stays cached
+	CacheTTL time.Duration `yaml:"cache_ttl"`
+	Timeout  time.Duration `yaml:"timeout"`
+}
+
+// GeoIPConfig enables looking up the network and country of analyzed servers
+type GeoIPConfig struct {
+	// Database is the path of an ip2asn TSV file from iptoasn.com, optionally gzipped (empty disables lookups)
+	Database string `yaml:"database"`
+}
+
+// WaybackConfig enables the Internet Archive's Wayback Machine for broken link suggestions and
+// archiving crawled pages
+type WaybackConfig struct {
+	Enabled bool `yaml:"enabled"`
+	// BaseURL serves the availability API at <base>/wayback/available
+	BaseURL string        `yaml:"base_url"`
+	Timeout time.Duration `yaml:"timeout"`
+	// Archive submits every crawled page to Save Page Now, which needs the S3-style keys of an
+	// archive.org account (https://archive.org/account/s3.php)
+	Archive   bool   `yaml:"archive"`
+	SaveURL   string `yaml:"save_url"`
+	AccessKey string `yaml:"access_key"`
+	SecretKey string `yaml:"secret_key"`
+	// SaveTimeout bounds waiting for a capture, which usually takes 10-60 seconds
+	SaveTimeout time.Duration `yaml:"save_timeout"`
+}
+
+// WorkerConfig controls how crawl workers pull jobs from the queue
+type WorkerConfig struct {
+	Concurrency       int           `yaml:"concurrency"`
+	PollInterval      time.Duration `yaml:"poll_interval"`
+	LeaseDuration     time.Duration `yaml:"lease_duration"`
+	HeartbeatInterval time.Duration `yaml:"heartbeat_interval"`
+	MaxAttempts       int           `yaml:"max_attempts"`
+	ProgressInterval  time.Duration `yaml:"progress_interval"`
+	// MaxCrawlsPerUser caps how many of one user's crawls run at once across all workers (0 = unlimited).
+	// users.max_concurrent_crawls overrides it per user.
+	MaxCrawlsPerUser int `yaml:"max_crawls_per_user"`
+}
+
+// MonitorConfig controls the uptime monitor, which pings every tracked URL on an interval
+type MonitorConfig struct {
+	Enabled bool `yaml:"enabled"`
+	// Interval is how often each URL is pinged
+	Interval    time.Duration `yaml:"interval"`
+	Timeout     time.Duration `yaml:"timeout"`
+	Concurrency int           `yaml:"concurrency"`
+	// Retention is how long pings are kept; availability covers at most this window
+	Retention time.Duration `yaml:"retention"`
+}
+
+// AlertsConfig controls how alert notifications are delivered
+type AlertsConfig struct {
+	// SMTPHost is the mail server for email alerts (empty disables email channels)
+	SMTPHost     string `yaml:"smtp_host"`
+	SMTPPort     int    `yaml:"smtp_port"`
+	SMTPUsername string `yaml:"smtp_username"`
+	SMTPPassword string `yaml:"smtp_password"`
+	// From is the sender address of alert emails
+	From string `yaml:"from"`
+	// Timeout bounds each delivery, including webhook and Slack requests
+	Timeout time.Duration `yaml:"timeout"`
+}
+
+// DigestConfig controls the daily and weekly activity digests users can subscribe to by email
+type DigestConfig struct {
+	// Enabled runs the digest job in this process; digests are sent through alerts.smtp_host
+	Enabled bool `yaml:"enabled"`
+}
+
+// DemoConfig controls the demo account, which lets visitors browse seeded example analyses with a
+// read-only token
+type DemoConfig struct {
+	Enabled bool `yaml:"enabled"`
+	// Username is the demo account's name; it is created and seeded at startup
+	Username string `yaml:"username"`
+	// TokenTTL is how long a demo token stays valid
+	TokenTTL time.Duration `yaml:"token_ttl"`
+}
+
+// RetentionConfig controls how long crawl history, text snapshots and broken link records are kept
+type RetentionConfig struct {
+	// Days is the longest any account keeps records (0 = forever). Accounts may choose fewer days,
+	// and administrators may override both per account.
+	Days int `yaml:"days"`
+	// Interval is how often old records are purged (0 disables the cleanup job)
+	Interval time.Duration `yaml:"interval"`
+}
+
+// StorageConfig selects where large artifacts such as archived snapshots and data exports are kept
+type StorageConfig struct {
+	// Backend is local (files under Path) or s3 (an S3-compatible bucket such as AWS S3 or MinIO)
+	Backend string `yaml:"backend"`
+	// Path is the directory of the local backend; mount a volume there in containers
+	Path string `yaml:"path"`
+	// Endpoint is the service URL, such as https://s3.eu-central-1.amazonaws.com or http://minio:9000
+	Endpoint  string `yaml:"endpoint"`
+	Region    string `yaml:"region"`
+	Bucket    string `yaml:"bucket"`
+	AccessKey string `yaml:"access_key"`
+	SecretKey string `yaml:"secret_key"`
+	// PathStyle puts the bucket in the path instead of the host name, as MinIO expects
+	PathStyle bool          `yaml:"path_style"`
+	Timeout   time.Duration `yaml:"timeout"`
+}
+
+// ArchiveConfig controls moving the text of old snapshots from MySQL to storage
+type ArchiveConfig struct {
+	Enabled bool `yaml:"enabled"`
+	// After is how old a snapshot must be to be archived
+	After time.Duration `yaml:"after"`
+	// Interval is how often old snapshots are archived
+	Interval time.Duration `yaml:"interval"`
+}
+
+// CORSConfig lists the browser origins allowed to call the API
+type CORSConfig struct {
+	AllowOrigins []string `yaml:"allow_origins"`
+}
+
+// JWTConfig controls token signing
+type JWTConfig struct {
+	Secret   string        `yaml:"secret"`
+	TokenTTL time.Duration `yaml:"token_ttl"`
+}
+
+// TenancyConfig lets one process serve several tenants, each with a database of its own. Requests
+// select their tenant by subdomain of BaseDomain or by Header; requests without one use the primary
+// database, where administrators provision tenants.
+type TenancyConfig struct {
+	Enabled bool `yaml:"enabled"`
+	// BaseDomain selects tenants by subdomain: acme.<base_domain> is tenant acme (empty disables)
+	BaseDomain string `yaml:"base_domain"`
+	// Header selects tenants by name, for clients that cannot use subdomains (empty disables)
+	Header string `yaml:"header"`
+	// DatabasePrefix is put before a tenant's name to name its database
+	DatabasePrefix string `yaml:"database_prefix"`
+	// SchemaFile holds the full schema new tenant databases are created from
+	SchemaFile string `yaml:"schema_file"`
+	// AdminUser creates tenant databases and grants database.user access to them; empty uses
+	// database.user, which then needs the CREATE privilege
+	AdminUser     string `yaml:"admin_user"`
+	AdminPassword string `yaml:"admin_password"`
+}
+
+// App is the active configuration. It holds the defaults until Load replaces it at startup.
+var App = Default()
+
+// Default returns the settings used when neither config.yaml nor the environment override them
+func Default() *Config {
+	return &Config{
+		Server: ServerConfig{
+			Port:           8080,
+			GinMode:        "debug",
+			GzipMinSize:    1024,
+			EmbeddedWorker: true,
+			BulkUrlMax:     100,
+			RequestTimeout: 30 * time.Second,
+			MaxBodyBytes:   1 << 20,
+			MaxUploadBytes: 10 << 20,
+			ServeFrontend:  true,
+		},
+		TLS: TLSConfig{
+			AutocertCacheDir: "autocert-cache",
+			RedirectPort:     80,
+		},
+		Database: DatabaseConfig{
+			Host:                  "localhost",
+			Port:                  3306,
+			User:                  "sykell_user",
+			Password:              "sykell_pass",
+			Name:                  "sykell_db",
+			MaxOpenConns:          25,
+			MaxIdleConns:          5,
+			ConnMaxLifetime:       5 * time.Minute,
+			SlowQueryThreshold:    500 * time.Millisecond,
+			ReplicaReadAfterWrite: 5 * time.Second,
+		},
+		Cache: CacheConfig{
+			TTL: 30 * time.Second,
+		},
+		Crawler: CrawlerConfig{
+			PageTimeout:             90 * time.Second,
+			RequestTimeout:          60 * time.Second,
+			LinkCheckTimeout:        15 * time.Second,
+			MaxConcurrentLinkChecks: 10,
+			SharedCacheWindow:       time.Hour,
+			StaleAfter:              7 * 24 * time.Hour,
+			DryRunTimeout:           20 * time.Second,
+			MaxDocumentMB:           10,
+			StreamAboveMB:           5,
+			LinkCacheTTL:            24 * time.Hour,
+		},
+		SafeBrowsing: SafeBrowsingConfig{
+			CacheTTL: 30 * time.Minute,
+			Timeout:  10 * time.Second,
+		},
+		Lighthouse: LighthouseConfig{
+			Strategy: "mobile",
+			Timeout:  90 * time.Second,
+		},
+		DNS: DNSConfig{
+			CacheTTL: 5 * time.Minute,
+			Timeout:  5 * time.Second,
+		},
+		CrUX: CrUXConfig{
+			CacheTTL: 12 * time.Hour,
+			Timeout:  10 * time.Second,
+		},
+		RDAP: RDAPConfig{
+			BaseURL:  "https://rdap.org",
+			CacheTTL: 24 * time.Hour,
+			Timeout:  10 * time.Second,
+		},
+		Wayback: WaybackConfig{
+			BaseURL:     "https://archive.org",
+			Timeout:     10 * time.Second,
+			SaveURL:     "https://web.archive.org/save",
+			SaveTimeout: 2 * time.Minute,
+		},
+		Monitor: MonitorConfig{
+			Interval:    5 * time.Minute,
+			Timeout:     10 * time.Second,
+			Concurrency: 10,
+			Retention:   30 * 24 * time.Hour,
+		},
+		Alerts: AlertsConfig{
+			SMTPPort: 587,
+			From:     "alerts@sykell-analyze.local",
+			Timeout:  10 * time.Second,
+		},
+		Worker: WorkerConfig{
+			Concurrency:       5,
+			PollInterval:      2 * time.Second,
+			LeaseDuration:     3 * time.Minute,
+			HeartbeatInterval: 15 * time.Second,
+			MaxAttempts:       3,
+			ProgressInterval:  2 * time.Second,
+			MaxCrawlsPerUser:  3,
+		},
+		Demo: DemoConfig{
+			Username: "guest",
+			TokenTTL: 2 * time.Hour,
+		},
+		Retention: RetentionConfig{
+			Interval: time.Hour,
+		},
+		Storage: StorageConfig{
+			Backend: "local",
+			Path:    "data/storage",
+			Region:  "us-east-1",
+			Timeout: 30 * time.Second,
+		},
+		Archive: ArchiveConfig{
+			After:    30 * 24 * time.Hour,
+			Interval: time.Hour,
+		},
+		CORS: CORSConfig{
+			AllowOrigins: []string{"http://localhost:3000", "http://localhost:80"},
+		},
+		JWT: JWTConfig{
+			Secret:   DefaultJWTSecret,
+			TokenTTL: 24 * time.Hour,
+		},
+		Tenancy: TenancyConfig{
+			Header:         "X-Tenant",
+			DatabasePrefix: "sykell_",
+			SchemaFile:     "../sql/init.sql",
+		},
+	}
+}
+
+// FilePath returns the config file location (CONFIG_FILE, default config.yaml in the working directory)
+func FilePath() string {
+	if path := os.Getenv("CONFIG_FILE"); path != "" {
+		return path
+	}
+	return "config.yaml"
+}
+
+// Load builds the configuration from the defaults, the YAML file at path and then environment
+// variables, in that order of precedence. A missing file is not an error so the backend still runs
+// from the environment alone. The result is validated before it is returned.
+func Load(path string) (*Config, error) {
+	cfg := Default()
+
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("failed to read config file: %w", err)
+		}
+		if err == nil {
+			if err := decodeYAML(data, cfg); err != nil {
+				return nil, fmt.Errorf("invalid config file %s: %w", path, err)
+			}
+		}
+	}
+
+	if err := applyEnv(cfg); err != nil {
+		return nil, err
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	cfg.Crawler.compileDomains()
+	return cfg, nil
+}
+
+// decodeYAML overlays the file onto cfg, rejecting unknown keys so typos are not silently ignored
+func decodeYAML(data []byte, cfg *Config) error {
+	if strings.TrimSpace(string(data)) == "" {
+		return nil
+	}
+	decoder := yaml.NewDecoder(strings.NewReader(string(data)))
+	decoder.KnownFields(true)
+	return decoder.Decode(cfg)
+}
+
+// envReader applies environment overrides, collecting parse errors instead of stopping at the first
+type envReader struct {
+	errs []error
+}
+
+func (r *envReader) lookup(name string) (string, bool) {
+	value, ok := os.LookupEnv(name)
+	if !ok || strings.TrimSpace(value) == "" {
+		return "", false
+	}
+	return strings.TrimSpace(value), true
+}
+
+func (r *envReader) string(name string, dst *string) {
+	if value, ok := r.lookup(name); ok {
+		*dst = value
+	}
+}
+
+func (r *envReader) int(name string, dst *int) {
+	if value, ok := r.lookup(name); ok {
+		parsed, err := strconv.Atoi(value)
+		if err != nil {
+			r.errs = append(r.errs, fmt.Errorf("%s must be an integer, got %q", name, value))
+			return
+		}
+		*dst = parsed
+	}
+}
+
+func (r *envReader) bool(name string, dst *bool) {
+	if value, ok := r.lookup(name); ok {
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			r.errs = append(r.errs, fmt.Errorf("%s must be true or false, got %q", name, value))
+			return
+		}
+		*dst = parsed
+	}
+}
+
+func (r *envReader) duration(name string, dst *time.Duration) {
+	if value, ok := r.lookup(name); ok {
+		parsed, err := time.ParseDuration(value)
+		if err != nil {
+			r.errs = append(r.errs, fmt.Errorf("%s must be a duration such as 30s or 5m, got %q", name, value))
+			return
+		}
+		*dst = parsed
+	}
+}
+
+func (r *envReader) list(name string, dst *[]string) {
+	if value, ok := r.lookup(name); ok {
+		var items []string
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		*dst = items
+	}
+}
+
+// applyEnv overrides file settings with environment variables
+func applyEnv(cfg *Config) error {
+	r := &envReader{}
+
+	r.int("PORT", &cfg.Server.Port)
+	r.string("GIN_MODE", &cfg.Server.GinMode)
+	r.int("GZIP_MIN_SIZE", &cfg.Server.GzipMinSize)
+	r.bool("EMBEDDED_WORKER", &cfg.Server.EmbeddedWorker)
+	r.int("BULK_URL_MAX", &cfg.Server.BulkUrlMax)
+	r.duration("REQUEST_TIMEOUT", &cfg.Server.RequestTimeout)
+	r.int("MAX_BODY_BYTES", &cfg.Server.MaxBodyBytes)
+	r.int("MAX_UPLOAD_BYTES", &cfg.Server.MaxUploadBytes)
+	r.bool("SERVE_FRONTEND", &cfg.Server.ServeFrontend)
+	r.string("LISTEN_SOCKET", &cfg.Server.ListenSocket)
+	r.bool("PPROF_ENABLED", &cfg.Server.Pprof)
+
+	r.string("TLS_CERT_FILE", &cfg.TLS.CertFile)
+	r.string("TLS_KEY_FILE", &cfg.TLS.KeyFile)
+	r.list("TLS_AUTOCERT_DOMAINS", &cfg.TLS.AutocertDomains)
+	r.string("TLS_AUTOCERT_EMAIL", &cfg.TLS.AutocertEmail)
+	r.string("TLS_AUTOCERT_CACHE_DIR", &cfg.TLS.AutocertCacheDir)
+	r.int("TLS_REDIRECT_PORT", &cfg.TLS.RedirectPort)
+
+	r.string("DB_HOST", &cfg.Database.Host)
+	r.int("DB_PORT", &cfg.Database.Port)
+	r.string("DB_USER", &cfg.Database.User)
+	r.string("DB_PASSWORD", &cfg.Database.Password)
+	r.string("DB_NAME", &cfg.Database.Name)
+	r.int("DB_MAX_OPEN_CONNS", &cfg.Database.MaxOpenConns)
+	r.int("DB_MAX_IDLE_CONNS", &cfg.Database.MaxIdleConns)
+	r.duration("DB_CONN_MAX_LIFETIME", &cfg.Database.ConnMaxLifetime)
+	r.duration("DB_SLOW_QUERY_THRESHOLD", &cfg.Database.SlowQueryThreshold)
+	r.list("DB_REPLICA_HOSTS", &cfg.Database.ReplicaHosts)
+	r.duration("DB_REPLICA_READ_AFTER_WRITE", &cfg.Database.ReplicaReadAfterWrite)
+
+	r.string("REDIS_URL", &cfg.Cache.RedisURL)
+	r.duration("CACHE_TTL", &cfg.Cache.TTL)
+
+	r.duration("CRAWLER_PAGE_TIMEOUT", &cfg.Crawler.PageTimeout)
+	r.duration("CRAWLER_REQUEST_TIMEOUT", &cfg.Crawler.RequestTimeout)
+	r.duration("CRAWLER_LINK_CHECK_TIMEOUT", &cfg.Crawler.LinkCheckTimeout)
+	r.int("CRAWLER_MAX_CONCURRENT_LINK_CHECKS", &cfg.Crawler.MaxConcurrentLinkChecks)
+	r.string("CRAWLER_USER_AGENT", &cfg.Crawler.UserAgent)
+	r.duration("CRAWL_CACHE_WINDOW", &cfg.Crawler.SharedCacheWindow)
+	r.duration("STALE_AFTER", &cfg.Crawler.StaleAfter)
+	r.duration("CRAWLER_DRY_RUN_TIMEOUT", &cfg.Crawler.DryRunTimeout)
+	r.bool("CRAWLER_TREAT_SUBDOMAINS_AS_INTERNAL", &cfg.Crawler.TreatSubdomainsAsInternal)
+	r.list("CRAWLER_INTERNAL_DOMAINS", &cfg.Crawler.InternalDomains)
+	r.list("CRAWLER_IGNORE_QUERY_PARAMS", &cfg.Crawler.IgnoreQueryParams)
+	r.bool("CRAWLER_IGNORE_TRAILING_SLASH", &cfg.Crawler.IgnoreTrailingSlash)
+	r.bool("CRAWLER_CHECK_ASSETS", &cfg.Crawler.CheckAssets)
+	r.bool("CRAWLER_CHECK_DOCUMENTS", &cfg.Crawler.CheckDocuments)
+	r.int("CRAWLER_MAX_DOCUMENT_MB", &cfg.Crawler.MaxDocumentMB)
+	r.int("CRAWLER_STREAM_ABOVE_MB", &cfg.Crawler.StreamAboveMB)
+	r.list("CRAWLER_ALLOW_DOMAINS", &cfg.Crawler.AllowDomains)
+	r.list("CRAWLER_DENY_DOMAINS", &cfg.Crawler.DenyDomains)
+	r.bool("CRAWLER_ALLOW_PRIVATE_NETWORKS", &cfg.Crawler.AllowPrivateNetworks)
+	r.duration("CRAWLER_LINK_CACHE_TTL", &cfg.Crawler.LinkCacheTTL)
+	r.list("DNS_SERVERS", &cfg.DNS.Servers)
+	r.string("DNS_DOH_URL", &cfg.DNS.DoHURL)
+	r.duration("DNS_CACHE_TTL", &cfg.DNS.CacheTTL)
+	r.duration("DNS_TIMEOUT", &cfg.DNS.Timeout)
+
+	r.string("SAFE_BROWSING_API_KEY", &cfg.SafeBrowsing.APIKey)
+	r.duration("SAFE_BROWSING_CACHE_TTL", &cfg.SafeBrowsing.CacheTTL)
+	r.duration("SAFE_BROWSING_TIMEOUT", &cfg.SafeBrowsing.Timeout)
+
+	r.bool("LIGHTHOUSE_ENABLED", &cfg.Lighthouse.Enabled)
+	r.string("PAGESPEED_API_KEY", &cfg.Lighthouse.APIKey)
+	r.string("LIGHTHOUSE_STRATEGY", &cfg.Lighthouse.Strategy)
+	r.duration("LIGHTHOUSE_TIMEOUT", &cfg.Lighthouse.Timeout)
+
+	r.string("CRUX_API_KEY", &cfg.CrUX.APIKey)
+	r.duration("CRUX_CACHE_TTL", &cfg.CrUX.CacheTTL)
+	r.duration("CRUX_TIMEOUT", &cfg.CrUX.Timeout)
+
+	r.bool("RDAP_ENABLED", &cfg.RDAP.Enabled)
+	r.string("RDAP_BASE_URL", &cfg.RDAP.BaseURL)
+	r.duration("RDAP_CACHE_TTL", &cfg.RDAP.CacheTTL)
+	r.duration("RDAP_TIMEOUT", &cfg.RDAP.Timeout)
+
+	r.string("GEOIP_DATABASE", &cfg.GeoIP.Database)
+
+	r.bool("WAYBACK_ENABLED", &cfg.Wayback.Enabled)
+	r.string("WAYBACK_BASE_URL", &cfg.Wayback.BaseURL)
+	r.duration("WAYBACK_TIMEOUT", &cfg.Wayback.Timeout)
+	r.bool("WAYBACK_ARCHIVE", &cfg.Wayback.Archive)
+	r.string("WAYBACK_SAVE_URL", &cfg.Wayback.SaveURL)
+	r.string("WAYBACK_ACCESS_KEY", &cfg.Wayback.AccessKey)
+	r.string("WAYBACK_SECRET_KEY", &cfg.Wayback.SecretKey)
+	r.duration("WAYBACK_SAVE_TIMEOUT", &cfg.Wayback.SaveTimeout)
+
+	r.bool("MONITOR_ENABLED", &cfg.Monitor.Enabled)
+	r.duration("MONITOR_INTERVAL", &cfg.Monitor.Interval)
+	r.duration("MONITOR_TIMEOUT", &cfg.Monitor.Timeout)
+	r.int("MONITOR_CONCURRENCY", &cfg.Monitor.Concurrency)
+	r.duration("MONITOR_RETENTION", &cfg.Monitor.Retention)
+
+	r.string("SMTP_HOST", &cfg.Alerts.SMTPHost)
+	r.int("SMTP_PORT", &cfg.Alerts.SMTPPort)
+	r.string("SMTP_USERNAME", &cfg.Alerts.SMTPUsername)
+	r.string("SMTP_PASSWORD", &cfg.Alerts.SMTPPassword)
+	r.string("ALERT_EMAIL_FROM", &cfg.Alerts.From)
+	r.duration("ALERT_TIMEOUT", &cfg.Alerts.Timeout)
+
+	r.bool("DIGEST_ENABLED", &cfg.Digest.Enabled)
+
+	r.bool("DEMO_ENABLED", &cfg.Demo.Enabled)
+	r.string("DEMO_USERNAME", &cfg.Demo.Username)
+	r.duration("DEMO_TOKEN_TTL", &cfg.Demo.TokenTTL)
+
+	r.int("RETENTION_DAYS", &cfg.Retention.Days)
+	r.duration("RETENTION_INTERVAL", &cfg.Retention.Interval)
+
+	r.string("STORAGE_BACKEND", &cfg.Storage.Backend)
+	r.string("STORAGE_PATH", &cfg.Storage.Path)
+	r.string("STORAGE_ENDPOINT", &cfg.Storage.Endpoint)
+	r.string("STORAGE_REGION", &cfg.Storage.Region)
+	r.string("STORAGE_BUCKET", &cfg.Storage.Bucket)
+	r.string("STORAGE_ACCESS_KEY", &cfg.Storage.AccessKey)
+	r.string("STORAGE_SECRET_KEY", &cfg.Storage.SecretKey)
+	r.bool("STORAGE_PATH_STYLE", &cfg.Storage.PathStyle)
+	r.duration("STORAGE_TIMEOUT", &cfg.Storage.Timeout)
+
+	r.bool("ARCHIVE_ENABLED", &cfg.Archive.Enabled)
+	r.duration("ARCHIVE_AFTER", &cfg.Archive.After)
+	r.duration("ARCHIVE_INTERVAL", &cfg.Archive.Interval)
+
+	r.int("WORKER_CONCURRENCY", &cfg.Worker.Concurrency)
+	r.duration("WORKER_POLL_INTERVAL", &cfg.Worker.PollInterval)
+	r.duration("WORKER_LEASE_DURATION", &cfg.Worker.LeaseDuration)
+	r.duration("WORKER_HEARTBEAT_INTERVAL", &cfg.Worker.HeartbeatInterval)
+	r.int("WORKER_MAX_ATTEMPTS", &cfg.Worker.MaxAttempts)
+	r.duration("WORKER_PROGRESS_INTERVAL", &cfg.Worker.ProgressInterval)
+	r.int("WORKER_MAX_CRAWLS_PER_USER", &cfg.Worker.MaxCrawlsPerUser)
+
+	r.list("CORS_ALLOW_ORIGINS", &cfg.CORS.AllowOrigins)
+
+	r.string("JWT_SECRET", &cfg.JWT.Secret)
+	r.duration("JWT_TOKEN_TTL", &cfg.JWT.TokenTTL)
+
+	r.bool("TENANCY_ENABLED", &cfg.Tenancy.Enabled)
+	r.string("TENANCY_BASE_DOMAIN", &cfg.Tenancy.BaseDomain)
+	r.string("TENANCY_HEADER", &cfg.Tenancy.Header)
+	r.string("TENANCY_DATABASE_PREFIX", &cfg.Tenancy.DatabasePrefix)
+	r.string("TENANCY_SCHEMA_FILE", &cfg.Tenancy.SchemaFile)
+	r.string("TENANCY_ADMIN_USER", &cfg.Tenancy.AdminUser)
+	r.string("TENANCY_ADMIN_PASSWORD", &cfg.Tenancy.AdminPassword)
+
+	return errors.Join(r.errs...)
+}
+
+// Validate reports every invalid setting at once so startup fails with a complete list
+func (c *Config) Validate() error {
+	var errs []error
+	check := func(ok bool, format string, args ...interface{}) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+
+	check(c.Server.Port > 0 && c.Server.Port <= 65535, "server.port must be between 1 and 65535")
+	check(c.Server.GinMode == "debug" || c.Server.GinMode == "release" || c.Server.GinMode == "test",
+		"server.gin_mode must be debug, release or test")
+	check(c.Server.GzipMinSize >= 0, "server.gzip_min_size must not be negative")
+	check(c.Server.BulkUrlMax > 0, "server.bulk_url_max must be positive")
+	check(c.Server.RequestTimeout >= 0, "server.request_timeout must not be negative")
+	check(c.Server.MaxBodyBytes > 0, "server.max_body_bytes must be positive")
+	check(c.Server.MaxUploadBytes >= c.Server.MaxBodyBytes, "server.max_upload_bytes must be at least server.max_body_bytes")
+
+	check((c.TLS.CertFile == "") == (c.TLS.KeyFile == ""), "tls.cert_file and tls.key_file must be set together")
+	check(c.TLS.CertFile == "" || len(c.TLS.AutocertDomains) == 0,
+		"tls.cert_file and tls.autocert_domains cannot both be set")
+	check(len(c.TLS.AutocertDomains) == 0 || c.TLS.AutocertCacheDir != "",
+		"tls.autocert_cache_dir is required with tls.autocert_domains")
+	check(c.TLS.RedirectPort >= 0 && c.TLS.RedirectPort <= 65535, "tls.redirect_port must be between 0 and 65535")
+	check(!c.TLS.Enabled() || c.TLS.RedirectPort != c.Server.Port, "tls.redirect_port must differ from server.port")
+
+	check(c.Database.Host != "", "database.host is required")
+	check(c.Database.Port > 0 && c.Database.Port <= 65535, "database.port must be between 1 and 65535")
+	check(c.Database.User != "", "database.user is required")
+	check(c.Database.Name != "", "database.name is required")
+	check(c.Database.MaxOpenConns >= 0, "database.max_open_conns must not be negative")
+	check(c.Database.MaxIdleConns >= 0, "database.max_idle_conns must not be negative")
+	check(c.Database.ConnMaxLifetime >= 0, "database.conn_max_lifetime must not be negative")
+	check(c.Database.SlowQueryThreshold >= 0, "database.slow_query_threshold must not be negative")
+	check(c.Database.ReplicaReadAfterWrite >= 0, "database.replica_read_after_write must not be negative")
+
+	check(c.Cache.TTL > 0, "cache.ttl must be positive")
+
+	check(c.Crawler.PageTimeout > 0, "crawler.page_timeout must be positive")
+	check(c.Crawler.RequestTimeout > 0, "crawler.request_timeout must be positive")
+	check(c.Crawler.RequestTimeout <= c.Crawler.PageTimeout, "crawler.request_timeout must not exceed crawler.page_timeout")
+	check(c.Crawler.LinkCheckTimeout > 0, "crawler.link_check_timeout must be positive")
+	check(c.Crawler.MaxConcurrentLinkChecks > 0, "crawler.max_concurrent_link_checks must be positive")
+	check(c.Crawler.SharedCacheWindow >= 0, "crawler.shared_cache_window must not be negative")
+	check(c.Crawler.StaleAfter > 0, "crawler.stale_after must be positive")
+	check(c.Crawler.DryRunTimeout > 0, "crawler.dry_run_timeout must be positive")
+	check(c.Crawler.MaxDocumentMB > 0, "crawler.max_document_mb must be positive")
+	check(c.Crawler.StreamAboveMB >= 0, "crawler.stream_above_mb must not be negative")
+	check(c.Crawler.LinkCacheTTL >= 0, "crawler.link_cache_ttl must not be negative")
+	for _, entry := range append(append([]string{}, c.Crawler.AllowDomains...), c.Crawler.DenyDomains...) {
+		_, err := domainPattern(entry)
+		check(err == nil, "crawler.allow_domains and crawler.deny_domains entry %q is not a valid regular expression", entry)
+	}
+	check(c.Server.RequestTimeout == 0 || c.Crawler.DryRunTimeout < c.Server.RequestTimeout,
+		"crawler.dry_run_timeout must be shorter than server.request_timeout")
+
+	check(len(c.DNS.Servers) == 0 || c.DNS.DoHURL == "", "set dns.servers or dns.doh_url, not both")
+	for _, server := range c.DNS.Servers {
+		check(validNameServer(server), "dns.servers entry %q must be an IP address, optionally with a port", server)
+	}
+	if c.DNS.DoHURL != "" {
+		u, err := url.Parse(c.DNS.DoHURL)
+		check(err == nil && u.Scheme == "https" && u.Host != "", "dns.doh_url must be an https URL")
+	}
+	check(c.DNS.CacheTTL >= 0, "dns.cache_ttl must not be negative")
+	check(c.DNS.Timeout > 0, "dns.timeout must be positive")
+
+	check(c.SafeBrowsing.CacheTTL > 0, "safe_browsing.cache_ttl must be positive")
+	check(c.SafeBrowsing.Timeout > 0, "safe_browsing.timeout must be positive")
+
+	check(c.Lighthouse.Strategy == "mobile" || c.Lighthouse.Strategy == "desktop", "lighthouse.strategy must be mobile or desktop")
+	check(c.Lighthouse.Timeout > 0, "lighthouse.timeout must be positive")
+
+	check(c.CrUX.CacheTTL > 0, "crux.cache_ttl must be positive")
+	check(c.CrUX.Timeout > 0, "crux.timeout must be positive")
+
+	check(!c.RDAP.Enabled || c.RDAP.BaseURL != "", "rdap.base_url is required when rdap.enabled is set")
+	check(c.RDAP.CacheTTL > 0, "rdap.cache_ttl must be positive")
+	check(c.RDAP.Timeout > 0, "rdap.timeout must be positive")
+
+	check(!c.Wayback.Enabled || c.Wayback.BaseURL != "", "wayback.base_url is required when wayback.enabled is set")
+	check(c.Wayback.Timeout > 0, "wayback.timeout must be positive")
+	check(!c.Wayback.Archive || c.Wayback.SaveURL != "", "wayback.save_url is required when wayback.archive is set")
+	check(!c.Wayback.Archive || (c.Wayback.AccessKey != "" && c.Wayback.SecretKey != ""),
+		"wayback.access_key and wayback.secret_key are required when wayback.archive is set")
+	check(c.Wayback.SaveTimeout > 0, "wayback.save_timeout must be positive")
+
+	check(c.Worker.Concurrency > 0, "worker.concurrency must be positive")
+	check(c.Worker.PollInterval > 0, "worker.poll_interval must be positive")
+	check(c.Worker.LeaseDuration > 0, "worker.lease_duration must be positive")
+	check(c.Worker.HeartbeatInterval > 0, "worker.heartbeat_interval must be positive")
+	check(c.Worker.MaxAttempts > 0, "worker.max_attempts must be positive")
+	check(c.Worker.ProgressInterval > 0, "worker.progress_interval must be positive")
+	check(c.Worker.MaxCrawlsPerUser >= 0, "worker.max_crawls_per_user must not be negative")
+
+	check(c.Monitor.Interval >= 10*time.Second, "monitor.interval must be at least 10s")
+	check(c.Monitor.Timeout > 0, "monitor.timeout must be positive")
+	check(c.Monitor.Timeout < c.Monitor.Interval, "monitor.timeout must be shorter than monitor.interval")
+	check(c.Monitor.Concurrency > 0, "monitor.concurrency must be positive")
+	check(c.Monitor.Retention >= 24*time.Hour, "monitor.retention must be at least 24h")
+
+	check(c.Alerts.SMTPPort > 0 && c.Alerts.SMTPPort <= 65535, "alerts.smtp_port must be between 1 and 65535")
+	check(c.Alerts.SMTPHost == "" || c.Alerts.From != "", "alerts.from is required when alerts.smtp_host is set")
+	check(c.Alerts.Timeout > 0, "alerts.timeout must be positive")
+
+	check(!c.Digest.Enabled || c.Alerts.SMTPHost != "", "alerts.smtp_host is required when digest.enabled is set")
+
+	check(!c.Demo.Enabled || c.Demo.Username != "", "demo.username is required when demo.enabled is set")
+	check(c.Demo.TokenTTL > 0, "demo.token_ttl must be positive")
+
+	check(c.Retention.Days >= 0, "retention.days must not be negative")
+	check(c.Retention.Interval == 0 || c.Retention.Interval >= time.Minute, "retention.interval must be at least 1m (0 disables cleanup)")
+
+	check(c.Storage.Backend == "local" || c.Storage.Backend == "s3", "storage.backend must be local or s3")
+	check(c.Storage.Backend != "local" || c.Storage.Path != "", "storage.path is required for the local backend")
+	s3 := c.Storage.Backend == "s3"
+	check(!s3 || (c.Storage.Endpoint != "" && c.Storage.Bucket != ""), "storage.endpoint and storage.bucket are required for the s3 backend")
+	check(!s3 || c.Storage.Region != "", "storage.region is required for the s3 backend")
+	check(!s3 || (c.Storage.AccessKey != "" && c.Storage.SecretKey != ""),
+		"storage.access_key and storage.secret_key are required for the s3 backend")
+	check(c.Storage.Timeout > 0, "storage.timeout must be positive")
+
+	check(c.Archive.After >= 24*time.Hour, "archive.after must be at least 24h")
+	check(c.Archive.Interval >= time.Minute, "archive.interval must be at least 1m")
+
+	check(len(c.CORS.AllowOrigins) > 0, "cors.allow_origins must list at least one origin")
+
+	check(c.JWT.Secret != "", "jwt.secret is required")
+	check(c.Server.GinMode != "release" || c.JWT.Secret != DefaultJWTSecret,
+		"jwt.secret must be changed from the development default in release mode")
+	check(c.JWT.TokenTTL > 0, "jwt.token_ttl must be positive")
+
+	if c.Tenancy.Enabled {
+		check(c.Tenancy.BaseDomain != "" || c.Tenancy.Header != "",
+			"tenancy.base_domain or tenancy.header is required when tenancy.enabled is set")
+		check(c.Tenancy.SchemaFile != "", "tenancy.schema_file is required when tenancy.enabled is set")
+	}
+	check(databasePrefix.MatchString(c.Tenancy.DatabasePrefix),
+		"tenancy.database_prefix may only use lowercase letters, digits and underscores")
+
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid configuration: %w", errors.Join(errs...))
+	}
+	return nil
+}
+
+// databasePrefix is what tenancy.database_prefix may hold; it becomes part of database names
+var databasePrefix = regexp.MustCompile(`^[a-z0-9_]{0,32}$`)
+
+// validNameServer reports whether server is an IP address, optionally with a port
+func validNameServer(server string) bool {
+	if _, err := netip.ParseAddrPort(server); err == nil {
+		return true
+	}
+	_, err := netip.ParseAddr(server)
+	return err == nil
+}
+
+// DSN builds the MySQL connection string
+func (d DatabaseConfig) DSN() string {
+	cfg := mysql.NewConfig()
+	cfg.User = d.User
+	cfg.Passwd = d.Password
+	cfg.Net = "tcp"
+	cfg.Addr = net.JoinHostPort(d.Host, strconv.Itoa(d.Port))
+	cfg.DBName = d.Name
+	cfg.ParseTime = true
+	// Keep the session in UTC so NOW() and CURRENT_TIMESTAMP agree with the UTC times the driver sends
+	cfg.Params = map[string]string{"time_zone": "'+00:00'"}
+	return cfg.FormatDSN()
+}
@@ -0,0 +1,290 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeConfigFile(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestLoad(t *testing.T) {
+	t.Run("defaults when the file is missing", func(t *testing.T) {
+		cfg, err := Load(filepath.Join(t.TempDir(), "missing.yaml"))
+		require.NoError(t, err)
+		assert.Equal(t, Default(), cfg)
+	})
+
+	t.Run("file values override defaults", func(t *testing.T) {
+		path := writeConfigFile(t, `
+server:
+  port: 9090
+database:
+  host: db.internal
+crawler:
+  link_check_timeout: 5s
+  user_agent: sykell-bot/1.0
+cors:
+  allow_origins: ["https://app.example.com"]
+`)
+		cfg, err := Load(path)
+		require.NoError(t, err)
+		assert.Equal(t, 9090, cfg.Server.Port)
+		assert.Equal(t, "db.internal", cfg.Database.Host)
+		assert.Equal(t, "sykell_db", cfg.Database.Name)
+		assert.Equal(t, 5*time.Second, cfg.Crawler.LinkCheckTimeout)
+		assert.Equal(t, "sykell-bot/1.0", cfg.Crawler.UserAgent)
+		assert.Equal(t, []string{"https://app.example.com"}, cfg.CORS.AllowOrigins)
+	})
+
+	t.Run("environment overrides the file", func(t *testing.T) {
+		path := writeConfigFile(t, "server:\n  port: 9090\nworker:\n  concurrency: 2\n")
+		t.Setenv("PORT", "7070")
+		t.Setenv("WORKER_CONCURRENCY", "12")
+		t.Setenv("WORKER_LEASE_DURATION", "10m")
+		t.Setenv("DB_HOST", "mysql")
+		t.Setenv("CORS_ALLOW_ORIGINS", "https://a.example.com, https://b.example.com")
+		t.Setenv("EMBEDDED_WORKER", "false")
+		t.Setenv("SERVE_FRONTEND", "false")
+		t.Setenv("CRAWLER_DENY_DOMAINS", "internal.example.com, re:.*\\.local")
+
+		cfg, err := Load(path)
+		require.NoError(t, err)
+		assert.Equal(t, 7070, cfg.Server.Port)
+		assert.Equal(t, 12, cfg.Worker.Concurrency)
+		assert.Equal(t, 10*time.Minute, cfg.Worker.LeaseDuration)
+		assert.Equal(t, "mysql", cfg.Database.Host)
+		assert.Equal(t, []string{"https://a.example.com", "https://b.example.com"}, cfg.CORS.AllowOrigins)
+		assert.False(t, cfg.Server.EmbeddedWorker)
+		assert.False(t, cfg.Server.ServeFrontend)
+		assert.Equal(t, []string{"internal.example.com", "re:.*\\.local"}, cfg.Crawler.DenyDomains)
+	})
+
+	t.Run("unknown keys are rejected", func(t *testing.T) {
+		path := writeConfigFile(t, "server:\n  prot: 9090\n")
+		_, err := Load(path)
+		assert.Error(t, err)
+	})
+
+	t.Run("malformed environment values are rejected", func(t *testing.T) {
+		t.Setenv("WORKER_LEASE_DURATION", "soon")
+		t.Setenv("PORT", "http")
+
+		_, err := Load("")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "WORKER_LEASE_DURATION")
+		assert.Contains(t, err.Error(), "PORT")
+	})
+
+	t.Run("invalid values fail validation", func(t *testing.T) {
+		t.Setenv("WORKER_CONCURRENCY", "-1")
+
+		_, err := Load("")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "worker.concurrency")
+	})
+}
+
+func TestValidate(t *testing.T) {
+	t.Run("defaults are valid", func(t *testing.T) {
+		assert.NoError(t, Default().Validate())
+	})
+
+	t.Run("reports every problem", func(t *testing.T) {
+		cfg := Default()
+		cfg.Server.Port = 0
+		cfg.Database.Host = ""
+		cfg.CORS.AllowOrigins = nil
+
+		err := cfg.Validate()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "server.port")
+		assert.Contains(t, err.Error(), "database.host")
+		assert.Contains(t, err.Error(), "cors.allow_origins")
+	})
+
+	t.Run("request timeout cannot exceed page timeout", func(t *testing.T) {
+		cfg := Default()
+		cfg.Crawler.RequestTimeout = 2 * cfg.Crawler.PageTimeout
+		assert.Error(t, cfg.Validate())
+	})
+
+	t.Run("dns name servers or DNS over HTTPS", func(t *testing.T) {
+		cfg := Default()
+		cfg.DNS.Servers = []string{"1.1.1.1", "[2606:4700:4700::1111]:53"}
+		assert.NoError(t, cfg.Validate())
+
+		cfg.DNS.Servers = []string{"dns.example.com"}
+		assert.ErrorContains(t, cfg.Validate(), "dns.servers")
+
+		cfg.DNS.Servers = []string{"1.1.1.1"}
+		cfg.DNS.DoHURL = "https://cloudflare-dns.com/dns-query"
+		assert.ErrorContains(t, cfg.Validate(), "not both")
+
+		cfg.DNS.Servers = nil
+		assert.NoError(t, cfg.Validate())
+		cfg.DNS.DoHURL = "http://cloudflare-dns.com/dns-query"
+		assert.ErrorContains(t, cfg.Validate(), "dns.doh_url")
+	})
+
+	t.Run("tenancy needs a way to select tenants", func(t *testing.T) {
+		cfg := Default()
+		cfg.Tenancy.Enabled = true
+		assert.NoError(t, cfg.Validate())
+
+		cfg.Tenancy.Header = ""
+		assert.ErrorContains(t, cfg.Validate(), "tenancy.base_domain or tenancy.header")
+		cfg.Tenancy.BaseDomain = "analyzer.example.com"
+		assert.NoError(t, cfg.Validate())
+
+		cfg.Tenancy.DatabasePrefix = "sykell-"
+		assert.ErrorContains(t, cfg.Validate(), "tenancy.database_prefix")
+	})
+
+	t.Run("dry runs must finish before the request times out", func(t *testing.T) {
+		cfg := Default()
+		cfg.Crawler.DryRunTimeout = cfg.Server.RequestTimeout
+		assert.Error(t, cfg.Validate())
+
+		cfg.Server.RequestTimeout = 0
+		assert.NoError(t, cfg.Validate())
+	})
+
+	t.Run("lighthouse strategy must be mobile or desktop", func(t *testing.T) {
+		cfg := Default()
+		cfg.Lighthouse.Strategy = "tablet"
+		assert.ErrorContains(t, cfg.Validate(), "lighthouse.strategy")
+
+		cfg.Lighthouse.Strategy = "desktop"
+		assert.NoError(t, cfg.Validate())
+	})
+
+	t.Run("TLS takes certificate files or autocert domains", func(t *testing.T) {
+		cfg := Default()
+		assert.False(t, cfg.TLS.Enabled())
+
+		cfg.TLS.CertFile = "server.crt"
+		assert.ErrorContains(t, cfg.Validate(), "tls.key_file")
+
+		cfg.TLS.KeyFile = "server.key"
+		assert.NoError(t, cfg.Validate())
+		assert.True(t, cfg.TLS.Enabled())
+
+		cfg.TLS.AutocertDomains = []string{"analyzer.example.com"}
+		assert.ErrorContains(t, cfg.Validate(), "tls.autocert_domains")
+
+		cfg.TLS.CertFile, cfg.TLS.KeyFile = "", ""
+		assert.NoError(t, cfg.Validate())
+
+		cfg.TLS.RedirectPort = cfg.Server.Port
+		assert.ErrorContains(t, cfg.Validate(), "tls.redirect_port")
+	})
+
+	t.Run("digests need an SMTP server", func(t *testing.T) {
+		cfg := Default()
+		cfg.Digest.Enabled = true
+		assert.ErrorContains(t, cfg.Validate(), "digest.enabled")
+
+		cfg.Alerts.SMTPHost = "smtp.example.com"
+		assert.NoError(t, cfg.Validate())
+	})
+
+	t.Run("s3 storage needs a bucket and credentials", func(t *testing.T) {
+		cfg := Default()
+		cfg.Storage.Backend = "s3"
+		assert.ErrorContains(t, cfg.Validate(), "storage.endpoint")
+
+		cfg.Storage.Endpoint, cfg.Storage.Bucket = "http://minio:9000", "artifacts"
+		assert.ErrorContains(t, cfg.Validate(), "storage.access_key")
+
+		cfg.Storage.AccessKey, cfg.Storage.SecretKey = "access", "secret"
+		assert.NoError(t, cfg.Validate())
+
+		cfg.Storage.Backend = "gcs"
+		assert.ErrorContains(t, cfg.Validate(), "storage.backend")
+	})
+
+	t.Run("release mode requires a real JWT secret", func(t *testing.T) {
+		cfg := Default()
+		cfg.Server.GinMode = "release"
+		assert.Error(t, cfg.Validate())
+
+		cfg.JWT.Secret = "a-long-random-secret"
+		assert.NoError(t, cfg.Validate())
+	})
+}
+
+func TestCheckTarget(t *testing.T) {
+	t.Run("everything is allowed by default", func(t *testing.T) {
+		assert.NoError(t, Default().Crawler.CheckTarget("https://anything.example"))
+	})
+
+	t.Run("allow list restricts targets to its domains", func(t *testing.T) {
+		cfg := Default().Crawler
+		cfg.AllowDomains = []string{"example.com", "re:intranet-[0-9]+\\.corp"}
+		assert.NoError(t, cfg.CheckTarget("https://example.com/page"))
+		assert.NoError(t, cfg.CheckTarget("https://Shop.Example.com."))
+		assert.NoError(t, cfg.CheckTarget("http://intranet-12.corp"))
+		assert.Error(t, cfg.CheckTarget("https://notexample.com"))
+		assert.Error(t, cfg.CheckTarget("http://intranet-12.corp.evil.com"), "regular expressions match the whole host")
+	})
+
+	t.Run("deny list wins", func(t *testing.T) {
+		cfg := Default().Crawler
+		cfg.AllowDomains = []string{"example.com"}
+		cfg.DenyDomains = []string{"*.admin.example.com"}
+		assert.NoError(t, cfg.CheckTarget("https://example.com"))
+		assert.ErrorContains(t, cfg.CheckTarget("https://eu.admin.example.com"), "not an allowed crawl target")
+	})
+
+	t.Run("private networks are refused unless allowed", func(t *testing.T) {
+		cfg := Default().Crawler
+		for _, target := range []string{
+			"http://localhost:3000", "http://app.localhost", "http://127.0.0.1", "http://10.0.0.5:8080",
+			"http://[::1]:8080", "http://[fd00::1]/", "http://[fe80::1%25eth0]/", "http://[::ffff:192.168.0.1]/",
+		} {
+			assert.ErrorContains(t, cfg.CheckTarget(target), "private network address", target)
+		}
+		assert.NoError(t, cfg.CheckTarget("http://[2001:4860:4860::8888]:8080/"))
+		assert.NoError(t, cfg.CheckTarget("http://8.8.8.8/"))
+
+		cfg.AllowPrivateNetworks = true
+		assert.NoError(t, cfg.CheckTarget("http://[::1]:8080"))
+		assert.NoError(t, cfg.CheckTarget("http://localhost:3000"))
+	})
+
+	t.Run("invalid regular expressions fail validation", func(t *testing.T) {
+		cfg := Default()
+		cfg.Crawler.DenyDomains = []string{"re:(unclosed"}
+		assert.ErrorContains(t, cfg.Validate(), "crawler.allow_domains")
+	})
+
+	t.Run("loaded settings are compiled once", func(t *testing.T) {
+		t.Setenv("CRAWLER_DENY_DOMAINS", "re:intranet-[0-9]+\\.corp")
+		cfg, err := Load("")
+		require.NoError(t, err)
+		compiled := cfg.Crawler.domains
+		require.NotNil(t, compiled)
+		assert.Same(t, compiled, cfg.Crawler.domainPolicy())
+		assert.ErrorContains(t, cfg.Crawler.CheckTarget("http://intranet-12.corp"), "not an allowed crawl target")
+
+		crawler := cfg.Crawler
+		crawler.DenyDomains = []string{"example.com"}
+		assert.NotSame(t, compiled, crawler.domainPolicy(), "changed settings are compiled again")
+		assert.NoError(t, crawler.CheckTarget("http://intranet-12.corp"))
+		assert.Error(t, crawler.CheckTarget("https://example.com"))
+	})
+}
+
+func TestDSN(t *testing.T) {
+	db := Default().Database
+	assert.Equal(t, "sykell_user:sykell_pass@tcp(localhost:3306)/sykell_db?parseTime=true&time_zone=%27%2B00%3A00%27", db.DSN())
+}
@@ -4,16 +4,16 @@ import (
 	"database/sql"
 	"fmt"
 
-	_ "github.com/go-sql-driver/mysql"
+	"sykell-analyze/backend/dbmetrics"
+
+	"github.com/go-sql-driver/mysql"
 )
 
 var DB *sql.DB
 
 func ConnectDB() error {
-	dsn := "sykell_user:sykell_pass@tcp(localhost:3306)/sykell_db?parseTime=true"
-
 	var err error
-	DB, err = sql.Open("mysql", dsn)
+	DB, err = openDB(App.Database)
 	if err != nil {
 		return fmt.Errorf("failed to connect to the database: %w", err)
 	}
@@ -26,3 +26,23 @@ func ConnectDB() error {
 	fmt.Println("✅ Connected to the database successfully.")
 	return nil
 }
+
+// openDB opens a connection pool to the database d describes without connecting yet
+func openDB(d DatabaseConfig) (*sql.DB, error) {
+	mysqlConfig, err := mysql.ParseDSN(d.DSN())
+	if err != nil {
+		return nil, err
+	}
+	connector, err := mysql.NewConnector(mysqlConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	// Every query is timed for the metrics endpoint, and slow ones are logged
+	db := sql.OpenDB(dbmetrics.Wrap(connector, d.SlowQueryThreshold))
+
+	db.SetMaxOpenConns(d.MaxOpenConns)
+	db.SetMaxIdleConns(d.MaxIdleConns)
+	db.SetConnMaxLifetime(d.ConnMaxLifetime)
+	return db, nil
+}
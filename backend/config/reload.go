@@ -0,0 +1,102 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"reflect"
+	"strings"
+	"sync"
+	"syscall"
+)
+
+var (
+	// reloadMu serializes reloads and guards reloadHooks
+	reloadMu    sync.Mutex
+	reloadHooks []func(cfg *Config)
+)
+
+// tunables copies the settings Reload applies at runtime from src to dst. Everything else, such as
+// ports, the database and API keys, keeps its startup value until a restart.
+func tunables(dst, src *Config) {
+	dst.Crawler = src.Crawler
+	dst.Worker.Concurrency = src.Worker.Concurrency
+	dst.Worker.MaxCrawlsPerUser = src.Worker.MaxCrawlsPerUser
+	dst.Server.BulkUrlMax = src.Server.BulkUrlMax
+	dst.CORS = src.CORS
+}
+
+// OnReload registers fn to run with the new configuration after every successful Reload, for
+// components that copied settings at startup
+func OnReload(fn func(cfg *Config)) {
+	reloadMu.Lock()
+	defer reloadMu.Unlock()
+	reloadHooks = append(reloadHooks, fn)
+}
+
+// Reload reads the configuration again like Load and applies its tunable settings: the crawler
+// section, worker.concurrency, worker.max_crawls_per_user, server.bulk_url_max and
+// cors.allow_origins. App is replaced rather than modified, so requests and crawls already running
+// keep the settings they started with. It returns the keys of the settings that changed; an invalid
+// configuration is rejected and App kept.
+func Reload(path string) ([]string, error) {
+	reloadMu.Lock()
+	defer reloadMu.Unlock()
+
+	loaded, err := Load(path)
+	if err != nil {
+		return nil, err
+	}
+	next := *App
+	tunables(&next, loaded)
+	// Tunable settings are also checked against the ones kept, e.g. crawler.dry_run_timeout
+	if err := next.Validate(); err != nil {
+		return nil, err
+	}
+
+	changed := changedKeys("", reflect.ValueOf(*App), reflect.ValueOf(next))
+	App = &next
+	for _, fn := range reloadHooks {
+		fn(&next)
+	}
+	return changed, nil
+}
+
+// ReloadOnHangup reloads the configuration from path whenever the process receives SIGHUP
+func ReloadOnHangup(path string) {
+	hangup := make(chan os.Signal, 1)
+	signal.Notify(hangup, syscall.SIGHUP)
+	go func() {
+		for range hangup {
+			changed, err := Reload(path)
+			if err != nil {
+				fmt.Printf("DEBUG: Configuration reload failed, keeping the current settings: %v\n", err)
+				continue
+			}
+			if len(changed) == 0 {
+				fmt.Println("🔄 Configuration reloaded, nothing changed")
+				continue
+			}
+			fmt.Printf("🔄 Configuration reloaded, changed: %s\n", strings.Join(changed, ", "))
+		}
+	}()
+}
+
+// changedKeys lists the dotted YAML keys whose values differ between the structs a and b
+func changedKeys(prefix string, a, b reflect.Value) []string {
+	changed := []string{}
+	for i := 0; i < a.NumField(); i++ {
+		field := a.Type().Field(i)
+		if !field.IsExported() {
+			// Derived from the exported settings, such as the compiled crawler domains
+			continue
+		}
+		key := prefix + strings.Split(field.Tag.Get("yaml"), ",")[0]
+		if field.Type.Kind() == reflect.Struct && field.Type.PkgPath() == a.Type().PkgPath() {
+			changed = append(changed, changedKeys(key+".", a.Field(i), b.Field(i))...)
+		} else if !reflect.DeepEqual(a.Field(i).Interface(), b.Field(i).Interface()) {
+			changed = append(changed, key)
+		}
+	}
+	return changed
+}
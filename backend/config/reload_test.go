@@ -0,0 +1,61 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReload(t *testing.T) {
+	previous, previousHooks := App, reloadHooks
+	t.Cleanup(func() { App, reloadHooks = previous, previousHooks })
+
+	t.Run("applies tunable settings only", func(t *testing.T) {
+		App = Default()
+		started := App
+		var hooked *Config
+		reloadHooks = nil
+		OnReload(func(cfg *Config) { hooked = cfg })
+
+		path := writeConfigFile(t, `
+server:
+  port: 9090
+  bulk_url_max: 20
+crawler:
+  user_agent: sykell-bot/2.0
+worker:
+  concurrency: 3
+cors:
+  allow_origins: [https://app.example.com]
+`)
+		changed, err := Reload(path)
+		require.NoError(t, err)
+
+		assert.ElementsMatch(t, []string{"server.bulk_url_max", "crawler.user_agent", "worker.concurrency", "cors.allow_origins"}, changed)
+		assert.Equal(t, 8080, App.Server.Port, "ports need a restart")
+		assert.Equal(t, 20, App.Server.BulkUrlMax)
+		assert.Equal(t, "sykell-bot/2.0", App.Crawler.UserAgent)
+		assert.Equal(t, 3, App.Worker.Concurrency)
+		assert.Equal(t, []string{"https://app.example.com"}, App.CORS.AllowOrigins)
+		assert.Same(t, App, hooked)
+		assert.Equal(t, 5, started.Worker.Concurrency, "the previous settings are not modified")
+	})
+
+	t.Run("rejects an invalid configuration", func(t *testing.T) {
+		App = Default()
+		kept := App
+
+		_, err := Reload(writeConfigFile(t, "worker:\n  concurrency: 0\n"))
+		assert.ErrorContains(t, err, "worker.concurrency")
+		assert.Same(t, kept, App)
+	})
+
+	t.Run("nothing changed", func(t *testing.T) {
+		App = Default()
+
+		changed, err := Reload("")
+		require.NoError(t, err)
+		assert.Empty(t, changed)
+	})
+}
@@ -0,0 +1,197 @@
+package config
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// replicaCheckInterval is how often replicas are pinged to take them out of or back into rotation
+const replicaCheckInterval = 10 * time.Second
+
+// replica is a read-only copy of the database that dashboard reads are spread over
+type replica struct {
+	addr    string
+	db      *sql.DB
+	healthy atomic.Bool
+}
+
+var (
+	replicas    []*replica
+	nextReplica atomic.Uint64
+	// lastWrites holds when each user last changed something, keyed by user ID
+	lastWrites sync.Map
+)
+
+// ConnectReplicas opens the read replicas of database.replica_hosts. They share the primary's
+// credentials and database name. A replica that is down does not stop the start: reads go to the
+// primary until a periodic check finds it up.
+func ConnectReplicas() error {
+	for _, addr := range App.Database.ReplicaHosts {
+		d := App.Database
+		host, port, err := splitReplicaAddr(addr, d.Port)
+		if err != nil {
+			return err
+		}
+		d.Host, d.Port = host, port
+
+		db, err := openDB(d)
+		if err != nil {
+			return fmt.Errorf("failed to open read replica %s: %w", addr, err)
+		}
+		r := &replica{addr: addr, db: db}
+		r.check()
+		replicas = append(replicas, r)
+	}
+
+	if len(replicas) > 0 {
+		go func() {
+			for range time.Tick(replicaCheckInterval) {
+				for _, r := range replicas {
+					r.check()
+				}
+			}
+		}()
+		fmt.Printf("✅ Reading dashboards from %d replica(s).\n", len(replicas))
+	}
+	return nil
+}
+
+// splitReplicaAddr reads "host" or "host:port"
+func splitReplicaAddr(addr string, defaultPort int) (string, int, error) {
+	host, portText, err := net.SplitHostPort(addr)
+	if err != nil {
+		return addr, defaultPort, nil
+	}
+	port, err := strconv.Atoi(portText)
+	if err != nil {
+		return "", 0, fmt.Errorf("invalid read replica address %q", addr)
+	}
+	return host, port, nil
+}
+
+// check pings the replica and takes it out of rotation while it does not answer
+func (r *replica) check() {
+	err := r.db.Ping()
+	if was := r.healthy.Swap(err == nil); was != (err == nil) {
+		if err != nil {
+			fmt.Printf("DEBUG: Read replica %s is down, reading from the primary: %v\n", r.addr, err)
+		} else {
+			fmt.Printf("DEBUG: Read replica %s is up\n", r.addr)
+		}
+	}
+}
+
+// fail takes the replica out of rotation after a query failed on it, until the next check
+func (r *replica) fail(err error) {
+	if r.healthy.Swap(false) {
+		fmt.Printf("DEBUG: Read replica %s failed, reading from the primary: %v\n", r.addr, err)
+	}
+}
+
+// NoteWrite records that the user changed something, so their reads stay on the primary until
+// replicas have caught up
+func NoteWrite(userID interface{}) {
+	if len(replicas) > 0 {
+		lastWrites.Store(fmt.Sprint(userID), time.Now())
+	}
+}
+
+// pickReplica returns the replica the user's next read goes to, or nil for the primary: when no
+// replica is up or the user wrote within database.replica_read_after_write
+func pickReplica(userID interface{}) *replica {
+	if len(replicas) == 0 {
+		return nil
+	}
+	if wrote, ok := lastWrites.Load(fmt.Sprint(userID)); ok {
+		if time.Since(wrote.(time.Time)) < App.Database.ReplicaReadAfterWrite {
+			return nil
+		}
+		lastWrites.Delete(fmt.Sprint(userID))
+	}
+
+	start := nextReplica.Add(1)
+	for i := range replicas {
+		r := replicas[(start+uint64(i))%uint64(len(replicas))]
+		if r.healthy.Load() {
+			return r
+		}
+	}
+	return nil
+}
+
+// ReadQuery runs a read that may see slightly old data on a replica, repeating it on the primary
+// when the replica fails. Reads of tenants go to the tenant's database; replicas only copy the primary.
+// Only a failing query is repeated: rows are streamed from the replica, so an error while iterating
+// them is left to the caller's rows.Err check, like any other read.
+func ReadQuery(ctx context.Context, userID interface{}, query string, args ...interface{}) (*sql.Rows, error) {
+	if t := TenantFrom(ctx); t != nil {
+		return t.DB.QueryContext(ctx, query, args...)
+	}
+	r := pickReplica(userID)
+	if r == nil {
+		return DB.QueryContext(ctx, query, args...)
+	}
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		// A cancelled request is not the replica's fault, and the primary would fail it as well
+		if ctx.Err() != nil {
+			return nil, err
+		}
+		r.fail(err)
+		return DB.QueryContext(ctx, query, args...)
+	}
+	return rows, nil
+}
+
+// ReadRow is a single-row read made by ReadQueryRow
+type ReadRow struct {
+	ctx    context.Context
+	tenant *Tenant
+	userID interface{}
+	query  string
+	args   []interface{}
+}
+
+// ReadQueryRow is ReadQuery for one row; the query runs when the row is scanned
+func ReadQueryRow(ctx context.Context, userID interface{}, query string, args ...interface{}) *ReadRow {
+	return &ReadRow{ctx: ctx, tenant: TenantFrom(ctx), userID: userID, query: query, args: args}
+}
+
+// Scan runs the query and copies the row into dest, like (*sql.Row).Scan
+func (row *ReadRow) Scan(dest ...interface{}) error {
+	if row.tenant != nil {
+		return row.tenant.DB.QueryRowContext(row.ctx, row.query, row.args...).Scan(dest...)
+	}
+	r := pickReplica(row.userID)
+	if r == nil {
+		return DB.QueryRowContext(row.ctx, row.query, row.args...).Scan(dest...)
+	}
+	err := r.db.QueryRowContext(row.ctx, row.query, row.args...).Scan(dest...)
+	if err != nil && err != sql.ErrNoRows && row.ctx.Err() == nil {
+		r.fail(err)
+		return DB.QueryRowContext(row.ctx, row.query, row.args...).Scan(dest...)
+	}
+	return err
+}
+
+// ReplicaStatus describes a read replica for the metrics endpoint
+type ReplicaStatus struct {
+	Addr    string
+	Healthy bool
+	Pool    sql.DBStats
+}
+
+// Replicas returns the status of every configured read replica
+func Replicas() []ReplicaStatus {
+	statuses := make([]ReplicaStatus, len(replicas))
+	for i, r := range replicas {
+		statuses[i] = ReplicaStatus{Addr: r.addr, Healthy: r.healthy.Load(), Pool: r.db.Stats()}
+	}
+	return statuses
+}
@@ -0,0 +1,86 @@
+package config
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitReplicaAddr(t *testing.T) {
+	host, port, err := splitReplicaAddr("replica-1", 3306)
+	assert.NoError(t, err)
+	assert.Equal(t, "replica-1", host)
+	assert.Equal(t, 3306, port)
+
+	host, port, err = splitReplicaAddr("10.0.0.5:3307", 3306)
+	assert.NoError(t, err)
+	assert.Equal(t, "10.0.0.5", host)
+	assert.Equal(t, 3307, port)
+
+	_, _, err = splitReplicaAddr("replica-1:mysql", 3306)
+	assert.Error(t, err)
+}
+
+func TestPickReplica(t *testing.T) {
+	previous := replicas
+	defer func() { replicas = previous }()
+
+	t.Run("without replicas reads go to the primary", func(t *testing.T) {
+		replicas = nil
+		assert.Nil(t, pickReplica(1))
+	})
+
+	up, down := &replica{addr: "up"}, &replica{addr: "down"}
+	up.healthy.Store(true)
+	replicas = []*replica{up, down}
+
+	t.Run("only healthy replicas are picked", func(t *testing.T) {
+		for i := 0; i < 4; i++ {
+			assert.Same(t, up, pickReplica(1))
+		}
+	})
+
+	t.Run("a failed replica leaves rotation", func(t *testing.T) {
+		up.fail(assert.AnError)
+		assert.Nil(t, pickReplica(1))
+		up.healthy.Store(true)
+	})
+
+	t.Run("a user's reads stay on the primary after a write", func(t *testing.T) {
+		NoteWrite(7)
+		assert.Nil(t, pickReplica(7))
+		assert.Same(t, up, pickReplica(8))
+
+		lastWrites.Store("7", time.Now().Add(-App.Database.ReplicaReadAfterWrite-time.Second))
+		assert.Same(t, up, pickReplica(7))
+	})
+}
+
+func TestReadsFollowTheRequestContext(t *testing.T) {
+	previous, previousDB := replicas, DB
+	defer func() { replicas, DB = previous, previousDB }()
+
+	// Nothing listens on port 1: a read that ignored ctx would fail to connect instead
+	unreachable, err := sql.Open("mysql", "user:password@tcp(127.0.0.1:1)/sykell")
+	require.NoError(t, err)
+	defer unreachable.Close()
+	DB = unreachable
+	up := &replica{addr: "up", db: unreachable}
+	up.healthy.Store(true)
+	replicas = []*replica{up}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err = ReadQuery(ctx, 1, "SELECT 1")
+	assert.ErrorIs(t, err, context.Canceled)
+	err = ReadQueryRow(ctx, 1, "SELECT 1").Scan(new(int))
+	assert.ErrorIs(t, err, context.Canceled)
+
+	// A cancelled request keeps the replica in rotation
+	assert.True(t, up.healthy.Load())
+}
@@ -0,0 +1,142 @@
+package config
+
+import (
+	"fmt"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+
+	"sykell-analyze/backend/analyzer"
+)
+
+// domainPattern parses an allow_domains or deny_domains entry: "re:" followed by a regular expression
+// that must match the whole host name, or a domain matching itself and its subdomains
+func domainPattern(entry string) (func(host string) bool, error) {
+	if expr, ok := strings.CutPrefix(entry, "re:"); ok {
+		re, err := regexp.Compile("^(?:" + expr + ")$")
+		if err != nil {
+			return nil, err
+		}
+		return re.MatchString, nil
+	}
+	domain := strings.TrimPrefix(strings.TrimPrefix(strings.ToLower(strings.TrimSpace(entry)), "*"), ".")
+	return func(host string) bool {
+		return host == domain || strings.HasSuffix(host, "."+domain)
+	}, nil
+}
+
+// domainPolicy is allow_domains and deny_domains compiled, so checking a target does not compile
+// their regular expressions again
+type domainPolicy struct {
+	// allowEntries and denyEntries are the entries compiled, to notice settings changed since
+	allowEntries, denyEntries []string
+	allow, deny               []func(host string) bool
+}
+
+// newDomainPolicy compiles the entries; invalid ones, which Validate reports, never match
+func newDomainPolicy(allow, deny []string) *domainPolicy {
+	return &domainPolicy{
+		allowEntries: slices.Clone(allow),
+		denyEntries:  slices.Clone(deny),
+		allow:        domainPatterns(allow),
+		deny:         domainPatterns(deny),
+	}
+}
+
+// domainPatterns compiles the valid entries
+func domainPatterns(entries []string) []func(host string) bool {
+	var patterns []func(host string) bool
+	for _, entry := range entries {
+		if match, err := domainPattern(entry); err == nil {
+			patterns = append(patterns, match)
+		}
+	}
+	return patterns
+}
+
+// matchesDomain reports whether host matches any of the patterns
+func matchesDomain(host string, patterns []func(host string) bool) bool {
+	for _, match := range patterns {
+		if match(host) {
+			return true
+		}
+	}
+	return false
+}
+
+// noDomains is the policy of settings without allow_domains and deny_domains
+var noDomains = &domainPolicy{}
+
+// compileDomains compiles AllowDomains and DenyDomains for CheckTarget
+func (c *CrawlerConfig) compileDomains() {
+	c.domains = nil
+	if len(c.AllowDomains) > 0 || len(c.DenyDomains) > 0 {
+		c.domains = newDomainPolicy(c.AllowDomains, c.DenyDomains)
+	}
+}
+
+// domainPolicy returns the compiled domain settings. Settings that were not loaded by Load, or were
+// changed since, are compiled on every call.
+func (c CrawlerConfig) domainPolicy() *domainPolicy {
+	if len(c.AllowDomains) == 0 && len(c.DenyDomains) == 0 {
+		return noDomains
+	}
+	if p := c.domains; p != nil && slices.Equal(p.allowEntries, c.AllowDomains) && slices.Equal(p.denyEntries, c.DenyDomains) {
+		return p
+	}
+	return newDomainPolicy(c.AllowDomains, c.DenyDomains)
+}
+
+// CheckTarget returns an error unless the operator's domain policy allows crawling rawURL: its host
+// must not match deny_domains and, when allow_domains is set, must match it. Unless
+// allow_private_networks is set, localhost and private IPv4 and IPv6 literals are refused as well;
+// host names resolving to private addresses are refused when HTTPClient connects.
+func (c CrawlerConfig) CheckTarget(rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return err
+	}
+	host := strings.TrimSuffix(strings.ToLower(u.Hostname()), ".")
+	domains := c.domainPolicy()
+	if matchesDomain(host, domains.deny) || (len(c.AllowDomains) > 0 && !matchesDomain(host, domains.allow)) {
+		return fmt.Errorf("%s is not an allowed crawl target on this server", host)
+	}
+	if !c.AllowPrivateNetworks && privateHost(host) {
+		return fmt.Errorf("%s is a private network address, not an allowed crawl target on this server", host)
+	}
+	return nil
+}
+
+// privateHost reports whether host is localhost or a private IP address (zone included, as in fe80::1%eth0)
+func privateHost(host string) bool {
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return true
+	}
+	addr, err := netip.ParseAddr(host)
+	return err == nil && analyzer.PrivateAddr(addr)
+}
+
+// transportKey identifies the shared transport of a resolver and private network policy
+type transportKey struct {
+	resolver   analyzer.Resolver
+	publicOnly bool
+}
+
+// transports holds one transport per transportKey, so crawls share their connections
+var transports sync.Map
+
+// HTTPClient returns the client crawls fetch with. Clients for the same resolver share a transport and
+// its kept-alive connections. It looks host names up through resolver (nil for the system resolver)
+// and, unless allow_private_networks is set, refuses to connect to private addresses.
+func (c CrawlerConfig) HTTPClient(resolver analyzer.Resolver) *http.Client {
+	key := transportKey{resolver: resolver, publicOnly: !c.AllowPrivateNetworks}
+	transport, ok := transports.Load(key)
+	if !ok {
+		transport, _ = transports.LoadOrStore(key, analyzer.NewTransport(resolver, key.publicOnly))
+	}
+	return &http.Client{Transport: transport.(http.RoundTripper)}
+}
@@ -0,0 +1,52 @@
+package config
+
+import (
+	"context"
+	"database/sql"
+)
+
+// Tenant is one of the isolated tenants a process serves. Each tenant has a database of its own;
+// requests and background jobs of the tenant carry it in their context, so DBFor picks its pool.
+type Tenant struct {
+	Name     string
+	Database string
+	DB       *sql.DB
+}
+
+// tenantKey is the context key of the tenant
+type tenantKey struct{}
+
+// WithTenant returns a copy of ctx whose database access goes to the tenant's database
+func WithTenant(ctx context.Context, t *Tenant) context.Context {
+	return context.WithValue(ctx, tenantKey{}, t)
+}
+
+// TenantFrom returns the tenant of ctx, or nil when ctx belongs to the primary database
+func TenantFrom(ctx context.Context) *Tenant {
+	t, _ := ctx.Value(tenantKey{}).(*Tenant)
+	return t
+}
+
+// TenantName returns the name of the tenant of ctx, or "" for the primary database
+func TenantName(ctx context.Context) string {
+	if t := TenantFrom(ctx); t != nil {
+		return t.Name
+	}
+	return ""
+}
+
+// DBFor returns the database of the tenant of ctx, or DB when ctx carries no tenant
+func DBFor(ctx context.Context) *sql.DB {
+	if t := TenantFrom(ctx); t != nil {
+		return t.DB
+	}
+	return DB
+}
+
+// OpenTenantDB opens a connection pool to the tenant database name, with the settings and
+// credentials of the primary database
+func OpenTenantDB(name string) (*sql.DB, error) {
+	d := App.Database
+	d.Name = name
+	return openDB(d)
+}
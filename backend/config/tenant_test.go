@@ -0,0 +1,26 @@
+package config
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDBFor(t *testing.T) {
+	previous := DB
+	defer func() { DB = previous }()
+	DB = &sql.DB{}
+	tenantDB := &sql.DB{}
+
+	ctx := context.Background()
+	assert.Same(t, DB, DBFor(ctx))
+	assert.Nil(t, TenantFrom(ctx))
+	assert.Equal(t, "", TenantName(ctx))
+
+	ctx = WithTenant(ctx, &Tenant{Name: "acme", Database: "sykell_acme", DB: tenantDB})
+	assert.Same(t, tenantDB, DBFor(ctx))
+	assert.Equal(t, "acme", TenantName(ctx))
+	assert.Equal(t, "acme", TenantName(context.WithoutCancel(ctx)))
+}
@@ -0,0 +1,57 @@
+package config
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// maxTxAttempts is how many times a transaction is tried when MySQL reports a deadlock
+const maxTxAttempts = 3
+
+// isRetryableTxError reports whether MySQL aborted the transaction because of lock contention
+func isRetryableTxError(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) {
+		// 1213: deadlock found, 1205: lock wait timeout exceeded
+		return mysqlErr.Number == 1213 || mysqlErr.Number == 1205
+	}
+	return false
+}
+
+// WithTransaction runs fn in a transaction on the database of ctx's tenant, committing on success and
+// rolling back on error. Deadlocks and lock wait timeouts are retried with a short backoff.
+func WithTransaction(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	var err error
+	for attempt := 1; attempt <= maxTxAttempts; attempt++ {
+		err = runTransaction(DBFor(ctx), fn)
+		if err == nil || !isRetryableTxError(err) {
+			return err
+		}
+		time.Sleep(time.Duration(attempt) * 50 * time.Millisecond)
+	}
+	return fmt.Errorf("transaction failed after %d attempts: %w", maxTxAttempts, err)
+}
+
+func runTransaction(db *sql.DB, fn func(tx *sql.Tx) error) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// IsDuplicateKey reports whether MySQL rejected a write because it would duplicate a unique key
+func IsDuplicateKey(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	return errors.As(err, &mysqlErr) && mysqlErr.Number == 1062
+}
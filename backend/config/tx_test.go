@@ -0,0 +1,17 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsRetryableTxError(t *testing.T) {
+	assert.True(t, isRetryableTxError(&mysql.MySQLError{Number: 1213, Message: "Deadlock found"}))
+	assert.True(t, isRetryableTxError(fmt.Errorf("insert: %w", &mysql.MySQLError{Number: 1205})))
+	assert.False(t, isRetryableTxError(&mysql.MySQLError{Number: 1062, Message: "Duplicate entry"}))
+	assert.False(t, isRetryableTxError(errors.New("connection reset")))
+}
@@ -0,0 +1,215 @@
+// Package crux looks up real-user Core Web Vitals of origins in the Chrome UX Report (CrUX) API.
+package crux
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"sykell-analyze/backend/analyzer"
+	"sykell-analyze/backend/config"
+)
+
+// Endpoint is the API method that returns the aggregated record of an origin
+const Endpoint = "https://chromeuxreport.googleapis.com/v1/records:queryRecord"
+
+// pruneAbove is the cache size at which expired entries are dropped
+const pruneAbove = 10000
+
+// metrics are the CrUX metrics requested, as the API names them
+var metrics = []string{"largest_contentful_paint", "cumulative_layout_shift", "interaction_to_next_paint"}
+
+// Default is nil when lookups are disabled (crux.api_key unset)
+var Default *Client
+
+// Configure enables lookups when an API key is configured
+func Configure(cfg config.CrUXConfig) {
+	if cfg.APIKey == "" {
+		Default = nil
+		return
+	}
+	Default = New(cfg.APIKey, cfg.CacheTTL, cfg.Timeout)
+	fmt.Println("✅ Chrome UX Report lookups enabled.")
+}
+
+// Client looks origins up and caches their records, which CrUX only updates once a day.
+// It is safe for concurrent use.
+type Client struct {
+	APIKey     string
+	Endpoint   string
+	CacheTTL   time.Duration
+	HTTPClient *http.Client
+
+	mu    sync.Mutex
+	cache map[string]cacheEntry
+}
+
+// cacheEntry is a cached record; vitals is nil for origins without field data
+type cacheEntry struct {
+	vitals  *analyzer.WebVitals
+	expires time.Time
+}
+
+// New creates a client for the public API
+func New(apiKey string, cacheTTL, timeout time.Duration) *Client {
+	return &Client{
+		APIKey:     apiKey,
+		Endpoint:   Endpoint,
+		CacheTTL:   cacheTTL,
+		HTTPClient: &http.Client{Timeout: timeout},
+		cache:      make(map[string]cacheEntry),
+	}
+}
+
+// OriginVitals returns the origin's field data across all devices, or nil when CrUX has none.
+// It implements analyzer.FieldDataSource.
+func (c *Client) OriginVitals(ctx context.Context, origin string) (*analyzer.WebVitals, error) {
+	now := time.Now()
+	c.mu.Lock()
+	entry, ok := c.cache[origin]
+	c.mu.Unlock()
+	if ok && now.Before(entry.expires) {
+		return copyVitals(entry.vitals), nil
+	}
+
+	vitals, err := c.query(ctx, origin)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	if len(c.cache) > pruneAbove {
+		for key, entry := range c.cache {
+			if !now.Before(entry.expires) {
+				delete(c.cache, key)
+			}
+		}
+	}
+	c.cache[origin] = cacheEntry{vitals: vitals, expires: now.Add(c.CacheTTL)}
+	c.mu.Unlock()
+	return copyVitals(vitals), nil
+}
+
+// copyVitals returns a deep copy so callers can fill in ratings without touching the cache
+func copyVitals(v *analyzer.WebVitals) *analyzer.WebVitals {
+	if v == nil {
+		return nil
+	}
+	copied := *v
+	for _, metric := range []**analyzer.VitalMetric{&copied.LCP, &copied.CLS, &copied.INP} {
+		if *metric != nil {
+			m := **metric
+			*metric = &m
+		}
+	}
+	return &copied
+}
+
+// queryRequest is the body of a records:queryRecord call
+type queryRequest struct {
+	Origin  string   `json:"origin"`
+	Metrics []string `json:"metrics"`
+}
+
+// queryResponse is the part of a records:queryRecord answer that is used
+type queryResponse struct {
+	Record struct {
+		Metrics          map[string]metricRecord `json:"metrics"`
+		CollectionPeriod struct {
+			FirstDate date `json:"firstDate"`
+			LastDate  date `json:"lastDate"`
+		} `json:"collectionPeriod"`
+	} `json:"record"`
+}
+
+// metricRecord is the histogram of a metric, whose bins are good, needs improvement and poor in
+// that order, and its 75th percentile. CLS percentiles are sent as strings.
+type metricRecord struct {
+	Histogram []struct {
+		Density float64 `json:"density"`
+	} `json:"histogram"`
+	Percentiles struct {
+		P75 json.Number `json:"p75"`
+	} `json:"percentiles"`
+}
+
+type date struct {
+	Year  int `json:"year"`
+	Month int `json:"month"`
+	Day   int `json:"day"`
+}
+
+func (d date) String() string {
+	return fmt.Sprintf("%04d-%02d-%02d", d.Year, d.Month, d.Day)
+}
+
+// query fetches the origin's record, returning nil when CrUX has no data for it
+func (c *Client) query(ctx context.Context, origin string) (*analyzer.WebVitals, error) {
+	payload, err := json.Marshal(queryRequest{Origin: origin, Metrics: metrics})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.Endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	// Sent as a header so the key never appears in error messages that include the URL
+	req.Header.Set("X-Goog-Api-Key", c.APIKey)
+
+	res, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("chrome ux report lookup failed: %w", err)
+	}
+	defer res.Body.Close()
+
+	// Origins with too little traffic are not in the dataset
+	if res.StatusCode == http.StatusNotFound {
+		io.Copy(io.Discard, io.LimitReader(res.Body, 64<<10))
+		return nil, nil
+	}
+	if res.StatusCode != http.StatusOK {
+		io.Copy(io.Discard, io.LimitReader(res.Body, 64<<10))
+		return nil, fmt.Errorf("chrome ux report lookup failed: %s", res.Status)
+	}
+
+	var decoded queryResponse
+	if err := json.NewDecoder(res.Body).Decode(&decoded); err != nil {
+		return nil, fmt.Errorf("invalid chrome ux report response: %w", err)
+	}
+
+	record := decoded.Record
+	vitals := &analyzer.WebVitals{
+		LCP: toMetric(record.Metrics["largest_contentful_paint"]),
+		CLS: toMetric(record.Metrics["cumulative_layout_shift"]),
+		INP: toMetric(record.Metrics["interaction_to_next_paint"]),
+	}
+	if record.CollectionPeriod.LastDate.Year > 0 {
+		vitals.Period = record.CollectionPeriod.FirstDate.String() + "/" + record.CollectionPeriod.LastDate.String()
+	}
+	if vitals.LCP == nil && vitals.CLS == nil && vitals.INP == nil {
+		return nil, nil
+	}
+	return vitals, nil
+}
+
+// toMetric converts a metric record, returning nil when the metric was not reported
+func toMetric(m metricRecord) *analyzer.VitalMetric {
+	p75, err := strconv.ParseFloat(m.Percentiles.P75.String(), 64)
+	if err != nil {
+		return nil
+	}
+	metric := &analyzer.VitalMetric{P75: p75}
+	if len(m.Histogram) == 3 {
+		metric.Good = m.Histogram[0].Density
+		metric.NeedsImprovement = m.Histogram[1].Density
+		metric.Poor = m.Histogram[2].Density
+	}
+	return metric
+}
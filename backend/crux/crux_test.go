@@ -0,0 +1,104 @@
+package crux
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const sampleRecord = `{
+	"record": {
+		"key": {"origin": "https://example.com"},
+		"metrics": {
+			"largest_contentful_paint": {
+				"histogram": [{"start": 0, "end": 2500, "density": 0.8}, {"start": 2500, "end": 4000, "density": 0.15}, {"start": 4000, "density": 0.05}],
+				"percentiles": {"p75": 2216}
+			},
+			"cumulative_layout_shift": {
+				"histogram": [{"start": "0.00", "end": "0.10", "density": 0.9}, {"start": "0.10", "end": "0.25", "density": 0.06}, {"start": "0.25", "density": 0.04}],
+				"percentiles": {"p75": "0.03"}
+			}
+		},
+		"collectionPeriod": {
+			"firstDate": {"year": 2026, "month": 9, "day": 18},
+			"lastDate": {"year": 2026, "month": 10, "day": 15}
+		}
+	}
+}`
+
+// fakeAPI has field data for https://example.com only and counts the lookups it answers
+func fakeAPI(t *testing.T, calls *int32) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(calls, 1)
+		if r.Header.Get("X-Goog-Api-Key") != "test-key" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+
+		var req queryRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		assert.Equal(t, metrics, req.Metrics)
+		if req.Origin != "https://example.com" {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error": {"code": 404, "message": "chrome ux report data not found"}}`))
+			return
+		}
+		w.Write([]byte(sampleRecord))
+	}))
+}
+
+func TestOriginVitals(t *testing.T) {
+	var calls int32
+	api := fakeAPI(t, &calls)
+	defer api.Close()
+
+	client := New("test-key", time.Hour, 5*time.Second)
+	client.Endpoint = api.URL
+
+	t.Run("converts the record", func(t *testing.T) {
+		vitals, err := client.OriginVitals(context.Background(), "https://example.com")
+		require.NoError(t, err)
+		require.NotNil(t, vitals)
+
+		assert.Equal(t, 2216.0, vitals.LCP.P75)
+		assert.Equal(t, 0.8, vitals.LCP.Good)
+		assert.Equal(t, 0.05, vitals.LCP.Poor)
+		assert.Equal(t, 0.03, vitals.CLS.P75)
+		assert.Nil(t, vitals.INP)
+		assert.Equal(t, "2026-09-18/2026-10-15", vitals.Period)
+	})
+
+	t.Run("answers repeated lookups from the cache", func(t *testing.T) {
+		vitals, err := client.OriginVitals(context.Background(), "https://example.com")
+		require.NoError(t, err)
+		assert.NotNil(t, vitals)
+		assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+
+		// Callers may change the returned record without affecting the cache
+		vitals.LCP.Rating = "good"
+		again, _ := client.OriginVitals(context.Background(), "https://example.com")
+		assert.Empty(t, again.LCP.Rating)
+	})
+
+	t.Run("origins without data are nil", func(t *testing.T) {
+		vitals, err := client.OriginVitals(context.Background(), "https://tiny.example")
+		require.NoError(t, err)
+		assert.Nil(t, vitals)
+	})
+
+	t.Run("API errors do not leak the key", func(t *testing.T) {
+		bad := New("wrong-key", time.Hour, 5*time.Second)
+		bad.Endpoint = api.URL
+		_, err := bad.OriginVitals(context.Background(), "https://example.com")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "403")
+		assert.NotContains(t, err.Error(), "wrong-key")
+	})
+}
@@ -0,0 +1,220 @@
+// Package dataexport builds the archive of everything stored about a user, for the right of access:
+// a ZIP with one JSON file per kind of record.
+package dataexport
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"sykell-analyze/backend/alerts"
+	"sykell-analyze/backend/config"
+	"sykell-analyze/backend/storage"
+)
+
+// TTL is how long a finished archive can be downloaded
+const TTL = 7 * 24 * time.Hour
+
+// BuildTimeout is how long an archive may take to build; a pending export older than this failed
+const BuildTimeout = time.Hour
+
+// Key names the object holding the archive of an export
+func Key(userID, exportID int) string {
+	return fmt.Sprintf("exports/%d/%d.zip", userID, exportID)
+}
+
+// table is one file of the archive: the rows of query, run with the user's ID
+type table struct {
+	file  string
+	query string
+}
+
+// tables are the files of the archive. The password hash is left out; page text snapshots are
+// listed without their content, which is not data about the user.
+var tables = []table{
+	{"profile.json", `
+		SELECT id, username, email, is_admin, max_concurrent_crawls, digest_frequency, digest_hour,
+			digest_last_sent_at, retention_days, timezone, created_at, updated_at
+		FROM users WHERE id = ?`},
+	{"urls.json", "SELECT * FROM urls WHERE user_id = ? ORDER BY id"},
+	{"crawl_runs.json", "SELECT * FROM crawl_runs WHERE user_id = ? ORDER BY id"},
+	{"broken_links.json", `
+		SELECT b.* FROM broken_links b JOIN urls u ON u.id = b.url_id
+		WHERE u.user_id = ? ORDER BY b.id`},
+	{"crawl_logs.json", `
+		SELECT l.* FROM crawl_logs l JOIN urls u ON u.id = l.url_id
+		WHERE u.user_id = ? ORDER BY l.id`},
+	{"content_snapshots.json", `
+		SELECT s.id, s.url_id, s.run_id, s.content_hash, s.change_percent, s.added_lines, s.removed_lines, s.created_at
+		FROM content_snapshots s JOIN urls u ON u.id = s.url_id
+		WHERE u.user_id = ? ORDER BY s.id`},
+	{"uptime_checks.json", `
+		SELECT c.* FROM uptime_checks c JOIN urls u ON u.id = c.url_id
+		WHERE u.user_id = ? ORDER BY c.id`},
+	{"lighthouse_results.json", `
+		SELECT r.* FROM lighthouse_results r JOIN urls u ON u.id = r.url_id
+		WHERE u.user_id = ? ORDER BY r.url_id`},
+	{"url_keywords.json", `
+		SELECT k.* FROM url_keywords k JOIN urls u ON u.id = k.url_id
+		WHERE u.user_id = ? ORDER BY k.id`},
+	{"keyword_results.json", `
+		SELECT k.* FROM keyword_results k JOIN urls u ON u.id = k.url_id
+		WHERE u.user_id = ? ORDER BY k.id`},
+	{"link_exclusions.json", "SELECT * FROM link_exclusions WHERE user_id = ? ORDER BY id"},
+	{"check_rules.json", "SELECT * FROM check_rules WHERE user_id = ? ORDER BY id"},
+	{"check_results.json", `
+		SELECT r.* FROM check_results r JOIN urls u ON u.id = r.url_id
+		WHERE u.user_id = ? ORDER BY r.id`},
+	{"alert_rules.json", "SELECT * FROM alert_rules WHERE user_id = ? ORDER BY id"},
+	{"alert_states.json", `
+		SELECT s.* FROM alert_states s JOIN alert_rules r ON r.id = s.rule_id
+		WHERE r.user_id = ? ORDER BY s.rule_id, s.url_id`},
+}
+
+// Build writes the user's archive, read from the database of ctx's tenant, to w
+func Build(ctx context.Context, w io.Writer, userID int) error {
+	zw := zip.NewWriter(w)
+	for _, t := range tables {
+		if err := writeTable(ctx, zw, t, userID); err != nil {
+			return fmt.Errorf("failed to export %s: %w", t.file, err)
+		}
+	}
+	return zw.Close()
+}
+
+// writeTable writes the rows of one table as a JSON array of objects keyed by column name
+func writeTable(ctx context.Context, zw *zip.Writer, t table, userID int) error {
+	rows, err := config.DBFor(ctx).Query(t.query, userID)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	columns, err := rows.ColumnTypes()
+	if err != nil {
+		return err
+	}
+
+	f, err := zw.Create(t.file)
+	if err != nil {
+		return err
+	}
+	b := bufio.NewWriter(f)
+	b.WriteString("[")
+
+	values := make([]interface{}, len(columns))
+	pointers := make([]interface{}, len(columns))
+	for i := range values {
+		pointers[i] = &values[i]
+	}
+	first := true
+	for rows.Next() {
+		if err := rows.Scan(pointers...); err != nil {
+			return err
+		}
+		if !first {
+			b.WriteString(",")
+		}
+		first = false
+
+		b.WriteString("\n  {")
+		for i, column := range columns {
+			if i > 0 {
+				b.WriteString(", ")
+			}
+			key, _ := json.Marshal(column.Name())
+			value, err := json.Marshal(jsonValue(values[i], column.DatabaseTypeName()))
+			if err != nil {
+				return err
+			}
+			b.Write(key)
+			b.WriteString(": ")
+			b.Write(value)
+		}
+		b.WriteString("}")
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	b.WriteString("\n]\n")
+	return b.Flush()
+}
+
+// jsonValue converts a value scanned from a column of databaseType for JSON encoding: JSON columns
+// are embedded as-is and other bytes become strings
+func jsonValue(v interface{}, databaseType string) interface{} {
+	b, ok := v.([]byte)
+	if !ok {
+		return v
+	}
+	if databaseType == "JSON" && json.Valid(b) {
+		return json.RawMessage(b)
+	}
+	return string(b)
+}
+
+// Run builds the archive of export exportID in the background and stores it. When email is set
+// and an SMTP server is configured, the user is sent downloadURL once the archive is ready, with
+// its expiry in loc. ctx selects the tenant; its cancellation is ignored.
+func Run(ctx context.Context, exportID, userID int, email, downloadURL string, loc *time.Location) {
+	ctx = context.WithoutCancel(ctx)
+	db := config.DBFor(ctx)
+	var buf bytes.Buffer
+	err := Build(ctx, &buf, userID)
+	if err != nil {
+		fmt.Printf("DEBUG: Data export %d for user %d failed: %v\n", exportID, userID, err)
+		if _, dbErr := db.Exec(
+			"UPDATE data_exports SET status = 'failed', error_message = ?, completed_at = ? WHERE id = ?",
+			err.Error(), time.Now(), exportID,
+		); dbErr != nil {
+			fmt.Printf("DEBUG: Failed to record failure of data export %d: %v\n", exportID, dbErr)
+		}
+		return
+	}
+
+	// The archive goes to storage, keeping large blobs out of MySQL
+	key := Key(userID, exportID)
+	store := storage.For(ctx)
+	if store == nil {
+		err = errors.New("storage is not set up")
+	} else {
+		err = store.Put(ctx, key, buf.Bytes())
+	}
+	if err != nil {
+		fmt.Printf("DEBUG: Failed to store data export %d: %v\n", exportID, err)
+		if _, dbErr := db.Exec(
+			"UPDATE data_exports SET status = 'failed', error_message = ?, completed_at = ? WHERE id = ?",
+			"failed to store the archive", time.Now(), exportID,
+		); dbErr != nil {
+			fmt.Printf("DEBUG: Failed to record failure of data export %d: %v\n", exportID, dbErr)
+		}
+		return
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(TTL)
+	_, err = db.Exec(`
+		UPDATE data_exports SET status = 'ready', storage_key = ?, size_bytes = ?, completed_at = ?, expires_at = ?
+		WHERE id = ?
+	`, key, buf.Len(), now, expiresAt, exportID)
+	if err != nil {
+		fmt.Printf("DEBUG: Failed to store data export %d: %v\n", exportID, err)
+		return
+	}
+	fmt.Printf("DEBUG: Data export %d for user %d ready (%d bytes)\n", exportID, userID, buf.Len())
+
+	if email == "" || config.App.Alerts.SMTPHost == "" {
+		return
+	}
+	body := fmt.Sprintf("Your account data export is ready. Download it until %s (%s):\n\n%s\n",
+		expiresAt.In(loc).Format("2006-01-02 15:04"), loc, downloadURL)
+	if err := alerts.SendEmail(ctx, email, "Your data export is ready", body); err != nil {
+		fmt.Printf("DEBUG: Failed to email data export %d to user %d: %v\n", exportID, userID, err)
+	}
+}
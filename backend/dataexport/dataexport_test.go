@@ -0,0 +1,43 @@
+package dataexport
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJsonValue(t *testing.T) {
+	t.Run("JSON columns are embedded", func(t *testing.T) {
+		encoded, _ := json.Marshal(jsonValue([]byte(`{"a":1}`), "JSON"))
+		assert.Equal(t, `{"a":1}`, string(encoded))
+	})
+
+	t.Run("other bytes become strings", func(t *testing.T) {
+		assert.Equal(t, "12.50", jsonValue([]byte("12.50"), "DECIMAL"))
+		assert.Equal(t, "not json", jsonValue([]byte("not json"), "JSON"))
+	})
+
+	t.Run("other values are kept", func(t *testing.T) {
+		now := time.Now()
+		assert.Equal(t, int64(3), jsonValue(int64(3), "INT"))
+		assert.Equal(t, now, jsonValue(now, "TIMESTAMP"))
+		assert.Nil(t, jsonValue(nil, "TEXT"))
+	})
+}
+
+func TestTables(t *testing.T) {
+	files := make(map[string]bool)
+	for _, table := range tables {
+		assert.False(t, files[table.file], "duplicate file %s", table.file)
+		files[table.file] = true
+		assert.Equal(t, 1, strings.Count(table.query, "?"), "%s takes the user ID only", table.file)
+		assert.NotContains(t, table.query, "password", table.file)
+	}
+}
+
+func TestKey(t *testing.T) {
+	assert.Equal(t, "exports/7/42.zip", Key(7, 42))
+}
@@ -0,0 +1,325 @@
+// Package dbmetrics measures the queries sent through a database/sql connector: how many ran, how
+// long they took and which were slow. Wrapping the connector measures every query of the *sql.DB
+// opened with it, whichever package sends it.
+package dbmetrics
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// bucketBounds are the upper bounds of the query duration histogram
+var bucketBounds = []time.Duration{
+	time.Millisecond, 5 * time.Millisecond, 10 * time.Millisecond, 50 * time.Millisecond,
+	100 * time.Millisecond, 500 * time.Millisecond, time.Second, 5 * time.Second,
+}
+
+// recentSlowQueries is how many of the latest slow queries Snapshot returns
+const recentSlowQueries = 20
+
+// maxQueryLength is how much of a query's text is logged and kept
+const maxQueryLength = 300
+
+// Bucket counts the queries that took at most Le; the last bucket, "+Inf", counts every query
+type Bucket struct {
+	Le    string `json:"le"`
+	Count int64  `json:"count"`
+}
+
+// SlowQuery is a query that took longer than the slow query threshold. Its arguments are not kept.
+type SlowQuery struct {
+	Query      string    `json:"query"`
+	DurationMs float64   `json:"duration_ms"`
+	At         time.Time `json:"at"`
+}
+
+// Stats summarizes the queries measured since the process started. A query that returns rows is
+// measured until its first rows arrive, not until they are all read.
+type Stats struct {
+	Queries      int64       `json:"queries"`
+	Errors       int64       `json:"errors"`
+	SlowQueries  int64       `json:"slow_queries"`
+	TotalSeconds float64     `json:"total_seconds"`
+	MaxMs        float64     `json:"max_ms"`
+	Buckets      []Bucket    `json:"buckets"`
+	RecentSlow   []SlowQuery `json:"recent_slow"`
+}
+
+var (
+	mu         sync.Mutex
+	queries    int64
+	errorCount int64
+	slowCount  int64
+	total      time.Duration
+	longest    time.Duration
+	buckets    = make([]int64, len(bucketBounds))
+	recentSlow []SlowQuery
+)
+
+// record adds one query to the stats and logs it when it took longer than threshold (0 disables)
+func record(query string, d time.Duration, err error, threshold time.Duration) {
+	slow := threshold > 0 && d > threshold
+
+	mu.Lock()
+	queries++
+	if err != nil {
+		errorCount++
+	}
+	total += d
+	if d > longest {
+		longest = d
+	}
+	for i, bound := range bucketBounds {
+		if d <= bound {
+			buckets[i]++
+			break
+		}
+	}
+	if slow {
+		slowCount++
+		recentSlow = append(recentSlow, SlowQuery{Query: compact(query), DurationMs: milliseconds(d), At: time.Now()})
+		if len(recentSlow) > recentSlowQueries {
+			recentSlow = recentSlow[len(recentSlow)-recentSlowQueries:]
+		}
+	}
+	mu.Unlock()
+
+	if slow {
+		fmt.Printf("DEBUG: Slow query took %s: %s\n", d.Round(time.Millisecond), compact(query))
+	}
+}
+
+// Snapshot returns the stats of the queries measured so far
+func Snapshot() Stats {
+	mu.Lock()
+	defer mu.Unlock()
+
+	stats := Stats{
+		Queries:      queries,
+		Errors:       errorCount,
+		SlowQueries:  slowCount,
+		TotalSeconds: total.Seconds(),
+		MaxMs:        milliseconds(longest),
+		Buckets:      make([]Bucket, 0, len(bucketBounds)+1),
+		RecentSlow:   append([]SlowQuery{}, recentSlow...),
+	}
+	var cumulative int64
+	for i, bound := range bucketBounds {
+		cumulative += buckets[i]
+		stats.Buckets = append(stats.Buckets, Bucket{Le: bound.String(), Count: cumulative})
+	}
+	stats.Buckets = append(stats.Buckets, Bucket{Le: "+Inf", Count: queries})
+	return stats
+}
+
+// reset clears the stats, for tests
+func reset() {
+	mu.Lock()
+	defer mu.Unlock()
+	queries, errorCount, slowCount, total, longest = 0, 0, 0, 0, 0
+	buckets = make([]int64, len(bucketBounds))
+	recentSlow = nil
+}
+
+// compact puts a query on one line and shortens it to maxQueryLength characters
+func compact(query string) string {
+	query = strings.Join(strings.Fields(query), " ")
+	if runes := []rune(query); len(runes) > maxQueryLength {
+		return string(runes[:maxQueryLength]) + "…"
+	}
+	return query
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+// Wrap returns a connector that measures every query of the connections inner opens, logging the
+// ones that take longer than slowThreshold (0 disables the log). Open the database with sql.OpenDB.
+func Wrap(inner driver.Connector, slowThreshold time.Duration) driver.Connector {
+	return &connector{inner: inner, threshold: slowThreshold}
+}
+
+type connector struct {
+	inner     driver.Connector
+	threshold time.Duration
+}
+
+func (c *connector) Connect(ctx context.Context) (driver.Conn, error) {
+	inner, err := c.inner.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &conn{inner: inner, threshold: c.threshold}, nil
+}
+
+func (c *connector) Driver() driver.Driver {
+	return c.inner.Driver()
+}
+
+// conn passes everything to the driver's connection, measuring queries on the way. The driver may
+// skip a query with arguments (driver.ErrSkip); database/sql then prepares it, and the statement
+// measures it instead.
+type conn struct {
+	inner     driver.Conn
+	threshold time.Duration
+}
+
+// measured runs fn and records it unless the driver skipped it
+func measured[T any](query string, threshold time.Duration, fn func() (T, error)) (T, error) {
+	start := time.Now()
+	result, err := fn()
+	if !errors.Is(err, driver.ErrSkip) {
+		record(query, time.Since(start), err, threshold)
+	}
+	return result, err
+}
+
+func (c *conn) Prepare(query string) (driver.Stmt, error) {
+	return c.PrepareContext(context.Background(), query)
+}
+
+func (c *conn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	var inner driver.Stmt
+	var err error
+	if preparer, ok := c.inner.(driver.ConnPrepareContext); ok {
+		inner, err = preparer.PrepareContext(ctx, query)
+	} else {
+		inner, err = c.inner.Prepare(query)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &stmt{inner: inner, query: query, threshold: c.threshold}, nil
+}
+
+func (c *conn) Close() error {
+	return c.inner.Close()
+}
+
+func (c *conn) Begin() (driver.Tx, error) {
+	return c.BeginTx(context.Background(), driver.TxOptions{})
+}
+
+func (c *conn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if beginner, ok := c.inner.(driver.ConnBeginTx); ok {
+		return beginner.BeginTx(ctx, opts)
+	}
+	return c.inner.Begin()
+}
+
+func (c *conn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	execer, ok := c.inner.(driver.ExecerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	return measured(query, c.threshold, func() (driver.Result, error) {
+		return execer.ExecContext(ctx, query, args)
+	})
+}
+
+func (c *conn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	queryer, ok := c.inner.(driver.QueryerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	return measured(query, c.threshold, func() (driver.Rows, error) {
+		return queryer.QueryContext(ctx, query, args)
+	})
+}
+
+func (c *conn) Ping(ctx context.Context) error {
+	if pinger, ok := c.inner.(driver.Pinger); ok {
+		return pinger.Ping(ctx)
+	}
+	return nil
+}
+
+func (c *conn) ResetSession(ctx context.Context) error {
+	if resetter, ok := c.inner.(driver.SessionResetter); ok {
+		return resetter.ResetSession(ctx)
+	}
+	return nil
+}
+
+func (c *conn) IsValid() bool {
+	if validator, ok := c.inner.(driver.Validator); ok {
+		return validator.IsValid()
+	}
+	return true
+}
+
+func (c *conn) CheckNamedValue(nv *driver.NamedValue) error {
+	if checker, ok := c.inner.(driver.NamedValueChecker); ok {
+		return checker.CheckNamedValue(nv)
+	}
+	return driver.ErrSkip
+}
+
+// stmt measures the executions of a prepared statement
+type stmt struct {
+	inner     driver.Stmt
+	query     string
+	threshold time.Duration
+}
+
+func (s *stmt) Close() error {
+	return s.inner.Close()
+}
+
+func (s *stmt) NumInput() int {
+	return s.inner.NumInput()
+}
+
+func (s *stmt) Exec(args []driver.Value) (driver.Result, error) {
+	return measured(s.query, s.threshold, func() (driver.Result, error) {
+		return s.inner.Exec(args)
+	})
+}
+
+func (s *stmt) Query(args []driver.Value) (driver.Rows, error) {
+	return measured(s.query, s.threshold, func() (driver.Rows, error) {
+		return s.inner.Query(args)
+	})
+}
+
+func (s *stmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	execer, ok := s.inner.(driver.StmtExecContext)
+	if !ok {
+		return s.Exec(values(args))
+	}
+	return measured(s.query, s.threshold, func() (driver.Result, error) {
+		return execer.ExecContext(ctx, args)
+	})
+}
+
+func (s *stmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	queryer, ok := s.inner.(driver.StmtQueryContext)
+	if !ok {
+		return s.Query(values(args))
+	}
+	return measured(s.query, s.threshold, func() (driver.Rows, error) {
+		return queryer.QueryContext(ctx, args)
+	})
+}
+
+func (s *stmt) CheckNamedValue(nv *driver.NamedValue) error {
+	if checker, ok := s.inner.(driver.NamedValueChecker); ok {
+		return checker.CheckNamedValue(nv)
+	}
+	return driver.ErrSkip
+}
+
+// values drops the names of positional arguments for drivers without context support
+func values(args []driver.NamedValue) []driver.Value {
+	result := make([]driver.Value, len(args))
+	for i, arg := range args {
+		result[i] = arg.Value
+	}
+	return result
+}
@@ -0,0 +1,121 @@
+package dbmetrics
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeConnector opens fakeConns, which run any statement without arguments directly and make
+// database/sql prepare the others, like the MySQL driver
+type fakeConnector struct{}
+
+func (fakeConnector) Connect(context.Context) (driver.Conn, error) { return fakeConn{}, nil }
+func (fakeConnector) Driver() driver.Driver                        { return nil }
+
+type fakeConn struct{}
+
+func (fakeConn) Prepare(query string) (driver.Stmt, error) { return fakeStmt{}, nil }
+func (fakeConn) Close() error                              { return nil }
+func (fakeConn) Begin() (driver.Tx, error)                 { return nil, errors.New("not supported") }
+
+func (fakeConn) ExecContext(_ context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	if len(args) > 0 {
+		return nil, driver.ErrSkip
+	}
+	if strings.Contains(query, "broken") {
+		return nil, errors.New("syntax error")
+	}
+	return driver.RowsAffected(1), nil
+}
+
+type fakeStmt struct{}
+
+func (fakeStmt) Close() error                                    { return nil }
+func (fakeStmt) NumInput() int                                   { return -1 }
+func (fakeStmt) Exec(args []driver.Value) (driver.Result, error) { return driver.RowsAffected(2), nil }
+func (fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	return nil, errors.New("not supported")
+}
+
+func TestWrap(t *testing.T) {
+	reset()
+	defer reset()
+
+	db := sql.OpenDB(Wrap(fakeConnector{}, time.Hour))
+	defer db.Close()
+
+	_, err := db.Exec("DELETE FROM urls")
+	assert.NoError(t, err)
+	// Skipped by the connection and measured once, as a prepared statement
+	result, err := db.Exec("DELETE FROM urls WHERE id = ?", 1)
+	assert.NoError(t, err)
+	affected, _ := result.RowsAffected()
+	assert.Equal(t, int64(2), affected)
+	_, err = db.Exec("broken query")
+	assert.Error(t, err)
+
+	stats := Snapshot()
+	assert.Equal(t, int64(3), stats.Queries)
+	assert.Equal(t, int64(1), stats.Errors)
+	assert.Equal(t, int64(0), stats.SlowQueries)
+	assert.Empty(t, stats.RecentSlow)
+}
+
+func TestRecord(t *testing.T) {
+	reset()
+	defer reset()
+
+	record("SELECT 1", 3*time.Millisecond, nil, time.Second)
+	record("SELECT\n\t  *   FROM urls", 2*time.Second, nil, time.Second)
+	record("SELECT 2", 20*time.Millisecond, errors.New("lost connection"), 0)
+
+	stats := Snapshot()
+	assert.Equal(t, int64(3), stats.Queries)
+	assert.Equal(t, int64(1), stats.Errors)
+	assert.Equal(t, int64(1), stats.SlowQueries)
+	assert.Equal(t, 2000.0, stats.MaxMs)
+	assert.InDelta(t, 2.023, stats.TotalSeconds, 0.0001)
+
+	counts := map[string]int64{}
+	for _, b := range stats.Buckets {
+		counts[b.Le] = b.Count
+	}
+	assert.Equal(t, int64(0), counts["1ms"])
+	assert.Equal(t, int64(1), counts["5ms"])
+	assert.Equal(t, int64(2), counts["50ms"])
+	assert.Equal(t, int64(2), counts["1s"])
+	assert.Equal(t, int64(3), counts["5s"])
+	assert.Equal(t, int64(3), counts["+Inf"])
+
+	if assert.Len(t, stats.RecentSlow, 1) {
+		assert.Equal(t, "SELECT * FROM urls", stats.RecentSlow[0].Query)
+		assert.Equal(t, 2000.0, stats.RecentSlow[0].DurationMs)
+	}
+}
+
+func TestRecentSlowIsBounded(t *testing.T) {
+	reset()
+	defer reset()
+
+	for i := 0; i < recentSlowQueries+5; i++ {
+		record("SELECT SLEEP(1)", time.Second, nil, time.Millisecond)
+	}
+	stats := Snapshot()
+	assert.Equal(t, int64(recentSlowQueries+5), stats.SlowQueries)
+	assert.Len(t, stats.RecentSlow, recentSlowQueries)
+}
+
+func TestCompact(t *testing.T) {
+	assert.Equal(t, "SELECT id FROM urls WHERE user_id = ?", compact("\n\tSELECT id\n\tFROM urls\n\tWHERE user_id = ?\n"))
+
+	long := compact("SELECT " + strings.Repeat("x", 500))
+	assert.Equal(t, maxQueryLength+1, len([]rune(long)))
+	assert.True(t, strings.HasSuffix(long, "…"))
+}
@@ -0,0 +1,197 @@
+// Package demo creates the demo account: a user holding example analyses that visitors can browse
+// with a read-only token, without signing up.
+package demo
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"sykell-analyze/backend/config"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// example is a seeded analysis
+type example struct {
+	url           string
+	title         string
+	htmlVersion   string
+	h1, h2, h3    int
+	internal      int
+	external      int
+	hasLoginForm  bool
+	brokenLinks   []brokenLink
+	crawledHrsAgo int
+	internalPages []string
+	noindex       bool
+	failed        string // error message of a failed crawl; the other fields are ignored
+}
+
+// brokenLink is a seeded broken link
+type brokenLink struct {
+	url        string
+	statusCode int
+	err        string
+}
+
+// examples are the analyses seeded into an empty demo account. They use the reserved example
+// domains, so nothing points at real sites.
+var examples = []example{
+	{
+		url: "https://example.com/", title: "Example Domain", htmlVersion: "HTML5",
+		h1: 1, h2: 4, h3: 6, internal: 24, external: 8, crawledHrsAgo: 2,
+		internalPages: []string{"https://example.com/about", "https://example.com/contact", "https://example.com/pricing"},
+	},
+	{
+		url: "https://shop.example.net/", title: "Example Shop - Home", htmlVersion: "HTML5",
+		h1: 1, h2: 9, h3: 14, internal: 86, external: 12, hasLoginForm: true, crawledHrsAgo: 5,
+		brokenLinks: []brokenLink{
+			{url: "https://shop.example.net/sale-2019", statusCode: 404, err: "Not Found"},
+			{url: "https://shop.example.net/gift-cards", statusCode: 410, err: "Gone"},
+			{url: "https://partner.example.org/feed", statusCode: 503, err: "Service Unavailable"},
+		},
+		internalPages: []string{"https://shop.example.net/cart", "https://shop.example.net/products", "https://shop.example.net/sale-2019"},
+	},
+	{
+		url: "https://blog.example.org/", title: "", htmlVersion: "HTML 4.01 Transitional",
+		h1: 0, h2: 12, h3: 3, internal: 41, external: 27, crawledHrsAgo: 30,
+		brokenLinks: []brokenLink{
+			{url: "http://old.example.org/archive", err: "dial tcp: lookup old.example.org: no such host"},
+		},
+	},
+	{
+		url: "https://staging.example.com/", title: "Staging", htmlVersion: "HTML5",
+		h1: 2, h2: 1, h3: 0, internal: 5, external: 0, noindex: true, crawledHrsAgo: 72,
+	},
+	{
+		url: "https://down.example.net/", crawledHrsAgo: 1,
+		failed: "failed to fetch URL: connection refused",
+	},
+}
+
+// Seed creates the demo account in the database of ctx's tenant when it does not exist yet and fills
+// it with example analyses when it has no URLs. It refuses to turn an existing regular account into
+// the demo account.
+func Seed(ctx context.Context, cfg config.DemoConfig) error {
+	userID, err := ensureUser(ctx, cfg.Username)
+	if err != nil {
+		return err
+	}
+
+	var count int
+	if err := config.DBFor(ctx).QueryRow("SELECT COUNT(*) FROM urls WHERE user_id = ?", userID).Scan(&count); err != nil {
+		return fmt.Errorf("failed to count demo URLs: %w", err)
+	}
+	if count > 0 {
+		return nil
+	}
+
+	return config.WithTransaction(ctx, func(tx *sql.Tx) error {
+		now := time.Now()
+		for _, e := range examples {
+			if err := seedExample(tx, userID, e, now); err != nil {
+				return fmt.Errorf("failed to seed %s: %w", e.url, err)
+			}
+		}
+		fmt.Printf("✅ Seeded the demo account with %d example analyses\n", len(examples))
+		return nil
+	})
+}
+
+// ensureUser returns the demo account's ID, creating it with a random password nobody knows
+func ensureUser(ctx context.Context, username string) (int, error) {
+	db := config.DBFor(ctx)
+	var userID int
+	var isDemo bool
+	err := db.QueryRow("SELECT id, is_demo FROM users WHERE username = ?", username).Scan(&userID, &isDemo)
+	if err == nil {
+		if !isDemo {
+			return 0, fmt.Errorf("demo username %q belongs to a regular account", username)
+		}
+		return userID, nil
+	} else if err != sql.ErrNoRows {
+		return 0, fmt.Errorf("failed to look up demo account: %w", err)
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return 0, err
+	}
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(hex.EncodeToString(secret)), bcrypt.DefaultCost)
+	if err != nil {
+		return 0, err
+	}
+
+	result, err := db.Exec(
+		"INSERT INTO users (username, email, password, is_demo) VALUES (?, ?, ?, TRUE)",
+		username, username+"@demo.invalid", string(hashedPassword),
+	)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create demo account: %w", err)
+	}
+	id, _ := result.LastInsertId()
+	return int(id), nil
+}
+
+// seedExample stores one example analysis with its broken links and crawl run
+func seedExample(tx *sql.Tx, userID int, e example, now time.Time) error {
+	crawledAt := now.Add(-time.Duration(e.crawledHrsAgo) * time.Hour)
+
+	if e.failed != "" {
+		result, err := tx.Exec(
+			"INSERT INTO urls (user_id, url, status, error_message, created_at, updated_at) VALUES (?, ?, 'error', ?, ?, ?)",
+			userID, e.url, e.failed, crawledAt, crawledAt,
+		)
+		if err != nil {
+			return err
+		}
+		urlID, _ := result.LastInsertId()
+		_, err = tx.Exec(
+			"INSERT INTO crawl_runs (url_id, user_id, status, error_message, started_at, finished_at) VALUES (?, ?, 'error', ?, ?, ?)",
+			urlID, userID, e.failed, crawledAt, crawledAt,
+		)
+		return err
+	}
+
+	internalPages, err := json.Marshal(append([]string{}, e.internalPages...))
+	if err != nil {
+		return err
+	}
+	result, err := tx.Exec(`
+		INSERT INTO urls (
+			user_id, url, status, html_version, title, h1_count, h2_count, h3_count,
+			internal_links, external_links, broken_links, has_login_form, is_noindex, internal_pages,
+			crawled_at, created_at, updated_at
+		) VALUES (?, ?, 'completed', ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, userID, e.url, e.htmlVersion, e.title, e.h1, e.h2, e.h3, e.internal, e.external, len(e.brokenLinks),
+		e.hasLoginForm, e.noindex, string(internalPages), crawledAt, crawledAt, crawledAt)
+	if err != nil {
+		return err
+	}
+	urlID, _ := result.LastInsertId()
+
+	for _, link := range e.brokenLinks {
+		var statusCode interface{}
+		if link.statusCode != 0 {
+			statusCode = link.statusCode
+		}
+		_, err := tx.Exec(
+			"INSERT INTO broken_links (url_id, link_url, status_code, error_message, created_at) VALUES (?, ?, ?, ?, ?)",
+			urlID, link.url, statusCode, link.err, crawledAt,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = tx.Exec(
+		"INSERT INTO crawl_runs (url_id, user_id, status, broken_links, started_at, finished_at) VALUES (?, ?, 'completed', ?, ?, ?)",
+		urlID, userID, len(e.brokenLinks), crawledAt.Add(-20*time.Second), crawledAt,
+	)
+	return err
+}
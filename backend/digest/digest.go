@@ -0,0 +1,159 @@
+// Package digest emails users a daily or weekly summary of their account: URLs with new broken
+// links, failed crawls and stale analyses. Each user picks the frequency and hour in their profile.
+package digest
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"sykell-analyze/backend/alerts"
+	"sykell-analyze/backend/config"
+	"sykell-analyze/backend/utils"
+)
+
+// pollInterval is how often the job looks for users due for a digest
+const pollInterval = 5 * time.Minute
+
+// Job sends due digests. Several processes may run one against the same database: each digest is
+// claimed before it is sent, so it is sent once.
+type Job struct {
+	Config config.DigestConfig
+	// StaleAfter is the age after which an analysis is listed as stale
+	StaleAfter time.Duration
+}
+
+// New creates a digest job
+func New(cfg config.DigestConfig, staleAfter time.Duration) *Job {
+	return &Job{Config: cfg, StaleAfter: staleAfter}
+}
+
+// subscriber is a user with a digest schedule
+type subscriber struct {
+	id        int
+	email     string
+	frequency string
+	hour      int
+	lastSent  *time.Time
+	timezone  string
+}
+
+// Run sends due digests until ctx is cancelled
+func (j *Job) Run(ctx context.Context) {
+	fmt.Println("📬 Digest job started")
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		j.sendDue(ctx, time.Now())
+
+		select {
+		case <-ctx.Done():
+			fmt.Println("📬 Digest job stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// sendDue sends the digest of every subscriber due at now
+func (j *Job) sendDue(ctx context.Context, now time.Time) {
+	// digest_last_sent_at has whole seconds; claim and release compare against it
+	now = now.Truncate(time.Second)
+
+	subscribers, err := loadSubscribers(ctx)
+	if err != nil {
+		fmt.Printf("DEBUG: Failed to load digest subscribers: %v\n", err)
+		return
+	}
+
+	for _, s := range subscribers {
+		if ctx.Err() != nil {
+			return
+		}
+		if !isDue(s.frequency, s.hour, s.lastSent, now) {
+			continue
+		}
+		claimed, err := claim(ctx, s, now)
+		if err != nil {
+			fmt.Printf("DEBUG: Failed to claim digest of user %d: %v\n", s.id, err)
+			continue
+		}
+		if !claimed {
+			continue // another process sends it
+		}
+		if err := j.send(ctx, s, now); err != nil {
+			fmt.Printf("DEBUG: Failed to send digest to user %d: %v\n", s.id, err)
+			// Release the claim so the next poll retries
+			if err := release(ctx, s, now); err != nil {
+				fmt.Printf("DEBUG: Failed to release digest of user %d: %v\n", s.id, err)
+			}
+		}
+	}
+}
+
+// send emails the subscriber's digest covering the time since the previous one. Nothing is sent
+// when there is nothing to report.
+func (j *Job) send(ctx context.Context, s subscriber, now time.Time) error {
+	since := now.Add(-period(s.frequency))
+	if s.lastSent != nil {
+		since = *s.lastSent
+	}
+	report, err := buildReport(ctx, s.id, s.frequency, since, now, j.StaleAfter)
+	if err != nil {
+		return err
+	}
+	report.Location = utils.Location(s.timezone)
+	if report.Empty() {
+		return nil
+	}
+	if err := alerts.SendEmail(ctx, s.email, report.Subject(), report.Text()); err != nil {
+		return err
+	}
+	fmt.Printf("DEBUG: Sent %s digest to user %d\n", s.frequency, s.id)
+	return nil
+}
+
+// loadSubscribers returns every user with a digest schedule
+func loadSubscribers(ctx context.Context) ([]subscriber, error) {
+	rows, err := config.DBFor(ctx).Query(`
+		SELECT id, email, digest_frequency, digest_hour, digest_last_sent_at, timezone
+		FROM users WHERE digest_frequency IN ('daily', 'weekly')
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var subscribers []subscriber
+	for rows.Next() {
+		var s subscriber
+		if err := rows.Scan(&s.id, &s.email, &s.frequency, &s.hour, &s.lastSent, &s.timezone); err != nil {
+			return nil, err
+		}
+		subscribers = append(subscribers, s)
+	}
+	return subscribers, rows.Err()
+}
+
+// claim marks the digest as sent at now unless another process did since it was loaded
+func claim(ctx context.Context, s subscriber, now time.Time) (bool, error) {
+	res, err := config.DBFor(ctx).Exec(`
+		UPDATE users SET digest_last_sent_at = ?, updated_at = updated_at
+		WHERE id = ? AND digest_last_sent_at <=> ?
+	`, now, s.id, s.lastSent)
+	if err != nil {
+		return false, err
+	}
+	n, _ := res.RowsAffected()
+	return n == 1, nil
+}
+
+// release undoes claim
+func release(ctx context.Context, s subscriber, now time.Time) error {
+	_, err := config.DBFor(ctx).Exec(`
+		UPDATE users SET digest_last_sent_at = ?, updated_at = updated_at
+		WHERE id = ? AND digest_last_sent_at = ?
+	`, s.lastSent, s.id, now)
+	return err
+}
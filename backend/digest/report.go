@@ -0,0 +1,191 @@
+package digest
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"sykell-analyze/backend/config"
+)
+
+// maxListed caps the entries listed per section of a digest; the section still reports the total
+const maxListed = 20
+
+// BrokenLinkChange is a URL whose latest crawl found more broken links than before the digest period
+type BrokenLinkChange struct {
+	URL    string
+	Before int
+	After  int
+}
+
+// FailedCrawl is a crawl that ended in an error during the digest period
+type FailedCrawl struct {
+	URL   string
+	Error string
+	At    time.Time
+}
+
+// StaleURL is a URL whose latest analysis is older than crawler.stale_after
+type StaleURL struct {
+	URL       string
+	CrawledAt time.Time
+}
+
+// Report is the account activity between Since and Until
+type Report struct {
+	Frequency    string
+	Since, Until time.Time
+	BrokenLinks  []BrokenLinkChange
+	FailedCrawls []FailedCrawl
+	StaleURLs    []StaleURL
+	// Location is the user's time zone the text shows times in; nil means UTC
+	Location *time.Location
+}
+
+// Empty reports whether there is nothing worth sending
+func (r Report) Empty() bool {
+	return len(r.BrokenLinks) == 0 && len(r.FailedCrawls) == 0 && len(r.StaleURLs) == 0
+}
+
+// Subject is the email subject of the digest
+func (r Report) Subject() string {
+	var parts []string
+	if n := len(r.BrokenLinks); n > 0 {
+		parts = append(parts, plural(n, "URL", "URLs")+" with new broken links")
+	}
+	if n := len(r.FailedCrawls); n > 0 {
+		parts = append(parts, plural(n, "failed crawl", "failed crawls"))
+	}
+	if n := len(r.StaleURLs); n > 0 {
+		parts = append(parts, plural(n, "stale URL", "stale URLs"))
+	}
+	return fmt.Sprintf("Your %s digest: %s", r.Frequency, strings.Join(parts, ", "))
+}
+
+// Text is the plain text body of the digest, with times in the user's time zone
+func (r Report) Text() string {
+	loc := r.Location
+	if loc == nil {
+		loc = time.UTC
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "Activity from %s to %s (%s)\n", r.Since.In(loc).Format("2006-01-02 15:04"), r.Until.In(loc).Format("2006-01-02 15:04"), loc)
+
+	if len(r.BrokenLinks) > 0 {
+		fmt.Fprintf(&b, "\nNew broken links (%d URLs)\n", len(r.BrokenLinks))
+		for i, c := range r.BrokenLinks {
+			if i == maxListed {
+				fmt.Fprintf(&b, "  ...and %d more\n", len(r.BrokenLinks)-maxListed)
+				break
+			}
+			fmt.Fprintf(&b, "  %s: %d broken (was %d)\n", c.URL, c.After, c.Before)
+		}
+	}
+	if len(r.FailedCrawls) > 0 {
+		fmt.Fprintf(&b, "\nFailed crawls (%d)\n", len(r.FailedCrawls))
+		for i, f := range r.FailedCrawls {
+			if i == maxListed {
+				fmt.Fprintf(&b, "  ...and %d more\n", len(r.FailedCrawls)-maxListed)
+				break
+			}
+			fmt.Fprintf(&b, "  %s at %s: %s\n", f.URL, f.At.In(loc).Format("2006-01-02 15:04"), f.Error)
+		}
+	}
+	if len(r.StaleURLs) > 0 {
+		fmt.Fprintf(&b, "\nStale URLs (%d), not analyzed recently\n", len(r.StaleURLs))
+		for i, s := range r.StaleURLs {
+			if i == maxListed {
+				fmt.Fprintf(&b, "  ...and %d more\n", len(r.StaleURLs)-maxListed)
+				break
+			}
+			fmt.Fprintf(&b, "  %s, last analyzed %s\n", s.URL, s.CrawledAt.In(loc).Format("2006-01-02"))
+		}
+	}
+
+	b.WriteString("\nChange how often you get this digest, or turn it off, with PUT /api/profile/digest.\n")
+	return b.String()
+}
+
+// plural formats a count with the matching noun
+func plural(n int, one, many string) string {
+	if n == 1 {
+		return "1 " + one
+	}
+	return fmt.Sprintf("%d %s", n, many)
+}
+
+// buildReport gathers the user's activity between since and until. URLs analyzed before
+// until-staleAfter are stale.
+func buildReport(ctx context.Context, userID int, frequency string, since, until time.Time, staleAfter time.Duration) (Report, error) {
+	db := config.DBFor(ctx)
+	r := Report{Frequency: frequency, Since: since, Until: until}
+
+	// Compare each URL crawled in the period with its last completed crawl before it; a first crawl
+	// counts every broken link as new
+	rows, err := db.Query(`
+		SELECT u.url, u.broken_links, (
+			SELECT r.broken_links FROM crawl_runs r
+			WHERE r.url_id = u.id AND r.status = 'completed' AND r.finished_at <= ?
+			ORDER BY r.finished_at DESC LIMIT 1
+		)
+		FROM urls u
+		WHERE u.user_id = ? AND u.status = 'completed' AND u.crawled_at > ? AND u.crawled_at <= ?
+		ORDER BY u.broken_links DESC
+	`, since, userID, since, until)
+	if err != nil {
+		return r, fmt.Errorf("failed to load broken links: %w", err)
+	}
+	for rows.Next() {
+		var c BrokenLinkChange
+		var before sql.NullInt64
+		if err := rows.Scan(&c.URL, &c.After, &before); err != nil {
+			rows.Close()
+			return r, err
+		}
+		c.Before = int(before.Int64)
+		if c.After > c.Before {
+			r.BrokenLinks = append(r.BrokenLinks, c)
+		}
+	}
+	rows.Close()
+
+	rows, err = db.Query(`
+		SELECT COALESCE(u.url, '(deleted URL)'), COALESCE(r.error_message, ''), r.finished_at
+		FROM crawl_runs r
+		LEFT JOIN urls u ON u.id = r.url_id
+		WHERE r.user_id = ? AND r.status = 'error' AND r.finished_at > ? AND r.finished_at <= ?
+		ORDER BY r.finished_at DESC
+	`, userID, since, until)
+	if err != nil {
+		return r, fmt.Errorf("failed to load failed crawls: %w", err)
+	}
+	for rows.Next() {
+		var f FailedCrawl
+		if err := rows.Scan(&f.URL, &f.Error, &f.At); err != nil {
+			rows.Close()
+			return r, err
+		}
+		r.FailedCrawls = append(r.FailedCrawls, f)
+	}
+	rows.Close()
+
+	rows, err = db.Query(`
+		SELECT url, crawled_at FROM urls
+		WHERE user_id = ? AND status = 'completed' AND crawled_at < ?
+		ORDER BY crawled_at
+	`, userID, until.Add(-staleAfter))
+	if err != nil {
+		return r, fmt.Errorf("failed to load stale URLs: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var s StaleURL
+		if err := rows.Scan(&s.URL, &s.CrawledAt); err != nil {
+			return r, err
+		}
+		r.StaleURLs = append(r.StaleURLs, s)
+	}
+	return r, rows.Err()
+}
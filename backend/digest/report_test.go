@@ -0,0 +1,69 @@
+package digest
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReport(t *testing.T) {
+	until := time.Date(2026, 10, 16, 8, 0, 0, 0, time.UTC)
+	since := until.AddDate(0, 0, -1)
+
+	t.Run("empty", func(t *testing.T) {
+		assert.True(t, Report{Frequency: FrequencyDaily, Since: since, Until: until}.Empty())
+	})
+
+	t.Run("lists every section", func(t *testing.T) {
+		r := Report{
+			Frequency:    FrequencyDaily,
+			Since:        since,
+			Until:        until,
+			BrokenLinks:  []BrokenLinkChange{{URL: "https://example.com", Before: 1, After: 4}},
+			FailedCrawls: []FailedCrawl{{URL: "https://down.example", Error: "connection refused", At: since.Add(time.Hour)}},
+			StaleURLs: []StaleURL{
+				{URL: "https://old.example", CrawledAt: until.AddDate(0, -1, 0)},
+				{URL: "https://older.example", CrawledAt: until.AddDate(0, -2, 0)},
+			},
+		}
+		assert.False(t, r.Empty())
+		assert.Equal(t, "Your daily digest: 1 URL with new broken links, 1 failed crawl, 2 stale URLs", r.Subject())
+
+		text := r.Text()
+		assert.Contains(t, text, "Activity from 2026-10-15 08:00 to 2026-10-16 08:00 (UTC)")
+		assert.Contains(t, text, "  https://example.com: 4 broken (was 1)\n")
+		assert.Contains(t, text, "  https://down.example at 2026-10-15 09:00: connection refused\n")
+		assert.Contains(t, text, "Stale URLs (2)")
+		assert.Contains(t, text, "  https://older.example, last analyzed 2026-08-16\n")
+	})
+
+	t.Run("times in the user's time zone", func(t *testing.T) {
+		berlin, err := time.LoadLocation("Europe/Berlin")
+		assert.NoError(t, err)
+		r := Report{
+			Frequency:    FrequencyDaily,
+			Since:        since,
+			Until:        until,
+			FailedCrawls: []FailedCrawl{{URL: "https://down.example", Error: "timeout", At: since.Add(time.Hour)}},
+			Location:     berlin,
+		}
+
+		text := r.Text()
+		assert.Contains(t, text, "Activity from 2026-10-15 10:00 to 2026-10-16 10:00 (Europe/Berlin)")
+		assert.Contains(t, text, "  https://down.example at 2026-10-15 11:00: timeout\n")
+	})
+
+	t.Run("long sections are cut", func(t *testing.T) {
+		r := Report{Frequency: FrequencyWeekly, Since: since, Until: until}
+		for i := 0; i < maxListed+5; i++ {
+			r.StaleURLs = append(r.StaleURLs, StaleURL{URL: fmt.Sprintf("https://example.com/%d", i), CrawledAt: since})
+		}
+
+		text := r.Text()
+		assert.Contains(t, text, "https://example.com/19,")
+		assert.NotContains(t, text, "https://example.com/20,")
+		assert.Contains(t, text, "...and 5 more")
+	})
+}
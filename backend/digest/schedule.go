@@ -0,0 +1,38 @@
+package digest
+
+import "time"
+
+// Frequencies of a user's digest
+const (
+	FrequencyOff    = "off"
+	FrequencyDaily  = "daily"
+	FrequencyWeekly = "weekly"
+)
+
+// period is how much activity one digest covers
+func period(frequency string) time.Duration {
+	if frequency == FrequencyWeekly {
+		return 7 * 24 * time.Hour
+	}
+	return 24 * time.Hour
+}
+
+// isDue reports whether a digest sent at lastSent (nil if never) is due again at now. Digests go out
+// at hour (UTC); a weekly digest waits six more days after the last one.
+func isDue(frequency string, hour int, lastSent *time.Time, now time.Time) bool {
+	if frequency != FrequencyDaily && frequency != FrequencyWeekly {
+		return false
+	}
+	now = now.UTC()
+	slot := time.Date(now.Year(), now.Month(), now.Day(), hour, 0, 0, 0, time.UTC)
+	if slot.After(now) {
+		slot = slot.AddDate(0, 0, -1)
+	}
+	if lastSent == nil {
+		return true
+	}
+	if frequency == FrequencyWeekly {
+		slot = slot.AddDate(0, 0, -6)
+	}
+	return lastSent.Before(slot)
+}
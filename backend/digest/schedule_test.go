@@ -0,0 +1,42 @@
+package digest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsDue(t *testing.T) {
+	now := time.Date(2026, 10, 16, 9, 30, 0, 0, time.UTC)
+	at := func(day, hour int) *time.Time {
+		t := time.Date(2026, 10, day, hour, 0, 0, 0, time.UTC)
+		return &t
+	}
+
+	t.Run("off is never due", func(t *testing.T) {
+		assert.False(t, isDue(FrequencyOff, 8, nil, now))
+	})
+
+	t.Run("first digest goes out right away", func(t *testing.T) {
+		assert.True(t, isDue(FrequencyDaily, 8, nil, now))
+		assert.True(t, isDue(FrequencyWeekly, 23, nil, now))
+	})
+
+	t.Run("daily", func(t *testing.T) {
+		assert.False(t, isDue(FrequencyDaily, 8, at(16, 8), now), "already sent today")
+		assert.True(t, isDue(FrequencyDaily, 8, at(15, 8), now), "sent yesterday")
+		assert.False(t, isDue(FrequencyDaily, 10, at(15, 10), now), "today's hour has not come yet")
+		assert.True(t, isDue(FrequencyDaily, 10, at(14, 10), now), "missed yesterday")
+	})
+
+	t.Run("weekly", func(t *testing.T) {
+		assert.False(t, isDue(FrequencyWeekly, 8, at(10, 8), now), "six days ago")
+		assert.True(t, isDue(FrequencyWeekly, 8, at(9, 8), now), "seven days ago")
+	})
+
+	t.Run("compares in UTC", func(t *testing.T) {
+		berlin := time.FixedZone("CEST", 2*60*60)
+		assert.False(t, isDue(FrequencyDaily, 9, at(16, 9), now.In(berlin)))
+	})
+}
@@ -0,0 +1,192 @@
+// Package dnscache resolves host names for crawls. Answers are cached in memory, and concurrent
+// lookups of one host share a query, so a page linking hundreds of times to the same sites does not
+// resolve them hundreds of times. Queries can go to specific name servers or DNS over HTTPS instead
+// of the system resolver.
+package dnscache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"sykell-analyze/backend/analyzer"
+	"sykell-analyze/backend/config"
+)
+
+// pruneAbove is the cache size at which expired entries are dropped
+const pruneAbove = 10000
+
+// negativeTTL caps how long a host that does not exist stays cached, as it may be registered soon
+const negativeTTL = 30 * time.Second
+
+// lookupTimeout bounds a shared lookup, whatever its callers' deadlines
+const lookupTimeout = 30 * time.Second
+
+var defaultResolver *Resolver
+
+// Configure sets up the resolver crawls use
+func Configure(cfg config.DNSConfig) {
+	upstream := net.DefaultResolver
+	switch {
+	case cfg.DoHURL != "":
+		upstream = &net.Resolver{PreferGo: true, Dial: dohDialer(cfg.DoHURL, cfg.Timeout)}
+		fmt.Printf("✅ DNS over HTTPS via %s\n", cfg.DoHURL)
+	case len(cfg.Servers) > 0:
+		upstream = &net.Resolver{PreferGo: true, Dial: serversDialer(cfg.Servers, cfg.Timeout)}
+		fmt.Printf("✅ DNS name servers: %s\n", strings.Join(cfg.Servers, ", "))
+	}
+	if cfg.CacheTTL <= 0 && upstream == net.DefaultResolver {
+		defaultResolver = nil
+		return
+	}
+	defaultResolver = New(upstream, cfg.CacheTTL)
+}
+
+// Default returns the configured resolver, or nil to use the system resolver uncached
+func Default() analyzer.Resolver {
+	if defaultResolver == nil {
+		return nil
+	}
+	return defaultResolver
+}
+
+// Resolver caches the addresses upstream resolves; other record types pass through. It implements
+// analyzer.Resolver and is safe for concurrent use.
+type Resolver struct {
+	upstream analyzer.Resolver
+	ttl      time.Duration
+
+	mu       sync.Mutex
+	cache    map[string]cacheEntry
+	inflight map[string]*lookup
+}
+
+// cacheEntry is a cached answer; err is set for hosts that do not exist
+type cacheEntry struct {
+	addrs   []net.IPAddr
+	err     error
+	expires time.Time
+}
+
+// lookup is a query in progress that later callers for the same host wait for
+type lookup struct {
+	done  chan struct{}
+	addrs []net.IPAddr
+	err   error
+}
+
+// New creates a resolver caching the answers of upstream for ttl (0 disables the cache)
+func New(upstream analyzer.Resolver, ttl time.Duration) *Resolver {
+	return &Resolver{
+		upstream: upstream,
+		ttl:      ttl,
+		cache:    make(map[string]cacheEntry),
+		inflight: make(map[string]*lookup),
+	}
+}
+
+// LookupIPAddr returns the addresses of host from the cache, or from upstream
+func (r *Resolver) LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error) {
+	key := strings.TrimSuffix(strings.ToLower(host), ".")
+	now := time.Now()
+
+	r.mu.Lock()
+	if entry, ok := r.cache[key]; ok && now.Before(entry.expires) {
+		r.mu.Unlock()
+		return copyAddrs(entry.addrs), entry.err
+	}
+	l, ok := r.inflight[key]
+	if !ok {
+		l = &lookup{done: make(chan struct{})}
+		r.inflight[key] = l
+		go r.resolve(key, l)
+	}
+	r.mu.Unlock()
+
+	select {
+	case <-l.done:
+		return copyAddrs(l.addrs), l.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// resolve queries upstream for a lookup shared by every caller waiting on it. It runs detached from
+// the callers' contexts, so one caller giving up does not fail the others.
+func (r *Resolver) resolve(key string, l *lookup) {
+	ctx, cancel := context.WithTimeout(context.Background(), lookupTimeout)
+	l.addrs, l.err = r.upstream.LookupIPAddr(ctx, key)
+	cancel()
+
+	r.mu.Lock()
+	delete(r.inflight, key)
+	if ttl := r.cacheFor(l.err); ttl > 0 {
+		now := time.Now()
+		if len(r.cache) > pruneAbove {
+			for k, entry := range r.cache {
+				if !now.Before(entry.expires) {
+					delete(r.cache, k)
+				}
+			}
+		}
+		r.cache[key] = cacheEntry{addrs: l.addrs, err: l.err, expires: now.Add(ttl)}
+	}
+	r.mu.Unlock()
+	close(l.done)
+}
+
+// cacheFor is how long an answer is cached: addresses for the TTL, hosts that do not exist briefly,
+// and failures such as timeouts not at all
+func (r *Resolver) cacheFor(err error) time.Duration {
+	if err == nil {
+		return r.ttl
+	}
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) && dnsErr.IsNotFound {
+		return min(r.ttl, negativeTTL)
+	}
+	return 0
+}
+
+// LookupCNAME, LookupMX and LookupTXT are asked once per crawl and are not cached
+func (r *Resolver) LookupCNAME(ctx context.Context, host string) (string, error) {
+	return r.upstream.LookupCNAME(ctx, host)
+}
+
+func (r *Resolver) LookupMX(ctx context.Context, name string) ([]*net.MX, error) {
+	return r.upstream.LookupMX(ctx, name)
+}
+
+func (r *Resolver) LookupTXT(ctx context.Context, name string) ([]string, error) {
+	return r.upstream.LookupTXT(ctx, name)
+}
+
+// copyAddrs returns a copy so callers can reorder or change the addresses without touching the cache
+func copyAddrs(addrs []net.IPAddr) []net.IPAddr {
+	if addrs == nil {
+		return nil
+	}
+	return append([]net.IPAddr(nil), addrs...)
+}
+
+// serversDialer connects the resolver to servers in turn instead of the name servers of
+// /etc/resolv.conf
+func serversDialer(servers []string, timeout time.Duration) func(ctx context.Context, network, address string) (net.Conn, error) {
+	var mu sync.Mutex
+	next := 0
+	d := net.Dialer{Timeout: timeout}
+	return func(ctx context.Context, network, _ string) (net.Conn, error) {
+		mu.Lock()
+		server := servers[next%len(servers)]
+		next++
+		mu.Unlock()
+		if _, _, err := net.SplitHostPort(server); err != nil {
+			server = net.JoinHostPort(server, "53")
+		}
+		return d.DialContext(ctx, network, server)
+	}
+}
@@ -0,0 +1,218 @@
+package dnscache
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"sykell-analyze/backend/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// countingResolver answers every host with one address, or err, and counts the lookups
+type countingResolver struct {
+	net.Resolver
+	lookups atomic.Int32
+	release chan struct{}
+	err     error
+}
+
+func (r *countingResolver) LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error) {
+	r.lookups.Add(1)
+	if r.release != nil {
+		<-r.release
+	}
+	if r.err != nil {
+		return nil, r.err
+	}
+	return []net.IPAddr{{IP: net.ParseIP("93.184.216.34")}}, nil
+}
+
+func TestResolver(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("answers are cached", func(t *testing.T) {
+		upstream := &countingResolver{}
+		r := New(upstream, time.Minute)
+		for i := 0; i < 3; i++ {
+			addrs, err := r.LookupIPAddr(ctx, "Example.com.")
+			require.NoError(t, err)
+			assert.Equal(t, "93.184.216.34", addrs[0].IP.String())
+		}
+		_, err := r.LookupIPAddr(ctx, "example.com")
+		require.NoError(t, err)
+		assert.EqualValues(t, 1, upstream.lookups.Load(), "host names are compared without case and trailing dot")
+	})
+
+	t.Run("callers cannot change the cache", func(t *testing.T) {
+		r := New(&countingResolver{}, time.Minute)
+		addrs, _ := r.LookupIPAddr(ctx, "example.com")
+		addrs[0] = net.IPAddr{IP: net.ParseIP("10.0.0.1")}
+		again, _ := r.LookupIPAddr(ctx, "example.com")
+		assert.Equal(t, "93.184.216.34", again[0].IP.String())
+	})
+
+	t.Run("concurrent lookups share a query", func(t *testing.T) {
+		upstream := &countingResolver{release: make(chan struct{})}
+		r := New(upstream, 0)
+		var wg sync.WaitGroup
+		for i := 0; i < 20; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, err := r.LookupIPAddr(ctx, "example.com")
+				assert.NoError(t, err)
+			}()
+		}
+		require.Eventually(t, func() bool { return upstream.lookups.Load() == 1 }, time.Second, time.Millisecond)
+		time.Sleep(10 * time.Millisecond)
+		close(upstream.release)
+		wg.Wait()
+		assert.EqualValues(t, 1, upstream.lookups.Load())
+
+		// Without a TTL nothing is kept once the query is answered
+		_, err := r.LookupIPAddr(ctx, "example.com")
+		require.NoError(t, err)
+		assert.EqualValues(t, 2, upstream.lookups.Load())
+	})
+
+	t.Run("a caller giving up does not fail the others", func(t *testing.T) {
+		upstream := &countingResolver{release: make(chan struct{})}
+		r := New(upstream, time.Minute)
+		canceled, cancel := context.WithCancel(ctx)
+		cancel()
+		_, err := r.LookupIPAddr(canceled, "example.com")
+		assert.ErrorIs(t, err, context.Canceled)
+
+		close(upstream.release)
+		addrs, err := r.LookupIPAddr(ctx, "example.com")
+		require.NoError(t, err)
+		assert.Len(t, addrs, 1)
+		assert.EqualValues(t, 1, upstream.lookups.Load())
+	})
+
+	t.Run("hosts that do not exist are cached briefly", func(t *testing.T) {
+		upstream := &countingResolver{err: &net.DNSError{Err: "no such host", Name: "nope.example", IsNotFound: true}}
+		r := New(upstream, time.Hour)
+		_, err := r.LookupIPAddr(ctx, "nope.example")
+		assert.ErrorContains(t, err, "no such host")
+		_, err = r.LookupIPAddr(ctx, "nope.example")
+		assert.ErrorContains(t, err, "no such host")
+		assert.EqualValues(t, 1, upstream.lookups.Load())
+		assert.Equal(t, negativeTTL, r.cacheFor(upstream.err))
+	})
+
+	t.Run("failures are not cached", func(t *testing.T) {
+		upstream := &countingResolver{err: &net.DNSError{Err: "i/o timeout", Name: "slow.example", IsTimeout: true}}
+		r := New(upstream, time.Hour)
+		r.LookupIPAddr(ctx, "slow.example")
+		r.LookupIPAddr(ctx, "slow.example")
+		assert.EqualValues(t, 2, upstream.lookups.Load())
+	})
+
+	t.Run("expired answers are looked up again", func(t *testing.T) {
+		upstream := &countingResolver{}
+		r := New(upstream, time.Minute)
+		r.LookupIPAddr(ctx, "example.com")
+		r.mu.Lock()
+		entry := r.cache["example.com"]
+		entry.expires = time.Now().Add(-time.Second)
+		r.cache["example.com"] = entry
+		r.mu.Unlock()
+		r.LookupIPAddr(ctx, "example.com")
+		assert.EqualValues(t, 2, upstream.lookups.Load())
+	})
+}
+
+func TestConfigure(t *testing.T) {
+	defer func() { defaultResolver = nil }()
+
+	Configure(config.DNSConfig{Timeout: time.Second})
+	assert.Nil(t, Default(), "the system resolver is used as is")
+
+	Configure(config.DNSConfig{CacheTTL: time.Minute, Timeout: time.Second})
+	assert.NotNil(t, Default())
+
+	Configure(config.DNSConfig{Servers: []string{"192.0.2.53"}, Timeout: time.Second})
+	assert.NotNil(t, Default(), "custom name servers without a cache")
+}
+
+func TestDoH(t *testing.T) {
+	var queries atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "application/dns-message", r.Header.Get("Content-Type"))
+		body, _ := io.ReadAll(r.Body)
+		var query dnsmessage.Message
+		if err := query.Unpack(body); err != nil || len(query.Questions) != 1 {
+			http.Error(w, "bad query", http.StatusBadRequest)
+			return
+		}
+		queries.Add(1)
+
+		question := query.Questions[0]
+		answer := dnsmessage.Message{
+			Header:    dnsmessage.Header{ID: query.ID, Response: true, Authoritative: true, RecursionAvailable: true},
+			Questions: query.Questions,
+		}
+		switch {
+		case question.Name.String() != "doh-test.example.":
+			answer.RCode = dnsmessage.RCodeNameError
+		case question.Type == dnsmessage.TypeA:
+			answer.Answers = []dnsmessage.Resource{{
+				Header: dnsmessage.ResourceHeader{Name: question.Name, Type: dnsmessage.TypeA, Class: dnsmessage.ClassINET, TTL: 60},
+				Body:   &dnsmessage.AResource{A: [4]byte{203, 0, 113, 7}},
+			}}
+		case question.Type == dnsmessage.TypeAAAA:
+			answer.Answers = []dnsmessage.Resource{{
+				Header: dnsmessage.ResourceHeader{Name: question.Name, Type: dnsmessage.TypeAAAA, Class: dnsmessage.ClassINET, TTL: 60},
+				Body:   &dnsmessage.AAAAResource{AAAA: [16]byte{0x20, 0x01, 0x0d, 0xb8, 15: 7}},
+			}}
+		}
+		packed, err := answer.Pack()
+		require.NoError(t, err)
+		w.Header().Set("Content-Type", "application/dns-message")
+		w.Write(packed)
+	}))
+	defer server.Close()
+
+	resolver := &net.Resolver{PreferGo: true, Dial: dohDialer(server.URL, 5*time.Second)}
+
+	t.Run("addresses", func(t *testing.T) {
+		addrs, err := resolver.LookupIPAddr(context.Background(), "doh-test.example")
+		require.NoError(t, err)
+		var found []string
+		for _, addr := range addrs {
+			found = append(found, addr.IP.String())
+		}
+		assert.ElementsMatch(t, []string{"203.0.113.7", "2001:db8::7"}, found)
+		assert.GreaterOrEqual(t, queries.Load(), int32(2))
+	})
+
+	t.Run("host that does not exist", func(t *testing.T) {
+		_, err := resolver.LookupIPAddr(context.Background(), "missing.example")
+		var dnsErr *net.DNSError
+		require.True(t, errors.As(err, &dnsErr), "%v", err)
+		assert.True(t, dnsErr.IsNotFound)
+	})
+
+	t.Run("endpoint errors fail the lookup", func(t *testing.T) {
+		broken := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "down", http.StatusServiceUnavailable)
+		}))
+		defer broken.Close()
+		resolver := &net.Resolver{PreferGo: true, Dial: dohDialer(broken.URL, 5*time.Second)}
+		_, err := resolver.LookupIPAddr(context.Background(), "doh-test.example")
+		assert.Error(t, err)
+	})
+}
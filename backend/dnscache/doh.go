@@ -0,0 +1,137 @@
+package dnscache
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// maxDoHResponse bounds a DNS over HTTPS answer; a DNS message cannot be larger
+const maxDoHResponse = 65535
+
+// dohDialer connects Go's resolver to a DNS over HTTPS endpoint (RFC 8484) instead of a name server.
+// The resolver speaks DNS over TCP to the returned connection: each query is framed with a two-byte
+// length, sent as an application/dns-message POST, and the answer framed the same way.
+func dohDialer(endpoint string, timeout time.Duration) func(ctx context.Context, network, address string) (net.Conn, error) {
+	client := &http.Client{Timeout: timeout}
+	return func(ctx context.Context, _, _ string) (net.Conn, error) {
+		return &dohConn{ctx: ctx, client: client, endpoint: endpoint}, nil
+	}
+}
+
+// dohConn is a net.Conn turning DNS over TCP messages into DNS over HTTPS requests. It is not a
+// net.PacketConn, so the resolver uses TCP framing.
+type dohConn struct {
+	ctx      context.Context
+	client   *http.Client
+	endpoint string
+
+	mu       sync.Mutex
+	query    bytes.Buffer
+	answer   bytes.Buffer
+	deadline time.Time
+	closed   bool
+}
+
+// Write collects a length-framed query and sends it once complete
+func (c *dohConn) Write(b []byte) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return 0, net.ErrClosed
+	}
+	c.query.Write(b)
+	for c.query.Len() >= 2 {
+		size := int(binary.BigEndian.Uint16(c.query.Bytes()))
+		if c.query.Len() < 2+size {
+			break
+		}
+		frame := c.query.Next(2 + size)
+		answer, err := c.exchange(frame[2:])
+		if err != nil {
+			return 0, err
+		}
+		binary.Write(&c.answer, binary.BigEndian, uint16(len(answer)))
+		c.answer.Write(answer)
+	}
+	return len(b), nil
+}
+
+// Read returns the framed answers
+func (c *dohConn) Read(b []byte) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.answer.Len() == 0 {
+		if c.closed {
+			return 0, net.ErrClosed
+		}
+		return 0, io.EOF
+	}
+	return c.answer.Read(b)
+}
+
+// exchange posts one DNS message and returns the answer
+func (c *dohConn) exchange(message []byte) ([]byte, error) {
+	ctx := c.ctx
+	if !c.deadline.IsZero() {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithDeadline(ctx, c.deadline)
+		defer cancel()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.endpoint, bytes.NewReader(message))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/dns-message")
+	req.Header.Set("Accept", "application/dns-message")
+
+	res, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("dns over https: %w", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		io.Copy(io.Discard, io.LimitReader(res.Body, 64<<10))
+		return nil, fmt.Errorf("dns over https: %s", res.Status)
+	}
+	answer, err := io.ReadAll(io.LimitReader(res.Body, maxDoHResponse+1))
+	if err != nil {
+		return nil, fmt.Errorf("dns over https: %w", err)
+	}
+	if len(answer) > maxDoHResponse {
+		return nil, errors.New("dns over https: answer too large")
+	}
+	return answer, nil
+}
+
+func (c *dohConn) Close() error {
+	c.mu.Lock()
+	c.closed = true
+	c.mu.Unlock()
+	return nil
+}
+
+func (c *dohConn) SetDeadline(t time.Time) error {
+	c.mu.Lock()
+	c.deadline = t
+	c.mu.Unlock()
+	return nil
+}
+
+func (c *dohConn) SetReadDeadline(t time.Time) error  { return nil }
+func (c *dohConn) SetWriteDeadline(t time.Time) error { return c.SetDeadline(t) }
+func (c *dohConn) LocalAddr() net.Addr                { return dohAddr{} }
+func (c *dohConn) RemoteAddr() net.Addr               { return dohAddr{} }
+
+// dohAddr is the address of a dohConn
+type dohAddr struct{}
+
+func (dohAddr) Network() string { return "https" }
+func (dohAddr) String() string  { return "dns-over-https" }
@@ -0,0 +1,174 @@
+// Package geoip maps server addresses to the autonomous system announcing them and its country,
+// using the free ip2asn database from https://iptoasn.com loaded into memory.
+package geoip
+
+import (
+	"bufio"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"net/netip"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"sykell-analyze/backend/analyzer"
+	"sykell-analyze/backend/config"
+)
+
+// Default is nil when no database is configured (geoip.database unset)
+var Default *Database
+
+// Configure loads the configured database; an unreadable database is an error rather than silently
+// disabling lookups
+func Configure(cfg config.GeoIPConfig) error {
+	if cfg.Database == "" {
+		Default = nil
+		return nil
+	}
+	db, err := Open(cfg.Database)
+	if err != nil {
+		return err
+	}
+	Default = db
+	fmt.Printf("✅ GeoIP database loaded (%d ranges).\n", db.Len())
+	return nil
+}
+
+// providers are friendly names of well-known hosting and CDN networks, by ASN
+var providers = map[uint32]string{
+	13335:  "Cloudflare",
+	16509:  "Amazon Web Services",
+	14618:  "Amazon Web Services",
+	15169:  "Google Cloud",
+	396982: "Google Cloud",
+	8075:   "Microsoft Azure",
+	54113:  "Fastly",
+	20940:  "Akamai",
+	63949:  "Akamai Connected Cloud (Linode)",
+	24940:  "Hetzner",
+	16276:  "OVHcloud",
+	14061:  "DigitalOcean",
+	20473:  "Vultr",
+}
+
+// ipRange is a block of addresses announced by one autonomous system
+type ipRange struct {
+	start, end netip.Addr
+	asn        uint32
+	country    string
+	name       string
+}
+
+// Database is an in-memory ip2asn table. It is read-only after loading and safe for concurrent use.
+type Database struct {
+	ranges []ipRange // sorted by start, not overlapping
+}
+
+// Open loads an ip2asn TSV file (ip2asn-combined, -v4 or -v6); paths ending in .gz are decompressed
+func Open(path string) (*Database, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open GeoIP database: %w", err)
+	}
+	defer f.Close()
+
+	var r io.Reader = f
+	if strings.HasSuffix(path, ".gz") {
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decompress GeoIP database: %w", err)
+		}
+		defer gz.Close()
+		r = gz
+	}
+
+	db, err := Parse(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load GeoIP database %s: %w", path, err)
+	}
+	return db, nil
+}
+
+// Parse reads ip2asn TSV rows: range start, range end, AS number, country code and AS description.
+// Unrouted ranges (AS 0) are skipped.
+func Parse(r io.Reader) (*Database, error) {
+	db := &Database{}
+	names := make(map[string]string) // interned AS descriptions, shared by an AS's many ranges
+
+	scanner := bufio.NewScanner(r)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := scanner.Text()
+		if text == "" {
+			continue
+		}
+		fields := strings.Split(text, "\t")
+		if len(fields) < 5 {
+			return nil, fmt.Errorf("line %d: expected 5 tab-separated fields, got %d", line, len(fields))
+		}
+		asn, err := strconv.ParseUint(fields[2], 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid AS number %q", line, fields[2])
+		}
+		if asn == 0 {
+			continue
+		}
+		start, err := netip.ParseAddr(fields[0])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid range start: %w", line, err)
+		}
+		end, err := netip.ParseAddr(fields[1])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid range end: %w", line, err)
+		}
+		if start.Is4() != end.Is4() || end.Less(start) {
+			return nil, fmt.Errorf("line %d: invalid range %s-%s", line, start, end)
+		}
+
+		name, ok := names[fields[4]]
+		if !ok {
+			name = fields[4]
+			names[name] = name
+		}
+		country := fields[3]
+		if country == "None" {
+			country = ""
+		}
+		db.ranges = append(db.ranges, ipRange{start: start, end: end, asn: uint32(asn), country: country, name: name})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	// The published files are sorted, but hand-made ones may not be
+	sort.Slice(db.ranges, func(i, j int) bool { return db.ranges[i].start.Less(db.ranges[j].start) })
+	return db, nil
+}
+
+// Len returns the number of routed ranges in the database
+func (db *Database) Len() int {
+	return len(db.ranges)
+}
+
+// LocateIP returns the autonomous system announcing ip. It implements analyzer.IPLocator.
+func (db *Database) LocateIP(ip netip.Addr) (analyzer.IPNetwork, bool) {
+	ip = ip.Unmap().WithZone("")
+	// First range starting after ip; the candidate is the one before it
+	i := sort.Search(len(db.ranges), func(i int) bool { return ip.Less(db.ranges[i].start) })
+	if i == 0 {
+		return analyzer.IPNetwork{}, false
+	}
+	r := db.ranges[i-1]
+	if r.start.Is4() != ip.Is4() || r.end.Less(ip) {
+		return analyzer.IPNetwork{}, false
+	}
+
+	provider, ok := providers[r.asn]
+	if !ok {
+		provider = r.name
+	}
+	return analyzer.IPNetwork{ASN: r.asn, ASName: r.name, Provider: provider, Country: r.country}, true
+}
@@ -0,0 +1,103 @@
+package geoip
+
+import (
+	"compress/gzip"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"sykell-analyze/backend/config"
+)
+
+const sample = "1.0.0.0\t1.0.0.255\t13335\tUS\tCLOUDFLARENET\n" +
+	"1.0.1.0\t1.0.3.255\t0\tNone\tNot routed\n" +
+	"5.9.0.0\t5.9.255.255\t24940\tDE\tHETZNER-AS\n" +
+	"2a01:4f8::\t2a01:4f8:ffff:ffff:ffff:ffff:ffff:ffff\t24940\tDE\tHETZNER-AS\n" +
+	"203.0.113.0\t203.0.113.255\t64500\tNL\tEXAMPLE-HOSTING\n"
+
+func TestLocateIP(t *testing.T) {
+	db, err := Parse(strings.NewReader(sample))
+	require.NoError(t, err)
+	assert.Equal(t, 4, db.Len())
+
+	t.Run("well-known networks get a friendly provider name", func(t *testing.T) {
+		network, ok := db.LocateIP(netip.MustParseAddr("1.0.0.1"))
+		require.True(t, ok)
+		assert.Equal(t, uint32(13335), network.ASN)
+		assert.Equal(t, "CLOUDFLARENET", network.ASName)
+		assert.Equal(t, "Cloudflare", network.Provider)
+		assert.Equal(t, "US", network.Country)
+	})
+
+	t.Run("other networks use the AS name", func(t *testing.T) {
+		network, ok := db.LocateIP(netip.MustParseAddr("203.0.113.7"))
+		require.True(t, ok)
+		assert.Equal(t, "EXAMPLE-HOSTING", network.Provider)
+		assert.Equal(t, "NL", network.Country)
+	})
+
+	t.Run("IPv6 and IPv4-mapped addresses", func(t *testing.T) {
+		network, ok := db.LocateIP(netip.MustParseAddr("2a01:4f8:10::1"))
+		require.True(t, ok)
+		assert.Equal(t, "Hetzner", network.Provider)
+
+		network, ok = db.LocateIP(netip.MustParseAddr("::ffff:5.9.1.1"))
+		require.True(t, ok)
+		assert.Equal(t, uint32(24940), network.ASN)
+	})
+
+	t.Run("unrouted and uncovered addresses are not found", func(t *testing.T) {
+		for _, ip := range []string{"1.0.2.1", "0.0.0.1", "9.9.9.9", "255.255.255.255", "::1"} {
+			_, ok := db.LocateIP(netip.MustParseAddr(ip))
+			assert.False(t, ok, ip)
+		}
+	})
+}
+
+func TestParse(t *testing.T) {
+	t.Run("rejects malformed rows", func(t *testing.T) {
+		for _, row := range []string{
+			"1.0.0.0\t1.0.0.255\t13335\tUS",
+			"1.0.0.0\t1.0.0.255\tAS13335\tUS\tCLOUDFLARENET",
+			"1.0.0.0\tnope\t13335\tUS\tCLOUDFLARENET",
+			"1.0.0.255\t1.0.0.0\t13335\tUS\tCLOUDFLARENET",
+		} {
+			_, err := Parse(strings.NewReader(row + "\n"))
+			assert.Error(t, err, row)
+		}
+	})
+}
+
+func TestConfigure(t *testing.T) {
+	defer func() { Default = nil }()
+
+	t.Run("no database disables lookups", func(t *testing.T) {
+		require.NoError(t, Configure(config.GeoIPConfig{}))
+		assert.Nil(t, Default)
+	})
+
+	t.Run("loads gzipped databases", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "ip2asn-combined.tsv.gz")
+		f, err := os.Create(path)
+		require.NoError(t, err)
+		gz := gzip.NewWriter(f)
+		_, err = gz.Write([]byte(sample))
+		require.NoError(t, err)
+		require.NoError(t, gz.Close())
+		require.NoError(t, f.Close())
+
+		require.NoError(t, Configure(config.GeoIPConfig{Database: path}))
+		require.NotNil(t, Default)
+		assert.Equal(t, 4, Default.Len())
+	})
+
+	t.Run("a missing database is an error", func(t *testing.T) {
+		err := Configure(config.GeoIPConfig{Database: filepath.Join(t.TempDir(), "missing.tsv")})
+		assert.Error(t, err)
+	})
+}
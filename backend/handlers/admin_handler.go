@@ -0,0 +1,386 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/pprof"
+	"strconv"
+	"strings"
+
+	"sykell-analyze/backend/analyzer"
+	"sykell-analyze/backend/cache"
+	"sykell-analyze/backend/config"
+	"sykell-analyze/backend/dbmetrics"
+	"sykell-analyze/backend/urlstatus"
+	"sykell-analyze/backend/worker"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetJobPriority lets an administrator move a waiting or stuck crawl job up or down the queue
+func SetJobPriority(c *gin.Context) {
+	jobID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid job ID",
+		})
+		return
+	}
+
+	var req struct {
+		Priority string `json:"priority" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request format",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	priority, err := worker.ParsePriority(req.Priority)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	err = worker.SetJobPriority(c.Request.Context(), jobID, priority)
+	switch {
+	case errors.Is(err, worker.ErrJobNotFound):
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Job not found",
+		})
+		return
+	case errors.Is(err, worker.ErrJobFinished):
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "Job has already finished",
+		})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to update job priority",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":  "Job priority updated",
+		"id":       jobID,
+		"priority": priority.String(),
+	})
+}
+
+// SetUserCrawlLimit sets how many of a user's crawls may run at once, e.g. for a plan with a larger quota.
+// null restores the configured default and 0 removes the limit.
+func SetUserCrawlLimit(c *gin.Context) {
+	userID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid user ID",
+		})
+		return
+	}
+
+	var req struct {
+		MaxConcurrentCrawls *int `json:"max_concurrent_crawls"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request format",
+			"details": err.Error(),
+		})
+		return
+	}
+	if req.MaxConcurrentCrawls != nil && *req.MaxConcurrentCrawls < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "max_concurrent_crawls must not be negative",
+		})
+		return
+	}
+
+	result, err := requestDB(c).Exec("UPDATE users SET max_concurrent_crawls = ? WHERE id = ?", req.MaxConcurrentCrawls, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to update crawl limit",
+			"details": err.Error(),
+		})
+		return
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		// MySQL reports 0 rows when the value did not change, so check the user exists
+		var exists bool
+		requestDB(c).QueryRow("SELECT EXISTS(SELECT 1 FROM users WHERE id = ?)", userID).Scan(&exists)
+		if !exists {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "User not found",
+			})
+			return
+		}
+	}
+
+	effective := config.App.Worker.MaxCrawlsPerUser
+	if req.MaxConcurrentCrawls != nil {
+		effective = *req.MaxConcurrentCrawls
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":               "Crawl limit updated",
+		"id":                    userID,
+		"max_concurrent_crawls": req.MaxConcurrentCrawls,
+		"effective_limit":       effective,
+	})
+}
+
+// SetUserRetention overrides how many days a user's crawl history is kept, e.g. for a plan with a
+// longer history. It wins over both the server's limit and the user's own setting; null removes the
+// override and 0 keeps records forever.
+func SetUserRetention(c *gin.Context) {
+	userID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid user ID",
+		})
+		return
+	}
+
+	var req struct {
+		OverrideDays *int `json:"override_days"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request format",
+			"details": err.Error(),
+		})
+		return
+	}
+	if req.OverrideDays != nil && *req.OverrideDays < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "override_days must not be negative",
+		})
+		return
+	}
+
+	_, err = requestDB(c).Exec("UPDATE users SET retention_override_days = ? WHERE id = ?", req.OverrideDays, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to update retention",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	settings, err := loadRetentionSettings(c.Request.Context(), userID)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "User not found",
+		})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Database error",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Retention updated",
+		"id":      userID,
+		"data":    settings,
+	})
+}
+
+// ReloadConfig applies the tunable settings of the config file and environment to this process
+// without a restart. Other API and worker processes reload on SIGHUP.
+func ReloadConfig(c *gin.Context) {
+	changed, err := config.Reload(config.FilePath())
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid configuration, the current settings are kept",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	fmt.Printf("DEBUG: Configuration reloaded by an administrator, changed: %v\n", changed)
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Configuration reloaded",
+		"changed": changed,
+	})
+}
+
+// Backfills queue backfillLimit URLs per call unless ?limit= says otherwise, up to maxBackfillLimit
+const (
+	backfillLimit    = 500
+	maxBackfillLimit = 5000
+)
+
+// BackfillResults re-queues completed URLs whose analysis an older analyzer stored, so they gain
+// what analyzers added since report. The oldest versions go first, demo accounts are skipped and the
+// jobs get low priority unless ?priority= says otherwise. Call it again until nothing remains.
+func BackfillResults(c *gin.Context) {
+	limit := backfillLimit
+	if value := c.Query("limit"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 || n > maxBackfillLimit {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": fmt.Sprintf("limit must be between 1 and %d", maxBackfillLimit),
+			})
+			return
+		}
+		limit = n
+	}
+
+	opts, err := enqueueOptions(c, "")
+	if err != nil {
+		respondInvalidOptions(c, err)
+		return
+	}
+	if c.Query("priority") == "" {
+		opts.Priority = worker.PriorityLow
+	}
+
+	var queued []int
+	var changes []urlstatus.Change
+	owners := make(map[int]bool)
+
+	err = config.WithTransaction(c.Request.Context(), func(tx *sql.Tx) error {
+		queued, changes = nil, nil
+		clear(owners)
+
+		rows, err := tx.Query(`
+			SELECT u.id, u.user_id FROM urls u
+			JOIN users owner ON owner.id = u.user_id
+			WHERE u.status = 'completed' AND u.result_schema_version < ? AND NOT owner.is_demo
+			ORDER BY u.result_schema_version, u.crawled_at
+			LIMIT ?
+			FOR UPDATE
+		`, analyzer.SchemaVersion, limit)
+		if err != nil {
+			return err
+		}
+		for rows.Next() {
+			var id, userID int
+			if err := rows.Scan(&id, &userID); err != nil {
+				rows.Close()
+				return err
+			}
+			queued = append(queued, id)
+			owners[userID] = true
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+
+		for _, id := range queued {
+			// Previous results stay visible until the new crawl replaces them
+			change, err := urlstatus.Transition(tx, urlstatus.Request{UrlID: id, To: urlstatus.Queued})
+			if err != nil {
+				return err
+			}
+			changes = append(changes, change)
+			if err := worker.EnqueueTx(tx, id, opts); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to queue outdated URLs",
+			"details": err.Error(),
+		})
+		return
+	}
+	urlstatus.Publish(c.Request.Context(), changes...)
+	if cache.Enabled() {
+		for userID := range owners {
+			cache.InvalidateUser(c.Request.Context(), userID)
+		}
+	}
+
+	var remaining int
+	err = requestDB(c).QueryRow(`
+		SELECT COUNT(*) FROM urls u
+		JOIN users owner ON owner.id = u.user_id
+		WHERE u.status = 'completed' AND u.result_schema_version < ? AND NOT owner.is_demo
+	`, analyzer.SchemaVersion).Scan(&remaining)
+	if err != nil {
+		fmt.Printf("DEBUG: Failed to count outdated URLs: %v\n", err)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":        "Outdated URLs queued for reanalysis",
+		"queued_count":   len(queued),
+		"remaining":      remaining,
+		"schema_version": analyzer.SchemaVersion,
+	})
+}
+
+// GetMetrics reports the database connection pools and the queries this process sent. Wait counts
+// growing between two calls mean requests queue for a connection and database.max_open_conns is
+// too low.
+func GetMetrics(c *gin.Context) {
+	database := gin.H{
+		"queries":                 dbmetrics.Snapshot(),
+		"slow_query_threshold_ms": config.App.Database.SlowQueryThreshold.Milliseconds(),
+	}
+	if requestDB(c) != nil {
+		database["pool"] = poolStats(requestDB(c).Stats())
+	}
+
+	replicas := []gin.H{}
+	for _, r := range config.Replicas() {
+		replicas = append(replicas, gin.H{
+			"addr":    r.Addr,
+			"healthy": r.Healthy,
+			"pool":    poolStats(r.Pool),
+		})
+	}
+	database["replicas"] = replicas
+
+	c.JSON(http.StatusOK, gin.H{
+		"database": database,
+	})
+}
+
+// poolStats picks the connection pool numbers worth watching
+func poolStats(stats sql.DBStats) gin.H {
+	return gin.H{
+		"max_open":             stats.MaxOpenConnections,
+		"open":                 stats.OpenConnections,
+		"in_use":               stats.InUse,
+		"idle":                 stats.Idle,
+		"wait_count":           stats.WaitCount,
+		"wait_duration_ms":     stats.WaitDuration.Milliseconds(),
+		"max_idle_closed":      stats.MaxIdleClosed,
+		"max_idle_time_closed": stats.MaxIdleTimeClosed,
+		"max_lifetime_closed":  stats.MaxLifetimeClosed,
+	}
+}
+
+// Pprof serves the net/http/pprof profiles of this process at /api/admin/debug/pprof/, e.g.
+// profile?seconds=20 for CPU or heap for memory. The route only exists with server.pprof enabled.
+func Pprof(c *gin.Context) {
+	switch name := strings.Trim(c.Param("profile"), "/"); name {
+	case "":
+		pprof.Index(c.Writer, c.Request)
+	case "cmdline":
+		pprof.Cmdline(c.Writer, c.Request)
+	case "profile":
+		pprof.Profile(c.Writer, c.Request)
+	case "symbol":
+		pprof.Symbol(c.Writer, c.Request)
+	case "trace":
+		pprof.Trace(c.Writer, c.Request)
+	default:
+		// Index only finds profiles under /debug/pprof/, so named ones are served directly
+		pprof.Handler(name).ServeHTTP(c.Writer, c.Request)
+	}
+}
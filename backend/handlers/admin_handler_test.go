@@ -0,0 +1,202 @@
+package handlers
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"sykell-analyze/backend/config"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetJobPriority(t *testing.T) {
+	router := setupTestRouter()
+	router.PUT("/admin/jobs/:id/priority", SetJobPriority)
+
+	put := func(path, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodPut, path, bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("invalid job ID", func(t *testing.T) {
+		w := put("/admin/jobs/abc/priority", `{"priority": "high"}`)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("missing priority", func(t *testing.T) {
+		w := put("/admin/jobs/1/priority", `{}`)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("unknown priority", func(t *testing.T) {
+		w := put("/admin/jobs/1/priority", `{"priority": "urgent"}`)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "high, normal or low")
+	})
+}
+
+func TestSetUserCrawlLimit(t *testing.T) {
+	router := setupTestRouter()
+	router.PUT("/admin/users/:id/crawl-limit", SetUserCrawlLimit)
+
+	put := func(path, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodPut, path, bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("invalid user ID", func(t *testing.T) {
+		w := put("/admin/users/abc/crawl-limit", `{"max_concurrent_crawls": 5}`)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("negative limit", func(t *testing.T) {
+		w := put("/admin/users/1/crawl-limit", `{"max_concurrent_crawls": -1}`)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("malformed body", func(t *testing.T) {
+		w := put("/admin/users/1/crawl-limit", `{"max_concurrent_crawls": "lots"}`)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
+
+func TestSetUserRetention(t *testing.T) {
+	router := setupTestRouter()
+	router.PUT("/admin/users/:id/retention", SetUserRetention)
+
+	put := func(path, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodPut, path, bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("invalid user ID", func(t *testing.T) {
+		w := put("/admin/users/abc/retention", `{"override_days": 30}`)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("negative override", func(t *testing.T) {
+		w := put("/admin/users/1/retention", `{"override_days": -1}`)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "must not be negative")
+	})
+}
+
+func TestReloadConfig(t *testing.T) {
+	router := setupTestRouter()
+	router.POST("/admin/reload", ReloadConfig)
+
+	t.Run("invalid configuration is rejected", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "config.yaml")
+		assert.NoError(t, os.WriteFile(path, []byte("worker:\n  concurrency: -1\n"), 0600))
+		t.Setenv("CONFIG_FILE", path)
+		kept := config.App
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodPost, "/admin/reload", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "worker.concurrency")
+		assert.Same(t, kept, config.App)
+	})
+}
+
+func TestGetMetrics(t *testing.T) {
+	router := setupTestRouter()
+	router.GET("/admin/metrics", GetMetrics)
+
+	// The pool is reported without connecting
+	db, err := sql.Open("mysql", "user:pass@tcp(127.0.0.1:1)/none")
+	assert.NoError(t, err)
+	defer db.Close()
+	db.SetMaxOpenConns(7)
+	previous := config.DB
+	config.DB = db
+	defer func() { config.DB = previous }()
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/admin/metrics", nil)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response struct {
+		Database struct {
+			Pool    map[string]float64     `json:"pool"`
+			Queries map[string]interface{} `json:"queries"`
+		} `json:"database"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, 7.0, response.Database.Pool["max_open"])
+	assert.Equal(t, 0.0, response.Database.Pool["in_use"])
+	assert.Contains(t, response.Database.Pool, "wait_count")
+	assert.Contains(t, response.Database.Queries, "buckets")
+}
+
+func TestBackfillResults(t *testing.T) {
+	router := setupTestRouter()
+	router.POST("/admin/backfill", BackfillResults)
+
+	post := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodPost, path, nil)
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("limit out of range", func(t *testing.T) {
+		for _, limit := range []string{"0", "5001", "many"} {
+			w := post("/admin/backfill?limit=" + limit)
+			assert.Equal(t, http.StatusBadRequest, w.Code, limit)
+			assert.Contains(t, w.Body.String(), "limit must be between 1 and 5000")
+		}
+	})
+
+	t.Run("unknown priority", func(t *testing.T) {
+		w := post("/admin/backfill?priority=urgent")
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
+
+func TestPprof(t *testing.T) {
+	router := setupTestRouter()
+	router.GET("/admin/debug/pprof/*profile", Pprof)
+
+	get := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, path, nil)
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("index lists the profiles", func(t *testing.T) {
+		w := get("/admin/debug/pprof/")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), "goroutine")
+	})
+
+	t.Run("named profile", func(t *testing.T) {
+		w := get("/admin/debug/pprof/goroutine?debug=1")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), "goroutine profile:")
+	})
+
+	t.Run("unknown profile", func(t *testing.T) {
+		w := get("/admin/debug/pprof/nothing")
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
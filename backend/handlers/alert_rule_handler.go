@@ -0,0 +1,208 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"sykell-analyze/backend/alerts"
+	"sykell-analyze/backend/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// alertRuleColumns lists the alert_rules columns read by scanAlertRule, in scan order
+const alertRuleColumns = "id, user_id, url_id, name, expression, channels, created_at"
+
+// scanAlertRule reads a row selected with alertRuleColumns into r
+func scanAlertRule(row rowScanner, r *models.AlertRule) error {
+	var channels []byte
+	if err := row.Scan(&r.ID, &r.UserID, &r.UrlID, &r.Name, &r.Condition, &channels, &r.CreatedAt); err != nil {
+		return err
+	}
+	return json.Unmarshal(channels, &r.Channels)
+}
+
+// GetAlertRules lists the user's alert rules, optionally filtered by url_id
+func GetAlertRules(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Authentication required",
+		})
+		return
+	}
+
+	query := "SELECT " + alertRuleColumns + " FROM alert_rules WHERE user_id = ?"
+	args := []interface{}{userID}
+
+	if urlID := c.Query("url_id"); urlID != "" {
+		id, err := strconv.Atoi(urlID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Invalid url_id",
+			})
+			return
+		}
+		query += " AND (url_id IS NULL OR url_id = ?)"
+		args = append(args, id)
+	}
+
+	query += " ORDER BY created_at DESC"
+
+	rows, err := requestDB(c).Query(query, args...)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Database query failed",
+			"details": err.Error(),
+		})
+		return
+	}
+	defer rows.Close()
+
+	rules := []models.AlertRule{}
+	for rows.Next() {
+		var r models.AlertRule
+		if err := scanAlertRule(rows, &r); err != nil {
+			continue // skip bad rows
+		}
+		rules = append(rules, r)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": rules,
+	})
+}
+
+// AddAlertRule stores a new alert rule for the account or a single URL. Crawl conditions are
+// evaluated after every crawl, monitor conditions after every uptime ping.
+func AddAlertRule(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Authentication required",
+		})
+		return
+	}
+
+	var req models.AlertRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request format",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	condition, err := alerts.ParseCondition(req.Condition)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid condition",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	channels := make([]models.AlertChannel, 0, len(req.Channels))
+	for _, ch := range req.Channels {
+		normalized, err := alerts.Channel{Type: ch.Type, Target: ch.Target, Secret: ch.Secret}.Normalize()
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid channel",
+				"details": err.Error(),
+			})
+			return
+		}
+		channels = append(channels, models.AlertChannel{Type: normalized.Type, Target: normalized.Target, Secret: normalized.Secret})
+	}
+
+	// Verify URL ownership when scoping the rule to a single URL
+	if req.UrlID != nil {
+		var ownedID int
+		err := requestDB(c).QueryRow("SELECT id FROM urls WHERE id = ? AND user_id = ?", *req.UrlID, userID).Scan(&ownedID)
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "URL not found",
+			})
+			return
+		} else if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Database error",
+			})
+			return
+		}
+	}
+
+	channelsJSON, _ := json.Marshal(channels)
+
+	now := time.Now()
+	result, err := requestDB(c).Exec(`
+		INSERT INTO alert_rules (user_id, url_id, name, expression, channels, created_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`, userID, req.UrlID, req.Name, condition.String(), string(channelsJSON), now)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to save alert rule",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	id, _ := result.LastInsertId()
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Alert rule created",
+		"data": models.AlertRule{
+			ID:        int(id),
+			UserID:    userID.(int),
+			UrlID:     req.UrlID,
+			Name:      req.Name,
+			Condition: condition.String(),
+			Channels:  channels,
+			CreatedAt: now,
+		},
+	})
+}
+
+// DeleteAlertRule removes an alert rule owned by the user. Open alerts of the rule are dropped without
+// a resolved notification.
+func DeleteAlertRule(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Authentication required",
+		})
+		return
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid alert rule ID",
+		})
+		return
+	}
+
+	result, err := requestDB(c).Exec("DELETE FROM alert_rules WHERE id = ? AND user_id = ?", id, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to delete alert rule",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Alert rule not found",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Alert rule deleted successfully",
+	})
+}
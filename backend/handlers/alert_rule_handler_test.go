@@ -0,0 +1,52 @@
+package handlers
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAddAlertRule(t *testing.T) {
+	post := func(body string, authenticated bool) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(http.MethodPost, "/alert-rules", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = req
+		if authenticated {
+			c.Set("user_id", 1)
+		}
+
+		AddAlertRule(c)
+		return w
+	}
+
+	webhook := `[{"type": "webhook", "target": "https://example.com/hook"}]`
+
+	t.Run("missing authentication", func(t *testing.T) {
+		w := post(`{"name": "Broken", "condition": "broken_links > 5", "channels": `+webhook+`}`, false)
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
+	t.Run("missing channels", func(t *testing.T) {
+		w := post(`{"name": "Broken", "condition": "broken_links > 5", "channels": []}`, true)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("invalid condition", func(t *testing.T) {
+		w := post(`{"name": "Broken", "condition": "pagerank > 5", "channels": `+webhook+`}`, true)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "unknown metric")
+	})
+
+	t.Run("invalid channel", func(t *testing.T) {
+		w := post(`{"name": "Down", "condition": "up == false", "channels": [{"type": "sms", "target": "+4912345"}]}`, true)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "unknown channel type")
+	})
+}
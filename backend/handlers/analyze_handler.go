@@ -0,0 +1,201 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"sykell-analyze/backend/analyzer"
+	"sykell-analyze/backend/config"
+	"sykell-analyze/backend/crux"
+	"sykell-analyze/backend/dnscache"
+	"sykell-analyze/backend/geoip"
+	"sykell-analyze/backend/rdap"
+	"sykell-analyze/backend/safebrowsing"
+	"sykell-analyze/backend/sanitize"
+	"sykell-analyze/backend/wayback"
+
+	"github.com/gin-gonic/gin"
+)
+
+// dryRunBrokenLink is one broken link of a dry-run analysis
+type dryRunBrokenLink struct {
+	LinkUrl      string   `json:"link_url"`
+	StatusCode   *int     `json:"status_code,omitempty"`
+	ErrorMessage *string  `json:"error_message,omitempty"`
+	RateLimited  bool     `json:"rate_limited"`
+	Suggestions  []string `json:"suggestions,omitempty"`
+}
+
+// dryRunResult mirrors the analysis fields of models.Url for a crawl that is not stored
+type dryRunResult struct {
+	Url                   string                   `json:"url"`
+	HtmlVersion           string                   `json:"html_version"`
+	Title                 string                   `json:"title"`
+	H1Count               int                      `json:"h1_count"`
+	H2Count               int                      `json:"h2_count"`
+	H3Count               int                      `json:"h3_count"`
+	InternalLinks         int                      `json:"internal_links"`
+	ExternalLinks         int                      `json:"external_links"`
+	BrokenLinks           int                      `json:"broken_links"`
+	HasLoginForm          bool                     `json:"has_login_form"`
+	Forms                 []analyzer.Form          `json:"forms"`
+	InternalNofollowLinks int                      `json:"internal_nofollow_links"`
+	ExternalNofollowLinks int                      `json:"external_nofollow_links"`
+	SponsoredLinks        int                      `json:"sponsored_links"`
+	UgcLinks              int                      `json:"ugc_links"`
+	IsNoindex             bool                     `json:"is_noindex"`
+	IsNofollow            bool                     `json:"is_nofollow"`
+	BrokenLinksDetails    []dryRunBrokenLink       `json:"broken_links_details"`
+	Hreflang              analyzer.Hreflang        `json:"hreflang"`
+	LinkHygiene           analyzer.LinkHygiene     `json:"link_hygiene"`
+	ContactLinks          analyzer.ContactLinks    `json:"contact_links"`
+	SocialProfiles        []analyzer.SocialProfile `json:"social_profiles"`
+	Fragments             analyzer.Fragments       `json:"fragments"`
+	Safety                *analyzer.Safety         `json:"safety,omitempty"`
+	Privacy               analyzer.Privacy         `json:"privacy"`
+	Consent               analyzer.Consent         `json:"consent"`
+	Performance           analyzer.Performance     `json:"performance"`
+	Documents             analyzer.Documents       `json:"documents"`
+	DNS                   *analyzer.DNS            `json:"dns,omitempty"`
+	Hosting               *analyzer.Hosting        `json:"hosting,omitempty"`
+	Registration          *analyzer.Registration   `json:"registration,omitempty"`
+	WebVitals             *analyzer.WebVitals      `json:"web_vitals,omitempty"`
+	BrokenAssets          []analyzer.BrokenAsset   `json:"broken_assets"`
+	Streamed              bool                     `json:"streamed,omitempty"`
+	CrawledAt             time.Time                `json:"crawled_at"`
+	DurationMs            int64                    `json:"duration_ms"`
+}
+
+// newDryRunResult converts an analysis into its response form
+func newDryRunResult(r *analyzer.Result) dryRunResult {
+	result := dryRunResult{
+		Url:                   r.URL,
+		HtmlVersion:           r.HTMLVersion,
+		Title:                 sanitize.Line(r.Title, sanitize.MaxTitle),
+		H1Count:               r.Headings.H1,
+		H2Count:               r.Headings.H2,
+		H3Count:               r.Headings.H3,
+		InternalLinks:         r.Links.Internal,
+		ExternalLinks:         r.Links.External,
+		BrokenLinks:           len(r.BrokenLinks),
+		HasLoginForm:          r.HasLoginForm,
+		Forms:                 r.Forms,
+		InternalNofollowLinks: r.Links.InternalNofollow,
+		ExternalNofollowLinks: r.Links.ExternalNofollow,
+		SponsoredLinks:        r.Links.Sponsored,
+		UgcLinks:              r.Links.UGC,
+		IsNoindex:             r.Robots.Noindex,
+		IsNofollow:            r.Robots.Nofollow,
+		BrokenLinksDetails:    make([]dryRunBrokenLink, 0, len(r.BrokenLinks)),
+		Hreflang:              r.Hreflang,
+		LinkHygiene:           r.LinkHygiene,
+		ContactLinks:          r.ContactLinks,
+		SocialProfiles:        r.SocialProfiles,
+		Fragments:             r.Fragments,
+		Safety:                r.Safety,
+		Privacy:               r.Privacy,
+		Consent:               r.Consent,
+		Performance:           r.Performance,
+		Documents:             r.Documents,
+		DNS:                   r.DNS,
+		Hosting:               r.Hosting,
+		Registration:          r.Registration,
+		WebVitals:             r.WebVitals,
+		BrokenAssets:          r.BrokenAssets,
+		Streamed:              r.Streamed,
+		CrawledAt:             r.FetchedAt,
+		DurationMs:            r.Duration.Milliseconds(),
+	}
+	for _, link := range r.BrokenLinks {
+		detail := dryRunBrokenLink{LinkUrl: sanitize.Line(link.URL, sanitize.MaxURL), RateLimited: link.RateLimited, Suggestions: link.Suggestions}
+		if link.StatusCode != 0 {
+			code := link.StatusCode
+			detail.StatusCode = &code
+		}
+		if link.Error != "" {
+			message := sanitize.Line(link.Error, sanitize.MaxMessage)
+			detail.ErrorMessage = &message
+		}
+		result.BrokenLinksDetails = append(result.BrokenLinksDetails, detail)
+	}
+	return result
+}
+
+// dryRunOptions fits the configured crawler tuning into the dry-run budget
+func dryRunOptions() analyzer.Options {
+	settings := config.App.Crawler
+	budget := settings.DryRunTimeout
+	opts := analyzer.Options{
+		PageTimeout:               budget,
+		RequestTimeout:            min(settings.RequestTimeout, budget),
+		LinkCheckTimeout:          min(settings.LinkCheckTimeout, budget),
+		MaxConcurrentLinkChecks:   settings.MaxConcurrentLinkChecks,
+		UserAgent:                 settings.UserAgent,
+		TreatSubdomainsAsInternal: settings.TreatSubdomainsAsInternal,
+		InternalDomains:           settings.InternalDomains,
+		IgnoreQueryParams:         settings.IgnoreQueryParams,
+		IgnoreTrailingSlash:       settings.IgnoreTrailingSlash,
+		CheckAssets:               settings.CheckAssets,
+		CheckDocuments:            settings.CheckDocuments,
+		MaxDocumentBytes:          int64(settings.MaxDocumentMB) << 20,
+		StreamAbove:               int64(settings.StreamAboveMB) << 20,
+		AllowTarget:               func(u *url.URL) error { return settings.CheckTarget(u.String()) },
+		Resolver:                  dnscache.Default(),
+		HTTPClient:                settings.HTTPClient(dnscache.Default()),
+	}
+	if safebrowsing.Default != nil {
+		opts.ThreatChecker = safebrowsing.Default
+	}
+	if crux.Default != nil {
+		opts.FieldData = crux.Default
+	}
+	if rdap.Default != nil {
+		opts.Registry = rdap.Default
+	}
+	if geoip.Default != nil {
+		opts.IPLocator = geoip.Default
+	}
+	if wayback.Default != nil {
+		opts.Archive = wayback.Default
+	}
+	return opts
+}
+
+// AnalyzeUrl crawls a URL while the client waits and returns the analysis without storing anything
+func AnalyzeUrl(c *gin.Context) {
+	var input struct {
+		URL string `json:"url" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request data",
+		})
+		return
+	}
+
+	normalizedURL, err := validateURL(input.URL)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid URL format",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	// The crawl stops early if the client goes away
+	crawlResult, err := analyzer.Analyze(c.Request.Context(), normalizedURL, analyzer.WithOptions(dryRunOptions()))
+	if err != nil {
+		fmt.Printf("DEBUG: Dry-run analysis of %s failed: %v\n", normalizedURL, err)
+		c.JSON(http.StatusBadGateway, gin.H{
+			"error": err.Error(),
+			"url":   normalizedURL,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": newDryRunResult(crawlResult),
+	})
+}
@@ -0,0 +1,136 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"sykell-analyze/backend/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// allowPrivateNetworks lets the test crawl its httptest servers on the loopback address
+func allowPrivateNetworks(t *testing.T) {
+	original := config.App
+	t.Cleanup(func() { config.App = original })
+
+	cfg := *config.App
+	cfg.Crawler.AllowPrivateNetworks = true
+	config.App = &cfg
+}
+
+func TestAnalyzeUrl(t *testing.T) {
+	router := setupTestRouter()
+	router.POST("/analyze", AnalyzeUrl)
+
+	post := func(body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodPost, "/analyze", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("missing URL", func(t *testing.T) {
+		w := post(`{}`)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("invalid URL", func(t *testing.T) {
+		w := post(`{"url": "https://"}`)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("private address refused", func(t *testing.T) {
+		for _, target := range []string{"http://127.0.0.1:8080", "http://[::1]:8080", "http://[fd00::1]/", "http://localhost"} {
+			w := post(`{"url": "` + target + `"}`)
+			assert.Equal(t, http.StatusBadRequest, w.Code, target)
+			assert.Contains(t, w.Body.String(), "private network address", target)
+		}
+	})
+
+	t.Run("returns the analysis", func(t *testing.T) {
+		allowPrivateNetworks(t)
+		mux := http.NewServeMux()
+		mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "text/html")
+			fmt.Fprint(w, `<!DOCTYPE html><html><head><title>Dry run</title></head><body>
+				<h1>Hello</h1><a href="/ok">ok</a><a href="/missing">missing</a></body></html>`)
+		})
+		mux.HandleFunc("/ok", func(w http.ResponseWriter, r *http.Request) {})
+		mux.HandleFunc("/missing", http.NotFound)
+		server := httptest.NewServer(mux)
+		defer server.Close()
+
+		w := post(`{"url": "` + server.URL + `"}`)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var response struct {
+			Data dryRunResult `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, server.URL, response.Data.Url)
+		assert.Equal(t, "Dry run", response.Data.Title)
+		assert.Equal(t, 1, response.Data.H1Count)
+		assert.Equal(t, 2, response.Data.InternalLinks)
+		assert.Equal(t, 1, response.Data.BrokenLinks)
+		require.Len(t, response.Data.BrokenLinksDetails, 1)
+		assert.Equal(t, server.URL+"/missing", response.Data.BrokenLinksDetails[0].LinkUrl)
+	})
+
+	t.Run("IPv6 literal with a port", func(t *testing.T) {
+		allowPrivateNetworks(t)
+		listener, err := net.Listen("tcp", "[::1]:0")
+		if err != nil {
+			t.Skip("no IPv6 loopback:", err)
+		}
+		server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "text/html")
+			fmt.Fprint(w, `<!DOCTYPE html><html><head><title>IPv6</title></head><body><a href="/next">next</a></body></html>`)
+		}))
+		server.Listener.Close()
+		server.Listener = listener
+		server.Start()
+		defer server.Close()
+
+		w := post(`{"url": "` + server.URL + `"}`)
+		require.Equal(t, http.StatusOK, w.Code)
+		var response struct {
+			Data dryRunResult `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, "IPv6", response.Data.Title)
+		assert.Equal(t, 1, response.Data.InternalLinks)
+	})
+
+	t.Run("unreachable site", func(t *testing.T) {
+		allowPrivateNetworks(t)
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+		target := server.URL
+		server.Close()
+
+		w := post(`{"url": "` + target + `"}`)
+		assert.Equal(t, http.StatusBadGateway, w.Code)
+	})
+}
+
+func TestDryRunOptions(t *testing.T) {
+	original := config.App
+	defer func() { config.App = original }()
+
+	cfg := *config.App
+	cfg.Crawler.DryRunTimeout = 5 * time.Second
+	config.App = &cfg
+
+	opts := dryRunOptions()
+	assert.Equal(t, 5*time.Second, opts.PageTimeout)
+	assert.Equal(t, 5*time.Second, opts.RequestTimeout)
+	assert.Equal(t, 5*time.Second, opts.LinkCheckTimeout)
+}
@@ -27,7 +27,7 @@ func Register(c *gin.Context) {
 
 	// Check if username already exists
 	var existingID int
-	err := config.DB.QueryRow("SELECT id FROM users WHERE username = ? OR email = ?", req.Username, req.Email).Scan(&existingID)
+	err := requestDB(c).QueryRow("SELECT id FROM users WHERE username = ? OR email = ?", req.Username, req.Email).Scan(&existingID)
 	if err == nil {
 		c.JSON(http.StatusConflict, gin.H{
 			"error": "Username or email already exists",
@@ -50,7 +50,7 @@ func Register(c *gin.Context) {
 	}
 
 	// Insert user
-	result, err := config.DB.Exec(
+	result, err := requestDB(c).Exec(
 		"INSERT INTO users (username, email, password, created_at, updated_at) VALUES (?, ?, ?, ?, ?)",
 		req.Username, req.Email, string(hashedPassword), time.Now(), time.Now(),
 	)
@@ -64,7 +64,7 @@ func Register(c *gin.Context) {
 	userID, _ := result.LastInsertId()
 
 	// Generate token
-	token, err := middleware.GenerateToken(int(userID), req.Username)
+	token, err := middleware.GenerateToken(c.Request.Context(), int(userID), req.Username)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Failed to generate token",
@@ -76,6 +76,7 @@ func Register(c *gin.Context) {
 		ID:        int(userID),
 		Username:  req.Username,
 		Email:     req.Email,
+		Timezone:  "UTC",
 		CreatedAt: time.Now(),
 		UpdatedAt: time.Now(),
 	}
@@ -101,10 +102,10 @@ func Login(c *gin.Context) {
 	// Get user from database
 	var user models.User
 	var hashedPassword string
-	err := config.DB.QueryRow(
-		"SELECT id, username, email, password, created_at, updated_at FROM users WHERE username = ?",
+	err := requestDB(c).QueryRow(
+		"SELECT id, username, email, password, timezone, created_at, updated_at FROM users WHERE username = ?",
 		req.Username,
-	).Scan(&user.ID, &user.Username, &user.Email, &hashedPassword, &user.CreatedAt, &user.UpdatedAt)
+	).Scan(&user.ID, &user.Username, &user.Email, &hashedPassword, &user.Timezone, &user.CreatedAt, &user.UpdatedAt)
 
 	if err == sql.ErrNoRows {
 		c.JSON(http.StatusUnauthorized, gin.H{
@@ -128,7 +129,7 @@ func Login(c *gin.Context) {
 	}
 
 	// Generate token
-	token, err := middleware.GenerateToken(user.ID, user.Username)
+	token, err := middleware.GenerateToken(c.Request.Context(), user.ID, user.Username)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Failed to generate token",
@@ -153,10 +154,10 @@ func GetProfile(c *gin.Context) {
 	}
 
 	var user models.User
-	err := config.DB.QueryRow(
-		"SELECT id, username, email, created_at, updated_at FROM users WHERE id = ?",
+	err := requestDB(c).QueryRow(
+		"SELECT id, username, email, timezone, created_at, updated_at FROM users WHERE id = ?",
 		userID,
-	).Scan(&user.ID, &user.Username, &user.Email, &user.CreatedAt, &user.UpdatedAt)
+	).Scan(&user.ID, &user.Username, &user.Email, &user.Timezone, &user.CreatedAt, &user.UpdatedAt)
 
 	if err == sql.ErrNoRows {
 		c.JSON(http.StatusNotFound, gin.H{
@@ -193,7 +194,7 @@ func RefreshToken(c *gin.Context) {
 		return
 	}
 
-	token, err := middleware.GenerateToken(userID.(int), username.(string))
+	token, err := middleware.GenerateToken(c.Request.Context(), userID.(int), username.(string))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Failed to generate token",
@@ -205,3 +206,45 @@ func RefreshToken(c *gin.Context) {
 		"token": token,
 	})
 }
+
+// DemoLogin returns a read-only token for the demo account, letting visitors browse its example
+// analyses without signing up
+func DemoLogin(c *gin.Context) {
+	if !config.App.Demo.Enabled {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Demo mode is not enabled",
+		})
+		return
+	}
+
+	var user models.User
+	err := requestDB(c).QueryRow(
+		"SELECT id, username, email, timezone, created_at, updated_at FROM users WHERE username = ? AND is_demo = TRUE",
+		config.App.Demo.Username,
+	).Scan(&user.ID, &user.Username, &user.Email, &user.Timezone, &user.CreatedAt, &user.UpdatedAt)
+
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Demo account not found",
+		})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Database error",
+		})
+		return
+	}
+
+	token, err := middleware.GenerateReadOnlyToken(c.Request.Context(), user.ID, user.Username, config.App.Demo.TokenTTL)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to generate token",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.AuthResponse{
+		Token: token,
+		User:  user,
+	})
+}
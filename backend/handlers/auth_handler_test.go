@@ -8,6 +8,7 @@ import (
 	"net/http/httptest"
 	"testing"
 
+	"sykell-analyze/backend/config"
 	"sykell-analyze/backend/models"
 
 	"github.com/gin-gonic/gin"
@@ -234,6 +235,23 @@ func TestGetProfile(t *testing.T) {
 	})
 }
 
+func TestDemoLogin(t *testing.T) {
+	t.Run("demo mode disabled", func(t *testing.T) {
+		original := config.App
+		defer func() { config.App = original }()
+		config.App.Demo.Enabled = false
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest(http.MethodPost, "/auth/demo", nil)
+
+		DemoLogin(c)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.Contains(t, w.Body.String(), "Demo mode is not enabled")
+	})
+}
+
 func TestPasswordHashing(t *testing.T) {
 	password := "testpassword123"
 
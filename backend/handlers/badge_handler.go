@@ -0,0 +1,205 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"sykell-analyze/backend/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// badgeCacheControl lets image proxies such as GitHub's cache a badge briefly while keeping it live
+const badgeCacheControl = "public, max-age=300"
+
+// newShareToken returns an unguessable token for public links to a URL
+func newShareToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// badgePath is where the badge of a shared URL is served
+func badgePath(token string) string {
+	return "/public/badge/" + token + ".svg"
+}
+
+// badgeContent picks the text and color of a badge. metric is "links" (broken link count, the
+// default) or "status" (analysis status); ok is false for any other metric.
+func badgeContent(metric, status string, brokenLinks int, crawled bool) (label, message, color string, ok bool) {
+	switch metric {
+	case "", "links":
+		label = "links"
+		switch {
+		case status == "error":
+			return label, "error", utils.BadgeRed, true
+		case !crawled:
+			return label, "pending", utils.BadgeLightGrey, true
+		case brokenLinks == 0:
+			return label, "0 broken", utils.BadgeGreen, true
+		default:
+			return label, fmt.Sprintf("%d broken", brokenLinks), utils.BadgeRed, true
+		}
+	case "status":
+		label = "analysis"
+		switch status {
+		case "completed":
+			return label, status, utils.BadgeGreen, true
+		case "error":
+			return label, status, utils.BadgeRed, true
+		case "running":
+			return label, status, utils.BadgeBlue, true
+		default:
+			return label, status, utils.BadgeLightGrey, true
+		}
+	}
+	return "", "", "", false
+}
+
+// writeBadge sends an SVG badge
+func writeBadge(c *gin.Context, code int, label, message, color string) {
+	c.Header("Cache-Control", badgeCacheControl)
+	c.Data(code, "image/svg+xml; charset=utf-8", utils.RenderBadge(label, message, color))
+}
+
+// ShareUrl makes a URL's badge publicly available, returning its share token (only if owned by user).
+// Sharing an already shared URL returns the existing token so embedded badges keep working.
+func ShareUrl(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Authentication required",
+		})
+		return
+	}
+
+	id := c.Param("id")
+
+	var token sql.NullString
+	err := requestDB(c).QueryRow("SELECT share_token FROM urls WHERE id = ? AND user_id = ?", id, userID).Scan(&token)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "URL not found",
+		})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Database error",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	if !token.Valid {
+		newToken, err := newShareToken()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to create share token",
+			})
+			return
+		}
+		// Sharing is not an analysis change, so updated_at is kept
+		_, err = requestDB(c).Exec(
+			"UPDATE urls SET share_token = ?, updated_at = updated_at WHERE id = ? AND user_id = ?",
+			newToken, id, userID,
+		)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to share URL",
+			})
+			return
+		}
+		token = sql.NullString{String: newToken, Valid: true}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"share_token": token.String,
+		"badge_url":   badgePath(token.String),
+	})
+}
+
+// UnshareUrl revokes a URL's share token; badges embedded with it stop resolving (only if owned by user)
+func UnshareUrl(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Authentication required",
+		})
+		return
+	}
+
+	id := c.Param("id")
+
+	var urlID int
+	err := requestDB(c).QueryRow("SELECT id FROM urls WHERE id = ? AND user_id = ?", id, userID).Scan(&urlID)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "URL not found",
+		})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Database error",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	_, err = requestDB(c).Exec("UPDATE urls SET share_token = NULL, updated_at = updated_at WHERE id = ?", urlID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to unshare URL",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "URL is no longer shared",
+	})
+}
+
+// GetBadge serves the SVG status badge of a shared URL at /public/badge/:token.svg.
+// ?metric=links (default) shows the broken link count and ?metric=status the analysis status.
+func GetBadge(c *gin.Context) {
+	token, found := strings.CutSuffix(c.Param("badge"), ".svg")
+	if !found || token == "" {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Badge not found",
+		})
+		return
+	}
+
+	metric := c.Query("metric")
+	label, _, _, ok := badgeContent(metric, "", 0, false)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "metric must be links or status",
+		})
+		return
+	}
+
+	var status string
+	var brokenLinks int
+	var crawledAt sql.NullTime
+	err := requestDB(c).QueryRow(
+		"SELECT status, broken_links, crawled_at FROM urls WHERE share_token = ?", token,
+	).Scan(&status, &brokenLinks, &crawledAt)
+	if err == sql.ErrNoRows {
+		// Still an image, so embeds of revoked badges show why instead of a broken icon
+		writeBadge(c, http.StatusNotFound, label, "not found", utils.BadgeLightGrey)
+		return
+	} else if err != nil {
+		fmt.Printf("DEBUG: Failed to load badge: %v\n", err)
+		writeBadge(c, http.StatusInternalServerError, label, "unavailable", utils.BadgeLightGrey)
+		return
+	}
+
+	label, message, color, _ := badgeContent(metric, status, brokenLinks, crawledAt.Valid)
+	writeBadge(c, http.StatusOK, label, message, color)
+}
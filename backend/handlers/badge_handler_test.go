@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"sykell-analyze/backend/utils"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBadgeContent(t *testing.T) {
+	tests := []struct {
+		name        string
+		metric      string
+		status      string
+		brokenLinks int
+		crawled     bool
+		message     string
+		color       string
+	}{
+		{"no broken links", "links", "completed", 0, true, "0 broken", utils.BadgeGreen},
+		{"broken links", "", "completed", 3, true, "3 broken", utils.BadgeRed},
+		{"never crawled", "links", "queued", 0, false, "pending", utils.BadgeLightGrey},
+		{"recrawl keeps the last result", "links", "running", 2, true, "2 broken", utils.BadgeRed},
+		{"failed crawl", "links", "error", 0, true, "error", utils.BadgeRed},
+		{"status", "status", "running", 0, false, "running", utils.BadgeBlue},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, message, color, ok := badgeContent(tt.metric, tt.status, tt.brokenLinks, tt.crawled)
+			assert.True(t, ok)
+			assert.Equal(t, tt.message, message)
+			assert.Equal(t, tt.color, color)
+		})
+	}
+
+	t.Run("unknown metric", func(t *testing.T) {
+		_, _, _, ok := badgeContent("seo", "completed", 0, true)
+		assert.False(t, ok)
+	})
+}
+
+func TestGetBadge(t *testing.T) {
+	router := setupTestRouter()
+	router.GET("/public/badge/:badge", GetBadge)
+
+	get := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, path, nil)
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("requires the .svg extension", func(t *testing.T) {
+		w := get("/public/badge/abc123")
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("unknown metric", func(t *testing.T) {
+		w := get("/public/badge/abc123.svg?metric=seo")
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
+
+func TestBadgePath(t *testing.T) {
+	token, err := newShareToken()
+	assert.NoError(t, err)
+	assert.Len(t, token, 32)
+	assert.Equal(t, "/public/badge/"+token+".svg", badgePath(token))
+}
@@ -0,0 +1,130 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"sykell-analyze/backend/models"
+	"sykell-analyze/backend/sanitize"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetBrokenLinks lists the broken links across the user's URLs, newest first. ?search= matches the
+// link and its error message, so users can find every page referencing a dead domain.
+func GetBrokenLinks(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Authentication required",
+		})
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+	offset := (page - 1) * limit
+
+	where := " FROM broken_links b JOIN urls u ON u.id = b.url_id WHERE u.user_id = ?"
+	args := []interface{}{userID}
+
+	if search := c.Query("search"); search != "" {
+		where += " AND (b.link_url LIKE ? OR b.error_message LIKE ?)"
+		searchPattern := "%" + search + "%"
+		args = append(args, searchPattern, searchPattern)
+	}
+
+	if statusCode := c.Query("status_code"); statusCode != "" {
+		code, err := strconv.Atoi(statusCode)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "status_code must be a number",
+			})
+			return
+		}
+		where += " AND b.status_code = ?"
+		args = append(args, code)
+	}
+
+	if urlID := c.Query("url_id"); urlID != "" {
+		id, err := strconv.Atoi(urlID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Invalid URL ID",
+			})
+			return
+		}
+		where += " AND b.url_id = ?"
+		args = append(args, id)
+	}
+
+	var total int
+	if err := requestDB(c).QueryRow("SELECT COUNT(*)"+where, args...).Scan(&total); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Database error",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	rows, err := requestDB(c).Query(`
+		SELECT b.id, b.url_id, b.link_url, b.status_code, b.error_message, b.suggestions, b.created_at,
+			u.url, COALESCE(u.title, '')`+where+`
+		ORDER BY b.created_at DESC, b.id DESC LIMIT ? OFFSET ?`,
+		append(args, limit, offset)...,
+	)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Database query failed",
+			"details": err.Error(),
+		})
+		return
+	}
+	defer rows.Close()
+
+	links := []models.BrokenLinkMatch{}
+	for rows.Next() {
+		var link models.BrokenLinkMatch
+		var suggestions []byte
+		err := rows.Scan(
+			&link.ID, &link.UrlID, &link.LinkUrl, &link.StatusCode, &link.ErrorMessage, &suggestions, &link.CreatedAt,
+			&link.PageUrl, &link.PageTitle,
+		)
+		if err != nil {
+			continue // skip bad rows
+		}
+		if suggestions != nil {
+			json.Unmarshal(suggestions, &link.Suggestions)
+		}
+		link.LinkUrl = sanitize.Line(link.LinkUrl, sanitize.MaxURL)
+		link.ErrorMessage = sanitize.LinePtr(link.ErrorMessage, sanitize.MaxMessage)
+		link.RateLimited = link.StatusCode != nil && *link.StatusCode == http.StatusTooManyRequests
+		link.PageTitle = sanitize.Line(link.PageTitle, sanitize.MaxTitle)
+		links = append(links, link)
+	}
+
+	if err = rows.Err(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Error reading results",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": links,
+		"pagination": gin.H{
+			"page":  page,
+			"limit": limit,
+			"total": total,
+			"pages": (total + limit - 1) / limit,
+		},
+	})
+}
@@ -0,0 +1,41 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetBrokenLinks(t *testing.T) {
+	get := func(query string, authenticated bool) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(http.MethodGet, "/broken-links"+query, nil)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = req
+		if authenticated {
+			c.Set("user_id", 1)
+		}
+
+		GetBrokenLinks(c)
+		return w
+	}
+
+	t.Run("missing authentication", func(t *testing.T) {
+		w := get("?search=dead.example", false)
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
+	t.Run("invalid status code", func(t *testing.T) {
+		w := get("?search=dead.example&status_code=gone", true)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("invalid URL ID", func(t *testing.T) {
+		w := get("?url_id=abc", true)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
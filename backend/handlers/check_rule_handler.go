@@ -0,0 +1,227 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"strconv"
+	"time"
+
+	"sykell-analyze/backend/analyzer"
+	"sykell-analyze/backend/config"
+	"sykell-analyze/backend/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// checkRuleColumns lists the check_rules columns read by scanCheckRule, in scan order
+const checkRuleColumns = "id, user_id, url_id, name, selector, rule_type, min_count, max_count, text, created_at"
+
+// scanCheckRule reads a row selected with checkRuleColumns into r
+func scanCheckRule(row rowScanner, r *models.CheckRule) error {
+	return row.Scan(&r.ID, &r.UserID, &r.UrlID, &r.Name, &r.Selector, &r.RuleType, &r.MinCount, &r.MaxCount, &r.Text, &r.CreatedAt)
+}
+
+// GetCheckRules lists the user's check rules, optionally filtered by url_id
+func GetCheckRules(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Authentication required",
+		})
+		return
+	}
+
+	query := "SELECT " + checkRuleColumns + " FROM check_rules WHERE user_id = ?"
+	args := []interface{}{userID}
+
+	if urlID := c.Query("url_id"); urlID != "" {
+		id, err := strconv.Atoi(urlID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Invalid url_id",
+			})
+			return
+		}
+		query += " AND (url_id IS NULL OR url_id = ?)"
+		args = append(args, id)
+	}
+
+	query += " ORDER BY created_at DESC"
+
+	rows, err := requestDB(c).Query(query, args...)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Database query failed",
+			"details": err.Error(),
+		})
+		return
+	}
+	defer rows.Close()
+
+	rules := []models.CheckRule{}
+	for rows.Next() {
+		var r models.CheckRule
+		if err := scanCheckRule(rows, &r); err != nil {
+			continue // skip bad rows
+		}
+		rules = append(rules, r)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": rules,
+	})
+}
+
+// AddCheckRule stores a new check rule for the account or a single URL.
+// Rules are evaluated on the next crawl of the URLs they apply to.
+func AddCheckRule(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Authentication required",
+		})
+		return
+	}
+
+	var req models.CheckRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request format",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	rule := analyzer.Rule{
+		Name:     req.Name,
+		Selector: req.Selector,
+		Type:     req.RuleType,
+		Min:      req.MinCount,
+		Max:      req.MaxCount,
+		Text:     req.Text,
+	}
+	if err := rule.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid check rule",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	// Verify URL ownership when scoping the rule to a single URL
+	if req.UrlID != nil {
+		var ownedID int
+		err := requestDB(c).QueryRow("SELECT id FROM urls WHERE id = ? AND user_id = ?", *req.UrlID, userID).Scan(&ownedID)
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "URL not found",
+			})
+			return
+		} else if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Database error",
+			})
+			return
+		}
+	}
+
+	var text *string
+	if req.Text != "" {
+		text = &req.Text
+	}
+
+	now := time.Now()
+	result, err := requestDB(c).Exec(`
+		INSERT INTO check_rules (user_id, url_id, name, selector, rule_type, min_count, max_count, text, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, userID, req.UrlID, req.Name, req.Selector, req.RuleType, req.MinCount, req.MaxCount, text, now)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to save check rule",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	id, _ := result.LastInsertId()
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Check rule created",
+		"data": models.CheckRule{
+			ID:        int(id),
+			UserID:    userID.(int),
+			UrlID:     req.UrlID,
+			Name:      req.Name,
+			Selector:  req.Selector,
+			RuleType:  req.RuleType,
+			MinCount:  req.MinCount,
+			MaxCount:  req.MaxCount,
+			Text:      text,
+			CreatedAt: now,
+		},
+	})
+}
+
+// DeleteCheckRule removes a check rule owned by the user. Results of past crawls are kept.
+func DeleteCheckRule(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Authentication required",
+		})
+		return
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid check rule ID",
+		})
+		return
+	}
+
+	result, err := requestDB(c).Exec("DELETE FROM check_rules WHERE id = ? AND user_id = ?", id, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to delete check rule",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Check rule not found",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Check rule deleted successfully",
+	})
+}
+
+// loadCheckResults returns the check results of a URL's latest crawl
+func loadCheckResults(ctx context.Context, urlID int) []models.CheckResult {
+	results := []models.CheckResult{}
+	rows, err := config.DBFor(ctx).Query(`
+		SELECT id, url_id, rule_id, name, selector, rule_type, passed, matches, COALESCE(message, ''), created_at
+		FROM check_results WHERE url_id = ?
+		ORDER BY id
+	`, urlID)
+	if err != nil {
+		return results
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var r models.CheckResult
+		err := rows.Scan(&r.ID, &r.UrlID, &r.RuleID, &r.Name, &r.Selector, &r.RuleType, &r.Passed, &r.Matches, &r.Message, &r.CreatedAt)
+		if err == nil {
+			results = append(results, r)
+		}
+	}
+	return results
+}
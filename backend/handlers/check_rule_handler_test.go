@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAddCheckRule(t *testing.T) {
+	post := func(body string, authenticated bool) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(http.MethodPost, "/check-rules", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = req
+		if authenticated {
+			c.Set("user_id", 1)
+		}
+
+		AddCheckRule(c)
+		return w
+	}
+
+	t.Run("missing authentication", func(t *testing.T) {
+		w := post(`{"name": "h1", "selector": "h1", "rule_type": "exists"}`, false)
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
+	t.Run("missing fields", func(t *testing.T) {
+		w := post(`{"name": "h1"}`, true)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("invalid selector", func(t *testing.T) {
+		w := post(`{"name": "bad", "selector": "div[", "rule_type": "exists"}`, true)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "invalid selector")
+	})
+
+	t.Run("count rule without bounds", func(t *testing.T) {
+		w := post(`{"name": "one h1", "selector": "h1", "rule_type": "count"}`, true)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("unknown rule type", func(t *testing.T) {
+		w := post(`{"name": "x", "selector": "h1", "rule_type": "xpath"}`, true)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "unknown rule type")
+	})
+}
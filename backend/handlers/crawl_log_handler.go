@@ -0,0 +1,167 @@
+package handlers
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+
+	"sykell-analyze/backend/models"
+	"sykell-analyze/backend/sanitize"
+
+	"github.com/gin-gonic/gin"
+)
+
+// parseLogLimit reads ?limit for crawl logs, crawl runs and uptime checks (default 100, at most 500)
+func parseLogLimit(value string) (int, bool) {
+	if value == "" {
+		return 100, true
+	}
+	limit, err := strconv.Atoi(value)
+	if err != nil || limit < 1 || limit > 500 {
+		return 0, false
+	}
+	return limit, true
+}
+
+// GetUrlLogs returns the crawl log of a URL, newest first, so users can see why a crawl was slow or failed
+func GetUrlLogs(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Authentication required",
+		})
+		return
+	}
+
+	limit, ok := parseLogLimit(c.Query("limit"))
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "limit must be between 1 and 500",
+		})
+		return
+	}
+
+	id := c.Param("id")
+
+	var urlID int
+	err := requestDB(c).QueryRow("SELECT id FROM urls WHERE id = ? AND user_id = ?", id, userID).Scan(&urlID)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "URL not found",
+		})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Database error",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	rows, err := requestDB(c).Query(`
+		SELECT id, url_id, job_id, level, event, message, duration_ms, details, created_at
+		FROM crawl_logs
+		WHERE url_id = ?
+		ORDER BY id DESC
+		LIMIT ?
+	`, urlID, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Database query failed",
+			"details": err.Error(),
+		})
+		return
+	}
+	defer rows.Close()
+
+	logs := []models.CrawlLog{}
+	for rows.Next() {
+		var entry models.CrawlLog
+		var details []byte
+		err := rows.Scan(
+			&entry.ID, &entry.UrlID, &entry.JobID, &entry.Level, &entry.Event,
+			&entry.Message, &entry.DurationMs, &details, &entry.CreatedAt,
+		)
+		if err != nil {
+			continue // skip bad rows
+		}
+		if len(details) > 0 {
+			entry.Details = details
+		}
+		logs = append(logs, entry)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"logs": logs,
+	})
+}
+
+// GetUrlRuns returns the crawl history of a URL, newest first, with the Wayback Machine copy of each crawl
+func GetUrlRuns(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Authentication required",
+		})
+		return
+	}
+
+	limit, ok := parseLogLimit(c.Query("limit"))
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "limit must be between 1 and 500",
+		})
+		return
+	}
+
+	id := c.Param("id")
+
+	var urlID int
+	err := requestDB(c).QueryRow("SELECT id FROM urls WHERE id = ? AND user_id = ?", id, userID).Scan(&urlID)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "URL not found",
+		})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Database error",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	rows, err := requestDB(c).Query(`
+		SELECT id, url_id, status, broken_links, error_message, started_at, finished_at, archive_url, archive_error
+		FROM crawl_runs
+		WHERE url_id = ?
+		ORDER BY id DESC
+		LIMIT ?
+	`, urlID, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Database query failed",
+			"details": err.Error(),
+		})
+		return
+	}
+	defer rows.Close()
+
+	runs := []models.CrawlRun{}
+	for rows.Next() {
+		var run models.CrawlRun
+		err := rows.Scan(
+			&run.ID, &run.UrlID, &run.Status, &run.BrokenLinks, &run.ErrorMessage,
+			&run.StartedAt, &run.FinishedAt, &run.ArchiveURL, &run.ArchiveError,
+		)
+		if err != nil {
+			continue // skip bad rows
+		}
+		run.ErrorMessage = sanitize.LinePtr(run.ErrorMessage, sanitize.MaxMessage)
+		runs = append(runs, run)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": runs,
+	})
+}
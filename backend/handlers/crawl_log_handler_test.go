@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseLogLimit(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected int
+		ok       bool
+	}{
+		{input: "", expected: 100, ok: true},
+		{input: "25", expected: 25, ok: true},
+		{input: "500", expected: 500, ok: true},
+		{input: "0", ok: false},
+		{input: "501", ok: false},
+		{input: "ten", ok: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.input, func(t *testing.T) {
+			limit, ok := parseLogLimit(tc.input)
+			assert.Equal(t, tc.ok, ok)
+			if tc.ok {
+				assert.Equal(t, tc.expected, limit)
+			}
+		})
+	}
+}
+
+func TestGetUrlLogs(t *testing.T) {
+	router := setupTestRouter()
+	router.GET("/urls/:id/logs", GetUrlLogs)
+	router.GET("/auth/urls/:id/logs", func(c *gin.Context) {
+		c.Set("user_id", 1)
+		GetUrlLogs(c)
+	})
+
+	t.Run("missing authentication", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/urls/1/logs", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
+	t.Run("invalid limit", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/auth/urls/1/logs?limit=1000", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
+
+func TestGetUrlRuns(t *testing.T) {
+	router := setupTestRouter()
+	router.GET("/urls/:id/runs", GetUrlRuns)
+	router.GET("/auth/urls/:id/runs", func(c *gin.Context) {
+		c.Set("user_id", 1)
+		GetUrlRuns(c)
+	})
+
+	t.Run("missing authentication", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/urls/1/runs", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
+	t.Run("invalid limit", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/auth/urls/1/runs?limit=0", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
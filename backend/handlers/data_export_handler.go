@@ -0,0 +1,294 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"sykell-analyze/backend/config"
+	"sykell-analyze/backend/dataexport"
+	"sykell-analyze/backend/models"
+	"sykell-analyze/backend/storage"
+	"sykell-analyze/backend/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// dataExportColumns lists the data_exports columns read by scanDataExport, in scan order
+const dataExportColumns = "id, status, token, size_bytes, error_message, created_at, completed_at, expires_at"
+
+// scanDataExport reads a row selected with dataExportColumns into e; the download URL is only set
+// once the archive is ready
+func scanDataExport(row rowScanner, e *models.DataExport, baseURL string) error {
+	var token string
+	if err := row.Scan(&e.ID, &e.Status, &token, &e.SizeBytes, &e.ErrorMessage, &e.CreatedAt, &e.CompletedAt, &e.ExpiresAt); err != nil {
+		return err
+	}
+	if e.Status == "ready" {
+		e.DownloadURL = baseURL + dataExportPath(token)
+	}
+	return nil
+}
+
+// dataExportPath is where an archive is downloaded
+func dataExportPath(token string) string {
+	return "/public/exports/" + token + ".zip"
+}
+
+// requestBaseURL is the scheme and host the client used to reach the API, for links in emails
+func requestBaseURL(c *gin.Context) string {
+	scheme := "http"
+	if c.Request.TLS != nil {
+		scheme = "https"
+	}
+	if proto := c.GetHeader("X-Forwarded-Proto"); proto == "http" || proto == "https" {
+		scheme = proto
+	}
+	return scheme + "://" + c.Request.Host
+}
+
+// RequestDataExport starts building an archive of all of the user's data and returns it as pending.
+// The user is emailed the download link once it is ready, when an SMTP server is configured;
+// otherwise poll GET /profile/exports.
+func RequestDataExport(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Authentication required",
+		})
+		return
+	}
+
+	var email, timezone string
+	if err := requestDB(c).QueryRow("SELECT email, timezone FROM users WHERE id = ?", userID).Scan(&email, &timezone); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Database error",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	// One archive at a time; a pending export older than the build timeout was lost with its process
+	var pendingID int
+	err := requestDB(c).QueryRow(
+		"SELECT id FROM data_exports WHERE user_id = ? AND status = 'pending' AND created_at > ? LIMIT 1",
+		userID, time.Now().Add(-dataexport.BuildTimeout),
+	).Scan(&pendingID)
+	if err == nil {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "An export is already being prepared",
+			"id":    pendingID,
+		})
+		return
+	} else if err != sql.ErrNoRows {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Database error",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	// Drop expired archives and abandoned builds
+	err = dropOldExports(c.Request.Context(), "data_exports", userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to clean up old exports",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	token, err := newShareToken()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to create download token",
+		})
+		return
+	}
+
+	now := time.Now()
+	result, err := requestDB(c).Exec(
+		"INSERT INTO data_exports (user_id, status, token, created_at) VALUES (?, 'pending', ?, ?)",
+		userID, token, now,
+	)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to start export",
+			"details": err.Error(),
+		})
+		return
+	}
+	id, _ := result.LastInsertId()
+
+	go dataexport.Run(c.Request.Context(), int(id), userID.(int), email, requestBaseURL(c)+dataExportPath(token), utils.Location(timezone))
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"message": "Export started",
+		"data": models.DataExport{
+			ID:        int(id),
+			Status:    "pending",
+			CreatedAt: now,
+		},
+	})
+}
+
+// GetDataExports lists the user's data exports, newest first
+func GetDataExports(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Authentication required",
+		})
+		return
+	}
+
+	rows, err := requestDB(c).Query(
+		"SELECT "+dataExportColumns+" FROM data_exports WHERE user_id = ? ORDER BY id DESC", userID,
+	)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Database query failed",
+			"details": err.Error(),
+		})
+		return
+	}
+	defer rows.Close()
+
+	baseURL := requestBaseURL(c)
+	exports := []models.DataExport{}
+	for rows.Next() {
+		var e models.DataExport
+		if err := scanDataExport(rows, &e, baseURL); err != nil {
+			continue // skip bad rows
+		}
+		exports = append(exports, e)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": exports,
+	})
+}
+
+// DownloadDataExport serves a ready archive by its download token (no authentication, so the
+// emailed link works in a browser)
+func DownloadDataExport(c *gin.Context) {
+	token, found := strings.CutSuffix(c.Param("file"), ".zip")
+	if !found || token == "" {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Export not found",
+		})
+		return
+	}
+
+	var content []byte
+	var storageKey sql.NullString
+	var completedAt, expiresAt time.Time
+	err := requestDB(c).QueryRow(
+		"SELECT content, storage_key, completed_at, expires_at FROM data_exports WHERE token = ? AND status = 'ready'", token,
+	).Scan(&content, &storageKey, &completedAt, &expiresAt)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Export not found",
+		})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Database error",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	if time.Now().After(expiresAt) {
+		c.JSON(http.StatusGone, gin.H{
+			"error": "Export has expired",
+		})
+		return
+	}
+
+	filename := "account-data-" + expiresAt.Add(-dataexport.TTL).Format("2006-01-02") + ".zip"
+	// Exports made before archives moved to storage still carry their content
+	if !storageKey.Valid {
+		serveDownload(c, bytes.NewReader(content), token, filename, "application/zip", completedAt)
+		return
+	}
+	serveStored(c, storageKey.String, token, filename, "application/zip", completedAt)
+}
+
+// dropOldExports deletes the user's expired and pending exports from table (data_exports or exports)
+// along with their files
+func dropOldExports(ctx context.Context, table string, userID interface{}) error {
+	rows, err := config.DBFor(ctx).Query(
+		"SELECT id, storage_key FROM "+table+" WHERE user_id = ? AND (expires_at < ? OR status = 'pending')",
+		userID, time.Now(),
+	)
+	if err != nil {
+		return err
+	}
+	var ids []interface{}
+	var keys []string
+	for rows.Next() {
+		var id int
+		var key sql.NullString
+		if err := rows.Scan(&id, &key); err != nil {
+			rows.Close()
+			return err
+		}
+		ids = append(ids, id)
+		if key.Valid {
+			keys = append(keys, key.String)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil || len(ids) == 0 {
+		return err
+	}
+
+	if _, err := config.DBFor(ctx).Exec("DELETE FROM "+table+" WHERE id IN ("+placeholders(len(ids))+")", ids...); err != nil {
+		return err
+	}
+	// A leftover object is unreachable without its row, so failures are only logged
+	if store := storage.For(ctx); store != nil {
+		for _, key := range keys {
+			if err := store.Delete(ctx, key); err != nil {
+				fmt.Printf("DEBUG: Failed to delete export %s: %v\n", key, err)
+			}
+		}
+	}
+	return nil
+}
+
+// serveStored serves a file from storage with serveDownload
+func serveStored(c *gin.Context, key, etag, filename, contentType string, modTime time.Time) {
+	store := storage.For(c.Request.Context())
+	if store == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Storage is not set up",
+		})
+		return
+	}
+	file, err := store.Open(c.Request.Context(), key)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to load export",
+			"details": err.Error(),
+		})
+		return
+	}
+	defer file.Close()
+	serveDownload(c, file, etag, filename, contentType, modTime)
+}
+
+// serveDownload sends a file as an attachment. Range requests are answered with the parts asked for,
+// so interrupted downloads resume; the ETag lets If-Range check the file is still the same.
+func serveDownload(c *gin.Context, content io.ReadSeeker, etag, filename, contentType string, modTime time.Time) {
+	c.Header("Content-Type", contentType)
+	c.Header("Content-Disposition", `attachment; filename="`+filename+`"`)
+	c.Header("ETag", `"`+etag+`"`)
+	http.ServeContent(c.Writer, c.Request, filename, modTime, content)
+}
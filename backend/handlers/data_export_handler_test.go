@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequestBaseURL(t *testing.T) {
+	base := func(header string) string {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request, _ = http.NewRequest(http.MethodPost, "http://api.example.com/api/profile/export", nil)
+		if header != "" {
+			c.Request.Header.Set("X-Forwarded-Proto", header)
+		}
+		return requestBaseURL(c)
+	}
+
+	assert.Equal(t, "http://api.example.com", base(""))
+	assert.Equal(t, "https://api.example.com", base("https"))
+	assert.Equal(t, "http://api.example.com", base("javascript"))
+}
+
+func TestDataExportHandlers(t *testing.T) {
+	t.Run("missing authentication", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest(http.MethodPost, "/profile/export", nil)
+		RequestDataExport(c)
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+		w = httptest.NewRecorder()
+		c, _ = gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest(http.MethodGet, "/profile/exports", nil)
+		GetDataExports(c)
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
+	t.Run("download needs a zip file name", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest(http.MethodGet, "/public/exports/abc", nil)
+		c.Params = gin.Params{{Key: "file", Value: "abc"}}
+
+		DownloadDataExport(c)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
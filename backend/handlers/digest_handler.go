@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+
+	"sykell-analyze/backend/config"
+	"sykell-analyze/backend/digest"
+	"sykell-analyze/backend/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// loadDigestSettings reads the user's digest schedule
+func loadDigestSettings(ctx context.Context, userID interface{}) (models.DigestSettings, error) {
+	s := models.DigestSettings{Available: config.App.Digest.Enabled}
+	err := config.DBFor(ctx).QueryRow(
+		"SELECT digest_frequency, digest_hour, digest_last_sent_at FROM users WHERE id = ?", userID,
+	).Scan(&s.Frequency, &s.Hour, &s.LastSentAt)
+	return s, err
+}
+
+// GetDigestSettings returns the user's activity digest schedule
+func GetDigestSettings(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Authentication required",
+		})
+		return
+	}
+
+	settings, err := loadDigestSettings(c.Request.Context(), userID)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "User not found",
+		})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Database error",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": settings,
+	})
+}
+
+// UpdateDigestSettings subscribes the user to a daily or weekly activity digest, or turns it off
+func UpdateDigestSettings(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Authentication required",
+		})
+		return
+	}
+
+	var req models.DigestSettingsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request format",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	if req.Frequency != digest.FrequencyOff && !config.App.Digest.Enabled {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Email digests are not enabled on this server",
+		})
+		return
+	}
+
+	_, err := requestDB(c).Exec(
+		"UPDATE users SET digest_frequency = ?, digest_hour = COALESCE(?, digest_hour) WHERE id = ?",
+		req.Frequency, req.Hour, userID,
+	)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to save digest settings",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	settings, err := loadDigestSettings(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Database error",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Digest settings saved",
+		"data":    settings,
+	})
+}
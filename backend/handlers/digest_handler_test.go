@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"sykell-analyze/backend/config"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUpdateDigestSettings(t *testing.T) {
+	put := func(body string, authenticated bool) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest(http.MethodPut, "/profile/digest", bytes.NewBufferString(body))
+		c.Request.Header.Set("Content-Type", "application/json")
+		if authenticated {
+			c.Set("user_id", 1)
+		}
+
+		UpdateDigestSettings(c)
+		return w
+	}
+
+	t.Run("missing authentication", func(t *testing.T) {
+		assert.Equal(t, http.StatusUnauthorized, put(`{"frequency":"daily"}`, false).Code)
+	})
+
+	t.Run("invalid settings", func(t *testing.T) {
+		assert.Equal(t, http.StatusBadRequest, put(`{"frequency":"hourly"}`, true).Code)
+		assert.Equal(t, http.StatusBadRequest, put(`{"frequency":"daily","hour":24}`, true).Code)
+		assert.Equal(t, http.StatusBadRequest, put(`{}`, true).Code)
+	})
+
+	t.Run("digests disabled on the server", func(t *testing.T) {
+		original := config.App
+		defer func() { config.App = original }()
+		config.App.Digest.Enabled = false
+
+		w := put(`{"frequency":"weekly","hour":7}`, true)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "not enabled")
+	})
+}
@@ -0,0 +1,281 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"net/url"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"sykell-analyze/backend/analyzer"
+	"sykell-analyze/backend/archive"
+	"sykell-analyze/backend/config"
+	"sykell-analyze/backend/models"
+	"sykell-analyze/backend/urlstatus"
+
+	"github.com/gin-gonic/gin"
+)
+
+// trackingParams are query parameters added by campaigns and ad clicks; they never change the page
+var trackingParams = []string{"utm_*", "gclid", "gclsrc", "dclid", "fbclid", "msclkid", "yclid", "mc_cid", "mc_eid", "_ga", "_hsenc", "_hsmi"}
+
+// duplicateKey normalizes a URL for duplicate detection: the scheme, a www. prefix, a trailing slash,
+// the fragment and tracking parameters (plus crawler.ignore_query_params) do not count
+func duplicateKey(rawURL string) string {
+	ignore := append(slices.Clone(trackingParams), config.App.Crawler.IgnoreQueryParams...)
+	canonical := analyzer.New(analyzer.WithOptions(analyzer.Options{
+		IgnoreTrailingSlash: true,
+		IgnoreQueryParams:   ignore,
+	})).Canonical(rawURL)
+
+	u, err := url.Parse(canonical)
+	if err != nil || u.Host == "" {
+		return strings.ToLower(rawURL)
+	}
+	key := strings.TrimPrefix(u.Host, "www.") + strings.TrimSuffix(u.EscapedPath(), "/")
+	if u.RawQuery != "" {
+		key += "?" + u.RawQuery
+	}
+	return key
+}
+
+// groupDuplicates groups URLs by duplicateKey and returns the groups with more than one URL, by key
+func groupDuplicates(urls []models.DuplicateUrl) []models.DuplicateGroup {
+	byKey := make(map[string][]models.DuplicateUrl)
+	for _, u := range urls {
+		key := duplicateKey(u.Url)
+		byKey[key] = append(byKey[key], u)
+	}
+
+	groups := []models.DuplicateGroup{}
+	for key, members := range byKey {
+		if len(members) < 2 {
+			continue
+		}
+		sort.Slice(members, func(i, j int) bool { return members[i].ID < members[j].ID })
+		groups = append(groups, models.DuplicateGroup{Key: key, KeepID: keepDuplicate(members).ID, Urls: members})
+	}
+	sort.Slice(groups, func(i, j int) bool { return groups[i].Key < groups[j].Key })
+	return groups
+}
+
+// keepDuplicate picks the URL of a group worth keeping: a completed analysis first, then the most
+// recent crawl, then the oldest URL. members are sorted by ID.
+func keepDuplicate(members []models.DuplicateUrl) models.DuplicateUrl {
+	keep := members[0]
+	for _, u := range members[1:] {
+		if (u.Status == "completed") != (keep.Status == "completed") {
+			if u.Status == "completed" {
+				keep = u
+			}
+			continue
+		}
+		if u.LastCrawledAt != nil && (keep.LastCrawledAt == nil || u.LastCrawledAt.After(*keep.LastCrawledAt)) {
+			keep = u
+		}
+	}
+	return keep
+}
+
+// loadDuplicateGroups finds the user's duplicate URLs
+func loadDuplicateGroups(ctx context.Context, userID interface{}) ([]models.DuplicateGroup, error) {
+	rows, err := config.DBFor(ctx).Query("SELECT id, url, status, crawled_at, created_at FROM urls WHERE user_id = ?", userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var urls []models.DuplicateUrl
+	for rows.Next() {
+		var u models.DuplicateUrl
+		if err := rows.Scan(&u.ID, &u.Url, &u.Status, &u.LastCrawledAt, &u.CreatedAt); err != nil {
+			continue // skip bad rows
+		}
+		urls = append(urls, u)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return groupDuplicates(urls), nil
+}
+
+// mergeDuplicateGroup keeps the group's KeepID URL and deletes the others. The kept URL takes over
+// their tags, up to maxTagsPerUrl, and their project when it has none.
+func mergeDuplicateGroup(tx *sql.Tx, userID interface{}, group models.DuplicateGroup) (int64, error) {
+	var others []interface{}
+	for _, u := range group.Urls {
+		if u.ID != group.KeepID {
+			others = append(others, u.ID)
+		}
+	}
+	inOthers := "(" + placeholders(len(others)) + ")"
+
+	// The kept URL's own tags come first, so they survive the limit
+	rows, err := tx.Query(
+		"SELECT tag FROM url_tags WHERE url_id = ? OR url_id IN "+inOthers+" ORDER BY url_id = ? DESC, created_at, tag",
+		append(append([]interface{}{group.KeepID}, others...), group.KeepID)...,
+	)
+	if err != nil {
+		return 0, err
+	}
+	var tags []string
+	for rows.Next() {
+		var tag string
+		if err := rows.Scan(&tag); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		if !slices.ContainsFunc(tags, func(t string) bool { return strings.EqualFold(t, tag) }) && len(tags) < maxTagsPerUrl {
+			tags = append(tags, tag)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	if _, err := tx.Exec("DELETE FROM url_tags WHERE url_id = ?", group.KeepID); err != nil {
+		return 0, err
+	}
+	now := time.Now()
+	for _, tag := range tags {
+		if _, err := tx.Exec("INSERT INTO url_tags (url_id, tag, created_at) VALUES (?, ?, ?)", group.KeepID, tag, now); err != nil {
+			return 0, err
+		}
+	}
+
+	if _, err := tx.Exec("UPDATE urls SET version = version + 1 WHERE id = ?", group.KeepID); err != nil {
+		return 0, err
+	}
+
+	var project sql.NullString
+	err = tx.QueryRow(
+		"SELECT project FROM urls WHERE project IS NOT NULL AND id IN "+inOthers+" ORDER BY id LIMIT 1", others...,
+	).Scan(&project)
+	if err != nil && err != sql.ErrNoRows {
+		return 0, err
+	}
+	if project.Valid {
+		if _, err := tx.Exec("UPDATE urls SET project = ? WHERE id = ? AND project IS NULL", project.String, group.KeepID); err != nil {
+			return 0, err
+		}
+	}
+
+	if err := urlstatus.UncountTx(tx, userID, others); err != nil {
+		return 0, err
+	}
+	result, err := tx.Exec("DELETE FROM urls WHERE user_id = ? AND id IN "+inOthers, append([]interface{}{userID}, others...)...)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// GetDuplicateUrls lists the user's URLs that point to the same page after normalization
+func GetDuplicateUrls(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Authentication required",
+		})
+		return
+	}
+
+	groups, err := loadDuplicateGroups(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Database query failed",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	duplicates := 0
+	for _, group := range groups {
+		duplicates += len(group.Urls) - 1
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":            groups,
+		"duplicate_count": duplicates,
+	})
+}
+
+// MergeDuplicateUrls keeps one URL of each duplicate group and deletes the rest
+func MergeDuplicateUrls(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Authentication required",
+		})
+		return
+	}
+
+	var req models.MergeDuplicatesRequest
+	// The body is optional
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid request format",
+				"details": err.Error(),
+			})
+			return
+		}
+	}
+
+	groups, err := loadDuplicateGroups(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Database query failed",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	var merged []models.DuplicateGroup
+	var deleted int64
+	err = config.WithTransaction(c.Request.Context(), func(tx *sql.Tx) error {
+		merged, deleted = nil, 0
+		for _, group := range groups {
+			if len(req.Keys) > 0 && !slices.Contains(req.Keys, group.Key) {
+				continue
+			}
+			n, err := mergeDuplicateGroup(tx, userID, group)
+			if err != nil {
+				return err
+			}
+			merged = append(merged, group)
+			deleted += n
+		}
+		return nil
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to merge duplicates",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	kept := []int{}
+	var removed []int
+	for _, group := range merged {
+		kept = append(kept, group.KeepID)
+		for _, u := range group.Urls {
+			if u.ID != group.KeepID {
+				removed = append(removed, u.ID)
+			}
+		}
+	}
+	go archive.ForgetUrls(c.Request.Context(), userID, removed)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":       "Duplicate URLs merged",
+		"merged_groups": len(merged),
+		"deleted_count": deleted,
+		"kept_ids":      kept,
+	})
+}
@@ -0,0 +1,52 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+
+	"sykell-analyze/backend/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDuplicateKey(t *testing.T) {
+	same := []string{
+		"https://example.com/pricing",
+		"http://www.example.com/pricing/",
+		"https://EXAMPLE.com/pricing?utm_source=newsletter&utm_medium=email",
+		"https://example.com/pricing#plans",
+		"https://example.com/pricing?fbclid=abc",
+	}
+	for _, u := range same {
+		assert.Equal(t, "example.com/pricing", duplicateKey(u), u)
+	}
+
+	assert.Equal(t, duplicateKey("https://example.com"), duplicateKey("https://www.example.com/"))
+	assert.NotEqual(t, duplicateKey("https://example.com/pricing"), duplicateKey("https://example.com/pricing?plan=pro"))
+	assert.NotEqual(t, duplicateKey("https://example.com/pricing"), duplicateKey("https://shop.example.com/pricing"))
+}
+
+func TestGroupDuplicates(t *testing.T) {
+	earlier := time.Now().Add(-48 * time.Hour)
+	later := time.Now().Add(-time.Hour)
+	urls := []models.DuplicateUrl{
+		{ID: 1, Url: "https://example.com/", Status: "error"},
+		{ID: 2, Url: "https://www.example.com", Status: "completed", LastCrawledAt: &earlier},
+		{ID: 3, Url: "https://example.com/?utm_campaign=spring", Status: "completed", LastCrawledAt: &later},
+		{ID: 4, Url: "https://example.com/about", Status: "completed"},
+		{ID: 5, Url: "https://other.example/", Status: "queued"},
+		{ID: 6, Url: "http://other.example", Status: "queued"},
+	}
+
+	groups := groupDuplicates(urls)
+	if assert.Len(t, groups, 2) {
+		assert.Equal(t, "example.com", groups[0].Key)
+		assert.Equal(t, 3, groups[0].KeepID, "the most recent completed analysis is kept")
+		assert.Len(t, groups[0].Urls, 3)
+
+		assert.Equal(t, "other.example", groups[1].Key)
+		assert.Equal(t, 5, groups[1].KeepID, "without a completed analysis the oldest URL is kept")
+	}
+
+	assert.Empty(t, groupDuplicates(urls[3:5]))
+}
@@ -0,0 +1,184 @@
+package handlers
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+	"time"
+
+	"sykell-analyze/backend/analyzer"
+	"sykell-analyze/backend/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetLinkExclusions lists the user's exclusion patterns, optionally filtered by url_id
+func GetLinkExclusions(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Authentication required",
+		})
+		return
+	}
+
+	query := `
+		SELECT id, user_id, url_id, pattern, pattern_type, created_at
+		FROM link_exclusions
+		WHERE user_id = ?
+	`
+	args := []interface{}{userID}
+
+	if urlID := c.Query("url_id"); urlID != "" {
+		id, err := strconv.Atoi(urlID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Invalid url_id",
+			})
+			return
+		}
+		query += " AND (url_id IS NULL OR url_id = ?)"
+		args = append(args, id)
+	}
+
+	query += " ORDER BY created_at DESC"
+
+	rows, err := requestDB(c).Query(query, args...)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Database query failed",
+			"details": err.Error(),
+		})
+		return
+	}
+	defer rows.Close()
+
+	exclusions := []models.LinkExclusion{}
+	for rows.Next() {
+		var e models.LinkExclusion
+		if err := rows.Scan(&e.ID, &e.UserID, &e.UrlID, &e.Pattern, &e.PatternType, &e.CreatedAt); err != nil {
+			continue // skip bad rows
+		}
+		exclusions = append(exclusions, e)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": exclusions,
+	})
+}
+
+// AddLinkExclusion stores a new exclusion pattern for the account or a single URL
+func AddLinkExclusion(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Authentication required",
+		})
+		return
+	}
+
+	var req models.LinkExclusionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request format",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	if req.PatternType == "" {
+		req.PatternType = "glob"
+	}
+
+	if _, err := analyzer.CompileLinkExclusion(analyzer.LinkExclusion{Pattern: req.Pattern, PatternType: req.PatternType}); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid exclusion pattern",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	// Verify URL ownership when scoping the exclusion to a single URL
+	if req.UrlID != nil {
+		var ownedID int
+		err := requestDB(c).QueryRow("SELECT id FROM urls WHERE id = ? AND user_id = ?", *req.UrlID, userID).Scan(&ownedID)
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "URL not found",
+			})
+			return
+		} else if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Database error",
+			})
+			return
+		}
+	}
+
+	now := time.Now()
+	result, err := requestDB(c).Exec(
+		"INSERT INTO link_exclusions (user_id, url_id, pattern, pattern_type, created_at) VALUES (?, ?, ?, ?, ?)",
+		userID, req.UrlID, req.Pattern, req.PatternType, now,
+	)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to save exclusion",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	id, _ := result.LastInsertId()
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Exclusion created",
+		"data": models.LinkExclusion{
+			ID:          int(id),
+			UserID:      userID.(int),
+			UrlID:       req.UrlID,
+			Pattern:     req.Pattern,
+			PatternType: req.PatternType,
+			CreatedAt:   now,
+		},
+	})
+}
+
+// DeleteLinkExclusion removes an exclusion pattern owned by the user
+func DeleteLinkExclusion(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Authentication required",
+		})
+		return
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid exclusion ID",
+		})
+		return
+	}
+
+	result, err := requestDB(c).Exec("DELETE FROM link_exclusions WHERE id = ? AND user_id = ?", id, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to delete exclusion",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Exclusion not found",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Exclusion deleted successfully",
+	})
+}
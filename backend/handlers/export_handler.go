@@ -0,0 +1,255 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"sykell-analyze/backend/analyzer"
+	"sykell-analyze/backend/config"
+	"sykell-analyze/backend/i18n"
+	"sykell-analyze/backend/middleware"
+	"sykell-analyze/backend/sanitize"
+	"sykell-analyze/backend/xlsx"
+
+	"github.com/gin-gonic/gin"
+)
+
+// xlsxContentType is the media type of Excel workbooks
+const xlsxContentType = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+
+// urlExportQuery selects the rows of the URLs sheet of an export, run with the user's ID
+const urlExportQuery = `
+	SELECT id, url, status, COALESCE(title, ''), COALESCE(html_version, ''), h1_count, h2_count, h3_count,
+		internal_links, external_links, broken_links, has_login_form, is_noindex, is_nofollow,
+		COALESCE(error_message, ''), crawled_at, created_at
+	FROM urls WHERE user_id = ? ORDER BY id
+`
+
+// sheetWriter receives the sheets of an export: an Excel workbook, or CSV files
+type sheetWriter interface {
+	AddSheet(name string) error
+	WriteRow(cells ...interface{}) error
+	Close() error
+}
+
+// seoFinding is one row of the SEO findings sheet
+type seoFinding struct {
+	Category string
+	Severity string
+	Code     string
+	Message  string
+}
+
+// seoFindings lists the SEO problems of a completed analysis: on-page basics from the stored
+// counts, then the hreflang and link hygiene findings of the crawl
+func seoFindings(title string, h1Count, brokenLinks int, noindex, nofollow bool, hreflang analyzer.Hreflang, hygiene analyzer.LinkHygiene) []seoFinding {
+	var findings []seoFinding
+	if strings.TrimSpace(title) == "" {
+		findings = append(findings, seoFinding{"on-page", "error", "missing_title", "The page has no title"})
+	}
+	switch {
+	case h1Count == 0:
+		findings = append(findings, seoFinding{"on-page", "warning", "missing_h1", "The page has no h1 heading"})
+	case h1Count > 1:
+		findings = append(findings, seoFinding{"on-page", "warning", "multiple_h1", fmt.Sprintf("The page has %d h1 headings", h1Count)})
+	}
+	if brokenLinks > 0 {
+		findings = append(findings, seoFinding{"links", "error", "broken_links", fmt.Sprintf("%d broken links", brokenLinks)})
+	}
+	if noindex {
+		findings = append(findings, seoFinding{"robots", "warning", "noindex", "Search engines are asked not to index the page"})
+	}
+	if nofollow {
+		findings = append(findings, seoFinding{"robots", "warning", "nofollow", "Search engines are asked not to follow the page's links"})
+	}
+	for _, f := range hreflang.Findings {
+		findings = append(findings, seoFinding{"hreflang", f.Severity, f.Code, f.Message})
+	}
+	for _, f := range hygiene.Findings {
+		findings = append(findings, seoFinding{"link hygiene", "warning", f.Code, f.Message})
+	}
+	return findings
+}
+
+// ExportUrls downloads the user's URLs as an Excel workbook (?format=xlsx, the default) with sheets
+// for the URLs, their broken links and SEO findings. Rows are streamed from the database into the
+// response, so an error after the first byte can only be logged and leaves the download truncated;
+// large accounts should build the export in the background with POST /exports instead.
+func ExportUrls(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Authentication required",
+		})
+		return
+	}
+
+	if format := c.DefaultQuery("format", "xlsx"); format != "xlsx" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Unsupported export format, use xlsx",
+		})
+		return
+	}
+
+	rows, err := requestDB(c).Query(urlExportQuery, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Database query failed",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	loc := userLocation(c.Request.Context(), userID)
+	c.Header("Content-Type", xlsxContentType)
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="urls-%s.xlsx"`, time.Now().In(loc).Format("2006-01-02")))
+	c.Status(http.StatusOK)
+
+	if err := writeExport(c.Request.Context(), xlsx.NewWriter(c.Writer), rows, userID, c.GetString(middleware.LangKey), loc); err != nil {
+		fmt.Printf("DEBUG: Export for user %v failed: %v\n", userID, err)
+	}
+}
+
+// labels translates sheet header labels into lang as a row
+func labels(lang string, texts ...string) []interface{} {
+	row := make([]interface{}, len(texts))
+	for i, text := range texts {
+		row[i] = i18n.T(lang, text)
+	}
+	return row
+}
+
+// writeExport writes the three sheets of an export with their names and headers in lang and times
+// in loc; urls is the open query of the URLs sheet
+func writeExport(ctx context.Context, w sheetWriter, urls *sql.Rows, userID interface{}, lang string, loc *time.Location) error {
+	defer urls.Close()
+
+	if err := w.AddSheet(i18n.T(lang, "URLs")); err != nil {
+		return err
+	}
+	if err := w.WriteRow(labels(lang, "ID", "URL", "Status", "Title", "HTML version", "H1", "H2", "H3",
+		"Internal links", "External links", "Broken links", "Login form", "Noindex", "Nofollow",
+		"Error", "Crawled at", "Created at")...); err != nil {
+		return err
+	}
+	for urls.Next() {
+		var id, h1, h2, h3, internal, external, broken int
+		var pageURL, status, title, htmlVersion, errorMessage string
+		var loginForm, noindex, nofollow bool
+		var crawledAt sql.NullTime
+		var createdAt time.Time
+		if err := urls.Scan(&id, &pageURL, &status, &title, &htmlVersion, &h1, &h2, &h3, &internal, &external,
+			&broken, &loginForm, &noindex, &nofollow, &errorMessage, &crawledAt, &createdAt); err != nil {
+			return err
+		}
+		title, errorMessage = sanitize.Line(title, sanitize.MaxTitle), sanitize.Line(errorMessage, sanitize.MaxMessage)
+		if err := w.WriteRow(id, pageURL, status, title, htmlVersion, h1, h2, h3, internal, external, broken,
+			loginForm, noindex, nofollow, errorMessage, crawledAt.Time.In(loc), createdAt.In(loc)); err != nil {
+			return err
+		}
+	}
+	if err := urls.Err(); err != nil {
+		return err
+	}
+
+	if err := writeBrokenLinksSheet(ctx, w, userID, lang); err != nil {
+		return err
+	}
+	if err := writeFindingsSheet(ctx, w, userID, lang); err != nil {
+		return err
+	}
+	return w.Close()
+}
+
+// writeBrokenLinksSheet lists the broken links of every URL of the user
+func writeBrokenLinksSheet(ctx context.Context, w sheetWriter, userID interface{}, lang string) error {
+	rows, err := config.DBFor(ctx).Query(`
+		SELECT u.id, u.url, b.link_url, b.status_code, COALESCE(b.error_message, ''), b.suggestions
+		FROM broken_links b
+		JOIN urls u ON u.id = b.url_id
+		WHERE u.user_id = ?
+		ORDER BY u.id, b.id
+	`, userID)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	if err := w.AddSheet(i18n.T(lang, "Broken links")); err != nil {
+		return err
+	}
+	if err := w.WriteRow(labels(lang, "URL ID", "Page", "Link", "Status code", "Error", "Suggestions")...); err != nil {
+		return err
+	}
+	for rows.Next() {
+		var urlID int
+		var pageURL, link, errorMessage string
+		var statusCode sql.NullInt64
+		var suggestionsJSON []byte
+		if err := rows.Scan(&urlID, &pageURL, &link, &statusCode, &errorMessage, &suggestionsJSON); err != nil {
+			return err
+		}
+		link, errorMessage = sanitize.Line(link, sanitize.MaxURL), sanitize.Line(errorMessage, sanitize.MaxMessage)
+		var suggestions []string
+		if suggestionsJSON != nil {
+			json.Unmarshal(suggestionsJSON, &suggestions)
+		}
+		var code interface{}
+		if statusCode.Valid {
+			code = statusCode.Int64
+		}
+		if err := w.WriteRow(urlID, pageURL, link, code, errorMessage, strings.Join(suggestions, "\n")); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// writeFindingsSheet lists the SEO findings of every completed URL of the user; on-page messages
+// are translated into lang, crawl findings stay English
+func writeFindingsSheet(ctx context.Context, w sheetWriter, userID interface{}, lang string) error {
+	rows, err := config.DBFor(ctx).Query(`
+		SELECT id, url, COALESCE(title, ''), h1_count, broken_links, is_noindex, is_nofollow, hreflang, link_hygiene
+		FROM urls WHERE user_id = ? AND status = 'completed'
+		ORDER BY id
+	`, userID)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	if err := w.AddSheet(i18n.T(lang, "SEO findings")); err != nil {
+		return err
+	}
+	if err := w.WriteRow(labels(lang, "URL ID", "URL", "Category", "Severity", "Code", "Message")...); err != nil {
+		return err
+	}
+	for rows.Next() {
+		var id, h1, broken int
+		var pageURL, title string
+		var noindex, nofollow bool
+		var hreflangJSON, hygieneJSON []byte
+		if err := rows.Scan(&id, &pageURL, &title, &h1, &broken, &noindex, &nofollow, &hreflangJSON, &hygieneJSON); err != nil {
+			return err
+		}
+		var hreflang analyzer.Hreflang
+		var hygiene analyzer.LinkHygiene
+		if hreflangJSON != nil {
+			json.Unmarshal(hreflangJSON, &hreflang)
+		}
+		if hygieneJSON != nil {
+			json.Unmarshal(hygieneJSON, &hygiene)
+		}
+		for _, f := range seoFindings(title, h1, broken, noindex, nofollow, hreflang, hygiene) {
+			if err := w.WriteRow(id, pageURL, f.Category, f.Severity, f.Code, i18n.T(lang, f.Message)); err != nil {
+				return err
+			}
+		}
+	}
+	return rows.Err()
+}
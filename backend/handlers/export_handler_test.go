@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"sykell-analyze/backend/analyzer"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSeoFindings(t *testing.T) {
+	t.Run("healthy page", func(t *testing.T) {
+		assert.Empty(t, seoFindings("Home", 1, 0, false, false, analyzer.Hreflang{}, analyzer.LinkHygiene{}))
+	})
+
+	t.Run("lists every problem", func(t *testing.T) {
+		findings := seoFindings(" ", 2, 3, true, false,
+			analyzer.Hreflang{Findings: []analyzer.HreflangFinding{{Code: "missing_return", Severity: "error", Message: "No return link"}}},
+			analyzer.LinkHygiene{Findings: []analyzer.LinkFinding{{Code: "javascript", Message: "javascript: link"}}},
+		)
+
+		codes := make([]string, len(findings))
+		for i, f := range findings {
+			codes[i] = f.Code
+		}
+		assert.Equal(t, []string{"missing_title", "multiple_h1", "broken_links", "noindex", "missing_return", "javascript"}, codes)
+		assert.Equal(t, "3 broken links", findings[2].Message)
+		assert.Equal(t, seoFinding{"hreflang", "error", "missing_return", "No return link"}, findings[4])
+	})
+}
+
+func TestExportUrls(t *testing.T) {
+	export := func(query string, authenticated bool) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest(http.MethodGet, "/export"+query, nil)
+		if authenticated {
+			c.Set("user_id", 1)
+		}
+
+		ExportUrls(c)
+		return w
+	}
+
+	t.Run("missing authentication", func(t *testing.T) {
+		assert.Equal(t, http.StatusUnauthorized, export("", false).Code)
+	})
+
+	t.Run("unsupported format", func(t *testing.T) {
+		w := export("?format=ods", true)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "Unsupported export format")
+	})
+}
@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"sykell-analyze/backend/config"
+	"sykell-analyze/backend/version"
+	"sykell-analyze/backend/worker"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Liveness reports that the process is up and serving requests
+func Liveness(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "alive",
+		"version": version.Version,
+	})
+}
+
+// Readiness checks the database and crawl workers, returning 503 when the service cannot do useful work
+func Readiness(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 3*time.Second)
+	defer cancel()
+
+	ready := true
+	checks := gin.H{}
+
+	if config.DBFor(ctx) == nil {
+		ready = false
+		checks["database"] = gin.H{"status": "down", "error": "not connected"}
+	} else if err := config.DBFor(ctx).PingContext(ctx); err != nil {
+		ready = false
+		checks["database"] = gin.H{"status": "down", "error": err.Error()}
+	} else {
+		checks["database"] = gin.H{"status": "up"}
+	}
+
+	if ready {
+		// Workers heartbeat every HeartbeatInterval; allow a few missed beats before declaring them gone
+		window := 3 * config.App.Worker.HeartbeatInterval
+		queue, err := worker.Status(ctx, window)
+		switch {
+		case err != nil:
+			ready = false
+			checks["queue"] = gin.H{"status": "down", "error": err.Error()}
+		case queue.LiveWorkers == 0:
+			ready = false
+			checks["queue"] = gin.H{"status": "down", "error": "no live crawl workers", "details": queue}
+		default:
+			checks["queue"] = gin.H{"status": "up", "details": queue}
+		}
+	}
+
+	status := "ready"
+	code := http.StatusOK
+	if !ready {
+		status = "not ready"
+		code = http.StatusServiceUnavailable
+	}
+
+	c.JSON(code, gin.H{
+		"status": status,
+		"checks": checks,
+		"build":  version.Info(),
+	})
+}
+
+// GetVersion returns the version, commit and build date of the running binary
+func GetVersion(c *gin.Context) {
+	c.JSON(http.StatusOK, version.Info())
+}
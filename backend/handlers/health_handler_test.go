@@ -0,0 +1,47 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"sykell-analyze/backend/version"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLiveness(t *testing.T) {
+	router := setupTestRouter()
+	router.GET("/api/health/live", Liveness)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/api/health/live", nil)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var body map[string]interface{}
+	json.Unmarshal(w.Body.Bytes(), &body)
+	assert.Equal(t, "alive", body["status"])
+	assert.Equal(t, version.Version, body["version"])
+}
+
+func TestReadinessWithoutDatabase(t *testing.T) {
+	router := setupTestRouter()
+	router.GET("/api/health/ready", Readiness)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/api/health/ready", nil)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+
+	var body struct {
+		Status string                    `json:"status"`
+		Checks map[string]map[string]any `json:"checks"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &body)
+	assert.Equal(t, "not ready", body.Status)
+	assert.Equal(t, "down", body.Checks["database"]["status"])
+}
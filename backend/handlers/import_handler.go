@@ -0,0 +1,507 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"sykell-analyze/backend/config"
+	"sykell-analyze/backend/dnscache"
+	"sykell-analyze/backend/models"
+	"sykell-analyze/backend/utils"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/net/html"
+)
+
+// Limits on reading a site's sitemaps
+const (
+	maxSitemapFiles      = 20
+	maxSitemapCandidates = 10000
+	// maxSitemapBytes is the size limit of the sitemaps.org protocol
+	maxSitemapBytes = utils.MaxSitemapBytes
+	// maxBookmarksBytes bounds an uploaded bookmarks file
+	maxBookmarksBytes = 5 << 20
+)
+
+// sitemapClient fetches robots.txt and sitemaps within the crawler's target policy
+func sitemapClient() *http.Client {
+	client := config.App.Crawler.HTTPClient(dnscache.Default())
+	client.Timeout = config.App.Crawler.RequestTimeout
+	client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if len(via) >= 10 {
+			return errors.New("stopped after 10 redirects")
+		}
+		return config.App.Crawler.CheckTarget(req.URL.String())
+	}
+	return client
+}
+
+// fetchSitemapFile downloads a robots.txt or sitemap
+func fetchSitemapFile(ctx context.Context, client *http.Client, target string) ([]byte, error) {
+	if err := config.App.Crawler.CheckTarget(target); err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", config.App.Crawler.UserAgent)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s answered %d", target, resp.StatusCode)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxSitemapBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(body) > maxSitemapBytes {
+		return nil, fmt.Errorf("%s is larger than %d MB", target, maxSitemapBytes>>20)
+	}
+	return body, nil
+}
+
+// sitemapSources returns the sitemaps to read for target: target itself when it names a sitemap,
+// otherwise the Sitemap lines of the site's robots.txt, or /sitemap.xml when there are none
+func sitemapSources(ctx context.Context, client *http.Client, target *url.URL) []string {
+	if path := strings.ToLower(target.Path); strings.HasSuffix(path, ".xml") || strings.HasSuffix(path, ".xml.gz") {
+		return []string{target.String()}
+	}
+
+	origin := target.Scheme + "://" + target.Host
+	var sources []string
+	if robots, err := fetchSitemapFile(ctx, client, origin+"/robots.txt"); err == nil {
+		for _, line := range strings.Split(string(robots), "\n") {
+			name, value, ok := strings.Cut(strings.TrimSpace(line), ":")
+			if ok && strings.EqualFold(strings.TrimSpace(name), "sitemap") {
+				if loc := strings.TrimSpace(value); loc != "" && !slices.Contains(sources, loc) {
+					sources = append(sources, loc)
+				}
+			}
+		}
+	}
+	if len(sources) == 0 {
+		sources = []string{origin + "/sitemap.xml"}
+	}
+	return sources
+}
+
+// discoverSitemapUrls reads the sitemaps of target, following sitemap indexes, and returns up to
+// maxSitemapCandidates distinct pages. Every fetch shares one crawler.dry_run_timeout budget, which
+// is shorter than server.request_timeout; sitemaps left unread when it runs out mark the result
+// truncated.
+func discoverSitemapUrls(ctx context.Context, target string) (models.SitemapImport, error) {
+	found := models.SitemapImport{Sitemaps: []string{}, Urls: []string{}}
+
+	ctx, cancel := context.WithTimeout(ctx, config.App.Crawler.DryRunTimeout)
+	defer cancel()
+
+	normalized, err := validateURL(target)
+	if err != nil {
+		return found, err
+	}
+	parsed, _ := url.Parse(normalized)
+
+	client := sitemapClient()
+	queue := sitemapSources(ctx, client, parsed)
+	seen := make(map[string]bool)
+	var lastErr error
+	for len(queue) > 0 && len(found.Sitemaps) < maxSitemapFiles && ctx.Err() == nil {
+		source := queue[0]
+		queue = queue[1:]
+
+		body, err := fetchSitemapFile(ctx, client, source)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		pages, children, err := utils.ParseSitemap(body)
+		if err != nil {
+			lastErr = fmt.Errorf("%s: %w", source, err)
+			continue
+		}
+		found.Sitemaps = append(found.Sitemaps, source)
+
+		for _, child := range children {
+			if !slices.Contains(found.Sitemaps, child) && !slices.Contains(queue, child) {
+				queue = append(queue, child)
+			}
+		}
+		for _, page := range pages {
+			if seen[page] {
+				continue
+			}
+			if len(found.Urls) == maxSitemapCandidates {
+				found.Truncated = true
+				break
+			}
+			seen[page] = true
+			found.Urls = append(found.Urls, page)
+		}
+	}
+	if len(queue) > 0 || ctx.Err() != nil {
+		found.Truncated = true
+	}
+
+	if len(found.Sitemaps) == 0 {
+		if lastErr == nil {
+			lastErr = errors.New("no sitemap found")
+		}
+		return found, lastErr
+	}
+	return found, nil
+}
+
+// ImportSitemap lists the pages of a site's sitemaps, and queues them once the user confirms
+func ImportSitemap(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Authentication required",
+		})
+		return
+	}
+
+	var req models.SitemapImportRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request format",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	limit := bulkUrlMax()
+	if req.Limit > limit {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("Too many URLs: at most %d can be imported at once", limit),
+		})
+		return
+	} else if req.Limit > 0 {
+		limit = req.Limit
+	}
+
+	found, err := discoverSitemapUrls(c.Request.Context(), req.URL)
+	if err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{
+			"error":   "Could not read the sitemap",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	if !req.Confirm {
+		existing, err := existingUrls(c.Request.Context(), userID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error":   "Database error",
+				"details": err.Error(),
+			})
+			return
+		}
+		for _, page := range found.Urls {
+			if !existing[normalizeURL(page)] {
+				found.NewCount++
+			}
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"data": found,
+		})
+		return
+	}
+
+	// Only pages that are in the sitemaps can be confirmed
+	selected := found.Urls
+	if len(req.URLs) > 0 {
+		selected = nil
+		for _, page := range req.URLs {
+			if slices.Contains(found.Urls, page) {
+				selected = append(selected, page)
+			}
+		}
+	}
+	if len(selected) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "None of the URLs are in the sitemap",
+		})
+		return
+	}
+	if len(selected) > limit {
+		selected = selected[:limit]
+	}
+
+	opts, err := enqueueOptions(c, req.Priority)
+	if err != nil {
+		respondInvalidOptions(c, err)
+		return
+	}
+	settings, ok := crawlSettingsFor(c, userID, req.Settings)
+	if !ok {
+		return
+	}
+
+	results, created, ok := addUrls(c, userID, selected, opts, settings)
+	if !ok {
+		return
+	}
+
+	status := http.StatusOK
+	if created > 0 {
+		status = http.StatusCreated
+	}
+
+	c.JSON(status, gin.H{
+		"message":       fmt.Sprintf("%d URL(s) queued for analysis", created),
+		"created_count": created,
+		"results":       results,
+		"skipped_count": len(found.Urls) - len(selected),
+	})
+}
+
+// existingUrls returns the set of the user's URLs
+func existingUrls(ctx context.Context, userID interface{}) (map[string]bool, error) {
+	rows, err := config.DBFor(ctx).Query("SELECT url FROM urls WHERE user_id = ?", userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	existing := make(map[string]bool)
+	for rows.Next() {
+		var u string
+		if err := rows.Scan(&u); err != nil {
+			return nil, err
+		}
+		existing[u] = true
+	}
+	return existing, rows.Err()
+}
+
+// bookmark is a link of a bookmarks file with the folders it is filed under, outermost first
+type bookmark struct {
+	URL     string
+	Folders []string
+}
+
+// parseBookmarks reads the Netscape bookmark file browsers export. Links that are not http(s), such
+// as javascript: bookmarklets, are skipped, and a link filed twice keeps the folders of both.
+// The browsers' own top folders, like the bookmarks toolbar, do not count as folders.
+func parseBookmarks(r io.Reader) ([]bookmark, error) {
+	var bookmarks []bookmark
+	index := make(map[string]int)
+	// folders has one entry per open <DL>: the heading that introduced it, or "" for none
+	var folders []string
+	var heading strings.Builder
+	inHeading, pending := false, ""
+
+	tokenizer := html.NewTokenizer(r)
+	for {
+		switch tokenizer.Next() {
+		case html.ErrorToken:
+			if err := tokenizer.Err(); err != io.EOF {
+				return nil, err
+			}
+			return bookmarks, nil
+
+		case html.StartTagToken:
+			token := tokenizer.Token()
+			switch token.Data {
+			case "h3":
+				inHeading, pending = true, ""
+				heading.Reset()
+				for _, attr := range token.Attr {
+					if attr.Key == "personal_toolbar_folder" || attr.Key == "unfiled_bookmarks_folder" {
+						inHeading = false
+					}
+				}
+			case "dl":
+				folders = append(folders, pending)
+				pending = ""
+			case "a":
+				for _, attr := range token.Attr {
+					if attr.Key != "href" {
+						continue
+					}
+					link := strings.TrimSpace(attr.Val)
+					if !strings.HasPrefix(link, "http://") && !strings.HasPrefix(link, "https://") {
+						break
+					}
+					var path []string
+					for _, folder := range folders {
+						if folder != "" && !slices.Contains(path, folder) {
+							path = append(path, folder)
+						}
+					}
+					if i, ok := index[link]; ok {
+						for _, folder := range path {
+							if !slices.Contains(bookmarks[i].Folders, folder) {
+								bookmarks[i].Folders = append(bookmarks[i].Folders, folder)
+							}
+						}
+						break
+					}
+					index[link] = len(bookmarks)
+					bookmarks = append(bookmarks, bookmark{URL: link, Folders: path})
+				}
+			}
+
+		case html.TextToken:
+			if inHeading {
+				heading.Write(tokenizer.Text())
+			}
+
+		case html.EndTagToken:
+			switch name, _ := tokenizer.TagName(); string(name) {
+			case "h3":
+				if inHeading {
+					pending = strings.Join(strings.Fields(heading.String()), " ")
+				}
+				inHeading = false
+			case "dl":
+				if len(folders) > 0 {
+					folders = folders[:len(folders)-1]
+				}
+			}
+		}
+	}
+}
+
+// folderTags turns bookmark folders into tags: commas become spaces, long names are cut and the
+// innermost folders are kept when there are more than maxTagsPerUrl
+func folderTags(folders []string) []string {
+	var tags []string
+	for _, folder := range folders {
+		tag := strings.Join(strings.Fields(strings.ReplaceAll(folder, ",", " ")), " ")
+		for runes := []rune(tag); len(tag) > maxTagLength; tag = strings.TrimSpace(string(runes)) {
+			runes = runes[:len(runes)-1]
+		}
+		if tag != "" && !slices.ContainsFunc(tags, func(t string) bool { return strings.EqualFold(t, tag) }) {
+			tags = append(tags, tag)
+		}
+	}
+	if len(tags) > maxTagsPerUrl {
+		tags = tags[len(tags)-maxTagsPerUrl:]
+	}
+	return tags
+}
+
+// ImportBookmarks queues the links of an uploaded bookmarks.html, tagging each new URL with its folders
+func ImportBookmarks(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Authentication required",
+		})
+		return
+	}
+
+	header, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Upload the bookmarks file as the file field of a multipart form",
+			"details": err.Error(),
+		})
+		return
+	}
+	if header.Size > maxBookmarksBytes {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{
+			"error": fmt.Sprintf("The bookmarks file is larger than %d MB", maxBookmarksBytes>>20),
+		})
+		return
+	}
+	file, err := header.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Could not read the upload",
+			"details": err.Error(),
+		})
+		return
+	}
+	defer file.Close()
+
+	bookmarks, err := parseBookmarks(io.LimitReader(file, maxBookmarksBytes))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Could not read the bookmarks file",
+			"details": err.Error(),
+		})
+		return
+	}
+	if len(bookmarks) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "No links found; export your bookmarks as HTML from the browser",
+		})
+		return
+	}
+	if max := bulkUrlMax(); len(bookmarks) > max {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("Too many URLs: the file has %d links and at most %d can be imported at once", len(bookmarks), max),
+		})
+		return
+	}
+
+	opts, err := enqueueOptions(c, c.PostForm("priority"))
+	if err != nil {
+		respondInvalidOptions(c, err)
+		return
+	}
+	settings, ok := crawlSettingsFor(c, userID, nil)
+	if !ok {
+		return
+	}
+
+	inputs := make([]string, len(bookmarks))
+	for i, b := range bookmarks {
+		inputs[i] = b.URL
+	}
+	results, created, ok := addUrls(c, userID, inputs, opts, settings)
+	if !ok {
+		return
+	}
+
+	// URLs that already existed keep their tags
+	err = config.WithTransaction(c.Request.Context(), func(tx *sql.Tx) error {
+		now := time.Now()
+		for i := range results {
+			if results[i].Status != "created" {
+				continue
+			}
+			results[i].Tags = folderTags(bookmarks[i].Folders)
+			for _, tag := range results[i].Tags {
+				if _, err := tx.Exec("INSERT IGNORE INTO url_tags (url_id, tag, created_at) VALUES (?, ?, ?)", results[i].ID, tag, now); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		fmt.Printf("DEBUG: Failed to tag imported bookmarks: %v\n", err)
+		for i := range results {
+			results[i].Tags = nil
+		}
+	}
+
+	status := http.StatusOK
+	if created > 0 {
+		status = http.StatusCreated
+	}
+
+	c.JSON(status, gin.H{
+		"message":       fmt.Sprintf("%d URL(s) queued for analysis", created),
+		"created_count": created,
+		"results":       results,
+	})
+}
@@ -0,0 +1,185 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"sykell-analyze/backend/config"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiscoverSitemapUrls(t *testing.T) {
+	allowPrivateNetworks(t)
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	mux.HandleFunc("/robots.txt", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "User-agent: *\nDisallow: /admin\nSitemap: %s/sitemap_index.xml\n", server.URL)
+	})
+	mux.HandleFunc("/sitemap_index.xml", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `<sitemapindex><sitemap><loc>%[1]s/pages.xml</loc></sitemap><sitemap><loc>%[1]s/posts.xml</loc></sitemap></sitemapindex>`, server.URL)
+	})
+	mux.HandleFunc("/pages.xml", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `<urlset><url><loc>%[1]s/</loc></url><url><loc>%[1]s/about</loc></url></urlset>`, server.URL)
+	})
+	mux.HandleFunc("/posts.xml", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `<urlset><url><loc>%[1]s/about</loc></url><url><loc>%[1]s/blog/hello</loc></url></urlset>`, server.URL)
+	})
+
+	t.Run("domain root follows robots.txt and sitemap indexes", func(t *testing.T) {
+		found, err := discoverSitemapUrls(context.Background(), server.URL)
+		require.NoError(t, err)
+		assert.Equal(t, []string{server.URL + "/sitemap_index.xml", server.URL + "/pages.xml", server.URL + "/posts.xml"}, found.Sitemaps)
+		assert.Equal(t, []string{server.URL + "/", server.URL + "/about", server.URL + "/blog/hello"}, found.Urls)
+		assert.False(t, found.Truncated)
+	})
+
+	t.Run("sitemap URL is read directly", func(t *testing.T) {
+		found, err := discoverSitemapUrls(context.Background(), server.URL+"/posts.xml")
+		require.NoError(t, err)
+		assert.Equal(t, []string{server.URL + "/posts.xml"}, found.Sitemaps)
+		assert.Len(t, found.Urls, 2)
+	})
+
+	t.Run("no sitemap", func(t *testing.T) {
+		_, err := discoverSitemapUrls(context.Background(), server.URL+"/missing.xml")
+		assert.Error(t, err)
+	})
+
+	t.Run("slow sitemaps share one deadline", func(t *testing.T) {
+		mux.HandleFunc("/slow_index.xml", func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprintf(w, `<sitemapindex><sitemap><loc>%[1]s/slow.xml?1</loc></sitemap><sitemap><loc>%[1]s/slow.xml?2</loc></sitemap></sitemapindex>`, server.URL)
+		})
+		mux.HandleFunc("/slow.xml", func(w http.ResponseWriter, r *http.Request) {
+			select {
+			case <-time.After(time.Second):
+			case <-r.Context().Done():
+			}
+		})
+		previous := config.App
+		cfg := *config.App
+		cfg.Crawler.DryRunTimeout = 200 * time.Millisecond
+		config.App = &cfg
+		defer func() { config.App = previous }()
+
+		start := time.Now()
+		found, err := discoverSitemapUrls(context.Background(), server.URL+"/slow_index.xml")
+		require.NoError(t, err)
+		assert.Less(t, time.Since(start), 900*time.Millisecond)
+		assert.Equal(t, []string{server.URL + "/slow_index.xml"}, found.Sitemaps)
+		assert.True(t, found.Truncated)
+	})
+}
+
+func TestImportSitemap(t *testing.T) {
+	post := func(body string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(http.MethodPost, "/import/sitemap", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = req
+		c.Set("user_id", 1)
+
+		ImportSitemap(c)
+		return w
+	}
+
+	t.Run("missing URL", func(t *testing.T) {
+		w := post(`{}`)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("limit above the bulk maximum", func(t *testing.T) {
+		w := post(fmt.Sprintf(`{"url": "example.com", "limit": %d}`, bulkUrlMax()+1))
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "Too many URLs")
+	})
+}
+
+const testBookmarks = `<!DOCTYPE NETSCAPE-Bookmark-file-1>
+<META HTTP-EQUIV="Content-Type" CONTENT="text/html; charset=UTF-8">
+<TITLE>Bookmarks</TITLE>
+<H1>Bookmarks</H1>
+<DL><p>
+    <DT><H3 ADD_DATE="1700000000" PERSONAL_TOOLBAR_FOLDER="true">Bookmarks bar</H3>
+    <DL><p>
+        <DT><A HREF="https://example.com/" ADD_DATE="1700000000">Example</A>
+        <DT><H3>Clients, 2026</H3>
+        <DL><p>
+            <DT><A HREF="https://shop.example/">Shop</A>
+            <DT><H3>Landing pages</H3>
+            <DL><p>
+                <DT><A HREF="https://shop.example/spring">Spring</A>
+            </DL><p>
+            <DT><A HREF="javascript:alert(1)">Bookmarklet</A>
+        </DL><p>
+    </DL><p>
+    <DT><H3>Reading</H3>
+    <DL><p>
+        <DT><A HREF="https://shop.example/">Shop again</A>
+        <DT><A HREF="place:sort=8">Recent</A>
+    </DL><p>
+</DL>`
+
+func TestParseBookmarks(t *testing.T) {
+	bookmarks, err := parseBookmarks(strings.NewReader(testBookmarks))
+	require.NoError(t, err)
+	assert.Equal(t, []bookmark{
+		{URL: "https://example.com/"},
+		{URL: "https://shop.example/", Folders: []string{"Clients, 2026", "Reading"}},
+		{URL: "https://shop.example/spring", Folders: []string{"Clients, 2026", "Landing pages"}},
+	}, bookmarks)
+}
+
+func TestFolderTags(t *testing.T) {
+	assert.Equal(t, []string{"Clients 2026", "Landing pages"}, folderTags([]string{"Clients, 2026", "Landing pages", "landing  pages"}))
+	assert.Empty(t, folderTags(nil))
+
+	long := folderTags([]string{strings.Repeat("ü", maxTagLength)})
+	require.Len(t, long, 1)
+	assert.LessOrEqual(t, len(long[0]), maxTagLength)
+}
+
+func TestImportBookmarks(t *testing.T) {
+	upload := func(field, content string) *httptest.ResponseRecorder {
+		var body bytes.Buffer
+		form := multipart.NewWriter(&body)
+		part, _ := form.CreateFormFile(field, "bookmarks.html")
+		part.Write([]byte(content))
+		form.Close()
+
+		req, _ := http.NewRequest(http.MethodPost, "/import/bookmarks", &body)
+		req.Header.Set("Content-Type", form.FormDataContentType())
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = req
+		c.Set("user_id", 1)
+
+		ImportBookmarks(c)
+		return w
+	}
+
+	t.Run("missing file", func(t *testing.T) {
+		w := upload("bookmarks", testBookmarks)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("no links", func(t *testing.T) {
+		w := upload("file", `<DL><p><DT><H3>Empty</H3><DL><p></DL><p></DL>`)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "No links found")
+	})
+}
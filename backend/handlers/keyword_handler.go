@@ -0,0 +1,205 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+	"unicode"
+
+	"sykell-analyze/backend/config"
+	"sykell-analyze/backend/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Limits on the target keywords of a URL
+const (
+	maxKeywordsPerUrl = 20
+	maxKeywordLength  = 100
+)
+
+// normalizeKeywords trims and collapses whitespace in each keyword and drops case-insensitive duplicates
+func normalizeKeywords(keywords []string) ([]string, error) {
+	normalized := []string{}
+	seen := make(map[string]bool)
+	for _, keyword := range keywords {
+		keyword = strings.Join(strings.Fields(keyword), " ")
+		if keyword == "" {
+			continue
+		}
+		if len(keyword) > maxKeywordLength {
+			return nil, fmt.Errorf("keyword %q is longer than %d characters", keyword, maxKeywordLength)
+		}
+		if strings.IndexFunc(keyword, func(r rune) bool { return unicode.IsLetter(r) || unicode.IsNumber(r) }) < 0 {
+			return nil, fmt.Errorf("keyword %q has no letters or digits", keyword)
+		}
+		key := strings.ToLower(keyword)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		normalized = append(normalized, keyword)
+	}
+	if len(normalized) > maxKeywordsPerUrl {
+		return nil, fmt.Errorf("at most %d keywords are allowed per URL", maxKeywordsPerUrl)
+	}
+	return normalized, nil
+}
+
+// GetUrlKeywords lists the target keywords of a URL (only if owned by user)
+func GetUrlKeywords(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Authentication required",
+		})
+		return
+	}
+
+	id := c.Param("id")
+
+	var ownedID int
+	err := requestDB(c).QueryRow("SELECT id FROM urls WHERE id = ? AND user_id = ?", id, userID).Scan(&ownedID)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "URL not found",
+		})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Database error",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	keywords, err := loadUrlKeywords(c.Request.Context(), ownedID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Database query failed",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": keywords,
+	})
+}
+
+// SetUrlKeywords replaces the target keywords of a URL (only if owned by user).
+// The new keywords are analyzed on the next crawl.
+func SetUrlKeywords(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Authentication required",
+		})
+		return
+	}
+
+	var req models.KeywordsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request format",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	keywords, err := normalizeKeywords(req.Keywords)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid keywords",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	id := c.Param("id")
+
+	var ownedID int
+	err = requestDB(c).QueryRow("SELECT id FROM urls WHERE id = ? AND user_id = ?", id, userID).Scan(&ownedID)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "URL not found",
+		})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Database error",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	err = config.WithTransaction(c.Request.Context(), func(tx *sql.Tx) error {
+		if _, err := tx.Exec("DELETE FROM url_keywords WHERE url_id = ?", ownedID); err != nil {
+			return err
+		}
+		now := time.Now()
+		for _, keyword := range keywords {
+			if _, err := tx.Exec("INSERT INTO url_keywords (url_id, keyword, created_at) VALUES (?, ?, ?)", ownedID, keyword, now); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to save keywords",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Keywords saved; they are analyzed on the next crawl",
+		"data":    keywords,
+	})
+}
+
+// loadUrlKeywords returns a URL's target keywords in the order they were saved
+func loadUrlKeywords(ctx context.Context, urlID int) ([]string, error) {
+	rows, err := config.DBFor(ctx).Query("SELECT keyword FROM url_keywords WHERE url_id = ? ORDER BY id", urlID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	keywords := []string{}
+	for rows.Next() {
+		var keyword string
+		if err := rows.Scan(&keyword); err != nil {
+			continue // skip bad rows
+		}
+		keywords = append(keywords, keyword)
+	}
+	return keywords, rows.Err()
+}
+
+// loadKeywordResults returns the keyword occurrences of a URL's latest crawl
+func loadKeywordResults(ctx context.Context, urlID int) []models.KeywordResult {
+	results := []models.KeywordResult{}
+	rows, err := config.DBFor(ctx).Query(`
+		SELECT keyword, title_count, headings_count, meta_description_count, body_count, density, created_at
+		FROM keyword_results WHERE url_id = ?
+		ORDER BY id
+	`, urlID)
+	if err != nil {
+		return results
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var r models.KeywordResult
+		err := rows.Scan(&r.Keyword, &r.Title, &r.Headings, &r.MetaDescription, &r.Body, &r.Density, &r.CreatedAt)
+		if err == nil {
+			results = append(results, r)
+		}
+	}
+	return results
+}
@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeKeywords(t *testing.T) {
+	t.Run("trims, collapses whitespace and drops duplicates", func(t *testing.T) {
+		keywords, err := normalizeKeywords([]string{"  coffee   beans ", "Coffee Beans", "", "espresso"})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"coffee beans", "espresso"}, keywords)
+	})
+
+	t.Run("empty list clears keywords", func(t *testing.T) {
+		keywords, err := normalizeKeywords(nil)
+		require.NoError(t, err)
+		assert.Empty(t, keywords)
+	})
+
+	t.Run("rejects punctuation-only keywords", func(t *testing.T) {
+		_, err := normalizeKeywords([]string{"!!!"})
+		assert.Error(t, err)
+	})
+
+	t.Run("rejects long keywords", func(t *testing.T) {
+		_, err := normalizeKeywords([]string{strings.Repeat("a", maxKeywordLength+1)})
+		assert.Error(t, err)
+	})
+
+	t.Run("rejects too many keywords", func(t *testing.T) {
+		var keywords []string
+		for i := 0; i <= maxKeywordsPerUrl; i++ {
+			keywords = append(keywords, fmt.Sprintf("keyword %d", i))
+		}
+		_, err := normalizeKeywords(keywords)
+		assert.Error(t, err)
+	})
+}
+
+func TestSetUrlKeywords(t *testing.T) {
+	put := func(body string, authenticated bool) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(http.MethodPut, "/urls/1/keywords", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = req
+		c.Params = gin.Params{{Key: "id", Value: "1"}}
+		if authenticated {
+			c.Set("user_id", 1)
+		}
+
+		SetUrlKeywords(c)
+		return w
+	}
+
+	t.Run("missing authentication", func(t *testing.T) {
+		w := put(`{"keywords": ["coffee"]}`, false)
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
+	t.Run("invalid body", func(t *testing.T) {
+		w := put(`{"keywords": "coffee"}`, true)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("invalid keyword", func(t *testing.T) {
+		w := put(`{"keywords": ["coffee", "???"]}`, true)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "no letters or digits")
+	})
+}
@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"database/sql"
+	"net/http"
+
+	"sykell-analyze/backend/config"
+	"sykell-analyze/backend/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetUrlLighthouse returns the latest Lighthouse scores of a URL (only if owned by user)
+func GetUrlLighthouse(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Authentication required",
+		})
+		return
+	}
+
+	id := c.Param("id")
+
+	var ownedID int
+	err := requestDB(c).QueryRow("SELECT id FROM urls WHERE id = ? AND user_id = ?", id, userID).Scan(&ownedID)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "URL not found",
+		})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Database error",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	var result models.LighthouseResult
+	var metrics []byte
+	err = requestDB(c).QueryRow(`
+		SELECT strategy, performance_score, accessibility_score, best_practices_score, seo_score,
+			metrics, lighthouse_version, fetched_at, error_message, checked_at
+		FROM lighthouse_results WHERE url_id = ?
+	`, ownedID).Scan(
+		&result.Strategy, &result.Scores.Performance, &result.Scores.Accessibility,
+		&result.Scores.BestPractices, &result.Scores.SEO, &metrics, &result.LighthouseVersion,
+		&result.FetchedAt, &result.Error, &result.CheckedAt,
+	)
+	if err == sql.ErrNoRows {
+		message := "The URL has not been scored by Lighthouse yet"
+		if !config.App.Lighthouse.Enabled {
+			message = "Lighthouse scoring is disabled"
+		}
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": message,
+		})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Database query failed",
+			"details": err.Error(),
+		})
+		return
+	}
+	result.Metrics = metrics
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": result,
+	})
+}
@@ -0,0 +1,168 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"sykell-analyze/backend/worker"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetQueue returns the user's waiting and running crawl jobs with their queue positions
+func GetQueue(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Authentication required",
+		})
+		return
+	}
+
+	jobs, err := worker.UserJobs(c.Request.Context(), userID.(int))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to fetch queue",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": jobs,
+	})
+}
+
+// CancelQueuedJob removes one of the user's crawl jobs before a worker starts it
+func CancelQueuedJob(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Authentication required",
+		})
+		return
+	}
+
+	jobID, err := strconv.Atoi(c.Param("job_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid job ID",
+		})
+		return
+	}
+
+	err = worker.CancelJob(c.Request.Context(), jobID, userID.(int))
+	switch {
+	case errors.Is(err, worker.ErrJobNotFound):
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Job not found",
+		})
+		return
+	case errors.Is(err, worker.ErrJobStarted):
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "Job has already started",
+		})
+		return
+	case errors.Is(err, worker.ErrJobFinished):
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "Job has already finished",
+		})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to cancel job",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Job removed from the queue",
+		"id":      jobID,
+	})
+}
+
+// PauseUrl holds the URL's queued crawl, or stops its running crawl at the next check, until
+// ResumeUrl, e.g. to keep crawls out of business hours
+func PauseUrl(c *gin.Context) {
+	setCrawlPaused(c, true)
+}
+
+// ResumeUrl releases a paused crawl, which keeps its place in the queue and the links it checked
+func ResumeUrl(c *gin.Context) {
+	setCrawlPaused(c, false)
+}
+
+// setCrawlPaused pauses or resumes the queued or running crawl of the user's URL
+func setCrawlPaused(c *gin.Context, paused bool) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Authentication required",
+		})
+		return
+	}
+
+	urlID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid URL ID",
+		})
+		return
+	}
+
+	var owned int
+	err = requestDB(c).QueryRow("SELECT id FROM urls WHERE id = ? AND user_id = ?", urlID, userID).Scan(&owned)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "URL not found",
+		})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Database error",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	message := "Crawl paused"
+	status := http.StatusOK
+	if paused {
+		var stopping bool
+		stopping, err = worker.PauseCrawl(c.Request.Context(), urlID)
+		if stopping {
+			// The worker stops the crawl at its next check
+			message = "Crawl pausing"
+			status = http.StatusAccepted
+		}
+	} else {
+		err = worker.ResumeCrawl(c.Request.Context(), urlID)
+		message = "Crawl resumed"
+	}
+	switch {
+	case errors.Is(err, worker.ErrNothingQueued):
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "URL has no queued or running crawl",
+		})
+		return
+	case errors.Is(err, worker.ErrNotPaused):
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "Crawl is not paused",
+		})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to update crawl",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(status, gin.H{
+		"message": message,
+		"id":      urlID,
+	})
+}
@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCancelQueuedJob(t *testing.T) {
+	cancel := func(jobID string, authenticated bool) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest(http.MethodDelete, "/queue/"+jobID, nil)
+		c.Params = gin.Params{{Key: "job_id", Value: jobID}}
+		if authenticated {
+			c.Set("user_id", 1)
+		}
+
+		CancelQueuedJob(c)
+		return w
+	}
+
+	t.Run("missing authentication", func(t *testing.T) {
+		assert.Equal(t, http.StatusUnauthorized, cancel("1", false).Code)
+	})
+
+	t.Run("invalid job ID", func(t *testing.T) {
+		assert.Equal(t, http.StatusBadRequest, cancel("abc", true).Code)
+	})
+}
+
+func TestGetQueue(t *testing.T) {
+	t.Run("missing authentication", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest(http.MethodGet, "/queue", nil)
+
+		GetQueue(c)
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+}
+
+func TestPauseUrl(t *testing.T) {
+	call := func(handler gin.HandlerFunc, id string, authenticated bool) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest(http.MethodPut, "/urls/"+id+"/pause", nil)
+		c.Params = gin.Params{{Key: "id", Value: id}}
+		if authenticated {
+			c.Set("user_id", 1)
+		}
+
+		handler(c)
+		return w
+	}
+
+	t.Run("missing authentication", func(t *testing.T) {
+		assert.Equal(t, http.StatusUnauthorized, call(PauseUrl, "1", false).Code)
+		assert.Equal(t, http.StatusUnauthorized, call(ResumeUrl, "1", false).Code)
+	})
+
+	t.Run("invalid URL ID", func(t *testing.T) {
+		assert.Equal(t, http.StatusBadRequest, call(PauseUrl, "abc", true).Code)
+		assert.Equal(t, http.StatusBadRequest, call(ResumeUrl, "abc", true).Code)
+	})
+}
@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+
+	"sykell-analyze/backend/config"
+	"sykell-analyze/backend/models"
+	"sykell-analyze/backend/retention"
+
+	"github.com/gin-gonic/gin"
+)
+
+// loadRetentionSettings reads the user's retention settings
+func loadRetentionSettings(ctx context.Context, userID interface{}) (models.RetentionSettings, error) {
+	s := models.RetentionSettings{GlobalDays: config.App.Retention.Days}
+	var days, overrideDays sql.NullInt64
+	err := config.DBFor(ctx).QueryRow(
+		"SELECT retention_days, retention_override_days FROM users WHERE id = ?", userID,
+	).Scan(&days, &overrideDays)
+	if err != nil {
+		return s, err
+	}
+	if days.Valid {
+		n := int(days.Int64)
+		s.Days = &n
+	}
+	if overrideDays.Valid {
+		n := int(overrideDays.Int64)
+		s.OverrideDays = &n
+	}
+	s.EffectiveDays = retention.EffectiveDays(s.GlobalDays, s.Days, s.OverrideDays)
+	return s, nil
+}
+
+// GetRetentionSettings returns how long the user's crawl history is kept
+func GetRetentionSettings(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Authentication required",
+		})
+		return
+	}
+
+	settings, err := loadRetentionSettings(c.Request.Context(), userID)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "User not found",
+		})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Database error",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": settings,
+	})
+}
+
+// UpdateRetentionSettings sets how many days the user's crawl history is kept. Days beyond the
+// server's limit are stored but capped when purging.
+func UpdateRetentionSettings(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Authentication required",
+		})
+		return
+	}
+
+	var req models.RetentionSettingsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request format",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	if _, err := requestDB(c).Exec("UPDATE users SET retention_days = ? WHERE id = ?", req.Days, userID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to save retention settings",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	settings, err := loadRetentionSettings(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Database error",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Retention settings saved",
+		"data":    settings,
+	})
+}
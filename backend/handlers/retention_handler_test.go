@@ -0,0 +1,35 @@
+package handlers
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUpdateRetentionSettings(t *testing.T) {
+	put := func(body string, authenticated bool) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest(http.MethodPut, "/profile/retention", bytes.NewBufferString(body))
+		c.Request.Header.Set("Content-Type", "application/json")
+		if authenticated {
+			c.Set("user_id", 1)
+		}
+
+		UpdateRetentionSettings(c)
+		return w
+	}
+
+	t.Run("missing authentication", func(t *testing.T) {
+		assert.Equal(t, http.StatusUnauthorized, put(`{"days":30}`, false).Code)
+	})
+
+	t.Run("days must be positive", func(t *testing.T) {
+		assert.Equal(t, http.StatusBadRequest, put(`{"days":0}`, true).Code)
+		assert.Equal(t, http.StatusBadRequest, put(`{"days":-5}`, true).Code)
+	})
+}
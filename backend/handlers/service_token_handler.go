@@ -0,0 +1,169 @@
+package handlers
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"sykell-analyze/backend/middleware"
+	"sykell-analyze/backend/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetServiceTokens lists the user's service accounts; their tokens are only shown once, on creation
+func GetServiceTokens(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Authentication required",
+		})
+		return
+	}
+
+	rows, err := requestDB(c).Query(`
+		SELECT id, name, scopes, created_at, expires_at, last_used_at
+		FROM service_tokens WHERE user_id = ? ORDER BY created_at DESC
+	`, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Database query failed",
+			"details": err.Error(),
+		})
+		return
+	}
+	defer rows.Close()
+
+	tokens := []models.ServiceToken{}
+	for rows.Next() {
+		var t models.ServiceToken
+		var scopes string
+		if err := rows.Scan(&t.ID, &t.Name, &scopes, &t.CreatedAt, &t.ExpiresAt, &t.LastUsedAt); err != nil {
+			continue // skip bad rows
+		}
+		t.Scopes = strings.Split(scopes, ",")
+		tokens = append(tokens, t)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":   tokens,
+		"scopes": middleware.Scopes,
+	})
+}
+
+// AddServiceToken creates a service account limited to the requested scopes and returns its token
+func AddServiceToken(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Authentication required",
+		})
+		return
+	}
+
+	var req models.ServiceTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request format",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	scopes := []string{}
+	for _, scope := range req.Scopes {
+		if !middleware.ValidScope(scope) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Unknown scope",
+				"details": "scopes are " + strings.Join(middleware.Scopes, ", "),
+			})
+			return
+		}
+		if !slices.Contains(scopes, scope) {
+			scopes = append(scopes, scope)
+		}
+	}
+
+	now := time.Now()
+	var expiresAt *time.Time
+	if req.ExpiresInDays != nil {
+		expires := now.AddDate(0, 0, *req.ExpiresInDays)
+		expiresAt = &expires
+	}
+
+	result, err := requestDB(c).Exec(`
+		INSERT INTO service_tokens (user_id, name, scopes, created_at, expires_at)
+		VALUES (?, ?, ?, ?, ?)
+	`, userID, req.Name, strings.Join(scopes, ","), now, expiresAt)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to save service token",
+			"details": err.Error(),
+		})
+		return
+	}
+	id, _ := result.LastInsertId()
+
+	token, err := middleware.GenerateServiceToken(c.Request.Context(), userID.(int), c.GetString("username"), int(id), scopes, expiresAt)
+	if err != nil {
+		requestDB(c).Exec("DELETE FROM service_tokens WHERE id = ?", id)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to generate token",
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Service token created, copy it now as it is not shown again",
+		"token":   token,
+		"data": models.ServiceToken{
+			ID:        int(id),
+			Name:      req.Name,
+			Scopes:    scopes,
+			CreatedAt: now,
+			ExpiresAt: expiresAt,
+		},
+	})
+}
+
+// DeleteServiceToken removes a service account, revoking its token at once
+func DeleteServiceToken(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Authentication required",
+		})
+		return
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid service token ID",
+		})
+		return
+	}
+
+	result, err := requestDB(c).Exec("DELETE FROM service_tokens WHERE id = ? AND user_id = ?", id, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to delete service token",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Service token not found",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Service token deleted successfully",
+	})
+}
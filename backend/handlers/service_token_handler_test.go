@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAddServiceToken(t *testing.T) {
+	post := func(body string, authenticated bool) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(http.MethodPost, "/profile/tokens", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = req
+		if authenticated {
+			c.Set("user_id", 1)
+			c.Set("username", "testuser")
+		}
+
+		AddServiceToken(c)
+		return w
+	}
+
+	t.Run("missing authentication", func(t *testing.T) {
+		w := post(`{"name": "CI", "scopes": ["urls:read"]}`, false)
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
+	t.Run("missing scopes", func(t *testing.T) {
+		w := post(`{"name": "CI", "scopes": []}`, true)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("unknown scope", func(t *testing.T) {
+		w := post(`{"name": "CI", "scopes": ["urls:read", "admin"]}`, true)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "stats:read")
+	})
+
+	t.Run("expiry out of range", func(t *testing.T) {
+		w := post(`{"name": "CI", "scopes": ["urls:read"], "expires_in_days": 0}`, true)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
@@ -0,0 +1,183 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"sykell-analyze/backend/config"
+	"sykell-analyze/backend/digest"
+	"sykell-analyze/backend/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// loadCrawlDefaults reads the crawl settings the user gives new URLs
+func loadCrawlDefaults(ctx context.Context, userID interface{}) (models.CrawlSettings, error) {
+	var s models.CrawlSettings
+	var checkLinks bool
+	err := config.DBFor(ctx).QueryRow(
+		"SELECT default_crawl_timeout_seconds, default_user_agent, default_check_broken_links FROM users WHERE id = ?", userID,
+	).Scan(&s.TimeoutSeconds, &s.UserAgent, &checkLinks)
+	s.CheckBrokenLinks = &checkLinks
+	return s, err
+}
+
+// validateCrawlSettings checks the settings fit within the server's crawler limits
+func validateCrawlSettings(s models.CrawlSettings) error {
+	limit := config.App.Crawler.PageTimeout
+	if s.TimeoutSeconds != nil && *s.TimeoutSeconds > int(limit.Seconds()) {
+		return fmt.Errorf("timeout_seconds must not exceed the server's page timeout of %d seconds", int(limit.Seconds()))
+	}
+	return nil
+}
+
+// withOverrides applies the settings given with a new URL over the user's defaults
+func withOverrides(settings models.CrawlSettings, override *models.CrawlSettings) (models.CrawlSettings, error) {
+	if override != nil {
+		if override.TimeoutSeconds != nil {
+			settings.TimeoutSeconds = override.TimeoutSeconds
+		}
+		if override.UserAgent != nil {
+			settings.UserAgent = override.UserAgent
+		}
+		if override.CheckBrokenLinks != nil {
+			settings.CheckBrokenLinks = override.CheckBrokenLinks
+		}
+	}
+	return settings, validateCrawlSettings(settings)
+}
+
+// crawlSettingsFor resolves the crawl settings of a URL the user adds, answering the request itself
+// and returning false when they cannot be used
+func crawlSettingsFor(c *gin.Context, userID interface{}, override *models.CrawlSettings) (models.CrawlSettings, bool) {
+	defaults, err := loadCrawlDefaults(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Database error",
+			"details": err.Error(),
+		})
+		return defaults, false
+	}
+	settings, err := withOverrides(defaults, override)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid crawl settings",
+			"details": err.Error(),
+		})
+		return settings, false
+	}
+	return settings, true
+}
+
+// loadUserSettings reads the user's crawl defaults and notification preferences
+func loadUserSettings(ctx context.Context, userID interface{}) (models.UserSettings, error) {
+	crawl, err := loadCrawlDefaults(ctx, userID)
+	if err != nil {
+		return models.UserSettings{}, err
+	}
+	notifications, err := loadDigestSettings(ctx, userID)
+	return models.UserSettings{Crawl: crawl, Notifications: notifications}, err
+}
+
+// GetSettings returns the crawl settings given to new URLs and the notification preferences
+func GetSettings(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Authentication required",
+		})
+		return
+	}
+
+	settings, err := loadUserSettings(c.Request.Context(), userID)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "User not found",
+		})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Database error",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": settings,
+	})
+}
+
+// UpdateSettings changes the crawl defaults and notification preferences included in the request.
+// New crawl defaults apply to URLs added afterwards; existing URLs keep their settings.
+func UpdateSettings(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Authentication required",
+		})
+		return
+	}
+
+	var req models.UserSettingsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request format",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	var sets []string
+	var args []interface{}
+	if req.Crawl != nil {
+		if err := validateCrawlSettings(*req.Crawl); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid crawl settings",
+				"details": err.Error(),
+			})
+			return
+		}
+		checkLinks := req.Crawl.CheckBrokenLinks == nil || *req.Crawl.CheckBrokenLinks
+		sets = append(sets, "default_crawl_timeout_seconds = ?", "default_user_agent = ?", "default_check_broken_links = ?")
+		args = append(args, req.Crawl.TimeoutSeconds, req.Crawl.UserAgent, checkLinks)
+	}
+	if req.Notifications != nil {
+		if req.Notifications.Frequency != digest.FrequencyOff && !config.App.Digest.Enabled {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Email digests are not enabled on this server",
+			})
+			return
+		}
+		sets = append(sets, "digest_frequency = ?", "digest_hour = COALESCE(?, digest_hour)")
+		args = append(args, req.Notifications.Frequency, req.Notifications.Hour)
+	}
+
+	if len(sets) > 0 {
+		args = append(args, userID)
+		if _, err := requestDB(c).Exec("UPDATE users SET "+strings.Join(sets, ", ")+" WHERE id = ?", args...); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error":   "Failed to save settings",
+				"details": err.Error(),
+			})
+			return
+		}
+	}
+
+	settings, err := loadUserSettings(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Database error",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Settings saved",
+		"data":    settings,
+	})
+}
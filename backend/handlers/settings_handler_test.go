@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"sykell-analyze/backend/config"
+	"sykell-analyze/backend/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithOverrides(t *testing.T) {
+	timeout, agent, check, noCheck := 30, "acme-monitor/2.0", true, false
+	defaults := models.CrawlSettings{TimeoutSeconds: &timeout, CheckBrokenLinks: &check}
+
+	t.Run("defaults apply without overrides", func(t *testing.T) {
+		settings, err := withOverrides(defaults, nil)
+		require.NoError(t, err)
+		assert.Equal(t, defaults, settings)
+	})
+
+	t.Run("overrides replace single defaults", func(t *testing.T) {
+		settings, err := withOverrides(defaults, &models.CrawlSettings{UserAgent: &agent, CheckBrokenLinks: &noCheck})
+		require.NoError(t, err)
+		assert.Equal(t, 30, *settings.TimeoutSeconds)
+		assert.Equal(t, agent, *settings.UserAgent)
+		assert.False(t, *settings.CheckBrokenLinks)
+	})
+
+	t.Run("timeout cannot exceed the server's page timeout", func(t *testing.T) {
+		tooLong := int(config.App.Crawler.PageTimeout.Seconds()) + 1
+		_, err := withOverrides(defaults, &models.CrawlSettings{TimeoutSeconds: &tooLong})
+		assert.ErrorContains(t, err, "page timeout")
+	})
+}
+
+func TestUpdateSettings(t *testing.T) {
+	put := func(body string, authenticated bool) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(http.MethodPut, "/settings", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = req
+		if authenticated {
+			c.Set("user_id", 1)
+		}
+
+		UpdateSettings(c)
+		return w
+	}
+
+	t.Run("missing authentication", func(t *testing.T) {
+		assert.Equal(t, http.StatusUnauthorized, put(`{"crawl": {}}`, false).Code)
+	})
+
+	t.Run("invalid timeout", func(t *testing.T) {
+		assert.Equal(t, http.StatusBadRequest, put(`{"crawl": {"timeout_seconds": 0}}`, true).Code)
+		w := put(`{"crawl": {"timeout_seconds": 100000}}`, true)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "page timeout")
+	})
+
+	t.Run("digest needs the server to send digests", func(t *testing.T) {
+		previous := config.App
+		cfg := *config.App
+		cfg.Digest.Enabled = false
+		config.App = &cfg
+		defer func() { config.App = previous }()
+
+		w := put(`{"notifications": {"frequency": "daily"}}`, true)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "not enabled")
+	})
+}
@@ -0,0 +1,161 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"sort"
+	"time"
+
+	"sykell-analyze/backend/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// trackedPage is what the user's own analyses say about a page listed in a generated sitemap
+type trackedPage struct {
+	noindex   bool
+	crawledAt time.Time
+}
+
+// sitemapURLs picks the pages of a generated sitemap: the crawled page and the internal pages it links
+// to, plus the user's other analyzed pages on the same host. Pages known to be broken or noindex are
+// left out; lastmod is only known for analyzed pages.
+func sitemapURLs(pageURL string, internalPages []string, broken map[string]bool, tracked map[string]trackedPage) []utils.SitemapURL {
+	root, err := url.Parse(pageURL)
+	if err != nil {
+		return nil
+	}
+
+	seen := make(map[string]bool)
+	urls := []utils.SitemapURL{}
+	add := func(loc string) {
+		u, err := url.Parse(loc)
+		if err != nil || u.Host != root.Host || (u.Scheme != "http" && u.Scheme != "https") {
+			return
+		}
+		u.Fragment = ""
+		loc = u.String()
+		if seen[loc] || broken[loc] {
+			return
+		}
+		seen[loc] = true
+
+		entry := utils.SitemapURL{Loc: loc}
+		if page, ok := tracked[loc]; ok {
+			if page.noindex {
+				return
+			}
+			entry.LastMod = page.crawledAt
+		}
+		urls = append(urls, entry)
+	}
+
+	add(pageURL)
+	for _, page := range internalPages {
+		add(page)
+	}
+	for page := range tracked {
+		add(page)
+	}
+
+	sort.Slice(urls, func(i, j int) bool { return urls[i].Loc < urls[j].Loc })
+	return urls
+}
+
+// GetGeneratedSitemap serves a sitemap.xml built from the latest crawl of a URL, for sites without
+// one of their own (only if owned by user)
+func GetGeneratedSitemap(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Authentication required",
+		})
+		return
+	}
+
+	id := c.Param("id")
+
+	var urlID int
+	var pageURL, status string
+	var internalPagesJSON []byte
+	err := requestDB(c).QueryRow(
+		"SELECT id, url, status, internal_pages FROM urls WHERE id = ? AND user_id = ?", id, userID,
+	).Scan(&urlID, &pageURL, &status, &internalPagesJSON)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "URL not found",
+		})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Database error",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	if status != "completed" {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "URL has not been analyzed yet",
+		})
+		return
+	}
+
+	var internalPages []string
+	if internalPagesJSON != nil {
+		json.Unmarshal(internalPagesJSON, &internalPages)
+	}
+
+	broken := make(map[string]bool)
+	rows, err := requestDB(c).Query("SELECT link_url FROM broken_links WHERE url_id = ?", urlID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Database query failed",
+			"details": err.Error(),
+		})
+		return
+	}
+	for rows.Next() {
+		var link string
+		if err := rows.Scan(&link); err == nil {
+			broken[link] = true
+		}
+	}
+	rows.Close()
+
+	// Every analyzed page of the user; sitemapURLs keeps those on the same host
+	tracked := make(map[string]trackedPage)
+	rows, err = requestDB(c).Query(
+		"SELECT url, is_noindex, crawled_at FROM urls WHERE user_id = ? AND status = 'completed'", userID,
+	)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Database query failed",
+			"details": err.Error(),
+		})
+		return
+	}
+	for rows.Next() {
+		var page string
+		var noindex sql.NullBool
+		var crawledAt sql.NullTime
+		if err := rows.Scan(&page, &noindex, &crawledAt); err != nil {
+			continue // skip bad rows
+		}
+		tracked[page] = trackedPage{noindex: noindex.Bool, crawledAt: crawledAt.Time}
+	}
+	rows.Close()
+
+	body, err := utils.RenderSitemap(sitemapURLs(pageURL, internalPages, broken, tracked))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to render sitemap",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.Data(http.StatusOK, "application/xml; charset=utf-8", body)
+}
@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"sykell-analyze/backend/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSitemapURLs(t *testing.T) {
+	crawledAt := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+
+	t.Run("lists the page and its working indexable internal pages", func(t *testing.T) {
+		urls := sitemapURLs("https://example.com/",
+			[]string{"https://example.com/b#top", "https://example.com/a", "https://example.com/gone", "https://example.com/private"},
+			map[string]bool{"https://example.com/gone": true},
+			map[string]trackedPage{
+				"https://example.com/":        {crawledAt: crawledAt},
+				"https://example.com/private": {noindex: true, crawledAt: crawledAt},
+				"https://example.com/tracked": {crawledAt: crawledAt},
+				"https://other.example/":      {crawledAt: crawledAt},
+			},
+		)
+
+		assert.Equal(t, []utils.SitemapURL{
+			{Loc: "https://example.com/", LastMod: crawledAt},
+			{Loc: "https://example.com/a"},
+			{Loc: "https://example.com/b"},
+			{Loc: "https://example.com/tracked", LastMod: crawledAt},
+		}, urls)
+	})
+
+	t.Run("noindex page is left out", func(t *testing.T) {
+		urls := sitemapURLs("https://example.com/", nil, nil, map[string]trackedPage{
+			"https://example.com/": {noindex: true},
+		})
+		assert.Empty(t, urls)
+	})
+}
+
+func TestGetGeneratedSitemap(t *testing.T) {
+	t.Run("missing authentication", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest(http.MethodGet, "/urls/1/generated-sitemap.xml", nil)
+		c.Params = gin.Params{{Key: "id", Value: "1"}}
+
+		GetGeneratedSitemap(c)
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+}
@@ -0,0 +1,191 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"sykell-analyze/backend/archive"
+	"sykell-analyze/backend/config"
+	"sykell-analyze/backend/models"
+	"sykell-analyze/backend/textdiff"
+
+	"github.com/gin-gonic/gin"
+)
+
+// errArchivedText is returned by loadSnapshot when archived text cannot be read back
+var errArchivedText = errors.New("failed to load archived snapshot text")
+
+// snapshotColumns lists the content_snapshots columns read by scanSnapshot, in scan order
+const snapshotColumns = "id, url_id, run_id, change_percent, added_lines, removed_lines, created_at"
+
+// scanSnapshot reads a row selected with snapshotColumns into s
+func scanSnapshot(row rowScanner, s *models.ContentSnapshot) error {
+	return row.Scan(&s.ID, &s.UrlID, &s.RunID, &s.ChangePercent, &s.AddedLines, &s.RemovedLines, &s.CreatedAt)
+}
+
+// GetUrlSnapshots lists the text snapshots kept for a URL, newest first, with how much each changed (only if owned by user)
+func GetUrlSnapshots(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Authentication required",
+		})
+		return
+	}
+
+	id := c.Param("id")
+
+	var urlID int
+	err := requestDB(c).QueryRow("SELECT id FROM urls WHERE id = ? AND user_id = ?", id, userID).Scan(&urlID)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "URL not found",
+		})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Database error",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	rows, err := requestDB(c).Query("SELECT "+snapshotColumns+" FROM content_snapshots WHERE url_id = ? ORDER BY id DESC", urlID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Database query failed",
+			"details": err.Error(),
+		})
+		return
+	}
+	defer rows.Close()
+
+	snapshots := []models.ContentSnapshot{}
+	for rows.Next() {
+		var s models.ContentSnapshot
+		if err := scanSnapshot(rows, &s); err != nil {
+			continue // skip bad rows
+		}
+		snapshots = append(snapshots, s)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": snapshots,
+	})
+}
+
+// GetUrlDiff returns the text added and removed between two snapshots of a URL (only if owned by user).
+// ?to defaults to the latest snapshot and ?from to the one before ?to.
+func GetUrlDiff(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Authentication required",
+		})
+		return
+	}
+
+	var snapshotIDs [2]int
+	for i, name := range []string{"from", "to"} {
+		if value := c.Query(name); value != "" {
+			id, err := strconv.Atoi(value)
+			if err != nil || id < 1 {
+				c.JSON(http.StatusBadRequest, gin.H{
+					"error": "Invalid " + name + " snapshot ID",
+				})
+				return
+			}
+			snapshotIDs[i] = id
+		}
+	}
+	fromID, toID := snapshotIDs[0], snapshotIDs[1]
+
+	id := c.Param("id")
+
+	var urlID int
+	err := requestDB(c).QueryRow("SELECT id FROM urls WHERE id = ? AND user_id = ?", id, userID).Scan(&urlID)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "URL not found",
+		})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Database error",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	diff := models.ContentDiff{UrlID: urlID}
+	var fromContent, toContent string
+	diff.To, toContent, err = loadSnapshot(c.Request.Context(), urlID, toID, 0)
+	if err == nil {
+		diff.From, fromContent, err = loadSnapshot(c.Request.Context(), urlID, fromID, diff.To.ID)
+	}
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Snapshot not found; a diff needs two crawls of the URL",
+		})
+		return
+	} else if errors.Is(err, errArchivedText) {
+		c.JSON(http.StatusBadGateway, gin.H{
+			"error":   "Failed to load archived snapshot",
+			"details": err.Error(),
+		})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Database query failed",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	d := textdiff.Compare(fromContent, toContent)
+	diff.ChangePercent = d.ChangePercent
+	diff.Added, diff.Removed = d.Added, d.Removed
+	if diff.Added == nil {
+		diff.Added = []string{}
+	}
+	if diff.Removed == nil {
+		diff.Removed = []string{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": diff,
+	})
+}
+
+// loadSnapshot reads a snapshot of the URL with its text. A zero snapshotID picks the newest snapshot
+// older than before, or the newest of all when before is 0 too.
+func loadSnapshot(ctx context.Context, urlID, snapshotID, before int) (models.ContentSnapshot, string, error) {
+	query := "SELECT " + snapshotColumns + ", content, archive_key FROM content_snapshots WHERE url_id = ?"
+	args := []interface{}{urlID}
+	switch {
+	case snapshotID != 0:
+		query += " AND id = ?"
+		args = append(args, snapshotID)
+	case before != 0:
+		query += " AND id < ?"
+		args = append(args, before)
+	}
+	query += " ORDER BY id DESC LIMIT 1"
+
+	var s models.ContentSnapshot
+	var content string
+	var archiveKey sql.NullString
+	err := config.DBFor(ctx).QueryRow(query, args...).Scan(&s.ID, &s.UrlID, &s.RunID, &s.ChangePercent, &s.AddedLines, &s.RemovedLines, &s.CreatedAt, &content, &archiveKey)
+	if err != nil {
+		return s, "", err
+	}
+	// Old snapshots may have been moved to storage
+	if content, err = archive.Content(ctx, content, archiveKey); err != nil {
+		return s, "", fmt.Errorf("%w: %v", errArchivedText, err)
+	}
+	return s, content, nil
+}
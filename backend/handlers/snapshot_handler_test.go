@@ -0,0 +1,41 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetUrlDiff(t *testing.T) {
+	get := func(query string, authenticated bool) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(http.MethodGet, "/urls/1/diff"+query, nil)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = req
+		c.Params = gin.Params{{Key: "id", Value: "1"}}
+		if authenticated {
+			c.Set("user_id", 1)
+		}
+
+		GetUrlDiff(c)
+		return w
+	}
+
+	t.Run("missing authentication", func(t *testing.T) {
+		w := get("", false)
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
+	t.Run("invalid snapshot IDs", func(t *testing.T) {
+		w := get("?from=abc", true)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "Invalid from snapshot ID")
+
+		w = get("?to=0", true)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
@@ -0,0 +1,252 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"sykell-analyze/backend/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+const maxTimeseriesDays = 365
+
+// parseRangeDays converts a range like "30d" into a number of days
+func parseRangeDays(value string) (int, error) {
+	if !strings.HasSuffix(value, "d") {
+		return 0, fmt.Errorf("range must be in days, e.g. 30d")
+	}
+
+	days, err := strconv.Atoi(strings.TrimSuffix(value, "d"))
+	if err != nil || days < 1 {
+		return 0, fmt.Errorf("range must be a positive number of days")
+	}
+	if days > maxTimeseriesDays {
+		return 0, fmt.Errorf("range cannot exceed %dd", maxTimeseriesDays)
+	}
+
+	return days, nil
+}
+
+// buildTimeseries returns one point per day ending at end, filling missing days with zeros
+func buildTimeseries(end time.Time, days int, counts map[string]models.TimeseriesPoint) []models.TimeseriesPoint {
+	points := make([]models.TimeseriesPoint, 0, days)
+	start := end.AddDate(0, 0, -(days - 1))
+
+	for i := 0; i < days; i++ {
+		date := start.AddDate(0, 0, i).Format("2006-01-02")
+		point, ok := counts[date]
+		if !ok {
+			point = models.TimeseriesPoint{Date: date}
+		}
+		points = append(points, point)
+	}
+
+	return points
+}
+
+// GetStatsTimeseries returns daily crawl, error and broken link counts for the authenticated user
+func GetStatsTimeseries(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Authentication required",
+		})
+		return
+	}
+
+	days, err := parseRangeDays(c.DefaultQuery("range", "30d"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid range",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	// Days are UTC days, like DATE() in the UTC database session
+	now := time.Now().UTC()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	since := today.AddDate(0, 0, -(days - 1))
+
+	rows, err := requestDB(c).Query(`
+		SELECT DATE(finished_at) AS day, COUNT(*),
+		       COALESCE(SUM(status = 'error'), 0), COALESCE(SUM(broken_links), 0)
+		FROM crawl_runs
+		WHERE user_id = ? AND finished_at >= ?
+		GROUP BY day
+		ORDER BY day
+	`, userID, since)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Database query failed",
+			"details": err.Error(),
+		})
+		return
+	}
+	defer rows.Close()
+
+	counts := make(map[string]models.TimeseriesPoint)
+	for rows.Next() {
+		var day time.Time
+		var point models.TimeseriesPoint
+		if err := rows.Scan(&day, &point.Crawls, &point.Errors, &point.BrokenLinks); err != nil {
+			continue // skip bad rows
+		}
+		point.Date = day.Format("2006-01-02")
+		counts[point.Date] = point
+	}
+
+	if err = rows.Err(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Error reading results",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": buildTimeseries(today, days, counts),
+		"range": gin.H{
+			"days": days,
+			"from": since.Format("2006-01-02"),
+			"to":   today.Format("2006-01-02"),
+		},
+	})
+}
+
+// domainUrlRow holds the columns needed to roll URLs up by domain
+type domainUrlRow struct {
+	Url           string
+	Title         string
+	Status        string
+	InternalLinks int
+	ExternalLinks int
+	BrokenLinks   int
+	UpdatedAt     time.Time
+}
+
+// hostOf returns the lower-cased host of a stored URL without the www. prefix
+func hostOf(rawURL string) string {
+	parsed, err := url.Parse(rawURL)
+	if err != nil || parsed.Hostname() == "" {
+		return ""
+	}
+	return strings.TrimPrefix(strings.ToLower(parsed.Hostname()), "www.")
+}
+
+// aggregateDomainStats groups URL rows by host, sorted by page count then domain
+func aggregateDomainStats(rows []domainUrlRow) []models.DomainStats {
+	byDomain := make(map[string]*models.DomainStats)
+	var domains []string
+
+	for _, row := range rows {
+		domain := hostOf(row.Url)
+		if domain == "" {
+			continue
+		}
+
+		stats, ok := byDomain[domain]
+		if !ok {
+			stats = &models.DomainStats{Domain: domain}
+			byDomain[domain] = stats
+			domains = append(domains, domain)
+		}
+
+		stats.Pages++
+		switch row.Status {
+		case "completed":
+			stats.CompletedPages++
+			stats.TotalBrokenLinks += row.BrokenLinks
+			stats.AvgInternalLinks += float64(row.InternalLinks)
+			stats.AvgExternalLinks += float64(row.ExternalLinks)
+			if strings.TrimSpace(row.Title) == "" {
+				stats.PagesWithoutTitle++
+			}
+		case "error":
+			stats.ErrorPages++
+		default:
+			continue // queued or running pages have not been crawled yet
+		}
+
+		if stats.LastCrawledAt == nil || row.UpdatedAt.After(*stats.LastCrawledAt) {
+			crawledAt := row.UpdatedAt
+			stats.LastCrawledAt = &crawledAt
+		}
+	}
+
+	result := make([]models.DomainStats, 0, len(domains))
+	for _, domain := range domains {
+		stats := byDomain[domain]
+		if stats.CompletedPages > 0 {
+			completed := float64(stats.CompletedPages)
+			stats.AvgBrokenLinks = float64(stats.TotalBrokenLinks) / completed
+			stats.AvgInternalLinks /= completed
+			stats.AvgExternalLinks /= completed
+		}
+		result = append(result, *stats)
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		if result[i].Pages != result[j].Pages {
+			return result[i].Pages > result[j].Pages
+		}
+		return result[i].Domain < result[j].Domain
+	})
+
+	return result
+}
+
+// GetDomainStats returns the authenticated user's URLs rolled up by domain
+func GetDomainStats(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Authentication required",
+		})
+		return
+	}
+
+	rows, err := requestDB(c).Query(`
+		SELECT url, COALESCE(title, ''), status, internal_links, external_links, broken_links, updated_at
+		FROM urls
+		WHERE user_id = ?
+	`, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Database query failed",
+			"details": err.Error(),
+		})
+		return
+	}
+	defer rows.Close()
+
+	var urlRows []domainUrlRow
+	for rows.Next() {
+		var row domainUrlRow
+		if err := rows.Scan(
+			&row.Url, &row.Title, &row.Status,
+			&row.InternalLinks, &row.ExternalLinks, &row.BrokenLinks, &row.UpdatedAt,
+		); err != nil {
+			continue // skip bad rows
+		}
+		urlRows = append(urlRows, row)
+	}
+
+	if err = rows.Err(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Error reading results",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": aggregateDomainStats(urlRows),
+	})
+}
@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"sykell-analyze/backend/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseRangeDays(t *testing.T) {
+	testCases := []struct {
+		name        string
+		value       string
+		expected    int
+		expectError bool
+	}{
+		{name: "thirty days", value: "30d", expected: 30},
+		{name: "single day", value: "1d", expected: 1},
+		{name: "maximum range", value: "365d", expected: 365},
+		{name: "missing unit", value: "30", expectError: true},
+		{name: "zero days", value: "0d", expectError: true},
+		{name: "exceeds maximum", value: "400d", expectError: true},
+		{name: "not a number", value: "abcd", expectError: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			days, err := parseRangeDays(tc.value)
+			if tc.expectError {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, days)
+		})
+	}
+}
+
+func TestBuildTimeseries(t *testing.T) {
+	end := time.Date(2024, 3, 10, 0, 0, 0, 0, time.UTC)
+	counts := map[string]models.TimeseriesPoint{
+		"2024-03-09": {Date: "2024-03-09", Crawls: 4, Errors: 1, BrokenLinks: 7},
+	}
+
+	points := buildTimeseries(end, 3, counts)
+
+	assert.Len(t, points, 3)
+	assert.Equal(t, "2024-03-08", points[0].Date)
+	assert.Equal(t, 0, points[0].Crawls)
+	assert.Equal(t, 4, points[1].Crawls)
+	assert.Equal(t, 1, points[1].Errors)
+	assert.Equal(t, 7, points[1].BrokenLinks)
+	assert.Equal(t, "2024-03-10", points[2].Date)
+}
+
+func TestGetStatsTimeseries(t *testing.T) {
+	t.Run("missing authentication", func(t *testing.T) {
+		req, _ := http.NewRequest(http.MethodGet, "/stats/timeseries", nil)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = req
+
+		GetStatsTimeseries(c)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
+	t.Run("invalid range", func(t *testing.T) {
+		req, _ := http.NewRequest(http.MethodGet, "/stats/timeseries?range=1y", nil)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = req
+		c.Set("user_id", 1)
+
+		GetStatsTimeseries(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
+
+func TestAggregateDomainStats(t *testing.T) {
+	older := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	newer := time.Date(2024, 3, 5, 12, 0, 0, 0, time.UTC)
+
+	rows := []domainUrlRow{
+		{Url: "https://www.example.com/", Title: "Home", Status: "completed", InternalLinks: 10, ExternalLinks: 2, BrokenLinks: 4, UpdatedAt: older},
+		{Url: "https://example.com/about", Title: "", Status: "completed", InternalLinks: 6, ExternalLinks: 0, BrokenLinks: 0, UpdatedAt: newer},
+		{Url: "https://example.com/pending", Status: "queued", UpdatedAt: newer.Add(time.Hour)},
+		{Url: "https://other.org/", Status: "error", UpdatedAt: older},
+		{Url: "not a url", Status: "completed"},
+	}
+
+	stats := aggregateDomainStats(rows)
+
+	assert.Len(t, stats, 2)
+
+	example := stats[0]
+	assert.Equal(t, "example.com", example.Domain)
+	assert.Equal(t, 3, example.Pages)
+	assert.Equal(t, 2, example.CompletedPages)
+	assert.Equal(t, 4, example.TotalBrokenLinks)
+	assert.Equal(t, 2.0, example.AvgBrokenLinks)
+	assert.Equal(t, 8.0, example.AvgInternalLinks)
+	assert.Equal(t, 1.0, example.AvgExternalLinks)
+	assert.Equal(t, 1, example.PagesWithoutTitle)
+	assert.Equal(t, newer, *example.LastCrawledAt)
+
+	other := stats[1]
+	assert.Equal(t, "other.org", other.Domain)
+	assert.Equal(t, 1, other.ErrorPages)
+	assert.Equal(t, 0.0, other.AvgBrokenLinks)
+	assert.Equal(t, older, *other.LastCrawledAt)
+}
@@ -0,0 +1,256 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"sykell-analyze/backend/config"
+	"sykell-analyze/backend/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Limits on the tags of a URL
+const (
+	maxTagsPerUrl = 20
+	maxTagLength  = 40
+)
+
+// errTooManyTags rolls back a bulk tag change that would leave a URL with more than maxTagsPerUrl tags
+var errTooManyTags = fmt.Errorf("a URL can have at most %d tags", maxTagsPerUrl)
+
+// normalizeTags trims and collapses whitespace in each tag and drops case-insensitive duplicates
+func normalizeTags(tags []string) ([]string, error) {
+	normalized := []string{}
+	seen := make(map[string]bool)
+	for _, tag := range tags {
+		tag = strings.Join(strings.Fields(tag), " ")
+		if tag == "" {
+			continue
+		}
+		if len(tag) > maxTagLength {
+			return nil, fmt.Errorf("tag %q is longer than %d characters", tag, maxTagLength)
+		}
+		// Tags are read back as a comma-separated list
+		if strings.Contains(tag, ",") {
+			return nil, fmt.Errorf("tag %q contains a comma", tag)
+		}
+		key := strings.ToLower(tag)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		normalized = append(normalized, tag)
+	}
+	if len(normalized) > maxTagsPerUrl {
+		return nil, errTooManyTags
+	}
+	return normalized, nil
+}
+
+// placeholders returns "?,?,?" for n values
+func placeholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?,", n), ",")
+}
+
+// ownedUrlIDs returns the IDs among ids of URLs the user owns
+func ownedUrlIDs(tx *sql.Tx, userID interface{}, ids []int) ([]interface{}, error) {
+	args := []interface{}{userID}
+	for _, id := range ids {
+		args = append(args, id)
+	}
+	rows, err := tx.Query("SELECT id FROM urls WHERE user_id = ? AND id IN ("+placeholders(len(ids))+")", args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var owned []interface{}
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		owned = append(owned, id)
+	}
+	return owned, rows.Err()
+}
+
+// BulkTagUrls adds, removes or replaces the tags of multiple URLs by IDs
+func BulkTagUrls(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Authentication required",
+		})
+		return
+	}
+
+	var req models.BulkTagsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request format",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	if len(req.IDs) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "No IDs provided",
+		})
+		return
+	}
+
+	tags, err := normalizeTags(req.Tags)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid tags",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	mode := req.Mode
+	if mode == "" {
+		mode = "add"
+	}
+	// Only set may clear every tag
+	if len(tags) == 0 && mode != "set" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "No tags provided",
+		})
+		return
+	}
+
+	var updated int
+	err = config.WithTransaction(c.Request.Context(), func(tx *sql.Tx) error {
+		owned, err := ownedUrlIDs(tx, userID, req.IDs)
+		if err != nil || len(owned) == 0 {
+			return err
+		}
+		updated = len(owned)
+		inOwned := " url_id IN (" + placeholders(len(owned)) + ")"
+
+		// Tags are part of the URL, so a tag change makes older copies of it stale
+		if _, err := tx.Exec("UPDATE urls SET version = version + 1 WHERE id IN ("+placeholders(len(owned))+")", owned...); err != nil {
+			return err
+		}
+
+		switch mode {
+		case "set":
+			if _, err := tx.Exec("DELETE FROM url_tags WHERE"+inOwned, owned...); err != nil {
+				return err
+			}
+		case "remove":
+			args := append([]interface{}{}, owned...)
+			for _, tag := range tags {
+				args = append(args, tag)
+			}
+			_, err := tx.Exec("DELETE FROM url_tags WHERE"+inOwned+" AND tag IN ("+placeholders(len(tags))+")", args...)
+			return err
+		}
+
+		now := time.Now()
+		for _, id := range owned {
+			for _, tag := range tags {
+				if _, err := tx.Exec("INSERT IGNORE INTO url_tags (url_id, tag, created_at) VALUES (?, ?, ?)", id, tag, now); err != nil {
+					return err
+				}
+			}
+		}
+
+		var crowded int
+		err = tx.QueryRow(
+			"SELECT COUNT(*) FROM (SELECT url_id FROM url_tags WHERE"+inOwned+" GROUP BY url_id HAVING COUNT(*) > ?) t",
+			append(append([]interface{}{}, owned...), maxTagsPerUrl)...,
+		).Scan(&crowded)
+		if err != nil {
+			return err
+		}
+		if crowded > 0 {
+			return errTooManyTags
+		}
+		return nil
+	})
+	if errors.Is(err, errTooManyTags) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid tags",
+			"details": err.Error(),
+		})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to update tags",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":       "URL tags updated",
+		"updated_count": updated,
+		"tags":          tags,
+	})
+}
+
+// BulkMoveToProject moves multiple URLs by IDs to a project, or out of their project
+func BulkMoveToProject(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Authentication required",
+		})
+		return
+	}
+
+	var req models.BulkProjectRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request format",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	if len(req.IDs) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "No IDs provided",
+		})
+		return
+	}
+
+	var project *string
+	if req.Project != nil {
+		if name := strings.Join(strings.Fields(*req.Project), " "); name != "" {
+			project = &name
+		}
+	}
+
+	args := []interface{}{project, time.Now(), userID}
+	for _, id := range req.IDs {
+		args = append(args, id)
+	}
+	result, err := requestDB(c).Exec(
+		"UPDATE urls SET project = ?, version = version + 1, updated_at = ? WHERE user_id = ? AND id IN ("+placeholders(len(req.IDs))+")",
+		args...,
+	)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to move URLs",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	c.JSON(http.StatusOK, gin.H{
+		"message":       "URLs moved",
+		"updated_count": rowsAffected,
+		"project":       project,
+	})
+}
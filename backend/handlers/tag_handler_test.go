@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeTags(t *testing.T) {
+	t.Run("trims, collapses whitespace and drops duplicates", func(t *testing.T) {
+		tags, err := normalizeTags([]string{"  client   a ", "Client A", "", "landing"})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"client a", "landing"}, tags)
+	})
+
+	t.Run("rejects commas", func(t *testing.T) {
+		_, err := normalizeTags([]string{"a,b"})
+		assert.Error(t, err)
+	})
+
+	t.Run("rejects long tags", func(t *testing.T) {
+		_, err := normalizeTags([]string{strings.Repeat("a", maxTagLength+1)})
+		assert.Error(t, err)
+	})
+
+	t.Run("rejects too many tags", func(t *testing.T) {
+		var tags []string
+		for i := 0; i <= maxTagsPerUrl; i++ {
+			tags = append(tags, fmt.Sprintf("tag-%d", i))
+		}
+		_, err := normalizeTags(tags)
+		assert.ErrorIs(t, err, errTooManyTags)
+	})
+}
+
+func TestBulkTagUrls(t *testing.T) {
+	put := func(body string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(http.MethodPut, "/urls/bulk/tags", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = req
+		c.Set("user_id", 1)
+
+		BulkTagUrls(c)
+		return w
+	}
+
+	t.Run("no IDs", func(t *testing.T) {
+		w := put(`{"ids": [], "tags": ["landing"]}`)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "No IDs provided")
+	})
+
+	t.Run("unknown mode", func(t *testing.T) {
+		w := put(`{"ids": [1], "tags": ["landing"], "mode": "toggle"}`)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("adding no tags", func(t *testing.T) {
+		w := put(`{"ids": [1], "tags": [" "]}`)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "No tags provided")
+	})
+}
+
+func TestBulkMoveToProject(t *testing.T) {
+	put := func(body string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(http.MethodPut, "/urls/bulk/project", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = req
+		c.Set("user_id", 1)
+
+		BulkMoveToProject(c)
+		return w
+	}
+
+	t.Run("no IDs", func(t *testing.T) {
+		w := put(`{"ids": [], "project": "Relaunch"}`)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("long project name", func(t *testing.T) {
+		w := put(fmt.Sprintf(`{"ids": [1], "project": %q}`, strings.Repeat("p", 101)))
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
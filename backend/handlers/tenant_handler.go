@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"sykell-analyze/backend/config"
+	"sykell-analyze/backend/demo"
+	"sykell-analyze/backend/tenancy"
+
+	"github.com/gin-gonic/gin"
+)
+
+// requestDB returns the database of the request's tenant, or the primary database
+func requestDB(c *gin.Context) *sql.DB {
+	return config.DBFor(c.Request.Context())
+}
+
+// requirePrimary answers with a 403 and returns false when the request belongs to a tenant. Tenants
+// are managed from the primary site, so administrators of one tenant cannot reach the others.
+func requirePrimary(c *gin.Context) bool {
+	if config.TenantName(c.Request.Context()) != "" {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "Tenants are managed from the primary site",
+		})
+		return false
+	}
+	return true
+}
+
+// ListTenants returns the tenants this deployment serves
+func ListTenants(c *gin.Context) {
+	if !requirePrimary(c) {
+		return
+	}
+	all, err := tenancy.All()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to list tenants",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	tenants := []gin.H{}
+	for _, t := range all {
+		tenants = append(tenants, gin.H{"name": t.Name, "database": t.Database})
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"data": tenants,
+	})
+}
+
+// CreateTenant provisions the database of a new tenant. It is served right away on this process and
+// within a minute on the others.
+func CreateTenant(c *gin.Context) {
+	if !requirePrimary(c) {
+		return
+	}
+	if !config.App.Tenancy.Enabled {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "Tenancy is not enabled",
+		})
+		return
+	}
+
+	var req struct {
+		Name string `json:"name" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request format",
+			"details": err.Error(),
+		})
+		return
+	}
+	if !tenancy.ValidName(req.Name) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": tenancy.ErrInvalidName.Error(),
+		})
+		return
+	}
+
+	_, err := tenancy.Lookup(req.Name)
+	if err == nil {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "Tenant already exists",
+		})
+		return
+	} else if !errors.Is(err, tenancy.ErrUnknown) {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Database error",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	t, err := tenancy.Provision(c.Request.Context(), req.Name)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to provision tenant",
+			"details": err.Error(),
+		})
+		return
+	}
+	// The demo account is per tenant, as demo logins read the tenant's database
+	if config.App.Demo.Enabled {
+		if err := demo.Seed(config.WithTenant(c.Request.Context(), t), config.App.Demo); err != nil {
+			fmt.Printf("DEBUG: Failed to seed the demo account of tenant %s: %v\n", t.Name, err)
+		}
+	}
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Tenant created",
+		"data":    gin.H{"name": t.Name, "database": t.Database},
+	})
+}
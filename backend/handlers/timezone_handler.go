@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"sykell-analyze/backend/config"
+	"sykell-analyze/backend/models"
+	"sykell-analyze/backend/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// userLocation returns the time zone the user chose, or UTC when it cannot be read
+func userLocation(ctx context.Context, userID interface{}) *time.Location {
+	var timezone string
+	if err := config.DBFor(ctx).QueryRow("SELECT timezone FROM users WHERE id = ?", userID).Scan(&timezone); err != nil {
+		fmt.Printf("DEBUG: Failed to load timezone of user %v: %v\n", userID, err)
+		return time.UTC
+	}
+	return utils.Location(timezone)
+}
+
+// UpdateTimezone sets the IANA time zone the user's exports and digests show times in
+func UpdateTimezone(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Authentication required",
+		})
+		return
+	}
+
+	var req models.TimezoneRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request format",
+			"details": err.Error(),
+		})
+		return
+	}
+	if !utils.ValidTimezone(req.Timezone) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Unknown timezone, use an IANA name such as Europe/Berlin",
+		})
+		return
+	}
+
+	if _, err := requestDB(c).Exec("UPDATE users SET timezone = ? WHERE id = ?", req.Timezone, userID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to save timezone",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Timezone saved",
+		"data":    gin.H{"timezone": req.Timezone},
+	})
+}
@@ -0,0 +1,39 @@
+package handlers
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUpdateTimezone(t *testing.T) {
+	put := func(body string, authenticated bool) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest(http.MethodPut, "/profile/timezone", bytes.NewBufferString(body))
+		c.Request.Header.Set("Content-Type", "application/json")
+		if authenticated {
+			c.Set("user_id", 1)
+		}
+
+		UpdateTimezone(c)
+		return w
+	}
+
+	t.Run("missing authentication", func(t *testing.T) {
+		assert.Equal(t, http.StatusUnauthorized, put(`{"timezone":"Europe/Berlin"}`, false).Code)
+	})
+
+	t.Run("invalid timezone", func(t *testing.T) {
+		assert.Equal(t, http.StatusBadRequest, put(`{}`, true).Code)
+		assert.Equal(t, http.StatusBadRequest, put(`{"timezone":"Local"}`, true).Code)
+
+		w := put(`{"timezone":"Europe/Atlantis"}`, true)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "IANA")
+	})
+}
@@ -0,0 +1,127 @@
+package handlers
+
+import (
+	"database/sql"
+	"math"
+	"net/http"
+	"time"
+
+	"sykell-analyze/backend/config"
+	"sykell-analyze/backend/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// uptimeWindows are the periods availability is reported for
+var uptimeWindows = []struct {
+	name   string
+	period time.Duration
+}{
+	{"24h", 24 * time.Hour},
+	{"7d", 7 * 24 * time.Hour},
+	{"30d", 30 * 24 * time.Hour},
+}
+
+// GetUrlUptime returns the uptime monitor's pings of a URL and its availability over the last 24 hours,
+// 7 days and 30 days (only if owned by user)
+func GetUrlUptime(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Authentication required",
+		})
+		return
+	}
+
+	limit, ok := parseLogLimit(c.Query("limit"))
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "limit must be between 1 and 500",
+		})
+		return
+	}
+
+	id := c.Param("id")
+
+	var urlID int
+	err := requestDB(c).QueryRow("SELECT id FROM urls WHERE id = ? AND user_id = ?", id, userID).Scan(&urlID)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "URL not found",
+		})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Database error",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	report := models.UptimeReport{
+		UrlID:           urlID,
+		Monitoring:      config.App.Monitor.Enabled,
+		IntervalSeconds: int(config.App.Monitor.Interval.Seconds()),
+		Availability:    []models.UptimeWindow{},
+		Checks:          []models.UptimeCheck{},
+	}
+
+	now := time.Now()
+	for _, w := range uptimeWindows {
+		window := models.UptimeWindow{Window: w.name}
+		var up sql.NullInt64
+		err := requestDB(c).QueryRow(`
+			SELECT COUNT(*), SUM(is_up), AVG(response_ms)
+			FROM uptime_checks
+			WHERE url_id = ? AND checked_at >= ?
+		`, urlID, now.Add(-w.period)).Scan(&window.Checks, &up, &window.AvgResponseMs)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error":   "Database query failed",
+				"details": err.Error(),
+			})
+			return
+		}
+		window.Up = int(up.Int64)
+		if window.Checks > 0 {
+			percent := math.Round(float64(window.Up)/float64(window.Checks)*10000) / 100
+			window.AvailabilityPercent = &percent
+		}
+		if window.AvgResponseMs != nil {
+			avg := math.Round(*window.AvgResponseMs*10) / 10
+			window.AvgResponseMs = &avg
+		}
+		report.Availability = append(report.Availability, window)
+	}
+
+	rows, err := requestDB(c).Query(`
+		SELECT status_code, response_ms, is_up, error_message, checked_at
+		FROM uptime_checks
+		WHERE url_id = ?
+		ORDER BY checked_at DESC
+		LIMIT ?
+	`, urlID, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Database query failed",
+			"details": err.Error(),
+		})
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var check models.UptimeCheck
+		if err := rows.Scan(&check.StatusCode, &check.ResponseMs, &check.Up, &check.Error, &check.CheckedAt); err != nil {
+			continue // skip bad rows
+		}
+		report.Checks = append(report.Checks, check)
+	}
+	if len(report.Checks) > 0 {
+		report.LastCheck = &report.Checks[0]
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": report,
+	})
+}
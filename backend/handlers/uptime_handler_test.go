@@ -0,0 +1,35 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetUrlUptime(t *testing.T) {
+	router := setupTestRouter()
+	router.GET("/urls/:id/uptime", GetUrlUptime)
+	router.GET("/auth/urls/:id/uptime", func(c *gin.Context) {
+		c.Set("user_id", 1)
+		GetUrlUptime(c)
+	})
+
+	t.Run("missing authentication", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/urls/1/uptime", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
+	t.Run("invalid limit", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/auth/urls/1/uptime?limit=0", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
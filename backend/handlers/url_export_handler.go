@@ -0,0 +1,391 @@
+package handlers
+
+import (
+	"archive/zip"
+	"bufio"
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"sykell-analyze/backend/config"
+	"sykell-analyze/backend/dataexport"
+	"sykell-analyze/backend/middleware"
+	"sykell-analyze/backend/models"
+	"sykell-analyze/backend/storage"
+	"sykell-analyze/backend/xlsx"
+
+	"github.com/gin-gonic/gin"
+)
+
+// exportTTL is how long a finished URL export can be downloaded
+const exportTTL = 7 * 24 * time.Hour
+
+// exportContentTypes are the media types of the export formats
+var exportContentTypes = map[string]string{
+	"xlsx": xlsxContentType,
+	"csv":  "text/csv; charset=utf-8",
+	"zip":  "application/zip",
+}
+
+// exportColumns lists the exports columns read by scanExport, in scan order
+const exportColumns = "id, format, status, token, size_bytes, error_message, created_at, completed_at, expires_at"
+
+// scanExport reads a row selected with exportColumns into e; the download URL is only set once the
+// file is ready
+func scanExport(row rowScanner, e *models.Export, baseURL string) error {
+	var token string
+	if err := row.Scan(&e.ID, &e.Format, &e.Status, &token, &e.SizeBytes, &e.ErrorMessage, &e.CreatedAt, &e.CompletedAt, &e.ExpiresAt); err != nil {
+		return err
+	}
+	if e.Status == "ready" {
+		e.DownloadURL = baseURL + exportPath(token, e.Format)
+	}
+	return nil
+}
+
+// exportPath is where a URL export is downloaded
+func exportPath(token, format string) string {
+	return "/public/downloads/" + token + "." + format
+}
+
+// RequestExport starts building an export of the user's URLs in the background and returns it as
+// pending; poll GET /exports until it is ready
+func RequestExport(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Authentication required",
+		})
+		return
+	}
+
+	var req models.ExportRequest
+	if err := c.ShouldBindJSON(&req); err != nil && err != io.EOF {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request format",
+			"details": err.Error(),
+		})
+		return
+	}
+	if req.Format == "" {
+		req.Format = "xlsx"
+	}
+
+	// One export at a time; a pending export older than the build timeout was lost with its process
+	var pendingID int
+	err := requestDB(c).QueryRow(
+		"SELECT id FROM exports WHERE user_id = ? AND status = 'pending' AND created_at > ? LIMIT 1",
+		userID, time.Now().Add(-dataexport.BuildTimeout),
+	).Scan(&pendingID)
+	if err == nil {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "An export is already being prepared",
+			"id":    pendingID,
+		})
+		return
+	} else if err != sql.ErrNoRows {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Database error",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	// Drop expired files and abandoned builds
+	if err := dropOldExports(c.Request.Context(), "exports", userID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to clean up old exports",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	token, err := newShareToken()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to create download token",
+		})
+		return
+	}
+
+	now := time.Now()
+	result, err := requestDB(c).Exec(
+		"INSERT INTO exports (user_id, format, status, token, created_at) VALUES (?, ?, 'pending', ?, ?)",
+		userID, req.Format, token, now,
+	)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to start export",
+			"details": err.Error(),
+		})
+		return
+	}
+	id, _ := result.LastInsertId()
+
+	go buildExport(c.Request.Context(), int(id), userID.(int), req.Format, c.GetString(middleware.LangKey), userLocation(c.Request.Context(), userID))
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"message": "Export started",
+		"data": models.Export{
+			ID:        int(id),
+			Format:    req.Format,
+			Status:    "pending",
+			CreatedAt: now,
+		},
+	})
+}
+
+// buildExport writes the file of export exportID and stores it, recording the outcome. ctx selects
+// the tenant; its cancellation is ignored, as the export outlives the request.
+func buildExport(ctx context.Context, exportID, userID int, format, lang string, loc *time.Location) {
+	ctx = context.WithoutCancel(ctx)
+	key := fmt.Sprintf("exports/%d/urls-%d.%s", userID, exportID, format)
+	size, err := writeExportFile(ctx, key, userID, format, lang, loc)
+	if err != nil {
+		fmt.Printf("DEBUG: Export %d for user %d failed: %v\n", exportID, userID, err)
+		if _, dbErr := config.DBFor(ctx).Exec(
+			"UPDATE exports SET status = 'failed', error_message = ?, completed_at = ? WHERE id = ?",
+			err.Error(), time.Now(), exportID,
+		); dbErr != nil {
+			fmt.Printf("DEBUG: Failed to record failure of export %d: %v\n", exportID, dbErr)
+		}
+		return
+	}
+
+	now := time.Now()
+	_, err = config.DBFor(ctx).Exec(`
+		UPDATE exports SET status = 'ready', storage_key = ?, size_bytes = ?, completed_at = ?, expires_at = ?
+		WHERE id = ?
+	`, key, size, now, now.Add(exportTTL), exportID)
+	if err != nil {
+		fmt.Printf("DEBUG: Failed to record export %d: %v\n", exportID, err)
+		return
+	}
+	fmt.Printf("DEBUG: Export %d for user %d ready (%d bytes)\n", exportID, userID, size)
+}
+
+// writeExportFile builds the export in format and puts it in storage under key, returning its size.
+// The file is written to a temporary file and streamed to storage, so large exports are not held in memory.
+func writeExportFile(ctx context.Context, key string, userID int, format, lang string, loc *time.Location) (int, error) {
+	store := storage.For(ctx)
+	if store == nil {
+		return 0, fmt.Errorf("storage is not set up")
+	}
+
+	tmp, err := os.CreateTemp("", "export-*."+format)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create export file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	rows, err := config.DBFor(ctx).Query(urlExportQuery, userID)
+	if err != nil {
+		return 0, err
+	}
+
+	out := bufio.NewWriter(tmp)
+	var w sheetWriter
+	switch format {
+	case "csv":
+		w = newCSVSheets(out, false)
+	case "zip":
+		w = newCSVSheets(out, true)
+	default:
+		w = xlsx.NewWriter(out)
+	}
+	if err := writeExport(ctx, w, rows, userID, lang, loc); err != nil {
+		return 0, err
+	}
+	if err := out.Flush(); err != nil {
+		return 0, fmt.Errorf("failed to write export file: %w", err)
+	}
+	size, err := tmp.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return 0, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, dataexport.BuildTimeout)
+	defer cancel()
+	if err := store.PutStream(ctx, key, tmp); err != nil {
+		return 0, fmt.Errorf("failed to store export: %w", err)
+	}
+	return int(size), nil
+}
+
+// GetExports lists the user's URL exports with their status, newest first
+func GetExports(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Authentication required",
+		})
+		return
+	}
+
+	rows, err := requestDB(c).Query("SELECT "+exportColumns+" FROM exports WHERE user_id = ? ORDER BY id DESC", userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Database query failed",
+			"details": err.Error(),
+		})
+		return
+	}
+	defer rows.Close()
+
+	baseURL := requestBaseURL(c)
+	exports := []models.Export{}
+	for rows.Next() {
+		var e models.Export
+		if err := scanExport(rows, &e, baseURL); err != nil {
+			continue // skip bad rows
+		}
+		exports = append(exports, e)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": exports,
+	})
+}
+
+// DownloadExport serves a ready URL export by its download token, answering Range requests so
+// interrupted downloads resume (no authentication, so download managers can fetch the link)
+func DownloadExport(c *gin.Context) {
+	file := c.Param("file")
+	dot := strings.LastIndex(file, ".")
+	if dot <= 0 {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Export not found",
+		})
+		return
+	}
+	token, extension := file[:dot], file[dot+1:]
+
+	var format, storageKey string
+	var completedAt, expiresAt time.Time
+	err := requestDB(c).QueryRow(
+		"SELECT format, storage_key, completed_at, expires_at FROM exports WHERE token = ? AND status = 'ready'", token,
+	).Scan(&format, &storageKey, &completedAt, &expiresAt)
+	if err == sql.ErrNoRows || (err == nil && format != extension) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Export not found",
+		})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Database error",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	if time.Now().After(expiresAt) {
+		c.JSON(http.StatusGone, gin.H{
+			"error": "Export has expired",
+		})
+		return
+	}
+
+	filename := "urls-" + completedAt.Format("2006-01-02") + "." + format
+	serveStored(c, storageKey, token, filename, exportContentTypes[format], completedAt)
+}
+
+// csvSheets writes the sheets of an export as CSV: every sheet as a file of a ZIP archive, or only
+// the first sheet as a plain CSV file
+type csvSheets struct {
+	out     io.Writer
+	archive *zip.Writer
+	sheet   *csv.Writer
+	sheets  int
+}
+
+// newCSVSheets writes to out, as a ZIP archive of CSV files when zipped
+func newCSVSheets(out io.Writer, zipped bool) *csvSheets {
+	s := &csvSheets{out: out}
+	if zipped {
+		s.archive = zip.NewWriter(out)
+	}
+	return s
+}
+
+// AddSheet starts a file named after the sheet; in a plain CSV file, rows of later sheets are dropped
+func (s *csvSheets) AddSheet(name string) error {
+	if err := s.flush(); err != nil {
+		return err
+	}
+	s.sheets++
+	switch {
+	case s.archive != nil:
+		f, err := s.archive.Create(name + ".csv")
+		if err != nil {
+			return err
+		}
+		s.sheet = csv.NewWriter(f)
+	case s.sheets == 1:
+		s.sheet = csv.NewWriter(s.out)
+	default:
+		s.sheet = nil
+	}
+	return nil
+}
+
+// WriteRow appends a row to the current sheet, formatting cells as the workbook shows them
+func (s *csvSheets) WriteRow(cells ...interface{}) error {
+	if s.sheet == nil {
+		return nil
+	}
+	record := make([]string, len(cells))
+	for i, cell := range cells {
+		record[i] = csvCell(cell)
+	}
+	return s.sheet.Write(record)
+}
+
+// Close finishes the last sheet and the archive
+func (s *csvSheets) Close() error {
+	if err := s.flush(); err != nil {
+		return err
+	}
+	if s.archive != nil {
+		return s.archive.Close()
+	}
+	return nil
+}
+
+func (s *csvSheets) flush() error {
+	if s.sheet == nil {
+		return nil
+	}
+	s.sheet.Flush()
+	return s.sheet.Error()
+}
+
+// csvCell formats a cell value. Text starting like a formula is prefixed with a quote, so spreadsheet
+// apps opening the file never run formulas taken from crawled pages.
+func csvCell(cell interface{}) string {
+	switch v := cell.(type) {
+	case nil:
+		return ""
+	case string:
+		if v != "" && strings.ContainsRune("=+-@\t\r", rune(v[0])) {
+			return "'" + v
+		}
+		return v
+	case bool:
+		return strconv.FormatBool(v)
+	case time.Time:
+		if v.IsZero() {
+			return ""
+		}
+		return v.Format("2006-01-02 15:04:05")
+	default:
+		return fmt.Sprint(v)
+	}
+}
@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCsvCell(t *testing.T) {
+	assert.Equal(t, "", csvCell(nil))
+	assert.Equal(t, "Home", csvCell("Home"))
+	assert.Equal(t, "'=HYPERLINK(\"x\")", csvCell("=HYPERLINK(\"x\")"))
+	assert.Equal(t, "'-1", csvCell("-1"))
+	assert.Equal(t, "true", csvCell(true))
+	assert.Equal(t, "42", csvCell(42))
+	assert.Equal(t, "", csvCell(time.Time{}))
+	assert.Equal(t, "2024-03-01 09:30:00", csvCell(time.Date(2024, 3, 1, 9, 30, 0, 0, time.UTC)))
+}
+
+func writeSheets(t *testing.T, w sheetWriter) {
+	require.NoError(t, w.AddSheet("URLs"))
+	require.NoError(t, w.WriteRow("ID", "URL"))
+	require.NoError(t, w.WriteRow(1, "https://example.com"))
+	require.NoError(t, w.AddSheet("Broken links"))
+	require.NoError(t, w.WriteRow("URL ID", "Link"))
+	require.NoError(t, w.Close())
+}
+
+func TestCSVSheets(t *testing.T) {
+	t.Run("plain CSV keeps the first sheet", func(t *testing.T) {
+		var buf bytes.Buffer
+		writeSheets(t, newCSVSheets(&buf, false))
+		assert.Equal(t, "ID,URL\n1,https://example.com\n", buf.String())
+	})
+
+	t.Run("ZIP holds every sheet", func(t *testing.T) {
+		var buf bytes.Buffer
+		writeSheets(t, newCSVSheets(&buf, true))
+
+		archive, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+		require.NoError(t, err)
+		require.Len(t, archive.File, 2)
+		assert.Equal(t, "URLs.csv", archive.File[0].Name)
+		assert.Equal(t, "Broken links.csv", archive.File[1].Name)
+
+		f, err := archive.File[1].Open()
+		require.NoError(t, err)
+		content, _ := io.ReadAll(f)
+		assert.Equal(t, "URL ID,Link\n", string(content))
+	})
+}
+
+func TestServeDownload(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	modTime := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	download := func(header http.Header) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodGet, "/public/downloads/abc.csv", nil)
+		c.Request.Header = header
+		serveDownload(c, strings.NewReader("0123456789"), "abc", "urls.csv", "text/csv; charset=utf-8", modTime)
+		return w
+	}
+
+	t.Run("whole file", func(t *testing.T) {
+		w := download(http.Header{})
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "0123456789", w.Body.String())
+		assert.Equal(t, "bytes", w.Header().Get("Accept-Ranges"))
+		assert.Equal(t, `attachment; filename="urls.csv"`, w.Header().Get("Content-Disposition"))
+	})
+
+	t.Run("resumes from a byte offset", func(t *testing.T) {
+		w := download(http.Header{"Range": {"bytes=6-"}, "If-Range": {`"abc"`}})
+		assert.Equal(t, http.StatusPartialContent, w.Code)
+		assert.Equal(t, "6789", w.Body.String())
+		assert.Equal(t, "bytes 6-9/10", w.Header().Get("Content-Range"))
+	})
+
+	t.Run("a changed file is sent whole", func(t *testing.T) {
+		w := download(http.Header{"Range": {"bytes=6-"}, "If-Range": {`"other"`}})
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "0123456789", w.Body.String())
+	})
+}
@@ -1,17 +1,28 @@
 package handlers
 
 import (
+	"context"
 	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"math"
 	"net/http"
+	"net/netip"
 	"net/url"
+	"reflect"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
 
+	"sykell-analyze/backend/analyzer"
+	"sykell-analyze/backend/archive"
 	"sykell-analyze/backend/config"
 	"sykell-analyze/backend/models"
-	"sykell-analyze/backend/utils"
+	"sykell-analyze/backend/sanitize"
+	"sykell-analyze/backend/urlstatus"
+	"sykell-analyze/backend/worker"
 
 	"github.com/gin-gonic/gin"
 )
@@ -23,16 +34,271 @@ func normalizeURL(inputURL string) string {
 
 	// If URL doesn't start with http:// or https://, add https://
 	if !strings.HasPrefix(inputURL, "http://") && !strings.HasPrefix(inputURL, "https://") {
+		// A bare IPv6 address only becomes a host in brackets
+		if addr, err := netip.ParseAddr(inputURL); err == nil && addr.Is6() {
+			inputURL = "[" + inputURL + "]"
+		}
 		inputURL = "https://" + inputURL
 	}
 
 	return inputURL
 }
 
+// validateURL normalizes the input and checks it is an absolute http(s) URL with a host
+func validateURL(inputURL string) (string, error) {
+	if strings.TrimSpace(inputURL) == "" {
+		return "", fmt.Errorf("URL is required")
+	}
+
+	normalizedURL := normalizeURL(inputURL)
+	parsed, err := url.Parse(normalizedURL)
+	if err != nil {
+		return "", err
+	}
+	if parsed.Host == "" {
+		return "", fmt.Errorf("URL must include a host")
+	}
+	if err := checkHost(parsed); err != nil {
+		return "", err
+	}
+	if err := config.App.Crawler.CheckTarget(normalizedURL); err != nil {
+		return "", err
+	}
+
+	return normalizedURL, nil
+}
+
+// checkHost rejects hosts url.Parse lets through but no crawl can reach: unbracketed IPv6 addresses,
+// brackets around anything but an IPv6 address, and ports outside 1-65535
+func checkHost(u *url.URL) error {
+	host := u.Hostname()
+	if strings.HasPrefix(u.Host, "[") {
+		if addr, err := netip.ParseAddr(host); err != nil || !addr.Is6() {
+			return fmt.Errorf("%s is not a valid IPv6 address", host)
+		}
+	} else if strings.Contains(host, ":") {
+		return fmt.Errorf("IPv6 addresses must be in brackets, e.g. https://[::1]:8080")
+	}
+	if port := u.Port(); port != "" {
+		if n, err := strconv.Atoi(port); err != nil || n < 1 || n > 65535 {
+			return fmt.Errorf("port %s is out of range", port)
+		}
+	}
+	return nil
+}
+
+// bulkUrlMax returns the maximum number of URLs accepted by AddUrlsBulk (server.bulk_url_max)
+func bulkUrlMax() int {
+	return config.App.Server.BulkUrlMax
+}
+
+// enqueueOptions reads crawl options shared by every endpoint that queues URLs.
+// ?fresh=true bypasses results recently crawled for the same URL by other users, and
+// ?priority=high|normal|low places the jobs in the queue. A priority given in the request
+// body takes precedence over the query string.
+func enqueueOptions(c *gin.Context, bodyPriority string) (worker.EnqueueOptions, error) {
+	name := bodyPriority
+	if name == "" {
+		name = c.Query("priority")
+	}
+	priority, err := worker.ParsePriority(name)
+	if err != nil {
+		return worker.EnqueueOptions{}, err
+	}
+
+	return worker.EnqueueOptions{
+		ForceFresh: c.Query("fresh") == "true",
+		Priority:   priority,
+	}, nil
+}
+
+// respondInvalidOptions reports an unusable enqueueOptions request
+func respondInvalidOptions(c *gin.Context, err error) {
+	c.JSON(http.StatusBadRequest, gin.H{
+		"error": err.Error(),
+	})
+}
+
+// urlColumns lists the urls table columns read by scanUrl, in scan order
+const urlColumns = `id, user_id, url, COALESCE(html_version, ''), COALESCE(title, ''), h1_count, h2_count, h3_count,
+	internal_links, external_links, broken_links, has_login_form,
+	status, error_message, created_at, updated_at,
+	internal_nofollow_links, external_nofollow_links, sponsored_links, ugc_links, is_noindex, is_nofollow,
+	crawled_at, result_schema_version,
+	progress_stage, progress_links_discovered, progress_links_to_check, progress_links_checked, progress_percent,
+	progress_updated_at, has_consent_banner,
+	EXISTS (SELECT 1 FROM crawl_jobs j WHERE j.url_id = urls.id AND j.status = 'paused'),
+	crawl_timeout_seconds, crawl_user_agent, check_broken_links,
+	version, project, (SELECT GROUP_CONCAT(t.tag ORDER BY t.tag SEPARATOR ',') FROM url_tags t WHERE t.url_id = urls.id)`
+
+// rowScanner is satisfied by both *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanUrl reads a row selected with urlColumns into u
+func scanUrl(row rowScanner, u *models.Url) error {
+	var stage sql.NullString
+	var progress models.CrawlProgress
+	var checkLinks bool
+	var tags sql.NullString
+	err := row.Scan(
+		&u.ID, &u.UserID, &u.Url, &u.HtmlVersion, &u.Title,
+		&u.H1Count, &u.H2Count, &u.H3Count,
+		&u.InternalLinks, &u.ExternalLinks, &u.BrokenLinks,
+		&u.HasLoginForm, &u.Status, &u.ErrorMessage,
+		&u.CreatedAt, &u.UpdatedAt,
+		&u.InternalNofollowLinks, &u.ExternalNofollowLinks, &u.SponsoredLinks, &u.UgcLinks,
+		&u.IsNoindex, &u.IsNofollow,
+		&u.LastCrawledAt, &u.ResultSchemaVersion,
+		&stage, &progress.LinksDiscovered, &progress.LinksToCheck, &progress.LinksChecked, &progress.Percent,
+		&progress.UpdatedAt, &u.HasConsentBanner,
+		&u.IsPaused,
+		&u.CrawlSettings.TimeoutSeconds, &u.CrawlSettings.UserAgent, &checkLinks,
+		&u.Version, &u.Project, &tags,
+	)
+	if err != nil {
+		return err
+	}
+	u.CrawlSettings.CheckBrokenLinks = &checkLinks
+	// Rows stored before text was sanitized on save are cleaned on the way out
+	u.Title = sanitize.Line(u.Title, sanitize.MaxTitle)
+	u.ErrorMessage = sanitize.LinePtr(u.ErrorMessage, sanitize.MaxMessage)
+
+	// Tags cannot contain commas, so the concatenated list splits back cleanly
+	u.Tags = []string{}
+	if tags.Valid {
+		u.Tags = strings.Split(tags.String, ",")
+	}
+
+	// A queued URL has not started its new crawl yet, so the last run's progress would mislead
+	if stage.Valid && u.Status != "queued" {
+		progress.Stage = stage.String
+		u.Progress = &progress
+	}
+
+	u.IsStale = isStale(u, time.Now(), staleAfter())
+	u.IsOutdated = isOutdated(u)
+	return nil
+}
+
+// staleAfter is the age after which a completed analysis is reported as stale (crawler.stale_after)
+func staleAfter() time.Duration {
+	return config.App.Crawler.StaleAfter
+}
+
+// isStale reports whether a completed analysis is older than the threshold
+func isStale(u *models.Url, now time.Time, threshold time.Duration) bool {
+	if u.Status != "completed" || u.LastCrawledAt == nil {
+		return false
+	}
+	return now.Sub(*u.LastCrawledAt) > threshold
+}
+
+// isOutdated reports whether a completed analysis was stored by an older analyzer
+func isOutdated(u *models.Url) bool {
+	return u.Status == "completed" && u.ResultSchemaVersion < analyzer.SchemaVersion
+}
+
+// applyEta fills in eta_seconds for queued and running URLs. Estimates are best effort:
+// if the queue cannot be read, or no worker is running, they are left out. Paused URLs have none.
+func applyEta(ctx context.Context, urls []models.Url) {
+	var pending []int
+	for _, u := range urls {
+		if (u.Status == "queued" && !u.IsPaused) || u.Status == "running" {
+			pending = append(pending, u.ID)
+		}
+	}
+	if len(pending) == 0 {
+		return
+	}
+
+	snapshot, err := worker.SnapshotQueue(ctx, pending, 3*config.App.Worker.HeartbeatInterval)
+	if err != nil {
+		fmt.Printf("DEBUG: Skipping ETA estimates: %v\n", err)
+		return
+	}
+
+	now := time.Now()
+	for i := range urls {
+		u := &urls[i]
+		switch u.Status {
+		case "queued":
+			if u.IsPaused {
+				continue
+			}
+			if seconds, ok := snapshot.EstimateQueued(u.ID); ok {
+				u.EtaSeconds = &seconds
+			}
+		case "running":
+			percent := 0
+			if u.Progress != nil {
+				percent = u.Progress.Percent
+			}
+			seconds := snapshot.EstimateRunning(now.Sub(u.UpdatedAt), percent)
+			u.EtaSeconds = &seconds
+		}
+	}
+}
+
+// urlFields are the JSON fields of a URL that ?fields= can select
+var urlFields = func() []string {
+	t := reflect.TypeOf(models.Url{})
+	fields := make([]string, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		fields = append(fields, name)
+	}
+	return fields
+}()
+
+// parseFields reads a comma-separated list of URL fields; an empty list selects every field
+func parseFields(param string) ([]string, error) {
+	var fields []string
+	for _, name := range strings.Split(param, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" || slices.Contains(fields, name) {
+			continue
+		}
+		if !slices.Contains(urlFields, name) {
+			return nil, fmt.Errorf("unknown field %q; use any of %s", name, strings.Join(urlFields, ", "))
+		}
+		fields = append(fields, name)
+	}
+	return fields, nil
+}
+
+// selectFields reduces each URL to the requested fields. Fields left out of a URL's
+// JSON when empty, such as eta_seconds, stay left out.
+func selectFields(urls []models.Url, fields []string) ([]map[string]json.RawMessage, error) {
+	selected := make([]map[string]json.RawMessage, 0, len(urls))
+	for _, u := range urls {
+		data, err := json.Marshal(u)
+		if err != nil {
+			return nil, err
+		}
+		var all map[string]json.RawMessage
+		if err := json.Unmarshal(data, &all); err != nil {
+			return nil, err
+		}
+		item := make(map[string]json.RawMessage, len(fields))
+		for _, name := range fields {
+			if value, ok := all[name]; ok {
+				item[name] = value
+			}
+		}
+		selected = append(selected, item)
+	}
+	return selected, nil
+}
+
 // AddUrl handles adding a new URL for analysis
 func AddUrl(c *gin.Context) {
 	var input struct {
-		URL string `json:"url" binding:"required"`
+		URL      string `json:"url" binding:"required"`
+		Priority string `json:"priority"`
+		// Settings override the user's crawl defaults for this URL
+		Settings *models.CrawlSettings `json:"settings"`
 	}
 
 	// Get authenticated user
@@ -53,6 +319,12 @@ func AddUrl(c *gin.Context) {
 		return
 	}
 
+	opts, err := enqueueOptions(c, input.Priority)
+	if err != nil {
+		respondInvalidOptions(c, err)
+		return
+	}
+
 	// Validate URL format
 	if input.URL == "" {
 		c.JSON(http.StatusBadRequest, gin.H{
@@ -61,11 +333,8 @@ func AddUrl(c *gin.Context) {
 		return
 	}
 
-	// Normalize the URL
-	normalizedURL := normalizeURL(input.URL)
-
-	// Validate that the normalized URL is valid
-	_, err := url.Parse(normalizedURL)
+	// Normalize and validate the URL
+	normalizedURL, err := validateURL(input.URL)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"error":   "Invalid URL format",
@@ -76,7 +345,7 @@ func AddUrl(c *gin.Context) {
 
 	// Check if URL already exists for this user
 	var existingID int
-	err = config.DB.QueryRow("SELECT id FROM urls WHERE url = ? AND user_id = ?", normalizedURL, userID).Scan(&existingID)
+	err = requestDB(c).QueryRow("SELECT id FROM urls WHERE url = ? AND user_id = ?", normalizedURL, userID).Scan(&existingID)
 	if err == nil {
 		c.JSON(http.StatusConflict, gin.H{
 			"error": "URL already exists for this user",
@@ -90,15 +359,21 @@ func AddUrl(c *gin.Context) {
 		return
 	}
 
+	settings, ok := crawlSettingsFor(c, userID, input.Settings)
+	if !ok {
+		return
+	}
+
 	// Insert URL with queued status
 	query := `
 		INSERT INTO urls (
-			user_id, url, status, created_at, updated_at
-		) VALUES (?, ?, 'queued', ?, ?)
+			user_id, url, status, crawl_timeout_seconds, crawl_user_agent, check_broken_links, created_at, updated_at
+		) VALUES (?, ?, 'queued', ?, ?, ?, ?, ?)
 	`
 
 	now := time.Now()
-	result, err := config.DB.Exec(query, userID, normalizedURL, now, now)
+	result, err := requestDB(c).Exec(query, userID, normalizedURL,
+		settings.TimeoutSeconds, settings.UserAgent, *settings.CheckBrokenLinks, now, now)
 
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
@@ -110,31 +385,35 @@ func AddUrl(c *gin.Context) {
 
 	// Get the inserted ID
 	id, _ := result.LastInsertId()
+	if err := urlstatus.CountNew(c.Request.Context(), userID, 1); err != nil {
+		fmt.Printf("DEBUG: Failed to count new URL %d: %v\n", id, err)
+	}
 
-	// Start crawling in background
-	go func() {
-		defer func() {
-			if r := recover(); r != nil {
-				fmt.Printf("PANIC in crawlAndUpdateURL: %v\n", r)
-				// Update status to error on panic
-				config.DB.Exec(
-					"UPDATE urls SET status = 'error', error_message = ?, updated_at = ? WHERE id = ?",
-					fmt.Sprintf("Panic during analysis: %v", r), time.Now(), int(id),
-				)
-			}
-		}()
-		fmt.Printf("DEBUG: Starting crawl for URL ID %d: %s\n", int(id), normalizedURL)
-		crawlAndUpdateURL(int(id), normalizedURL)
-	}()
+	// Queue the crawl for the worker pool
+	if err := worker.Enqueue(c.Request.Context(), int(id), opts); err != nil {
+		urlstatus.Apply(c.Request.Context(), urlstatus.Request{
+			UrlID: int(id),
+			To:    urlstatus.Error,
+			Force: true,
+			Set:   "error_message = ?",
+			Args:  []interface{}{err.Error()},
+		})
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to queue URL for analysis",
+			"details": err.Error(),
+		})
+		return
+	}
 
 	// Create response object
 	urlData := models.Url{
-		ID:        int(id),
-		UserID:    userID.(int),
-		Url:       normalizedURL,
-		Status:    "queued",
-		CreatedAt: now,
-		UpdatedAt: now,
+		ID:            int(id),
+		UserID:        userID.(int),
+		Url:           normalizedURL,
+		Status:        "queued",
+		CreatedAt:     now,
+		UpdatedAt:     now,
+		CrawlSettings: settings,
 	}
 
 	c.JSON(http.StatusCreated, gin.H{
@@ -143,73 +422,199 @@ func AddUrl(c *gin.Context) {
 	})
 }
 
-// crawlAndUpdateURL performs the actual crawling and updates the database
-func crawlAndUpdateURL(urlID int, url string) {
-	// Update status to running
-	config.DB.Exec("UPDATE urls SET status = 'running', updated_at = ? WHERE id = ?", time.Now(), urlID)
+// AddUrlsBulk adds several URLs in one transaction, reporting the outcome of each item
+func AddUrlsBulk(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Authentication required",
+		})
+		return
+	}
 
-	// Crawl and analyze the URL
-	crawlResult, err := utils.CrawlURL(url)
+	var req struct {
+		URLs     []string `json:"urls" binding:"required"`
+		Priority string   `json:"priority"`
+		// Settings override the user's crawl defaults for every URL
+		Settings *models.CrawlSettings `json:"settings"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request format",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	opts, err := enqueueOptions(c, req.Priority)
 	if err != nil {
-		// Update status to error
-		config.DB.Exec(
-			"UPDATE urls SET status = 'error', error_message = ?, updated_at = ? WHERE id = ?",
-			err.Error(), time.Now(), urlID,
-		)
+		respondInvalidOptions(c, err)
 		return
 	}
 
-	// Debug logging
-	fmt.Printf("DEBUG: Crawl result for URL %s (ID: %d):\n", url, urlID)
-	fmt.Printf("  Title: %s\n", crawlResult.Title)
-	fmt.Printf("  Internal Links: %d\n", crawlResult.InternalLinks)
-	fmt.Printf("  External Links: %d\n", crawlResult.ExternalLinks)
-	fmt.Printf("  H1: %d, H2: %d, H3: %d\n", crawlResult.H1, crawlResult.H2, crawlResult.H3)
-	fmt.Printf("  HTML Version: %s\n", crawlResult.HtmlVersion)
-	fmt.Printf("  Has Login Form: %t\n", crawlResult.HasLoginForm)
+	if len(req.URLs) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "No URLs provided",
+		})
+		return
+	}
 
-	// Update with analysis results
-	query := `
-		UPDATE urls SET 
-			html_version = ?, title = ?, h1_count = ?, h2_count = ?, h3_count = ?,
-			internal_links = ?, external_links = ?, broken_links = ?, has_login_form = ?,
-			status = 'completed', updated_at = ?
-		WHERE id = ?
-	`
+	if max := bulkUrlMax(); len(req.URLs) > max {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("Too many URLs: at most %d can be submitted at once", max),
+		})
+		return
+	}
 
-	result, err := config.DB.Exec(query,
-		crawlResult.HtmlVersion,
-		crawlResult.Title,
-		crawlResult.H1,
-		crawlResult.H2,
-		crawlResult.H3,
-		crawlResult.InternalLinks,
-		crawlResult.ExternalLinks,
-		len(crawlResult.BrokenLinksDetails),
-		crawlResult.HasLoginForm,
-		time.Now(),
-		urlID,
-	)
-	if err != nil {
-		// If update fails, mark as error
-		fmt.Printf("DEBUG: Database update failed: %v\n", err)
-		config.DB.Exec(
-			"UPDATE urls SET status = 'error', error_message = ?, updated_at = ? WHERE id = ?",
-			"Failed to save analysis results: "+err.Error(), time.Now(), urlID,
-		)
+	settings, ok := crawlSettingsFor(c, userID, req.Settings)
+	if !ok {
+		return
+	}
+
+	results, created, ok := addUrls(c, userID, req.URLs, opts, settings)
+	if !ok {
 		return
 	}
 
-	rowsAffected, _ := result.RowsAffected()
-	fmt.Printf("DEBUG: Database update successful, rows affected: %d\n", rowsAffected)
+	status := http.StatusOK
+	if created > 0 {
+		status = http.StatusCreated
+	}
 
-	// Store broken links details
-	for _, brokenLink := range crawlResult.BrokenLinksDetails {
-		config.DB.Exec(
-			"INSERT INTO broken_links (url_id, link_url, status_code, error_message, created_at) VALUES (?, ?, ?, ?, ?)",
-			urlID, brokenLink.URL, brokenLink.StatusCode, brokenLink.Error, time.Now(),
-		)
+	c.JSON(status, gin.H{
+		"message":       fmt.Sprintf("%d URL(s) queued for analysis", created),
+		"created_count": created,
+		"results":       results,
+	})
+}
+
+// addUrls saves and queues the inputs in one transaction, reporting a created, duplicate or invalid
+// result per input. On failure it answers the request itself and returns false.
+func addUrls(c *gin.Context, userID interface{}, inputs []string, opts worker.EnqueueOptions, settings models.CrawlSettings) ([]models.BulkUrlResult, int, bool) {
+	tx, err := requestDB(c).Begin()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Database error",
+		})
+		return nil, 0, false
 	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	results := make([]models.BulkUrlResult, 0, len(inputs))
+	seen := make(map[string]int)
+	created := 0
+
+	for _, input := range inputs {
+		result := models.BulkUrlResult{Input: input}
+
+		normalizedURL, err := validateURL(input)
+		if err != nil {
+			result.Status = "invalid"
+			result.Error = err.Error()
+			results = append(results, result)
+			continue
+		}
+		result.Url = normalizedURL
+
+		// Duplicate within this request
+		if id, ok := seen[normalizedURL]; ok {
+			result.Status = "duplicate"
+			result.ID = id
+			results = append(results, result)
+			continue
+		}
+
+		// Duplicate of an existing URL for this user
+		var existingID int
+		err = tx.QueryRow("SELECT id FROM urls WHERE url = ? AND user_id = ?", normalizedURL, userID).Scan(&existingID)
+		if err == nil {
+			result.Status = "duplicate"
+			result.ID = existingID
+			seen[normalizedURL] = existingID
+			results = append(results, result)
+			continue
+		} else if err != sql.ErrNoRows {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Database error",
+			})
+			return nil, 0, false
+		}
+
+		insert, err := tx.Exec(`
+			INSERT INTO urls (user_id, url, status, crawl_timeout_seconds, crawl_user_agent, check_broken_links, created_at, updated_at)
+			VALUES (?, ?, 'queued', ?, ?, ?, ?, ?)
+		`, userID, normalizedURL, settings.TimeoutSeconds, settings.UserAgent, *settings.CheckBrokenLinks, now, now)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error":   "Failed to save URLs",
+				"details": err.Error(),
+			})
+			return nil, 0, false
+		}
+		id, _ := insert.LastInsertId()
+		if err := urlstatus.CountNewTx(tx, userID, 1); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error":   "Failed to save URLs",
+				"details": err.Error(),
+			})
+			return nil, 0, false
+		}
+
+		if err := worker.EnqueueTx(tx, int(id), opts); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error":   "Failed to queue URLs for analysis",
+				"details": err.Error(),
+			})
+			return nil, 0, false
+		}
+
+		result.Status = "created"
+		result.ID = int(id)
+		seen[normalizedURL] = int(id)
+		created++
+		results = append(results, result)
+	}
+
+	if err := tx.Commit(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to save URLs",
+			"details": err.Error(),
+		})
+		return nil, 0, false
+	}
+	return results, created, true
+}
+
+// urlListWhere returns the WHERE clause selecting the user's URLs that match filters, with its
+// arguments. user_id and status come first so they match the leading columns of the list indexes.
+func urlListWhere(userID interface{}, filters models.UrlFilters) (string, []interface{}) {
+	conditions := []string{"user_id = ?"}
+	args := []interface{}{userID}
+
+	if filters.Status != "" {
+		conditions = append(conditions, "status = ?")
+		args = append(args, filters.Status)
+	}
+	if filters.ConsentBanner != nil {
+		conditions = append(conditions, "has_consent_banner = ?")
+		args = append(args, *filters.ConsentBanner)
+	}
+	if filters.Tag != "" {
+		conditions = append(conditions, "EXISTS (SELECT 1 FROM url_tags t WHERE t.url_id = urls.id AND t.tag = ?)")
+		args = append(args, filters.Tag)
+	}
+	if filters.Project != "" {
+		conditions = append(conditions, "project = ?")
+		args = append(args, filters.Project)
+	}
+	if filters.Search != "" {
+		searchPattern := "%" + filters.Search + "%"
+		conditions = append(conditions, "(title LIKE ? OR url LIKE ?)")
+		args = append(args, searchPattern, searchPattern)
+	}
+	return strings.Join(conditions, " AND "), args
 }
 
 // GetUrls retrieves all analyzed URLs for the authenticated user
@@ -225,8 +630,13 @@ func GetUrls(c *gin.Context) {
 	// Get pagination parameters
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
 	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
-	status := c.Query("status")
-	search := c.Query("search")
+	filters := models.UrlFilters{
+		Status:  c.Query("status"),
+		Search:  c.Query("search"),
+		Tag:     c.Query("tag"),
+		Project: c.Query("project"),
+		Sort:    c.Query("sort"),
+	}
 
 	if page < 1 {
 		page = 1
@@ -237,40 +647,79 @@ func GetUrls(c *gin.Context) {
 
 	offset := (page - 1) * limit
 
-	// Build query with filters
-	baseQuery := `
-		SELECT id, user_id, url, html_version, title, h1_count, h2_count, h3_count,
-		       internal_links, external_links, broken_links, has_login_form, 
-		       status, error_message, created_at, updated_at
-		FROM urls 
-		WHERE user_id = ?
-	`
+	// Compliance audits look for sites with or without a cookie consent banner
+	if consentBanner := c.Query("consent_banner"); consentBanner != "" {
+		hasBanner, err := strconv.ParseBool(consentBanner)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "consent_banner must be true or false",
+			})
+			return
+		}
+		filters.ConsentBanner = &hasBanner
+	}
 
-	countQuery := "SELECT COUNT(*) FROM urls WHERE user_id = ?"
-	args := []interface{}{userID}
-	countArgs := []interface{}{userID}
+	// A saved view supplies the filters the query leaves out
+	if viewParam := c.Query("view"); viewParam != "" {
+		viewID, err := strconv.Atoi(viewParam)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Invalid view ID",
+			})
+			return
+		}
+		view, err := loadView(c.Request.Context(), userID, viewID)
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "View not found",
+			})
+			return
+		} else if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error":   "Database error",
+				"details": err.Error(),
+			})
+			return
+		}
+		filters = mergeFilters(filters, view.Filters)
+	}
 
-	if status != "" {
-		baseQuery += " AND status = ?"
-		countQuery += " AND status = ?"
-		args = append(args, status)
-		countArgs = append(countArgs, status)
+	order, err := urlOrder(filters.Sort)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid sort",
+			"details": err.Error(),
+		})
+		return
 	}
 
-	if search != "" {
-		baseQuery += " AND (title LIKE ? OR url LIKE ?)"
-		countQuery += " AND (title LIKE ? OR url LIKE ?)"
-		searchPattern := "%" + search + "%"
-		args = append(args, searchPattern, searchPattern)
-		countArgs = append(countArgs, searchPattern, searchPattern)
+	// Lightweight polling asks for a few fields such as id,status,progress
+	fields, err := parseFields(c.Query("fields"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid fields",
+			"details": err.Error(),
+		})
+		return
 	}
 
-	baseQuery += " ORDER BY created_at DESC LIMIT ? OFFSET ?"
-	args = append(args, limit, offset)
+	where, whereArgs := urlListWhere(userID, filters)
+
+	// The page's IDs are found in the (user_id, status, created_at) or (user_id, created_at) index
+	// alone, so only the rows shown are read in full, however deep the page is
+	listQuery := "SELECT " + urlColumns + " FROM urls JOIN (SELECT id FROM urls WHERE " + where +
+		" ORDER BY " + order + " LIMIT ? OFFSET ?) page USING (id) ORDER BY " + order
+	args := append(append([]interface{}{}, whereArgs...), limit, offset)
 
-	// Get total count
+	// Get total count. Without filters besides status it comes from the kept URL counts, since
+	// counting a large account's rows on every request is slow; ?exact=true counts them.
 	var total int
-	err := config.DB.QueryRow(countQuery, countArgs...).Scan(&total)
+	var approximate bool
+	if c.Query("exact") != "true" && countable(filters) {
+		total, approximate, err = urlstatus.Total(c.Request.Context(), userID, filters.Status)
+	} else {
+		err = config.ReadQueryRow(c.Request.Context(), userID, "SELECT COUNT(*) FROM urls WHERE "+where, whereArgs...).Scan(&total)
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Database error",
@@ -279,7 +728,7 @@ func GetUrls(c *gin.Context) {
 	}
 
 	// Get URLs
-	rows, err := config.DB.Query(baseQuery, args...)
+	rows, err := config.ReadQuery(c.Request.Context(), userID, listQuery, args...)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error":   "Database query failed",
@@ -292,14 +741,7 @@ func GetUrls(c *gin.Context) {
 	var urls []models.Url
 	for rows.Next() {
 		var u models.Url
-		err := rows.Scan(
-			&u.ID, &u.UserID, &u.Url, &u.HtmlVersion, &u.Title,
-			&u.H1Count, &u.H2Count, &u.H3Count,
-			&u.InternalLinks, &u.ExternalLinks, &u.BrokenLinks,
-			&u.HasLoginForm, &u.Status, &u.ErrorMessage,
-			&u.CreatedAt, &u.UpdatedAt,
-		)
-		if err != nil {
+		if err := scanUrl(rows, &u); err != nil {
 			continue // skip bad rows
 		}
 		urls = append(urls, u)
@@ -313,17 +755,38 @@ func GetUrls(c *gin.Context) {
 		return
 	}
 
+	if len(fields) == 0 || slices.Contains(fields, "eta_seconds") {
+		applyEta(c.Request.Context(), urls)
+	}
+
+	var data interface{} = urls
+	if len(fields) > 0 {
+		if data, err = selectFields(urls, fields); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error":   "Error reading results",
+				"details": err.Error(),
+			})
+			return
+		}
+	}
+
 	c.JSON(http.StatusOK, gin.H{
-		"data": urls,
+		"data": data,
 		"pagination": gin.H{
-			"page":  page,
-			"limit": limit,
-			"total": total,
-			"pages": (total + limit - 1) / limit,
+			"page":        page,
+			"limit":       limit,
+			"total":       total,
+			"pages":       (total + limit - 1) / limit,
+			"approximate": approximate,
 		},
 	})
 }
 
+// countable reports whether the URL counts can give the total of a list with these filters
+func countable(filters models.UrlFilters) bool {
+	return filters.Search == "" && filters.ConsentBanner == nil && filters.Tag == "" && filters.Project == ""
+}
+
 // GetUrlByID retrieves a specific URL by ID with broken links details
 func GetUrlByID(c *gin.Context) {
 	userID, exists := c.Get("user_id")
@@ -337,18 +800,9 @@ func GetUrlByID(c *gin.Context) {
 	id := c.Param("id")
 
 	var url models.Url
-	err := config.DB.QueryRow(`
-		SELECT id, user_id, url, html_version, title, h1_count, h2_count, h3_count,
-		       internal_links, external_links, broken_links, has_login_form, 
-		       status, error_message, created_at, updated_at
-		FROM urls WHERE id = ? AND user_id = ?
-	`, id, userID).Scan(
-		&url.ID, &url.UserID, &url.Url, &url.HtmlVersion, &url.Title,
-		&url.H1Count, &url.H2Count, &url.H3Count,
-		&url.InternalLinks, &url.ExternalLinks, &url.BrokenLinks,
-		&url.HasLoginForm, &url.Status, &url.ErrorMessage,
-		&url.CreatedAt, &url.UpdatedAt,
-	)
+	err := scanUrl(config.ReadQueryRow(c.Request.Context(), userID,
+		"SELECT "+urlColumns+" FROM urls WHERE id = ? AND user_id = ?", id, userID,
+	), &url)
 
 	if err == sql.ErrNoRows {
 		c.JSON(http.StatusNotFound, gin.H{
@@ -363,9 +817,14 @@ func GetUrlByID(c *gin.Context) {
 		return
 	}
 
+	single := []models.Url{url}
+	applyEta(c.Request.Context(), single)
+	url = single[0]
+	c.Header("ETag", strconv.Quote(strconv.Itoa(url.Version)))
+
 	// Get broken links details
-	brokenLinksRows, err := config.DB.Query(`
-		SELECT id, url_id, link_url, status_code, error_message, created_at
+	brokenLinksRows, err := config.ReadQuery(c.Request.Context(), userID, `
+		SELECT id, url_id, link_url, status_code, error_message, suggestions, created_at
 		FROM broken_links WHERE url_id = ?
 		ORDER BY created_at DESC
 	`, url.ID)
@@ -375,10 +834,17 @@ func GetUrlByID(c *gin.Context) {
 		defer brokenLinksRows.Close()
 		for brokenLinksRows.Next() {
 			var bl models.BrokenLink
+			var suggestions []byte
 			err := brokenLinksRows.Scan(
-				&bl.ID, &bl.UrlID, &bl.LinkUrl, &bl.StatusCode, &bl.ErrorMessage, &bl.CreatedAt,
+				&bl.ID, &bl.UrlID, &bl.LinkUrl, &bl.StatusCode, &bl.ErrorMessage, &suggestions, &bl.CreatedAt,
 			)
 			if err == nil {
+				if suggestions != nil {
+					json.Unmarshal(suggestions, &bl.Suggestions)
+				}
+				bl.LinkUrl = sanitize.Line(bl.LinkUrl, sanitize.MaxURL)
+				bl.ErrorMessage = sanitize.LinePtr(bl.ErrorMessage, sanitize.MaxMessage)
+				bl.RateLimited = bl.StatusCode != nil && *bl.StatusCode == http.StatusTooManyRequests
 				brokenLinks = append(brokenLinks, bl)
 			}
 		}
@@ -387,13 +853,43 @@ func GetUrlByID(c *gin.Context) {
 	result := models.UrlWithBrokenLinks{
 		Url:                url,
 		BrokenLinksDetails: brokenLinks,
+		CheckResults:       loadCheckResults(c.Request.Context(), url.ID),
+		KeywordResults:     loadKeywordResults(c.Request.Context(), url.ID),
 	}
+	loadAudits(c.Request.Context(), userID, url.ID, &result)
 
 	c.JSON(http.StatusOK, gin.H{
 		"data": result,
 	})
 }
 
+// loadAudits fills in the JSON audit columns of a URL's latest crawl; they stay empty before the first crawl
+func loadAudits(ctx context.Context, userID interface{}, urlID int, result *models.UrlWithBrokenLinks) {
+	var forms, hreflang, linkHygiene, contactLinks, socialProfiles, fragments, safety, privacy, consent, performance, dns, hosting, registration, webVitals, brokenAssets, documents []byte
+	err := config.ReadQueryRow(ctx, userID,
+		"SELECT forms, hreflang, link_hygiene, contact_links, social_profiles, fragments, safety, privacy, consent, performance, dns, hosting, registration, web_vitals, broken_assets, documents FROM urls WHERE id = ?", urlID,
+	).Scan(&forms, &hreflang, &linkHygiene, &contactLinks, &socialProfiles, &fragments, &safety, &privacy, &consent, &performance, &dns, &hosting, &registration, &webVitals, &brokenAssets, &documents)
+	if err != nil {
+		return
+	}
+	result.Forms = forms
+	result.Hreflang = hreflang
+	result.LinkHygiene = linkHygiene
+	result.ContactLinks = contactLinks
+	result.SocialProfiles = socialProfiles
+	result.Fragments = fragments
+	result.Safety = safety
+	result.Privacy = privacy
+	result.Consent = consent
+	result.Performance = performance
+	result.DNS = dns
+	result.Hosting = hosting
+	result.Registration = registration
+	result.WebVitals = webVitals
+	result.BrokenAssets = brokenAssets
+	result.Documents = documents
+}
+
 // DeleteUrl deletes a URL by ID (only if owned by user)
 func DeleteUrl(c *gin.Context) {
 	userID, exists := c.Get("user_id")
@@ -406,8 +902,37 @@ func DeleteUrl(c *gin.Context) {
 
 	id := c.Param("id")
 
-	result, err := config.DB.Exec("DELETE FROM urls WHERE id = ? AND user_id = ?", id, userID)
+	version, err := expectedVersion(c)
 	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid version",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	query := "DELETE FROM urls WHERE id = ? AND user_id = ?"
+	args := []interface{}{id, userID}
+	if version != 0 {
+		query += " AND version = ?"
+		args = append(args, version)
+	}
+	var rowsAffected int64
+	err = config.WithTransaction(c.Request.Context(), func(tx *sql.Tx) error {
+		if err := urlstatus.UncountTx(tx, userID, []interface{}{id}); err != nil {
+			return err
+		}
+		result, err := tx.Exec(query, args...)
+		if err != nil {
+			return err
+		}
+		if rowsAffected, _ = result.RowsAffected(); rowsAffected == 0 {
+			// Nothing was deleted, so the counts stay as they were
+			return sql.ErrNoRows
+		}
+		return nil
+	})
+	if err != nil && err != sql.ErrNoRows {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error":   "Failed to delete URL",
 			"details": err.Error(),
@@ -415,19 +940,56 @@ func DeleteUrl(c *gin.Context) {
 		return
 	}
 
-	rowsAffected, _ := result.RowsAffected()
 	if rowsAffected == 0 {
+		// With a version the row may still exist, changed since the client read it
+		var current int
+		err := requestDB(c).QueryRow("SELECT version FROM urls WHERE id = ? AND user_id = ?", id, userID).Scan(&current)
+		if version != 0 && err == nil {
+			respondVersionConflict(c, current)
+			return
+		}
 		c.JSON(http.StatusNotFound, gin.H{
 			"error": "URL not found",
 		})
 		return
 	}
 
+	if urlID, err := strconv.Atoi(id); err == nil {
+		go archive.ForgetUrls(c.Request.Context(), userID, []int{urlID})
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"message": "URL deleted successfully",
 	})
 }
 
+// expectedVersion returns the URL version the client last read, from an If-Match header or
+// ?version=. 0 means the request is not conditional.
+func expectedVersion(c *gin.Context) (int, error) {
+	value := strings.TrimSpace(c.GetHeader("If-Match"))
+	if value == "" {
+		value = c.Query("version")
+	}
+	if value == "" || value == "*" {
+		return 0, nil
+	}
+	value = strings.Trim(strings.TrimPrefix(value, "W/"), `"`)
+	version, err := strconv.Atoi(value)
+	if err != nil || version < 1 {
+		return 0, fmt.Errorf("version must be a positive number, got %q", value)
+	}
+	return version, nil
+}
+
+// respondVersionConflict reports that the URL changed since the client read it
+func respondVersionConflict(c *gin.Context, current int) {
+	c.Header("ETag", strconv.Quote(strconv.Itoa(current)))
+	c.JSON(http.StatusPreconditionFailed, gin.H{
+		"error":   "URL was changed by someone else; reload it and try again",
+		"version": current,
+	})
+}
+
 // ReanalyzeUrl reanalyzes a URL by ID (only if owned by user)
 func ReanalyzeUrl(c *gin.Context) {
 	userID, exists := c.Get("user_id")
@@ -440,9 +1002,24 @@ func ReanalyzeUrl(c *gin.Context) {
 
 	id := c.Param("id")
 
+	opts, err := enqueueOptions(c, "")
+	if err != nil {
+		respondInvalidOptions(c, err)
+		return
+	}
+
+	version, err := expectedVersion(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid version",
+			"details": err.Error(),
+		})
+		return
+	}
+
 	// Get the URL first and verify ownership
 	var url string
-	err := config.DB.QueryRow("SELECT url FROM urls WHERE id = ? AND user_id = ?", id, userID).Scan(&url)
+	err = requestDB(c).QueryRow("SELECT url FROM urls WHERE id = ? AND user_id = ?", id, userID).Scan(&url)
 	if err == sql.ErrNoRows {
 		c.JSON(http.StatusNotFound, gin.H{
 			"error": "URL not found",
@@ -456,12 +1033,27 @@ func ReanalyzeUrl(c *gin.Context) {
 		return
 	}
 
-	// Reset status to queued
-	_, err = config.DB.Exec(
-		"UPDATE urls SET status = 'queued', error_message = NULL, updated_at = ? WHERE id = ?",
-		time.Now(), id,
-	)
-	if err != nil {
+	// Reset status to queued; a running crawl is only re-queued with ?force=true
+	urlID, _ := strconv.Atoi(id)
+	_, err = urlstatus.Apply(c.Request.Context(), urlstatus.Request{
+		UrlID:   urlID,
+		To:      urlstatus.Queued,
+		Force:   c.Query("force") == "true",
+		Version: version,
+		Set:     "error_message = NULL",
+	})
+	var invalid *urlstatus.InvalidTransitionError
+	var conflict *urlstatus.VersionConflictError
+	if errors.As(err, &conflict) {
+		respondVersionConflict(c, conflict.Current)
+		return
+	} else if errors.As(err, &invalid) {
+		c.JSON(http.StatusConflict, gin.H{
+			"error":   "URL cannot be reanalyzed now",
+			"details": err.Error(),
+		})
+		return
+	} else if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Failed to queue URL for reanalysis",
 		})
@@ -469,24 +1061,15 @@ func ReanalyzeUrl(c *gin.Context) {
 	}
 
 	// Clear existing broken links
-	config.DB.Exec("DELETE FROM broken_links WHERE url_id = ?", id)
-
-	// Start crawling in background
-	go func() {
-		defer func() {
-			if r := recover(); r != nil {
-				fmt.Printf("PANIC in reanalyze crawlAndUpdateURL: %v\n", r)
-				// Update status to error on panic
-				config.DB.Exec(
-					"UPDATE urls SET status = 'error', error_message = ?, updated_at = ? WHERE id = ?",
-					fmt.Sprintf("Panic during reanalysis: %v", r), time.Now(), id,
-				)
-			}
-		}()
-		urlID, _ := strconv.Atoi(id)
-		fmt.Printf("DEBUG: Starting reanalyze for URL ID %d: %s\n", urlID, url)
-		crawlAndUpdateURL(urlID, url)
-	}()
+	requestDB(c).Exec("DELETE FROM broken_links WHERE url_id = ?", id)
+
+	// Queue the crawl for the worker pool
+	if err := worker.Enqueue(c.Request.Context(), urlID, opts); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to queue URL for reanalysis",
+		})
+		return
+	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"message": "URL queued for reanalysis",
@@ -494,7 +1077,79 @@ func ReanalyzeUrl(c *gin.Context) {
 	})
 }
 
-// BulkDelete deletes multiple URLs by IDs
+// RefreshStaleUrls re-queues every completed URL whose analysis is older than the staleness threshold
+func RefreshStaleUrls(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Authentication required",
+		})
+		return
+	}
+
+	opts, err := enqueueOptions(c, "")
+	if err != nil {
+		respondInvalidOptions(c, err)
+		return
+	}
+
+	cutoff := time.Now().Add(-staleAfter())
+	var queued []int
+	var changes []urlstatus.Change
+
+	err = config.WithTransaction(c.Request.Context(), func(tx *sql.Tx) error {
+		queued, changes = nil, nil
+
+		rows, err := tx.Query(
+			"SELECT id FROM urls WHERE user_id = ? AND status = 'completed' AND crawled_at < ? FOR UPDATE",
+			userID, cutoff,
+		)
+		if err != nil {
+			return err
+		}
+		for rows.Next() {
+			var id int
+			if err := rows.Scan(&id); err == nil {
+				queued = append(queued, id)
+			}
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+
+		for _, id := range queued {
+			// Previous results stay visible until the new crawl replaces them
+			change, err := urlstatus.Transition(tx, urlstatus.Request{UrlID: id, To: urlstatus.Queued})
+			if err != nil {
+				return err
+			}
+			changes = append(changes, change)
+			if err := worker.EnqueueTx(tx, id, opts); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to queue stale URLs",
+			"details": err.Error(),
+		})
+		return
+	}
+	urlstatus.Publish(c.Request.Context(), changes...)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":      "Stale URLs queued for reanalysis",
+		"queued_count": len(queued),
+		"ids":          queued,
+		"stale_after":  staleAfter().String(),
+	})
+}
+
+// BulkDelete deletes multiple URLs by IDs. URLs listed in versions are only deleted while they have
+// the version the client read; the others are reported as conflicts.
 func BulkDelete(c *gin.Context) {
 	userID, exists := c.Get("user_id")
 	if !exists {
@@ -506,6 +1161,8 @@ func BulkDelete(c *gin.Context) {
 
 	var req struct {
 		IDs []int `json:"ids" binding:"required"`
+		// Versions holds the version the client last read of each URL, keyed by ID (optional)
+		Versions map[int]int `json:"versions"`
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -522,35 +1179,86 @@ func BulkDelete(c *gin.Context) {
 		return
 	}
 
-	// Build query with placeholders
-	query := "DELETE FROM urls WHERE user_id = ? AND id IN ("
-	args := []interface{}{userID}
+	if err := checkVersions(req.Versions); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid version",
+			"details": err.Error(),
+		})
+		return
+	}
 
-	for i, id := range req.IDs {
-		if i > 0 {
-			query += ","
-		}
-		query += "?"
+	args := []interface{}{userID}
+	for _, id := range req.IDs {
 		args = append(args, id)
 	}
-	query += ")"
 
-	result, err := config.DB.Exec(query, args...)
+	var deleted []int
+	var conflicts []gin.H
+	err := config.WithTransaction(c.Request.Context(), func(tx *sql.Tx) error {
+		deleted, conflicts = nil, []gin.H{}
+
+		// Lock the URLs so none changes between the version check and the delete
+		rows, err := tx.Query("SELECT id, version FROM urls WHERE user_id = ? AND id IN ("+placeholders(len(req.IDs))+") FOR UPDATE", args...)
+		if err != nil {
+			return err
+		}
+		var ids []interface{}
+		for rows.Next() {
+			var id, version int
+			if err := rows.Scan(&id, &version); err != nil {
+				rows.Close()
+				return err
+			}
+			if expected := req.Versions[id]; expected != 0 && expected != version {
+				conflicts = append(conflicts, gin.H{"id": id, "version": version})
+				continue
+			}
+			deleted = append(deleted, id)
+			ids = append(ids, id)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+		if len(ids) == 0 {
+			return nil
+		}
+
+		if err := urlstatus.UncountTx(tx, userID, ids); err != nil {
+			return err
+		}
+		_, err = tx.Exec("DELETE FROM urls WHERE user_id = ? AND id IN ("+placeholders(len(ids))+")", append([]interface{}{userID}, ids...)...)
+		return err
+	})
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Failed to delete URLs",
 		})
 		return
 	}
+	if len(deleted) > 0 {
+		go archive.ForgetUrls(c.Request.Context(), userID, deleted)
+	}
 
-	rowsAffected, _ := result.RowsAffected()
 	c.JSON(http.StatusOK, gin.H{
 		"message":       "URLs deleted successfully",
-		"deleted_count": rowsAffected,
+		"deleted_count": len(deleted),
+		"conflicts":     conflicts,
 	})
 }
 
-// BulkReanalyze reanalyzes multiple URLs by IDs
+// checkVersions validates the per-URL versions of a bulk request
+func checkVersions(versions map[int]int) error {
+	for id, version := range versions {
+		if version < 1 {
+			return fmt.Errorf("version of URL %d must be a positive number, got %d", id, version)
+		}
+	}
+	return nil
+}
+
+// BulkReanalyze reanalyzes multiple URLs by IDs. URLs listed in versions are only queued while they
+// have the version the client read; the others are reported as conflicts.
 func BulkReanalyze(c *gin.Context) {
 	userID, exists := c.Get("user_id")
 	if !exists {
@@ -561,7 +1269,12 @@ func BulkReanalyze(c *gin.Context) {
 	}
 
 	var req struct {
-		IDs []int `json:"ids" binding:"required"`
+		IDs      []int  `json:"ids" binding:"required"`
+		Priority string `json:"priority"`
+		// Force re-queues URLs whose crawl is running, which are skipped otherwise
+		Force bool `json:"force"`
+		// Versions holds the version the client last read of each URL, keyed by ID (optional)
+		Versions map[int]int `json:"versions"`
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -578,6 +1291,20 @@ func BulkReanalyze(c *gin.Context) {
 		return
 	}
 
+	opts, err := enqueueOptions(c, req.Priority)
+	if err != nil {
+		respondInvalidOptions(c, err)
+		return
+	}
+
+	if err := checkVersions(req.Versions); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid version",
+			"details": err.Error(),
+		})
+		return
+	}
+
 	// Get URLs and verify ownership
 	query := "SELECT id, url FROM urls WHERE user_id = ? AND id IN ("
 	args := []interface{}{userID}
@@ -591,7 +1318,7 @@ func BulkReanalyze(c *gin.Context) {
 	}
 	query += ")"
 
-	rows, err := config.DB.Query(query, args...)
+	rows, err := requestDB(c).Query(query, args...)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Database error",
@@ -616,33 +1343,40 @@ func BulkReanalyze(c *gin.Context) {
 	}
 
 	// Reset status to queued for all URLs
+	queued := 0
+	skipped := []gin.H{}
+	conflicts := []gin.H{}
 	for _, item := range urlsToReanalyze {
-		config.DB.Exec(
-			"UPDATE urls SET status = 'queued', error_message = NULL, updated_at = ? WHERE id = ?",
-			time.Now(), item.ID,
-		)
-		config.DB.Exec("DELETE FROM broken_links WHERE url_id = ?", item.ID)
-
-		// Start crawling in background
-		go func(id int, url string) {
-			defer func() {
-				if r := recover(); r != nil {
-					fmt.Printf("PANIC in bulk reanalyze crawlAndUpdateURL: %v\n", r)
-					// Update status to error on panic
-					config.DB.Exec(
-						"UPDATE urls SET status = 'error', error_message = ?, updated_at = ? WHERE id = ?",
-						fmt.Sprintf("Panic during bulk reanalysis: %v", r), time.Now(), id,
-					)
-				}
-			}()
-			fmt.Printf("DEBUG: Starting bulk reanalyze for URL ID %d: %s\n", id, url)
-			crawlAndUpdateURL(id, url)
-		}(item.ID, item.URL)
+		_, err := urlstatus.Apply(c.Request.Context(), urlstatus.Request{
+			UrlID:   item.ID,
+			To:      urlstatus.Queued,
+			Force:   req.Force || c.Query("force") == "true",
+			Version: req.Versions[item.ID],
+			Set:     "error_message = NULL",
+		})
+		var conflict *urlstatus.VersionConflictError
+		if errors.As(err, &conflict) {
+			conflicts = append(conflicts, gin.H{"id": item.ID, "version": conflict.Current})
+			continue
+		}
+		if err != nil {
+			skipped = append(skipped, gin.H{"id": item.ID, "error": err.Error()})
+			continue
+		}
+		queued++
+		requestDB(c).Exec("DELETE FROM broken_links WHERE url_id = ?", item.ID)
+
+		// Queue the crawl for the worker pool
+		if err := worker.Enqueue(c.Request.Context(), item.ID, opts); err != nil {
+			fmt.Printf("DEBUG: Failed to queue bulk reanalyze for URL ID %d: %v\n", item.ID, err)
+		}
 	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"message":      "URLs queued for reanalysis",
-		"queued_count": len(urlsToReanalyze),
+		"queued_count": queued,
+		"skipped":      skipped,
+		"conflicts":    conflicts,
 	})
 }
 
@@ -659,7 +1393,7 @@ func GetStats(c *gin.Context) {
 	var stats models.UrlStats
 
 	// Get URL counts by status
-	rows, err := config.DB.Query(`
+	rows, err := config.ReadQuery(c.Request.Context(), userID, `
 		SELECT status, COUNT(*) 
 		FROM urls 
 		WHERE user_id = ? 
@@ -688,13 +1422,58 @@ func GetStats(c *gin.Context) {
 	}
 
 	// Get total broken links
-	config.DB.QueryRow(`
+	config.ReadQueryRow(c.Request.Context(), userID, `
 		SELECT COALESCE(SUM(broken_links), 0)
 		FROM urls 
 		WHERE user_id = ? AND status = 'completed'
 	`, userID).Scan(&stats.TotalBrokenLinks)
 
+	// Core Web Vitals of the origins, stored only when CrUX lookups are enabled
+	config.ReadQueryRow(c.Request.Context(), userID, `
+		SELECT
+			COALESCE(SUM(JSON_UNQUOTE(JSON_EXTRACT(web_vitals, '$.assessment')) = 'passed'), 0),
+			COALESCE(SUM(JSON_UNQUOTE(JSON_EXTRACT(web_vitals, '$.assessment')) = 'failed'), 0),
+			COALESCE(SUM(JSON_UNQUOTE(JSON_EXTRACT(web_vitals, '$.assessment')) = 'no_data'), 0),
+			AVG(JSON_EXTRACT(web_vitals, '$.lcp.p75')),
+			AVG(JSON_EXTRACT(web_vitals, '$.cls.p75')),
+			AVG(JSON_EXTRACT(web_vitals, '$.inp.p75'))
+		FROM urls
+		WHERE user_id = ? AND status = 'completed' AND web_vitals IS NOT NULL
+	`, userID).Scan(
+		&stats.WebVitals.Passed, &stats.WebVitals.Failed, &stats.WebVitals.NoData,
+		&stats.WebVitals.AvgLCP, &stats.WebVitals.AvgCLS, &stats.WebVitals.AvgINP,
+	)
+
+	stats.ExpiringDomains = loadExpiringDomains(c.Request.Context(), userID, time.Now())
+
 	c.JSON(http.StatusOK, gin.H{
 		"data": stats,
 	})
 }
+
+// loadExpiringDomains lists the user's domains whose registration expires within analyzer.ExpiryWarning,
+// soonest first. Expiry dates are only known when RDAP lookups are enabled.
+func loadExpiringDomains(ctx context.Context, userID interface{}, now time.Time) []models.ExpiringDomain {
+	domains := []models.ExpiringDomain{}
+	rows, err := config.DBFor(ctx).Query(`
+		SELECT JSON_UNQUOTE(JSON_EXTRACT(registration, '$.domain')) AS domain, MIN(domain_expires_at), COUNT(*)
+		FROM urls
+		WHERE user_id = ? AND domain_expires_at IS NOT NULL AND domain_expires_at < ?
+		GROUP BY domain
+		ORDER BY MIN(domain_expires_at)
+	`, userID, now.Add(analyzer.ExpiryWarning))
+	if err != nil {
+		return domains
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var d models.ExpiringDomain
+		if err := rows.Scan(&d.Domain, &d.ExpiresAt, &d.Urls); err != nil {
+			continue // skip bad rows
+		}
+		d.DaysLeft = int(math.Floor(d.ExpiresAt.Sub(now).Hours() / 24))
+		domains = append(domains, d)
+	}
+	return domains
+}
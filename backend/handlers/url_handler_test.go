@@ -7,6 +7,11 @@ import (
 	"net/http/httptest"
 	"strconv"
 	"testing"
+	"time"
+
+	"sykell-analyze/backend/analyzer"
+	"sykell-analyze/backend/config"
+	"sykell-analyze/backend/models"
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
@@ -36,6 +41,27 @@ func TestAddUrl(t *testing.T) {
 		assert.NotEqual(t, http.StatusInternalServerError, w.Code)
 	})
 
+	t.Run("invalid priority", func(t *testing.T) {
+		requestBody := map[string]string{
+			"url":      "https://example.com",
+			"priority": "urgent",
+		}
+
+		jsonData, _ := json.Marshal(requestBody)
+		req, _ := http.NewRequest(http.MethodPost, "/urls", bytes.NewBuffer(jsonData))
+		req.Header.Set("Content-Type", "application/json")
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = req
+		c.Set("user_id", 1)
+
+		AddUrl(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "priority")
+	})
+
 	t.Run("missing authentication", func(t *testing.T) {
 		requestBody := map[string]string{
 			"url": "https://example.com",
@@ -161,6 +187,19 @@ func TestGetUrls(t *testing.T) {
 		// Note: This would need proper database mocking for full test
 		assert.NotEqual(t, http.StatusInternalServerError, w.Code)
 	})
+
+	t.Run("invalid consent banner filter", func(t *testing.T) {
+		req, _ := http.NewRequest(http.MethodGet, "/urls?consent_banner=maybe", nil)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = req
+		c.Set("user_id", 1)
+
+		GetUrls(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
 }
 
 func TestGetUrlByID(t *testing.T) {
@@ -382,6 +421,21 @@ func TestBulkDelete(t *testing.T) {
 
 		assert.Equal(t, http.StatusBadRequest, w.Code)
 	})
+
+	t.Run("invalid version", func(t *testing.T) {
+		req, _ := http.NewRequest(http.MethodDelete, "/urls/bulk", bytes.NewBufferString(`{"ids": [1, 2], "versions": {"2": 0}}`))
+		req.Header.Set("Content-Type", "application/json")
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = req
+		c.Set("user_id", 1)
+
+		BulkDelete(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "Invalid version")
+	})
 }
 
 func TestGetStats(t *testing.T) {
@@ -471,3 +525,257 @@ func TestPaginationValidation(t *testing.T) {
 		})
 	}
 }
+
+func TestValidateURL(t *testing.T) {
+	testCases := []struct {
+		name        string
+		input       string
+		expected    string
+		expectError bool
+	}{
+		{name: "adds https scheme", input: "example.com", expected: "https://example.com"},
+		{name: "keeps http scheme", input: "http://example.com/page", expected: "http://example.com/page"},
+		{name: "trims whitespace", input: "  example.com/a  ", expected: "https://example.com/a"},
+		{name: "empty", input: "   ", expectError: true},
+		{name: "no host", input: "https://", expectError: true},
+		{name: "unparseable", input: "http://exa mple.com:port", expectError: true},
+		{name: "IPv6 literal with a port", input: "http://[2001:4860:4860::8888]:8080/a", expected: "http://[2001:4860:4860::8888]:8080/a"},
+		{name: "bare IPv6 literal", input: "2001:4860:4860::8888", expected: "https://[2001:4860:4860::8888]"},
+		{name: "public IPv4 literal", input: "8.8.8.8:8443", expected: "https://8.8.8.8:8443"},
+		{name: "IPv6 literal without brackets", input: "https://2001:db8::1/", expectError: true},
+		{name: "brackets around a host name", input: "https://[example.com]/", expectError: true},
+		{name: "brackets around IPv4", input: "https://[8.8.8.8]/", expectError: true},
+		{name: "port out of range", input: "https://example.com:70000", expectError: true},
+		{name: "port zero", input: "https://example.com:0", expectError: true},
+		{name: "IPv6 loopback", input: "http://[::1]:8080", expectError: true},
+		{name: "IPv6 unique local", input: "http://[fd12:3456::1]/", expectError: true},
+		{name: "IPv6 link-local with zone", input: "http://[fe80::1%25eth0]:8080/", expectError: true},
+		{name: "IPv4-mapped private", input: "http://[::ffff:10.0.0.1]/", expectError: true},
+		{name: "private IPv4", input: "192.168.1.10", expectError: true},
+		{name: "localhost", input: "localhost:3000", expectError: true},
+	}
+
+	t.Run("denied by the domain policy", func(t *testing.T) {
+		previous := config.App
+		cfg := *config.App
+		cfg.Crawler.DenyDomains = []string{"internal.example.com"}
+		config.App = &cfg
+		defer func() { config.App = previous }()
+
+		_, err := validateURL("wiki.internal.example.com")
+		assert.ErrorContains(t, err, "not an allowed crawl target")
+	})
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			normalized, err := validateURL(tc.input)
+			if tc.expectError {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, normalized)
+		})
+	}
+}
+
+func TestAddUrlsBulk(t *testing.T) {
+	post := func(body string, userID interface{}) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(http.MethodPost, "/urls/bulk", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = req
+		if userID != nil {
+			c.Set("user_id", userID)
+		}
+
+		AddUrlsBulk(c)
+		return w
+	}
+
+	t.Run("missing authentication", func(t *testing.T) {
+		w := post(`{"urls": ["https://example.com"]}`, nil)
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
+	t.Run("empty list", func(t *testing.T) {
+		w := post(`{"urls": []}`, 1)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("too many URLs", func(t *testing.T) {
+		previous := config.App
+		cfg := *config.App
+		cfg.Server.BulkUrlMax = 2
+		config.App = &cfg
+		defer func() { config.App = previous }()
+
+		w := post(`{"urls": ["a.com", "b.com", "c.com"]}`, 1)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "at most 2")
+	})
+}
+
+func TestIsStale(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	recent := now.Add(-time.Hour)
+	old := now.Add(-10 * 24 * time.Hour)
+
+	testCases := []struct {
+		name     string
+		url      models.Url
+		expected bool
+	}{
+		{name: "recently crawled", url: models.Url{Status: "completed", LastCrawledAt: &recent}, expected: false},
+		{name: "crawled long ago", url: models.Url{Status: "completed", LastCrawledAt: &old}, expected: true},
+		{name: "never crawled", url: models.Url{Status: "completed"}, expected: false},
+		{name: "queued for recrawl", url: models.Url{Status: "queued", LastCrawledAt: &old}, expected: false},
+		{name: "failed", url: models.Url{Status: "error", LastCrawledAt: &old}, expected: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, isStale(&tc.url, now, 7*24*time.Hour))
+		})
+	}
+}
+
+func TestIsOutdated(t *testing.T) {
+	testCases := []struct {
+		name     string
+		url      models.Url
+		expected bool
+	}{
+		{name: "current analyzer", url: models.Url{Status: "completed", ResultSchemaVersion: analyzer.SchemaVersion}, expected: false},
+		{name: "stored before versions were kept", url: models.Url{Status: "completed"}, expected: true},
+		{name: "older analyzer", url: models.Url{Status: "completed", ResultSchemaVersion: analyzer.SchemaVersion - 1}, expected: true},
+		{name: "never crawled", url: models.Url{Status: "queued"}, expected: false},
+		{name: "failed", url: models.Url{Status: "error"}, expected: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, isOutdated(&tc.url))
+		})
+	}
+}
+
+func TestStaleAfter(t *testing.T) {
+	assert.Equal(t, 7*24*time.Hour, staleAfter())
+
+	previous := config.App
+	cfg := *config.App
+	cfg.Crawler.StaleAfter = 48 * time.Hour
+	config.App = &cfg
+	defer func() { config.App = previous }()
+
+	assert.Equal(t, 48*time.Hour, staleAfter())
+}
+
+func TestParseFields(t *testing.T) {
+	t.Run("empty selects everything", func(t *testing.T) {
+		fields, err := parseFields("")
+		assert.NoError(t, err)
+		assert.Empty(t, fields)
+	})
+
+	t.Run("trims and drops duplicates", func(t *testing.T) {
+		fields, err := parseFields("id, url,status,id,,broken_links")
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"id", "url", "status", "broken_links"}, fields)
+	})
+
+	t.Run("unknown field", func(t *testing.T) {
+		_, err := parseFields("id,password")
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "broken_links")
+	})
+}
+
+func TestSelectFields(t *testing.T) {
+	urls := []models.Url{{ID: 7, Url: "https://example.com", Status: "completed", BrokenLinks: 3, Title: "Example"}}
+
+	selected, err := selectFields(urls, []string{"id", "status", "broken_links", "eta_seconds"})
+	assert.NoError(t, err)
+
+	body, _ := json.Marshal(selected)
+	assert.JSONEq(t, `[{"id": 7, "status": "completed", "broken_links": 3}]`, string(body))
+}
+
+func TestExpectedVersion(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name     string
+		ifMatch  string
+		query    string
+		expected int
+		wantErr  bool
+	}{
+		{"unconditional", "", "", 0, false},
+		{"any version", "*", "", 0, false},
+		{"quoted etag", `"3"`, "", 3, false},
+		{"weak etag", `W/"7"`, "", 7, false},
+		{"query parameter", "", "?version=4", 4, false},
+		{"header wins over query", `"2"`, "?version=4", 2, false},
+		{"not a number", `"abc"`, "", 0, true},
+		{"zero", "", "?version=0", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = httptest.NewRequest(http.MethodDelete, "/urls/1"+tt.query, nil)
+			if tt.ifMatch != "" {
+				c.Request.Header.Set("If-Match", tt.ifMatch)
+			}
+
+			version, err := expectedVersion(c)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, version)
+		})
+	}
+}
+
+func TestCheckVersions(t *testing.T) {
+	assert.NoError(t, checkVersions(nil))
+	assert.NoError(t, checkVersions(map[int]int{1: 3, 2: 1}))
+	assert.Error(t, checkVersions(map[int]int{1: 3, 2: 0}))
+	assert.Error(t, checkVersions(map[int]int{1: -1}))
+}
+
+func TestUrlListWhere(t *testing.T) {
+	t.Run("user only", func(t *testing.T) {
+		where, args := urlListWhere(1, models.UrlFilters{})
+		assert.Equal(t, "user_id = ?", where)
+		assert.Equal(t, []interface{}{1}, args)
+	})
+
+	t.Run("status follows user for the list index", func(t *testing.T) {
+		hasBanner := true
+		where, args := urlListWhere(1, models.UrlFilters{
+			Status:        "error",
+			Search:        "shop",
+			ConsentBanner: &hasBanner,
+			Project:       "Client A",
+		})
+		assert.Equal(t, "user_id = ? AND status = ? AND has_consent_banner = ? AND project = ? AND (title LIKE ? OR url LIKE ?)", where)
+		assert.Equal(t, []interface{}{1, "error", true, "Client A", "%shop%", "%shop%"}, args)
+	})
+}
+
+func TestCountable(t *testing.T) {
+	hasBanner := false
+	assert.True(t, countable(models.UrlFilters{}))
+	assert.True(t, countable(models.UrlFilters{Status: "error", Sort: "-broken_links"}))
+	assert.False(t, countable(models.UrlFilters{Search: "shop"}))
+	assert.False(t, countable(models.UrlFilters{ConsentBanner: &hasBanner}))
+	assert.False(t, countable(models.UrlFilters{Tag: "client"}))
+	assert.False(t, countable(models.UrlFilters{Project: "Client A"}))
+}
@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"sykell-analyze/backend/config"
+	"sykell-analyze/backend/migrations"
+
+	"github.com/gin-gonic/gin"
+)
+
+// benchUrlRows is how many URLs the benchmark account has
+const benchUrlRows = 1000000
+
+// benchDigits yields 0-9; six of them cross joined count to a million
+const benchDigits = "(SELECT 0 d UNION ALL SELECT 1 UNION ALL SELECT 2 UNION ALL SELECT 3 UNION ALL SELECT 4 " +
+	"UNION ALL SELECT 5 UNION ALL SELECT 6 UNION ALL SELECT 7 UNION ALL SELECT 8 UNION ALL SELECT 9)"
+
+// setupUrlListBenchmark connects to the MySQL database in BENCH_DATABASE_DSN, which must have the
+// schema of sql/init.sql, and gives a benchmark account a million URLs the first time it runs:
+//
+//	BENCH_DATABASE_DSN='root:password@tcp(localhost:3306)/url_bench?parseTime=true' \
+//	  go test ./handlers -run '^$' -bench GetUrls -benchtime 200x
+func setupUrlListBenchmark(b *testing.B) int {
+	dsn := os.Getenv("BENCH_DATABASE_DSN")
+	if dsn == "" {
+		b.Skip("BENCH_DATABASE_DSN is not set")
+	}
+
+	db, err := sql.Open("mysql", dsn)
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.Cleanup(func() { db.Close() })
+	previous := config.DB
+	config.DB = db
+	b.Cleanup(func() { config.DB = previous })
+
+	if _, err := migrations.Run(db); err != nil {
+		b.Fatal(err)
+	}
+
+	_, err = db.Exec("INSERT IGNORE INTO users (username, email, password) VALUES ('bench_urls', 'bench_urls@example.com', '')")
+	if err != nil {
+		b.Fatal(err)
+	}
+	var userID, count int
+	if err := db.QueryRow("SELECT id FROM users WHERE username = 'bench_urls'").Scan(&userID); err != nil {
+		b.Fatal(err)
+	}
+	if err := db.QueryRow("SELECT COUNT(*) FROM urls WHERE user_id = ?", userID).Scan(&count); err != nil {
+		b.Fatal(err)
+	}
+	if count >= benchUrlRows {
+		return userID
+	}
+
+	b.Logf("seeding %d URLs, which takes a few minutes once", benchUrlRows)
+	if _, err := db.Exec("DELETE FROM urls WHERE user_id = ?", userID); err != nil {
+		b.Fatal(err)
+	}
+	_, err = db.Exec(`
+		INSERT INTO urls (user_id, url, title, status, broken_links, created_at)
+		SELECT ?, CONCAT('https://bench.example/page/', n), CONCAT('Page ', n),
+			ELT(1 + n % 5, 'queued', 'running', 'completed', 'error', 'cancelled'), n % 17,
+			TIMESTAMP('2024-01-01') + INTERVAL n SECOND
+		FROM (
+			SELECT a.d + 10*b.d + 100*c.d + 1000*d.d + 10000*e.d + 100000*f.d AS n
+			FROM `+benchDigits+` a, `+benchDigits+` b, `+benchDigits+` c,
+				`+benchDigits+` d, `+benchDigits+` e, `+benchDigits+` f
+		) seq
+	`, userID)
+	if err != nil {
+		b.Fatal(err)
+	}
+	if _, err := db.Exec("ANALYZE TABLE urls"); err != nil {
+		b.Fatal(err)
+	}
+	return userID
+}
+
+func BenchmarkGetUrls(b *testing.B) {
+	userID := setupUrlListBenchmark(b)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/urls", func(c *gin.Context) {
+		c.Set("user_id", userID)
+		GetUrls(c)
+	})
+
+	cases := []struct {
+		name  string
+		query string
+	}{
+		{"first page", "?limit=20"},
+		{"status filter", "?limit=20&status=error"},
+		{"deep page", "?limit=20&page=40000"},
+		{"deep page with status filter", "?limit=20&status=completed&page=8000"},
+		{"sorted by broken links", "?limit=20&sort=-broken_links"},
+		{"search", "?limit=20&search=page/99999"},
+		{"polling fields", "?limit=100&fields=id,status,progress"},
+	}
+
+	for _, tc := range cases {
+		b.Run(tc.name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				w := httptest.NewRecorder()
+				router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/urls"+tc.query, nil))
+				if w.Code != http.StatusOK {
+					b.Fatalf("GET /urls%s returned %d: %s", tc.query, w.Code, w.Body.String())
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,296 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"sykell-analyze/backend/config"
+	"sykell-analyze/backend/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// urlSortColumns are the columns the URL list can be sorted by
+var urlSortColumns = map[string]bool{
+	"created_at":     true,
+	"updated_at":     true,
+	"crawled_at":     true,
+	"url":            true,
+	"title":          true,
+	"status":         true,
+	"broken_links":   true,
+	"internal_links": true,
+	"external_links": true,
+}
+
+// urlOrder turns a sort such as -broken_links into an ORDER BY clause; the default is newest first
+func urlOrder(sortBy string) (string, error) {
+	if sortBy == "" {
+		sortBy = "-created_at"
+	}
+	column, direction := strings.TrimPrefix(sortBy, "-"), "ASC"
+	if strings.HasPrefix(sortBy, "-") {
+		direction = "DESC"
+	}
+	if !urlSortColumns[column] {
+		columns := make([]string, 0, len(urlSortColumns))
+		for name := range urlSortColumns {
+			columns = append(columns, name)
+		}
+		sort.Strings(columns)
+		return "", fmt.Errorf("cannot sort by %q; use one of %s, with - for descending order", column, strings.Join(columns, ", "))
+	}
+	// id keeps pages stable when the column has ties
+	return column + " " + direction + ", id " + direction, nil
+}
+
+// mergeFilters fills the filters not given in the query from a saved view
+func mergeFilters(query, saved models.UrlFilters) models.UrlFilters {
+	if query.Status == "" {
+		query.Status = saved.Status
+	}
+	if query.Search == "" {
+		query.Search = saved.Search
+	}
+	if query.ConsentBanner == nil {
+		query.ConsentBanner = saved.ConsentBanner
+	}
+	if query.Tag == "" {
+		query.Tag = saved.Tag
+	}
+	if query.Project == "" {
+		query.Project = saved.Project
+	}
+	if query.Sort == "" {
+		query.Sort = saved.Sort
+	}
+	return query
+}
+
+// loadView reads one of the user's views
+func loadView(ctx context.Context, userID interface{}, id int) (models.UrlView, error) {
+	var v models.UrlView
+	var filters []byte
+	err := config.DBFor(ctx).QueryRow(
+		"SELECT id, name, filters, created_at, updated_at FROM url_views WHERE id = ? AND user_id = ?", id, userID,
+	).Scan(&v.ID, &v.Name, &filters, &v.CreatedAt, &v.UpdatedAt)
+	if err != nil {
+		return v, err
+	}
+	return v, json.Unmarshal(filters, &v.Filters)
+}
+
+// bindView reads and validates a view from the request body, answering the request when it is invalid
+func bindView(c *gin.Context) (models.UrlViewRequest, bool) {
+	var req models.UrlViewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request format",
+			"details": err.Error(),
+		})
+		return req, false
+	}
+	if _, err := urlOrder(req.Filters.Sort); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid sort",
+			"details": err.Error(),
+		})
+		return req, false
+	}
+	return req, true
+}
+
+// GetViews lists the user's saved views of the URL list
+func GetViews(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Authentication required",
+		})
+		return
+	}
+
+	rows, err := requestDB(c).Query(
+		"SELECT id, name, filters, created_at, updated_at FROM url_views WHERE user_id = ? ORDER BY name", userID,
+	)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Database query failed",
+			"details": err.Error(),
+		})
+		return
+	}
+	defer rows.Close()
+
+	views := []models.UrlView{}
+	for rows.Next() {
+		var v models.UrlView
+		var filters []byte
+		if err := rows.Scan(&v.ID, &v.Name, &filters, &v.CreatedAt, &v.UpdatedAt); err != nil {
+			continue // skip bad rows
+		}
+		if err := json.Unmarshal(filters, &v.Filters); err != nil {
+			continue
+		}
+		views = append(views, v)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": views,
+	})
+}
+
+// AddView saves a named set of URL list filters, applied with GET /api/urls?view=:id
+func AddView(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Authentication required",
+		})
+		return
+	}
+
+	req, ok := bindView(c)
+	if !ok {
+		return
+	}
+
+	filters, _ := json.Marshal(req.Filters)
+	now := time.Now()
+	result, err := requestDB(c).Exec(
+		"INSERT INTO url_views (user_id, name, filters, created_at, updated_at) VALUES (?, ?, ?, ?, ?)",
+		userID, req.Name, string(filters), now, now,
+	)
+	if config.IsDuplicateKey(err) {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "A view with this name already exists",
+		})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to save view",
+			"details": err.Error(),
+		})
+		return
+	}
+	id, _ := result.LastInsertId()
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "View created",
+		"data": models.UrlView{
+			ID:        int(id),
+			Name:      req.Name,
+			Filters:   req.Filters,
+			CreatedAt: now,
+			UpdatedAt: now,
+		},
+	})
+}
+
+// UpdateView renames a view or replaces its filters
+func UpdateView(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Authentication required",
+		})
+		return
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid view ID",
+		})
+		return
+	}
+
+	req, ok := bindView(c)
+	if !ok {
+		return
+	}
+
+	filters, _ := json.Marshal(req.Filters)
+	_, err = requestDB(c).Exec(
+		"UPDATE url_views SET name = ?, filters = ?, updated_at = ? WHERE id = ? AND user_id = ?",
+		req.Name, string(filters), time.Now(), id, userID,
+	)
+	if config.IsDuplicateKey(err) {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "A view with this name already exists",
+		})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to save view",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	view, err := loadView(c.Request.Context(), userID, id)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "View not found",
+		})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Database error",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "View updated",
+		"data":    view,
+	})
+}
+
+// DeleteView removes one of the user's views
+func DeleteView(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Authentication required",
+		})
+		return
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid view ID",
+		})
+		return
+	}
+
+	result, err := requestDB(c).Exec("DELETE FROM url_views WHERE id = ? AND user_id = ?", id, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to delete view",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "View not found",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "View deleted successfully",
+	})
+}
@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"sykell-analyze/backend/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUrlOrder(t *testing.T) {
+	tests := []struct {
+		sort     string
+		expected string
+	}{
+		{"", "created_at DESC, id DESC"},
+		{"broken_links", "broken_links ASC, id ASC"},
+		{"-broken_links", "broken_links DESC, id DESC"},
+		{"title", "title ASC, id ASC"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.sort, func(t *testing.T) {
+			order, err := urlOrder(tt.sort)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, order)
+		})
+	}
+
+	t.Run("unknown column", func(t *testing.T) {
+		_, err := urlOrder("-password_hash")
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "broken_links")
+	})
+}
+
+func TestMergeFilters(t *testing.T) {
+	yes, no := true, false
+	saved := models.UrlFilters{Status: "completed", Search: "shop", ConsentBanner: &yes, Sort: "-broken_links"}
+
+	t.Run("saved filters fill the gaps", func(t *testing.T) {
+		assert.Equal(t, saved, mergeFilters(models.UrlFilters{}, saved))
+	})
+
+	t.Run("query takes precedence", func(t *testing.T) {
+		merged := mergeFilters(models.UrlFilters{Status: "error", ConsentBanner: &no}, saved)
+		assert.Equal(t, "error", merged.Status)
+		assert.Equal(t, "shop", merged.Search)
+		assert.False(t, *merged.ConsentBanner)
+		assert.Equal(t, "-broken_links", merged.Sort)
+	})
+}
+
+func TestAddView(t *testing.T) {
+	post := func(body string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(http.MethodPost, "/views", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = req
+		c.Set("user_id", 1)
+
+		AddView(c)
+		return w
+	}
+
+	t.Run("missing name", func(t *testing.T) {
+		w := post(`{"filters": {"status": "error"}}`)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("unknown status", func(t *testing.T) {
+		w := post(`{"name": "Stuck", "filters": {"status": "stuck"}}`)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("unknown sort", func(t *testing.T) {
+		w := post(`{"name": "Odd", "filters": {"sort": "-user_id"}}`)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "Invalid sort")
+	})
+}
@@ -0,0 +1,94 @@
+// Package httpserver runs the API server over plain HTTP or, when TLS is configured, over HTTPS
+// with certificate files or Let's Encrypt certificates, plus a listener redirecting HTTP to HTTPS.
+// It listens on TCP, a Unix socket or a socket passed by systemd.
+package httpserver
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"sykell-analyze/backend/config"
+
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// ListenAndServe serves server as cfg asks until it fails, on the listener Listen opens for
+// server.Addr and socketPath. server.Addr is the HTTPS address when TLS is enabled.
+func ListenAndServe(server *http.Server, socketPath string, cfg config.TLSConfig) error {
+	l, err := Listen(server.Addr, socketPath)
+	if err != nil {
+		return err
+	}
+	if !cfg.Enabled() {
+		return server.Serve(l)
+	}
+
+	redirect := RedirectHandler(port(server.Addr))
+	if len(cfg.AutocertDomains) > 0 {
+		manager := &autocert.Manager{
+			Prompt:     autocert.AcceptTOS,
+			Cache:      autocert.DirCache(cfg.AutocertCacheDir),
+			HostPolicy: autocert.HostWhitelist(cfg.AutocertDomains...),
+			Email:      cfg.AutocertEmail,
+		}
+		server.TLSConfig = manager.TLSConfig()
+		// HTTP-01 challenges arrive on the redirect listener; everything else is redirected
+		redirect = manager.HTTPHandler(redirect)
+	} else {
+		server.TLSConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+	}
+
+	if cfg.RedirectPort > 0 {
+		go serveRedirect(cfg.RedirectPort, redirect)
+	}
+	return server.ServeTLS(l, cfg.CertFile, cfg.KeyFile)
+}
+
+// serveRedirect runs the plain HTTP listener. Its failure is logged; HTTPS keeps running.
+func serveRedirect(port int, handler http.Handler) {
+	redirectServer := &http.Server{
+		Addr:              ":" + strconv.Itoa(port),
+		Handler:           handler,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	fmt.Printf("↪️  Redirecting http://:%d to HTTPS\n", port)
+	if err := redirectServer.ListenAndServe(); err != nil {
+		fmt.Printf("DEBUG: HTTP redirect listener stopped: %v\n", err)
+	}
+}
+
+// RedirectHandler permanently redirects every request to the same URL over HTTPS on httpsPort
+func RedirectHandler(httpsPort int) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host := r.Host
+		if h, _, err := net.SplitHostPort(r.Host); err == nil {
+			host = h
+		}
+		host = strings.Trim(host, "[]") // IPv6 literals are bracketed again below
+		if httpsPort != 443 {
+			host = net.JoinHostPort(host, strconv.Itoa(httpsPort))
+		} else if strings.Contains(host, ":") {
+			host = "[" + host + "]"
+		}
+		target := "https://" + host + r.URL.RequestURI()
+		http.Redirect(w, r, target, http.StatusPermanentRedirect)
+	})
+}
+
+// port returns the port of a listen address such as ":8443", or 443 when it has none
+func port(addr string) int {
+	_, p, err := net.SplitHostPort(addr)
+	if err != nil {
+		return 443
+	}
+	n, err := strconv.Atoi(p)
+	if err != nil {
+		return 443
+	}
+	return n
+}
@@ -0,0 +1,41 @@
+package httpserver
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedirectHandler(t *testing.T) {
+	redirect := func(httpsPort int, target, host string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, target, nil)
+		req.Host = host
+		RedirectHandler(httpsPort).ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("keeps path and query", func(t *testing.T) {
+		w := redirect(443, "/api/urls?page=2", "example.com")
+		assert.Equal(t, http.StatusPermanentRedirect, w.Code)
+		assert.Equal(t, "https://example.com/api/urls?page=2", w.Header().Get("Location"))
+	})
+
+	t.Run("replaces the port", func(t *testing.T) {
+		assert.Equal(t, "https://example.com/", redirect(443, "/", "example.com:80").Header().Get("Location"))
+		assert.Equal(t, "https://example.com:8443/", redirect(8443, "/", "example.com:8080").Header().Get("Location"))
+	})
+
+	t.Run("IPv6 hosts", func(t *testing.T) {
+		assert.Equal(t, "https://[::1]/", redirect(443, "/", "[::1]:80").Header().Get("Location"))
+		assert.Equal(t, "https://[::1]:8443/", redirect(8443, "/", "[::1]").Header().Get("Location"))
+	})
+}
+
+func TestPort(t *testing.T) {
+	assert.Equal(t, 8443, port(":8443"))
+	assert.Equal(t, 443, port("localhost:https"))
+	assert.Equal(t, 443, port(""))
+}
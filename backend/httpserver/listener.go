@@ -0,0 +1,77 @@
+package httpserver
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"os"
+	"strconv"
+)
+
+// systemdFirstFD is the first file descriptor systemd passes to a socket-activated service
+const systemdFirstFD = 3
+
+// socketMode lets the owner and group, such as a reverse proxy's, connect to a Unix socket
+const socketMode = 0660
+
+// Listen opens the server's listener: the socket systemd passed when the process was socket
+// activated, otherwise the Unix socket at socketPath when set, otherwise TCP on addr
+func Listen(addr, socketPath string) (net.Listener, error) {
+	if l, err := systemdListener(); l != nil || err != nil {
+		return l, err
+	}
+	if socketPath != "" {
+		return listenUnix(socketPath)
+	}
+	return net.Listen("tcp", addr)
+}
+
+// systemdListener returns the first socket passed by systemd (see sd_listen_fds), or nil when the
+// process was not socket activated
+func systemdListener() (net.Listener, error) {
+	pid, err := strconv.Atoi(os.Getenv("LISTEN_PID"))
+	if err != nil || pid != os.Getpid() {
+		return nil, nil
+	}
+	fds, err := strconv.Atoi(os.Getenv("LISTEN_FDS"))
+	if err != nil || fds < 1 {
+		return nil, nil
+	}
+	// Child processes must not take the sockets for theirs
+	os.Unsetenv("LISTEN_PID")
+	os.Unsetenv("LISTEN_FDS")
+	os.Unsetenv("LISTEN_FDNAMES")
+
+	f := os.NewFile(systemdFirstFD, "systemd socket")
+	defer f.Close()
+	l, err := net.FileListener(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to use the systemd socket: %w", err)
+	}
+	return l, nil
+}
+
+// listenUnix listens on a Unix socket at path, replacing a socket left behind by a previous run
+func listenUnix(path string) (net.Listener, error) {
+	if info, err := os.Lstat(path); err == nil {
+		if info.Mode().Type() != fs.ModeSocket {
+			return nil, fmt.Errorf("%s exists and is not a socket", path)
+		}
+		if err := os.Remove(path); err != nil {
+			return nil, err
+		}
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(path, socketMode); err != nil {
+		l.Close()
+		return nil, err
+	}
+	return l, nil
+}
@@ -0,0 +1,67 @@
+package httpserver
+
+import (
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListen(t *testing.T) {
+	t.Run("TCP without a socket", func(t *testing.T) {
+		l, err := Listen("127.0.0.1:0", "")
+		require.NoError(t, err)
+		defer l.Close()
+		assert.Equal(t, "tcp", l.Addr().Network())
+	})
+
+	t.Run("Unix socket", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "api.sock")
+		l, err := Listen(":0", path)
+		require.NoError(t, err)
+		go http.Serve(l, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("ok"))
+		}))
+
+		info, err := os.Stat(path)
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(socketMode), info.Mode().Perm())
+
+		conn, err := net.Dial("unix", path)
+		require.NoError(t, err)
+		conn.Close()
+		l.Close()
+
+		// A socket left behind is replaced
+		l, err = net.Listen("unix", path)
+		require.NoError(t, err)
+		l.(*net.UnixListener).SetUnlinkOnClose(false)
+		l.Close()
+		l, err = Listen(":0", path)
+		require.NoError(t, err)
+		l.Close()
+	})
+
+	t.Run("refuses to replace other files", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "config.yaml")
+		require.NoError(t, os.WriteFile(path, []byte("server:"), 0600))
+
+		_, err := Listen(":0", path)
+		assert.ErrorContains(t, err, "not a socket")
+	})
+
+	t.Run("ignores sockets meant for another process", func(t *testing.T) {
+		t.Setenv("LISTEN_PID", strconv.Itoa(os.Getpid()+1))
+		t.Setenv("LISTEN_FDS", "1")
+
+		l, err := Listen("127.0.0.1:0", "")
+		require.NoError(t, err)
+		defer l.Close()
+		assert.Equal(t, "tcp", l.Addr().Network())
+	})
+}
@@ -0,0 +1,89 @@
+// Package i18n translates API error messages and report labels. Each language has a catalog in
+// locales/ that maps the English text to its translation; text missing from a catalog stays English.
+package i18n
+
+import (
+	"embed"
+	"encoding/json"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Default is the language messages are written in
+const Default = "en"
+
+//go:embed locales/*.json
+var locales embed.FS
+
+// catalogs maps a language to its translations, keyed by the English text
+var catalogs = loadCatalogs()
+
+func loadCatalogs() map[string]map[string]string {
+	files, err := locales.ReadDir("locales")
+	if err != nil {
+		panic("i18n: " + err.Error())
+	}
+	catalogs := make(map[string]map[string]string)
+	for _, file := range files {
+		data, err := locales.ReadFile(path.Join("locales", file.Name()))
+		if err != nil {
+			panic("i18n: " + err.Error())
+		}
+		var catalog map[string]string
+		if err := json.Unmarshal(data, &catalog); err != nil {
+			panic("i18n: invalid catalog " + file.Name() + ": " + err.Error())
+		}
+		catalogs[strings.TrimSuffix(file.Name(), ".json")] = catalog
+	}
+	return catalogs
+}
+
+// Languages lists the supported languages, sorted, including Default
+func Languages() []string {
+	languages := []string{Default}
+	for lang := range catalogs {
+		languages = append(languages, lang)
+	}
+	sort.Strings(languages)
+	return languages
+}
+
+// Match picks the supported language the client prefers most from an Accept-Language header such as
+// "de-CH, fr;q=0.8". Regions are ignored, and Default is returned when nothing matches.
+func Match(acceptLanguage string) string {
+	best, bestQ := Default, 0.0
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		q := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+
+		lang, _, _ := strings.Cut(strings.ToLower(strings.TrimSpace(tag)), "-")
+		if lang == "*" {
+			lang = Default
+		}
+		if _, ok := catalogs[lang]; !ok && lang != Default {
+			continue
+		}
+		if q > bestQ {
+			best, bestQ = lang, q
+		}
+	}
+	return best
+}
+
+// T translates message into lang, returning it unchanged for Default, unsupported languages and
+// messages missing from the catalog
+func T(lang, message string) string {
+	if translated, ok := catalogs[lang][message]; ok {
+		return translated
+	}
+	return message
+}
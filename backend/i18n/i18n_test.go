@@ -0,0 +1,49 @@
+package i18n
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatch(t *testing.T) {
+	t.Run("picks the highest weighted supported language", func(t *testing.T) {
+		assert.Equal(t, "fr", Match("de;q=0.5, fr;q=0.9, en;q=0.1"))
+		assert.Equal(t, "de", Match("de-CH, fr;q=0.8"))
+	})
+
+	t.Run("skips unsupported languages", func(t *testing.T) {
+		assert.Equal(t, "de", Match("ja, de;q=0.5"))
+		assert.Equal(t, Default, Match("ja, zh-CN"))
+	})
+
+	t.Run("falls back to the default", func(t *testing.T) {
+		assert.Equal(t, Default, Match(""))
+		assert.Equal(t, Default, Match("*"))
+		assert.Equal(t, Default, Match("de;q=0"))
+		assert.Equal(t, Default, Match("de;q=abc"))
+	})
+}
+
+func TestT(t *testing.T) {
+	t.Run("translates catalog messages", func(t *testing.T) {
+		assert.Equal(t, "URL nicht gefunden", T("de", "URL not found"))
+		assert.Equal(t, "URL introuvable", T("fr", "URL not found"))
+	})
+
+	t.Run("returns other messages unchanged", func(t *testing.T) {
+		assert.Equal(t, "URL not found", T(Default, "URL not found"))
+		assert.Equal(t, "URL not found", T("ja", "URL not found"))
+		assert.Equal(t, "Invalid snapshot ID", T("de", "Invalid snapshot ID"))
+	})
+
+	t.Run("every catalog translates the same messages", func(t *testing.T) {
+		assert.Equal(t, []string{"de", "en", "fr"}, Languages())
+		for message := range catalogs["de"] {
+			assert.Contains(t, catalogs["fr"], message)
+		}
+		for message := range catalogs["fr"] {
+			assert.Contains(t, catalogs["de"], message)
+		}
+	})
+}
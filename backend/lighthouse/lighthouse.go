@@ -0,0 +1,175 @@
+// Package lighthouse scores pages with Lighthouse, run remotely by the PageSpeed Insights API v5.
+package lighthouse
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"time"
+
+	"sykell-analyze/backend/config"
+)
+
+// Endpoint is the PageSpeed Insights method that runs Lighthouse against a page
+const Endpoint = "https://www.googleapis.com/pagespeedonline/v5/runPagespeed"
+
+// categories are the Lighthouse categories scored, as the API names them
+var categories = []string{"performance", "accessibility", "best-practices", "seo"}
+
+// metricAudits are the lab metrics reported, by Lighthouse audit ID
+var metricAudits = []string{
+	"first-contentful-paint", "largest-contentful-paint", "total-blocking-time",
+	"cumulative-layout-shift", "speed-index", "interactive",
+}
+
+// Default is nil when Lighthouse scoring is disabled (lighthouse.enabled unset)
+var Default *Client
+
+// Configure enables Lighthouse scoring when it is switched on
+func Configure(cfg config.LighthouseConfig) {
+	if !cfg.Enabled {
+		Default = nil
+		return
+	}
+	Default = New(cfg.APIKey, cfg.Strategy, cfg.Timeout)
+	fmt.Printf("✅ Lighthouse scoring enabled (%s).\n", cfg.Strategy)
+}
+
+// Client runs Lighthouse through PageSpeed Insights. It is safe for concurrent use.
+type Client struct {
+	APIKey string
+	// Strategy is the device emulated: mobile or desktop
+	Strategy   string
+	Endpoint   string
+	HTTPClient *http.Client
+}
+
+// New creates a client for the public API
+func New(apiKey, strategy string, timeout time.Duration) *Client {
+	return &Client{
+		APIKey:     apiKey,
+		Strategy:   strategy,
+		Endpoint:   Endpoint,
+		HTTPClient: &http.Client{Timeout: timeout},
+	}
+}
+
+// Scores are the category scores from 0 to 100; a category is nil when Lighthouse could not score it
+type Scores struct {
+	Performance   *int `json:"performance"`
+	Accessibility *int `json:"accessibility"`
+	BestPractices *int `json:"best_practices"`
+	SEO           *int `json:"seo"`
+}
+
+// Report is the outcome of one Lighthouse run
+type Report struct {
+	Strategy string `json:"strategy"`
+	Scores   Scores `json:"scores"`
+	// Metrics are lab measurements by audit ID, in milliseconds except for the unitless cumulative-layout-shift
+	Metrics           map[string]float64 `json:"metrics"`
+	LighthouseVersion string             `json:"lighthouse_version"`
+	FetchedAt         time.Time          `json:"fetched_at"`
+}
+
+// runResponse is the part of a runPagespeed answer that is used
+type runResponse struct {
+	LighthouseResult struct {
+		LighthouseVersion string `json:"lighthouseVersion"`
+		FetchTime         string `json:"fetchTime"`
+		RuntimeError      *struct {
+			Code    string `json:"code"`
+			Message string `json:"message"`
+		} `json:"runtimeError"`
+		Categories map[string]struct {
+			Score *float64 `json:"score"`
+		} `json:"categories"`
+		Audits map[string]struct {
+			NumericValue *float64 `json:"numericValue"`
+		} `json:"audits"`
+	} `json:"lighthouseResult"`
+}
+
+// errorResponse is the answer of a failed call
+type errorResponse struct {
+	Error struct {
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// Run audits pageURL and returns its scores. Errors include Lighthouse's reason when the page could
+// not be loaded, such as a DNS failure or a page that never painted.
+func (c *Client) Run(ctx context.Context, pageURL string) (*Report, error) {
+	query := url.Values{"url": {pageURL}, "strategy": {c.Strategy}}
+	for _, category := range categories {
+		query.Add("category", category)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.Endpoint+"?"+query.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	if c.APIKey != "" {
+		// Sent as a header so the key never appears in error messages that include the URL
+		req.Header.Set("X-Goog-Api-Key", c.APIKey)
+	}
+
+	res, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("lighthouse run failed: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		var decoded errorResponse
+		json.NewDecoder(io.LimitReader(res.Body, 64<<10)).Decode(&decoded)
+		if decoded.Error.Message != "" {
+			return nil, fmt.Errorf("lighthouse run failed: %s: %s", res.Status, decoded.Error.Message)
+		}
+		return nil, fmt.Errorf("lighthouse run failed: %s", res.Status)
+	}
+
+	var decoded runResponse
+	if err := json.NewDecoder(res.Body).Decode(&decoded); err != nil {
+		return nil, fmt.Errorf("invalid lighthouse response: %w", err)
+	}
+	result := decoded.LighthouseResult
+	if result.RuntimeError != nil && result.RuntimeError.Code != "" && result.RuntimeError.Code != "NO_ERROR" {
+		return nil, fmt.Errorf("lighthouse could not audit the page: %s: %s", result.RuntimeError.Code, result.RuntimeError.Message)
+	}
+
+	report := &Report{
+		Strategy:          c.Strategy,
+		Metrics:           make(map[string]float64),
+		LighthouseVersion: result.LighthouseVersion,
+		FetchedAt:         time.Now().UTC(),
+	}
+	if fetched, err := time.Parse(time.RFC3339, result.FetchTime); err == nil {
+		report.FetchedAt = fetched.UTC()
+	}
+
+	score := func(category string) *int {
+		scored, ok := result.Categories[category]
+		if !ok || scored.Score == nil {
+			return nil
+		}
+		value := int(math.Round(*scored.Score * 100))
+		return &value
+	}
+	report.Scores = Scores{
+		Performance:   score("performance"),
+		Accessibility: score("accessibility"),
+		BestPractices: score("best-practices"),
+		SEO:           score("seo"),
+	}
+
+	for _, id := range metricAudits {
+		if audit, ok := result.Audits[id]; ok && audit.NumericValue != nil {
+			report.Metrics[id] = *audit.NumericValue
+		}
+	}
+	return report, nil
+}
@@ -0,0 +1,78 @@
+package lighthouse
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const sampleResponse = `{
+	"lighthouseResult": {
+		"lighthouseVersion": "12.2.1",
+		"fetchTime": "2026-10-16T09:30:00.000Z",
+		"categories": {
+			"performance": {"score": 0.874},
+			"accessibility": {"score": 1},
+			"best-practices": {"score": 0.5},
+			"seo": {"score": null}
+		},
+		"audits": {
+			"largest-contentful-paint": {"numericValue": 2450.5},
+			"cumulative-layout-shift": {"numericValue": 0.02},
+			"unused-javascript": {"numericValue": 120}
+		}
+	}
+}`
+
+func TestRun(t *testing.T) {
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Query().Get("url") {
+		case "https://example.com/":
+			assert.Equal(t, "desktop", r.URL.Query().Get("strategy"))
+			assert.Equal(t, categories, r.URL.Query()["category"])
+			assert.Equal(t, "test-key", r.Header.Get("X-Goog-Api-Key"))
+			w.Write([]byte(sampleResponse))
+		case "https://blank.example/":
+			w.Write([]byte(`{"lighthouseResult": {"runtimeError": {"code": "NO_FCP", "message": "The page did not paint any content."}}}`))
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error": {"code": 400, "message": "Lighthouse returned error: ERRORED_DOCUMENT_REQUEST."}}`))
+		}
+	}))
+	defer api.Close()
+
+	client := New("test-key", "desktop", 5*time.Second)
+	client.Endpoint = api.URL
+
+	t.Run("converts scores and metrics", func(t *testing.T) {
+		report, err := client.Run(context.Background(), "https://example.com/")
+		require.NoError(t, err)
+
+		assert.Equal(t, 87, *report.Scores.Performance)
+		assert.Equal(t, 100, *report.Scores.Accessibility)
+		assert.Equal(t, 50, *report.Scores.BestPractices)
+		assert.Nil(t, report.Scores.SEO)
+		assert.Equal(t, map[string]float64{"largest-contentful-paint": 2450.5, "cumulative-layout-shift": 0.02}, report.Metrics)
+		assert.Equal(t, "12.2.1", report.LighthouseVersion)
+		assert.Equal(t, "desktop", report.Strategy)
+		assert.Equal(t, time.Date(2026, 10, 16, 9, 30, 0, 0, time.UTC), report.FetchedAt)
+	})
+
+	t.Run("reports pages Lighthouse could not audit", func(t *testing.T) {
+		_, err := client.Run(context.Background(), "https://blank.example/")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "NO_FCP")
+	})
+
+	t.Run("includes the API error message", func(t *testing.T) {
+		_, err := client.Run(context.Background(), "https://down.example/")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "ERRORED_DOCUMENT_REQUEST")
+		assert.NotContains(t, err.Error(), "test-key")
+	})
+}
@@ -0,0 +1,108 @@
+package linkcache
+
+import (
+	"context"
+	"database/sql"
+
+	"sykell-analyze/backend/analyzer"
+	"sykell-analyze/backend/config"
+)
+
+// Checkpoint is the link cache of a crawl job that can be paused. It answers the links earlier,
+// paused attempts of the job checked, and once Paused reports true it keeps the verdicts of the
+// links checked so far, so the attempt that resumes the crawl only checks the rest. Other lookups
+// and stores go to Cache, which may be nil. It implements analyzer.LinkCache.
+type Checkpoint struct {
+	JobID  int
+	Cache  analyzer.LinkCache
+	Paused func() bool
+}
+
+// LookupLinks returns the verdicts the job's earlier attempts checked, then those of Cache
+func (c *Checkpoint) LookupLinks(ctx context.Context, links []string) (map[string]*analyzer.BrokenLink, error) {
+	rows, err := config.DBFor(ctx).QueryContext(ctx,
+		"SELECT url, broken, status_code, error_message FROM crawl_checkpoints WHERE job_id = ?", c.JobID)
+	if err != nil {
+		return c.lookupCache(ctx, links, nil)
+	}
+	defer rows.Close()
+
+	wanted := make(map[string]bool, len(links))
+	for _, link := range links {
+		wanted[link] = true
+	}
+	verdicts := make(map[string]*analyzer.BrokenLink)
+	for rows.Next() {
+		var link string
+		var broken bool
+		var statusCode sql.NullInt64
+		var errorMessage sql.NullString
+		if err := rows.Scan(&link, &broken, &statusCode, &errorMessage); err != nil {
+			return c.lookupCache(ctx, links, verdicts)
+		}
+		if wanted[link] {
+			verdicts[link] = verdict(link, broken, statusCode, errorMessage)
+		}
+	}
+	return c.lookupCache(ctx, links, verdicts)
+}
+
+// lookupCache adds the verdicts Cache has for the links not in verdicts
+func (c *Checkpoint) lookupCache(ctx context.Context, links []string, verdicts map[string]*analyzer.BrokenLink) (map[string]*analyzer.BrokenLink, error) {
+	if verdicts == nil {
+		verdicts = make(map[string]*analyzer.BrokenLink)
+	}
+	if c.Cache == nil {
+		return verdicts, nil
+	}
+	var rest []string
+	for _, link := range links {
+		if _, ok := verdicts[link]; !ok {
+			rest = append(rest, link)
+		}
+	}
+	if len(rest) == 0 {
+		return verdicts, nil
+	}
+	cached, err := c.Cache.LookupLinks(ctx, rest)
+	for link, v := range cached {
+		verdicts[link] = v
+	}
+	return verdicts, err
+}
+
+// StoreLinks passes verdicts on to Cache and, when the crawl was paused, records them for the job.
+// Checks that failed on a server or network error are not passed to a link cache, so the resumed
+// crawl repeats them.
+func (c *Checkpoint) StoreLinks(ctx context.Context, verdicts map[string]*analyzer.BrokenLink) error {
+	var err error
+	if c.Cache != nil {
+		err = c.Cache.StoreLinks(ctx, verdicts)
+	}
+	if c.Paused == nil || !c.Paused() {
+		return err
+	}
+
+	links := make([]string, 0, len(verdicts))
+	for link := range verdicts {
+		links = append(links, link)
+	}
+	for start := 0; start < len(links); start += batchSize {
+		batch := links[start:min(start+batchSize, len(links))]
+		args := make([]interface{}, 0, 6*len(batch))
+		for _, link := range batch {
+			statusCode, errorMessage := verdictColumns(verdicts[link])
+			args = append(args, c.JobID, hash(link), link, verdicts[link] != nil, statusCode, errorMessage)
+		}
+
+		_, storeErr := config.DBFor(ctx).ExecContext(ctx, `
+			INSERT INTO crawl_checkpoints (job_id, url_hash, url, broken, status_code, error_message)
+			VALUES `+rowPlaceholders(len(batch), 6)+`
+			ON DUPLICATE KEY UPDATE broken = VALUES(broken), status_code = VALUES(status_code),
+				error_message = VALUES(error_message)`, args...)
+		if storeErr != nil {
+			return storeErr
+		}
+	}
+	return err
+}
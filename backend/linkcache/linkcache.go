@@ -0,0 +1,151 @@
+// Package linkcache keeps link check verdicts in the database, so crawls of any page or user reuse
+// the checks of recent crawls instead of requesting the same links again.
+package linkcache
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"strings"
+	"time"
+
+	"sykell-analyze/backend/analyzer"
+	"sykell-analyze/backend/config"
+	"sykell-analyze/backend/sanitize"
+)
+
+// batchSize is how many links one query looks up or stores
+const batchSize = 500
+
+// purgeBatchSize is how many rows one DELETE of Purge removes, keeping locks short
+const purgeBatchSize = 10000
+
+// Cache keeps verdicts for TTL. It implements analyzer.LinkCache.
+type Cache struct {
+	TTL time.Duration
+}
+
+// New creates a cache reusing verdicts for ttl
+func New(ttl time.Duration) *Cache {
+	return &Cache{TTL: ttl}
+}
+
+// LookupLinks returns the verdicts of the links checked within the TTL
+func (c *Cache) LookupLinks(ctx context.Context, links []string) (map[string]*analyzer.BrokenLink, error) {
+	verdicts := make(map[string]*analyzer.BrokenLink)
+	since := time.Now().Add(-c.TTL)
+	for start := 0; start < len(links); start += batchSize {
+		batch := links[start:min(start+batchSize, len(links))]
+		args := make([]interface{}, 0, len(batch)+1)
+		for _, link := range batch {
+			args = append(args, hash(link))
+		}
+		args = append(args, since)
+
+		rows, err := config.DBFor(ctx).QueryContext(ctx, `
+			SELECT url, broken, status_code, error_message FROM link_checks
+			WHERE url_hash IN (`+placeholders(len(batch))+`) AND checked_at > ?`, args...)
+		if err != nil {
+			return verdicts, err
+		}
+		for rows.Next() {
+			var link string
+			var broken bool
+			var statusCode sql.NullInt64
+			var errorMessage sql.NullString
+			if err := rows.Scan(&link, &broken, &statusCode, &errorMessage); err != nil {
+				rows.Close()
+				return verdicts, err
+			}
+			verdicts[link] = verdict(link, broken, statusCode, errorMessage)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return verdicts, err
+		}
+	}
+	return verdicts, nil
+}
+
+// StoreLinks records verdicts as checked now, replacing older ones
+func (c *Cache) StoreLinks(ctx context.Context, verdicts map[string]*analyzer.BrokenLink) error {
+	links := make([]string, 0, len(verdicts))
+	for link := range verdicts {
+		links = append(links, link)
+	}
+	now := time.Now()
+	for start := 0; start < len(links); start += batchSize {
+		batch := links[start:min(start+batchSize, len(links))]
+		args := make([]interface{}, 0, 6*len(batch))
+		for _, link := range batch {
+			statusCode, errorMessage := verdictColumns(verdicts[link])
+			args = append(args, hash(link), link, verdicts[link] != nil, statusCode, errorMessage, now)
+		}
+
+		_, err := config.DBFor(ctx).ExecContext(ctx, `
+			INSERT INTO link_checks (url_hash, url, broken, status_code, error_message, checked_at)
+			VALUES `+rowPlaceholders(len(batch), 6)+`
+			ON DUPLICATE KEY UPDATE broken = VALUES(broken), status_code = VALUES(status_code),
+				error_message = VALUES(error_message), checked_at = VALUES(checked_at)`, args...)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// verdict is the verdict of a stored row: nil for a working link
+func verdict(link string, broken bool, statusCode sql.NullInt64, errorMessage sql.NullString) *analyzer.BrokenLink {
+	if !broken {
+		return nil
+	}
+	return &analyzer.BrokenLink{
+		URL:        link,
+		StatusCode: int(statusCode.Int64),
+		Error:      errorMessage.String,
+	}
+}
+
+// verdictColumns returns the status_code and error_message columns stored for a verdict
+func verdictColumns(verdict *analyzer.BrokenLink) (statusCode, errorMessage interface{}) {
+	if verdict != nil {
+		if verdict.StatusCode != 0 {
+			statusCode = verdict.StatusCode
+		}
+		errorMessage = sanitize.Line(verdict.Error, sanitize.MaxMessage)
+	}
+	return statusCode, errorMessage
+}
+
+// Purge deletes the verdicts checked before cutoff in batches, returning how many were deleted
+func Purge(ctx context.Context, cutoff time.Time) (int64, error) {
+	var total int64
+	for {
+		res, err := config.DBFor(ctx).Exec("DELETE FROM link_checks WHERE checked_at < ? LIMIT ?", cutoff, purgeBatchSize)
+		if err != nil {
+			return total, err
+		}
+		n, _ := res.RowsAffected()
+		total += n
+		if n < purgeBatchSize {
+			return total, nil
+		}
+	}
+}
+
+// hash is the key of a link, which may be too long to index
+func hash(link string) []byte {
+	sum := sha256.Sum256([]byte(link))
+	return sum[:]
+}
+
+// placeholders returns n comma-separated placeholders
+func placeholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?,", n), ",")
+}
+
+// rowPlaceholders returns the placeholders of n rows of width values each
+func rowPlaceholders(n, width int) string {
+	row := "(" + placeholders(width) + ")"
+	return strings.TrimSuffix(strings.Repeat(row+",", n), ",")
+}
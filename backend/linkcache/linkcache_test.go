@@ -0,0 +1,76 @@
+package linkcache
+
+import (
+	"context"
+	"testing"
+
+	"sykell-analyze/backend/analyzer"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHash(t *testing.T) {
+	assert.Len(t, hash("https://example.com/"), 32)
+	assert.Equal(t, hash("https://example.com/"), hash("https://example.com/"))
+	assert.NotEqual(t, hash("https://example.com/"), hash("https://example.com/a"))
+}
+
+func TestPlaceholders(t *testing.T) {
+	assert.Equal(t, "?", placeholders(1))
+	assert.Equal(t, "?,?,?", placeholders(3))
+	assert.Equal(t, "(?,?),(?,?),(?,?)", rowPlaceholders(3, 2))
+}
+
+// fakeCache is a LinkCache in memory
+type fakeCache struct {
+	verdicts map[string]*analyzer.BrokenLink
+	stored   map[string]*analyzer.BrokenLink
+}
+
+func (f *fakeCache) LookupLinks(ctx context.Context, links []string) (map[string]*analyzer.BrokenLink, error) {
+	found := make(map[string]*analyzer.BrokenLink)
+	for _, link := range links {
+		if verdict, ok := f.verdicts[link]; ok {
+			found[link] = verdict
+		}
+	}
+	return found, nil
+}
+
+func (f *fakeCache) StoreLinks(ctx context.Context, verdicts map[string]*analyzer.BrokenLink) error {
+	f.stored = verdicts
+	return nil
+}
+
+func TestCheckpoint(t *testing.T) {
+	broken := &analyzer.BrokenLink{URL: "https://example.com/gone", StatusCode: 404}
+	cache := &fakeCache{verdicts: map[string]*analyzer.BrokenLink{
+		"https://example.com/":     nil,
+		"https://example.com/gone": broken,
+	}}
+	checkpoint := &Checkpoint{JobID: 1, Cache: cache, Paused: func() bool { return false }}
+
+	t.Run("checkpointed verdicts come before the cache", func(t *testing.T) {
+		verdicts, err := checkpoint.lookupCache(context.Background(),
+			[]string{"https://example.com/", "https://example.com/gone", "https://example.com/new"},
+			map[string]*analyzer.BrokenLink{"https://example.com/": broken})
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]*analyzer.BrokenLink{
+			"https://example.com/":     broken,
+			"https://example.com/gone": broken,
+		}, verdicts)
+	})
+
+	t.Run("running crawls only store in the cache", func(t *testing.T) {
+		verdicts := map[string]*analyzer.BrokenLink{"https://example.com/new": nil}
+		assert.NoError(t, checkpoint.StoreLinks(context.Background(), verdicts))
+		assert.Equal(t, verdicts, cache.stored)
+	})
+
+	t.Run("without a cache", func(t *testing.T) {
+		verdicts, err := (&Checkpoint{JobID: 1}).lookupCache(context.Background(), []string{"https://example.com/"}, nil)
+		assert.NoError(t, err)
+		assert.Empty(t, verdicts)
+		assert.NoError(t, (&Checkpoint{JobID: 1}).StoreLinks(context.Background(), nil))
+	})
+}
@@ -1,63 +1,216 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"net/http"
-	"os"
+	"strconv"
+	_ "time/tzdata" // Timezone preferences work without tzdata in the container
 
+	"sykell-analyze/backend/alerts"
+	"sykell-analyze/backend/archive"
+	"sykell-analyze/backend/cache"
 	"sykell-analyze/backend/config"
-	"sykell-analyze/backend/routes"
+	"sykell-analyze/backend/crux"
+	"sykell-analyze/backend/demo"
+	"sykell-analyze/backend/digest"
+	"sykell-analyze/backend/dnscache"
+	"sykell-analyze/backend/geoip"
+	"sykell-analyze/backend/httpserver"
+	"sykell-analyze/backend/lighthouse"
+	"sykell-analyze/backend/middleware"
+	"sykell-analyze/backend/migrations"
+	"sykell-analyze/backend/monitor"
+	"sykell-analyze/backend/rdap"
+	"sykell-analyze/backend/retention"
+	"sykell-analyze/backend/safebrowsing"
+	"sykell-analyze/backend/storage"
+	"sykell-analyze/backend/tenancy"
+	"sykell-analyze/backend/urlstatus"
+	"sykell-analyze/backend/wayback"
+	"sykell-analyze/backend/worker"
 
-	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 )
 
 func main() {
-	// Set Gin mode based on environment
-	if os.Getenv("GIN_MODE") == "" {
-		gin.SetMode(gin.DebugMode)
+	// Load config.yaml (CONFIG_FILE) with environment overrides, refusing to start on invalid settings
+	cfg, err := config.Load(config.FilePath())
+	if err != nil {
+		log.Fatalf("Failed to load configuration: %v", err)
 	}
+	config.App = cfg
+
+	// Apply tunable settings from the config file on SIGHUP (or POST /api/admin/reload)
+	config.ReloadOnHangup(config.FilePath())
+
+	gin.SetMode(cfg.Server.GinMode)
+	middleware.ConfigureAuth(cfg.JWT)
 
 	// Connect to database
 	if err := config.ConnectDB(); err != nil {
 		log.Fatalf("Failed to connect to database: %v", err)
 	}
 
-	// Create a new Gin router
-	router := gin.Default()
+	// Bring databases created from an older sql/init.sql up to date
+	applied, err := migrations.Run(config.DB)
+	if err != nil {
+		log.Fatalf("Failed to migrate database: %v", err)
+	}
+	for _, name := range applied {
+		fmt.Printf("DEBUG: Applied migration %s\n", name)
+	}
+
+	// Spread the URL list and stats over read replicas when configured
+	if err := config.ConnectReplicas(); err != nil {
+		log.Fatalf("Failed to connect to read replicas: %v", err)
+	}
+
+	// Create and seed the read-only demo account when enabled
+	if cfg.Demo.Enabled {
+		if err := demo.Seed(context.Background(), cfg.Demo); err != nil {
+			log.Fatalf("Failed to seed demo account: %v", err)
+		}
+	}
+
+	// Connect to the optional Redis response cache
+	if err := cache.Connect(cfg.Cache); err != nil {
+		log.Fatalf("Failed to connect to cache: %v", err)
+	}
+
+	// Resolve crawled host names through the configured name servers, caching the answers
+	dnscache.Configure(cfg.DNS)
+
+	// Enable Google Safe Browsing lookups when an API key is configured
+	safebrowsing.Configure(cfg.SafeBrowsing)
+
+	// Look up field Core Web Vitals in the Chrome UX Report when an API key is configured
+	crux.Configure(cfg.CrUX)
+
+	// Look up the registrar and expiry date of analyzed domains when enabled
+	rdap.Configure(cfg.RDAP)
+
+	// Load the GeoIP database for server ASN and country lookups when one is configured
+	if err := geoip.Configure(cfg.GeoIP); err != nil {
+		log.Fatalf("Failed to load GeoIP database: %v", err)
+	}
+
+	// Suggest archived copies of broken links from the Wayback Machine when enabled
+	wayback.Configure(cfg.Wayback)
+
+	// Score crawled pages with Lighthouse when enabled
+	lighthouse.Configure(cfg.Lighthouse)
+
+	// Keep archived snapshots and data exports on the local volume or in S3-compatible storage
+	if err := storage.Configure(cfg.Storage); err != nil {
+		log.Fatalf("Failed to set up storage: %v", err)
+	}
+
+	// Deliver alert rule notifications, including email when an SMTP server is configured
+	alerts.Configure(cfg.Alerts)
+	urlstatus.Subscribe(alerts.StatusChanged)
 
-	// Configure CORS
-	router.Use(cors.New(cors.Config{
-		AllowOrigins:     []string{"http://localhost:3000", "http://localhost:80"},
-		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization"},
-		AllowCredentials: true,
-	}))
+	// Run the crawl worker and background jobs of the primary database
+	addTenant := startBackground(context.Background(), cfg)
 
-	// Health check route
-	router.GET("/api/health", func(c *gin.Context) {
-		c.JSON(http.StatusOK, gin.H{
-			"message": "API is running!",
-			"status":  "healthy",
-			"version": "1.0.0",
+	// With tenancy, bring every tenant database up to date, seed its demo account and hand it to the
+	// background jobs as well, including tenants provisioned while the server runs
+	if cfg.Tenancy.Enabled {
+		go tenancy.Watch(context.Background(), func(t *config.Tenant) {
+			applied, err := migrations.Run(t.DB)
+			if err != nil {
+				fmt.Printf("DEBUG: Failed to migrate the database of tenant %s: %v\n", t.Name, err)
+				return
+			}
+			for _, name := range applied {
+				fmt.Printf("DEBUG: Applied migration %s to tenant %s\n", name, t.Name)
+			}
+			if cfg.Demo.Enabled {
+				if err := demo.Seed(config.WithTenant(context.Background(), t), cfg.Demo); err != nil {
+					fmt.Printf("DEBUG: Failed to seed the demo account of tenant %s: %v\n", t.Name, err)
+				}
+			}
+			addTenant(t)
 		})
-	})
+	}
 
-	// Register all API routes
-	routes.RegisterRoutes(router)
+	// Create the Gin router with the API middleware and routes
+	router := newRouter(cfg)
 
 	// Start the server
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "8080"
-	}
+	port := strconv.Itoa(cfg.Server.Port)
 
-	fmt.Printf("🚀 Server is running on http://localhost:%s\n", port)
-	fmt.Printf("📊 Health check: http://localhost:%s/api/health\n", port)
-	fmt.Printf("🔐 Auth endpoints: http://localhost:%s/api/auth/login\n", port)
+	scheme := "http"
+	if cfg.TLS.Enabled() {
+		scheme = "https"
+	}
+	if cfg.Server.ListenSocket != "" {
+		fmt.Printf("🚀 Server is running on unix:%s\n", cfg.Server.ListenSocket)
+	} else {
+		fmt.Printf("🚀 Server is running on %s://localhost:%s\n", scheme, port)
+		fmt.Printf("📊 Health check: %s://localhost:%s/api/health\n", scheme, port)
+		fmt.Printf("🔐 Auth endpoints: %s://localhost:%s/api/auth/login\n", scheme, port)
+	}
 
-	if err := router.Run(":" + port); err != nil {
+	server := &http.Server{
+		Addr:    ":" + port,
+		Handler: serverHandler(router, cfg),
+	}
+	// Plain HTTP, or HTTPS with certificate files or Let's Encrypt as tls configures
+	if err := httpserver.ListenAndServe(server, cfg.Server.ListenSocket, cfg.TLS); err != nil {
 		log.Fatalf("Failed to start server: %v", err)
 	}
 }
+
+// startBackground starts the crawl worker and background jobs cfg enables for the database of ctx. It
+// returns a function that adds a tenant database: the worker and the uptime monitor serve every
+// tenant with their configured concurrency in total, while the digest, retention and archive jobs,
+// which only sweep their own database, run once per tenant.
+func startBackground(ctx context.Context, cfg *config.Config) func(*config.Tenant) {
+	// Process crawl jobs in-process unless dedicated worker binaries are deployed
+	var w *worker.Worker
+	if cfg.Server.EmbeddedWorker {
+		w = worker.New(cfg.Worker)
+		config.OnReload(func(cfg *config.Config) { w.Reconfigure(cfg.Worker) })
+		go w.Run(ctx)
+	}
+
+	// Ping tracked URLs for uptime when enabled; monitors in other processes share the work
+	var m *monitor.Monitor
+	if cfg.Monitor.Enabled {
+		m = monitor.New(cfg.Monitor, cfg.Crawler.UserAgent)
+		go m.Run(ctx)
+	}
+
+	startJobs(ctx, cfg)
+
+	return func(t *config.Tenant) {
+		tenantCtx := config.WithTenant(ctx, t)
+		if w != nil {
+			w.AddTenant(t)
+		}
+		if m != nil {
+			go m.Run(tenantCtx)
+		}
+		startJobs(tenantCtx, cfg)
+	}
+}
+
+// startJobs starts the digest, retention and archive jobs cfg enables for the database of ctx
+func startJobs(ctx context.Context, cfg *config.Config) {
+	// Email daily and weekly activity digests when enabled; each digest is sent by one process only
+	if cfg.Digest.Enabled {
+		go digest.New(cfg.Digest, cfg.Crawler.StaleAfter).Run(ctx)
+	}
+
+	// Purge crawl history past each account's retention; deletes are idempotent, so every process may run it
+	if cfg.Retention.Interval > 0 {
+		go retention.New(cfg.Retention).Run(ctx)
+	}
+
+	// Move old snapshot text to storage; a snapshot is only marked archived once, so every process may run it
+	if cfg.Archive.Enabled {
+		go archive.New(cfg.Archive).Run(ctx)
+	}
+}
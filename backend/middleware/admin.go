@@ -0,0 +1,44 @@
+package middleware
+
+import (
+	"database/sql"
+	"net/http"
+
+	"sykell-analyze/backend/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequireAdmin only lets administrators through. It must run after AuthMiddleware. The flag is read
+// from the database on every request so revoking it takes effect without waiting for tokens to expire.
+func RequireAdmin() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, exists := c.Get("user_id")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"error": "Authentication required",
+			})
+			c.Abort()
+			return
+		}
+
+		var isAdmin bool
+		err := config.DBFor(c.Request.Context()).QueryRow("SELECT is_admin FROM users WHERE id = ?", userID).Scan(&isAdmin)
+		if err != nil && err != sql.ErrNoRows {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Database error",
+			})
+			c.Abort()
+			return
+		}
+		if !isAdmin {
+			c.JSON(http.StatusForbidden, gin.H{
+				"error": "Administrator access required",
+			})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
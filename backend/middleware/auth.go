@@ -1,38 +1,72 @@
 package middleware
 
 import (
+	"context"
+	"database/sql"
 	"net/http"
-	"os"
 	"strings"
 	"time"
 
+	"sykell-analyze/backend/config"
+
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
 )
 
 var jwtSecret = []byte(getJWTSecret())
 
+// tokenTTL is how long issued tokens stay valid
+var tokenTTL = config.App.JWT.TokenTTL
+
 func getJWTSecret() string {
-	secret := os.Getenv("JWT_SECRET")
-	if secret == "" {
-		return "your-secret-key-change-in-production" // Default for development
-	}
-	return secret
+	return config.App.JWT.Secret
+}
+
+// ConfigureAuth applies the loaded JWT settings; call it once at startup before serving requests
+func ConfigureAuth(cfg config.JWTConfig) {
+	jwtSecret = []byte(cfg.Secret)
+	tokenTTL = cfg.TokenTTL
 }
 
 type Claims struct {
 	UserID   int    `json:"user_id"`
 	Username string `json:"username"`
+	// ReadOnly tokens, issued for the demo account, may only read
+	ReadOnly bool `json:"read_only,omitempty"`
+	// ServiceTokenID is set on service account tokens, which may only call the routes their Scopes allow
+	ServiceTokenID int      `json:"service_token_id,omitempty"`
+	Scopes         []string `json:"scopes,omitempty"`
+	// Tenant is the tenant the token was issued by; it is only valid on that tenant's requests
+	Tenant string `json:"tenant,omitempty"`
 	jwt.RegisteredClaims
 }
 
-// GenerateToken creates a new JWT token for a user
-func GenerateToken(userID int, username string) (string, error) {
+// GenerateToken creates a new JWT token for a user of the tenant of ctx
+func GenerateToken(ctx context.Context, userID int, username string) (string, error) {
+	claims := Claims{
+		UserID:   userID,
+		Username: username,
+		Tenant:   config.TenantName(ctx),
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(tokenTTL)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			Issuer:    "sykell-analyze",
+		},
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString(jwtSecret)
+}
+
+// GenerateReadOnlyToken creates a token valid for ttl that AuthMiddleware only lets read
+func GenerateReadOnlyToken(ctx context.Context, userID int, username string, ttl time.Duration) (string, error) {
 	claims := Claims{
 		UserID:   userID,
 		Username: username,
+		ReadOnly: true,
+		Tenant:   config.TenantName(ctx),
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(24 * time.Hour)),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(ttl)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 			Issuer:    "sykell-analyze",
 		},
@@ -42,6 +76,33 @@ func GenerateToken(userID int, username string) (string, error) {
 	return token.SignedString(jwtSecret)
 }
 
+// GenerateServiceToken creates a token for the service account tokenID of a user, limited to scopes.
+// A nil expiresAt issues a token valid until the service account is deleted.
+func GenerateServiceToken(ctx context.Context, userID int, username string, tokenID int, scopes []string, expiresAt *time.Time) (string, error) {
+	claims := Claims{
+		UserID:         userID,
+		Username:       username,
+		ServiceTokenID: tokenID,
+		Scopes:         scopes,
+		Tenant:         config.TenantName(ctx),
+		RegisteredClaims: jwt.RegisteredClaims{
+			IssuedAt: jwt.NewNumericDate(time.Now()),
+			Issuer:   "sykell-analyze",
+		},
+	}
+	if expiresAt != nil {
+		claims.ExpiresAt = jwt.NewNumericDate(*expiresAt)
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString(jwtSecret)
+}
+
+// isReadMethod reports whether an HTTP method only reads
+func isReadMethod(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions
+}
+
 // ValidateToken validates and parses a JWT token
 func ValidateToken(tokenString string) (*Claims, error) {
 	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
@@ -80,8 +141,9 @@ func AuthMiddleware() gin.HandlerFunc {
 			return
 		}
 
+		// A token of one tenant is no use on another, where its user ID names someone else
 		claims, err := ValidateToken(tokenString)
-		if err != nil {
+		if err != nil || claims.Tenant != config.TenantName(c.Request.Context()) {
 			c.JSON(http.StatusUnauthorized, gin.H{
 				"error": "Invalid token",
 			})
@@ -89,9 +151,47 @@ func AuthMiddleware() gin.HandlerFunc {
 			return
 		}
 
+		if claims.ReadOnly && !isReadMethod(c.Request.Method) {
+			c.JSON(http.StatusForbidden, gin.H{
+				"error": "The demo account is read-only",
+			})
+			c.Abort()
+			return
+		}
+
+		if claims.ServiceTokenID != 0 {
+			if !scopeAllowed(claims.Scopes, c.Request.Method, c.FullPath()) {
+				c.JSON(http.StatusForbidden, gin.H{
+					"error": "The token's scopes do not allow this request",
+				})
+				c.Abort()
+				return
+			}
+			// Deleting the service account revokes its tokens immediately
+			var id int
+			db := config.DBFor(c.Request.Context())
+			err := db.QueryRow("SELECT id FROM service_tokens WHERE id = ? AND user_id = ?", claims.ServiceTokenID, claims.UserID).Scan(&id)
+			if err == sql.ErrNoRows {
+				c.JSON(http.StatusUnauthorized, gin.H{
+					"error": "Token has been revoked",
+				})
+				c.Abort()
+				return
+			} else if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{
+					"error": "Database error",
+				})
+				c.Abort()
+				return
+			}
+			db.Exec("UPDATE service_tokens SET last_used_at = ? WHERE id = ?", time.Now(), id)
+			c.Set("scopes", claims.Scopes)
+		}
+
 		// Store user info in context
 		c.Set("user_id", claims.UserID)
 		c.Set("username", claims.Username)
+		c.Set("read_only", claims.ReadOnly)
 		c.Next()
 	}
 }
@@ -104,9 +204,11 @@ func OptionalAuthMiddleware() gin.HandlerFunc {
 			tokenString := strings.TrimPrefix(authHeader, "Bearer ")
 			if tokenString != authHeader {
 				claims, err := ValidateToken(tokenString)
-				if err == nil {
+				// Service account tokens are only accepted where AuthMiddleware checks their scopes
+				if err == nil && claims.ServiceTokenID == 0 && claims.Tenant == config.TenantName(c.Request.Context()) {
 					c.Set("user_id", claims.UserID)
 					c.Set("username", claims.Username)
+					c.Set("read_only", claims.ReadOnly)
 				}
 			}
 		}
@@ -1,6 +1,7 @@
 package middleware
 
 import (
+	"context"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -16,7 +17,7 @@ func TestGenerateToken(t *testing.T) {
 		userID := 1
 		username := "testuser"
 
-		token, err := GenerateToken(userID, username)
+		token, err := GenerateToken(context.Background(), userID, username)
 
 		assert.NoError(t, err)
 		assert.NotEmpty(t, token)
@@ -34,7 +35,7 @@ func TestGenerateToken(t *testing.T) {
 		userID := 123
 		username := "testuser123"
 
-		token, err := GenerateToken(userID, username)
+		token, err := GenerateToken(context.Background(), userID, username)
 		assert.NoError(t, err)
 
 		// Parse and validate claims
@@ -59,7 +60,7 @@ func TestValidateToken(t *testing.T) {
 		userID := 1
 		username := "testuser"
 
-		token, err := GenerateToken(userID, username)
+		token, err := GenerateToken(context.Background(), userID, username)
 		assert.NoError(t, err)
 
 		claims, err := ValidateToken(token)
@@ -133,7 +134,7 @@ func TestAuthMiddleware(t *testing.T) {
 		// Generate valid token
 		userID := 1
 		username := "testuser"
-		token, err := GenerateToken(userID, username)
+		token, err := GenerateToken(context.Background(), userID, username)
 		assert.NoError(t, err)
 
 		// Create test request
@@ -244,10 +245,37 @@ func TestAuthMiddleware(t *testing.T) {
 		assert.Equal(t, http.StatusUnauthorized, c.Writer.Status())
 	})
 
+	t.Run("read-only token can only read", func(t *testing.T) {
+		token, err := GenerateReadOnlyToken(context.Background(), 2, "demo", time.Hour)
+		assert.NoError(t, err)
+
+		for method, allowed := range map[string]bool{
+			http.MethodGet: true, http.MethodHead: true, http.MethodOptions: true,
+			http.MethodPost: false, http.MethodPut: false, http.MethodDelete: false,
+		} {
+			req, _ := http.NewRequest(method, "/protected", nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = req
+
+			AuthMiddleware()(c)
+
+			if allowed {
+				assert.False(t, c.IsAborted(), method)
+				assert.Equal(t, true, c.MustGet("read_only"))
+			} else {
+				assert.True(t, c.IsAborted(), method)
+				assert.Equal(t, http.StatusForbidden, c.Writer.Status(), method)
+			}
+		}
+	})
+
 	t.Run("case insensitive bearer", func(t *testing.T) {
 		userID := 1
 		username := "testuser"
-		token, err := GenerateToken(userID, username)
+		token, err := GenerateToken(context.Background(), userID, username)
 		assert.NoError(t, err)
 
 		req, _ := http.NewRequest(http.MethodGet, "/protected", nil)
@@ -281,7 +309,7 @@ func TestTokenExpirationTime(t *testing.T) {
 		username := "testuser"
 
 		beforeGeneration := time.Now()
-		token, err := GenerateToken(userID, username)
+		token, err := GenerateToken(context.Background(), userID, username)
 		afterGeneration := time.Now()
 
 		assert.NoError(t, err)
@@ -310,7 +338,7 @@ func TestClaimsValidation(t *testing.T) {
 		userID := 42
 		username := "validuser"
 
-		token, err := GenerateToken(userID, username)
+		token, err := GenerateToken(context.Background(), userID, username)
 		assert.NoError(t, err)
 
 		claims, err := ValidateToken(token)
@@ -0,0 +1,75 @@
+package middleware
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// BodyLimit rejects request bodies larger than limit with a 413 before any handler reads them.
+// routes raises the limit of individual routes, by their full path such as /api/import/bookmarks.
+// A body sent without Content-Length is read up to the limit first, so handlers never see more.
+func BodyLimit(limit int64, routes map[string]int64) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.Body == nil || c.Request.Body == http.NoBody {
+			c.Next()
+			return
+		}
+
+		max := limit
+		if routeLimit, ok := routes[c.FullPath()]; ok {
+			max = routeLimit
+		}
+
+		if c.Request.ContentLength > max {
+			tooLarge(c, max)
+			return
+		}
+		if c.Request.ContentLength < 0 {
+			body, err := io.ReadAll(io.LimitReader(c.Request.Body, max+1))
+			c.Request.Body.Close()
+			if err != nil {
+				c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+					"error":   "Could not read the request body",
+					"details": err.Error(),
+				})
+				return
+			}
+			if int64(len(body)) > max {
+				tooLarge(c, max)
+				return
+			}
+			c.Request.Body = io.NopCloser(bytes.NewReader(body))
+			c.Request.ContentLength = int64(len(body))
+		}
+
+		// A client sending more than its Content-Length is cut off at the limit as well
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, max)
+		c.Next()
+	}
+}
+
+// tooLarge answers 413 with the limit the body exceeded
+func tooLarge(c *gin.Context, max int64) {
+	// The rest of the body is not read, so the connection cannot be reused
+	c.Header("Connection", "close")
+	c.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, gin.H{
+		"error":       fmt.Sprintf("Request body is larger than the %s allowed here", formatSize(max)),
+		"limit_bytes": max,
+	})
+}
+
+// formatSize writes a byte count in the largest whole unit
+func formatSize(n int64) string {
+	switch {
+	case n >= 1<<20 && n%(1<<20) == 0:
+		return fmt.Sprintf("%d MB", n>>20)
+	case n >= 1<<10 && n%(1<<10) == 0:
+		return fmt.Sprintf("%d KB", n>>10)
+	default:
+		return fmt.Sprintf("%d bytes", n)
+	}
+}
@@ -0,0 +1,71 @@
+package middleware
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBodyLimit(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(BodyLimit(10, map[string]int64{"/import": 20}))
+	echo := func(c *gin.Context) {
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			c.String(http.StatusBadRequest, err.Error())
+			return
+		}
+		c.String(http.StatusOK, string(body))
+	}
+	router.POST("/json", echo)
+	router.POST("/import", echo)
+
+	request := func(path, body string, chunked bool) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+		if chunked {
+			req.ContentLength = -1
+		}
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("bodies within the limit pass", func(t *testing.T) {
+		w := request("/json", "0123456789", false)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "0123456789", w.Body.String())
+	})
+
+	t.Run("larger bodies get a 413", func(t *testing.T) {
+		w := request("/json", "0123456789a", false)
+		assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+
+		var body map[string]interface{}
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		assert.Equal(t, "Request body is larger than the 10 bytes allowed here", body["error"])
+		assert.Equal(t, float64(10), body["limit_bytes"])
+	})
+
+	t.Run("bodies without a length are measured", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, request("/json", "0123456789", true).Code)
+		assert.Equal(t, http.StatusRequestEntityTooLarge, request("/json", "0123456789a", true).Code)
+	})
+
+	t.Run("routes may allow more", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, request("/import", strings.Repeat("x", 20), false).Code)
+		assert.Equal(t, http.StatusRequestEntityTooLarge, request("/import", strings.Repeat("x", 21), true).Code)
+	})
+}
+
+func TestFormatSize(t *testing.T) {
+	assert.Equal(t, "1 MB", formatSize(1<<20))
+	assert.Equal(t, "512 KB", formatSize(512<<10))
+	assert.Equal(t, "1500 bytes", formatSize(1500))
+}
@@ -0,0 +1,69 @@
+package middleware
+
+import (
+	"bytes"
+	"net/http"
+	"time"
+
+	"sykell-analyze/backend/cache"
+
+	"github.com/gin-gonic/gin"
+)
+
+// cachingWriter captures the response body so it can be stored after the handler runs
+type cachingWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *cachingWriter) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+// CacheResponse serves successful GET responses from the per-user Redis cache
+func CacheResponse(ttl time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, ok := c.Get("user_id")
+		if !cache.Enabled() || !ok || c.Request.Method != http.MethodGet {
+			c.Next()
+			return
+		}
+
+		key, err := cache.UserKey(c.Request.Context(), userID.(int), c.Request.URL.RequestURI())
+		if err != nil {
+			c.Next()
+			return
+		}
+
+		if body, hit := cache.Get(c.Request.Context(), key); hit {
+			c.Header("X-Cache", "HIT")
+			c.Data(http.StatusOK, "application/json; charset=utf-8", body)
+			c.Abort()
+			return
+		}
+
+		writer := &cachingWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+		c.Header("X-Cache", "MISS")
+		c.Next()
+
+		if writer.Status() == http.StatusOK {
+			cache.Set(c.Request.Context(), key, writer.body.Bytes(), ttl)
+		}
+	}
+}
+
+// InvalidateCacheOnWrite drops the user's cached responses after any successful write request
+func InvalidateCacheOnWrite() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		if c.Request.Method == http.MethodGet || c.Request.Method == http.MethodHead {
+			return
+		}
+		if userID, ok := c.Get("user_id"); ok && c.Writer.Status() < http.StatusBadRequest {
+			cache.InvalidateUser(c.Request.Context(), userID.(int))
+		}
+	}
+}
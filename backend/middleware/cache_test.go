@@ -0,0 +1,109 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"sykell-analyze/backend/cache"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCacheResponseDisabled(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	calls := 0
+	router := gin.New()
+	router.GET("/urls", func(c *gin.Context) {
+		c.Set("user_id", 1)
+		c.Next()
+	}, CacheResponse(time.Minute), func(c *gin.Context) {
+		calls++
+		c.JSON(http.StatusOK, gin.H{"data": []string{}})
+	})
+
+	for i := 0; i < 2; i++ {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/urls", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Empty(t, w.Header().Get("X-Cache"))
+	}
+
+	// Without REDIS_URL every request reaches the handler
+	assert.Equal(t, 2, calls)
+}
+
+func TestInvalidateCacheOnWriteDisabled(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set("user_id", 1)
+		c.Next()
+	}, InvalidateCacheOnWrite())
+	router.POST("/urls", func(c *gin.Context) {
+		c.JSON(http.StatusCreated, gin.H{"message": "ok"})
+	})
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPost, "/urls", nil)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+}
+
+func TestCacheResponseWithRedis(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	server := miniredis.RunT(t)
+	cache.Client = redis.NewClient(&redis.Options{Addr: server.Addr()})
+	defer func() { cache.Client = nil }()
+
+	calls := 0
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set("user_id", 7)
+		c.Next()
+	}, InvalidateCacheOnWrite())
+	router.GET("/urls", CacheResponse(time.Minute), func(c *gin.Context) {
+		calls++
+		c.JSON(http.StatusOK, gin.H{"calls": calls})
+	})
+	router.DELETE("/urls/:id", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"message": "deleted"})
+	})
+
+	get := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, path, nil)
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	first := get("/urls?page=1")
+	assert.Equal(t, "MISS", first.Header().Get("X-Cache"))
+
+	second := get("/urls?page=1")
+	assert.Equal(t, "HIT", second.Header().Get("X-Cache"))
+	assert.Equal(t, first.Body.String(), second.Body.String())
+	assert.Equal(t, 1, calls)
+
+	// A different query string is cached separately
+	assert.Equal(t, "MISS", get("/urls?page=2").Header().Get("X-Cache"))
+
+	// Writes invalidate every cached response of the user
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodDelete, "/urls/1", nil)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	assert.Equal(t, "MISS", get("/urls?page=1").Header().Get("X-Cache"))
+	assert.Equal(t, 3, calls)
+}
@@ -0,0 +1,14 @@
+package middleware
+
+import (
+	"slices"
+
+	"sykell-analyze/backend/config"
+)
+
+// AllowedOrigin reports whether a browser origin may call the API. It reads cors.allow_origins on
+// every request, so a configuration reload applies without a restart; "*" allows every origin.
+func AllowedOrigin(origin string) bool {
+	origins := config.App.CORS.AllowOrigins
+	return slices.Contains(origins, "*") || slices.Contains(origins, origin)
+}
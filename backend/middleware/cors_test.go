@@ -0,0 +1,28 @@
+package middleware
+
+import (
+	"testing"
+
+	"sykell-analyze/backend/config"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAllowedOrigin(t *testing.T) {
+	previous := config.App
+	t.Cleanup(func() { config.App = previous })
+	cfg := *config.App
+	cfg.CORS.AllowOrigins = []string{"https://app.example.com"}
+	config.App = &cfg
+
+	assert.True(t, AllowedOrigin("https://app.example.com"))
+	assert.False(t, AllowedOrigin("https://evil.example.com"))
+
+	t.Run("follows reloaded settings", func(t *testing.T) {
+		reloaded := cfg
+		reloaded.CORS.AllowOrigins = []string{"*"}
+		config.App = &reloaded
+
+		assert.True(t, AllowedOrigin("https://evil.example.com"))
+	})
+}
@@ -0,0 +1,138 @@
+package middleware
+
+import (
+	"bytes"
+	"compress/gzip"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// compressibleTypes lists the content types worth compressing; images and archives already are
+var compressibleTypes = []string{
+	"application/json",
+	"application/javascript",
+	"application/xml",
+	"image/svg+xml",
+	"text/",
+}
+
+// gzipWriter holds back the first minSize bytes so small responses can be sent uncompressed
+type gzipWriter struct {
+	gin.ResponseWriter
+	minSize int
+	buf     bytes.Buffer
+	gz      *gzip.Writer
+	decided bool
+}
+
+func (w *gzipWriter) Write(data []byte) (int, error) {
+	if w.gz != nil {
+		return w.gz.Write(data)
+	}
+	if w.decided {
+		return w.ResponseWriter.Write(data)
+	}
+
+	w.buf.Write(data)
+	if w.buf.Len() >= w.minSize {
+		if err := w.decide(true); err != nil {
+			return 0, err
+		}
+	}
+	return len(data), nil
+}
+
+func (w *gzipWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+// Flush sends whatever is buffered so streaming responses are not held back
+func (w *gzipWriter) Flush() {
+	if !w.decided {
+		w.decide(false)
+	}
+	if w.gz != nil {
+		w.gz.Flush()
+	}
+	w.ResponseWriter.Flush()
+}
+
+// decide picks compressed or plain output and writes out the buffered bytes
+func (w *gzipWriter) decide(bigEnough bool) error {
+	w.decided = true
+
+	if bigEnough && w.shouldCompress() {
+		header := w.Header()
+		header.Set("Content-Encoding", "gzip")
+		header.Add("Vary", "Accept-Encoding")
+		header.Del("Content-Length")
+		w.gz = gzip.NewWriter(w.ResponseWriter)
+		_, err := w.gz.Write(w.buf.Bytes())
+		w.buf.Reset()
+		return err
+	}
+
+	if w.buf.Len() == 0 {
+		return nil
+	}
+	_, err := w.ResponseWriter.Write(w.buf.Bytes())
+	w.buf.Reset()
+	return err
+}
+
+// shouldCompress checks the status and headers set by the handler
+func (w *gzipWriter) shouldCompress() bool {
+	status := w.Status()
+	if status < http.StatusOK || status == http.StatusNoContent || status == http.StatusNotModified {
+		return false
+	}
+	if w.Header().Get("Content-Encoding") != "" {
+		return false
+	}
+	// Byte ranges refer to the uncompressed file; compressing them would corrupt resumed downloads
+	if status == http.StatusPartialContent || w.Header().Get("Content-Range") != "" || w.Header().Get("Accept-Ranges") != "" {
+		return false
+	}
+
+	contentType := strings.ToLower(w.Header().Get("Content-Type"))
+	if strings.HasPrefix(contentType, "text/event-stream") {
+		return false
+	}
+	for _, t := range compressibleTypes {
+		if strings.HasPrefix(contentType, t) {
+			return true
+		}
+	}
+	return false
+}
+
+// close flushes the remaining buffer and terminates the gzip stream
+func (w *gzipWriter) close() {
+	if !w.decided {
+		w.decide(false)
+	}
+	if w.gz != nil {
+		w.gz.Close()
+	}
+}
+
+// Gzip compresses responses of compressible content types once they reach minSize bytes. Responses
+// that answer or accept Range requests are sent as-is.
+func Gzip(minSize int) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.Method == http.MethodHead ||
+			!strings.Contains(c.GetHeader("Accept-Encoding"), "gzip") ||
+			strings.EqualFold(c.GetHeader("Upgrade"), "websocket") {
+			c.Next()
+			return
+		}
+
+		writer := &gzipWriter{ResponseWriter: c.Writer, minSize: minSize}
+		c.Writer = writer
+		defer writer.close()
+
+		c.Next()
+	}
+}
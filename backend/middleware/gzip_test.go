@@ -0,0 +1,103 @@
+package middleware
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func setupGzipRouter(minSize int) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(Gzip(minSize))
+	router.GET("/large", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"data": strings.Repeat("sykell ", 500)})
+	})
+	router.GET("/small", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"status": "ok"})
+	})
+	router.GET("/binary", func(c *gin.Context) {
+		c.Data(http.StatusOK, "image/png", make([]byte, 4096))
+	})
+	router.GET("/stream", func(c *gin.Context) {
+		c.Header("Content-Type", "text/plain")
+		c.Writer.Write([]byte(strings.Repeat("a", 600)))
+		c.Writer.Write([]byte(strings.Repeat("b", 600)))
+	})
+	router.GET("/download.csv", func(c *gin.Context) {
+		c.Header("Content-Type", "text/csv")
+		http.ServeContent(c.Writer, c.Request, "download.csv", time.Time{}, strings.NewReader(strings.Repeat("a,b\n", 1000)))
+	})
+	return router
+}
+
+func TestGzip(t *testing.T) {
+	router := setupGzipRouter(1024)
+
+	request := func(path string, acceptGzip bool) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, path, nil)
+		if acceptGzip {
+			req.Header.Set("Accept-Encoding", "gzip, deflate")
+		}
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("large JSON is compressed", func(t *testing.T) {
+		w := request("/large", true)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "gzip", w.Header().Get("Content-Encoding"))
+		assert.Contains(t, w.Header().Get("Vary"), "Accept-Encoding")
+
+		reader, err := gzip.NewReader(w.Body)
+		assert.NoError(t, err)
+		body, _ := io.ReadAll(reader)
+		assert.Contains(t, string(body), "sykell sykell")
+	})
+
+	t.Run("small response is sent as-is", func(t *testing.T) {
+		w := request("/small", true)
+
+		assert.Empty(t, w.Header().Get("Content-Encoding"))
+		assert.JSONEq(t, `{"status":"ok"}`, w.Body.String())
+	})
+
+	t.Run("client without gzip support", func(t *testing.T) {
+		w := request("/large", false)
+
+		assert.Empty(t, w.Header().Get("Content-Encoding"))
+		assert.Contains(t, w.Body.String(), "sykell sykell")
+	})
+
+	t.Run("incompressible content type", func(t *testing.T) {
+		w := request("/binary", true)
+
+		assert.Empty(t, w.Header().Get("Content-Encoding"))
+		assert.Equal(t, 4096, w.Body.Len())
+	})
+
+	t.Run("multiple writes crossing the threshold", func(t *testing.T) {
+		w := request("/stream", true)
+
+		assert.Equal(t, "gzip", w.Header().Get("Content-Encoding"))
+		reader, err := gzip.NewReader(w.Body)
+		assert.NoError(t, err)
+		body, _ := io.ReadAll(reader)
+		assert.Equal(t, strings.Repeat("a", 600)+strings.Repeat("b", 600), string(body))
+	})
+	t.Run("ranged downloads are sent as-is", func(t *testing.T) {
+		w := request("/download.csv", true)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Empty(t, w.Header().Get("Content-Encoding"))
+		assert.Equal(t, 4000, w.Body.Len())
+	})
+}
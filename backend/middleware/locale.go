@@ -0,0 +1,35 @@
+package middleware
+
+import (
+	"sykell-analyze/backend/i18n"
+
+	"github.com/gin-gonic/gin"
+)
+
+// LangKey is the context key under which Locale stores the response language
+const LangKey = "lang"
+
+// Locale picks the response language from Accept-Language, stores it under LangKey for handlers
+// and translates the "error" field of JSON error bodies
+func Locale() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		lang := i18n.Match(c.GetHeader("Accept-Language"))
+		c.Set(LangKey, lang)
+		c.Header("Content-Language", lang)
+		c.Writer.Header().Add("Vary", "Accept-Language")
+		if lang == i18n.Default {
+			c.Next()
+			return
+		}
+
+		writer := &errorBodyWriter{ResponseWriter: c.Writer, edit: func(payload map[string]interface{}) {
+			if message, ok := payload["error"].(string); ok {
+				payload["error"] = i18n.T(lang, message)
+			}
+		}}
+		c.Writer = writer
+		defer writer.flush()
+
+		c.Next()
+	}
+}
@@ -0,0 +1,59 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLocale(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(VersionHeader())
+	router.Use(Locale())
+	router.GET("/ok", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"lang": c.GetString(LangKey)})
+	})
+	router.GET("/error", func(c *gin.Context) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "URL not found"})
+	})
+
+	request := func(path, acceptLanguage string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("Accept-Language", acceptLanguage)
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("stores the matched language", func(t *testing.T) {
+		w := request("/ok", "fr-FR,fr;q=0.9")
+
+		assert.Equal(t, "fr", w.Header().Get("Content-Language"))
+		assert.Equal(t, "Accept-Language", w.Header().Get("Vary"))
+		assert.JSONEq(t, `{"lang":"fr"}`, w.Body.String())
+	})
+
+	t.Run("translates JSON errors", func(t *testing.T) {
+		w := request("/error", "de")
+
+		var body map[string]string
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.Equal(t, "URL nicht gefunden", body["error"])
+		assert.NotEmpty(t, body["version"])
+	})
+
+	t.Run("English without a supported language", func(t *testing.T) {
+		w := request("/error", "ja")
+
+		var body map[string]string
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		assert.Equal(t, "en", w.Header().Get("Content-Language"))
+		assert.Equal(t, "URL not found", body["error"])
+	})
+}
@@ -0,0 +1,25 @@
+package middleware
+
+import (
+	"net/http"
+
+	"sykell-analyze/backend/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ReadYourWrites keeps the user's reads on the primary database for a moment after any successful
+// write request, so a URL they just added or deleted never looks missing or back from a replica
+// that has not caught up
+func ReadYourWrites() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		if isReadMethod(c.Request.Method) {
+			return
+		}
+		if userID, ok := c.Get("user_id"); ok && c.Writer.Status() < http.StatusBadRequest {
+			config.NoteWrite(userID)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package middleware
+
+import (
+	"slices"
+	"strings"
+)
+
+// Scopes a service account token can be granted
+const (
+	ScopeURLsRead  = "urls:read"
+	ScopeURLsWrite = "urls:write"
+	ScopeStatsRead = "stats:read"
+)
+
+// Scopes lists every scope, in the order they are documented
+var Scopes = []string{ScopeURLsRead, ScopeURLsWrite, ScopeStatsRead}
+
+// ValidScope reports whether scope is one of Scopes
+func ValidScope(scope string) bool {
+	return slices.Contains(Scopes, scope)
+}
+
+// scopedRoutes maps route prefixes to the scopes reading and changing them need. Routes not listed,
+// such as the profile, token management and operator endpoints, need a user's own token.
+var scopedRoutes = []struct {
+	prefix      string
+	read, write string
+}{
+	{prefix: "/api/urls", read: ScopeURLsRead, write: ScopeURLsWrite},
+	{prefix: "/api/queue", read: ScopeURLsRead, write: ScopeURLsWrite},
+	{prefix: "/api/broken-links", read: ScopeURLsRead},
+	{prefix: "/api/views", read: ScopeURLsRead, write: ScopeURLsWrite},
+	{prefix: "/api/export", read: ScopeURLsRead},
+	{prefix: "/api/analyze", write: ScopeURLsWrite},
+	{prefix: "/api/import", write: ScopeURLsWrite},
+	{prefix: "/api/stats", read: ScopeStatsRead},
+}
+
+// requiredScope returns the scope a service account token needs for the route, or "" when service
+// account tokens may not use it. route is the matched route pattern, such as /api/urls/:id.
+func requiredScope(method, route string) string {
+	for _, r := range scopedRoutes {
+		if route != r.prefix && !strings.HasPrefix(route, r.prefix+"/") {
+			continue
+		}
+		if isReadMethod(method) {
+			return r.read
+		}
+		return r.write
+	}
+	return ""
+}
+
+// scopeAllowed reports whether a service account token with the granted scopes may call the route
+func scopeAllowed(granted []string, method, route string) bool {
+	scope := requiredScope(method, route)
+	return scope != "" && slices.Contains(granted, scope)
+}
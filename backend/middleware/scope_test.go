@@ -0,0 +1,82 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequiredScope(t *testing.T) {
+	tests := []struct {
+		method, route, scope string
+	}{
+		{http.MethodGet, "/api/urls", ScopeURLsRead},
+		{http.MethodGet, "/api/urls/:id/logs", ScopeURLsRead},
+		{http.MethodPost, "/api/urls", ScopeURLsWrite},
+		{http.MethodDelete, "/api/queue/:job_id", ScopeURLsWrite},
+		{http.MethodPost, "/api/analyze", ScopeURLsWrite},
+		{http.MethodGet, "/api/stats/domains", ScopeStatsRead},
+		{http.MethodGet, "/api/export", ScopeURLsRead},
+		{http.MethodGet, "/api/urlshortener", ""},
+		{http.MethodGet, "/api/profile", ""},
+		{http.MethodPost, "/api/profile/tokens", ""},
+		{http.MethodPost, "/api/auth/refresh", ""},
+		{http.MethodPut, "/api/admin/users/:id/retention", ""},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.scope, requiredScope(tt.method, tt.route), tt.method+" "+tt.route)
+	}
+}
+
+func TestServiceTokenScopes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(AuthMiddleware())
+	router.GET("/api/urls/:id", func(c *gin.Context) { c.Status(http.StatusOK) })
+	router.GET("/api/profile", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	request := func(path, token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	token, err := GenerateServiceToken(context.Background(), 1, "testuser", 7, []string{ScopeStatsRead}, nil)
+	require.NoError(t, err)
+
+	t.Run("scopes are carried in the token", func(t *testing.T) {
+		claims, err := ValidateToken(token)
+		require.NoError(t, err)
+		assert.Equal(t, 7, claims.ServiceTokenID)
+		assert.Equal(t, []string{ScopeStatsRead}, claims.Scopes)
+		assert.Nil(t, claims.ExpiresAt)
+	})
+
+	t.Run("routes outside the scopes are forbidden", func(t *testing.T) {
+		w := request("/api/urls/1", token)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.Contains(t, w.Body.String(), "scopes")
+	})
+
+	t.Run("routes without a scope are forbidden", func(t *testing.T) {
+		assert.Equal(t, http.StatusForbidden, request("/api/profile", token).Code)
+	})
+
+	t.Run("optional authentication ignores service tokens", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = req
+
+		OptionalAuthMiddleware()(c)
+		_, exists := c.Get("user_id")
+		assert.False(t, exists)
+	})
+}
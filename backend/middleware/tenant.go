@@ -0,0 +1,78 @@
+package middleware
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+
+	"sykell-analyze/backend/config"
+	"sykell-analyze/backend/tenancy"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TenantKey is the context key under which Tenant stores the name of the request's tenant
+const TenantKey = "tenant"
+
+// Tenant selects the database of the tenant a request asks for with the tenancy.header header or a
+// subdomain of tenancy.base_domain, looking it up with lookup. Handlers reach it through the request
+// context (config.DBFor); requests naming no tenant use the primary database. Does nothing unless
+// tenancy is enabled.
+func Tenant(lookup func(name string) (*config.Tenant, error)) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		settings := config.App.Tenancy
+		if !settings.Enabled {
+			c.Next()
+			return
+		}
+		name := requestTenant(c.Request, settings)
+		if name == "" {
+			c.Next()
+			return
+		}
+
+		t, err := lookup(name)
+		if errors.Is(err, tenancy.ErrUnknown) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Unknown tenant",
+			})
+			c.Abort()
+			return
+		} else if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Database error",
+			})
+			c.Abort()
+			return
+		}
+
+		c.Request = c.Request.WithContext(config.WithTenant(c.Request.Context(), t))
+		c.Set(TenantKey, t.Name)
+		c.Next()
+	}
+}
+
+// requestTenant returns the tenant named by the request's header, else by the subdomain of its host
+// under the base domain; "" means none. www is the primary site.
+func requestTenant(r *http.Request, settings config.TenancyConfig) string {
+	if settings.Header != "" {
+		if name := strings.TrimSpace(r.Header.Get(settings.Header)); name != "" {
+			return strings.ToLower(name)
+		}
+	}
+	if settings.BaseDomain == "" {
+		return ""
+	}
+	host := r.Host
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	base := strings.ToLower(strings.Trim(settings.BaseDomain, "."))
+	sub, ok := strings.CutSuffix(host, "."+base)
+	if !ok || sub == "www" || strings.Contains(sub, ".") {
+		return ""
+	}
+	return sub
+}
@@ -0,0 +1,154 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"sykell-analyze/backend/config"
+	"sykell-analyze/backend/tenancy"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// enableTenancy turns tenancy on for the rest of the test, selecting tenants by header and by
+// subdomain of analyzer.example.com
+func enableTenancy(t *testing.T) {
+	previous := config.App
+	t.Cleanup(func() { config.App = previous })
+	cfg := *config.App
+	cfg.Tenancy.Enabled = true
+	cfg.Tenancy.BaseDomain = "analyzer.example.com"
+	config.App = &cfg
+}
+
+func TestRequestTenant(t *testing.T) {
+	settings := config.Default().Tenancy
+	settings.BaseDomain = "analyzer.example.com"
+
+	tests := []struct {
+		name, host, header, want string
+	}{
+		{"subdomain", "acme.analyzer.example.com", "", "acme"},
+		{"subdomain with a port", "acme.analyzer.example.com:8080", "", "acme"},
+		{"subdomain in upper case", "ACME.Analyzer.Example.com.", "", "acme"},
+		{"header wins over the subdomain", "acme.analyzer.example.com", " Globex ", "globex"},
+		{"base domain", "analyzer.example.com", "", ""},
+		{"www", "www.analyzer.example.com", "", ""},
+		{"nested subdomain", "a.acme.analyzer.example.com", "", ""},
+		{"other domain", "acme.example.org", "", ""},
+		{"lookalike domain", "acmeanalyzer.example.com", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/urls", nil)
+			req.Host = tt.host
+			if tt.header != "" {
+				req.Header.Set("X-Tenant", tt.header)
+			}
+			assert.Equal(t, tt.want, requestTenant(req, settings))
+		})
+	}
+
+	t.Run("without a base domain only the header counts", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/urls", nil)
+		req.Host = "acme.analyzer.example.com"
+		assert.Equal(t, "", requestTenant(req, config.Default().Tenancy))
+	})
+}
+
+func TestTenant(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	acme := &config.Tenant{Name: "acme", Database: "sykell_acme"}
+	lookup := func(name string) (*config.Tenant, error) {
+		switch name {
+		case "acme":
+			return acme, nil
+		case "broken":
+			return nil, errors.New("connection refused")
+		}
+		return nil, tenancy.ErrUnknown
+	}
+
+	router := gin.New()
+	router.Use(Tenant(lookup))
+	router.GET("/tenant", func(c *gin.Context) {
+		c.String(http.StatusOK, config.TenantName(c.Request.Context()))
+	})
+	request := func(host string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/tenant", nil)
+		req.Host = host
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("disabled", func(t *testing.T) {
+		w := request("acme.analyzer.example.com")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Empty(t, w.Body.String())
+	})
+
+	enableTenancy(t)
+
+	t.Run("tenant is set on the request context", func(t *testing.T) {
+		w := request("acme.analyzer.example.com")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "acme", w.Body.String())
+	})
+
+	t.Run("primary site", func(t *testing.T) {
+		w := request("analyzer.example.com")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Empty(t, w.Body.String())
+	})
+
+	t.Run("unknown tenant", func(t *testing.T) {
+		w := request("initech.analyzer.example.com")
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.Contains(t, w.Body.String(), "Unknown tenant")
+	})
+
+	t.Run("lookup failure", func(t *testing.T) {
+		w := request("broken.analyzer.example.com")
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+	})
+}
+
+func TestAuthMiddlewareTenant(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	enableTenancy(t)
+	acme := &config.Tenant{Name: "acme", Database: "sykell_acme"}
+	lookup := func(name string) (*config.Tenant, error) {
+		if name == "acme" {
+			return acme, nil
+		}
+		return nil, tenancy.ErrUnknown
+	}
+
+	router := gin.New()
+	router.Use(Tenant(lookup), AuthMiddleware())
+	router.GET("/api/profile", func(c *gin.Context) { c.Status(http.StatusOK) })
+	request := func(host, token string) int {
+		req := httptest.NewRequest(http.MethodGet, "/api/profile", nil)
+		req.Host = host
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	primaryToken, err := GenerateToken(context.Background(), 1, "testuser")
+	require.NoError(t, err)
+	acmeToken, err := GenerateToken(config.WithTenant(context.Background(), acme), 1, "testuser")
+	require.NoError(t, err)
+
+	assert.Equal(t, http.StatusOK, request("acme.analyzer.example.com", acmeToken))
+	assert.Equal(t, http.StatusOK, request("analyzer.example.com", primaryToken))
+	assert.Equal(t, http.StatusUnauthorized, request("acme.analyzer.example.com", primaryToken))
+	assert.Equal(t, http.StatusUnauthorized, request("analyzer.example.com", acmeToken))
+}
@@ -0,0 +1,142 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"sykell-analyze/backend/version"
+)
+
+// timeoutWriter buffers the handler's response so it can be discarded if the deadline passes first
+type timeoutWriter struct {
+	mu          sync.Mutex
+	header      http.Header
+	buf         bytes.Buffer
+	status      int
+	wroteHeader bool
+	timedOut    bool
+}
+
+func (w *timeoutWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *timeoutWriter) WriteHeader(status int) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.timedOut || w.wroteHeader {
+		return
+	}
+	w.wroteHeader = true
+	w.status = status
+}
+
+func (w *timeoutWriter) Write(data []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.timedOut {
+		return 0, http.ErrHandlerTimeout
+	}
+	if !w.wroteHeader {
+		w.wroteHeader = true
+		w.status = http.StatusOK
+	}
+	return w.buf.Write(data)
+}
+
+// Flush is a no-op: the whole response is sent once the handler returns
+func (w *timeoutWriter) Flush() {}
+
+// Timeout gives every request a server-side deadline. Handlers run with a context that is cancelled
+// at the deadline, and if they have not finished by then the client gets a 504 with the request ID
+// while the late response is discarded. Streaming and WebSocket requests, and requests for paths
+// under one of the exempt prefixes (file downloads), are passed through untouched and unbuffered.
+// A timeout of 0 disables the deadline.
+func Timeout(next http.Handler, timeout time.Duration, exempt ...string) http.Handler {
+	if timeout <= 0 {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.EqualFold(r.Header.Get("Upgrade"), "websocket") ||
+			strings.Contains(r.Header.Get("Accept"), "text/event-stream") ||
+			hasAnyPrefix(r.URL.Path, exempt) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		requestID := ensureRequestID(r)
+
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+
+		tw := &timeoutWriter{header: make(http.Header)}
+		done := make(chan struct{})
+		panicked := make(chan interface{}, 1)
+
+		go func() {
+			defer func() {
+				if p := recover(); p != nil {
+					panicked <- p
+				}
+			}()
+			next.ServeHTTP(tw, r.WithContext(ctx))
+			close(done)
+		}()
+
+		select {
+		case p := <-panicked:
+			panic(p)
+		case <-done:
+			tw.mu.Lock()
+			defer tw.mu.Unlock()
+			for key, values := range tw.header {
+				w.Header()[key] = values
+			}
+			if !tw.wroteHeader {
+				tw.status = http.StatusOK
+			}
+			w.WriteHeader(tw.status)
+			w.Write(tw.buf.Bytes())
+		case <-ctx.Done():
+			tw.mu.Lock()
+			tw.timedOut = true
+			tw.mu.Unlock()
+
+			// The client went away; there is nobody to answer
+			if errors.Is(ctx.Err(), context.Canceled) {
+				return
+			}
+
+			fmt.Printf("DEBUG: Request %s %s (%s) exceeded the %s deadline\n", r.Method, r.URL.Path, requestID, timeout)
+
+			body, _ := json.Marshal(map[string]string{
+				"error":      "Request timed out",
+				"request_id": requestID,
+				"version":    version.Version,
+			})
+			w.Header().Set("Content-Type", "application/json; charset=utf-8")
+			w.Header().Set(RequestIDHeader, requestID)
+			w.Header().Set("X-App-Version", version.Version)
+			w.WriteHeader(http.StatusGatewayTimeout)
+			w.Write(body)
+		}
+	})
+}
+
+// hasAnyPrefix reports whether path starts with one of prefixes
+func hasAnyPrefix(path string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,116 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestID(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(RequestID())
+	router.GET("/id", func(c *gin.Context) {
+		c.String(http.StatusOK, c.GetString("request_id"))
+	})
+
+	t.Run("generates an ID", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/id", nil)
+		router.ServeHTTP(w, req)
+
+		id := w.Header().Get(RequestIDHeader)
+		assert.Len(t, id, 32)
+		assert.Equal(t, id, w.Body.String())
+	})
+
+	t.Run("keeps a valid client ID", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/id", nil)
+		req.Header.Set(RequestIDHeader, "client-abc_123")
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, "client-abc_123", w.Header().Get(RequestIDHeader))
+	})
+
+	t.Run("replaces an unsafe client ID", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/id", nil)
+		req.Header.Set(RequestIDHeader, "bad id\nwith newline")
+		router.ServeHTTP(w, req)
+
+		assert.NotEqual(t, "bad id\nwith newline", w.Header().Get(RequestIDHeader))
+		assert.Len(t, w.Header().Get(RequestIDHeader), 32)
+	})
+}
+
+func TestTimeout(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(RequestID())
+	router.GET("/fast", func(c *gin.Context) {
+		c.Header("X-Custom", "yes")
+		c.JSON(http.StatusCreated, gin.H{"ok": true})
+	})
+	router.GET("/slow", func(c *gin.Context) {
+		select {
+		case <-c.Request.Context().Done():
+		case <-time.After(time.Second):
+		}
+		c.JSON(http.StatusOK, gin.H{"late": true})
+	})
+
+	router.GET("/downloads/slow.csv", func(c *gin.Context) {
+		c.Writer.WriteString("id,url\n")
+		c.Writer.Flush()
+		time.Sleep(100 * time.Millisecond)
+		c.Writer.WriteString("1,https://example.com\n")
+	})
+
+	handler := Timeout(router, 50*time.Millisecond, "/downloads/")
+
+	t.Run("fast handlers respond normally", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/fast", nil)
+		handler.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+		assert.Equal(t, "yes", w.Header().Get("X-Custom"))
+		assert.JSONEq(t, `{"ok":true}`, w.Body.String())
+	})
+
+	t.Run("slow handlers get a 504 with the request ID", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/slow", nil)
+		req.Header.Set(RequestIDHeader, "req-42")
+		handler.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusGatewayTimeout, w.Code)
+		assert.Equal(t, "req-42", w.Header().Get(RequestIDHeader))
+
+		var body map[string]string
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		assert.Equal(t, "req-42", body["request_id"])
+		assert.Equal(t, "Request timed out", body["error"])
+	})
+
+	t.Run("exempt paths are streamed past the deadline", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/downloads/slow.csv", nil)
+		handler.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.True(t, w.Flushed)
+		assert.Equal(t, "id,url\n1,https://example.com\n", w.Body.String())
+	})
+
+	t.Run("zero disables the deadline", func(t *testing.T) {
+		assert.Equal(t, http.Handler(router), Timeout(router, 0))
+	})
+}
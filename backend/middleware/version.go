@@ -0,0 +1,70 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"sykell-analyze/backend/version"
+
+	"github.com/gin-gonic/gin"
+)
+
+// errorBodyWriter holds back JSON error bodies so fields can be added to or changed in them
+type errorBodyWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+	// edit changes the decoded error body before it is written
+	edit func(payload map[string]interface{})
+}
+
+func (w *errorBodyWriter) isJSONError() bool {
+	return w.Status() >= http.StatusBadRequest &&
+		strings.HasPrefix(w.Header().Get("Content-Type"), "application/json")
+}
+
+func (w *errorBodyWriter) Write(data []byte) (int, error) {
+	if w.isJSONError() {
+		return w.body.Write(data)
+	}
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *errorBodyWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+// flush writes the held-back error body after passing it through edit
+func (w *errorBodyWriter) flush() {
+	if w.body.Len() == 0 {
+		return
+	}
+
+	body := w.body.Bytes()
+	var payload map[string]interface{}
+	if err := json.Unmarshal(body, &payload); err == nil && payload != nil {
+		w.edit(payload)
+		if edited, err := json.Marshal(payload); err == nil {
+			body = edited
+		}
+	}
+	w.ResponseWriter.Write(body)
+}
+
+// VersionHeader sets X-App-Version on every response and adds the version to JSON error bodies
+func VersionHeader() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Header("X-App-Version", version.Version)
+
+		writer := &errorBodyWriter{ResponseWriter: c.Writer, edit: func(payload map[string]interface{}) {
+			if _, ok := payload["version"]; !ok {
+				payload["version"] = version.Version
+			}
+		}}
+		c.Writer = writer
+		defer writer.flush()
+
+		c.Next()
+	}
+}
@@ -0,0 +1,58 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"sykell-analyze/backend/version"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVersionHeader(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(VersionHeader())
+	router.GET("/ok", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"data": "value"})
+	})
+	router.GET("/error", func(c *gin.Context) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "URL not found"})
+	})
+	router.GET("/text-error", func(c *gin.Context) {
+		c.String(http.StatusBadRequest, "bad request")
+	})
+
+	request := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, path, nil)
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("success responses are untouched", func(t *testing.T) {
+		w := request("/ok")
+
+		assert.Equal(t, version.Version, w.Header().Get("X-App-Version"))
+		assert.JSONEq(t, `{"data":"value"}`, w.Body.String())
+	})
+
+	t.Run("JSON errors include the version", func(t *testing.T) {
+		w := request("/error")
+
+		var body map[string]string
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.Equal(t, "URL not found", body["error"])
+		assert.Equal(t, version.Version, body["version"])
+	})
+
+	t.Run("non-JSON errors pass through", func(t *testing.T) {
+		w := request("/text-error")
+
+		assert.Equal(t, "bad request", w.Body.String())
+	})
+}
@@ -0,0 +1,133 @@
+// Package migrations brings the schema of an existing database up to date. sql/init.sql always
+// holds the full schema for new databases; a migration repeats a change to it for databases created
+// before the change, so statements that find their table, column or index already there are skipped.
+package migrations
+
+import (
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+//go:embed sql/*.sql
+var files embed.FS
+
+// Migration is one file of sql/, applied once per database in the order of its name
+type Migration struct {
+	Name       string
+	Statements []string
+}
+
+// alreadyApplied lists the MySQL errors of a statement whose change the schema already has:
+// table exists, duplicate column, duplicate index and dropping a missing index or column
+var alreadyApplied = map[uint16]bool{1050: true, 1060: true, 1061: true, 1091: true}
+
+// Load returns the embedded migrations, oldest first
+func Load() ([]Migration, error) {
+	names, err := fs.Glob(files, "sql/*.sql")
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(names)
+
+	migrations := make([]Migration, 0, len(names))
+	for _, name := range names {
+		content, err := files.ReadFile(name)
+		if err != nil {
+			return nil, err
+		}
+		migrations = append(migrations, Migration{
+			Name:       strings.TrimSuffix(strings.TrimPrefix(name, "sql/"), ".sql"),
+			Statements: splitStatements(string(content)),
+		})
+	}
+	return migrations, nil
+}
+
+// splitStatements splits a migration into the statements the driver runs one at a time.
+// Statements end with a semicolon at the end of a line; -- comment lines are dropped.
+func splitStatements(content string) []string {
+	var statements []string
+	var current []string
+	for _, line := range strings.Split(content, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "--") {
+			continue
+		}
+		current = append(current, line)
+		if strings.HasSuffix(trimmed, ";") {
+			statement := strings.TrimSuffix(strings.TrimSpace(strings.Join(current, "\n")), ";")
+			statements = append(statements, statement)
+			current = nil
+		}
+	}
+	if len(current) > 0 {
+		statements = append(statements, strings.TrimSpace(strings.Join(current, "\n")))
+	}
+	return statements
+}
+
+// Run applies the migrations db has not had yet and returns their names
+func Run(db *sql.DB) ([]string, error) {
+	migrations, err := Load()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load migrations: %w", err)
+	}
+
+	_, err = db.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (
+		name VARCHAR(255) PRIMARY KEY,
+		applied_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	)`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create schema_migrations: %w", err)
+	}
+
+	applied := make(map[string]bool)
+	rows, err := db.Query("SELECT name FROM schema_migrations")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list applied migrations: %w", err)
+	}
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		applied[name] = true
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	var ran []string
+	for _, m := range migrations {
+		if applied[m.Name] {
+			continue
+		}
+		// MySQL commits DDL statements implicitly, so a migration cannot run in a transaction;
+		// one that failed part way is retried from the start and skips the changes it already made
+		for _, statement := range m.Statements {
+			if _, err := db.Exec(statement); err != nil && !isAlreadyApplied(err) {
+				return ran, fmt.Errorf("migration %s failed: %w", m.Name, err)
+			}
+		}
+		if _, err := db.Exec("INSERT INTO schema_migrations (name, applied_at) VALUES (?, ?)", m.Name, time.Now()); err != nil {
+			return ran, fmt.Errorf("failed to record migration %s: %w", m.Name, err)
+		}
+		ran = append(ran, m.Name)
+	}
+	return ran, nil
+}
+
+func isAlreadyApplied(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	return errors.As(err, &mysqlErr) && alreadyApplied[mysqlErr.Number]
+}
@@ -0,0 +1,49 @@
+package migrations
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"testing"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplitStatements(t *testing.T) {
+	content := `-- Add an index
+ALTER TABLE urls ADD INDEX idx_a (a);
+
+ALTER TABLE urls
+    ADD INDEX idx_b (b);
+-- trailing statement without a semicolon
+DROP TABLE old`
+
+	assert.Equal(t, []string{
+		"ALTER TABLE urls ADD INDEX idx_a (a)",
+		"ALTER TABLE urls\n    ADD INDEX idx_b (b)",
+		"DROP TABLE old",
+	}, splitStatements(content))
+}
+
+func TestLoad(t *testing.T) {
+	migrations, err := Load()
+	assert.NoError(t, err)
+	assert.NotEmpty(t, migrations)
+
+	name := regexp.MustCompile(`^\d{4}_[a-z0-9_]+$`)
+	for i, m := range migrations {
+		assert.Regexp(t, name, m.Name)
+		assert.NotEmpty(t, m.Statements, m.Name)
+		if i > 0 {
+			assert.Less(t, migrations[i-1].Name, m.Name)
+		}
+	}
+}
+
+func TestIsAlreadyApplied(t *testing.T) {
+	assert.True(t, isAlreadyApplied(&mysql.MySQLError{Number: 1061, Message: "Duplicate key name"}))
+	assert.True(t, isAlreadyApplied(fmt.Errorf("exec: %w", &mysql.MySQLError{Number: 1091})))
+	assert.False(t, isAlreadyApplied(&mysql.MySQLError{Number: 1064, Message: "syntax error"}))
+	assert.False(t, isAlreadyApplied(errors.New("connection refused")))
+}
@@ -0,0 +1,7 @@
+-- Serve the URL list of large accounts from indexes: filtering by status and ordering by creation
+-- time, the default order, and looking up a user's URL by address when adding one
+ALTER TABLE urls ADD INDEX idx_user_status_created (user_id, status, created_at);
+ALTER TABLE urls ADD INDEX idx_user_created (user_id, created_at);
+ALTER TABLE urls ADD INDEX idx_user_url (user_id, url(255));
+-- user_id leads the new indexes, so the foreign key no longer needs its own
+ALTER TABLE urls DROP INDEX idx_user_id;
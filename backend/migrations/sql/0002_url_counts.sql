@@ -0,0 +1,9 @@
+-- Approximate URL list totals, counted again from urls every hour
+CREATE TABLE IF NOT EXISTS url_counts (
+    user_id INT NOT NULL,
+    status ENUM('queued', 'running', 'completed', 'error', 'cancelled') NOT NULL,
+    total INT NOT NULL DEFAULT 0,
+    counted_at TIMESTAMP NOT NULL, -- when the URLs were last counted exactly
+    PRIMARY KEY (user_id, status),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
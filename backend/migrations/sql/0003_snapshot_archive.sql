@@ -0,0 +1,4 @@
+-- The text of old snapshots moves to object storage; content is emptied and archive_key names the object
+ALTER TABLE content_snapshots ADD COLUMN archive_key VARCHAR(255) NULL;
+ALTER TABLE content_snapshots ADD COLUMN archived_at TIMESTAMP NULL;
+ALTER TABLE content_snapshots ADD INDEX idx_unarchived (archived_at, created_at);
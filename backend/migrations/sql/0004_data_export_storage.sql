@@ -0,0 +1,2 @@
+-- Data export archives are written to storage instead of the content column
+ALTER TABLE data_exports ADD COLUMN storage_key VARCHAR(255) NULL;
@@ -0,0 +1,16 @@
+-- URL exports built in the background into storage
+CREATE TABLE IF NOT EXISTS exports (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    user_id INT NOT NULL,
+    format ENUM('xlsx', 'csv', 'zip') NOT NULL,
+    status ENUM('pending', 'ready', 'failed') DEFAULT 'pending',
+    token CHAR(32) NOT NULL UNIQUE, -- unguessable download token
+    storage_key VARCHAR(255) NULL, -- object holding the file once ready
+    size_bytes BIGINT NULL,
+    error_message TEXT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    completed_at TIMESTAMP NULL,
+    expires_at TIMESTAMP NULL,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    INDEX idx_user_created (user_id, created_at)
+);
@@ -0,0 +1,10 @@
+-- Link check verdicts shared by every crawl, reused for crawler.link_cache_ttl
+CREATE TABLE IF NOT EXISTS link_checks (
+    url_hash BINARY(32) PRIMARY KEY, -- SHA-256 of the link
+    url TEXT NOT NULL,
+    broken BOOLEAN NOT NULL,
+    status_code INT NULL,
+    error_message TEXT NULL,
+    checked_at TIMESTAMP NOT NULL,
+    INDEX idx_checked_at (checked_at)
+);
@@ -0,0 +1,3 @@
+-- Layout version of each stored analysis (analyzer.SchemaVersion); 0 marks analyses stored before versions were kept
+ALTER TABLE urls ADD COLUMN result_schema_version INT NOT NULL DEFAULT 0;
+ALTER TABLE urls ADD INDEX idx_status_schema_version (status, result_schema_version);
@@ -0,0 +1,14 @@
+-- A running crawl stops at its next check once its owner asks to pause it
+ALTER TABLE crawl_jobs ADD COLUMN pause_requested BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- Link verdicts a paused crawl job had checked, taken up by the attempt that resumes it
+CREATE TABLE IF NOT EXISTS crawl_checkpoints (
+    job_id INT NOT NULL,
+    url_hash BINARY(32) NOT NULL, -- SHA-256 of the link
+    url TEXT NOT NULL,
+    broken BOOLEAN NOT NULL,
+    status_code INT NULL,
+    error_message TEXT NULL,
+    PRIMARY KEY (job_id, url_hash),
+    FOREIGN KEY (job_id) REFERENCES crawl_jobs(id) ON DELETE CASCADE
+);
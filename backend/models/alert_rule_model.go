@@ -0,0 +1,29 @@
+package models
+
+import "time"
+
+// AlertChannel is where an alert rule's notifications go: an email address, a webhook URL or a Slack
+// incoming webhook URL
+type AlertChannel struct {
+	Type   string `json:"type" binding:"required"`
+	Target string `json:"target" binding:"required,max=500"`
+	// Secret signs webhook deliveries; one is generated when it is left empty
+	Secret string `json:"secret,omitempty" binding:"omitempty,min=16,max=200"`
+}
+
+type AlertRule struct {
+	ID        int            `json:"id"`
+	UserID    int            `json:"user_id"`
+	UrlID     *int           `json:"url_id,omitempty"`
+	Name      string         `json:"name"`
+	Condition string         `json:"condition"`
+	Channels  []AlertChannel `json:"channels"`
+	CreatedAt time.Time      `json:"created_at"`
+}
+
+type AlertRuleRequest struct {
+	UrlID     *int           `json:"url_id"`
+	Name      string         `json:"name" binding:"required,max=100"`
+	Condition string         `json:"condition" binding:"required,max=200"`
+	Channels  []AlertChannel `json:"channels" binding:"required,min=1,max=10,dive"`
+}
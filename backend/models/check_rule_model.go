@@ -0,0 +1,40 @@
+package models
+
+import "time"
+
+type CheckRule struct {
+	ID        int       `json:"id"`
+	UserID    int       `json:"user_id"`
+	UrlID     *int      `json:"url_id,omitempty"`
+	Name      string    `json:"name"`
+	Selector  string    `json:"selector"`
+	RuleType  string    `json:"rule_type"`
+	MinCount  *int      `json:"min_count,omitempty"`
+	MaxCount  *int      `json:"max_count,omitempty"`
+	Text      *string   `json:"text,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type CheckRuleRequest struct {
+	UrlID    *int   `json:"url_id"`
+	Name     string `json:"name" binding:"required,max=100"`
+	Selector string `json:"selector" binding:"required,max=500"`
+	RuleType string `json:"rule_type" binding:"required"`
+	MinCount *int   `json:"min_count"`
+	MaxCount *int   `json:"max_count"`
+	Text     string `json:"text" binding:"max=500"`
+}
+
+// CheckResult is the outcome of a check rule in the latest crawl of a URL
+type CheckResult struct {
+	ID        int       `json:"id"`
+	UrlID     int       `json:"url_id"`
+	RuleID    *int      `json:"rule_id"` // nil once the rule is deleted
+	Name      string    `json:"name"`
+	Selector  string    `json:"selector"`
+	RuleType  string    `json:"rule_type"`
+	Passed    bool      `json:"passed"`
+	Matches   int       `json:"matches"`
+	Message   string    `json:"message"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -0,0 +1,33 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// CrawlLog is one lifecycle event of a URL's crawl jobs
+type CrawlLog struct {
+	ID         int64           `json:"id"`
+	UrlID      int             `json:"url_id"`
+	JobID      *int            `json:"job_id,omitempty"`
+	Level      string          `json:"level"`
+	Event      string          `json:"event"`
+	Message    string          `json:"message"`
+	DurationMs *int64          `json:"duration_ms,omitempty"`
+	Details    json.RawMessage `json:"details,omitempty"`
+	CreatedAt  time.Time       `json:"created_at"`
+}
+
+// CrawlRun is the outcome of one crawl of a URL
+type CrawlRun struct {
+	ID           int        `json:"id"`
+	UrlID        int        `json:"url_id"`
+	Status       string     `json:"status"`
+	BrokenLinks  int        `json:"broken_links"`
+	ErrorMessage *string    `json:"error_message,omitempty"`
+	StartedAt    *time.Time `json:"started_at"`
+	FinishedAt   time.Time  `json:"finished_at"`
+	// ArchiveURL is the Wayback Machine copy captured after the crawl when archiving is enabled
+	ArchiveURL   *string `json:"archive_url,omitempty"`
+	ArchiveError *string `json:"archive_error,omitempty"`
+}
@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+// DuplicateGroup is a set of a user's URLs that point to the same page once normalized
+type DuplicateGroup struct {
+	// Key is the normalized form the URLs share: host without www, path without trailing slash,
+	// and the query without tracking parameters
+	Key string `json:"key"`
+	// KeepID is the URL a merge keeps: a completed one first, then the most recently crawled, then the oldest
+	KeepID int            `json:"keep_id"`
+	Urls   []DuplicateUrl `json:"urls"`
+}
+
+// DuplicateUrl is one URL of a DuplicateGroup
+type DuplicateUrl struct {
+	ID            int        `json:"id"`
+	Url           string     `json:"url"`
+	Status        string     `json:"status"`
+	LastCrawledAt *time.Time `json:"last_crawled_at"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+// MergeDuplicatesRequest limits a merge to the groups with the given keys; without keys every group is merged
+type MergeDuplicatesRequest struct {
+	Keys []string `json:"keys"`
+}
@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+type LinkExclusion struct {
+	ID          int       `json:"id"`
+	UserID      int       `json:"user_id"`
+	UrlID       *int      `json:"url_id,omitempty"`
+	Pattern     string    `json:"pattern"`
+	PatternType string    `json:"pattern_type"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type LinkExclusionRequest struct {
+	UrlID       *int   `json:"url_id"`
+	Pattern     string `json:"pattern" binding:"required,max=500"`
+	PatternType string `json:"pattern_type"`
+}
@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// ExportRequest starts building a URL export in the background
+type ExportRequest struct {
+	// Format is xlsx (the default), csv (the URLs sheet only) or zip (every sheet as CSV)
+	Format string `json:"format" binding:"omitempty,oneof=xlsx csv zip"`
+}
+
+// Export is a URL export built in the background, downloadable from DownloadURL until ExpiresAt once ready
+type Export struct {
+	ID     int    `json:"id"`
+	Format string `json:"format"`
+	// Status is pending, ready or failed
+	Status       string     `json:"status"`
+	SizeBytes    *int64     `json:"size_bytes,omitempty"`
+	ErrorMessage *string    `json:"error_message,omitempty"`
+	DownloadURL  string     `json:"download_url,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+	CompletedAt  *time.Time `json:"completed_at,omitempty"`
+	ExpiresAt    *time.Time `json:"expires_at,omitempty"`
+}
@@ -0,0 +1,24 @@
+package models
+
+// SitemapImportRequest lists the pages of a site's sitemaps and, with Confirm, queues them
+type SitemapImportRequest struct {
+	// URL is a sitemap, or a domain whose robots.txt or /sitemap.xml points to its sitemaps
+	URL     string `json:"url" binding:"required"`
+	Confirm bool   `json:"confirm"`
+	// URLs picks the discovered pages to queue; without them the first Limit pages are queued
+	URLs []string `json:"urls"`
+	// Limit caps how many pages are queued, at most server.bulk_url_max
+	Limit    int            `json:"limit" binding:"min=0"`
+	Priority string         `json:"priority"`
+	Settings *CrawlSettings `json:"settings"`
+}
+
+// SitemapImport is what was found in a site's sitemaps
+type SitemapImport struct {
+	Sitemaps []string `json:"sitemaps"`
+	Urls     []string `json:"urls"`
+	// NewCount is how many of Urls are not among the user's URLs yet
+	NewCount int `json:"new_count"`
+	// Truncated reports that the sitemaps list more pages than were read
+	Truncated bool `json:"truncated"`
+}
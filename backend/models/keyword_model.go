@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+// KeywordsRequest replaces the target keywords of a URL
+type KeywordsRequest struct {
+	Keywords []string `json:"keywords"`
+}
+
+// KeywordResult is how often a target keyword appeared in the latest crawl of a URL
+type KeywordResult struct {
+	Keyword         string    `json:"keyword"`
+	Title           int       `json:"title"`
+	Headings        int       `json:"headings"`
+	MetaDescription int       `json:"meta_description"`
+	Body            int       `json:"body"`
+	Density         float64   `json:"density"` // percentage of body words
+	CreatedAt       time.Time `json:"created_at"`
+}
@@ -0,0 +1,27 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// LighthouseScores are Lighthouse category scores from 0 to 100; nil when a category was not scored
+type LighthouseScores struct {
+	Performance   *int `json:"performance"`
+	Accessibility *int `json:"accessibility"`
+	BestPractices *int `json:"best_practices"`
+	SEO           *int `json:"seo"`
+}
+
+// LighthouseResult holds the latest Lighthouse scores of a URL
+type LighthouseResult struct {
+	Strategy string           `json:"strategy"` // mobile or desktop
+	Scores   LighthouseScores `json:"scores"`
+	// Metrics are lab measurements by audit ID, in milliseconds except for cumulative-layout-shift
+	Metrics           json.RawMessage `json:"metrics,omitempty"`
+	LighthouseVersion *string         `json:"lighthouse_version"`
+	FetchedAt         *time.Time      `json:"fetched_at"`
+	// Error is why the latest run failed; the scores are then from an earlier run, if any
+	Error     *string   `json:"error,omitempty"`
+	CheckedAt time.Time `json:"checked_at"`
+}
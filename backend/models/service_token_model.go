@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+// ServiceToken is a service account an integration authenticates as, limited to Scopes
+type ServiceToken struct {
+	ID         int        `json:"id"`
+	Name       string     `json:"name"`
+	Scopes     []string   `json:"scopes"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+}
+
+type ServiceTokenRequest struct {
+	Name   string   `json:"name" binding:"required,max=100"`
+	Scopes []string `json:"scopes" binding:"required,min=1"`
+	// ExpiresInDays of nil issues a token that stays valid until the service account is deleted
+	ExpiresInDays *int `json:"expires_in_days" binding:"omitempty,min=1,max=3650"`
+}
@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+// ContentSnapshot is the normalized page text of one crawl, without the text itself
+type ContentSnapshot struct {
+	ID    int  `json:"id"`
+	UrlID int  `json:"url_id"`
+	RunID *int `json:"run_id"` // nil once the crawl run is gone
+	// ChangePercent is how much of the text changed since the previous snapshot; nil for the first one
+	ChangePercent *float64  `json:"change_percent"`
+	AddedLines    int       `json:"added_lines"`
+	RemovedLines  int       `json:"removed_lines"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// ContentDiff is the text added and removed between two snapshots of a URL
+type ContentDiff struct {
+	UrlID         int             `json:"url_id"`
+	From          ContentSnapshot `json:"from"`
+	To            ContentSnapshot `json:"to"`
+	ChangePercent float64         `json:"change_percent"`
+	Added         []string        `json:"added"`
+	Removed       []string        `json:"removed"`
+}
@@ -1,7 +1,29 @@
 package models
 
+import "time"
+
 type Stats struct {
 	TotalUrls        int            `json:"total_urls"`
 	StatusCounts     map[string]int `json:"status_counts"`
 	TotalBrokenLinks int            `json:"total_broken_links"`
 }
+
+type TimeseriesPoint struct {
+	Date        string `json:"date"`
+	Crawls      int    `json:"crawls"`
+	Errors      int    `json:"errors"`
+	BrokenLinks int    `json:"broken_links"`
+}
+
+type DomainStats struct {
+	Domain            string     `json:"domain"`
+	Pages             int        `json:"pages"`
+	CompletedPages    int        `json:"completed_pages"`
+	ErrorPages        int        `json:"error_pages"`
+	TotalBrokenLinks  int        `json:"total_broken_links"`
+	AvgBrokenLinks    float64    `json:"avg_broken_links"`
+	AvgInternalLinks  float64    `json:"avg_internal_links"`
+	AvgExternalLinks  float64    `json:"avg_external_links"`
+	PagesWithoutTitle int        `json:"pages_without_title"`
+	LastCrawledAt     *time.Time `json:"last_crawled_at,omitempty"`
+}
@@ -0,0 +1,15 @@
+package models
+
+// BulkTagsRequest changes the tags of several URLs at once. Mode add (the default) adds the tags,
+// remove takes them off and set replaces each URL's tags with them.
+type BulkTagsRequest struct {
+	IDs  []int    `json:"ids" binding:"required"`
+	Tags []string `json:"tags"`
+	Mode string   `json:"mode" binding:"omitempty,oneof=add remove set"`
+}
+
+// BulkProjectRequest moves several URLs to a project; an empty or null project takes them out of theirs
+type BulkProjectRequest struct {
+	IDs     []int   `json:"ids" binding:"required"`
+	Project *string `json:"project" binding:"omitempty,max=100"`
+}
@@ -0,0 +1,36 @@
+package models
+
+import "time"
+
+// UptimeCheck is one ping of a URL by the uptime monitor
+type UptimeCheck struct {
+	StatusCode *int      `json:"status_code"`
+	ResponseMs *int      `json:"response_ms"`
+	Up         bool      `json:"up"`
+	Error      *string   `json:"error,omitempty"`
+	CheckedAt  time.Time `json:"checked_at"`
+}
+
+// UptimeWindow is the availability of a URL over a recent period
+type UptimeWindow struct {
+	// Window is 24h, 7d or 30d
+	Window string `json:"window"`
+	Checks int    `json:"checks"`
+	Up     int    `json:"up"`
+	// AvailabilityPercent is the share of pings that were up; nil without pings in the window
+	AvailabilityPercent *float64 `json:"availability_percent"`
+	// AvgResponseMs averages the pings that got an answer
+	AvgResponseMs *float64 `json:"avg_response_ms"`
+}
+
+// UptimeReport is the monitoring history of a URL
+type UptimeReport struct {
+	UrlID int `json:"url_id"`
+	// Monitoring is false when the monitor is switched off; older pings are still reported
+	Monitoring      bool           `json:"monitoring"`
+	IntervalSeconds int            `json:"interval_seconds"`
+	LastCheck       *UptimeCheck   `json:"last_check"`
+	Availability    []UptimeWindow `json:"availability"`
+	// Checks are the most recent pings, newest first
+	Checks []UptimeCheck `json:"checks"`
+}
@@ -1,6 +1,9 @@
 package models
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+)
 
 type Url struct {
 	ID            int       `json:"id"`
@@ -19,20 +22,118 @@ type Url struct {
 	ErrorMessage  *string   `json:"error_message,omitempty"`
 	CreatedAt     time.Time `json:"created_at"`
 	UpdatedAt     time.Time `json:"updated_at"`
+
+	// Link follow attributes and robots directives
+	InternalNofollowLinks int  `json:"internal_nofollow_links"`
+	ExternalNofollowLinks int  `json:"external_nofollow_links"`
+	SponsoredLinks        int  `json:"sponsored_links"`
+	UgcLinks              int  `json:"ugc_links"`
+	IsNoindex             bool `json:"is_noindex"`
+	IsNofollow            bool `json:"is_nofollow"`
+
+	// HasConsentBanner reports whether a cookie consent banner was detected, nil before the first crawl
+	HasConsentBanner *bool `json:"has_consent_banner"`
+
+	// Freshness of the analysis
+	LastCrawledAt *time.Time `json:"last_crawled_at"`
+	IsStale       bool       `json:"is_stale"`
+
+	// ResultSchemaVersion is the analyzer version that stored the analysis, 0 before versions were
+	// kept. IsOutdated reports a completed analysis older than the running analyzer, which lacks
+	// what analyzers added since report.
+	ResultSchemaVersion int  `json:"result_schema_version"`
+	IsOutdated          bool `json:"is_outdated"`
+
+	// Progress of the current or last crawl, omitted for URLs that were never crawled
+	Progress *CrawlProgress `json:"progress,omitempty"`
+
+	// IsPaused reports a queued analysis held until it is resumed
+	IsPaused bool `json:"is_paused"`
+
+	// Version is raised by every status change and edit; send it as If-Match to change the URL safely
+	Version int `json:"version"`
+
+	// Tags label the URL; Project is the project it belongs to, nil when it has none
+	Tags    []string `json:"tags"`
+	Project *string  `json:"project"`
+
+	// CrawlSettings are the settings the URL is crawled with, taken from the owner's defaults when it was added
+	CrawlSettings CrawlSettings `json:"crawl_settings"`
+
+	// Estimated seconds until a queued or running analysis completes
+	EtaSeconds *int `json:"eta_seconds,omitempty"`
+}
+
+// CrawlProgress reports how far a crawl has got
+type CrawlProgress struct {
+	Stage           string     `json:"stage"`
+	LinksDiscovered int        `json:"links_discovered"`
+	LinksToCheck    int        `json:"links_to_check"`
+	LinksChecked    int        `json:"links_checked"`
+	Percent         int        `json:"percent"`
+	UpdatedAt       *time.Time `json:"updated_at"`
 }
 
 type BrokenLink struct {
-	ID           int       `json:"id"`
-	UrlID        int       `json:"url_id"`
-	LinkUrl      string    `json:"link_url"`
-	StatusCode   *int      `json:"status_code,omitempty"`
-	ErrorMessage *string   `json:"error_message,omitempty"`
-	CreatedAt    time.Time `json:"created_at"`
+	ID           int     `json:"id"`
+	UrlID        int     `json:"url_id"`
+	LinkUrl      string  `json:"link_url"`
+	StatusCode   *int    `json:"status_code,omitempty"`
+	ErrorMessage *string `json:"error_message,omitempty"`
+	// RateLimited is set for links still answered 429 Too Many Requests after a retry, which are not necessarily dead
+	RateLimited bool `json:"rate_limited"`
+	// Suggestions are working replacements: the link on https or with www added or removed, or an archived copy
+	Suggestions []string  `json:"suggestions,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// BrokenLinkMatch is a broken link found by GET /api/broken-links, with the page it was found on
+type BrokenLinkMatch struct {
+	BrokenLink
+	PageUrl   string `json:"page_url"`
+	PageTitle string `json:"page_title"`
 }
 
 type UrlWithBrokenLinks struct {
 	Url
 	BrokenLinksDetails []BrokenLink `json:"broken_links_details"`
+	// CheckResults holds the outcome of the user's check rules in the latest crawl
+	CheckResults []CheckResult `json:"check_results"`
+	// KeywordResults holds the occurrences of the URL's target keywords in the latest crawl
+	KeywordResults []KeywordResult `json:"keyword_results"`
+	// Forms holds the forms of the latest crawl with their fields and findings
+	Forms json.RawMessage `json:"forms,omitempty"`
+	// Hreflang holds the language annotations and findings of the latest crawl
+	Hreflang json.RawMessage `json:"hreflang,omitempty"`
+	// LinkHygiene holds the suspicious links found in the latest crawl
+	LinkHygiene json.RawMessage `json:"link_hygiene,omitempty"`
+	// ContactLinks holds the mailto and tel links of the latest crawl and the malformed ones
+	ContactLinks json.RawMessage `json:"contact_links,omitempty"`
+	// SocialProfiles holds the social network accounts the page linked to at the latest crawl
+	SocialProfiles json.RawMessage `json:"social_profiles,omitempty"`
+	// Fragments holds the links of the latest crawl to elements missing from the page itself
+	Fragments json.RawMessage `json:"fragments,omitempty"`
+	// Safety holds the Safe Browsing verdict of the latest crawl, when lookups are enabled
+	Safety json.RawMessage `json:"safety,omitempty"`
+	// Privacy holds the cookies and trackers found in the latest crawl
+	Privacy json.RawMessage `json:"privacy,omitempty"`
+	// Consent holds the consent banner detection of the latest crawl
+	Consent json.RawMessage `json:"consent,omitempty"`
+	// Performance holds the HTTP version and advertised alternatives such as HTTP/3 at the latest crawl
+	Performance json.RawMessage `json:"performance,omitempty"`
+	// DNS holds the records of the host at the latest crawl
+	DNS json.RawMessage `json:"dns,omitempty"`
+	// Hosting holds the server IP at the latest crawl, with its ASN, provider and country when GeoIP is configured
+	Hosting json.RawMessage `json:"hosting,omitempty"`
+	// Registration holds the registrar and expiry date of the domain, when RDAP lookups are enabled
+	Registration json.RawMessage `json:"registration,omitempty"`
+	// WebVitals holds the origin's Chrome UX Report field data at the latest crawl, when lookups are enabled
+	WebVitals json.RawMessage `json:"web_vitals,omitempty"`
+	// BrokenAssets holds the images, scripts and stylesheets that failed their check in the latest crawl,
+	// when asset checks are enabled
+	BrokenAssets json.RawMessage `json:"broken_assets,omitempty"`
+	// Documents holds the linked PDFs and office documents of the latest crawl, with their findings when checked
+	Documents json.RawMessage `json:"documents,omitempty"`
 }
 
 type UrlStats struct {
@@ -42,4 +143,39 @@ type UrlStats struct {
 	CompletedUrls    int `json:"completed_urls"`
 	ErrorUrls        int `json:"error_urls"`
 	TotalBrokenLinks int `json:"total_broken_links"`
+	// WebVitals summarizes the field Core Web Vitals of completed URLs
+	WebVitals WebVitalsStats `json:"web_vitals"`
+	// ExpiringDomains warns about tracked domains that expire within 30 days or have expired
+	ExpiringDomains []ExpiringDomain `json:"expiring_domains"`
+}
+
+// ExpiringDomain is a tracked domain close to its registration expiry date
+type ExpiringDomain struct {
+	Domain    string    `json:"domain"`
+	ExpiresAt time.Time `json:"expires_at"`
+	// DaysLeft is negative once the domain has expired
+	DaysLeft int `json:"days_left"`
+	// Urls is how many of the user's URLs are on the domain
+	Urls int `json:"urls"`
+}
+
+// WebVitalsStats counts completed URLs by the Core Web Vitals assessment of their origin and averages
+// the 75th percentiles of those with field data (nil when none has)
+type WebVitalsStats struct {
+	Passed int      `json:"passed"`
+	Failed int      `json:"failed"`
+	NoData int      `json:"no_data"`
+	AvgLCP *float64 `json:"avg_lcp_p75"`
+	AvgCLS *float64 `json:"avg_cls_p75"`
+	AvgINP *float64 `json:"avg_inp_p75"`
+}
+
+type BulkUrlResult struct {
+	Input  string `json:"input"`
+	Url    string `json:"url,omitempty"`
+	Status string `json:"status"` // created, duplicate or invalid
+	ID     int    `json:"id,omitempty"`
+	Error  string `json:"error,omitempty"`
+	// Tags were given to the URL by an import, such as the folders of a bookmark
+	Tags []string `json:"tags,omitempty"`
 }
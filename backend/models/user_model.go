@@ -9,6 +9,7 @@ type User struct {
 	Username  string    `json:"username"`
 	Email     string    `json:"email"`
 	Password  string    `json:"-"` // Never serialize password
+	Timezone  string    `json:"timezone"`
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
 }
@@ -28,3 +29,75 @@ type AuthResponse struct {
 	Token string `json:"token"`
 	User  User   `json:"user"`
 }
+
+// DigestSettings is a user's activity digest schedule
+type DigestSettings struct {
+	// Frequency is off, daily or weekly
+	Frequency string `json:"frequency"`
+	// Hour is the UTC hour the digest goes out
+	Hour       int        `json:"hour"`
+	LastSentAt *time.Time `json:"last_sent_at,omitempty"`
+	// Available is false when the server does not send digests
+	Available bool `json:"available"`
+}
+
+// CrawlSettings tune how a URL is crawled. Nil timeout and user agent follow the server's crawler
+// settings, and a nil CheckBrokenLinks checks them.
+type CrawlSettings struct {
+	TimeoutSeconds   *int    `json:"timeout_seconds" binding:"omitempty,min=1"`
+	UserAgent        *string `json:"user_agent" binding:"omitempty,min=1,max=255"`
+	CheckBrokenLinks *bool   `json:"check_broken_links"`
+}
+
+// UserSettings are the crawl settings given to new URLs and the account's notification preferences
+type UserSettings struct {
+	Crawl         CrawlSettings  `json:"crawl"`
+	Notifications DigestSettings `json:"notifications"`
+}
+
+// UserSettingsRequest changes the sections it includes; Crawl replaces every crawl default
+type UserSettingsRequest struct {
+	Crawl         *CrawlSettings         `json:"crawl"`
+	Notifications *DigestSettingsRequest `json:"notifications"`
+}
+
+type TimezoneRequest struct {
+	// Timezone is an IANA zone name such as Europe/Berlin, or UTC
+	Timezone string `json:"timezone" binding:"required,max=64"`
+}
+
+type DigestSettingsRequest struct {
+	Frequency string `json:"frequency" binding:"required,oneof=off daily weekly"`
+	// Hour keeps the current hour when omitted
+	Hour *int `json:"hour" binding:"omitempty,min=0,max=23"`
+}
+
+// DataExport is an archive of the user's data, downloadable from DownloadURL until ExpiresAt once ready
+type DataExport struct {
+	ID int `json:"id"`
+	// Status is pending, ready or failed
+	Status       string     `json:"status"`
+	SizeBytes    *int64     `json:"size_bytes,omitempty"`
+	ErrorMessage *string    `json:"error_message,omitempty"`
+	DownloadURL  string     `json:"download_url,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+	CompletedAt  *time.Time `json:"completed_at,omitempty"`
+	ExpiresAt    *time.Time `json:"expires_at,omitempty"`
+}
+
+// RetentionSettings is how long an account keeps crawl history, text snapshots and broken link records
+type RetentionSettings struct {
+	// GlobalDays is the server's limit (0 = forever)
+	GlobalDays int `json:"global_days"`
+	// Days is the account's own, shorter retention; nil follows the server's limit
+	Days *int `json:"days"`
+	// OverrideDays is set by administrators and replaces both (0 = forever)
+	OverrideDays *int `json:"override_days"`
+	// EffectiveDays is the retention that applies (0 = forever)
+	EffectiveDays int `json:"effective_days"`
+}
+
+type RetentionSettingsRequest struct {
+	// Days of nil restores the server's limit
+	Days *int `json:"days" binding:"omitempty,min=1,max=36500"`
+}
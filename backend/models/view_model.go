@@ -0,0 +1,28 @@
+package models
+
+import "time"
+
+// UrlFilters narrow and order the URL list, as query parameters of GET /api/urls or saved in a view
+type UrlFilters struct {
+	Status        string `json:"status,omitempty" binding:"omitempty,oneof=queued running completed error cancelled"`
+	Search        string `json:"search,omitempty" binding:"max=200"`
+	ConsentBanner *bool  `json:"consent_banner,omitempty"`
+	Tag           string `json:"tag,omitempty" binding:"max=40"`
+	Project       string `json:"project,omitempty" binding:"max=100"`
+	// Sort is a column such as broken_links, prefixed with - for descending order
+	Sort string `json:"sort,omitempty"`
+}
+
+// UrlView is a named set of URL list filters
+type UrlView struct {
+	ID        int        `json:"id"`
+	Name      string     `json:"name"`
+	Filters   UrlFilters `json:"filters"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+type UrlViewRequest struct {
+	Name    string     `json:"name" binding:"required,max=100"`
+	Filters UrlFilters `json:"filters"`
+}
@@ -0,0 +1,107 @@
+// Package monitor pings tracked URLs on an interval and records whether they are up, so users can
+// see the availability of their pages between crawls.
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"sykell-analyze/backend/alerts"
+	"sykell-analyze/backend/config"
+	"sykell-analyze/backend/dnscache"
+)
+
+const (
+	// pollInterval is how often the monitor looks for URLs due for a ping
+	pollInterval = 15 * time.Second
+	// pruneInterval is how often pings older than the retention are deleted
+	pruneInterval = time.Hour
+)
+
+// Monitor pings due URLs. Several processes may run one against the same database: each URL is
+// claimed before it is pinged, so it is pinged once per interval. With tenancy, one monitor runs for
+// every tenant database and its concurrency is shared between them.
+type Monitor struct {
+	Config    config.MonitorConfig
+	UserAgent string
+	client    *http.Client
+	// slots limits the pings in flight across every Run of the monitor
+	slots chan struct{}
+}
+
+// New creates a monitor sending userAgent with every ping
+func New(cfg config.MonitorConfig, userAgent string) *Monitor {
+	client := config.App.Crawler.HTTPClient(dnscache.Default())
+	client.Timeout = cfg.Timeout
+	return &Monitor{
+		Config:    cfg,
+		UserAgent: userAgent,
+		client:    client,
+		slots:     make(chan struct{}, cfg.Concurrency),
+	}
+}
+
+// Run pings due URLs of the database of ctx until ctx is cancelled
+func (m *Monitor) Run(ctx context.Context) {
+	fmt.Printf("📡 Uptime monitor started (every %s)\n", m.Config.Interval)
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	var lastPrune time.Time
+	for {
+		if time.Since(lastPrune) >= pruneInterval {
+			if err := pruneChecks(ctx, time.Now().Add(-m.Config.Retention)); err != nil {
+				fmt.Printf("DEBUG: Failed to prune uptime checks: %v\n", err)
+			}
+			lastPrune = time.Now()
+		}
+		m.pingDue(ctx)
+
+		select {
+		case <-ctx.Done():
+			fmt.Println("📡 Uptime monitor stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// pingDue claims the URLs due for a ping in batches and pings them concurrently
+func (m *Monitor) pingDue(ctx context.Context) {
+	for ctx.Err() == nil {
+		targets, err := claimDue(ctx, time.Now(), m.Config.Interval, m.Config.Concurrency*4)
+		if err != nil {
+			fmt.Printf("DEBUG: Failed to claim URLs for uptime checks: %v\n", err)
+			return
+		}
+		if len(targets) == 0 {
+			return
+		}
+
+		var wg sync.WaitGroup
+		for _, target := range targets {
+			// URLs added before the domain policy changed are no longer pinged
+			if config.App.Crawler.CheckTarget(target.url) != nil {
+				continue
+			}
+			wg.Add(1)
+			m.slots <- struct{}{}
+			go func() {
+				defer wg.Done()
+				defer func() { <-m.slots }()
+				check := Ping(ctx, m.client, target.url, m.UserAgent)
+				if ctx.Err() != nil {
+					return // shutting down; the URL is pinged again after the interval
+				}
+				if err := recordCheck(ctx, target.id, check); err != nil {
+					fmt.Printf("DEBUG: Failed to record uptime check for URL ID %d: %v\n", target.id, err)
+				}
+				alerts.EvaluatePing(ctx, target.id, check.Up, check.StatusCode, check.ResponseTime)
+			}()
+		}
+		wg.Wait()
+	}
+}
@@ -0,0 +1,68 @@
+package monitor
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Check is the outcome of one ping
+type Check struct {
+	// StatusCode is the status of the final response after redirects, 0 when the request failed
+	StatusCode int
+	// ResponseTime is how long the response headers took to arrive
+	ResponseTime time.Duration
+	// Up is true when the URL answered with a status below 400
+	Up    bool
+	Error string
+}
+
+// Ping sends a HEAD request to url, falling back to GET for servers that do not implement HEAD
+func Ping(ctx context.Context, client *http.Client, url, userAgent string) Check {
+	check := ping(ctx, client, http.MethodHead, url, userAgent)
+	if check.StatusCode == http.StatusMethodNotAllowed || check.StatusCode == http.StatusNotImplemented {
+		check = ping(ctx, client, http.MethodGet, url, userAgent)
+	}
+	return check
+}
+
+func ping(ctx context.Context, client *http.Client, method, url, userAgent string) Check {
+	req, err := http.NewRequestWithContext(ctx, method, url, nil)
+	if err != nil {
+		return Check{Error: err.Error()}
+	}
+	req.Header.Set("User-Agent", userAgent)
+	req.Header.Set("Accept", "*/*")
+
+	startedAt := time.Now()
+	res, err := client.Do(req)
+	elapsed := time.Since(startedAt)
+	if err != nil {
+		return Check{ResponseTime: elapsed, Error: describeError(err)}
+	}
+	// Only the headers matter; closing without reading the body aborts a GET early
+	res.Body.Close()
+
+	return Check{
+		StatusCode:   res.StatusCode,
+		ResponseTime: elapsed,
+		Up:           res.StatusCode < 400,
+	}
+}
+
+// describeError shortens common network errors the way broken link checks report them
+func describeError(err error) string {
+	var timeout interface{ Timeout() bool }
+	message := err.Error()
+	switch {
+	case errors.As(err, &timeout) && timeout.Timeout():
+		return "Timeout"
+	case strings.Contains(message, "no such host"):
+		return "Host not found"
+	case strings.Contains(message, "connection refused"):
+		return "Connection refused"
+	}
+	return message
+}
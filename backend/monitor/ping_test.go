@@ -0,0 +1,77 @@
+package monitor
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPing(t *testing.T) {
+	client := &http.Client{Timeout: time.Second}
+
+	t.Run("a successful HEAD is up", func(t *testing.T) {
+		var method, userAgent string
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			method, userAgent = r.Method, r.UserAgent()
+		}))
+		defer server.Close()
+
+		check := Ping(context.Background(), client, server.URL, "test-agent")
+		assert.True(t, check.Up)
+		assert.Equal(t, http.StatusOK, check.StatusCode)
+		assert.Equal(t, http.MethodHead, method)
+		assert.Equal(t, "test-agent", userAgent)
+		assert.Empty(t, check.Error)
+	})
+
+	t.Run("falls back to GET when HEAD is not allowed", func(t *testing.T) {
+		var methods []string
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			methods = append(methods, r.Method)
+			if r.Method == http.MethodHead {
+				w.WriteHeader(http.StatusMethodNotAllowed)
+			}
+		}))
+		defer server.Close()
+
+		check := Ping(context.Background(), client, server.URL, "test-agent")
+		assert.True(t, check.Up)
+		assert.Equal(t, []string{http.MethodHead, http.MethodGet}, methods)
+	})
+
+	t.Run("error statuses are down", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}))
+		defer server.Close()
+
+		check := Ping(context.Background(), client, server.URL, "test-agent")
+		assert.False(t, check.Up)
+		assert.Equal(t, http.StatusServiceUnavailable, check.StatusCode)
+	})
+
+	t.Run("timeouts are down", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			time.Sleep(200 * time.Millisecond)
+		}))
+		defer server.Close()
+
+		check := Ping(context.Background(), &http.Client{Timeout: 50 * time.Millisecond}, server.URL, "test-agent")
+		assert.False(t, check.Up)
+		assert.Zero(t, check.StatusCode)
+		assert.Equal(t, "Timeout", check.Error)
+	})
+
+	t.Run("refused connections are down", func(t *testing.T) {
+		server := httptest.NewServer(http.NotFoundHandler())
+		server.Close()
+
+		check := Ping(context.Background(), client, server.URL, "test-agent")
+		assert.False(t, check.Up)
+		assert.Equal(t, "Connection refused", check.Error)
+	})
+}
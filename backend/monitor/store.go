@@ -0,0 +1,93 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"sykell-analyze/backend/config"
+)
+
+// target is a URL claimed for a ping
+type target struct {
+	id  int
+	url string
+}
+
+// claimDue reserves up to limit URLs whose next ping is due by moving their next ping one interval
+// ahead. A URL another monitor claimed first is skipped. updated_at is kept, as pings do not change the URL.
+func claimDue(ctx context.Context, now time.Time, interval time.Duration, limit int) ([]target, error) {
+	db := config.DBFor(ctx)
+	rows, err := db.Query(`
+		SELECT id, url FROM urls
+		WHERE uptime_next_check_at IS NULL OR uptime_next_check_at <= ?
+		ORDER BY uptime_next_check_at
+		LIMIT ?
+	`, now, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find due URLs: %w", err)
+	}
+	var due []target
+	for rows.Next() {
+		var t target
+		if err := rows.Scan(&t.id, &t.url); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		due = append(due, t)
+	}
+	rows.Close()
+
+	var claimed []target
+	for _, t := range due {
+		res, err := db.Exec(`
+			UPDATE urls SET uptime_next_check_at = ?, updated_at = updated_at
+			WHERE id = ? AND (uptime_next_check_at IS NULL OR uptime_next_check_at <= ?)
+		`, now.Add(interval), t.id, now)
+		if err != nil {
+			return claimed, fmt.Errorf("failed to claim URL %d: %w", t.id, err)
+		}
+		if n, _ := res.RowsAffected(); n == 1 {
+			claimed = append(claimed, t)
+		}
+	}
+	return claimed, nil
+}
+
+// recordCheck stores a ping; the response time is only kept for pings that got an answer
+func recordCheck(ctx context.Context, urlID int, check Check) error {
+	var statusCode, responseMs, errorMessage interface{}
+	if check.StatusCode != 0 {
+		statusCode = check.StatusCode
+		responseMs = check.ResponseTime.Milliseconds()
+	}
+	if check.Error != "" {
+		errorMessage = truncate(check.Error, 500)
+	}
+	_, err := config.DBFor(ctx).Exec(`
+		INSERT INTO uptime_checks (url_id, status_code, response_ms, is_up, error_message, checked_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`, urlID, statusCode, responseMs, check.Up, errorMessage, time.Now())
+	return err
+}
+
+// pruneChecks deletes pings older than before, in batches to keep locks short
+func pruneChecks(ctx context.Context, before time.Time) error {
+	for {
+		res, err := config.DBFor(ctx).Exec("DELETE FROM uptime_checks WHERE checked_at < ? LIMIT 10000", before)
+		if err != nil {
+			return err
+		}
+		if n, _ := res.RowsAffected(); n < 10000 {
+			return nil
+		}
+	}
+}
+
+// truncate shortens s to at most n bytes
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n]
+}
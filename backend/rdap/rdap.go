@@ -0,0 +1,186 @@
+// Package rdap looks up domain registrations with the Registration Data Access Protocol, the
+// structured successor of WHOIS.
+package rdap
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"sykell-analyze/backend/analyzer"
+	"sykell-analyze/backend/config"
+	"sykell-analyze/backend/version"
+)
+
+// DefaultBaseURL redirects each query to the RDAP server of the domain's registry
+const DefaultBaseURL = "https://rdap.org"
+
+// pruneAbove is the cache size at which expired entries are dropped
+const pruneAbove = 10000
+
+// Default is nil when lookups are disabled (rdap.enabled unset)
+var Default *Client
+
+// Configure enables lookups when they are switched on
+func Configure(cfg config.RDAPConfig) {
+	if !cfg.Enabled {
+		Default = nil
+		return
+	}
+	Default = New(cfg.BaseURL, cfg.CacheTTL, cfg.Timeout)
+	fmt.Println("✅ RDAP domain lookups enabled.")
+}
+
+// Client looks domains up and caches their registrations, which rarely change.
+// It is safe for concurrent use.
+type Client struct {
+	BaseURL    string
+	CacheTTL   time.Duration
+	HTTPClient *http.Client
+
+	mu    sync.Mutex
+	cache map[string]cacheEntry
+}
+
+// cacheEntry is a cached registration; registration is nil for domains the registry does not know
+type cacheEntry struct {
+	registration *analyzer.Registration
+	expires      time.Time
+}
+
+// New creates a client querying baseURL/domain/<name>
+func New(baseURL string, cacheTTL, timeout time.Duration) *Client {
+	return &Client{
+		BaseURL:    strings.TrimSuffix(baseURL, "/"),
+		CacheTTL:   cacheTTL,
+		HTTPClient: &http.Client{Timeout: timeout},
+		cache:      make(map[string]cacheEntry),
+	}
+}
+
+// LookupDomain returns the domain's registrar and registration dates, or nil when the registry has
+// no record of it. It implements analyzer.DomainRegistry.
+func (c *Client) LookupDomain(ctx context.Context, domain string) (*analyzer.Registration, error) {
+	now := time.Now()
+	c.mu.Lock()
+	entry, ok := c.cache[domain]
+	c.mu.Unlock()
+	if ok && now.Before(entry.expires) {
+		return copyRegistration(entry.registration), nil
+	}
+
+	registration, err := c.query(ctx, domain)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	if len(c.cache) > pruneAbove {
+		for key, entry := range c.cache {
+			if !now.Before(entry.expires) {
+				delete(c.cache, key)
+			}
+		}
+	}
+	c.cache[domain] = cacheEntry{registration: registration, expires: now.Add(c.CacheTTL)}
+	c.mu.Unlock()
+	return copyRegistration(registration), nil
+}
+
+// copyRegistration returns a copy so callers can fill in fields without touching the cache
+func copyRegistration(r *analyzer.Registration) *analyzer.Registration {
+	if r == nil {
+		return nil
+	}
+	copied := *r
+	return &copied
+}
+
+// domainResponse is the part of an RDAP domain object that is used
+type domainResponse struct {
+	Events []struct {
+		Action string    `json:"eventAction"`
+		Date   time.Time `json:"eventDate"`
+	} `json:"events"`
+	Entities []struct {
+		Roles []string `json:"roles"`
+		// VCard is a jCard: ["vcard", [[name, params, type, value], ...]]
+		VCard []json.RawMessage `json:"vcardArray"`
+	} `json:"entities"`
+}
+
+// query fetches the domain object, returning nil when the registry has no record of the domain
+func (c *Client) query(ctx context.Context, domain string) (*analyzer.Registration, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.BaseURL+"/domain/"+url.PathEscape(domain), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/rdap+json")
+	req.Header.Set("User-Agent", "sykell-analyze/"+version.Version)
+
+	res, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("rdap lookup failed: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == http.StatusNotFound {
+		io.Copy(io.Discard, io.LimitReader(res.Body, 64<<10))
+		return nil, nil
+	}
+	if res.StatusCode != http.StatusOK {
+		io.Copy(io.Discard, io.LimitReader(res.Body, 64<<10))
+		return nil, fmt.Errorf("rdap lookup failed: %s", res.Status)
+	}
+
+	var decoded domainResponse
+	if err := json.NewDecoder(io.LimitReader(res.Body, 1<<20)).Decode(&decoded); err != nil {
+		return nil, fmt.Errorf("invalid rdap response: %w", err)
+	}
+
+	registration := &analyzer.Registration{}
+	for _, event := range decoded.Events {
+		date := event.Date.UTC()
+		switch event.Action {
+		case "registration":
+			registration.CreatedAt = &date
+		case "expiration":
+			registration.ExpiresAt = &date
+		}
+	}
+	for _, entity := range decoded.Entities {
+		for _, role := range entity.Roles {
+			if role == "registrar" {
+				registration.Registrar = vcardName(entity.VCard)
+			}
+		}
+	}
+	return registration, nil
+}
+
+// vcardName returns the formatted name (fn) of a jCard, or "" when it has none
+func vcardName(vcard []json.RawMessage) string {
+	if len(vcard) < 2 {
+		return ""
+	}
+	var properties [][]json.RawMessage
+	if err := json.Unmarshal(vcard[1], &properties); err != nil {
+		return ""
+	}
+	for _, property := range properties {
+		if len(property) < 4 {
+			continue
+		}
+		var name, value string
+		if json.Unmarshal(property[0], &name) == nil && name == "fn" && json.Unmarshal(property[3], &value) == nil {
+			return value
+		}
+	}
+	return ""
+}
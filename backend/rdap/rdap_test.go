@@ -0,0 +1,79 @@
+package rdap
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const sampleDomain = `{
+	"objectClassName": "domain",
+	"ldhName": "EXAMPLE.COM",
+	"events": [
+		{"eventAction": "registration", "eventDate": "1995-08-14T04:00:00Z"},
+		{"eventAction": "expiration", "eventDate": "2026-08-13T04:00:00Z"},
+		{"eventAction": "last update of RDAP database", "eventDate": "2026-10-16T07:00:00Z"}
+	],
+	"entities": [{
+		"objectClassName": "entity",
+		"roles": ["registrar"],
+		"vcardArray": ["vcard", [["version", {}, "text", "4.0"], ["fn", {}, "text", "Example Registrar, Inc."]]]
+	}]
+}`
+
+func TestLookupDomain(t *testing.T) {
+	var calls int32
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		switch r.URL.Path {
+		case "/domain/example.com":
+			w.Header().Set("Content-Type", "application/rdap+json")
+			w.Write([]byte(sampleDomain))
+		case "/domain/unknown.example":
+			w.WriteHeader(http.StatusNotFound)
+		default:
+			w.WriteHeader(http.StatusTooManyRequests)
+		}
+	}))
+	defer api.Close()
+
+	client := New(api.URL+"/", time.Hour, 5*time.Second)
+
+	t.Run("reads the registrar and dates", func(t *testing.T) {
+		r, err := client.LookupDomain(context.Background(), "example.com")
+		require.NoError(t, err)
+		require.NotNil(t, r)
+
+		assert.Equal(t, "Example Registrar, Inc.", r.Registrar)
+		assert.Equal(t, time.Date(1995, 8, 14, 4, 0, 0, 0, time.UTC), *r.CreatedAt)
+		assert.Equal(t, time.Date(2026, 8, 13, 4, 0, 0, 0, time.UTC), *r.ExpiresAt)
+	})
+
+	t.Run("answers repeated lookups from the cache", func(t *testing.T) {
+		r, err := client.LookupDomain(context.Background(), "example.com")
+		require.NoError(t, err)
+		r.Domain = "changed"
+
+		again, _ := client.LookupDomain(context.Background(), "example.com")
+		assert.Empty(t, again.Domain)
+		assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+	})
+
+	t.Run("unknown domains are nil", func(t *testing.T) {
+		r, err := client.LookupDomain(context.Background(), "unknown.example")
+		require.NoError(t, err)
+		assert.Nil(t, r)
+	})
+
+	t.Run("rate limits are errors", func(t *testing.T) {
+		_, err := client.LookupDomain(context.Background(), "busy.example")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "429")
+	})
+}
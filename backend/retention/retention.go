@@ -0,0 +1,200 @@
+// Package retention purges crawl history, text snapshots and broken link records older than each
+// account's retention period, and link check verdicts past crawler.link_cache_ttl.
+package retention
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"sykell-analyze/backend/archive"
+	"sykell-analyze/backend/config"
+	"sykell-analyze/backend/linkcache"
+)
+
+// batchSize is how many rows one DELETE removes, keeping locks short
+const batchSize = 10000
+
+// EffectiveDays is how many days an account keeps records (0 = forever). An administrator's override
+// wins; otherwise the account's own setting applies, capped at the global limit.
+func EffectiveDays(global int, days, overrideDays *int) int {
+	if overrideDays != nil {
+		return *overrideDays
+	}
+	if days != nil && *days > 0 && (global == 0 || *days < global) {
+		return *days
+	}
+	return global
+}
+
+// Job purges old records on an interval. Several processes may run one: deletes are idempotent.
+type Job struct {
+	Config config.RetentionConfig
+}
+
+// New creates a cleanup job
+func New(cfg config.RetentionConfig) *Job {
+	return &Job{Config: cfg}
+}
+
+// Run purges old records until ctx is cancelled
+func (j *Job) Run(ctx context.Context) {
+	fmt.Printf("🧹 Retention cleanup started (every %s)\n", j.Config.Interval)
+	ticker := time.NewTicker(j.Config.Interval)
+	defer ticker.Stop()
+
+	for {
+		j.purge(ctx, time.Now())
+
+		select {
+		case <-ctx.Done():
+			fmt.Println("🧹 Retention cleanup stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// account is a user whose records expire
+type account struct {
+	id   int
+	days int
+}
+
+// purge deletes the expired records of every account
+func (j *Job) purge(ctx context.Context, now time.Time) {
+	if deleted, err := linkcache.Purge(ctx, now.Add(-config.App.Crawler.LinkCacheTTL)); err != nil {
+		fmt.Printf("DEBUG: Failed to purge expired link checks: %v\n", err)
+	} else if deleted > 0 {
+		fmt.Printf("DEBUG: Purged %d expired link checks\n", deleted)
+	}
+
+	accounts, err := loadAccounts(ctx, j.Config.Days)
+	if err != nil {
+		fmt.Printf("DEBUG: Failed to load retention settings: %v\n", err)
+		return
+	}
+
+	for _, a := range accounts {
+		if ctx.Err() != nil {
+			return
+		}
+		deleted, err := PurgeUser(ctx, a.id, now.AddDate(0, 0, -a.days))
+		if err != nil {
+			fmt.Printf("DEBUG: Failed to purge old records of user %d: %v\n", a.id, err)
+			continue
+		}
+		if deleted > 0 {
+			fmt.Printf("DEBUG: Purged %d records older than %d days of user %d\n", deleted, a.days, a.id)
+		}
+	}
+}
+
+// loadAccounts returns the accounts whose records expire, with their retention
+func loadAccounts(ctx context.Context, global int) ([]account, error) {
+	rows, err := config.DBFor(ctx).Query("SELECT id, retention_days, retention_override_days FROM users")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var accounts []account
+	for rows.Next() {
+		var id int
+		var days, overrideDays sql.NullInt64
+		if err := rows.Scan(&id, &days, &overrideDays); err != nil {
+			return nil, err
+		}
+		if effective := EffectiveDays(global, intPtr(days), intPtr(overrideDays)); effective > 0 {
+			accounts = append(accounts, account{id: id, days: effective})
+		}
+	}
+	return accounts, rows.Err()
+}
+
+// intPtr converts a nullable column
+func intPtr(v sql.NullInt64) *int {
+	if !v.Valid {
+		return nil
+	}
+	n := int(v.Int64)
+	return &n
+}
+
+// purges delete one batch of a user's records older than a cutoff; arguments are user ID and cutoff
+var purges = []string{
+	"DELETE FROM crawl_runs WHERE user_id = ? AND finished_at < ? LIMIT 10000",
+	"DELETE FROM crawl_logs WHERE url_id IN (SELECT id FROM urls WHERE user_id = ?) AND created_at < ? LIMIT 10000",
+	"DELETE FROM content_snapshots WHERE url_id IN (SELECT id FROM urls WHERE user_id = ?) AND created_at < ? LIMIT 10000",
+	"DELETE FROM broken_links WHERE url_id IN (SELECT id FROM urls WHERE user_id = ?) AND created_at < ? LIMIT 10000",
+}
+
+// PurgeUser deletes the user's crawl runs, crawl logs, text snapshots and broken link records from
+// before cutoff in the database of ctx's tenant, returning how many rows were deleted
+func PurgeUser(ctx context.Context, userID int, cutoff time.Time) (int64, error) {
+	total, err := purgeArchivedSnapshots(ctx, userID, cutoff)
+	if err != nil {
+		return total, err
+	}
+	for _, query := range purges {
+		for {
+			res, err := config.DBFor(ctx).Exec(query, userID, cutoff)
+			if err != nil {
+				return total, err
+			}
+			n, _ := res.RowsAffected()
+			total += n
+			if n < batchSize {
+				break
+			}
+		}
+	}
+	return total, nil
+}
+
+// purgeArchivedSnapshots deletes the user's archived snapshots from before cutoff along with their
+// objects in storage, returning how many rows were deleted
+func purgeArchivedSnapshots(ctx context.Context, userID int, cutoff time.Time) (int64, error) {
+	db := config.DBFor(ctx)
+	var total int64
+	for {
+		rows, err := db.Query(`
+			SELECT id, archive_key FROM content_snapshots
+			WHERE url_id IN (SELECT id FROM urls WHERE user_id = ?) AND created_at < ? AND archive_key IS NOT NULL
+			LIMIT 1000
+		`, userID, cutoff)
+		if err != nil {
+			return total, err
+		}
+		var ids []interface{}
+		var keys []string
+		for rows.Next() {
+			var id int64
+			var key string
+			if err := rows.Scan(&id, &key); err != nil {
+				rows.Close()
+				return total, err
+			}
+			ids = append(ids, id)
+			keys = append(keys, key)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return total, err
+		}
+		if len(ids) == 0 {
+			return total, nil
+		}
+
+		archive.Forget(ctx, keys)
+		in := strings.TrimSuffix(strings.Repeat("?,", len(ids)), ",")
+		res, err := db.Exec("DELETE FROM content_snapshots WHERE id IN ("+in+")", ids...)
+		if err != nil {
+			return total, err
+		}
+		n, _ := res.RowsAffected()
+		total += n
+	}
+}
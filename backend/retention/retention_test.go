@@ -0,0 +1,36 @@
+package retention
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEffectiveDays(t *testing.T) {
+	days := func(n int) *int { return &n }
+
+	t.Run("global limit", func(t *testing.T) {
+		assert.Equal(t, 0, EffectiveDays(0, nil, nil))
+		assert.Equal(t, 90, EffectiveDays(90, nil, nil))
+	})
+
+	t.Run("accounts may keep records for fewer days", func(t *testing.T) {
+		assert.Equal(t, 30, EffectiveDays(90, days(30), nil))
+		assert.Equal(t, 90, EffectiveDays(90, days(365), nil))
+		assert.Equal(t, 365, EffectiveDays(0, days(365), nil))
+	})
+
+	t.Run("administrator override wins", func(t *testing.T) {
+		assert.Equal(t, 365, EffectiveDays(90, days(30), days(365)))
+		assert.Equal(t, 0, EffectiveDays(90, days(30), days(0)))
+	})
+}
+
+func TestPurges(t *testing.T) {
+	for _, query := range purges {
+		assert.Equal(t, 2, strings.Count(query, "?"), query)
+		assert.True(t, strings.HasSuffix(query, fmt.Sprintf("LIMIT %d", batchSize)), query)
+	}
+}
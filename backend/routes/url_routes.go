@@ -1,6 +1,8 @@
 package routes
 
 import (
+	"sykell-analyze/backend/cache"
+	"sykell-analyze/backend/config"
 	"sykell-analyze/backend/handlers"
 	"sykell-analyze/backend/middleware"
 
@@ -8,6 +10,15 @@ import (
 )
 
 func RegisterRoutes(router *gin.Engine) {
+	// Badges of shared URLs, embeddable in READMEs and dashboards (no authentication required)
+	router.GET("/public/badge/:badge", handlers.GetBadge) // :badge is "<token>.svg"
+
+	// Account data archives, linked from the email sent when they are ready
+	router.GET("/public/exports/:file", handlers.DownloadDataExport) // :file is "<token>.zip"
+
+	// URL exports built in the background; downloads answer Range requests to resume
+	router.GET("/public/downloads/:file", handlers.DownloadExport) // :file is "<token>.<format>"
+
 	api := router.Group("/api")
 	{
 		// Public routes (no authentication required)
@@ -15,29 +26,125 @@ func RegisterRoutes(router *gin.Engine) {
 		{
 			auth.POST("/register", handlers.Register)
 			auth.POST("/login", handlers.Login)
+			auth.POST("/demo", handlers.DemoLogin) // Read-only token for the demo account
 		}
 
 		// Protected routes (authentication required)
 		protected := api.Group("/")
-		protected.Use(middleware.AuthMiddleware())
+		protected.Use(middleware.AuthMiddleware(), middleware.InvalidateCacheOnWrite(), middleware.ReadYourWrites())
+		cached := middleware.CacheResponse(cache.TTL)
 		{
 			// User profile
 			protected.GET("/profile", handlers.GetProfile)
+			protected.PUT("/profile/timezone", handlers.UpdateTimezone)           // Time zone of exports and digests
+			protected.GET("/profile/digest", handlers.GetDigestSettings)          // Activity digest schedule
+			protected.PUT("/profile/digest", handlers.UpdateDigestSettings)       // Subscribe to or stop the digest
+			protected.POST("/profile/export", handlers.RequestDataExport)         // Build an archive of all account data
+			protected.GET("/profile/exports", handlers.GetDataExports)            // Account data archives and their download links
+			protected.GET("/profile/retention", handlers.GetRetentionSettings)    // How long crawl history is kept
+			protected.PUT("/profile/retention", handlers.UpdateRetentionSettings) // Keep crawl history for fewer days
+			protected.GET("/profile/tokens", handlers.GetServiceTokens)           // Service accounts of integrations
+			protected.POST("/profile/tokens", handlers.AddServiceToken)           // Create a service account token with scopes
+			protected.DELETE("/profile/tokens/:id", handlers.DeleteServiceToken)  // Revoke a service account token
 			protected.POST("/auth/refresh", handlers.RefreshToken)
 
+			// Settings
+			protected.GET("/settings", handlers.GetSettings)    // Crawl defaults for new URLs and notification preferences
+			protected.PUT("/settings", handlers.UpdateSettings) // Change crawl defaults or notification preferences
+
 			// URL management endpoints
-			protected.POST("/urls", handlers.AddUrl)                    // Add new URL for analysis
-			protected.GET("/urls", handlers.GetUrls)                    // Get all URLs with pagination/filtering
-			protected.GET("/urls/:id", handlers.GetUrlByID)             // Get specific URL with details
-			protected.DELETE("/urls/:id", handlers.DeleteUrl)           // Delete URL
-			protected.PUT("/urls/:id/reanalyze", handlers.ReanalyzeUrl) // Reanalyze URL
+			protected.POST("/urls", handlers.AddUrl)                         // Add new URL for analysis
+			protected.GET("/urls", cached, handlers.GetUrls)                 // Get all URLs with pagination/filtering
+			protected.GET("/urls/:id", handlers.GetUrlByID)                  // Get specific URL with details
+			protected.DELETE("/urls/:id", handlers.DeleteUrl)                // Delete URL
+			protected.PUT("/urls/:id/reanalyze", handlers.ReanalyzeUrl)      // Reanalyze URL
+			protected.GET("/urls/:id/logs", handlers.GetUrlLogs)             // Crawl lifecycle log
+			protected.GET("/urls/:id/runs", handlers.GetUrlRuns)             // Crawl history with archived copies
+			protected.POST("/analyze", handlers.AnalyzeUrl)                  // Analyze without saving (dry run)
+			protected.POST("/urls/:id/share", handlers.ShareUrl)             // Publish the URL's status badge
+			protected.DELETE("/urls/:id/share", handlers.UnshareUrl)         // Revoke the status badge
+			protected.GET("/urls/:id/keywords", handlers.GetUrlKeywords)     // Target keywords
+			protected.PUT("/urls/:id/keywords", handlers.SetUrlKeywords)     // Replace target keywords
+			protected.GET("/urls/:id/lighthouse", handlers.GetUrlLighthouse) // Latest Lighthouse scores
+			protected.GET("/urls/:id/uptime", handlers.GetUrlUptime)         // Uptime monitor pings and availability
+			protected.GET("/urls/:id/snapshots", handlers.GetUrlSnapshots)   // Text snapshots of recent crawls
+			protected.GET("/urls/:id/diff", handlers.GetUrlDiff)             // Text added and removed between crawls
+
+			// Broken links across all URLs
+			protected.GET("/broken-links", handlers.GetBrokenLinks) // Search links and error messages, e.g. for a dead domain
+
+			// Saved views of the URL list
+			protected.GET("/views", handlers.GetViews)          // List saved filter and sort combinations
+			protected.POST("/views", handlers.AddView)          // Save a view
+			protected.PUT("/views/:id", handlers.UpdateView)    // Rename a view or change its filters
+			protected.DELETE("/views/:id", handlers.DeleteView) // Delete a view
+
+			// Files generated from crawl results
+			protected.GET("/urls/:id/generated-sitemap.xml", handlers.GetGeneratedSitemap) // sitemap.xml of the crawled site
+			protected.GET("/export", handlers.ExportUrls)                                  // Workbook of URLs, broken links and SEO findings
+			protected.POST("/exports", handlers.RequestExport)                             // Build an XLSX, CSV or ZIP export in the background
+			protected.GET("/exports", handlers.GetExports)                                 // Past exports with their status and download links
+
+			// Crawl queue
+			protected.GET("/queue", handlers.GetQueue)                   // Your waiting and running crawl jobs
+			protected.DELETE("/queue/:job_id", handlers.CancelQueuedJob) // Remove a job before it starts
+			protected.PUT("/urls/:id/pause", handlers.PauseUrl)          // Hold or stop the URL's crawl
+			protected.PUT("/urls/:id/resume", handlers.ResumeUrl)        // Release a paused crawl
 
 			// Bulk operations
-			protected.DELETE("/urls/bulk", handlers.BulkDelete)           // Delete multiple URLs
-			protected.PUT("/urls/bulk/reanalyze", handlers.BulkReanalyze) // Reanalyze multiple URLs
+			protected.POST("/urls/bulk", handlers.AddUrlsBulk)               // Add multiple URLs
+			protected.POST("/urls/refresh-stale", handlers.RefreshStaleUrls) // Reanalyze all stale URLs
+			protected.DELETE("/urls/bulk", handlers.BulkDelete)              // Delete multiple URLs
+			protected.PUT("/urls/bulk/reanalyze", handlers.BulkReanalyze)    // Reanalyze multiple URLs
+			protected.PUT("/urls/bulk/tags", handlers.BulkTagUrls)           // Add, remove or replace tags of multiple URLs
+			protected.PUT("/urls/bulk/project", handlers.BulkMoveToProject)  // Move multiple URLs to a project
+
+			// Imports
+			protected.POST("/import/sitemap", handlers.ImportSitemap)     // Queue the pages of a site's sitemaps
+			protected.POST("/import/bookmarks", handlers.ImportBookmarks) // Queue the links of a browser bookmarks export
+
+			// Duplicate URLs
+			protected.GET("/urls/duplicates", handlers.GetDuplicateUrls)          // URLs pointing to the same page
+			protected.POST("/urls/duplicates/merge", handlers.MergeDuplicateUrls) // Keep one URL per duplicate group
+
+			// Link check exclusions
+			protected.GET("/link-exclusions", handlers.GetLinkExclusions)          // List exclusion patterns
+			protected.POST("/link-exclusions", handlers.AddLinkExclusion)          // Add exclusion pattern
+			protected.DELETE("/link-exclusions/:id", handlers.DeleteLinkExclusion) // Delete exclusion pattern
+
+			// Custom CSS selector checks
+			protected.GET("/check-rules", handlers.GetCheckRules)          // List check rules
+			protected.POST("/check-rules", handlers.AddCheckRule)          // Add check rule
+			protected.DELETE("/check-rules/:id", handlers.DeleteCheckRule) // Delete check rule
+
+			// Alerts on crawl results and uptime pings
+			protected.GET("/alert-rules", handlers.GetAlertRules)          // List alert rules
+			protected.POST("/alert-rules", handlers.AddAlertRule)          // Add alert rule
+			protected.DELETE("/alert-rules/:id", handlers.DeleteAlertRule) // Delete alert rule
 
 			// Statistics
-			protected.GET("/stats", handlers.GetStats) // Get user statistics
+			protected.GET("/stats", cached, handlers.GetStats)                      // Get user statistics
+			protected.GET("/stats/timeseries", cached, handlers.GetStatsTimeseries) // Get daily crawl trends
+			protected.GET("/stats/domains", cached, handlers.GetDomainStats)        // Get per-domain rollup
+		}
+
+		// Operator endpoints
+		admin := protected.Group("/admin")
+		admin.Use(middleware.RequireAdmin())
+		{
+			admin.PUT("/jobs/:id/priority", handlers.SetJobPriority)        // Move a crawl job up or down the queue
+			admin.PUT("/users/:id/crawl-limit", handlers.SetUserCrawlLimit) // Set a user's concurrent crawl limit
+			admin.PUT("/users/:id/retention", handlers.SetUserRetention)    // Override a user's retention
+			admin.POST("/reload", handlers.ReloadConfig)                    // Apply tunable settings of the config file
+			admin.GET("/metrics", handlers.GetMetrics)                      // Database pool and query timings
+			admin.POST("/backfill", handlers.BackfillResults)               // Recrawl analyses stored by an older analyzer
+			admin.GET("/tenants", handlers.ListTenants)                     // Tenants served by this deployment
+			admin.POST("/tenants", handlers.CreateTenant)                   // Provision a tenant database
+
+			// CPU, heap, goroutine and other profiles, for profiling crawls under load
+			if config.App != nil && config.App.Server.Pprof {
+				admin.GET("/debug/pprof/*profile", handlers.Pprof)
+			}
 		}
 	}
 }
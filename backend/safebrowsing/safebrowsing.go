@@ -0,0 +1,210 @@
+// Package safebrowsing looks URLs up in Google's Safe Browsing threat lists with the v4 Lookup API.
+package safebrowsing
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"sykell-analyze/backend/config"
+	"sykell-analyze/backend/version"
+)
+
+// Endpoint is the Lookup API method that matches URLs against the threat lists
+const Endpoint = "https://safebrowsing.googleapis.com/v4/threatMatches:find"
+
+// maxEntries is the most URLs the API accepts in one request
+const maxEntries = 500
+
+// pruneAbove is the cache size at which expired entries are dropped
+const pruneAbove = 10000
+
+// threatTypes are the lists every URL is checked against
+var threatTypes = []string{"MALWARE", "SOCIAL_ENGINEERING", "UNWANTED_SOFTWARE", "POTENTIALLY_HARMFUL_APPLICATION"}
+
+// Default is nil when lookups are disabled (safe_browsing.api_key unset)
+var Default *Client
+
+// Configure enables lookups when an API key is configured
+func Configure(cfg config.SafeBrowsingConfig) {
+	if cfg.APIKey == "" {
+		Default = nil
+		return
+	}
+	Default = New(cfg.APIKey, cfg.CacheTTL, cfg.Timeout)
+	fmt.Println("✅ Safe Browsing lookups enabled.")
+}
+
+// Client looks URLs up and caches the verdicts so repeated crawls of a site stay within the API quota.
+// It is safe for concurrent use.
+type Client struct {
+	APIKey   string
+	Endpoint string
+	// CacheTTL is how long unlisted URLs are cached; listed URLs are cached for the duration the API returns
+	CacheTTL   time.Duration
+	HTTPClient *http.Client
+
+	mu    sync.Mutex
+	cache map[string]cacheEntry
+}
+
+// cacheEntry is a cached verdict; types is empty for URLs that are not listed
+type cacheEntry struct {
+	types   []string
+	expires time.Time
+}
+
+// New creates a client for the public API
+func New(apiKey string, cacheTTL, timeout time.Duration) *Client {
+	return &Client{
+		APIKey:     apiKey,
+		Endpoint:   Endpoint,
+		CacheTTL:   cacheTTL,
+		HTTPClient: &http.Client{Timeout: timeout},
+		cache:      make(map[string]cacheEntry),
+	}
+}
+
+// CheckURLs returns the threat types of every listed URL, answering from the cache where possible.
+// It implements analyzer.ThreatChecker.
+func (c *Client) CheckURLs(ctx context.Context, urls []string) (map[string][]string, error) {
+	listed := make(map[string][]string)
+	var misses []string
+
+	now := time.Now()
+	c.mu.Lock()
+	for _, u := range urls {
+		if entry, ok := c.cache[u]; ok && now.Before(entry.expires) {
+			if len(entry.types) > 0 {
+				listed[u] = entry.types
+			}
+			continue
+		}
+		misses = append(misses, u)
+	}
+	c.mu.Unlock()
+
+	for start := 0; start < len(misses); start += maxEntries {
+		batch := misses[start:min(start+maxEntries, len(misses))]
+		matches, err := c.find(ctx, batch)
+		if err != nil {
+			return nil, err
+		}
+
+		now := time.Now()
+		c.mu.Lock()
+		if len(c.cache) > pruneAbove {
+			for u, entry := range c.cache {
+				if !now.Before(entry.expires) {
+					delete(c.cache, u)
+				}
+			}
+		}
+		for _, u := range batch {
+			entry := cacheEntry{expires: now.Add(c.CacheTTL)}
+			if m, ok := matches[u]; ok {
+				entry.types = m.types
+				if m.cacheDuration > 0 {
+					entry.expires = now.Add(m.cacheDuration)
+				}
+				listed[u] = m.types
+			}
+			c.cache[u] = entry
+		}
+		c.mu.Unlock()
+	}
+	return listed, nil
+}
+
+// match collects the threat types of one listed URL
+type match struct {
+	types         []string
+	cacheDuration time.Duration
+}
+
+// findRequest is the body of a threatMatches:find call
+type findRequest struct {
+	Client struct {
+		ClientID      string `json:"clientId"`
+		ClientVersion string `json:"clientVersion"`
+	} `json:"client"`
+	ThreatInfo struct {
+		ThreatTypes      []string      `json:"threatTypes"`
+		PlatformTypes    []string      `json:"platformTypes"`
+		ThreatEntryTypes []string      `json:"threatEntryTypes"`
+		ThreatEntries    []threatEntry `json:"threatEntries"`
+	} `json:"threatInfo"`
+}
+
+type threatEntry struct {
+	URL string `json:"url"`
+}
+
+// findResponse is the answer of a threatMatches:find call; it has no matches when nothing is listed
+type findResponse struct {
+	Matches []threatMatch `json:"matches"`
+}
+
+// threatMatch is a URL found on one threat list
+type threatMatch struct {
+	ThreatType    string      `json:"threatType"`
+	Threat        threatEntry `json:"threat"`
+	CacheDuration string      `json:"cacheDuration"`
+}
+
+// find sends one lookup for at most maxEntries URLs
+func (c *Client) find(ctx context.Context, urls []string) (map[string]match, error) {
+	var body findRequest
+	body.Client.ClientID = "sykell-analyze"
+	body.Client.ClientVersion = version.Version
+	body.ThreatInfo.ThreatTypes = threatTypes
+	body.ThreatInfo.PlatformTypes = []string{"ANY_PLATFORM"}
+	body.ThreatInfo.ThreatEntryTypes = []string{"URL"}
+	for _, u := range urls {
+		body.ThreatInfo.ThreatEntries = append(body.ThreatInfo.ThreatEntries, threatEntry{URL: u})
+	}
+
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.Endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	// Sent as a header so the key never appears in error messages that include the URL
+	req.Header.Set("X-Goog-Api-Key", c.APIKey)
+
+	res, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("safe browsing lookup failed: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		io.Copy(io.Discard, io.LimitReader(res.Body, 64<<10))
+		return nil, fmt.Errorf("safe browsing lookup failed: %s", res.Status)
+	}
+
+	var decoded findResponse
+	if err := json.NewDecoder(res.Body).Decode(&decoded); err != nil {
+		return nil, fmt.Errorf("invalid safe browsing response: %w", err)
+	}
+
+	matches := make(map[string]match)
+	for _, m := range decoded.Matches {
+		entry := matches[m.Threat.URL]
+		entry.types = append(entry.types, m.ThreatType)
+		if d, err := time.ParseDuration(m.CacheDuration); err == nil && (entry.cacheDuration == 0 || d < entry.cacheDuration) {
+			entry.cacheDuration = d
+		}
+		matches[m.Threat.URL] = entry
+	}
+	return matches, nil
+}
@@ -0,0 +1,81 @@
+package safebrowsing
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeAPI lists every URL in listed as malware and counts the lookups it answers
+func fakeAPI(t *testing.T, listed map[string]bool, calls *int32) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(calls, 1)
+		if r.Header.Get("X-Goog-Api-Key") != "test-key" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+
+		var req findRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		assert.LessOrEqual(t, len(req.ThreatInfo.ThreatEntries), maxEntries)
+
+		var res findResponse
+		for _, entry := range req.ThreatInfo.ThreatEntries {
+			if listed[entry.URL] {
+				res.Matches = append(res.Matches, threatMatch{ThreatType: "MALWARE", Threat: entry, CacheDuration: "300s"})
+			}
+		}
+		json.NewEncoder(w).Encode(res)
+	}))
+}
+
+func TestCheckURLs(t *testing.T) {
+	var calls int32
+	api := fakeAPI(t, map[string]bool{"https://evil.example/": true}, &calls)
+	defer api.Close()
+
+	client := New("test-key", time.Minute, 5*time.Second)
+	client.Endpoint = api.URL
+
+	t.Run("reports listed URLs only", func(t *testing.T) {
+		listed, err := client.CheckURLs(context.Background(), []string{"https://example.com/", "https://evil.example/"})
+		require.NoError(t, err)
+		assert.Equal(t, map[string][]string{"https://evil.example/": {"MALWARE"}}, listed)
+		assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+	})
+
+	t.Run("answers repeated lookups from the cache", func(t *testing.T) {
+		listed, err := client.CheckURLs(context.Background(), []string{"https://evil.example/", "https://example.com/"})
+		require.NoError(t, err)
+		assert.Len(t, listed, 1)
+		assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+	})
+
+	t.Run("splits large lookups into batches", func(t *testing.T) {
+		atomic.StoreInt32(&calls, 0)
+		var urls []string
+		for i := 0; i < maxEntries+1; i++ {
+			urls = append(urls, fmt.Sprintf("https://site%d.example/", i))
+		}
+		_, err := client.CheckURLs(context.Background(), urls)
+		require.NoError(t, err)
+		assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+	})
+
+	t.Run("API errors do not leak the key", func(t *testing.T) {
+		bad := New("wrong-key", time.Minute, 5*time.Second)
+		bad.Endpoint = api.URL
+		_, err := bad.CheckURLs(context.Background(), []string{"https://example.org/"})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "403")
+		assert.NotContains(t, err.Error(), "wrong-key")
+	})
+}
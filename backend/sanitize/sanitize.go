@@ -0,0 +1,76 @@
+// Package sanitize cleans text taken from crawled pages (titles, error messages, link text) before it
+// is stored or returned by the API. Pages are arbitrary input: they can carry invalid UTF-8, control
+// characters, bidi overrides that disguise text, or megabytes in a <title>.
+package sanitize
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Length limits, in characters, of the stored fields
+const (
+	MaxTitle   = 500
+	MaxMessage = 1000
+	MaxURL     = 2048
+)
+
+// ellipsis marks text that was cut short
+const ellipsis = "…"
+
+// Line returns s as a single line of at most max characters: invalid UTF-8 is replaced, control and
+// invisible formatting characters are dropped, runs of whitespace become one space and the ends are
+// trimmed. Longer text is cut on a character boundary and ends with an ellipsis. max <= 0 means no limit.
+func Line(s string, max int) string {
+	s = strings.ToValidUTF8(s, "�")
+	s = strings.Map(func(r rune) rune {
+		switch {
+		case unicode.IsSpace(r):
+			return ' '
+		case unicode.IsControl(r), isFormat(r):
+			return -1
+		}
+		return r
+	}, s)
+	s = strings.Join(strings.Fields(s), " ")
+	return truncate(s, max)
+}
+
+// LinePtr is Line for nullable columns; nil stays nil
+func LinePtr(s *string, max int) *string {
+	if s == nil {
+		return nil
+	}
+	clean := Line(*s, max)
+	return &clean
+}
+
+// isFormat reports invisible characters that change how the text around them is displayed:
+// bidi marks, overrides and isolates, the zero-width space and the byte order mark. Zero-width
+// joiners stay, as scripts such as Persian and emoji sequences need them.
+func isFormat(r rune) bool {
+	switch {
+	case r == 0x200B, r == 0x200E, r == 0x200F, // zero-width space, LRM, RLM
+		r >= 0x202A && r <= 0x202E, // bidi embeddings and overrides
+		r >= 0x2066 && r <= 0x2069, // bidi isolates
+		r == 0xFEFF:
+		return true
+	}
+	return false
+}
+
+// truncate cuts s to at most max characters, ellipsis included
+func truncate(s string, max int) string {
+	if max <= 0 || utf8.RuneCountInString(s) <= max {
+		return s
+	}
+	cut := 0
+	for i := range s {
+		if cut == max-1 {
+			return strings.TrimRight(s[:i], " ") + ellipsis
+		}
+		cut++
+	}
+	return s
+}
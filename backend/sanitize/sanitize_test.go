@@ -0,0 +1,61 @@
+package sanitize
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLine(t *testing.T) {
+	t.Run("keeps ordinary text", func(t *testing.T) {
+		assert.Equal(t, "Café – Home", Line("Café – Home", MaxTitle))
+	})
+
+	t.Run("collapses whitespace and newlines", func(t *testing.T) {
+		assert.Equal(t, "Example Domain", Line("\n\t  Example\r\n   Domain  ", MaxTitle))
+	})
+
+	t.Run("drops control characters", func(t *testing.T) {
+		assert.Equal(t, "ab", Line("a\x00\x07\x1b\x7fb", MaxTitle))
+	})
+
+	t.Run("drops bidi overrides", func(t *testing.T) {
+		assert.Equal(t, "invoicefdp.exe", Line("invoice\u202efdp.exe", MaxTitle))
+		assert.Equal(t, "ab", Line("a\u2066\u200b\ufeffb", MaxTitle))
+	})
+
+	t.Run("keeps zero-width joiners", func(t *testing.T) {
+		assert.Equal(t, "می\u200cخواهم", Line("می\u200cخواهم", MaxTitle))
+	})
+
+	t.Run("replaces invalid UTF-8", func(t *testing.T) {
+		clean := Line("bad \xff\xfe bytes", MaxTitle)
+		assert.True(t, utf8.ValidString(clean))
+		assert.Equal(t, "bad � bytes", clean)
+	})
+
+	t.Run("truncates on a character boundary", func(t *testing.T) {
+		clean := Line(strings.Repeat("é", 20), 10)
+		assert.True(t, utf8.ValidString(clean))
+		assert.Equal(t, strings.Repeat("é", 9)+"…", clean)
+		assert.Equal(t, 10, utf8.RuneCountInString(clean))
+	})
+
+	t.Run("does not leave a space before the ellipsis", func(t *testing.T) {
+		assert.Equal(t, "abcd…", Line("abcd efgh", 6))
+	})
+
+	t.Run("no limit", func(t *testing.T) {
+		long := strings.Repeat("a", 5000)
+		assert.Equal(t, long, Line(long, 0))
+	})
+}
+
+func TestLinePtr(t *testing.T) {
+	assert.Nil(t, LinePtr(nil, MaxMessage))
+
+	message := "timeout\x00 while\nreading"
+	assert.Equal(t, "timeout while reading", *LinePtr(&message, MaxMessage))
+}
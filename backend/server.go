@@ -0,0 +1,99 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+
+	"sykell-analyze/backend/config"
+	"sykell-analyze/backend/handlers"
+	"sykell-analyze/backend/middleware"
+	"sykell-analyze/backend/routes"
+	"sykell-analyze/backend/tenancy"
+	"sykell-analyze/backend/version"
+	"sykell-analyze/backend/web"
+
+	"github.com/gin-contrib/cors"
+	"github.com/gin-gonic/gin"
+)
+
+// newRouter creates the Gin router with the middleware and routes of the API
+func newRouter(cfg *config.Config) *gin.Engine {
+	router := gin.Default()
+
+	// Configure CORS; origins are looked up per request so reloads apply
+	allowHeaders := []string{"Origin", "Content-Type", "Accept", "Authorization", "If-Match", middleware.RequestIDHeader}
+	if cfg.Tenancy.Enabled && cfg.Tenancy.Header != "" {
+		allowHeaders = append(allowHeaders, cfg.Tenancy.Header)
+	}
+	router.Use(cors.New(cors.Config{
+		AllowOriginFunc:  middleware.AllowedOrigin,
+		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
+		AllowHeaders:     allowHeaders,
+		ExposeHeaders:    []string{"ETag", middleware.RequestIDHeader},
+		AllowCredentials: true,
+	}))
+
+	// Tag every request with an ID for logs and timeout responses
+	router.Use(middleware.RequestID())
+
+	// Serve the tenant named by subdomain or header from its own database when tenancy is enabled
+	router.Use(middleware.Tenant(tenancy.Lookup))
+
+	// Compress larger JSON/text responses
+	router.Use(middleware.Gzip(cfg.Server.GzipMinSize))
+
+	// Report the running version in a header and in every JSON error
+	router.Use(middleware.VersionHeader())
+
+	// Answer in the client's Accept-Language where a translation exists
+	router.Use(middleware.Locale())
+
+	// Reject oversized request bodies with a 413; imports and bulk adds may send more than JSON requests
+	uploadLimit := int64(cfg.Server.MaxUploadBytes)
+	router.MaxMultipartMemory = uploadLimit
+	router.Use(middleware.BodyLimit(int64(cfg.Server.MaxBodyBytes), map[string]int64{
+		"/api/import/bookmarks": uploadLimit,
+		"/api/import/sitemap":   uploadLimit,
+		"/api/urls/bulk":        uploadLimit,
+	}))
+
+	// Health check route
+	router.GET("/api/health", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{
+			"message": "API is running!",
+			"status":  "healthy",
+			"version": version.Version,
+		})
+	})
+	router.GET("/api/health/live", handlers.Liveness)
+	router.GET("/api/health/ready", handlers.Readiness)
+	router.GET("/api/version", handlers.GetVersion)
+
+	// Register all API routes
+	routes.RegisterRoutes(router)
+
+	// Serve the embedded frontend; unknown paths get index.html for client-side routing
+	if cfg.Server.ServeFrontend {
+		if build, ok := web.Build(); ok {
+			router.NoRoute(web.Handler(build))
+			fmt.Println("🖥️  Serving the embedded frontend at /")
+		}
+	}
+
+	return router
+}
+
+// unbufferedPaths are served without the request deadline. Downloads of stored exports are streamed
+// from storage and may take longer than any request should, so they must not be buffered. CPU
+// profiles and traces run for as many seconds as the administrator asks.
+var unbufferedPaths = []string{
+	"/public/downloads/",
+	"/public/exports/",
+	"/api/admin/debug/pprof/",
+}
+
+// serverHandler wraps router in the server-side deadline. Handlers that outlive
+// server.request_timeout are answered with a 504 instead of holding the connection.
+func serverHandler(router http.Handler, cfg *config.Config) http.Handler {
+	return middleware.Timeout(router, cfg.Server.RequestTimeout, unbufferedPaths...)
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"sykell-analyze/backend/config"
+	"sykell-analyze/backend/middleware"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRangeRequestsThroughMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cfg := config.Default()
+	content := strings.Repeat("id,url\n", 3000)
+
+	router := newRouter(cfg)
+	router.GET("/test/urls.csv", func(c *gin.Context) {
+		c.Header("Content-Type", "text/csv; charset=utf-8")
+		http.ServeContent(c.Writer, c.Request, "urls.csv", time.Time{}, strings.NewReader(content))
+	})
+	handler := serverHandler(router, cfg)
+
+	download := func(rangeHeader string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/test/urls.csv", nil)
+		req.Header.Set("Accept-Encoding", "gzip")
+		if rangeHeader != "" {
+			req.Header.Set("Range", rangeHeader)
+		}
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("resumed download gets the exact bytes", func(t *testing.T) {
+		w := download("bytes=7000-")
+		assert.Equal(t, http.StatusPartialContent, w.Code)
+		assert.Empty(t, w.Header().Get("Content-Encoding"))
+		assert.Equal(t, "bytes 7000-20999/21000", w.Header().Get("Content-Range"))
+		assert.Equal(t, content[7000:], w.Body.String())
+	})
+
+	t.Run("whole download is not compressed either", func(t *testing.T) {
+		w := download("")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Empty(t, w.Header().Get("Content-Encoding"))
+		assert.Equal(t, content, w.Body.String())
+	})
+}
+
+// adminConnector is a database in which every user is an administrator: every query answers one
+// row holding true
+type adminConnector struct{}
+
+func (adminConnector) Connect(context.Context) (driver.Conn, error) { return adminConn{}, nil }
+func (adminConnector) Driver() driver.Driver                        { return nil }
+
+type adminConn struct{}
+
+func (adminConn) Prepare(query string) (driver.Stmt, error) { return adminStmt{}, nil }
+func (adminConn) Close() error                              { return nil }
+func (adminConn) Begin() (driver.Tx, error)                 { return nil, errors.New("not supported") }
+
+type adminStmt struct{}
+
+func (adminStmt) Close() error                                    { return nil }
+func (adminStmt) NumInput() int                                   { return -1 }
+func (adminStmt) Exec(args []driver.Value) (driver.Result, error) { return driver.RowsAffected(0), nil }
+func (adminStmt) Query(args []driver.Value) (driver.Rows, error)  { return &adminRows{}, nil }
+
+type adminRows struct{ done bool }
+
+func (r *adminRows) Columns() []string { return []string{"is_admin"} }
+func (r *adminRows) Close() error      { return nil }
+func (r *adminRows) Next(dest []driver.Value) error {
+	if r.done {
+		return io.EOF
+	}
+	r.done = true
+	dest[0] = true
+	return nil
+}
+
+func TestPprofOutlivesRequestTimeout(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cfg := config.Default()
+	cfg.Server.Pprof = true
+	cfg.Server.RequestTimeout = 200 * time.Millisecond
+
+	previousApp, previousDB := config.App, config.DB
+	config.App, config.DB = cfg, sql.OpenDB(adminConnector{})
+	defer func() {
+		config.DB.Close()
+		config.App, config.DB = previousApp, previousDB
+	}()
+
+	token, err := middleware.GenerateToken(context.Background(), 1, "admin")
+	require.NoError(t, err)
+	handler := serverHandler(newRouter(cfg), cfg)
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/api/admin/debug/pprof/profile?seconds=1", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	start := time.Now()
+	handler.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.GreaterOrEqual(t, time.Since(start), time.Second)
+	assert.NotEmpty(t, w.Body.Bytes())
+}
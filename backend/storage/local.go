@@ -0,0 +1,133 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// Local keeps objects as files under a directory, which should be a volume outside the container
+type Local struct {
+	root string
+}
+
+// NewLocal creates a store under root, creating the directory if needed
+func NewLocal(root string) (*Local, error) {
+	if err := os.MkdirAll(root, 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create storage directory: %w", err)
+	}
+	return &Local{root: root}, nil
+}
+
+// file maps a key to its path, rejecting keys that would leave the root
+func (l *Local) file(key string) (string, error) {
+	clean := path.Clean("/" + key)
+	if key == "" || clean != "/"+key {
+		return "", fmt.Errorf("invalid object key %q", key)
+	}
+	return filepath.Join(l.root, filepath.FromSlash(clean)), nil
+}
+
+// Put writes data under key through a temporary file, so readers never see a partial object
+func (l *Local) Put(ctx context.Context, key string, data []byte) error {
+	return l.PutStream(ctx, key, bytes.NewReader(data))
+}
+
+// PutStream copies r to a temporary file and moves it under key, so readers never see a partial object
+func (l *Local) PutStream(ctx context.Context, key string, r io.ReadSeeker) error {
+	name, err := l.file(key)
+	if err != nil {
+		return err
+	}
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(name), 0o750); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(name), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), name)
+}
+
+// Get reads the object stored under key
+func (l *Local) Get(ctx context.Context, key string) ([]byte, error) {
+	name, err := l.file(key)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	return data, err
+}
+
+// Open opens the file of the object stored under key
+func (l *Local) Open(ctx context.Context, key string) (io.ReadSeekCloser, error) {
+	name, err := l.file(key)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	return f, err
+}
+
+// Delete removes the object stored under key
+func (l *Local) Delete(ctx context.Context, key string) error {
+	name, err := l.file(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(name); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// DeletePrefix removes every object whose key starts with prefix. Emptied directories are left behind.
+func (l *Local) DeletePrefix(ctx context.Context, prefix string) error {
+	// Only the directory holding the prefix can contain matching keys
+	dir := path.Dir("/" + prefix)
+	root := filepath.Join(l.root, filepath.FromSlash(dir))
+	err := filepath.WalkDir(root, func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || strings.HasPrefix(d.Name(), ".upload-") {
+			return nil
+		}
+		rel, err := filepath.Rel(l.root, name)
+		if err != nil {
+			return err
+		}
+		if strings.HasPrefix(filepath.ToSlash(rel), prefix) {
+			return os.Remove(name)
+		}
+		return nil
+	})
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	return err
+}
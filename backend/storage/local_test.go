@@ -0,0 +1,81 @@
+package storage
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocal(t *testing.T) {
+	root := t.TempDir()
+	l, err := NewLocal(filepath.Join(root, "artifacts"))
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	t.Run("round trip", func(t *testing.T) {
+		require.NoError(t, l.Put(ctx, "exports/1/2.zip", []byte("zip")))
+		data, err := l.Get(ctx, "exports/1/2.zip")
+		require.NoError(t, err)
+		assert.Equal(t, "zip", string(data))
+
+		require.NoError(t, l.Put(ctx, "exports/1/2.zip", []byte("newer")))
+		data, _ = l.Get(ctx, "exports/1/2.zip")
+		assert.Equal(t, "newer", string(data))
+	})
+
+	t.Run("streamed upload", func(t *testing.T) {
+		content := strings.NewReader("id,url\n1,https://example.com\n")
+		content.Seek(3, io.SeekStart)
+		require.NoError(t, l.PutStream(ctx, "exports/1/urls-1.csv", content))
+		data, err := l.Get(ctx, "exports/1/urls-1.csv")
+		require.NoError(t, err)
+		assert.Equal(t, "id,url\n1,https://example.com\n", string(data))
+	})
+
+	t.Run("ranged reads", func(t *testing.T) {
+		require.NoError(t, l.Put(ctx, "exports/1/urls-3.csv", []byte("0123456789")))
+		f, err := l.Open(ctx, "exports/1/urls-3.csv")
+		require.NoError(t, err)
+		defer f.Close()
+
+		_, err = f.Seek(7, io.SeekStart)
+		require.NoError(t, err)
+		rest, err := io.ReadAll(f)
+		require.NoError(t, err)
+		assert.Equal(t, "789", string(rest))
+	})
+
+	t.Run("missing objects", func(t *testing.T) {
+		_, err := l.Get(ctx, "exports/9/9.zip")
+		assert.ErrorIs(t, err, ErrNotFound)
+		_, err = l.Open(ctx, "exports/9/9.zip")
+		assert.ErrorIs(t, err, ErrNotFound)
+		assert.NoError(t, l.Delete(ctx, "exports/9/9.zip"))
+		assert.NoError(t, l.DeletePrefix(ctx, "snapshots/9/"))
+	})
+
+	t.Run("delete by prefix", func(t *testing.T) {
+		require.NoError(t, l.Put(ctx, "snapshots/1/2/4.json", []byte("a")))
+		require.NoError(t, l.Put(ctx, "snapshots/1/20/5.json", []byte("b")))
+		require.NoError(t, l.DeletePrefix(ctx, "snapshots/1/2/"))
+
+		_, err := l.Get(ctx, "snapshots/1/2/4.json")
+		assert.ErrorIs(t, err, ErrNotFound)
+		_, err = l.Get(ctx, "snapshots/1/20/5.json")
+		assert.NoError(t, err)
+	})
+
+	t.Run("keys stay inside the root", func(t *testing.T) {
+		for _, key := range []string{"", "../outside", "exports/../../outside", "/abs", "exports//x"} {
+			assert.Error(t, l.Put(ctx, key, []byte("x")), key)
+		}
+		_, err := os.Stat(filepath.Join(root, "outside"))
+		assert.True(t, os.IsNotExist(err))
+	})
+}
@@ -0,0 +1,383 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"sykell-analyze/backend/config"
+)
+
+// S3 is a bucket of an S3-compatible service, reached with AWS Signature Version 4
+type S3 struct {
+	endpoint  *url.URL
+	region    string
+	bucket    string
+	accessKey string
+	secretKey string
+	pathStyle bool
+	client    *http.Client
+	// stream reads objects opened with Open, bounded by their context rather than a timeout, since a
+	// large download may take longer than any request should
+	stream *http.Client
+	// now is replaced in tests to sign at a fixed time
+	now func() time.Time
+}
+
+// NewS3 creates a client for the bucket of cfg
+func NewS3(cfg config.StorageConfig) *S3 {
+	endpoint, _ := url.Parse(strings.TrimSuffix(cfg.Endpoint, "/"))
+	return &S3{
+		endpoint:  endpoint,
+		region:    cfg.Region,
+		bucket:    cfg.Bucket,
+		accessKey: cfg.AccessKey,
+		secretKey: cfg.SecretKey,
+		pathStyle: cfg.PathStyle,
+		client:    &http.Client{Timeout: cfg.Timeout},
+		stream:    &http.Client{Transport: &http.Transport{Proxy: http.ProxyFromEnvironment, ResponseHeaderTimeout: cfg.Timeout}},
+		now:       time.Now,
+	}
+}
+
+// objectURL addresses a key, in the path (MinIO) or as a subdomain of the endpoint (AWS)
+func (s *S3) objectURL(key string, query url.Values) *url.URL {
+	u := *s.endpoint
+	if s.pathStyle {
+		u.Path = "/" + s.bucket + "/" + key
+	} else {
+		u.Host = s.bucket + "." + u.Host
+		u.Path = "/" + key
+	}
+	u.RawQuery = canonicalQuery(query)
+	return &u
+}
+
+func (s *S3) do(ctx context.Context, method, key string, query url.Values, body []byte) (*http.Response, error) {
+	return s.doWithHeaders(ctx, s.client, method, key, query, body, nil)
+}
+
+// doWithHeaders sends a signed request with extra headers, which are signed too
+func (s *S3) doWithHeaders(ctx context.Context, client *http.Client, method, key string, query url.Values, body []byte, headers http.Header) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, s.objectURL(key, query).String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	for name, values := range headers {
+		req.Header[name] = values
+	}
+	sum := sha256.Sum256(body)
+	s.sign(req, hex.EncodeToString(sum[:]), s.now())
+	return client.Do(req)
+}
+
+// Put stores data under key, replacing any object there
+func (s *S3) Put(ctx context.Context, key string, data []byte) error {
+	resp, err := s.do(ctx, http.MethodPut, key, nil, data)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return s3Error(resp, http.StatusOK)
+}
+
+// PutStream stores the content of r under key. r is read twice, once to hash the payload for the
+// signature and once to upload it, so nothing but the copy buffer is held in memory.
+func (s *S3) PutStream(ctx context.Context, key string, r io.ReadSeeker) error {
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	hash := sha256.New()
+	size, err := io.Copy(hash, r)
+	if err != nil {
+		return err
+	}
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, s.objectURL(key, nil).String(), io.NopCloser(r))
+	if err != nil {
+		return err
+	}
+	req.ContentLength = size
+	if size == 0 {
+		req.Body = http.NoBody
+	}
+	s.sign(req, hex.EncodeToString(hash.Sum(nil)), s.now())
+
+	// Large uploads are bounded by ctx rather than the request timeout, like downloads
+	resp, err := s.stream.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return s3Error(resp, http.StatusOK)
+}
+
+// Get returns the object stored under key
+func (s *S3) Get(ctx context.Context, key string) ([]byte, error) {
+	resp, err := s.do(ctx, http.MethodGet, key, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrNotFound
+	}
+	if err := s3Error(resp, http.StatusOK); err != nil {
+		return nil, err
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// Open looks up the size of the object stored under key; reads then fetch it in ranges from the
+// current offset, so a seek costs nothing until the next read
+func (s *S3) Open(ctx context.Context, key string) (io.ReadSeekCloser, error) {
+	resp, err := s.do(ctx, http.MethodHead, key, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrNotFound
+	}
+	if err := s3Error(resp, http.StatusOK); err != nil {
+		return nil, err
+	}
+	return &s3Object{s: s, ctx: ctx, key: key, size: resp.ContentLength}, nil
+}
+
+// s3Object reads an object from its offset with Range requests
+type s3Object struct {
+	s      *S3
+	ctx    context.Context
+	key    string
+	size   int64
+	offset int64
+	// body is the open response from offset on, nil after a seek
+	body io.ReadCloser
+}
+
+func (o *s3Object) Read(p []byte) (int, error) {
+	if o.offset >= o.size {
+		return 0, io.EOF
+	}
+	if o.body == nil {
+		headers := http.Header{"Range": {fmt.Sprintf("bytes=%d-", o.offset)}}
+		resp, err := o.s.doWithHeaders(o.ctx, o.s.stream, http.MethodGet, o.key, nil, nil, headers)
+		if err != nil {
+			return 0, err
+		}
+		if err := s3Error(resp, http.StatusPartialContent, http.StatusOK); err != nil {
+			resp.Body.Close()
+			return 0, err
+		}
+		o.body = resp.Body
+		// A service ignoring Range sends the whole object
+		if resp.StatusCode == http.StatusOK && o.offset > 0 {
+			if _, err := io.CopyN(io.Discard, o.body, o.offset); err != nil {
+				return 0, err
+			}
+		}
+	}
+	n, err := o.body.Read(p)
+	o.offset += int64(n)
+	return n, err
+}
+
+func (o *s3Object) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekCurrent:
+		offset += o.offset
+	case io.SeekEnd:
+		offset += o.size
+	}
+	if offset < 0 {
+		return 0, errors.New("seek before the start of the object")
+	}
+	if offset != o.offset && o.body != nil {
+		o.body.Close()
+		o.body = nil
+	}
+	o.offset = offset
+	return offset, nil
+}
+
+func (o *s3Object) Close() error {
+	if o.body != nil {
+		return o.body.Close()
+	}
+	return nil
+}
+
+// Delete removes the object stored under key
+func (s *S3) Delete(ctx context.Context, key string) error {
+	resp, err := s.do(ctx, http.MethodDelete, key, nil, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil
+	}
+	return s3Error(resp, http.StatusNoContent, http.StatusOK)
+}
+
+// listResult is the part of a ListObjectsV2 response that is used
+type listResult struct {
+	Keys                  []string `xml:"Contents>Key"`
+	IsTruncated           bool     `xml:"IsTruncated"`
+	NextContinuationToken string   `xml:"NextContinuationToken"`
+}
+
+// DeletePrefix lists the objects under prefix and deletes them one by one
+func (s *S3) DeletePrefix(ctx context.Context, prefix string) error {
+	token := ""
+	for {
+		query := url.Values{"list-type": {"2"}, "prefix": {prefix}}
+		if token != "" {
+			query.Set("continuation-token", token)
+		}
+		resp, err := s.do(ctx, http.MethodGet, "", query, nil)
+		if err != nil {
+			return err
+		}
+		var list listResult
+		if err := s3Error(resp, http.StatusOK); err != nil {
+			resp.Body.Close()
+			return err
+		}
+		err = xml.NewDecoder(resp.Body).Decode(&list)
+		resp.Body.Close()
+		if err != nil {
+			return fmt.Errorf("failed to read object list: %w", err)
+		}
+
+		for _, key := range list.Keys {
+			if err := s.Delete(ctx, key); err != nil {
+				return err
+			}
+		}
+		if !list.IsTruncated || list.NextContinuationToken == "" {
+			return nil
+		}
+		token = list.NextContinuationToken
+	}
+}
+
+// s3Error turns an unexpected status into an error with the service's message
+func s3Error(resp *http.Response, expected ...int) error {
+	for _, code := range expected {
+		if resp.StatusCode == code {
+			return nil
+		}
+	}
+	var body struct {
+		Code    string `xml:"Code"`
+		Message string `xml:"Message"`
+	}
+	xml.NewDecoder(io.LimitReader(resp.Body, 64*1024)).Decode(&body)
+	if body.Code != "" {
+		return fmt.Errorf("object storage returned %d %s: %s", resp.StatusCode, body.Code, body.Message)
+	}
+	return fmt.Errorf("object storage returned %d", resp.StatusCode)
+}
+
+// sign adds the AWS Signature Version 4 headers to req. Every header already set is signed.
+func (s *S3) sign(req *http.Request, payloadHash string, now time.Time) {
+	amzDate := now.UTC().Format("20060102T150405Z")
+	day := amzDate[:8]
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		headers[strings.ToLower(name)] = strings.TrimSpace(strings.Join(values, ","))
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		uriEncode(req.URL.Path, false),
+		req.URL.RawQuery,
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := day + "/" + s.region + "/s3/aws4_request"
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(requestHash[:])
+
+	key := hmacSHA256([]byte("AWS4"+s.secretKey), day)
+	key = hmacSHA256(key, s.region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.accessKey, scope, signedHeaders, signature))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// canonicalQuery encodes query parameters sorted by name, as signing needs them
+func canonicalQuery(query url.Values) string {
+	if len(query) == 0 {
+		return ""
+	}
+	names := make([]string, 0, len(query))
+	for name := range query {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var parts []string
+	for _, name := range names {
+		for _, value := range query[name] {
+			parts = append(parts, uriEncode(name, true)+"="+uriEncode(value, true))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+// uriEncode percent-encodes everything but unreserved characters, and slashes unless encodeSlash
+func uriEncode(s string, encodeSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9', c == '-', c == '_', c == '.', c == '~':
+			b.WriteByte(c)
+		case c == '/' && !encodeSlash:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
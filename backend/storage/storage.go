@@ -0,0 +1,57 @@
+// Package storage keeps large artifacts such as archived snapshots and data exports outside the
+// database, on a local volume or in S3-compatible object storage such as AWS S3 or MinIO.
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"sykell-analyze/backend/config"
+)
+
+// ErrNotFound is returned by Get for a key with no object
+var ErrNotFound = errors.New("object not found")
+
+// Store holds objects by key. Keys are slash-separated paths such as snapshots/12/345.json.
+type Store interface {
+	Put(ctx context.Context, key string, data []byte) error
+	// PutStream stores the content of r from its start under key without holding it in memory,
+	// for files too large to build as a byte slice
+	PutStream(ctx context.Context, key string, r io.ReadSeeker) error
+	// Get returns ErrNotFound when the key has no object
+	Get(ctx context.Context, key string) ([]byte, error)
+	// Open returns the object for reading parts of it, for large downloads and Range requests.
+	// It returns ErrNotFound when the key has no object.
+	Open(ctx context.Context, key string) (io.ReadSeekCloser, error)
+	// Delete succeeds when the key has no object
+	Delete(ctx context.Context, key string) error
+	// DeletePrefix deletes every object whose key starts with prefix
+	DeletePrefix(ctx context.Context, prefix string) error
+}
+
+// current is the store set by Configure, nil until then
+var current Store
+
+// Configure sets up the backend selected by cfg (storage.backend: local or s3)
+func Configure(cfg config.StorageConfig) error {
+	switch cfg.Backend {
+	case "s3":
+		current = NewS3(cfg)
+	case "local":
+		local, err := NewLocal(cfg.Path)
+		if err != nil {
+			return err
+		}
+		current = local
+	default:
+		return fmt.Errorf("unknown storage backend %q", cfg.Backend)
+	}
+	return nil
+}
+
+// Default returns the configured store, or nil before Configure
+func Default() Store {
+	return current
+}
@@ -0,0 +1,51 @@
+package storage
+
+import (
+	"context"
+	"io"
+
+	"sykell-analyze/backend/config"
+)
+
+// For returns the store of ctx's tenant: the configured store with every key under
+// tenants/<name>/, so tenants never read or overwrite each other's objects. Without a tenant it is
+// Default. It returns nil before Configure.
+func For(ctx context.Context) Store {
+	if current == nil {
+		return nil
+	}
+	if name := config.TenantName(ctx); name != "" {
+		return prefixed{store: current, prefix: "tenants/" + name + "/"}
+	}
+	return current
+}
+
+// prefixed keeps the objects of a store under a key prefix
+type prefixed struct {
+	store  Store
+	prefix string
+}
+
+func (p prefixed) Put(ctx context.Context, key string, data []byte) error {
+	return p.store.Put(ctx, p.prefix+key, data)
+}
+
+func (p prefixed) PutStream(ctx context.Context, key string, r io.ReadSeeker) error {
+	return p.store.PutStream(ctx, p.prefix+key, r)
+}
+
+func (p prefixed) Get(ctx context.Context, key string) ([]byte, error) {
+	return p.store.Get(ctx, p.prefix+key)
+}
+
+func (p prefixed) Open(ctx context.Context, key string) (io.ReadSeekCloser, error) {
+	return p.store.Open(ctx, p.prefix+key)
+}
+
+func (p prefixed) Delete(ctx context.Context, key string) error {
+	return p.store.Delete(ctx, p.prefix+key)
+}
+
+func (p prefixed) DeletePrefix(ctx context.Context, prefix string) error {
+	return p.store.DeletePrefix(ctx, p.prefix+prefix)
+}
@@ -0,0 +1,46 @@
+package storage
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"sykell-analyze/backend/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFor(t *testing.T) {
+	previous := current
+	t.Cleanup(func() { current = previous })
+
+	current = nil
+	assert.Nil(t, For(context.Background()))
+
+	root := t.TempDir()
+	local, err := NewLocal(root)
+	require.NoError(t, err)
+	current = local
+
+	primary := context.Background()
+	acme := config.WithTenant(primary, &config.Tenant{Name: "acme"})
+	assert.Equal(t, Store(local), For(primary))
+
+	require.NoError(t, For(primary).Put(primary, "exports/1/urls-1.csv", []byte("primary")))
+	require.NoError(t, For(acme).Put(acme, "exports/1/urls-1.csv", []byte("acme")))
+
+	data, err := For(primary).Get(primary, "exports/1/urls-1.csv")
+	require.NoError(t, err)
+	assert.Equal(t, "primary", string(data))
+	data, err = For(acme).Get(acme, "exports/1/urls-1.csv")
+	require.NoError(t, err)
+	assert.Equal(t, "acme", string(data))
+	assert.FileExists(t, filepath.Join(root, "tenants", "acme", "exports", "1", "urls-1.csv"))
+
+	require.NoError(t, For(acme).DeletePrefix(acme, "exports/1/"))
+	_, err = For(acme).Get(acme, "exports/1/urls-1.csv")
+	assert.ErrorIs(t, err, ErrNotFound)
+	_, err = For(primary).Get(primary, "exports/1/urls-1.csv")
+	assert.NoError(t, err, "deleting a tenant's objects leaves the primary's alone")
+}
@@ -0,0 +1,266 @@
+// Package tenancy keeps the registry of tenants served by one process. Each tenant has a database of
+// its own, listed in the tenants table of the primary database; Provision creates it and
+// middleware.Tenant selects it per request.
+package tenancy
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	_ "github.com/go-sql-driver/mysql"
+
+	"sykell-analyze/backend/config"
+	"sykell-analyze/backend/migrations"
+)
+
+// watchInterval is how often Watch looks for tenants provisioned by other processes
+const watchInterval = time.Minute
+
+// namePattern is what a tenant may be called; it becomes part of a database name and a subdomain
+var namePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_]{0,31}$`)
+
+// ErrUnknown is returned by Lookup for names no tenant was provisioned with
+var ErrUnknown = errors.New("unknown tenant")
+
+// ErrInvalidName is returned by Provision for names that are not valid tenant names
+var ErrInvalidName = errors.New("tenant names use lowercase letters, digits and underscores, up to 32 characters")
+
+// ValidName reports whether name may be used as a tenant name
+func ValidName(name string) bool {
+	return namePattern.MatchString(name)
+}
+
+// tenants holds the tenants whose connection pools are open, by name
+var tenants sync.Map
+
+// Lookup returns the tenant called name with an open connection pool to its database
+func Lookup(name string) (*config.Tenant, error) {
+	if t, ok := tenants.Load(name); ok {
+		return t.(*config.Tenant), nil
+	}
+	if !ValidName(name) {
+		return nil, ErrUnknown
+	}
+
+	var database string
+	err := config.DB.QueryRow("SELECT database_name FROM tenants WHERE name = ?", name).Scan(&database)
+	if err == sql.ErrNoRows {
+		return nil, ErrUnknown
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to look up tenant %s: %w", name, err)
+	}
+	return open(name, database)
+}
+
+// open returns the tenant called name, opening a pool to database unless another caller did first
+func open(name, database string) (*config.Tenant, error) {
+	db, err := config.OpenTenantDB(database)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open the database of tenant %s: %w", name, err)
+	}
+	t, loaded := tenants.LoadOrStore(name, &config.Tenant{Name: name, Database: database, DB: db})
+	if loaded {
+		db.Close()
+	}
+	return t.(*config.Tenant), nil
+}
+
+// All returns every provisioned tenant, ordered by name
+func All() ([]*config.Tenant, error) {
+	rows, err := config.DB.Query("SELECT name, database_name FROM tenants ORDER BY name")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tenants: %w", err)
+	}
+	defer rows.Close()
+
+	var all []*config.Tenant
+	for rows.Next() {
+		var name, database string
+		if err := rows.Scan(&name, &database); err != nil {
+			return nil, err
+		}
+		t, err := open(name, database)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, t)
+	}
+	return all, rows.Err()
+}
+
+// Watch calls fn once for every provisioned tenant, including tenants provisioned later by this or
+// another process, until ctx is cancelled
+func Watch(ctx context.Context, fn func(*config.Tenant)) {
+	seen := make(map[string]bool)
+	ticker := time.NewTicker(watchInterval)
+	defer ticker.Stop()
+
+	for {
+		all, err := All()
+		if err != nil {
+			fmt.Printf("DEBUG: Failed to load tenants: %v\n", err)
+		}
+		for _, t := range all {
+			if !seen[t.Name] {
+				seen[t.Name] = true
+				fn(t)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Provision creates the database of tenant name from the schema file, grants database.user access to
+// it, applies the migrations and registers the tenant. Running it again for an existing tenant only
+// adds missing tables.
+func Provision(ctx context.Context, name string) (*config.Tenant, error) {
+	if !ValidName(name) {
+		return nil, ErrInvalidName
+	}
+	settings := config.App.Tenancy
+	schema, err := os.ReadFile(settings.SchemaFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the schema: %w", err)
+	}
+	database := settings.DatabasePrefix + name
+
+	admin := config.App.Database
+	if settings.AdminUser != "" {
+		admin.User = settings.AdminUser
+		admin.Password = settings.AdminPassword
+	}
+	admin.Name = ""
+	server, err := sql.Open("mysql", admin.DSN())
+	if err != nil {
+		return nil, err
+	}
+	defer server.Close()
+
+	if _, err := server.ExecContext(ctx, "CREATE DATABASE IF NOT EXISTS `"+database+"` CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci"); err != nil {
+		return nil, fmt.Errorf("create database: %w", err)
+	}
+
+	admin.Name = database
+	tenantAdmin, err := sql.Open("mysql", admin.DSN())
+	if err != nil {
+		return nil, err
+	}
+	defer tenantAdmin.Close()
+	for _, statement := range SchemaStatements(string(schema)) {
+		if _, err := tenantAdmin.ExecContext(ctx, statement); err != nil {
+			return nil, fmt.Errorf("apply schema: %w", err)
+		}
+	}
+
+	if user := config.App.Database.User; admin.User != user {
+		grant := fmt.Sprintf("GRANT ALL PRIVILEGES ON `%s`.* TO '%s'@'%%'", database, strings.ReplaceAll(user, "'", "''"))
+		if _, err := server.ExecContext(ctx, grant); err != nil {
+			return nil, fmt.Errorf("grant access to %s: %w", user, err)
+		}
+	}
+
+	t, err := open(name, database)
+	if err != nil {
+		return nil, err
+	}
+	// The schema file is current, so this records the migrations it already contains
+	if _, err := migrations.Run(t.DB); err != nil {
+		return nil, fmt.Errorf("migrate: %w", err)
+	}
+
+	_, err = config.DB.ExecContext(ctx,
+		"INSERT IGNORE INTO tenants (name, database_name) VALUES (?, ?)", name, database)
+	if err != nil {
+		return nil, fmt.Errorf("register tenant: %w", err)
+	}
+	return t, nil
+}
+
+// demoSeed matches the statement of the schema file that seeds the demo account; tenants get theirs
+// from demo.Seed, with the configured credentials, instead
+var demoSeed = regexp.MustCompile("(?is)^INSERT\\s+(IGNORE\\s+)?INTO\\s+`?users`?[\\s(]")
+
+// SchemaStatements splits a schema file into its statements. Semicolons end a statement only outside
+// quotes and comments; -- , # and /* */ comments are dropped, while /*! */ version comments, which
+// MySQL runs, are kept. The INSERT seeding the demo account is dropped as well.
+func SchemaStatements(schema string) []string {
+	var statements []string
+	var current strings.Builder
+	flush := func() {
+		statement := strings.TrimSpace(current.String())
+		current.Reset()
+		if statement != "" && !demoSeed.MatchString(statement) {
+			statements = append(statements, statement)
+		}
+	}
+
+	for i := 0; i < len(schema); i++ {
+		c := schema[i]
+		switch {
+		case c == '\'' || c == '"' || c == '`':
+			// Copy the quoted text, including doubled and (outside backticks) backslash-escaped quotes
+			end := i + 1
+			for end < len(schema) {
+				if schema[end] == '\\' && c != '`' {
+					end += 2
+					continue
+				}
+				if schema[end] == c {
+					if end+1 < len(schema) && schema[end+1] == c {
+						end += 2
+						continue
+					}
+					break
+				}
+				end++
+			}
+			end = min(end, len(schema)-1)
+			current.WriteString(schema[i : end+1])
+			i = end
+		case c == '#' || lineComment(schema[i:]):
+			// A line comment runs to the end of the line, which is kept
+			end := strings.IndexByte(schema[i:], '\n')
+			if end < 0 {
+				i = len(schema)
+				break
+			}
+			i += end - 1
+		case strings.HasPrefix(schema[i:], "/*"):
+			end := strings.Index(schema[i+2:], "*/")
+			if end < 0 {
+				end = len(schema) - i - 2
+			}
+			if strings.HasPrefix(schema[i:], "/*!") {
+				current.WriteString(schema[i:min(i+end+4, len(schema))])
+			} else {
+				current.WriteByte(' ')
+			}
+			i += end + 3
+		case c == ';':
+			flush()
+		default:
+			current.WriteByte(c)
+		}
+	}
+	flush()
+	return statements
+}
+
+// lineComment reports whether s starts with a -- comment, which MySQL only recognises when the
+// dashes are followed by whitespace or end the input
+func lineComment(s string) bool {
+	return strings.HasPrefix(s, "--") && (len(s) == 2 || unicode.IsSpace(rune(s[2])))
+}
@@ -0,0 +1,72 @@
+package tenancy
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidName(t *testing.T) {
+	for _, name := range []string{"acme", "acme_2", "9lives"} {
+		assert.True(t, ValidName(name), name)
+	}
+	for _, name := range []string{"Acme", "acme`; DROP DATABASE x", "", "_acme", "a-b", "acme.example"} {
+		assert.False(t, ValidName(name), name)
+	}
+}
+
+func TestLookupRejectsInvalidNames(t *testing.T) {
+	_, err := Lookup("acme`")
+	assert.ErrorIs(t, err, ErrUnknown)
+}
+
+func TestSchemaStatements(t *testing.T) {
+	schema := `-- Users
+CREATE TABLE IF NOT EXISTS users (
+    id INT PRIMARY KEY, -- the id; never reused
+    name VARCHAR(64)
+);
+
+-- Insert default user for development
+INSERT IGNORE INTO users (id, name) VALUES
+(1, 'demo'); -- password: password
+`
+	statements := SchemaStatements(schema)
+	require.Len(t, statements, 1)
+	assert.Contains(t, statements[0], "CREATE TABLE IF NOT EXISTS users")
+	assert.Contains(t, statements[0], "name VARCHAR(64)")
+	assert.NotContains(t, statements[0], "never reused")
+}
+
+func TestSchemaStatementsRespectQuotes(t *testing.T) {
+	schema := `/* Settings */
+CREATE TABLE IF NOT EXISTS settings (
+    name VARCHAR(64) COMMENT 'e.g. theme; see --help',
+    value VARCHAR(64) DEFAULT 'a;b -- c' # the value
+) /*!50100 COMMENT='one; two' */;
+INSERT INTO settings (name, value) VALUES ('it''s', "x\";y"), ('--', 'z');
+INSERT INTO ` + "`users`" + ` (id) VALUES (1);
+SELECT 1--1;
+`
+	statements := SchemaStatements(schema)
+	require.Len(t, statements, 3)
+	assert.Contains(t, statements[0], "COMMENT 'e.g. theme; see --help'")
+	assert.Contains(t, statements[0], "DEFAULT 'a;b -- c'")
+	assert.Contains(t, statements[0], "/*!50100 COMMENT='one; two' */")
+	assert.NotContains(t, statements[0], "the value")
+	assert.NotContains(t, statements[0], "Settings")
+	assert.Equal(t, `INSERT INTO settings (name, value) VALUES ('it''s', "x\";y"), ('--', 'z')`, statements[1])
+	assert.Equal(t, "SELECT 1--1", statements[2])
+}
+
+func TestSchemaStatementsOfInitSQL(t *testing.T) {
+	schema, err := os.ReadFile("../../sql/init.sql")
+	require.NoError(t, err)
+	for _, statement := range SchemaStatements(string(schema)) {
+		assert.NotContains(t, strings.ToUpper(statement), "INSERT", statement)
+		assert.NotContains(t, statement, "--", statement)
+	}
+}
@@ -0,0 +1,129 @@
+// Package textdiff compares text snapshots line by line
+package textdiff
+
+import (
+	"math"
+	"strings"
+)
+
+// maxMatrix caps the cells of the longest common subsequence table; larger inputs are compared as
+// sets of lines, which ignores lines that only moved
+const maxMatrix = 4_000_000
+
+// Diff is the difference between two texts
+type Diff struct {
+	// Added and Removed are the lines only found in the new or the old text, in order
+	Added   []string
+	Removed []string
+	// ChangePercent is the share of the characters of both texts in added or removed lines, 0-100
+	ChangePercent float64
+}
+
+// Lines splits text into its non-empty lines
+func Lines(text string) []string {
+	var lines []string
+	for _, line := range strings.Split(text, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// Compare diffs old and new line by line
+func Compare(old, new string) Diff {
+	a, b := Lines(old), Lines(new)
+
+	// Lines shared at both ends need no alignment
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+	midA, midB := a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]
+
+	var d Diff
+	if (len(midA)+1)*(len(midB)+1) <= maxMatrix {
+		d.Removed, d.Added = lcsDiff(midA, midB)
+	} else {
+		d.Removed, d.Added = setDiff(midA, midB)
+	}
+
+	total := length(a) + length(b)
+	if total > 0 {
+		d.ChangePercent = math.Round(float64(length(d.Added)+length(d.Removed))/float64(total)*10000) / 100
+	}
+	return d
+}
+
+// lcsDiff returns the lines of a and b outside their longest common subsequence
+func lcsDiff(a, b []string) (removed, added []string) {
+	// table[i][j] is the length of the LCS of a[i:] and b[j:]
+	width := len(b) + 1
+	table := make([]int32, (len(a)+1)*width)
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				table[i*width+j] = table[(i+1)*width+j+1] + 1
+			} else {
+				table[i*width+j] = max(table[(i+1)*width+j], table[i*width+j+1])
+			}
+		}
+	}
+
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			i++
+			j++
+		case table[(i+1)*width+j] >= table[i*width+j+1]:
+			removed = append(removed, a[i])
+			i++
+		default:
+			added = append(added, b[j])
+			j++
+		}
+	}
+	removed = append(removed, a[i:]...)
+	added = append(added, b[j:]...)
+	return removed, added
+}
+
+// setDiff returns the lines of a missing from b and the other way round, counting duplicates
+func setDiff(a, b []string) (removed, added []string) {
+	counts := make(map[string]int, len(a))
+	for _, line := range a {
+		counts[line]++
+	}
+	for _, line := range b {
+		if counts[line] > 0 {
+			counts[line]--
+		} else {
+			added = append(added, line)
+		}
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		if counts[a[i]] > 0 {
+			counts[a[i]]--
+			removed = append(removed, a[i])
+		}
+	}
+	// Collected back to front so the last copies of repeated lines count as removed
+	for l, r := 0, len(removed)-1; l < r; l, r = l+1, r-1 {
+		removed[l], removed[r] = removed[r], removed[l]
+	}
+	return removed, added
+}
+
+// length sums the characters of lines
+func length(lines []string) int {
+	n := 0
+	for _, line := range lines {
+		n += len(line)
+	}
+	return n
+}
@@ -0,0 +1,46 @@
+package textdiff
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompare(t *testing.T) {
+	t.Run("identical", func(t *testing.T) {
+		d := Compare("Welcome\nPrices\nContact", "Welcome\n  Prices  \n\nContact")
+		assert.Empty(t, d.Added)
+		assert.Empty(t, d.Removed)
+		assert.Zero(t, d.ChangePercent)
+	})
+
+	t.Run("added and removed lines in order", func(t *testing.T) {
+		d := Compare("Welcome\nPrice: 10 EUR\nContact\nFooter", "Welcome\nNew offer\nPrice: 12 EUR\nContact\nFooter")
+		assert.Equal(t, []string{"New offer", "Price: 12 EUR"}, d.Added)
+		assert.Equal(t, []string{"Price: 10 EUR"}, d.Removed)
+		assert.Greater(t, d.ChangePercent, 0.0)
+		assert.Less(t, d.ChangePercent, 100.0)
+	})
+
+	t.Run("moved line", func(t *testing.T) {
+		d := Compare("a\nb\nc", "b\nc\na")
+		assert.Equal(t, []string{"a"}, d.Added)
+		assert.Equal(t, []string{"a"}, d.Removed)
+	})
+
+	t.Run("everything replaced", func(t *testing.T) {
+		d := Compare("old page", "new page")
+		assert.Equal(t, 100.0, d.ChangePercent)
+	})
+
+	t.Run("empty texts", func(t *testing.T) {
+		assert.Zero(t, Compare("", "").ChangePercent)
+		assert.Equal(t, 100.0, Compare("", "Hello").ChangePercent)
+	})
+}
+
+func TestSetDiff(t *testing.T) {
+	removed, added := setDiff([]string{"a", "b", "a"}, []string{"a", "c"})
+	assert.Equal(t, []string{"b", "a"}, removed)
+	assert.Equal(t, []string{"c"}, added)
+}
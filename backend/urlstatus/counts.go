@@ -0,0 +1,146 @@
+package urlstatus
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+
+	"sykell-analyze/backend/config"
+)
+
+// Statuses lists every status a URL can have
+var Statuses = []string{Queued, Running, Completed, Error, Cancelled}
+
+// countMaxAge is how long a user's URL counts are trusted before they are counted again, which
+// bounds how far they drift from writes that bypass them
+const countMaxAge = time.Hour
+
+// execer is satisfied by both *sql.DB and *sql.Tx
+type execer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+}
+
+// The url_counts table holds the number of URLs of each user in each status, so the URL list can
+// show its total without counting a large account's rows on every request. A user has a row for
+// every status or none: the counts only change once Total counted them, and adding to a user
+// without counts does nothing.
+
+// CountNew records n new queued URLs of the user
+func CountNew(ctx context.Context, userID interface{}, n int) error {
+	return countNew(config.DBFor(ctx), userID, n)
+}
+
+// CountNewTx is CountNew as part of a caller-managed transaction
+func CountNewTx(tx *sql.Tx, userID interface{}, n int) error {
+	return countNew(tx, userID, n)
+}
+
+func countNew(db execer, userID interface{}, n int) error {
+	_, err := db.Exec("UPDATE url_counts SET total = total + ? WHERE user_id = ? AND status = ?", n, userID, Queued)
+	return err
+}
+
+// UncountTx removes the user's URLs with the given IDs from the counts. Call it in the transaction
+// that deletes them, before the delete.
+func UncountTx(tx *sql.Tx, userID interface{}, ids []interface{}) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	_, err := tx.Exec(`
+		UPDATE url_counts c
+		JOIN (
+			SELECT status, COUNT(*) AS n FROM urls
+			WHERE user_id = ? AND id IN (?`+strings.Repeat(", ?", len(ids)-1)+`)
+			GROUP BY status
+		) d ON d.status = c.status
+		SET c.total = c.total - d.n
+		WHERE c.user_id = ?
+	`, append(append([]interface{}{userID}, ids...), userID)...)
+	return err
+}
+
+// moveCount moves the URL from one status count of its user to another
+func moveCount(tx *sql.Tx, urlID int, from, to string) error {
+	if from == to {
+		return nil
+	}
+	_, err := tx.Exec(`
+		UPDATE url_counts c
+		JOIN urls u ON u.user_id = c.user_id
+		SET c.total = c.total + IF(c.status = ?, 1, -1)
+		WHERE u.id = ? AND c.status IN (?, ?)
+	`, to, urlID, to, from)
+	return err
+}
+
+// Total returns how many URLs the user has in status, or in any status when it is empty. The
+// number comes from url_counts and is approximate, which the second result reports; counts that
+// are missing, older than an hour or negative are counted again first, exactly.
+func Total(ctx context.Context, userID interface{}, status string) (int, bool, error) {
+	query := "SELECT COALESCE(SUM(total), 0), COUNT(*), MIN(counted_at), MIN(total) FROM url_counts WHERE user_id = ?"
+	args := []interface{}{userID}
+	if status != "" {
+		query += " AND status = ?"
+		args = append(args, status)
+	}
+
+	var total, rows int
+	var countedAt sql.NullTime
+	var lowest sql.NullInt64
+	if err := config.DBFor(ctx).QueryRow(query, args...).Scan(&total, &rows, &countedAt, &lowest); err != nil {
+		return 0, false, err
+	}
+	if rows > 0 && countedAt.Valid && time.Since(countedAt.Time) < countMaxAge && lowest.Int64 >= 0 {
+		return total, true, nil
+	}
+
+	counts, err := Recount(ctx, userID)
+	if err != nil {
+		return 0, false, err
+	}
+	if status != "" {
+		return counts[status], false, nil
+	}
+	total = 0
+	for _, n := range counts {
+		total += n
+	}
+	return total, false, nil
+}
+
+// Recount counts the user's URLs in each status and stores the counts
+func Recount(ctx context.Context, userID interface{}) (map[string]int, error) {
+	counts := make(map[string]int, len(Statuses))
+	err := config.WithTransaction(ctx, func(tx *sql.Tx) error {
+		rows, err := tx.Query("SELECT status, COUNT(*) FROM urls WHERE user_id = ? GROUP BY status", userID)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		for rows.Next() {
+			var status string
+			var n int
+			if err := rows.Scan(&status, &n); err != nil {
+				return err
+			}
+			counts[status] = n
+		}
+		if err := rows.Err(); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		for _, status := range Statuses {
+			_, err := tx.Exec(`
+				INSERT INTO url_counts (user_id, status, total, counted_at) VALUES (?, ?, ?, ?)
+				ON DUPLICATE KEY UPDATE total = VALUES(total), counted_at = VALUES(counted_at)
+			`, userID, status, counts[status], now)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return counts, err
+}
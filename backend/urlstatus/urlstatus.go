@@ -0,0 +1,181 @@
+// Package urlstatus is the state machine of a URL's analysis status. Every status change goes
+// through Transition, which rejects the changes the machine does not allow and announces the
+// others to the subscribers, such as the alert rules.
+package urlstatus
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"sykell-analyze/backend/config"
+)
+
+// Statuses of a URL
+const (
+	Queued    = "queued"
+	Running   = "running"
+	Completed = "completed"
+	Error     = "error"
+	Cancelled = "cancelled"
+)
+
+// transitions lists the statuses each status may change to
+var transitions = map[string][]string{
+	// Re-queueing a queued URL only changes its job
+	Queued: {Queued, Running, Cancelled},
+	// A worker retries a crawl whose lease expired
+	Running:   {Running, Completed, Error},
+	Completed: {Queued},
+	Error:     {Queued},
+	Cancelled: {Queued},
+}
+
+// forcedTransitions override the URL's job and need Request.Force: re-queueing a running crawl,
+// which still finishes, and settling a queued URL whose job was cancelled or could not be queued
+var forcedTransitions = map[string][]string{
+	Running: {Queued},
+	Queued:  {Completed, Error},
+}
+
+// ErrNotFound is returned by Transition for a URL that does not exist
+var ErrNotFound = errors.New("URL not found")
+
+// InvalidTransitionError is returned by Transition for a change the state machine does not allow
+type InvalidTransitionError struct {
+	From, To string
+	// Forceable reports that the change is allowed with Request.Force
+	Forceable bool
+}
+
+func (e *InvalidTransitionError) Error() string {
+	if e.Forceable {
+		return fmt.Sprintf("the URL is %s; force the change to %s", e.From, e.To)
+	}
+	return fmt.Sprintf("the URL cannot change from %s to %s", e.From, e.To)
+}
+
+// VersionConflictError is returned by Transition when the URL changed since the client read it
+type VersionConflictError struct {
+	Current int
+}
+
+func (e *VersionConflictError) Error() string {
+	return fmt.Sprintf("the URL changed since it was read; its version is now %d", e.Current)
+}
+
+// Check returns an *InvalidTransitionError unless a URL may change from one status to the other
+func Check(from, to string, force bool) error {
+	if slices.Contains(transitions[from], to) || (force && slices.Contains(forcedTransitions[from], to)) {
+		return nil
+	}
+	return &InvalidTransitionError{From: from, To: to, Forceable: slices.Contains(forcedTransitions[from], to)}
+}
+
+// Request is a status change of one URL
+type Request struct {
+	UrlID int
+	To    string
+	Force bool
+	// From, when set, is the only status the change applies to
+	From string
+	// Version, when set, is the version the URL must still have
+	Version int
+	// Set lists further assignments of the same UPDATE, such as "error_message = ?", with Args
+	Set  string
+	Args []interface{}
+}
+
+// Change is a status change that was made
+type Change struct {
+	UrlID int
+	From  string
+	To    string
+	At    time.Time
+}
+
+// Transition changes the URL's status within tx, after locking its row and checking the change.
+// Pass the returned change to Publish once tx has committed; a change to the same status is not
+// announced.
+func Transition(tx *sql.Tx, r Request) (Change, error) {
+	change := Change{UrlID: r.UrlID, To: r.To, At: time.Now()}
+
+	var version int
+	err := tx.QueryRow("SELECT status, version FROM urls WHERE id = ? FOR UPDATE", r.UrlID).Scan(&change.From, &version)
+	if err == sql.ErrNoRows {
+		return change, ErrNotFound
+	} else if err != nil {
+		return change, err
+	}
+	if r.Version != 0 && r.Version != version {
+		return change, &VersionConflictError{Current: version}
+	}
+	if r.From != "" && change.From != r.From {
+		return change, &InvalidTransitionError{From: change.From, To: r.To}
+	}
+	if err := Check(change.From, r.To, r.Force); err != nil {
+		return change, err
+	}
+
+	query := "UPDATE urls SET status = ?, version = version + 1, updated_at = ?"
+	if r.Set != "" {
+		query += ", " + r.Set
+	}
+	args := append([]interface{}{r.To, change.At}, r.Args...)
+	if _, err := tx.Exec(query+" WHERE id = ?", append(args, r.UrlID)...); err != nil {
+		return change, err
+	}
+	if err := moveCount(tx, r.UrlID, change.From, r.To); err != nil {
+		return change, err
+	}
+	return change, nil
+}
+
+// Apply makes one status change in its own transaction on the database of ctx's tenant and
+// publishes it
+func Apply(ctx context.Context, r Request) (Change, error) {
+	var change Change
+	err := config.WithTransaction(ctx, func(tx *sql.Tx) error {
+		var err error
+		change, err = Transition(tx, r)
+		return err
+	})
+	if err == nil {
+		Publish(ctx, change)
+	}
+	return change, err
+}
+
+var (
+	mu          sync.RWMutex
+	subscribers []func(context.Context, Change)
+)
+
+// Subscribe calls fn with every published change and the context of its publisher, which tells
+// the tenant the URL belongs to. Subscribers run in the goroutine that made the change, one after
+// the other.
+func Subscribe(fn func(context.Context, Change)) {
+	mu.Lock()
+	defer mu.Unlock()
+	subscribers = append(subscribers, fn)
+}
+
+// Publish announces committed changes, made in the database of ctx's tenant, to the subscribers
+func Publish(ctx context.Context, changes ...Change) {
+	mu.RLock()
+	fns := slices.Clone(subscribers)
+	mu.RUnlock()
+
+	for _, change := range changes {
+		if change.From == change.To {
+			continue
+		}
+		for _, fn := range fns {
+			fn(ctx, change)
+		}
+	}
+}
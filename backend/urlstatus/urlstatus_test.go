@@ -0,0 +1,101 @@
+package urlstatus
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"sykell-analyze/backend/config"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		from, to string
+		force    bool
+		allowed  bool
+	}{
+		{Queued, Running, false, true},
+		{Queued, Cancelled, false, true},
+		{Queued, Queued, false, true},
+		{Running, Completed, false, true},
+		{Running, Error, false, true},
+		{Running, Running, false, true},
+		{Completed, Queued, false, true},
+		{Error, Queued, false, true},
+		{Cancelled, Queued, false, true},
+
+		{Running, Queued, false, false},
+		{Running, Queued, true, true},
+		{Queued, Completed, false, false},
+		{Queued, Error, true, true},
+
+		{Completed, Running, false, false},
+		{Completed, Error, true, false},
+		{Cancelled, Completed, true, false},
+		{Running, Cancelled, true, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.from+" to "+tt.to, func(t *testing.T) {
+			err := Check(tt.from, tt.to, tt.force)
+			if tt.allowed {
+				assert.NoError(t, err)
+				return
+			}
+			var invalid *InvalidTransitionError
+			if assert.True(t, errors.As(err, &invalid)) {
+				assert.Equal(t, tt.from, invalid.From)
+				assert.Equal(t, tt.to, invalid.To)
+			}
+		})
+	}
+
+	t.Run("forceable changes say so", func(t *testing.T) {
+		assert.Contains(t, Check(Running, Queued, false).Error(), "force")
+		assert.NotContains(t, Check(Completed, Running, false).Error(), "force")
+	})
+}
+
+func TestPublish(t *testing.T) {
+	previous := subscribers
+	defer func() { subscribers = previous }()
+	subscribers = nil
+
+	var seen []Change
+	var tenants []string
+	Subscribe(func(ctx context.Context, change Change) {
+		seen = append(seen, change)
+		tenants = append(tenants, config.TenantName(ctx))
+	})
+
+	ctx := config.WithTenant(context.Background(), &config.Tenant{Name: "acme"})
+	Publish(ctx,
+		Change{UrlID: 1, From: Queued, To: Running},
+		Change{UrlID: 1, From: Running, To: Running},
+		Change{UrlID: 2, From: Running, To: Completed},
+	)
+	assert.Equal(t, []Change{{UrlID: 1, From: Queued, To: Running}, {UrlID: 2, From: Running, To: Completed}}, seen)
+	assert.Equal(t, []string{"acme", "acme"}, tenants, "subscribers get the publisher's tenant")
+}
+
+func TestVersionConflictError(t *testing.T) {
+	err := error(&VersionConflictError{Current: 5})
+	var conflict *VersionConflictError
+	if assert.True(t, errors.As(err, &conflict)) {
+		assert.Equal(t, 5, conflict.Current)
+	}
+	assert.Contains(t, err.Error(), "5")
+}
+
+func TestStatuses(t *testing.T) {
+	// Every status is counted, so the counts of a user add up to all their URLs
+	for from, targets := range transitions {
+		assert.Contains(t, Statuses, from)
+		for _, to := range targets {
+			assert.Contains(t, Statuses, to)
+		}
+	}
+	assert.Len(t, Statuses, len(transitions))
+}
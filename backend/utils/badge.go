@@ -0,0 +1,58 @@
+package utils
+
+import (
+	"fmt"
+	"html"
+)
+
+// Badge colors, matching the palette README badges commonly use
+const (
+	BadgeGreen     = "#4c1"
+	BadgeRed       = "#e05d44"
+	BadgeOrange    = "#fe7d37"
+	BadgeBlue      = "#007ec6"
+	BadgeLightGrey = "#9f9f9f"
+	badgeLabelGrey = "#555"
+)
+
+// badgeTextWidth approximates the rendered width of s in 11px Verdana. Narrow and wide glyphs are
+// adjusted so short labels stay snug without measuring fonts server-side.
+func badgeTextWidth(s string) int {
+	width := 0
+	for _, r := range s {
+		switch {
+		case r == 'i' || r == 'l' || r == 'j' || r == 'I' || r == '.' || r == ',' || r == ':' || r == '|' || r == '!':
+			width += 4
+		case r == ' ' || r == 'f' || r == 't' || r == 'r':
+			width += 5
+		case r == 'm' || r == 'w' || r == 'M' || r == 'W':
+			width += 10
+		case r >= 'A' && r <= 'Z':
+			width += 8
+		default:
+			width += 7
+		}
+	}
+	return width
+}
+
+// RenderBadge draws a flat two-part "label | message" SVG badge
+func RenderBadge(label, message, color string) []byte {
+	labelWidth := badgeTextWidth(label) + 10
+	messageWidth := badgeTextWidth(message) + 10
+	total := labelWidth + messageWidth
+	label, message = html.EscapeString(label), html.EscapeString(message)
+
+	return []byte(fmt.Sprintf(`<svg xmlns="http://www.w3.org/2000/svg" width="%[1]d" height="20" role="img" aria-label="%[4]s: %[5]s">`+
+		`<title>%[4]s: %[5]s</title>`+
+		`<linearGradient id="s" x2="0" y2="100%%"><stop offset="0" stop-color="#bbb" stop-opacity=".1"/><stop offset="1" stop-opacity=".1"/></linearGradient>`+
+		`<clipPath id="r"><rect width="%[1]d" height="20" rx="3" fill="#fff"/></clipPath>`+
+		`<g clip-path="url(#r)"><rect width="%[2]d" height="20" fill="%[7]s"/><rect x="%[2]d" width="%[3]d" height="20" fill="%[6]s"/><rect width="%[1]d" height="20" fill="url(#s)"/></g>`+
+		`<g fill="#fff" text-anchor="middle" font-family="Verdana,Geneva,DejaVu Sans,sans-serif" font-size="11">`+
+		`<text x="%[8]d" y="15" fill="#010101" fill-opacity=".3">%[4]s</text><text x="%[8]d" y="14">%[4]s</text>`+
+		`<text x="%[9]d" y="15" fill="#010101" fill-opacity=".3">%[5]s</text><text x="%[9]d" y="14">%[5]s</text>`+
+		`</g></svg>`,
+		total, labelWidth, messageWidth, label, message, color, badgeLabelGrey,
+		labelWidth/2, labelWidth+messageWidth/2,
+	))
+}
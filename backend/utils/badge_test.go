@@ -0,0 +1,36 @@
+package utils
+
+import (
+	"encoding/xml"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenderBadge(t *testing.T) {
+	t.Run("renders well-formed SVG", func(t *testing.T) {
+		svg := RenderBadge("links", "3 broken", BadgeRed)
+
+		var doc struct {
+			XMLName xml.Name `xml:"svg"`
+			Width   int      `xml:"width,attr"`
+			Title   string   `xml:"title"`
+		}
+		require.NoError(t, xml.Unmarshal(svg, &doc))
+		assert.Equal(t, "links: 3 broken", doc.Title)
+		assert.Equal(t, badgeTextWidth("links")+badgeTextWidth("3 broken")+20, doc.Width)
+		assert.Contains(t, string(svg), BadgeRed)
+	})
+
+	t.Run("escapes text", func(t *testing.T) {
+		svg := string(RenderBadge("a<b", `"x"&y`, BadgeBlue))
+		assert.NotContains(t, svg, "a<b")
+		assert.Contains(t, svg, "a&lt;b")
+		assert.Contains(t, svg, "&amp;y")
+	})
+
+	t.Run("longer text makes a wider badge", func(t *testing.T) {
+		assert.Greater(t, badgeTextWidth("120 broken"), badgeTextWidth("0 broken"))
+	})
+}
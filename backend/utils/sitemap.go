@@ -0,0 +1,103 @@
+package utils
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// MaxSitemapURLs is the most URLs the sitemaps.org protocol allows in one file
+const MaxSitemapURLs = 50000
+
+// MaxSitemapBytes is the size limit of the sitemaps.org protocol, which applies uncompressed
+const MaxSitemapBytes = 50 << 20
+
+// SitemapURL is one page of a sitemap; LastMod is left out when zero
+type SitemapURL struct {
+	Loc     string
+	LastMod time.Time
+}
+
+// sitemapURLSet is the XML document of a sitemap
+type sitemapURLSet struct {
+	XMLName xml.Name        `xml:"urlset"`
+	Xmlns   string          `xml:"xmlns,attr"`
+	URLs    []sitemapURLXML `xml:"url"`
+}
+
+type sitemapURLXML struct {
+	Loc     string `xml:"loc"`
+	LastMod string `xml:"lastmod,omitempty"`
+}
+
+// RenderSitemap writes a sitemaps.org sitemap listing the first MaxSitemapURLs urls
+func RenderSitemap(urls []SitemapURL) ([]byte, error) {
+	if len(urls) > MaxSitemapURLs {
+		urls = urls[:MaxSitemapURLs]
+	}
+	set := sitemapURLSet{Xmlns: "http://www.sitemaps.org/schemas/sitemap/0.9", URLs: make([]sitemapURLXML, 0, len(urls))}
+	for _, u := range urls {
+		entry := sitemapURLXML{Loc: u.Loc}
+		if !u.LastMod.IsZero() {
+			entry.LastMod = u.LastMod.UTC().Format("2006-01-02")
+		}
+		set.URLs = append(set.URLs, entry)
+	}
+
+	body, err := xml.MarshalIndent(set, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), append(body, '\n')...), nil
+}
+
+// sitemapDocument is either a urlset or a sitemapindex
+type sitemapDocument struct {
+	XMLName  xml.Name
+	URLs     []sitemapURLXML `xml:"url"`
+	Sitemaps []sitemapURLXML `xml:"sitemap"`
+}
+
+// ParseSitemap reads a sitemap, gzip-compressed or not. A urlset returns its pages, a sitemap index
+// the sitemaps it lists.
+func ParseSitemap(body []byte) (pages, sitemaps []string, err error) {
+	if bytes.HasPrefix(body, []byte{0x1f, 0x8b}) {
+		reader, err := gzip.NewReader(bytes.NewReader(body))
+		if err != nil {
+			return nil, nil, err
+		}
+		// A small compressed file can expand to far more than it weighs
+		if body, err = io.ReadAll(io.LimitReader(reader, MaxSitemapBytes+1)); err != nil {
+			return nil, nil, err
+		}
+		if len(body) > MaxSitemapBytes {
+			return nil, nil, fmt.Errorf("sitemap is larger than %d MB uncompressed", MaxSitemapBytes>>20)
+		}
+	}
+
+	var doc sitemapDocument
+	if err := xml.Unmarshal(body, &doc); err != nil {
+		return nil, nil, fmt.Errorf("not a sitemap: %w", err)
+	}
+	switch doc.XMLName.Local {
+	case "urlset":
+		for _, u := range doc.URLs {
+			if loc := strings.TrimSpace(u.Loc); loc != "" {
+				pages = append(pages, loc)
+			}
+		}
+	case "sitemapindex":
+		for _, s := range doc.Sitemaps {
+			if loc := strings.TrimSpace(s.Loc); loc != "" {
+				sitemaps = append(sitemaps, loc)
+			}
+		}
+	default:
+		return nil, nil, fmt.Errorf("not a sitemap: the root element is <%s>", doc.XMLName.Local)
+	}
+	return pages, sitemaps, nil
+}
@@ -0,0 +1,86 @@
+package utils
+
+import (
+	"bytes"
+	"compress/gzip"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenderSitemap(t *testing.T) {
+	t.Run("lists pages with their modification date", func(t *testing.T) {
+		body, err := RenderSitemap([]SitemapURL{
+			{Loc: "https://example.com/", LastMod: time.Date(2026, 10, 16, 23, 0, 0, 0, time.UTC)},
+			{Loc: "https://example.com/a?b=1&c=2"},
+		})
+		require.NoError(t, err)
+
+		xml := string(body)
+		assert.True(t, strings.HasPrefix(xml, `<?xml version="1.0" encoding="UTF-8"?>`))
+		assert.Contains(t, xml, `<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">`)
+		assert.Contains(t, xml, "<loc>https://example.com/</loc>\n    <lastmod>2026-10-16</lastmod>")
+		assert.Contains(t, xml, "<loc>https://example.com/a?b=1&amp;c=2</loc>\n  </url>")
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		body, err := RenderSitemap(nil)
+		require.NoError(t, err)
+		assert.Contains(t, string(body), `<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9"></urlset>`)
+	})
+}
+
+func TestParseSitemap(t *testing.T) {
+	t.Run("urlset", func(t *testing.T) {
+		pages, sitemaps, err := ParseSitemap([]byte(`<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <url><loc> https://example.com/ </loc><lastmod>2026-10-01</lastmod></url>
+  <url><loc>https://example.com/a?b=1&amp;c=2</loc></url>
+  <url><loc></loc></url>
+</urlset>`))
+		require.NoError(t, err)
+		assert.Equal(t, []string{"https://example.com/", "https://example.com/a?b=1&c=2"}, pages)
+		assert.Empty(t, sitemaps)
+	})
+
+	t.Run("sitemap index", func(t *testing.T) {
+		pages, sitemaps, err := ParseSitemap([]byte(`<sitemapindex xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <sitemap><loc>https://example.com/sitemap-posts.xml</loc></sitemap>
+  <sitemap><loc>https://example.com/sitemap-pages.xml.gz</loc></sitemap>
+</sitemapindex>`))
+		require.NoError(t, err)
+		assert.Empty(t, pages)
+		assert.Equal(t, []string{"https://example.com/sitemap-posts.xml", "https://example.com/sitemap-pages.xml.gz"}, sitemaps)
+	})
+
+	t.Run("gzip", func(t *testing.T) {
+		var compressed bytes.Buffer
+		writer := gzip.NewWriter(&compressed)
+		writer.Write([]byte(`<urlset><url><loc>https://example.com/</loc></url></urlset>`))
+		writer.Close()
+
+		pages, _, err := ParseSitemap(compressed.Bytes())
+		require.NoError(t, err)
+		assert.Equal(t, []string{"https://example.com/"}, pages)
+	})
+
+	t.Run("gzip bomb", func(t *testing.T) {
+		var compressed bytes.Buffer
+		writer := gzip.NewWriter(&compressed)
+		writer.Write([]byte("<urlset>"))
+		writer.Write(make([]byte, MaxSitemapBytes))
+		writer.Close()
+		require.Less(t, compressed.Len(), 1<<20)
+
+		_, _, err := ParseSitemap(compressed.Bytes())
+		assert.ErrorContains(t, err, "larger than 50 MB uncompressed")
+	})
+
+	t.Run("not a sitemap", func(t *testing.T) {
+		_, _, err := ParseSitemap([]byte(`<html><body>Not found</body></html>`))
+		assert.Error(t, err)
+	})
+}
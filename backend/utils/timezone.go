@@ -0,0 +1,22 @@
+package utils
+
+import "time"
+
+// ValidTimezone reports whether name is an IANA time zone such as Europe/Berlin or UTC. "Local" is
+// rejected: it means the server's zone, which is not a user preference.
+func ValidTimezone(name string) bool {
+	if name == "" || name == "Local" {
+		return false
+	}
+	_, err := time.LoadLocation(name)
+	return err == nil
+}
+
+// Location loads the time zone a user chose, falling back to UTC for an empty or invalid name
+func Location(name string) *time.Location {
+	if !ValidTimezone(name) {
+		return time.UTC
+	}
+	loc, _ := time.LoadLocation(name)
+	return loc
+}
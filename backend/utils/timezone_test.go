@@ -0,0 +1,23 @@
+package utils
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTimezone(t *testing.T) {
+	t.Run("accepts IANA zones", func(t *testing.T) {
+		assert.True(t, ValidTimezone("UTC"))
+		assert.True(t, ValidTimezone("Europe/Berlin"))
+		assert.Equal(t, "Europe/Berlin", Location("Europe/Berlin").String())
+	})
+
+	t.Run("falls back to UTC", func(t *testing.T) {
+		for _, name := range []string{"", "Local", "Mars/Olympus", "../etc/passwd"} {
+			assert.False(t, ValidTimezone(name), name)
+			assert.Equal(t, time.UTC, Location(name), name)
+		}
+	})
+}
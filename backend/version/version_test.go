@@ -0,0 +1,29 @@
+package version
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInfo(t *testing.T) {
+	t.Run("ldflags values win", func(t *testing.T) {
+		oldVersion, oldCommit, oldDate := Version, Commit, BuildDate
+		defer func() { Version, Commit, BuildDate = oldVersion, oldCommit, oldDate }()
+
+		Version, Commit, BuildDate = "2.3.4", "abc1234", "2024-05-01T10:00:00Z"
+		info := Info()
+
+		assert.Equal(t, "2.3.4", info.Version)
+		assert.Equal(t, "abc1234", info.Commit)
+		assert.Equal(t, "2024-05-01T10:00:00Z", info.BuildDate)
+		assert.NotEmpty(t, info.GoVersion)
+	})
+
+	t.Run("never empty", func(t *testing.T) {
+		info := Info()
+
+		assert.NotEmpty(t, info.Commit)
+		assert.NotEmpty(t, info.BuildDate)
+	})
+}
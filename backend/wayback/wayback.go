@@ -0,0 +1,203 @@
+// Package wayback looks pages up in the Internet Archive's Wayback Machine and archives them with
+// Save Page Now
+package wayback
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"sykell-analyze/backend/config"
+	"sykell-analyze/backend/version"
+)
+
+// Default is nil when the Wayback Machine is not used (wayback.enabled unset)
+var Default *Client
+
+// Archiver is nil when crawled pages are not archived (wayback.archive unset)
+var Archiver *Client
+
+// Configure enables Wayback Machine lookups and archiving when they are switched on
+func Configure(cfg config.WaybackConfig) {
+	Default, Archiver = nil, nil
+	if cfg.Enabled {
+		Default = New(cfg.BaseURL, cfg.Timeout)
+		fmt.Println("✅ Wayback Machine lookups enabled.")
+	}
+	if cfg.Archive {
+		Archiver = New(cfg.BaseURL, cfg.Timeout)
+		Archiver.SaveURL = strings.TrimSuffix(cfg.SaveURL, "/")
+		Archiver.AccessKey, Archiver.SecretKey = cfg.AccessKey, cfg.SecretKey
+		Archiver.SaveTimeout = cfg.SaveTimeout
+		fmt.Println("✅ Wayback Machine archiving enabled.")
+	}
+}
+
+// Client queries the Wayback Machine. It is safe for concurrent use.
+type Client struct {
+	BaseURL    string
+	HTTPClient *http.Client
+
+	// SaveURL is the Save Page Now API, used by Save with the account's S3-style keys
+	SaveURL   string
+	AccessKey string
+	SecretKey string
+	// SaveTimeout bounds Save, which polls the capture's status every PollInterval
+	SaveTimeout  time.Duration
+	PollInterval time.Duration
+}
+
+// New creates a client for the availability API at baseURL/wayback/available
+func New(baseURL string, timeout time.Duration) *Client {
+	return &Client{
+		BaseURL:      strings.TrimSuffix(baseURL, "/"),
+		HTTPClient:   &http.Client{Timeout: timeout},
+		SaveTimeout:  2 * time.Minute,
+		PollInterval: 5 * time.Second,
+	}
+}
+
+// availabilityResponse is the answer of the availability API
+type availabilityResponse struct {
+	ArchivedSnapshots struct {
+		Closest *struct {
+			Available bool   `json:"available"`
+			URL       string `json:"url"`
+			Status    string `json:"status"`
+		} `json:"closest"`
+	} `json:"archived_snapshots"`
+}
+
+// ClosestSnapshot returns the URL of the most recent successful capture of pageURL, or "" when the
+// page was never archived. It implements analyzer.Archive.
+func (c *Client) ClosestSnapshot(ctx context.Context, pageURL string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.BaseURL+"/wayback/available?url="+url.QueryEscape(pageURL), nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("User-Agent", "sykell-analyze/"+version.Version)
+
+	res, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("wayback lookup failed: %w", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		io.Copy(io.Discard, io.LimitReader(res.Body, 64<<10))
+		return "", fmt.Errorf("wayback lookup failed: %s", res.Status)
+	}
+
+	var body availabilityResponse
+	if err := json.NewDecoder(io.LimitReader(res.Body, 1<<20)).Decode(&body); err != nil {
+		return "", fmt.Errorf("wayback lookup failed: %w", err)
+	}
+	closest := body.ArchivedSnapshots.Closest
+	// Captures of error pages are no replacement
+	if closest == nil || !closest.Available || closest.URL == "" || !strings.HasPrefix(closest.Status, "2") {
+		return "", nil
+	}
+	if rest, ok := strings.CutPrefix(closest.URL, "http://"); ok {
+		return "https://" + rest, nil
+	}
+	return closest.URL, nil
+}
+
+// saveJob is the answer to a capture request
+type saveJob struct {
+	JobID   string `json:"job_id"`
+	Message string `json:"message"`
+}
+
+// saveStatus is the state of a capture: pending, success or error
+type saveStatus struct {
+	Status      string `json:"status"`
+	Timestamp   string `json:"timestamp"`
+	OriginalURL string `json:"original_url"`
+	Message     string `json:"message"`
+}
+
+// Save asks Save Page Now to capture pageURL and waits up to SaveTimeout for the capture, returning
+// the URL of the archived copy
+func (c *Client) Save(ctx context.Context, pageURL string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.SaveTimeout)
+	defer cancel()
+
+	var job saveJob
+	form := url.Values{"url": {pageURL}}
+	if err := c.saveRequest(ctx, http.MethodPost, c.SaveURL, strings.NewReader(form.Encode()), &job); err != nil {
+		return "", err
+	}
+	if job.JobID == "" {
+		return "", fmt.Errorf("save page now refused the capture: %s", job.Message)
+	}
+
+	timedOut := fmt.Errorf("capture did not finish within %s", c.SaveTimeout)
+	ticker := time.NewTicker(c.PollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return "", timedOut
+		case <-ticker.C:
+		}
+
+		var status saveStatus
+		if err := c.saveRequest(ctx, http.MethodGet, c.SaveURL+"/status/"+url.PathEscape(job.JobID), nil, &status); err != nil {
+			if ctx.Err() != nil {
+				return "", timedOut
+			}
+			return "", err
+		}
+		switch status.Status {
+		case "success":
+			if status.OriginalURL == "" {
+				status.OriginalURL = pageURL
+			}
+			return c.snapshotURL(status.Timestamp, status.OriginalURL), nil
+		case "error":
+			return "", fmt.Errorf("capture failed: %s", status.Message)
+		}
+	}
+}
+
+// saveRequest calls the Save Page Now API and decodes its JSON answer into out
+func (c *Client) saveRequest(ctx context.Context, method, target string, body io.Reader, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, method, target, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Authorization", "LOW "+c.AccessKey+":"+c.SecretKey)
+	req.Header.Set("User-Agent", "sykell-analyze/"+version.Version)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	}
+
+	res, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("save page now request failed: %w", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		io.Copy(io.Discard, io.LimitReader(res.Body, 64<<10))
+		return fmt.Errorf("save page now request failed: %s", res.Status)
+	}
+	if err := json.NewDecoder(io.LimitReader(res.Body, 1<<20)).Decode(out); err != nil {
+		return fmt.Errorf("save page now request failed: %w", err)
+	}
+	return nil
+}
+
+// snapshotURL is where the Wayback Machine serves a capture, on the host of SaveURL
+func (c *Client) snapshotURL(timestamp, originalURL string) string {
+	origin := "https://web.archive.org"
+	if u, err := url.Parse(c.SaveURL); err == nil && u.Host != "" {
+		origin = u.Scheme + "://" + u.Host
+	}
+	return origin + "/web/" + timestamp + "/" + originalURL
+}
@@ -0,0 +1,114 @@
+package wayback
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClosestSnapshot(t *testing.T) {
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/wayback/available" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		switch r.URL.Query().Get("url") {
+		case "https://example.com/old":
+			w.Write([]byte(`{"url": "https://example.com/old", "archived_snapshots": {"closest": {"status": "200", "available": true,
+				"url": "http://web.archive.org/web/20240101000000/https://example.com/old", "timestamp": "20240101000000"}}}`))
+		case "https://example.com/error":
+			w.Write([]byte(`{"archived_snapshots": {"closest": {"status": "404", "available": true,
+				"url": "http://web.archive.org/web/20240101000000/https://example.com/error"}}}`))
+		case "https://example.com/busy":
+			w.WriteHeader(http.StatusServiceUnavailable)
+		default:
+			w.Write([]byte(`{"archived_snapshots": {}}`))
+		}
+	}))
+	defer api.Close()
+
+	client := New(api.URL+"/", 5*time.Second)
+
+	t.Run("returns the capture over https", func(t *testing.T) {
+		snapshot, err := client.ClosestSnapshot(context.Background(), "https://example.com/old")
+		require.NoError(t, err)
+		assert.Equal(t, "https://web.archive.org/web/20240101000000/https://example.com/old", snapshot)
+	})
+
+	t.Run("never archived", func(t *testing.T) {
+		snapshot, err := client.ClosestSnapshot(context.Background(), "https://example.com/new")
+		require.NoError(t, err)
+		assert.Empty(t, snapshot)
+	})
+
+	t.Run("ignores captures of error pages", func(t *testing.T) {
+		snapshot, err := client.ClosestSnapshot(context.Background(), "https://example.com/error")
+		require.NoError(t, err)
+		assert.Empty(t, snapshot)
+	})
+
+	t.Run("reports API failures", func(t *testing.T) {
+		_, err := client.ClosestSnapshot(context.Background(), "https://example.com/busy")
+		assert.ErrorContains(t, err, "503")
+	})
+}
+
+func TestSave(t *testing.T) {
+	var polls int32
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "LOW access:secret", r.Header.Get("Authorization"))
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/save":
+			r.ParseForm()
+			switch r.PostForm.Get("url") {
+			case "https://example.com/":
+				w.Write([]byte(`{"url": "https://example.com/", "job_id": "spn2-abc"}`))
+			case "https://example.com/blocked":
+				w.Write([]byte(`{"status": "error", "status_ext": "error:blocked-url", "message": "This URL is excluded."}`))
+			default:
+				w.Write([]byte(`{"url": "https://example.com/slow", "job_id": "spn2-slow"}`))
+			}
+		case r.URL.Path == "/save/status/spn2-abc":
+			if atomic.AddInt32(&polls, 1) < 2 {
+				w.Write([]byte(`{"status": "pending", "job_id": "spn2-abc"}`))
+				return
+			}
+			w.Write([]byte(`{"status": "success", "job_id": "spn2-abc", "timestamp": "20261016120000", "original_url": "https://example.com/"}`))
+		case r.URL.Path == "/save/status/spn2-slow":
+			w.Write([]byte(`{"status": "pending", "job_id": "spn2-slow"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer api.Close()
+
+	client := New(api.URL, 5*time.Second)
+	client.SaveURL = api.URL + "/save"
+	client.AccessKey, client.SecretKey = "access", "secret"
+	client.PollInterval = 10 * time.Millisecond
+
+	t.Run("waits for the capture", func(t *testing.T) {
+		archived, err := client.Save(context.Background(), "https://example.com/")
+		require.NoError(t, err)
+		assert.Equal(t, api.URL+"/web/20261016120000/https://example.com/", archived)
+		assert.EqualValues(t, 2, atomic.LoadInt32(&polls))
+	})
+
+	t.Run("refused capture", func(t *testing.T) {
+		_, err := client.Save(context.Background(), "https://example.com/blocked")
+		assert.ErrorContains(t, err, "This URL is excluded.")
+	})
+
+	t.Run("gives up after the save timeout", func(t *testing.T) {
+		slow := *client
+		slow.SaveTimeout = 50 * time.Millisecond
+		_, err := slow.Save(context.Background(), "https://example.com/slow")
+		assert.ErrorContains(t, err, "did not finish within 50ms")
+	})
+}
@@ -0,0 +1,58 @@
+// Package web serves the React frontend embedded into the binary. Copy the output of
+// `npm run build` in frontend/ to web/dist before building the server; without it the server only
+// serves the API.
+package web
+
+import (
+	"embed"
+	"io/fs"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+//go:embed all:dist
+var dist embed.FS
+
+// Build returns the embedded frontend build, or false when none was embedded
+func Build() (fs.FS, bool) {
+	build, err := fs.Sub(dist, "dist")
+	if err != nil {
+		return nil, false
+	}
+	if _, err := fs.Stat(build, "index.html"); err != nil {
+		return nil, false
+	}
+	return build, true
+}
+
+// Handler serves the files of build and answers any other GET outside /api/ and /public/ with
+// index.html, so client-side routes survive a reload. Register it with router.NoRoute.
+func Handler(build fs.FS) gin.HandlerFunc {
+	files := http.FileServer(http.FS(build))
+	return func(c *gin.Context) {
+		p := c.Request.URL.Path
+		if (c.Request.Method != http.MethodGet && c.Request.Method != http.MethodHead) ||
+			strings.HasPrefix(p, "/api/") || strings.HasPrefix(p, "/public/") {
+			return // gin answers with its 404
+		}
+
+		name := strings.TrimPrefix(path.Clean(p), "/")
+		if info, err := fs.Stat(build, name); err != nil || info.IsDir() {
+			// Client-side route: the app decides what to show
+			c.Header("Cache-Control", "no-cache")
+			c.FileFromFS("/", http.FS(build))
+			return
+		}
+
+		// Create React App fingerprints everything under static/
+		if strings.HasPrefix(name, "static/") {
+			c.Header("Cache-Control", "public, max-age=31536000, immutable")
+		} else {
+			c.Header("Cache-Control", "no-cache")
+		}
+		files.ServeHTTP(c.Writer, c.Request)
+	}
+}
@@ -0,0 +1,70 @@
+package web
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	build := fstest.MapFS{
+		"index.html":          {Data: []byte("<div id=root></div>")},
+		"favicon.ico":         {Data: []byte("icon")},
+		"static/js/main.1.js": {Data: []byte("console.log(1)")},
+	}
+	router := gin.New()
+	router.GET("/api/health", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"status": "healthy"})
+	})
+	router.NoRoute(Handler(build))
+
+	request := func(method, path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(method, path, nil)
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("serves files of the build", func(t *testing.T) {
+		w := request(http.MethodGet, "/static/js/main.1.js")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "console.log(1)", w.Body.String())
+		assert.Contains(t, w.Header().Get("Cache-Control"), "immutable")
+
+		w = request(http.MethodGet, "/favicon.ico")
+		assert.Equal(t, "icon", w.Body.String())
+		assert.Equal(t, "no-cache", w.Header().Get("Cache-Control"))
+	})
+
+	t.Run("client-side routes get index.html", func(t *testing.T) {
+		for _, path := range []string{"/", "/dashboard", "/urls/12", "/static"} {
+			w := request(http.MethodGet, path)
+			assert.Equal(t, http.StatusOK, w.Code, path)
+			assert.Equal(t, "<div id=root></div>", w.Body.String(), path)
+			assert.Equal(t, "no-cache", w.Header().Get("Cache-Control"), path)
+		}
+	})
+
+	t.Run("API routes are not shadowed", func(t *testing.T) {
+		assert.JSONEq(t, `{"status":"healthy"}`, request(http.MethodGet, "/api/health").Body.String())
+		assert.Equal(t, http.StatusNotFound, request(http.MethodGet, "/api/missing").Code)
+		assert.Equal(t, http.StatusNotFound, request(http.MethodGet, "/public/badges/x.svg").Code)
+		assert.Equal(t, http.StatusNotFound, request(http.MethodPost, "/dashboard").Code)
+	})
+}
+
+func TestBuild(t *testing.T) {
+	t.Run("reports a missing build", func(t *testing.T) {
+		// Only the placeholder is committed; release builds copy the frontend in first
+		if _, err := dist.ReadFile("dist/index.html"); err == nil {
+			t.Skip("a frontend build is embedded")
+		}
+		_, ok := Build()
+		assert.False(t, ok)
+	})
+}
@@ -0,0 +1,52 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"sykell-analyze/backend/config"
+	"sykell-analyze/backend/wayback"
+)
+
+// archivePage submits the crawled page to the Wayback Machine when archiving is enabled and records
+// the archived copy, or why archiving failed, with the crawl run
+func archivePage(ctx context.Context, job *Job, runID int64) {
+	archiver := wayback.Archiver
+	if archiver == nil {
+		return
+	}
+
+	startedAt := time.Now()
+	archiveURL, err := archiver.Save(ctx, job.Url)
+	if err != nil {
+		fmt.Printf("DEBUG: Archiving failed for URL ID %d: %v\n", job.UrlID, err)
+		if _, err := config.DBFor(ctx).Exec("UPDATE crawl_runs SET archive_error = ? WHERE id = ?", err.Error(), runID); err != nil {
+			fmt.Printf("DEBUG: Failed to save archive error for URL ID %d: %v\n", job.UrlID, err)
+		}
+		logEvent(ctx, logEntry{
+			UrlID:    job.UrlID,
+			JobID:    job.ID,
+			Level:    "warn",
+			Event:    EventWarning,
+			Message:  "Wayback Machine archiving failed: " + err.Error(),
+			Duration: time.Since(startedAt),
+		})
+		return
+	}
+
+	if _, err := config.DBFor(ctx).Exec("UPDATE crawl_runs SET archive_url = ? WHERE id = ?", archiveURL, runID); err != nil {
+		fmt.Printf("DEBUG: Failed to save archive URL for URL ID %d: %v\n", job.UrlID, err)
+		return
+	}
+	logEvent(ctx, logEntry{
+		UrlID:    job.UrlID,
+		JobID:    job.ID,
+		Event:    EventArchived,
+		Message:  "Archived the page in the Wayback Machine",
+		Duration: time.Since(startedAt),
+		Details: map[string]interface{}{
+			"archive_url": archiveURL,
+		},
+	})
+}
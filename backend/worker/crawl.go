@@ -0,0 +1,749 @@
+package worker
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"sync"
+	"time"
+
+	"sykell-analyze/backend/analyzer"
+	"sykell-analyze/backend/cache"
+	"sykell-analyze/backend/config"
+	"sykell-analyze/backend/crux"
+	"sykell-analyze/backend/dnscache"
+	"sykell-analyze/backend/geoip"
+	"sykell-analyze/backend/linkcache"
+	"sykell-analyze/backend/rdap"
+	"sykell-analyze/backend/safebrowsing"
+	"sykell-analyze/backend/sanitize"
+	"sykell-analyze/backend/urlstatus"
+	"sykell-analyze/backend/wayback"
+)
+
+// crawlOptions applies the configured crawler tuning, Safe Browsing, CrUX, RDAP and GeoIP lookups and
+// the link check cache to a crawl
+func crawlOptions(exclusions *analyzer.LinkExcluder) analyzer.Options {
+	settings := config.App.Crawler
+	opts := analyzer.Options{
+		Exclusions:                exclusions,
+		PageTimeout:               settings.PageTimeout,
+		RequestTimeout:            settings.RequestTimeout,
+		LinkCheckTimeout:          settings.LinkCheckTimeout,
+		MaxConcurrentLinkChecks:   settings.MaxConcurrentLinkChecks,
+		UserAgent:                 settings.UserAgent,
+		TreatSubdomainsAsInternal: settings.TreatSubdomainsAsInternal,
+		InternalDomains:           settings.InternalDomains,
+		IgnoreQueryParams:         settings.IgnoreQueryParams,
+		IgnoreTrailingSlash:       settings.IgnoreTrailingSlash,
+		CheckAssets:               settings.CheckAssets,
+		CheckDocuments:            settings.CheckDocuments,
+		MaxDocumentBytes:          int64(settings.MaxDocumentMB) << 20,
+		StreamAbove:               int64(settings.StreamAboveMB) << 20,
+		AllowTarget:               func(u *url.URL) error { return settings.CheckTarget(u.String()) },
+		Resolver:                  dnscache.Default(),
+		HTTPClient:                settings.HTTPClient(dnscache.Default()),
+	}
+	if safebrowsing.Default != nil {
+		opts.ThreatChecker = safebrowsing.Default
+	}
+	if crux.Default != nil {
+		opts.FieldData = crux.Default
+	}
+	if rdap.Default != nil {
+		opts.Registry = rdap.Default
+	}
+	if geoip.Default != nil {
+		opts.IPLocator = geoip.Default
+	}
+	if wayback.Default != nil {
+		opts.Archive = wayback.Default
+	}
+	if settings.LinkCacheTTL > 0 {
+		opts.LinkCache = linkcache.New(settings.LinkCacheTTL)
+	}
+	return opts
+}
+
+// crawlAndUpdateURL performs the actual crawling and updates the database.
+// Unless the job forces a fresh crawl, a recent crawl of the same URL by another user is reused.
+// It reports whether the owner paused the crawl before it finished; the URL is queued again then,
+// and its job must be put back with pauseJob.
+func crawlAndUpdateURL(ctx context.Context, job *Job) (paused bool) {
+	urlID, url := job.UrlID, job.Url
+	startedAt := time.Now()
+	defer invalidateOwnerCache(ctx, urlID)
+
+	// Update status to running and reset the progress of the previous crawl
+	_, err := urlstatus.Apply(ctx, urlstatus.Request{
+		UrlID: urlID,
+		To:    urlstatus.Running,
+		Set: `progress_stage = ?, progress_links_discovered = 0, progress_links_to_check = 0,
+			progress_links_checked = 0, progress_percent = 0, progress_updated_at = ?`,
+		Args: []interface{}{analyzer.StageFetching, startedAt},
+	})
+	if err != nil {
+		fmt.Printf("DEBUG: Not crawling URL ID %d: %v\n", urlID, err)
+		return
+	}
+	invalidateOwnerCache(ctx, urlID)
+
+	// Reuse a recent crawl of the same page instead of fetching it again
+	if !job.ForceFresh {
+		if sourceID, ok := findSharedResult(ctx, urlID, sharedCacheWindow()); ok {
+			err := copySharedResult(ctx, urlID, sourceID, startedAt)
+			if err == nil {
+				fmt.Printf("DEBUG: Reused crawl result of URL ID %d for URL ID %d\n", sourceID, urlID)
+				logEvent(ctx, logEntry{
+					UrlID:    urlID,
+					JobID:    job.ID,
+					Event:    EventReused,
+					Message:  "Reused a recent analysis of the same URL instead of crawling",
+					Duration: time.Since(startedAt),
+				})
+				return
+			}
+			fmt.Printf("DEBUG: Failed to reuse crawl result for URL ID %d, crawling instead: %v\n", urlID, err)
+		}
+	}
+
+	// Links the user asked us not to check
+	exclusions, err := loadLinkExclusions(ctx, urlID)
+	if err != nil {
+		fmt.Printf("DEBUG: Ignoring link exclusions for URL ID %d: %v\n", urlID, err)
+		logEvent(ctx, logEntry{
+			UrlID:   urlID,
+			JobID:   job.ID,
+			Level:   "warn",
+			Event:   EventWarning,
+			Message: "Link exclusions could not be loaded; all links were checked",
+		})
+	}
+
+	// Selector rules the user wants evaluated
+	rules, err := loadCheckRules(ctx, urlID)
+	if err != nil {
+		fmt.Printf("DEBUG: Ignoring check rules for URL ID %d: %v\n", urlID, err)
+		logEvent(ctx, logEntry{
+			UrlID:   urlID,
+			JobID:   job.ID,
+			Level:   "warn",
+			Event:   EventWarning,
+			Message: "Check rules could not be loaded; no checks were evaluated",
+		})
+	}
+
+	// Target keywords to count on the page
+	keywords, err := loadKeywords(ctx, urlID)
+	if err != nil {
+		fmt.Printf("DEBUG: Ignoring keywords for URL ID %d: %v\n", urlID, err)
+		logEvent(ctx, logEntry{
+			UrlID:   urlID,
+			JobID:   job.ID,
+			Level:   "warn",
+			Event:   EventWarning,
+			Message: "Keywords could not be loaded; no keywords were analyzed",
+		})
+	}
+
+	// Timeout, user agent and link checks chosen when the URL was added
+	opts := crawlOptions(exclusions)
+	settings, err := loadCrawlSettings(ctx, urlID)
+	if err != nil {
+		fmt.Printf("DEBUG: Ignoring crawl settings for URL ID %d: %v\n", urlID, err)
+		logEvent(ctx, logEntry{
+			UrlID:   urlID,
+			JobID:   job.ID,
+			Level:   "warn",
+			Event:   EventWarning,
+			Message: "Crawl settings could not be loaded; the server's settings were used",
+		})
+	}
+	settings.apply(&opts)
+
+	// Crawls are not cancelled on shutdown: the worker waits for them to finish. They stop early
+	// only when their owner pauses them, keeping the links checked so far for the resumed crawl.
+	crawlCtx, stopWatching := watchPause(ctx, config.App.Worker.PollInterval, func() (bool, error) {
+		return pauseRequested(ctx, job.ID)
+	})
+	defer stopWatching()
+	opts.LinkCache = &linkcache.Checkpoint{
+		JobID:  job.ID,
+		Cache:  opts.LinkCache,
+		Paused: func() bool { return errors.Is(context.Cause(crawlCtx), errCrawlPaused) },
+	}
+
+	// Crawl and analyze the URL, saving progress periodically while links are checked
+	progress := startProgressReporter(ctx, urlID, config.App.Worker.ProgressInterval)
+	defer progress.Stop() // also stops the reporter if the crawl panics
+	crawlResult, err := analyzer.New(
+		analyzer.WithOptions(opts),
+		analyzer.WithRules(rules.Rules...),
+		analyzer.WithKeywords(keywords...),
+		analyzer.WithProgress(progress.Update),
+	).Analyze(crawlCtx, url)
+	progress.Stop()
+	stopWatching()
+	if errors.Is(context.Cause(crawlCtx), errCrawlPaused) {
+		requeuePaused(ctx, job, startedAt)
+		return true
+	}
+	if err != nil {
+		// Update status to error
+		saveCrawlError(ctx, urlID, startedAt, err.Error())
+		logFailure(ctx, job, startedAt, err.Error())
+		return
+	}
+
+	// Debug logging
+	fmt.Printf("DEBUG: Crawl result for URL %s (ID: %d):\n", url, urlID)
+	fmt.Printf("  Title: %s\n", crawlResult.Title)
+	fmt.Printf("  Internal Links: %d\n", crawlResult.Links.Internal)
+	fmt.Printf("  External Links: %d\n", crawlResult.Links.External)
+	fmt.Printf("  H1: %d, H2: %d, H3: %d\n", crawlResult.Headings.H1, crawlResult.Headings.H2, crawlResult.Headings.H3)
+	fmt.Printf("  HTML Version: %s\n", crawlResult.HTMLVersion)
+	fmt.Printf("  Has Login Form: %t\n", crawlResult.HasLoginForm)
+	fmt.Printf("  Nofollow Links: %d internal, %d external\n", crawlResult.Links.InternalNofollow, crawlResult.Links.ExternalNofollow)
+	fmt.Printf("  Noindex: %t, Nofollow: %t\n", crawlResult.Robots.Noindex, crawlResult.Robots.Nofollow)
+
+	runID, err := saveCrawlResult(ctx, job, startedAt, crawlResult, rules.IDs)
+	if errors.Is(err, errLeaseLost) {
+		// The job is crawled again by the worker now holding the lease, which saves its own result
+		fmt.Printf("DEBUG: Not saving the crawl of URL ID %d: %v\n", urlID, err)
+		return
+	}
+	if err != nil {
+		// If saving fails, mark as error
+		fmt.Printf("DEBUG: Database update failed: %v\n", err)
+		saveCrawlError(ctx, urlID, startedAt, "Failed to save analysis results: "+err.Error())
+		logFailure(ctx, job, startedAt, "Failed to save analysis results: "+err.Error())
+		return
+	}
+
+	fmt.Printf("DEBUG: Database update successful for URL ID %d\n", urlID)
+	logEvent(ctx, logEntry{
+		UrlID: urlID,
+		JobID: job.ID,
+		Event: EventCompleted,
+		Message: fmt.Sprintf("Analysis completed: %d internal, %d external, %d broken links",
+			crawlResult.Links.Internal, crawlResult.Links.External, len(crawlResult.BrokenLinks)),
+		Duration: time.Since(startedAt),
+		Details: map[string]interface{}{
+			"internal_links": crawlResult.Links.Internal,
+			"external_links": crawlResult.Links.External,
+			"broken_links":   len(crawlResult.BrokenLinks),
+			"cached_links":   crawlResult.Links.Cached,
+			"streamed":       crawlResult.Streamed,
+		},
+	})
+
+	// Lighthouse loads the page again in a browser, so it runs once the analysis is saved
+	runLighthouse(ctx, job)
+
+	// The Wayback Machine fetches the page itself, too
+	archivePage(ctx, job, runID)
+	return false
+}
+
+// errCrawlPaused is the cause of a crawl's cancellation when its owner paused it
+var errCrawlPaused = errors.New("crawl paused")
+
+// watchPause returns a context of ctx that is cancelled with errCrawlPaused once requested reports
+// that the owner asked to pause the crawl, asking every interval until stop is called
+func watchPause(ctx context.Context, interval time.Duration, requested func() (bool, error)) (crawlCtx context.Context, stop func()) {
+	crawlCtx, cancel := context.WithCancelCause(ctx)
+	done := make(chan struct{})
+	var once sync.Once
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+			}
+			paused, err := requested()
+			if err != nil {
+				fmt.Printf("DEBUG: Failed to check whether a crawl was paused: %v\n", err)
+			} else if paused {
+				cancel(errCrawlPaused)
+				return
+			}
+		}
+	}()
+	return crawlCtx, func() {
+		once.Do(func() {
+			close(done)
+			cancel(nil)
+		})
+	}
+}
+
+// requeuePaused returns the URL of a paused crawl to the queue and records how far the crawl got
+func requeuePaused(ctx context.Context, job *Job, startedAt time.Time) {
+	_, err := urlstatus.Apply(ctx, urlstatus.Request{
+		UrlID: job.UrlID,
+		To:    urlstatus.Queued,
+		From:  urlstatus.Running,
+		Force: true,
+	})
+	if err != nil {
+		fmt.Printf("DEBUG: Failed to queue paused URL ID %d again: %v\n", job.UrlID, err)
+	}
+
+	var checked int
+	err = config.DBFor(ctx).QueryRow("SELECT COUNT(*) FROM crawl_checkpoints WHERE job_id = ?", job.ID).Scan(&checked)
+	if err != nil {
+		fmt.Printf("DEBUG: Failed to count checkpointed links of job %d: %v\n", job.ID, err)
+	}
+	logEvent(ctx, logEntry{
+		UrlID:    job.UrlID,
+		JobID:    job.ID,
+		Event:    EventPaused,
+		Message:  fmt.Sprintf("Crawl paused; %d checked links are kept for when it resumes", checked),
+		Duration: time.Since(startedAt),
+		Details:  map[string]interface{}{"checkpointed_links": checked},
+	})
+}
+
+// logFailure records why a crawl attempt failed
+func logFailure(ctx context.Context, job *Job, startedAt time.Time, message string) {
+	logEvent(ctx, logEntry{
+		UrlID:    job.UrlID,
+		JobID:    job.ID,
+		Level:    "error",
+		Event:    EventFailed,
+		Message:  message,
+		Duration: time.Since(startedAt),
+	})
+}
+
+// saveCrawlResult stores the analysis, its broken links, check and keyword results and the crawl run atomically,
+// returning the run's ID. ruleIDs holds the check_rules ID of each crawlResult.Rules entry. Nothing is saved,
+// and errLeaseLost returned, once the worker no longer holds the job's lease.
+func saveCrawlResult(ctx context.Context, job *Job, startedAt time.Time, crawlResult *analyzer.Result, ruleIDs []int) (int64, error) {
+	urlID := job.UrlID
+	var runID int64
+	var change urlstatus.Change
+	err := config.WithTransaction(ctx, func(tx *sql.Tx) error {
+		if err := lockLease(tx, job); err != nil {
+			return err
+		}
+		now := time.Now()
+
+		// Sent as a string: MySQL refuses to build JSON values from binary parameters
+		hreflang, err := json.Marshal(crawlResult.Hreflang)
+		if err != nil {
+			return fmt.Errorf("failed to encode hreflang: %w", err)
+		}
+		linkHygiene, err := json.Marshal(crawlResult.LinkHygiene)
+		if err != nil {
+			return fmt.Errorf("failed to encode link hygiene: %w", err)
+		}
+		contactLinks, err := json.Marshal(crawlResult.ContactLinks)
+		if err != nil {
+			return fmt.Errorf("failed to encode contact links: %w", err)
+		}
+		forms, err := json.Marshal(crawlResult.Forms)
+		if err != nil {
+			return fmt.Errorf("failed to encode forms: %w", err)
+		}
+		socialProfiles, err := json.Marshal(crawlResult.SocialProfiles)
+		if err != nil {
+			return fmt.Errorf("failed to encode social profiles: %w", err)
+		}
+		fragments, err := json.Marshal(crawlResult.Fragments)
+		if err != nil {
+			return fmt.Errorf("failed to encode fragment links: %w", err)
+		}
+		privacy, err := json.Marshal(crawlResult.Privacy)
+		if err != nil {
+			return fmt.Errorf("failed to encode privacy report: %w", err)
+		}
+		consent, err := json.Marshal(crawlResult.Consent)
+		if err != nil {
+			return fmt.Errorf("failed to encode consent detection: %w", err)
+		}
+		performance, err := json.Marshal(crawlResult.Performance)
+		if err != nil {
+			return fmt.Errorf("failed to encode performance: %w", err)
+		}
+		documents, err := json.Marshal(crawlResult.Documents)
+		if err != nil {
+			return fmt.Errorf("failed to encode documents: %w", err)
+		}
+		internalPages, err := json.Marshal(crawlResult.InternalPages)
+		if err != nil {
+			return fmt.Errorf("failed to encode internal pages: %w", err)
+		}
+		// Without Safe Browsing there is no verdict and the column is cleared
+		var safety *string
+		if crawlResult.Safety != nil {
+			encoded, err := json.Marshal(crawlResult.Safety)
+			if err != nil {
+				return fmt.Errorf("failed to encode safety verdict: %w", err)
+			}
+			value := string(encoded)
+			safety = &value
+		}
+		// Hosts that are IP addresses have no DNS records
+		var dns *string
+		if crawlResult.DNS != nil {
+			encoded, err := json.Marshal(crawlResult.DNS)
+			if err != nil {
+				return fmt.Errorf("failed to encode dns records: %w", err)
+			}
+			value := string(encoded)
+			dns = &value
+		}
+		// Likewise without RDAP lookups; the expiry date is also stored on its own for stats
+		var registration *string
+		var domainExpiresAt *time.Time
+		if crawlResult.Registration != nil {
+			encoded, err := json.Marshal(crawlResult.Registration)
+			if err != nil {
+				return fmt.Errorf("failed to encode domain registration: %w", err)
+			}
+			value := string(encoded)
+			registration = &value
+			domainExpiresAt = crawlResult.Registration.ExpiresAt
+		}
+		// Likewise without CrUX lookups
+		var webVitals *string
+		if crawlResult.WebVitals != nil {
+			encoded, err := json.Marshal(crawlResult.WebVitals)
+			if err != nil {
+				return fmt.Errorf("failed to encode web vitals: %w", err)
+			}
+			value := string(encoded)
+			webVitals = &value
+		}
+
+		// Likewise when asset checks are off
+		var brokenAssets *string
+		if crawlResult.BrokenAssets != nil {
+			encoded, err := json.Marshal(crawlResult.BrokenAssets)
+			if err != nil {
+				return fmt.Errorf("failed to encode broken assets: %w", err)
+			}
+			value := string(encoded)
+			brokenAssets = &value
+		}
+
+		// A failed fetch before any connection leaves no server to report
+		var hosting *string
+		if crawlResult.Hosting != nil {
+			encoded, err := json.Marshal(crawlResult.Hosting)
+			if err != nil {
+				return fmt.Errorf("failed to encode hosting: %w", err)
+			}
+			value := string(encoded)
+			hosting = &value
+		}
+
+		// Update with analysis results
+		query := `
+			UPDATE urls SET 
+				html_version = ?, title = ?, h1_count = ?, h2_count = ?, h3_count = ?,
+				internal_links = ?, external_links = ?, broken_links = ?, has_login_form = ?, forms = ?,
+				internal_nofollow_links = ?, external_nofollow_links = ?, sponsored_links = ?, ugc_links = ?,
+				is_noindex = ?, is_nofollow = ?, hreflang = ?, link_hygiene = ?, contact_links = ?, social_profiles = ?, fragments = ?, safety = ?, privacy = ?, consent = ?, has_consent_banner = ?,
+				performance = ?, dns = ?, hosting = ?, registration = ?, domain_expires_at = ?, web_vitals = ?,
+				broken_assets = ?, documents = ?, internal_pages = ?, result_schema_version = ?, crawled_at = ?, updated_at = ?
+			WHERE id = ?
+		`
+
+		_, err = tx.Exec(query,
+			crawlResult.HTMLVersion,
+			sanitize.Line(crawlResult.Title, sanitize.MaxTitle),
+			crawlResult.Headings.H1,
+			crawlResult.Headings.H2,
+			crawlResult.Headings.H3,
+			crawlResult.Links.Internal,
+			crawlResult.Links.External,
+			len(crawlResult.BrokenLinks),
+			crawlResult.HasLoginForm,
+			string(forms),
+			crawlResult.Links.InternalNofollow,
+			crawlResult.Links.ExternalNofollow,
+			crawlResult.Links.Sponsored,
+			crawlResult.Links.UGC,
+			crawlResult.Robots.Noindex,
+			crawlResult.Robots.Nofollow,
+			string(hreflang),
+			string(linkHygiene),
+			string(contactLinks),
+			string(socialProfiles),
+			string(fragments),
+			safety,
+			string(privacy),
+			string(consent),
+			crawlResult.Consent.Detected,
+			string(performance),
+			dns,
+			hosting,
+			registration,
+			domainExpiresAt,
+			webVitals,
+			brokenAssets,
+			string(documents),
+			string(internalPages),
+			analyzer.SchemaVersion,
+			now,
+			now,
+			urlID,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to update URL: %w", err)
+		}
+		if change, err = settle(tx, urlID, urlstatus.Completed, "error_message = NULL"); err != nil {
+			return fmt.Errorf("failed to update URL status: %w", err)
+		}
+
+		// Replace broken links details so a retried job never duplicates them
+		if _, err := tx.Exec("DELETE FROM broken_links WHERE url_id = ?", urlID); err != nil {
+			return fmt.Errorf("failed to clear broken links: %w", err)
+		}
+		for _, brokenLink := range crawlResult.BrokenLinks {
+			var statusCode *int
+			if brokenLink.StatusCode != 0 {
+				statusCode = &brokenLink.StatusCode
+			}
+			var suggestions *string
+			if len(brokenLink.Suggestions) > 0 {
+				encoded, err := json.Marshal(brokenLink.Suggestions)
+				if err != nil {
+					return fmt.Errorf("failed to encode link suggestions: %w", err)
+				}
+				value := string(encoded)
+				suggestions = &value
+			}
+			_, err := tx.Exec(
+				"INSERT INTO broken_links (url_id, link_url, status_code, error_message, suggestions, created_at) VALUES (?, ?, ?, ?, ?, ?)",
+				urlID, sanitize.Line(brokenLink.URL, sanitize.MaxURL), statusCode,
+				sanitize.Line(brokenLink.Error, sanitize.MaxMessage), suggestions, now,
+			)
+			if err != nil {
+				return fmt.Errorf("failed to store broken link: %w", err)
+			}
+		}
+
+		if err := saveCheckResults(tx, urlID, crawlResult.Rules, ruleIDs, now); err != nil {
+			return err
+		}
+		if err := saveKeywordResults(tx, urlID, crawlResult.Keywords, now); err != nil {
+			return err
+		}
+
+		runID, err = recordCrawlRun(tx, urlID, startedAt, "completed", len(crawlResult.BrokenLinks), "")
+		if err != nil {
+			return err
+		}
+		return saveSnapshot(ctx, tx, urlID, runID, crawlResult.Content, now)
+	})
+	if err == nil {
+		urlstatus.Publish(ctx, change)
+	}
+	return runID, err
+}
+
+// saveCrawlError marks the URL as failed and records the failed run
+func saveCrawlError(ctx context.Context, urlID int, startedAt time.Time, message string) {
+	// Messages can quote the page or its server
+	message = sanitize.Line(message, sanitize.MaxMessage)
+	var change urlstatus.Change
+	err := config.WithTransaction(ctx, func(tx *sql.Tx) error {
+		var err error
+		if change, err = settle(tx, urlID, urlstatus.Error, "error_message = ?", message); err != nil {
+			return err
+		}
+		_, err = recordCrawlRun(tx, urlID, startedAt, "error", 0, message)
+		return err
+	})
+	if err != nil {
+		fmt.Printf("DEBUG: Failed to save crawl error for URL ID %d: %v\n", urlID, err)
+		return
+	}
+	urlstatus.Publish(ctx, change)
+}
+
+// recordCrawlRun appends the outcome of a crawl to the crawl history and returns the run's ID
+func recordCrawlRun(db execer, urlID int, startedAt time.Time, status string, brokenLinks int, errorMessage string) (int64, error) {
+	var errMsg *string
+	if errorMessage != "" {
+		errMsg = &errorMessage
+	}
+
+	res, err := db.Exec(`
+		INSERT INTO crawl_runs (url_id, user_id, status, broken_links, error_message, started_at, finished_at)
+		SELECT id, user_id, ?, ?, ?, ?, ? FROM urls WHERE id = ?
+	`, status, brokenLinks, errMsg, startedAt, time.Now(), urlID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to record crawl run: %w", err)
+	}
+	return res.LastInsertId()
+}
+
+// loadLinkExclusions builds the excluder for a URL from its own and its owner's account-wide patterns
+func loadLinkExclusions(ctx context.Context, urlID int) (*analyzer.LinkExcluder, error) {
+	rows, err := config.DBFor(ctx).Query(`
+		SELECT e.pattern, e.pattern_type
+		FROM link_exclusions e
+		JOIN urls u ON u.user_id = e.user_id
+		WHERE u.id = ? AND (e.url_id IS NULL OR e.url_id = u.id)
+	`, urlID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load link exclusions: %w", err)
+	}
+	defer rows.Close()
+
+	var exclusions []analyzer.LinkExclusion
+	for rows.Next() {
+		var e analyzer.LinkExclusion
+		if err := rows.Scan(&e.Pattern, &e.PatternType); err != nil {
+			continue // skip bad rows
+		}
+		exclusions = append(exclusions, e)
+	}
+
+	return analyzer.NewLinkExcluder(exclusions)
+}
+
+// checkRules are the selector rules evaluated during a crawl, with their check_rules IDs in the same order
+type checkRules struct {
+	IDs   []int
+	Rules []analyzer.Rule
+}
+
+// loadCheckRules returns the URL's own and its owner's account-wide check rules
+func loadCheckRules(ctx context.Context, urlID int) (checkRules, error) {
+	var rules checkRules
+	rows, err := config.DBFor(ctx).Query(`
+		SELECT r.id, r.name, r.selector, r.rule_type, r.min_count, r.max_count, COALESCE(r.text, '')
+		FROM check_rules r
+		JOIN urls u ON u.user_id = r.user_id
+		WHERE u.id = ? AND (r.url_id IS NULL OR r.url_id = u.id)
+		ORDER BY r.id
+	`, urlID)
+	if err != nil {
+		return rules, fmt.Errorf("failed to load check rules: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id int
+		var r analyzer.Rule
+		if err := rows.Scan(&id, &r.Name, &r.Selector, &r.Type, &r.Min, &r.Max, &r.Text); err != nil {
+			continue // skip bad rows
+		}
+		rules.IDs = append(rules.IDs, id)
+		rules.Rules = append(rules.Rules, r)
+	}
+	return rules, rows.Err()
+}
+
+// saveCheckResults replaces the URL's check results with those of the latest crawl
+func saveCheckResults(db execer, urlID int, results []analyzer.RuleResult, ruleIDs []int, now time.Time) error {
+	if _, err := db.Exec("DELETE FROM check_results WHERE url_id = ?", urlID); err != nil {
+		return fmt.Errorf("failed to clear check results: %w", err)
+	}
+	for i, r := range results {
+		var ruleID *int
+		if i < len(ruleIDs) {
+			ruleID = &ruleIDs[i]
+		}
+		_, err := db.Exec(`
+			INSERT INTO check_results (url_id, rule_id, name, selector, rule_type, passed, matches, message, created_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+		`, urlID, ruleID, r.Rule.Name, r.Rule.Selector, r.Rule.Type, r.Passed, r.Matches, r.Message, now)
+		if err != nil {
+			return fmt.Errorf("failed to store check result: %w", err)
+		}
+	}
+	return nil
+}
+
+// crawlSettings are the crawl settings of a URL, chosen when it was added
+type crawlSettings struct {
+	timeoutSeconds   sql.NullInt64
+	userAgent        sql.NullString
+	checkBrokenLinks bool
+}
+
+// loadCrawlSettings reads the URL's crawl settings
+func loadCrawlSettings(ctx context.Context, urlID int) (crawlSettings, error) {
+	s := crawlSettings{checkBrokenLinks: true}
+	err := config.DBFor(ctx).QueryRow(
+		"SELECT crawl_timeout_seconds, crawl_user_agent, check_broken_links FROM urls WHERE id = ?", urlID,
+	).Scan(&s.timeoutSeconds, &s.userAgent, &s.checkBrokenLinks)
+	if err != nil {
+		return crawlSettings{checkBrokenLinks: true}, fmt.Errorf("failed to load crawl settings: %w", err)
+	}
+	return s, nil
+}
+
+// apply tunes opts to the URL's settings. The timeout only ever shortens the server's timeouts, in
+// case they were lowered after the URL was added.
+func (s crawlSettings) apply(opts *analyzer.Options) {
+	if s.timeoutSeconds.Valid {
+		timeout := time.Duration(s.timeoutSeconds.Int64) * time.Second
+		opts.PageTimeout = min(opts.PageTimeout, timeout)
+		opts.RequestTimeout = min(opts.RequestTimeout, timeout)
+		opts.LinkCheckTimeout = min(opts.LinkCheckTimeout, timeout)
+	}
+	if s.userAgent.Valid {
+		opts.UserAgent = s.userAgent.String
+	}
+	opts.SkipLinkChecks = !s.checkBrokenLinks
+}
+
+// loadKeywords returns the URL's target keywords
+func loadKeywords(ctx context.Context, urlID int) ([]string, error) {
+	rows, err := config.DBFor(ctx).Query("SELECT keyword FROM url_keywords WHERE url_id = ? ORDER BY id", urlID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load keywords: %w", err)
+	}
+	defer rows.Close()
+
+	var keywords []string
+	for rows.Next() {
+		var keyword string
+		if err := rows.Scan(&keyword); err != nil {
+			continue // skip bad rows
+		}
+		keywords = append(keywords, keyword)
+	}
+	return keywords, rows.Err()
+}
+
+// saveKeywordResults replaces the URL's keyword results with those of the latest crawl
+func saveKeywordResults(db execer, urlID int, results []analyzer.KeywordResult, now time.Time) error {
+	if _, err := db.Exec("DELETE FROM keyword_results WHERE url_id = ?", urlID); err != nil {
+		return fmt.Errorf("failed to clear keyword results: %w", err)
+	}
+	for _, r := range results {
+		_, err := db.Exec(`
+			INSERT INTO keyword_results (url_id, keyword, title_count, headings_count, meta_description_count, body_count, density, created_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		`, urlID, r.Keyword, r.Title, r.Headings, r.MetaDescription, r.Body, r.Density, now)
+		if err != nil {
+			return fmt.Errorf("failed to store keyword result: %w", err)
+		}
+	}
+	return nil
+}
+
+// invalidateOwnerCache drops cached list and stats responses of the URL's owner
+func invalidateOwnerCache(ctx context.Context, urlID int) {
+	if !cache.Enabled() {
+		return
+	}
+	var userID int
+	if err := config.DBFor(ctx).QueryRow("SELECT user_id FROM urls WHERE id = ?", urlID).Scan(&userID); err == nil {
+		cache.InvalidateUser(ctx, userID)
+	}
+}
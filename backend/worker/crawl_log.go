@@ -0,0 +1,83 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"sykell-analyze/backend/config"
+)
+
+// Crawl log events, in the order they usually happen
+const (
+	EventEnqueued  = "enqueued"
+	EventPriority  = "priority_changed"
+	EventCancelled = "cancelled"
+	EventPaused    = "paused"
+	EventResumed   = "resumed"
+	EventStarted   = "started"
+	EventReused    = "reused"
+	EventCompleted = "completed"
+	EventScored    = "lighthouse_scored"
+	EventArchived  = "archived"
+	EventFailed    = "failed"
+	EventAbandoned = "abandoned"
+	EventWarning   = "warning"
+)
+
+// maxLogMessageLength matches crawl_logs.message
+const maxLogMessageLength = 1000
+
+// logEntry is one line of a URL's crawl log
+type logEntry struct {
+	UrlID    int
+	JobID    int // 0 when the event is not tied to a job
+	Level    string
+	Event    string
+	Message  string
+	Duration time.Duration // 0 when not applicable
+	Details  map[string]interface{}
+}
+
+// writeLog appends an entry to the crawl log. The log is diagnostic only, so a failed write is
+// printed rather than failing the crawl.
+func writeLog(db execer, e logEntry) {
+	if e.Level == "" {
+		e.Level = "info"
+	}
+	if len(e.Message) > maxLogMessageLength {
+		e.Message = e.Message[:maxLogMessageLength]
+	}
+
+	var jobID *int
+	if e.JobID != 0 {
+		jobID = &e.JobID
+	}
+	var durationMs *int64
+	if e.Duration > 0 {
+		ms := e.Duration.Milliseconds()
+		durationMs = &ms
+	}
+	// Sent as a string: MySQL refuses to build JSON values from binary parameters
+	var details *string
+	if len(e.Details) > 0 {
+		if encoded, err := json.Marshal(e.Details); err == nil {
+			value := string(encoded)
+			details = &value
+		}
+	}
+
+	_, err := db.Exec(`
+		INSERT INTO crawl_logs (url_id, job_id, level, event, message, duration_ms, details, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`, e.UrlID, jobID, e.Level, e.Event, e.Message, durationMs, details, time.Now())
+	if err != nil {
+		fmt.Printf("DEBUG: Failed to write crawl log for URL ID %d: %v\n", e.UrlID, err)
+	}
+}
+
+// logEvent writes an entry outside of any transaction
+func logEvent(ctx context.Context, e logEntry) {
+	writeLog(config.DBFor(ctx), e)
+}
@@ -0,0 +1,155 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"sykell-analyze/backend/config"
+)
+
+// defaultCrawlDuration is assumed until enough crawls have finished to measure the average
+const defaultCrawlDuration = 30 * time.Second
+
+// averageCacheTTL limits how often the crawl history is scanned for the average duration
+const averageCacheTTL = time.Minute
+
+// cachedAverage is the average crawl duration of one tenant
+type cachedAverage struct {
+	value     time.Duration
+	expiresAt time.Time
+}
+
+// averageCache holds the cached average of each tenant by name, "" being the primary database
+var averageCache struct {
+	sync.Mutex
+	byTenant map[string]cachedAverage
+}
+
+// AverageCrawlDuration is the mean duration of the last 100 completed crawls of ctx's tenant,
+// cached for a minute
+func AverageCrawlDuration(ctx context.Context) time.Duration {
+	averageCache.Lock()
+	defer averageCache.Unlock()
+
+	tenant := config.TenantName(ctx)
+	if cached, ok := averageCache.byTenant[tenant]; ok && time.Now().Before(cached.expiresAt) {
+		return cached.value
+	}
+
+	var seconds float64
+	err := config.DBFor(ctx).QueryRow(`
+		SELECT COALESCE(AVG(TIMESTAMPDIFF(MICROSECOND, started_at, finished_at)) / 1000000, 0)
+		FROM (
+			SELECT started_at, finished_at FROM crawl_runs
+			WHERE status = 'completed' AND started_at IS NOT NULL
+			ORDER BY id DESC
+			LIMIT 100
+		) recent
+	`).Scan(&seconds)
+
+	average := defaultCrawlDuration
+	if err != nil {
+		fmt.Printf("DEBUG: Failed to compute average crawl duration: %v\n", err)
+	} else if seconds > 0 {
+		average = time.Duration(seconds * float64(time.Second))
+	}
+
+	if averageCache.byTenant == nil {
+		averageCache.byTenant = make(map[string]cachedAverage)
+	}
+	averageCache.byTenant[tenant] = cachedAverage{value: average, expiresAt: time.Now().Add(averageCacheTTL)}
+	return average
+}
+
+// QueueSnapshot holds what completion estimates are based on
+type QueueSnapshot struct {
+	// Positions maps URL IDs with a pending job to the number of pending jobs ahead of them
+	Positions map[int]int
+	// Slots is how many crawls live workers run at once
+	Slots int
+	// AverageDuration is the typical time one crawl takes
+	AverageDuration time.Duration
+}
+
+// SnapshotQueue reads the queue positions of the given URLs and the current worker capacity
+func SnapshotQueue(ctx context.Context, urlIDs []int, heartbeatWindow time.Duration) (QueueSnapshot, error) {
+	snapshot := QueueSnapshot{
+		Positions:       make(map[int]int),
+		AverageDuration: AverageCrawlDuration(ctx),
+	}
+
+	err := config.DBFor(ctx).QueryRow(
+		"SELECT COALESCE(SUM(concurrency), 0) FROM crawl_workers WHERE last_heartbeat_at >= ?",
+		time.Now().Add(-heartbeatWindow),
+	).Scan(&snapshot.Slots)
+	if err != nil {
+		return snapshot, fmt.Errorf("failed to count worker slots: %w", err)
+	}
+
+	if len(urlIDs) == 0 {
+		return snapshot, nil
+	}
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(urlIDs)), ",")
+	args := make([]interface{}, len(urlIDs))
+	for i, id := range urlIDs {
+		args[i] = id
+	}
+
+	// Jobs are leased by priority then id, so higher priorities and older jobs of the same priority are ahead
+	rows, err := config.DBFor(ctx).Query(`
+		SELECT j.url_id,
+			(SELECT COUNT(*) FROM crawl_jobs ahead
+			 WHERE ahead.status = 'pending'
+			   AND (ahead.priority > j.priority OR (ahead.priority = j.priority AND ahead.id < j.id)))
+		FROM crawl_jobs j
+		WHERE j.status = 'pending' AND j.url_id IN (`+placeholders+`)
+	`, args...)
+	if err != nil {
+		return snapshot, fmt.Errorf("failed to read queue positions: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var urlID, ahead int
+		if err := rows.Scan(&urlID, &ahead); err != nil {
+			continue // skip bad rows
+		}
+		snapshot.Positions[urlID] = ahead
+	}
+	return snapshot, rows.Err()
+}
+
+// EstimateQueued returns the seconds until a queued URL should be analyzed: the jobs ahead of it
+// are shared across the worker slots, then its own crawl takes about the average duration.
+// ok is false when no worker is running, since the URL will then wait indefinitely.
+func (s QueueSnapshot) EstimateQueued(urlID int) (seconds int, ok bool) {
+	if s.Slots <= 0 {
+		return 0, false
+	}
+	ahead := s.Positions[urlID]
+	rounds := ahead/s.Slots + 1
+	return ceilSeconds(time.Duration(rounds) * s.AverageDuration), true
+}
+
+// EstimateRunning returns the seconds left for a running crawl. Once link checks are under way
+// the reported percent is extrapolated; before that the average duration is used.
+func (s QueueSnapshot) EstimateRunning(elapsed time.Duration, percent int) int {
+	var remaining time.Duration
+	if percent >= 10 && percent < 100 {
+		remaining = elapsed * time.Duration(100-percent) / time.Duration(percent)
+	} else {
+		remaining = s.AverageDuration - elapsed
+	}
+	if remaining < time.Second {
+		remaining = time.Second
+	}
+	return ceilSeconds(remaining)
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int((d + time.Second - 1) / time.Second)
+}
@@ -0,0 +1,51 @@
+package worker
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEstimateQueued(t *testing.T) {
+	snapshot := QueueSnapshot{
+		Positions:       map[int]int{1: 0, 2: 4, 3: 10},
+		Slots:           5,
+		AverageDuration: 20 * time.Second,
+	}
+
+	t.Run("next in line takes one crawl", func(t *testing.T) {
+		seconds, ok := snapshot.EstimateQueued(1)
+		assert.True(t, ok)
+		assert.Equal(t, 20, seconds)
+	})
+
+	t.Run("jobs ahead are shared across slots", func(t *testing.T) {
+		seconds, _ := snapshot.EstimateQueued(2)
+		assert.Equal(t, 20, seconds)
+
+		seconds, _ = snapshot.EstimateQueued(3)
+		assert.Equal(t, 60, seconds)
+	})
+
+	t.Run("no workers means no estimate", func(t *testing.T) {
+		_, ok := QueueSnapshot{AverageDuration: time.Second}.EstimateQueued(1)
+		assert.False(t, ok)
+	})
+}
+
+func TestEstimateRunning(t *testing.T) {
+	snapshot := QueueSnapshot{AverageDuration: 30 * time.Second}
+
+	t.Run("falls back to the average before link checks start", func(t *testing.T) {
+		assert.Equal(t, 20, snapshot.EstimateRunning(10*time.Second, 5))
+	})
+
+	t.Run("extrapolates reported progress", func(t *testing.T) {
+		assert.Equal(t, 30, snapshot.EstimateRunning(10*time.Second, 25))
+	})
+
+	t.Run("never drops below one second", func(t *testing.T) {
+		assert.Equal(t, 1, snapshot.EstimateRunning(time.Minute, 0))
+	})
+}
@@ -0,0 +1,90 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"sykell-analyze/backend/config"
+	"sykell-analyze/backend/lighthouse"
+)
+
+// runLighthouse scores the crawled page with Lighthouse when scoring is enabled.
+// A failed run keeps the previous scores and records why the latest run failed.
+func runLighthouse(ctx context.Context, job *Job) {
+	client := lighthouse.Default
+	if client == nil {
+		return
+	}
+
+	startedAt := time.Now()
+	report, err := client.Run(ctx, job.Url)
+	if err != nil {
+		fmt.Printf("DEBUG: Lighthouse run failed for URL ID %d: %v\n", job.UrlID, err)
+		if err := saveLighthouseError(ctx, job.UrlID, client.Strategy, err.Error()); err != nil {
+			fmt.Printf("DEBUG: Failed to save Lighthouse error for URL ID %d: %v\n", job.UrlID, err)
+		}
+		logEvent(ctx, logEntry{
+			UrlID:    job.UrlID,
+			JobID:    job.ID,
+			Level:    "warn",
+			Event:    EventWarning,
+			Message:  "Lighthouse scoring failed: " + err.Error(),
+			Duration: time.Since(startedAt),
+		})
+		return
+	}
+
+	if err := saveLighthouseReport(ctx, job.UrlID, report); err != nil {
+		fmt.Printf("DEBUG: Failed to save Lighthouse scores for URL ID %d: %v\n", job.UrlID, err)
+		return
+	}
+	logEvent(ctx, logEntry{
+		UrlID:    job.UrlID,
+		JobID:    job.ID,
+		Event:    EventScored,
+		Message:  fmt.Sprintf("Lighthouse scored the page (%s)", report.Strategy),
+		Duration: time.Since(startedAt),
+		Details: map[string]interface{}{
+			"performance":    report.Scores.Performance,
+			"accessibility":  report.Scores.Accessibility,
+			"best_practices": report.Scores.BestPractices,
+			"seo":            report.Scores.SEO,
+		},
+	})
+}
+
+// saveLighthouseReport replaces the URL's Lighthouse scores
+func saveLighthouseReport(ctx context.Context, urlID int, report *lighthouse.Report) error {
+	// Sent as a string: MySQL refuses to build JSON values from binary parameters
+	metrics, err := json.Marshal(report.Metrics)
+	if err != nil {
+		return fmt.Errorf("failed to encode lighthouse metrics: %w", err)
+	}
+
+	_, err = config.DBFor(ctx).Exec(`
+		INSERT INTO lighthouse_results (url_id, strategy, performance_score, accessibility_score, best_practices_score,
+			seo_score, metrics, lighthouse_version, fetched_at, error_message, checked_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, NULL, ?)
+		ON DUPLICATE KEY UPDATE strategy = VALUES(strategy), performance_score = VALUES(performance_score),
+			accessibility_score = VALUES(accessibility_score), best_practices_score = VALUES(best_practices_score),
+			seo_score = VALUES(seo_score), metrics = VALUES(metrics), lighthouse_version = VALUES(lighthouse_version),
+			fetched_at = VALUES(fetched_at), error_message = NULL, checked_at = VALUES(checked_at)
+	`, urlID, report.Strategy, report.Scores.Performance, report.Scores.Accessibility, report.Scores.BestPractices,
+		report.Scores.SEO, string(metrics), report.LighthouseVersion, report.FetchedAt, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to store lighthouse scores: %w", err)
+	}
+	return nil
+}
+
+// saveLighthouseError records a failed run without discarding earlier scores
+func saveLighthouseError(ctx context.Context, urlID int, strategy, message string) error {
+	_, err := config.DBFor(ctx).Exec(`
+		INSERT INTO lighthouse_results (url_id, strategy, error_message, checked_at)
+		VALUES (?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE error_message = VALUES(error_message), checked_at = VALUES(checked_at)
+	`, urlID, strategy, message, time.Now())
+	return err
+}
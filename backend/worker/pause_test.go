@@ -0,0 +1,43 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWatchPause(t *testing.T) {
+	t.Run("pause cancels the crawl", func(t *testing.T) {
+		var checks atomic.Int32
+		crawlCtx, stop := watchPause(context.Background(), time.Millisecond, func() (bool, error) {
+			switch checks.Add(1) {
+			case 1:
+				return false, errors.New("connection refused")
+			case 2:
+				return false, nil
+			}
+			return true, nil
+		})
+		defer stop()
+
+		select {
+		case <-crawlCtx.Done():
+		case <-time.After(time.Second):
+			t.Fatal("crawl was not cancelled")
+		}
+		assert.ErrorIs(t, context.Cause(crawlCtx), errCrawlPaused)
+		assert.EqualValues(t, 3, checks.Load())
+	})
+
+	t.Run("stopping is not a pause", func(t *testing.T) {
+		crawlCtx, stop := watchPause(context.Background(), time.Hour, func() (bool, error) { return true, nil })
+		stop()
+		stop()
+		assert.Error(t, crawlCtx.Err())
+		assert.NotErrorIs(t, context.Cause(crawlCtx), errCrawlPaused)
+	})
+}
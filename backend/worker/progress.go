@@ -0,0 +1,105 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"sykell-analyze/backend/analyzer"
+	"sykell-analyze/backend/config"
+)
+
+// progressReporter receives progress callbacks from the crawler and writes the latest snapshot
+// to the database at most once per interval, so link checks never wait on the database
+type progressReporter struct {
+	urlID    int
+	interval time.Duration
+	write    func(urlID int, p analyzer.Progress) error
+
+	mu     sync.Mutex
+	latest analyzer.Progress
+	dirty  bool
+
+	stop     chan struct{}
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+// startProgressReporter begins flushing progress for the URL in the background
+func startProgressReporter(ctx context.Context, urlID int, interval time.Duration) *progressReporter {
+	r := newProgressReporter(urlID, interval, func(urlID int, p analyzer.Progress) error {
+		return saveProgress(ctx, urlID, p)
+	})
+	go r.loop()
+	return r
+}
+
+func newProgressReporter(urlID int, interval time.Duration, write func(int, analyzer.Progress) error) *progressReporter {
+	return &progressReporter{
+		urlID:    urlID,
+		interval: interval,
+		write:    write,
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+}
+
+// Update records the latest progress; it is passed to the crawler as CrawlOptions.Progress
+func (r *progressReporter) Update(p analyzer.Progress) {
+	r.mu.Lock()
+	r.latest = p
+	r.dirty = true
+	r.mu.Unlock()
+}
+
+// Stop ends the background loop after writing any unsaved progress. It is safe to call more than once.
+func (r *progressReporter) Stop() {
+	r.stopOnce.Do(func() { close(r.stop) })
+	<-r.done
+}
+
+func (r *progressReporter) loop() {
+	defer close(r.done)
+
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.stop:
+			r.flush()
+			return
+		case <-ticker.C:
+			r.flush()
+		}
+	}
+}
+
+// flush writes the latest snapshot if it changed since the last write
+func (r *progressReporter) flush() {
+	r.mu.Lock()
+	if !r.dirty {
+		r.mu.Unlock()
+		return
+	}
+	p := r.latest
+	r.dirty = false
+	r.mu.Unlock()
+
+	if err := r.write(r.urlID, p); err != nil {
+		fmt.Printf("DEBUG: Failed to save crawl progress for URL ID %d: %v\n", r.urlID, err)
+	}
+}
+
+// saveProgress stores a progress snapshot without touching updated_at
+func saveProgress(ctx context.Context, urlID int, p analyzer.Progress) error {
+	_, err := config.DBFor(ctx).Exec(`
+		UPDATE urls SET
+			progress_stage = ?, progress_links_discovered = ?, progress_links_to_check = ?,
+			progress_links_checked = ?, progress_percent = ?, progress_updated_at = ?,
+			updated_at = updated_at
+		WHERE id = ?
+	`, p.Stage, p.LinksDiscovered, p.LinksToCheck, p.LinksChecked, p.Percent(), time.Now(), urlID)
+	return err
+}
@@ -0,0 +1,49 @@
+package worker
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"sykell-analyze/backend/analyzer"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProgressReporter(t *testing.T) {
+	var mu sync.Mutex
+	var written []analyzer.Progress
+	write := func(urlID int, p analyzer.Progress) error {
+		mu.Lock()
+		defer mu.Unlock()
+		assert.Equal(t, 9, urlID)
+		written = append(written, p)
+		return nil
+	}
+
+	t.Run("only the latest snapshot is written", func(t *testing.T) {
+		written = nil
+		r := newProgressReporter(9, time.Hour, write)
+		go r.loop()
+
+		r.Update(analyzer.Progress{Stage: analyzer.StageCheckingLinks, LinksChecked: 1})
+		r.Update(analyzer.Progress{Stage: analyzer.StageCheckingLinks, LinksChecked: 2})
+		r.Stop()
+
+		assert.Len(t, written, 1)
+		assert.Equal(t, 2, written[0].LinksChecked)
+	})
+
+	t.Run("unchanged progress is not rewritten", func(t *testing.T) {
+		written = nil
+		r := newProgressReporter(9, time.Hour, write)
+		go r.loop()
+
+		r.Update(analyzer.Progress{Stage: analyzer.StageDone})
+		r.flush()
+		r.Stop()
+		r.Stop()
+
+		assert.Len(t, written, 1)
+	})
+}
@@ -0,0 +1,650 @@
+package worker
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"sykell-analyze/backend/config"
+	"sykell-analyze/backend/urlstatus"
+)
+
+// Job is a leased crawl job
+type Job struct {
+	ID         int
+	UrlID      int
+	Url        string
+	Attempts   int
+	ForceFresh bool
+	// WorkerID is the worker holding the lease
+	WorkerID string
+}
+
+// Priority orders pending jobs: higher priorities are leased first, then oldest first
+type Priority int
+
+const (
+	PriorityLow    Priority = -1
+	PriorityNormal Priority = 0
+	PriorityHigh   Priority = 1
+)
+
+// ErrInvalidPriority is returned by ParsePriority for unknown names
+var ErrInvalidPriority = errors.New("priority must be high, normal or low")
+
+// ParsePriority converts high, normal or low into a Priority; an empty name means normal
+func ParsePriority(name string) (Priority, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "high":
+		return PriorityHigh, nil
+	case "", "normal":
+		return PriorityNormal, nil
+	case "low":
+		return PriorityLow, nil
+	}
+	return PriorityNormal, ErrInvalidPriority
+}
+
+// String returns the name accepted by ParsePriority
+func (p Priority) String() string {
+	switch {
+	case p > PriorityNormal:
+		return "high"
+	case p < PriorityNormal:
+		return "low"
+	}
+	return "normal"
+}
+
+// EnqueueOptions tunes how a queued URL is crawled
+type EnqueueOptions struct {
+	// ForceFresh skips the shared crawl cache and always fetches the page
+	ForceFresh bool
+	// Priority decides the job's place in the queue (default normal)
+	Priority Priority
+}
+
+// execer is satisfied by both *sql.DB and *sql.Tx
+type execer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+}
+
+// Enqueue adds a crawl job for the URL unless one is already waiting. A paused job counts as
+// waiting and is resumed.
+func Enqueue(ctx context.Context, urlID int, opts EnqueueOptions) error {
+	return enqueue(config.DBFor(ctx), urlID, opts)
+}
+
+// EnqueueTx is Enqueue as part of a caller-managed transaction
+func EnqueueTx(tx *sql.Tx, urlID int, opts EnqueueOptions) error {
+	return enqueue(tx, urlID, opts)
+}
+
+func enqueue(db execer, urlID int, opts EnqueueOptions) error {
+	now := time.Now()
+	result, err := db.Exec(`
+		INSERT INTO crawl_jobs (url_id, status, force_fresh, priority, created_at, updated_at)
+		SELECT ?, 'pending', ?, ?, ?, ?
+		FROM DUAL
+		WHERE NOT EXISTS (
+			SELECT 1 FROM crawl_jobs WHERE url_id = ? AND status IN ('pending', 'paused')
+		)
+	`, urlID, opts.ForceFresh, opts.Priority, now, now, urlID)
+	if err != nil {
+		return fmt.Errorf("failed to enqueue crawl job: %w", err)
+	}
+
+	if inserted, _ := result.RowsAffected(); inserted > 0 {
+		jobID, _ := result.LastInsertId()
+		message := fmt.Sprintf("Queued for analysis with %s priority", opts.Priority)
+		if opts.ForceFresh {
+			message += ", skipping recently shared results"
+		}
+		writeLog(db, logEntry{UrlID: urlID, JobID: int(jobID), Event: EventEnqueued, Message: message})
+		return nil
+	}
+
+	// A waiting job picks up the stricter freshness requirement and the higher priority
+	_, err = db.Exec(`
+		UPDATE crawl_jobs
+		SET status = 'pending', force_fresh = force_fresh OR ?, priority = GREATEST(priority, ?), updated_at = ?
+		WHERE url_id = ? AND status IN ('pending', 'paused')
+	`, opts.ForceFresh, opts.Priority, now, urlID)
+	if err != nil {
+		return fmt.Errorf("failed to update queued crawl job: %w", err)
+	}
+	return nil
+}
+
+// ErrJobNotFound is returned by SetJobPriority for unknown job IDs
+var ErrJobNotFound = errors.New("crawl job not found")
+
+// ErrJobFinished is returned by SetJobPriority for jobs that are no longer waiting or running
+var ErrJobFinished = errors.New("crawl job already finished")
+
+// SetJobPriority changes the priority of a job that has not finished, e.g. to move a stuck job forward
+func SetJobPriority(ctx context.Context, jobID int, priority Priority) error {
+	var urlID int
+	var status string
+	err := config.DBFor(ctx).QueryRow("SELECT url_id, status FROM crawl_jobs WHERE id = ?", jobID).Scan(&urlID, &status)
+	if err == sql.ErrNoRows {
+		return ErrJobNotFound
+	} else if err != nil {
+		return fmt.Errorf("failed to load crawl job: %w", err)
+	}
+	if status == "done" || status == "failed" {
+		return ErrJobFinished
+	}
+
+	result, err := config.DBFor(ctx).Exec(`
+		UPDATE crawl_jobs SET priority = ?, updated_at = ?
+		WHERE id = ? AND status IN ('pending', 'paused', 'leased')
+	`, priority, time.Now(), jobID)
+	if err != nil {
+		return fmt.Errorf("failed to update job priority: %w", err)
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return ErrJobFinished
+	}
+
+	logEvent(ctx, logEntry{
+		UrlID:   urlID,
+		JobID:   jobID,
+		Event:   EventPriority,
+		Message: fmt.Sprintf("Priority changed to %s by an administrator", priority),
+	})
+	return nil
+}
+
+// QueuedJob is a user's crawl job that is waiting or running
+type QueuedJob struct {
+	ID     int    `json:"id"`
+	UrlID  int    `json:"url_id"`
+	Url    string `json:"url"`
+	Status string `json:"status"` // queued, paused, running or pausing (running until its next check)
+	// Position is the job's place in the shared queue, 1 being next; nil unless queued
+	Position   *int      `json:"position"`
+	Priority   string    `json:"priority"`
+	Attempts   int       `json:"attempts"`
+	WorkerID   *string   `json:"worker_id"`
+	EnqueuedAt time.Time `json:"enqueued_at"`
+}
+
+// UserJobs returns the user's waiting and running jobs: running ones first, then in the order
+// workers will take them
+func UserJobs(ctx context.Context, userID int) ([]QueuedJob, error) {
+	// Jobs are leased by priority then id, so higher priorities and older jobs of the same priority are ahead
+	rows, err := config.DBFor(ctx).Query(`
+		SELECT j.id, j.url_id, u.url, j.status, j.pause_requested, j.priority, j.attempts, j.worker_id, j.created_at,
+			(SELECT COUNT(*) FROM crawl_jobs ahead
+			 WHERE ahead.status = 'pending'
+			   AND (ahead.priority > j.priority OR (ahead.priority = j.priority AND ahead.id < j.id)))
+		FROM crawl_jobs j
+		JOIN urls u ON u.id = j.url_id
+		WHERE u.user_id = ? AND j.status IN ('pending', 'paused', 'leased')
+		ORDER BY j.status = 'leased' DESC, j.priority DESC, j.id
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read queued jobs: %w", err)
+	}
+	defer rows.Close()
+
+	jobs := []QueuedJob{}
+	for rows.Next() {
+		var job QueuedJob
+		var status string
+		var pauseRequested bool
+		var priority Priority
+		var workerID sql.NullString
+		var ahead int
+		if err := rows.Scan(&job.ID, &job.UrlID, &job.Url, &status, &pauseRequested, &priority, &job.Attempts, &workerID,
+			&job.EnqueuedAt, &ahead); err != nil {
+			return nil, fmt.Errorf("failed to read queued job: %w", err)
+		}
+		job.Priority = priority.String()
+		if workerID.Valid {
+			job.WorkerID = &workerID.String
+		}
+		switch status {
+		case "pending":
+			job.Status = "queued"
+			position := ahead + 1
+			job.Position = &position
+		case "paused":
+			job.Status = "paused"
+		default:
+			job.Status = "running"
+			if pauseRequested {
+				job.Status = "pausing"
+			}
+		}
+		jobs = append(jobs, job)
+	}
+	return jobs, rows.Err()
+}
+
+// ErrJobStarted is returned by CancelJob for jobs a worker has already picked up
+var ErrJobStarted = errors.New("crawl job already started")
+
+// CancelJob removes the user's job before a worker picks it up. A URL left without work returns
+// to the outcome of its last crawl, or becomes cancelled when it was never crawled.
+func CancelJob(ctx context.Context, jobID, userID int) error {
+	tx, err := config.DBFor(ctx).Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var urlID int
+	var status string
+	err = tx.QueryRow(`
+		SELECT j.url_id, j.status FROM crawl_jobs j
+		JOIN urls u ON u.id = j.url_id
+		WHERE j.id = ? AND u.user_id = ?
+		FOR UPDATE
+	`, jobID, userID).Scan(&urlID, &status)
+	if err == sql.ErrNoRows {
+		return ErrJobNotFound
+	} else if err != nil {
+		return fmt.Errorf("failed to load crawl job: %w", err)
+	}
+	switch status {
+	case "leased":
+		return ErrJobStarted
+	case "done", "failed":
+		return ErrJobFinished
+	}
+
+	if _, err := tx.Exec("DELETE FROM crawl_jobs WHERE id = ?", jobID); err != nil {
+		return fmt.Errorf("failed to delete crawl job: %w", err)
+	}
+
+	var lastStatus, lastError sql.NullString
+	err = tx.QueryRow(
+		"SELECT status, error_message FROM crawl_runs WHERE url_id = ? ORDER BY id DESC LIMIT 1", urlID,
+	).Scan(&lastStatus, &lastError)
+	if err != nil && err != sql.ErrNoRows {
+		return fmt.Errorf("failed to load last crawl: %w", err)
+	}
+	if !lastStatus.Valid {
+		lastStatus.String = urlstatus.Cancelled
+		lastError = sql.NullString{String: "Analysis cancelled before it started", Valid: true}
+	}
+	// A running crawl of the same URL still owns its status
+	change, err := urlstatus.Transition(tx, urlstatus.Request{
+		UrlID: urlID,
+		To:    lastStatus.String,
+		From:  urlstatus.Queued,
+		Force: true,
+		Set:   "error_message = ?",
+		Args:  []interface{}{lastError},
+	})
+	var invalid *urlstatus.InvalidTransitionError
+	if err != nil && !errors.As(err, &invalid) {
+		return fmt.Errorf("failed to restore URL status: %w", err)
+	}
+
+	writeLog(tx, logEntry{UrlID: urlID, JobID: jobID, Event: EventCancelled, Message: "Removed from the queue by the owner"})
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	if invalid == nil {
+		urlstatus.Publish(ctx, change)
+	}
+	return nil
+}
+
+// ErrNothingQueued is returned by PauseCrawl when the URL has no crawl waiting in the queue or running
+var ErrNothingQueued = errors.New("no crawl is queued")
+
+// ErrNotPaused is returned by ResumeCrawl when the URL has no paused crawl
+var ErrNotPaused = errors.New("crawl is not paused")
+
+// PauseCrawl holds the URL's waiting job so workers skip it until ResumeCrawl. A running crawl is
+// asked to stop: its worker notices within worker.poll_interval, keeps the verdicts of the links
+// it checked (see linkcache.Checkpoint) and puts the job back as paused. stopping reports the
+// latter case.
+func PauseCrawl(ctx context.Context, urlID int) (stopping bool, err error) {
+	err = setWaitingStatus(ctx, urlID, "pending", "paused", EventPaused, "Paused by the owner")
+	if !errors.Is(err, ErrNothingQueued) {
+		return false, err
+	}
+	jobID, err := setPauseRequested(ctx, urlID, true)
+	if err != nil {
+		return false, err
+	}
+	if jobID != 0 {
+		logEvent(ctx, logEntry{UrlID: urlID, JobID: jobID, Event: EventPaused,
+			Message: "Pause requested by the owner; the crawl stops at its next check"})
+	}
+	return true, nil
+}
+
+// ResumeCrawl releases a paused job. It keeps its place in the queue: workers still take jobs by
+// priority, then oldest first. A running crawl asked to pause that has not stopped yet carries on.
+func ResumeCrawl(ctx context.Context, urlID int) error {
+	err := setWaitingStatus(ctx, urlID, "paused", "pending", EventResumed, "Resumed by the owner")
+	if !errors.Is(err, ErrNotPaused) {
+		return err
+	}
+	jobID, err := setPauseRequested(ctx, urlID, false)
+	if errors.Is(err, ErrNothingQueued) || (err == nil && jobID == 0) {
+		return ErrNotPaused
+	} else if err != nil {
+		return err
+	}
+	logEvent(ctx, logEntry{UrlID: urlID, JobID: jobID, Event: EventResumed, Message: "Pause withdrawn by the owner"})
+	return nil
+}
+
+// setPauseRequested asks the URL's running crawl to pause, or withdraws that request, returning the
+// job's ID, or 0 when the request already was in that state. It returns ErrNothingQueued when no
+// crawl runs.
+func setPauseRequested(ctx context.Context, urlID int, requested bool) (int, error) {
+	var jobID int
+	var current bool
+	err := config.DBFor(ctx).QueryRow(
+		"SELECT id, pause_requested FROM crawl_jobs WHERE url_id = ? AND status = 'leased' ORDER BY id LIMIT 1", urlID,
+	).Scan(&jobID, &current)
+	if err == sql.ErrNoRows {
+		return 0, ErrNothingQueued
+	} else if err != nil {
+		return 0, fmt.Errorf("failed to load crawl job: %w", err)
+	}
+	if current == requested {
+		return 0, nil
+	}
+
+	result, err := config.DBFor(ctx).Exec(
+		"UPDATE crawl_jobs SET pause_requested = ?, updated_at = ? WHERE id = ? AND status = 'leased'",
+		requested, time.Now(), jobID,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("failed to update crawl job: %w", err)
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		// The crawl finished or paused in the meantime
+		return 0, ErrNothingQueued
+	}
+	return jobID, nil
+}
+
+// setWaitingStatus moves the URL's waiting job from one status to another and logs event
+func setWaitingStatus(ctx context.Context, urlID int, from, to, event, message string) error {
+	var jobID int
+	err := config.DBFor(ctx).QueryRow(
+		"SELECT id FROM crawl_jobs WHERE url_id = ? AND status = ? ORDER BY id LIMIT 1", urlID, from,
+	).Scan(&jobID)
+	if err == sql.ErrNoRows {
+		if from == "paused" {
+			return ErrNotPaused
+		}
+		return ErrNothingQueued
+	} else if err != nil {
+		return fmt.Errorf("failed to load crawl job: %w", err)
+	}
+
+	result, err := config.DBFor(ctx).Exec(
+		"UPDATE crawl_jobs SET status = ?, updated_at = ? WHERE id = ? AND status = ?",
+		to, time.Now(), jobID, from,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update crawl job: %w", err)
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		// Another request or a worker got to the job first
+		if from == "paused" {
+			return ErrNotPaused
+		}
+		return ErrNothingQueued
+	}
+
+	logEvent(ctx, logEntry{UrlID: urlID, JobID: jobID, Event: event, Message: message})
+	return nil
+}
+
+// leaseJob claims the highest priority, oldest available job for the worker, returning nil when the queue is empty.
+// Jobs whose lease expired (their worker died) become available again until maxAttempts is reached.
+// Jobs of users who already have their limit of crawls running are skipped; the limit is
+// users.max_concurrent_crawls, falling back to perUserLimit (0 = unlimited).
+func leaseJob(ctx context.Context, workerID string, leaseDuration time.Duration, maxAttempts, perUserLimit int) (*Job, error) {
+	tx, err := config.DBFor(ctx).Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin lease transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	var job Job
+	// Locking the owner row makes other workers skip that user's jobs until this lease commits,
+	// so concurrent workers cannot together overshoot the per-user limit
+	err = tx.QueryRow(`
+		SELECT j.id, j.url_id, u.url, j.attempts, j.force_fresh
+		FROM crawl_jobs j
+		JOIN urls u ON u.id = j.url_id
+		JOIN users owner ON owner.id = u.user_id
+		WHERE (j.status = 'pending' OR (j.status = 'leased' AND j.lease_expires_at < ?))
+		  AND j.attempts < ?
+		  AND (
+			COALESCE(owner.max_concurrent_crawls, ?) = 0
+			OR (
+				SELECT COUNT(*) FROM crawl_jobs running
+				JOIN urls ru ON ru.id = running.url_id
+				WHERE ru.user_id = owner.id AND running.status = 'leased' AND running.lease_expires_at >= ?
+			) < COALESCE(owner.max_concurrent_crawls, ?)
+		  )
+		ORDER BY j.priority DESC, j.id
+		LIMIT 1
+		FOR UPDATE OF j, owner SKIP LOCKED
+	`, now, maxAttempts, perUserLimit, now, perUserLimit).Scan(&job.ID, &job.UrlID, &job.Url, &job.Attempts, &job.ForceFresh)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to select crawl job: %w", err)
+	}
+
+	_, err = tx.Exec(`
+		UPDATE crawl_jobs
+		SET status = 'leased', worker_id = ?, lease_expires_at = ?, attempts = attempts + 1, updated_at = ?
+		WHERE id = ?
+	`, workerID, now.Add(leaseDuration), now, job.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to lease crawl job: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit lease: %w", err)
+	}
+
+	job.Attempts++
+	job.WorkerID = workerID
+	return &job, nil
+}
+
+// errLeaseLost is returned by lockLease when the job's lease passed to another worker, e.g. because
+// this one missed extending it
+var errLeaseLost = errors.New("the crawl job's lease was lost to another worker")
+
+// lockLease locks the job's row for the rest of tx while job.WorkerID still holds its lease, so the
+// lease cannot expire and pass to another worker before tx commits
+func lockLease(tx *sql.Tx, job *Job) error {
+	var id int
+	err := tx.QueryRow(`
+		SELECT id FROM crawl_jobs
+		WHERE id = ? AND worker_id = ? AND status = 'leased'
+		FOR UPDATE
+	`, job.ID, job.WorkerID).Scan(&id)
+	if err == sql.ErrNoRows {
+		return errLeaseLost
+	}
+	if err != nil {
+		return fmt.Errorf("failed to lock the crawl job: %w", err)
+	}
+	return nil
+}
+
+// extendLease pushes the lease deadline forward while the worker is still busy with the job
+func extendLease(ctx context.Context, jobID int, workerID string, leaseDuration time.Duration) error {
+	now := time.Now()
+	_, err := config.DBFor(ctx).Exec(`
+		UPDATE crawl_jobs SET lease_expires_at = ?, updated_at = ?
+		WHERE id = ? AND worker_id = ? AND status = 'leased'
+	`, now.Add(leaseDuration), now, jobID, workerID)
+	return err
+}
+
+// finishJob marks a job leased by the worker as done or failed. It reports false when the worker
+// no longer held the lease: the lease expired and another worker took the job over, or the job
+// was failed as abandoned, and the outcome belongs to that other attempt.
+func finishJob(ctx context.Context, jobID int, workerID, status string) (bool, error) {
+	result, err := config.DBFor(ctx).Exec(`
+		UPDATE crawl_jobs SET status = ?, lease_expires_at = NULL, updated_at = ?
+		WHERE id = ? AND worker_id = ? AND status = 'leased'
+	`, status, time.Now(), jobID, workerID)
+	if err != nil {
+		return false, err
+	}
+	if affected, err := result.RowsAffected(); err != nil || affected == 0 {
+		return false, err
+	}
+	// The links checked before the crawl was paused are no longer needed
+	_, err = config.DBFor(ctx).Exec("DELETE FROM crawl_checkpoints WHERE job_id = ?", jobID)
+	return true, err
+}
+
+// pauseJob puts a leased job whose crawl stopped for a pause back in the queue as paused. The
+// attempt does not count towards worker.max_attempts.
+func pauseJob(ctx context.Context, jobID int, workerID string) error {
+	_, err := config.DBFor(ctx).Exec(`
+		UPDATE crawl_jobs
+		SET status = 'paused', pause_requested = FALSE, worker_id = NULL, lease_expires_at = NULL,
+			attempts = GREATEST(attempts - 1, 0), updated_at = ?
+		WHERE id = ? AND worker_id = ? AND status = 'leased'
+	`, time.Now(), jobID, workerID)
+	return err
+}
+
+// pauseRequested reports whether the owner asked the job's running crawl to pause
+func pauseRequested(ctx context.Context, jobID int) (bool, error) {
+	var requested bool
+	err := config.DBFor(ctx).QueryRow("SELECT pause_requested FROM crawl_jobs WHERE id = ?", jobID).Scan(&requested)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	return requested, err
+}
+
+// failAbandonedJobs gives up on jobs whose lease expired after the last allowed attempt
+func failAbandonedJobs(ctx context.Context, maxAttempts int) error {
+	now := time.Now()
+	tx, err := config.DBFor(ctx).Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`
+		INSERT INTO crawl_logs (url_id, job_id, level, event, message, created_at)
+		SELECT url_id, id, 'error', ?, CONCAT('Worker ', COALESCE(worker_id, 'unknown'), ' stopped responding after attempt ', attempts), ?
+		FROM crawl_jobs
+		WHERE status = 'leased' AND lease_expires_at < ? AND attempts >= ?
+	`, EventAbandoned, now, now, maxAttempts)
+	if err != nil {
+		return err
+	}
+
+	rows, err := tx.Query(`
+		SELECT url_id FROM crawl_jobs
+		WHERE status = 'leased' AND lease_expires_at < ? AND attempts >= ?
+	`, now, maxAttempts)
+	if err != nil {
+		return err
+	}
+	var urlIDs []int
+	for rows.Next() {
+		var urlID int
+		if err := rows.Scan(&urlID); err != nil {
+			rows.Close()
+			return err
+		}
+		urlIDs = append(urlIDs, urlID)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	var changes []urlstatus.Change
+	for _, urlID := range urlIDs {
+		change, err := settle(tx, urlID, urlstatus.Error, "error_message = 'Analysis abandoned: worker stopped responding'")
+		if err == urlstatus.ErrNotFound {
+			continue
+		} else if err != nil {
+			return err
+		}
+		changes = append(changes, change)
+	}
+
+	_, err = tx.Exec(`
+		UPDATE crawl_jobs SET status = 'failed', lease_expires_at = NULL, updated_at = ?
+		WHERE status = 'leased' AND lease_expires_at < ? AND attempts >= ?
+	`, now, now, maxAttempts)
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	urlstatus.Publish(ctx, changes...)
+	return nil
+}
+
+// heartbeat records that the worker is alive
+func heartbeat(ctx context.Context, workerID, hostname string, startedAt time.Time, concurrency int) error {
+	now := time.Now()
+	_, err := config.DBFor(ctx).Exec(`
+		INSERT INTO crawl_workers (id, hostname, concurrency, started_at, last_heartbeat_at)
+		VALUES (?, ?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE last_heartbeat_at = VALUES(last_heartbeat_at)
+	`, workerID, hostname, concurrency, startedAt, now)
+	return err
+}
+
+// unregister removes the worker from the registry on clean shutdown
+func unregister(ctx context.Context, workerID string) error {
+	_, err := config.DBFor(ctx).Exec("DELETE FROM crawl_workers WHERE id = ?", workerID)
+	return err
+}
+
+// QueueStatus summarizes the shared crawl queue
+type QueueStatus struct {
+	LiveWorkers int `json:"live_workers"`
+	PendingJobs int `json:"pending_jobs"`
+	LeasedJobs  int `json:"leased_jobs"`
+}
+
+// Status counts workers that sent a heartbeat within the window and jobs waiting or in progress
+func Status(ctx context.Context, heartbeatWindow time.Duration) (QueueStatus, error) {
+	var status QueueStatus
+	err := config.DBFor(ctx).QueryRowContext(ctx,
+		"SELECT COUNT(*) FROM crawl_workers WHERE last_heartbeat_at >= ?",
+		time.Now().Add(-heartbeatWindow),
+	).Scan(&status.LiveWorkers)
+	if err != nil {
+		return status, fmt.Errorf("failed to count workers: %w", err)
+	}
+
+	err = config.DBFor(ctx).QueryRowContext(ctx, `
+		SELECT COALESCE(SUM(status = 'pending'), 0), COALESCE(SUM(status = 'leased'), 0)
+		FROM crawl_jobs WHERE status IN ('pending', 'leased')
+	`).Scan(&status.PendingJobs, &status.LeasedJobs)
+	if err != nil {
+		return status, fmt.Errorf("failed to count jobs: %w", err)
+	}
+
+	return status, nil
+}
@@ -0,0 +1,172 @@
+package worker
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"sykell-analyze/backend/analyzer"
+	"sykell-analyze/backend/archive"
+	"sykell-analyze/backend/config"
+	"sykell-analyze/backend/urlstatus"
+)
+
+// analysisColumns are the urls columns written by a crawl. They are copied as-is when another
+// user's recent result is reused, so crawled_at keeps pointing at the real fetch time.
+var analysisColumns = []string{
+	"html_version", "title", "h1_count", "h2_count", "h3_count",
+	"internal_links", "external_links", "broken_links", "has_login_form", "forms",
+	"internal_nofollow_links", "external_nofollow_links", "sponsored_links", "ugc_links",
+	"is_noindex", "is_nofollow", "hreflang", "link_hygiene", "contact_links", "social_profiles", "fragments", "safety", "privacy", "consent", "has_consent_banner", "performance", "dns", "hosting",
+	"registration", "domain_expires_at", "web_vitals", "broken_assets", "documents", "internal_pages",
+	"result_schema_version", "crawled_at",
+	"progress_stage", "progress_links_discovered", "progress_links_to_check", "progress_links_checked",
+	"progress_percent", "progress_updated_at",
+}
+
+// sharedCacheWindow is how recent another user's crawl must be to be reused (crawler.shared_cache_window, 0 disables)
+func sharedCacheWindow() time.Duration {
+	return config.App.Crawler.SharedCacheWindow
+}
+
+// findSharedResult returns the most recent completed crawl of the same URL by another record,
+// stored by the running analyzer version.
+// Results are only shared when neither owner has link exclusions or check rules and the URL has no
+// target keywords, since those change the broken links found and add user-specific results, and
+// when both URLs have the same crawl settings. The demo account's seeded analyses are never shared.
+func findSharedResult(ctx context.Context, urlID int, window time.Duration) (int, bool) {
+	if window <= 0 {
+		return 0, false
+	}
+
+	var sourceID int
+	err := config.DBFor(ctx).QueryRow(`
+		SELECT src.id
+		FROM urls dst
+		JOIN urls src ON src.url = dst.url AND src.id <> dst.id
+		JOIN users owner ON owner.id = src.user_id
+		WHERE dst.id = ?
+		  AND NOT owner.is_demo
+		  AND src.status = 'completed'
+		  AND src.crawled_at >= ?
+		  AND src.result_schema_version = ?
+		  AND src.crawl_timeout_seconds <=> dst.crawl_timeout_seconds
+		  AND src.crawl_user_agent <=> dst.crawl_user_agent
+		  AND src.check_broken_links = dst.check_broken_links
+		  AND NOT EXISTS (
+			SELECT 1 FROM link_exclusions e
+			WHERE e.user_id = src.user_id AND (e.url_id IS NULL OR e.url_id = src.id)
+		  )
+		  AND NOT EXISTS (
+			SELECT 1 FROM link_exclusions e
+			WHERE e.user_id = dst.user_id AND (e.url_id IS NULL OR e.url_id = dst.id)
+		  )
+		  AND NOT EXISTS (
+			SELECT 1 FROM check_rules r
+			WHERE r.user_id IN (src.user_id, dst.user_id) AND (r.url_id IS NULL OR r.url_id IN (src.id, dst.id))
+		  )
+		  AND NOT EXISTS (SELECT 1 FROM url_keywords k WHERE k.url_id IN (src.id, dst.id))
+		ORDER BY src.crawled_at DESC
+		LIMIT 1
+	`, urlID, time.Now().Add(-window), analyzer.SchemaVersion).Scan(&sourceID)
+	if err != nil {
+		if err != sql.ErrNoRows {
+			fmt.Printf("DEBUG: Shared crawl cache lookup failed for URL ID %d: %v\n", urlID, err)
+		}
+		return 0, false
+	}
+	return sourceID, true
+}
+
+// copySharedResult copies another record's analysis, broken links and Lighthouse scores into urlID.
+// The copy is independent: later changes to either record never affect the other.
+func copySharedResult(ctx context.Context, urlID, sourceID int, startedAt time.Time) error {
+	assignments := make([]string, len(analysisColumns))
+	for i, column := range analysisColumns {
+		assignments[i] = fmt.Sprintf("dst.%s = src.%s", column, column)
+	}
+
+	var change urlstatus.Change
+	err := config.WithTransaction(ctx, func(tx *sql.Tx) error {
+		now := time.Now()
+
+		_, err := tx.Exec(`
+			UPDATE urls dst
+			JOIN urls src ON src.id = ?
+			SET `+strings.Join(assignments, ", ")+`, dst.updated_at = ?
+			WHERE dst.id = ?
+		`, sourceID, now, urlID)
+		if err != nil {
+			return fmt.Errorf("failed to copy analysis: %w", err)
+		}
+		if change, err = settle(tx, urlID, urlstatus.Completed, "error_message = NULL"); err != nil {
+			return fmt.Errorf("failed to update URL status: %w", err)
+		}
+
+		if _, err := tx.Exec("DELETE FROM broken_links WHERE url_id = ?", urlID); err != nil {
+			return fmt.Errorf("failed to clear broken links: %w", err)
+		}
+		_, err = tx.Exec(`
+			INSERT INTO broken_links (url_id, link_url, status_code, error_message, suggestions, created_at)
+			SELECT ?, link_url, status_code, error_message, suggestions, ? FROM broken_links WHERE url_id = ?
+		`, urlID, now, sourceID)
+		if err != nil {
+			return fmt.Errorf("failed to copy broken links: %w", err)
+		}
+
+		// Results are only shared without check rules or keywords, so earlier results are outdated
+		if _, err := tx.Exec("DELETE FROM check_results WHERE url_id = ?", urlID); err != nil {
+			return fmt.Errorf("failed to clear check results: %w", err)
+		}
+		if _, err := tx.Exec("DELETE FROM keyword_results WHERE url_id = ?", urlID); err != nil {
+			return fmt.Errorf("failed to clear keyword results: %w", err)
+		}
+
+		// The source's Lighthouse scores describe the same page; without any the URL keeps its own
+		_, err = tx.Exec(`
+			INSERT INTO lighthouse_results (url_id, strategy, performance_score, accessibility_score, best_practices_score,
+				seo_score, metrics, lighthouse_version, fetched_at, error_message, checked_at)
+			SELECT ?, strategy, performance_score, accessibility_score, best_practices_score,
+				seo_score, metrics, lighthouse_version, fetched_at, error_message, checked_at
+			FROM lighthouse_results WHERE url_id = ?
+			ON DUPLICATE KEY UPDATE strategy = VALUES(strategy), performance_score = VALUES(performance_score),
+				accessibility_score = VALUES(accessibility_score), best_practices_score = VALUES(best_practices_score),
+				seo_score = VALUES(seo_score), metrics = VALUES(metrics), lighthouse_version = VALUES(lighthouse_version),
+				fetched_at = VALUES(fetched_at), error_message = VALUES(error_message), checked_at = VALUES(checked_at)
+		`, urlID, sourceID)
+		if err != nil {
+			return fmt.Errorf("failed to copy lighthouse scores: %w", err)
+		}
+
+		var brokenLinks int
+		if err := tx.QueryRow("SELECT broken_links FROM urls WHERE id = ?", urlID).Scan(&brokenLinks); err != nil {
+			return err
+		}
+		runID, err := recordCrawlRun(tx, urlID, startedAt, "completed", brokenLinks, "")
+		if err != nil {
+			return err
+		}
+
+		// The source's latest text snapshot is the content of the reused crawl
+		var content string
+		var archiveKey sql.NullString
+		err = tx.QueryRow(
+			"SELECT content, archive_key FROM content_snapshots WHERE url_id = ? ORDER BY id DESC LIMIT 1", sourceID,
+		).Scan(&content, &archiveKey)
+		if err == sql.ErrNoRows {
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("failed to load content snapshot: %w", err)
+		}
+		if content, err = archive.Content(ctx, content, archiveKey); err != nil {
+			return err
+		}
+		return saveSnapshot(ctx, tx, urlID, runID, content, now)
+	})
+	if err == nil {
+		urlstatus.Publish(ctx, change)
+	}
+	return err
+}
@@ -0,0 +1,97 @@
+package worker
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"sykell-analyze/backend/archive"
+	"sykell-analyze/backend/textdiff"
+)
+
+// snapshotsKept is how many text snapshots are kept per URL; older ones are deleted
+const snapshotsKept = 30
+
+// saveSnapshot stores the text of a crawl with how much it changed since the URL's previous snapshot
+func saveSnapshot(ctx context.Context, tx *sql.Tx, urlID int, runID int64, content string, now time.Time) error {
+	sum := sha256.Sum256([]byte(content))
+	hash := hex.EncodeToString(sum[:])
+
+	// The first snapshot of a URL has nothing to compare with
+	var changePercent *float64
+	var added, removed int
+	var previous, previousHash string
+	var previousKey sql.NullString
+	err := tx.QueryRow(
+		"SELECT content, content_hash, archive_key FROM content_snapshots WHERE url_id = ? ORDER BY id DESC LIMIT 1", urlID,
+	).Scan(&previous, &previousHash, &previousKey)
+	switch {
+	case err == sql.ErrNoRows:
+	case err != nil:
+		return fmt.Errorf("failed to load previous snapshot: %w", err)
+	case previousHash == hash:
+		changePercent = new(float64)
+	default:
+		// The newest snapshot is not archived, unless nothing was crawled since archival began
+		if previous, err = archive.Content(ctx, previous, previousKey); err != nil {
+			return err
+		}
+		diff := textdiff.Compare(previous, content)
+		changePercent = &diff.ChangePercent
+		added, removed = len(diff.Added), len(diff.Removed)
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO content_snapshots (url_id, run_id, content, content_hash, change_percent, added_lines, removed_lines, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`, urlID, runID, content, hash, changePercent, added, removed, now)
+	if err != nil {
+		return fmt.Errorf("failed to store content snapshot: %w", err)
+	}
+
+	// Pruned snapshots may have been archived; their objects go with them
+	prunedKeys, err := archivedBeyondKept(tx, urlID)
+	if err != nil {
+		return fmt.Errorf("failed to load pruned content snapshots: %w", err)
+	}
+	_, err = tx.Exec(`
+		DELETE FROM content_snapshots
+		WHERE url_id = ? AND id < (
+			SELECT id FROM (
+				SELECT id FROM content_snapshots WHERE url_id = ? ORDER BY id DESC LIMIT 1 OFFSET ?
+			) oldest_kept
+		)
+	`, urlID, urlID, snapshotsKept-1)
+	if err != nil {
+		return fmt.Errorf("failed to prune content snapshots: %w", err)
+	}
+	archive.Forget(ctx, prunedKeys)
+	return nil
+}
+
+// archivedBeyondKept returns the archive keys of the URL's snapshots older than the ones kept
+func archivedBeyondKept(tx *sql.Tx, urlID int) ([]string, error) {
+	rows, err := tx.Query(`
+		SELECT archive_key FROM content_snapshots
+		WHERE url_id = ? AND archive_key IS NOT NULL AND id < (
+			SELECT id FROM content_snapshots WHERE url_id = ? ORDER BY id DESC LIMIT 1 OFFSET ?
+		)
+	`, urlID, urlID, snapshotsKept-1)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var keys []string
+	for rows.Next() {
+		var key string
+		if err := rows.Scan(&key); err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return keys, rows.Err()
+}
@@ -0,0 +1,19 @@
+package worker
+
+import (
+	"database/sql"
+	"errors"
+
+	"sykell-analyze/backend/urlstatus"
+)
+
+// settle moves a URL whose crawl ended to its final status. A URL re-queued while the crawl ran
+// stays queued for its next crawl: the results are kept and the status is left alone.
+func settle(tx *sql.Tx, urlID int, to string, set string, args ...interface{}) (urlstatus.Change, error) {
+	change, err := urlstatus.Transition(tx, urlstatus.Request{UrlID: urlID, To: to, Set: set, Args: args})
+	var invalid *urlstatus.InvalidTransitionError
+	if errors.As(err, &invalid) && invalid.From == urlstatus.Queued {
+		return urlstatus.Change{UrlID: urlID, From: invalid.From, To: invalid.From}, nil
+	}
+	return change, err
+}
@@ -0,0 +1,301 @@
+package worker
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"sykell-analyze/backend/config"
+	"sykell-analyze/backend/urlstatus"
+)
+
+// Config controls how a worker pulls and processes crawl jobs (the worker section of config.yaml)
+type Config = config.WorkerConfig
+
+// DefaultConfig returns the worker settings used when nothing overrides them
+func DefaultConfig() Config {
+	return config.Default().Worker
+}
+
+// Worker leases crawl jobs from the shared queue and processes them. With tenancy, one worker serves
+// the queue of every tenant added with AddTenant, so its concurrency is shared between all of them
+// rather than multiplied by the number of tenants.
+type Worker struct {
+	ID       string
+	Hostname string
+	Config   Config
+
+	// concurrency and maxCrawlsPerUser start from Config and change with Reconfigure
+	concurrency      atomic.Int64
+	maxCrawlsPerUser atomic.Int64
+	// running counts the poll loops; loops beyond concurrency stop after their current job
+	running atomic.Int64
+	// resized wakes Run to start poll loops after concurrency grew
+	resized chan struct{}
+
+	// tenants are the databases served besides the one of the Run context
+	tenantsMu sync.Mutex
+	tenants   []*config.Tenant
+	// nextQueue rotates the database each lease starts from
+	nextQueue atomic.Uint64
+}
+
+// New creates a worker with an ID unique to this process
+func New(cfg Config) *Worker {
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "unknown"
+	}
+	w := &Worker{
+		ID:       fmt.Sprintf("%s-%d-%d", hostname, os.Getpid(), time.Now().UnixNano()),
+		Hostname: hostname,
+		Config:   cfg,
+		resized:  make(chan struct{}, 1),
+	}
+	w.concurrency.Store(int64(cfg.Concurrency))
+	w.maxCrawlsPerUser.Store(int64(cfg.MaxCrawlsPerUser))
+	return w
+}
+
+// Reconfigure applies reloaded worker settings: the number of parallel crawls and the per-user
+// crawl limit. Shrinking lets in-flight crawls finish before their poll loops stop.
+func (w *Worker) Reconfigure(cfg Config) {
+	w.maxCrawlsPerUser.Store(int64(cfg.MaxCrawlsPerUser))
+	if previous := w.concurrency.Swap(int64(cfg.Concurrency)); previous != int64(cfg.Concurrency) {
+		fmt.Printf("👷 Crawl worker %s concurrency changed from %d to %d\n", w.ID, previous, cfg.Concurrency)
+	}
+	select {
+	case w.resized <- struct{}{}:
+	default:
+	}
+}
+
+// AddTenant makes the worker lease jobs from the tenant's database as well, including while it runs
+func (w *Worker) AddTenant(t *config.Tenant) {
+	w.tenantsMu.Lock()
+	defer w.tenantsMu.Unlock()
+	w.tenants = append(w.tenants, t)
+}
+
+// queues returns ctx for the database of the Run context followed by a context for every tenant
+func (w *Worker) queues(ctx context.Context) []context.Context {
+	w.tenantsMu.Lock()
+	defer w.tenantsMu.Unlock()
+	queues := make([]context.Context, 0, len(w.tenants)+1)
+	queues = append(queues, ctx)
+	for _, t := range w.tenants {
+		queues = append(queues, config.WithTenant(ctx, t))
+	}
+	return queues
+}
+
+// Run processes jobs until ctx is cancelled, then waits for in-flight crawls to finish
+func (w *Worker) Run(ctx context.Context) {
+	startedAt := time.Now()
+	fmt.Printf("👷 Crawl worker %s started (concurrency %d)\n", w.ID, w.Config.Concurrency)
+
+	var wg sync.WaitGroup
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		w.heartbeatLoop(ctx, startedAt)
+	}()
+
+	for ctx.Err() == nil {
+		for w.running.Load() < w.concurrency.Load() {
+			w.running.Add(1)
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				w.pollLoop(ctx)
+			}()
+		}
+
+		select {
+		case <-ctx.Done():
+		case <-w.resized:
+		}
+	}
+
+	wg.Wait()
+
+	for _, queue := range w.queues(ctx) {
+		if err := unregister(queue, w.ID); err != nil {
+			fmt.Printf("DEBUG: Failed to unregister worker %s%s: %v\n", w.ID, tenantSuffix(queue), err)
+		}
+	}
+	fmt.Printf("👷 Crawl worker %s stopped\n", w.ID)
+}
+
+// heartbeatLoop keeps the worker registration fresh and fails jobs abandoned by dead workers
+func (w *Worker) heartbeatLoop(ctx context.Context, startedAt time.Time) {
+	ticker := time.NewTicker(w.Config.HeartbeatInterval)
+	defer ticker.Stop()
+
+	for {
+		for _, queue := range w.queues(ctx) {
+			if err := heartbeat(queue, w.ID, w.Hostname, startedAt, int(w.concurrency.Load())); err != nil {
+				fmt.Printf("DEBUG: Worker heartbeat failed%s: %v\n", tenantSuffix(queue), err)
+			}
+			if err := failAbandonedJobs(queue, w.Config.MaxAttempts); err != nil {
+				fmt.Printf("DEBUG: Failed to clean up abandoned jobs%s: %v\n", tenantSuffix(queue), err)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// pollLoop leases and processes one job at a time, sleeping when the queue is empty. It stops
+// between jobs when the worker runs more loops than its concurrency.
+func (w *Worker) pollLoop(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		default:
+		}
+		if w.retire() {
+			return
+		}
+
+		queue, job := w.lease(ctx)
+		if job == nil {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(w.Config.PollInterval):
+			}
+			continue
+		}
+
+		// The crawl keeps the tenant of its queue but not the cancellation of ctx
+		w.process(context.WithoutCancel(queue), job)
+	}
+}
+
+// lease takes the next job from the databases the worker serves, returning the context of the
+// job's database. Every call starts from the next database, so a tenant with a long queue cannot
+// keep the others waiting.
+func (w *Worker) lease(ctx context.Context) (context.Context, *Job) {
+	queues := w.queues(ctx)
+	start := int(w.nextQueue.Add(1) % uint64(len(queues)))
+	for i := range queues {
+		queue := queues[(start+i)%len(queues)]
+		job, err := leaseJob(queue, w.ID, w.Config.LeaseDuration, w.Config.MaxAttempts, int(w.maxCrawlsPerUser.Load()))
+		if err != nil {
+			fmt.Printf("DEBUG: Failed to lease crawl job%s: %v\n", tenantSuffix(queue), err)
+			continue
+		}
+		if job != nil {
+			return queue, job
+		}
+	}
+	return nil, nil
+}
+
+// tenantSuffix names the tenant of ctx for log messages
+func tenantSuffix(ctx context.Context) string {
+	if name := config.TenantName(ctx); name != "" {
+		return " of tenant " + name
+	}
+	return ""
+}
+
+// retire reports whether the calling poll loop should stop because concurrency was lowered, and
+// then takes it off the running count
+func (w *Worker) retire() bool {
+	for {
+		running := w.running.Load()
+		if running <= w.concurrency.Load() {
+			return false
+		}
+		if w.running.CompareAndSwap(running, running-1) {
+			return true
+		}
+	}
+}
+
+// process crawls a leased job, extending the lease until the crawl finishes
+func (w *Worker) process(ctx context.Context, job *Job) {
+	done := make(chan struct{})
+	defer close(done)
+
+	go func() {
+		ticker := time.NewTicker(w.Config.LeaseDuration / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				if err := extendLease(ctx, job.ID, w.ID, w.Config.LeaseDuration); err != nil {
+					fmt.Printf("DEBUG: Failed to extend lease for job %d: %v\n", job.ID, err)
+				}
+			}
+		}
+	}()
+
+	logEvent(ctx, logEntry{
+		UrlID:   job.UrlID,
+		JobID:   job.ID,
+		Event:   EventStarted,
+		Message: fmt.Sprintf("Attempt %d started on worker %s", job.Attempts, w.ID),
+	})
+
+	status := "done"
+	paused := false
+	startedAt := time.Now()
+	func() {
+		defer func() {
+			if r := recover(); r != nil {
+				fmt.Printf("PANIC in crawlAndUpdateURL: %v\n", r)
+				status = "failed"
+				logEvent(ctx, logEntry{
+					UrlID:    job.UrlID,
+					JobID:    job.ID,
+					Level:    "error",
+					Event:    EventFailed,
+					Message:  fmt.Sprintf("Panic during analysis: %v", r),
+					Duration: time.Since(startedAt),
+				})
+				// Update status to error on panic
+				var change urlstatus.Change
+				err := config.WithTransaction(ctx, func(tx *sql.Tx) error {
+					var err error
+					change, err = settle(tx, job.UrlID, urlstatus.Error, "error_message = ?", fmt.Sprintf("Panic during analysis: %v", r))
+					return err
+				})
+				if err != nil {
+					fmt.Printf("DEBUG: Failed to save panic of URL ID %d: %v\n", job.UrlID, err)
+				} else {
+					urlstatus.Publish(ctx, change)
+				}
+			}
+		}()
+		fmt.Printf("DEBUG: Worker %s starting crawl for URL ID %d (attempt %d): %s\n", w.ID, job.UrlID, job.Attempts, job.Url)
+		paused = crawlAndUpdateURL(ctx, job)
+	}()
+
+	if paused {
+		if err := pauseJob(ctx, job.ID, w.ID); err != nil {
+			fmt.Printf("DEBUG: Failed to pause job %d: %v\n", job.ID, err)
+		}
+		return
+	}
+	finished, err := finishJob(ctx, job.ID, w.ID, status)
+	if err != nil {
+		fmt.Printf("DEBUG: Failed to finish job %d: %v\n", job.ID, err)
+	} else if !finished {
+		fmt.Printf("DEBUG: Worker %s lost the lease of job %d before it finished; not marking it %s\n", w.ID, job.ID, status)
+	}
+}
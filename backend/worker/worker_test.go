@@ -0,0 +1,342 @@
+package worker
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"sykell-analyze/backend/analyzer"
+	"sykell-analyze/backend/config"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// withCrawlerConfig swaps in modified crawler settings for the duration of the test
+func withCrawlerConfig(t *testing.T, modify func(*config.CrawlerConfig)) {
+	previous := config.App
+	cfg := *config.App
+	modify(&cfg.Crawler)
+	config.App = &cfg
+	t.Cleanup(func() { config.App = previous })
+}
+
+func TestCrawlOptions(t *testing.T) {
+	withCrawlerConfig(t, func(c *config.CrawlerConfig) {
+		c.LinkCheckTimeout = 5 * time.Second
+		c.MaxConcurrentLinkChecks = 3
+		c.UserAgent = "sykell-bot/1.0"
+	})
+
+	opts := crawlOptions(nil)
+	assert.Equal(t, 5*time.Second, opts.LinkCheckTimeout)
+	assert.Equal(t, 3, opts.MaxConcurrentLinkChecks)
+	assert.Equal(t, "sykell-bot/1.0", opts.UserAgent)
+	assert.Nil(t, opts.Exclusions)
+	assert.NotNil(t, opts.LinkCache)
+
+	withCrawlerConfig(t, func(c *config.CrawlerConfig) { c.LinkCacheTTL = 0 })
+	assert.Nil(t, crawlOptions(nil).LinkCache)
+}
+
+func TestCrawlSettingsApply(t *testing.T) {
+	t.Run("server settings by default", func(t *testing.T) {
+		opts := crawlOptions(nil)
+		crawlSettings{checkBrokenLinks: true}.apply(&opts)
+		assert.Equal(t, crawlOptions(nil).PageTimeout, opts.PageTimeout)
+		assert.False(t, opts.SkipLinkChecks)
+	})
+
+	t.Run("URL settings only shorten timeouts", func(t *testing.T) {
+		withCrawlerConfig(t, func(c *config.CrawlerConfig) {
+			c.PageTimeout = 90 * time.Second
+			c.RequestTimeout = 60 * time.Second
+			c.LinkCheckTimeout = 5 * time.Second
+		})
+		opts := crawlOptions(nil)
+		crawlSettings{
+			timeoutSeconds: sql.NullInt64{Int64: 30, Valid: true},
+			userAgent:      sql.NullString{String: "acme-monitor/2.0", Valid: true},
+		}.apply(&opts)
+		assert.Equal(t, 30*time.Second, opts.PageTimeout)
+		assert.Equal(t, 30*time.Second, opts.RequestTimeout)
+		assert.Equal(t, 5*time.Second, opts.LinkCheckTimeout)
+		assert.Equal(t, "acme-monitor/2.0", opts.UserAgent)
+		assert.True(t, opts.SkipLinkChecks)
+	})
+}
+
+func TestNewWorkerIDsAreUnique(t *testing.T) {
+	a := New(DefaultConfig())
+	b := New(DefaultConfig())
+
+	assert.NotEmpty(t, a.ID)
+	assert.NotEqual(t, a.ID, b.ID)
+	assert.Contains(t, a.ID, a.Hostname)
+}
+
+func TestSharedCacheWindow(t *testing.T) {
+	t.Run("default", func(t *testing.T) {
+		assert.Equal(t, time.Hour, sharedCacheWindow())
+	})
+
+	t.Run("override", func(t *testing.T) {
+		withCrawlerConfig(t, func(c *config.CrawlerConfig) { c.SharedCacheWindow = 15 * time.Minute })
+		assert.Equal(t, 15*time.Minute, sharedCacheWindow())
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		withCrawlerConfig(t, func(c *config.CrawlerConfig) { c.SharedCacheWindow = 0 })
+		assert.Equal(t, time.Duration(0), sharedCacheWindow())
+
+		_, ok := findSharedResult(context.Background(), 1, sharedCacheWindow())
+		assert.False(t, ok)
+	})
+}
+
+// recordingExecer captures statements instead of running them
+type recordingExecer struct {
+	queries []string
+	args    [][]interface{}
+}
+
+func (r *recordingExecer) Exec(query string, args ...interface{}) (sql.Result, error) {
+	r.queries = append(r.queries, query)
+	r.args = append(r.args, args)
+	return driver.RowsAffected(1), nil
+}
+
+func TestWriteLog(t *testing.T) {
+	t.Run("optional fields become NULL", func(t *testing.T) {
+		db := &recordingExecer{}
+		writeLog(db, logEntry{UrlID: 7, Event: EventStarted, Message: "Attempt 1 started"})
+
+		assert.Len(t, db.queries, 1)
+		args := db.args[0]
+		assert.Equal(t, 7, args[0])
+		assert.Nil(t, args[1].(*int))
+		assert.Equal(t, "info", args[2])
+		assert.Equal(t, EventStarted, args[3])
+		assert.Nil(t, args[5].(*int64))
+		assert.Nil(t, args[6].(*string))
+	})
+
+	t.Run("job, duration and details are stored", func(t *testing.T) {
+		db := &recordingExecer{}
+		writeLog(db, logEntry{
+			UrlID:    7,
+			JobID:    3,
+			Level:    "error",
+			Event:    EventFailed,
+			Message:  strings.Repeat("x", 2000),
+			Duration: 1500 * time.Millisecond,
+			Details:  map[string]interface{}{"broken_links": 2},
+		})
+
+		args := db.args[0]
+		assert.Equal(t, 3, *args[1].(*int))
+		assert.Equal(t, "error", args[2])
+		assert.Len(t, args[4], maxLogMessageLength)
+		assert.Equal(t, int64(1500), *args[5].(*int64))
+		assert.JSONEq(t, `{"broken_links": 2}`, *args[6].(*string))
+	})
+}
+
+func TestParsePriority(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected Priority
+	}{
+		{input: "", expected: PriorityNormal},
+		{input: "normal", expected: PriorityNormal},
+		{input: "HIGH", expected: PriorityHigh},
+		{input: " low ", expected: PriorityLow},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.input, func(t *testing.T) {
+			priority, err := ParsePriority(tc.input)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, priority)
+		})
+	}
+
+	t.Run("unknown", func(t *testing.T) {
+		_, err := ParsePriority("urgent")
+		assert.ErrorIs(t, err, ErrInvalidPriority)
+	})
+
+	t.Run("round trip", func(t *testing.T) {
+		for _, p := range []Priority{PriorityLow, PriorityNormal, PriorityHigh} {
+			parsed, err := ParsePriority(p.String())
+			assert.NoError(t, err)
+			assert.Equal(t, p, parsed)
+		}
+	})
+}
+
+func TestReconfigure(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Concurrency = 2
+	w := New(cfg)
+	w.running.Store(2)
+
+	t.Run("no loop retires at the configured concurrency", func(t *testing.T) {
+		assert.False(t, w.retire())
+	})
+
+	t.Run("lowering concurrency retires the extra loops", func(t *testing.T) {
+		cfg.Concurrency = 1
+		cfg.MaxCrawlsPerUser = 4
+		w.Reconfigure(cfg)
+
+		assert.True(t, w.retire())
+		assert.False(t, w.retire())
+		assert.Equal(t, int64(1), w.running.Load())
+		assert.Equal(t, int64(4), w.maxCrawlsPerUser.Load())
+	})
+
+	t.Run("raising concurrency wakes Run", func(t *testing.T) {
+		cfg.Concurrency = 3
+		w.Reconfigure(cfg)
+
+		assert.Len(t, w.resized, 1)
+		assert.False(t, w.retire())
+	})
+}
+
+func TestAddTenant(t *testing.T) {
+	w := New(DefaultConfig())
+	ctx := context.Background()
+	assert.Equal(t, []context.Context{ctx}, w.queues(ctx))
+
+	acme := &config.Tenant{Name: "acme"}
+	globex := &config.Tenant{Name: "globex"}
+	w.AddTenant(acme)
+	w.AddTenant(globex)
+
+	queues := w.queues(ctx)
+	if assert.Len(t, queues, 3) {
+		assert.Nil(t, config.TenantFrom(queues[0]))
+		assert.Equal(t, acme, config.TenantFrom(queues[1]))
+		assert.Equal(t, globex, config.TenantFrom(queues[2]))
+	}
+	assert.Equal(t, " of tenant acme", tenantSuffix(queues[1]))
+	assert.Empty(t, tenantSuffix(queues[0]))
+}
+
+// leaseConnector is a database whose UPDATEs affect affected rows and whose queries return that many
+// rows, recording the statements run
+type leaseConnector struct {
+	affected int64
+	queries  *[]string
+}
+
+func (c leaseConnector) Connect(context.Context) (driver.Conn, error) { return leaseConn(c), nil }
+func (c leaseConnector) Driver() driver.Driver                        { return nil }
+
+type leaseConn leaseConnector
+
+func (c leaseConn) Prepare(query string) (driver.Stmt, error) {
+	return nil, errors.New("not supported")
+}
+func (c leaseConn) Close() error              { return nil }
+func (c leaseConn) Begin() (driver.Tx, error) { return c, nil }
+func (c leaseConn) Commit() error             { return nil }
+func (c leaseConn) Rollback() error           { return nil }
+
+func (c leaseConn) ExecContext(_ context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	*c.queries = append(*c.queries, query)
+	if strings.Contains(query, "UPDATE") {
+		return driver.RowsAffected(c.affected), nil
+	}
+	return driver.RowsAffected(0), nil
+}
+
+func (c leaseConn) QueryContext(_ context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	*c.queries = append(*c.queries, query)
+	return &leaseRows{left: c.affected}, nil
+}
+
+// leaseRows returns left rows of a single id column
+type leaseRows struct {
+	left int64
+}
+
+func (r *leaseRows) Columns() []string { return []string{"id"} }
+func (r *leaseRows) Close() error      { return nil }
+
+func (r *leaseRows) Next(dest []driver.Value) error {
+	if r.left == 0 {
+		return io.EOF
+	}
+	r.left--
+	dest[0] = int64(3)
+	return nil
+}
+
+func TestFinishJob(t *testing.T) {
+	useDB := func(t *testing.T, affected int64) *[]string {
+		var queries []string
+		previous := config.DB
+		config.DB = sql.OpenDB(leaseConnector{affected: affected, queries: &queries})
+		t.Cleanup(func() {
+			config.DB.Close()
+			config.DB = previous
+		})
+		return &queries
+	}
+
+	t.Run("the lease holder finishes the job", func(t *testing.T) {
+		queries := useDB(t, 1)
+		finished, err := finishJob(context.Background(), 3, "worker-a", "done")
+		assert.NoError(t, err)
+		assert.True(t, finished)
+		assert.Contains(t, (*queries)[0], "worker_id = ? AND status = 'leased'")
+		assert.Len(t, *queries, 2)
+	})
+
+	t.Run("a lost lease leaves the job alone", func(t *testing.T) {
+		queries := useDB(t, 0)
+		finished, err := finishJob(context.Background(), 3, "worker-a", "failed")
+		assert.NoError(t, err)
+		assert.False(t, finished)
+		assert.Len(t, *queries, 1)
+	})
+}
+
+func TestSaveCrawlResultChecksTheLease(t *testing.T) {
+	useDB := func(t *testing.T, leased int64) *[]string {
+		var queries []string
+		previous := config.DB
+		config.DB = sql.OpenDB(leaseConnector{affected: leased, queries: &queries})
+		t.Cleanup(func() {
+			config.DB.Close()
+			config.DB = previous
+		})
+		return &queries
+	}
+	job := &Job{ID: 3, UrlID: 7, WorkerID: "worker-a"}
+
+	t.Run("the lease holder locks its job", func(t *testing.T) {
+		queries := useDB(t, 1)
+		err := config.WithTransaction(context.Background(), func(tx *sql.Tx) error {
+			return lockLease(tx, job)
+		})
+		assert.NoError(t, err)
+		if assert.Len(t, *queries, 1) {
+			assert.Contains(t, (*queries)[0], "worker_id = ? AND status = 'leased'")
+			assert.Contains(t, (*queries)[0], "FOR UPDATE")
+		}
+	})
+
+	t.Run("a lost lease saves nothing", func(t *testing.T) {
+		queries := useDB(t, 0)
+		_, err := saveCrawlResult(context.Background(), job, time.Now(), &analyzer.Result{}, nil)
+		assert.ErrorIs(t, err, errLeaseLost)
+		assert.Len(t, *queries, 1)
+	})
+}
@@ -0,0 +1,259 @@
+// Package xlsx streams Excel workbooks. Rows are written straight into the zip archive, so a workbook
+// of any size is produced with constant memory; the catch is that sheets are written one after the other.
+package xlsx
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Limits of the file format
+const (
+	MaxRows         = 1_048_576
+	MaxColumns      = 16_384
+	MaxCellLength   = 32_767
+	MaxSheetNameLen = 31
+)
+
+// styleDate is the cellXfs index of the date and time style in styles.xml
+const styleDate = 1
+
+// excelEpoch is day 0 of Excel's date serial numbers (as corrected for the 1900 leap year bug)
+var excelEpoch = time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)
+
+// Writer streams a workbook to an io.Writer. Call AddSheet before writing rows and Close at the end.
+type Writer struct {
+	zip    *zip.Writer
+	sheet  *bufio.Writer
+	sheets []string
+	rows   int
+	err    error
+}
+
+// NewWriter starts a workbook written to w
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{zip: zip.NewWriter(w)}
+}
+
+// AddSheet finishes the current sheet and starts a new one. Names must be unique, at most 31
+// characters and free of : \ / ? * [ ].
+func (w *Writer) AddSheet(name string) error {
+	if w.err != nil {
+		return w.err
+	}
+	if name == "" || utf8.RuneCountInString(name) > MaxSheetNameLen || strings.ContainsAny(name, `:\/?*[]`) {
+		return fmt.Errorf("invalid sheet name %q", name)
+	}
+	for _, existing := range w.sheets {
+		if strings.EqualFold(existing, name) {
+			return fmt.Errorf("duplicate sheet name %q", name)
+		}
+	}
+	if err := w.endSheet(); err != nil {
+		return w.fail(err)
+	}
+
+	w.sheets = append(w.sheets, name)
+	part, err := w.zip.Create(fmt.Sprintf("xl/worksheets/sheet%d.xml", len(w.sheets)))
+	if err != nil {
+		return w.fail(err)
+	}
+	w.sheet = bufio.NewWriter(part)
+	w.rows = 0
+	_, err = w.sheet.WriteString(xml.Header + `<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+	return w.fail(err)
+}
+
+// WriteRow appends a row to the current sheet. Cells may be strings, integers, floats, bools,
+// time.Time (written as dates) or nil for an empty cell; anything else is written with fmt.Sprint.
+// Excel dates have no time zone, so times are written as the wall clock of their location.
+func (w *Writer) WriteRow(cells ...interface{}) error {
+	if w.err != nil {
+		return w.err
+	}
+	if w.sheet == nil {
+		return errors.New("xlsx: WriteRow called before AddSheet")
+	}
+	if w.rows == MaxRows {
+		return fmt.Errorf("sheet %q is full at %d rows", w.sheets[len(w.sheets)-1], MaxRows)
+	}
+	if len(cells) > MaxColumns {
+		return fmt.Errorf("row has %d cells, more than the %d columns of a sheet", len(cells), MaxColumns)
+	}
+	w.rows++
+
+	b := w.sheet
+	fmt.Fprintf(b, `<row r="%d">`, w.rows)
+	for i, cell := range cells {
+		if cell == nil {
+			continue
+		}
+		ref := ColumnName(i) + strconv.Itoa(w.rows)
+		switch v := cell.(type) {
+		case string:
+			writeString(b, ref, v)
+		case bool:
+			value := "0"
+			if v {
+				value = "1"
+			}
+			fmt.Fprintf(b, `<c r="%s" t="b"><v>%s</v></c>`, ref, value)
+		case int:
+			fmt.Fprintf(b, `<c r="%s"><v>%d</v></c>`, ref, v)
+		case int64:
+			fmt.Fprintf(b, `<c r="%s"><v>%d</v></c>`, ref, v)
+		case float64:
+			fmt.Fprintf(b, `<c r="%s"><v>%s</v></c>`, ref, strconv.FormatFloat(v, 'g', -1, 64))
+		case time.Time:
+			if v.IsZero() {
+				continue
+			}
+			fmt.Fprintf(b, `<c r="%s" s="%d"><v>%s</v></c>`, ref, styleDate, strconv.FormatFloat(dateSerial(v), 'f', -1, 64))
+		default:
+			writeString(b, ref, fmt.Sprint(v))
+		}
+	}
+	_, err := b.WriteString("</row>")
+	return w.fail(err)
+}
+
+// Close finishes the last sheet and writes the workbook parts
+func (w *Writer) Close() error {
+	if w.err != nil {
+		return w.err
+	}
+	if len(w.sheets) == 0 {
+		return errors.New("xlsx: a workbook needs at least one sheet")
+	}
+	if err := w.endSheet(); err != nil {
+		return w.fail(err)
+	}
+
+	parts := []struct{ name, content string }{
+		{"[Content_Types].xml", w.contentTypes()},
+		{"_rels/.rels", rootRels},
+		{"xl/workbook.xml", w.workbook()},
+		{"xl/_rels/workbook.xml.rels", w.workbookRels()},
+		{"xl/styles.xml", styles},
+	}
+	for _, p := range parts {
+		part, err := w.zip.Create(p.name)
+		if err != nil {
+			return w.fail(err)
+		}
+		if _, err := io.WriteString(part, p.content); err != nil {
+			return w.fail(err)
+		}
+	}
+	return w.fail(w.zip.Close())
+}
+
+// fail remembers the first error so later calls return it too
+func (w *Writer) fail(err error) error {
+	if err != nil && w.err == nil {
+		w.err = err
+	}
+	return err
+}
+
+// endSheet closes the sheet being written, if any
+func (w *Writer) endSheet() error {
+	if w.sheet == nil {
+		return nil
+	}
+	if _, err := w.sheet.WriteString("</sheetData></worksheet>"); err != nil {
+		return err
+	}
+	err := w.sheet.Flush()
+	w.sheet = nil
+	return err
+}
+
+// writeString writes an inline string cell, cut to the cell limit. Characters XML cannot hold are
+// replaced by xml.EscapeText.
+func writeString(b *bufio.Writer, ref, s string) {
+	if len(s) > MaxCellLength {
+		s = s[:MaxCellLength]
+		for !utf8.ValidString(s) {
+			s = s[:len(s)-1]
+		}
+	}
+	fmt.Fprintf(b, `<c r="%s" t="inlineStr"><is><t xml:space="preserve">`, ref)
+	xml.EscapeText(b, []byte(s))
+	b.WriteString("</t></is></c>")
+}
+
+// dateSerial converts the wall clock of t to Excel's serial date: days since excelEpoch
+func dateSerial(t time.Time) float64 {
+	wall := time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.UTC)
+	return wall.Sub(excelEpoch).Hours() / 24
+}
+
+// ColumnName returns the letters of the zero-based column i: A, B, ..., Z, AA, AB, ...
+func ColumnName(i int) string {
+	name := ""
+	for i++; i > 0; i = (i - 1) / 26 {
+		name = string(rune('A'+(i-1)%26)) + name
+	}
+	return name
+}
+
+func (w *Writer) contentTypes() string {
+	var b strings.Builder
+	b.WriteString(xml.Header + `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">`)
+	b.WriteString(`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>`)
+	b.WriteString(`<Default Extension="xml" ContentType="application/xml"/>`)
+	b.WriteString(`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>`)
+	b.WriteString(`<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>`)
+	for i := range w.sheets {
+		fmt.Fprintf(&b, `<Override PartName="/xl/worksheets/sheet%d.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>`, i+1)
+	}
+	b.WriteString(`</Types>`)
+	return b.String()
+}
+
+func (w *Writer) workbook() string {
+	var b strings.Builder
+	b.WriteString(xml.Header + `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets>`)
+	for i, name := range w.sheets {
+		b.WriteString(`<sheet name="`)
+		xml.EscapeText(&b, []byte(name))
+		fmt.Fprintf(&b, `" sheetId="%d" r:id="rId%d"/>`, i+1, i+1)
+	}
+	b.WriteString(`</sheets></workbook>`)
+	return b.String()
+}
+
+func (w *Writer) workbookRels() string {
+	var b strings.Builder
+	b.WriteString(xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">`)
+	for i := range w.sheets {
+		fmt.Fprintf(&b, `<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet%d.xml"/>`, i+1, i+1)
+	}
+	fmt.Fprintf(&b, `<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>`, len(w.sheets)+1)
+	b.WriteString(`</Relationships>`)
+	return b.String()
+}
+
+const rootRels = xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+	`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+	`</Relationships>`
+
+// styles holds the default style and, at styleDate, a date and time format
+const styles = xml.Header + `<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` +
+	`<numFmts count="1"><numFmt numFmtId="164" formatCode="yyyy-mm-dd hh:mm:ss"/></numFmts>` +
+	`<fonts count="1"><font><sz val="11"/><name val="Calibri"/></font></fonts>` +
+	`<fills count="2"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill></fills>` +
+	`<borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders>` +
+	`<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>` +
+	`<cellXfs count="2"><xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/>` +
+	`<xf numFmtId="164" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/></cellXfs>` +
+	`</styleSheet>`
@@ -0,0 +1,91 @@
+package xlsx
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// readPart returns one file of a workbook
+func readPart(t *testing.T, workbook []byte, name string) string {
+	r, err := zip.NewReader(bytes.NewReader(workbook), int64(len(workbook)))
+	require.NoError(t, err)
+	f, err := r.Open(name)
+	require.NoError(t, err, name)
+	defer f.Close()
+	content, err := io.ReadAll(f)
+	require.NoError(t, err)
+	return string(content)
+}
+
+func TestWriter(t *testing.T) {
+	t.Run("writes sheets with typed cells", func(t *testing.T) {
+		var buf bytes.Buffer
+		w := NewWriter(&buf)
+		require.NoError(t, w.AddSheet("URLs"))
+		require.NoError(t, w.WriteRow("ID", "URL", "Broken", "Crawled"))
+		require.NoError(t, w.WriteRow(1, "https://example.com/?a=1&b=<2>", true, time.Date(2026, 1, 2, 12, 0, 0, 0, time.UTC)))
+		require.NoError(t, w.WriteRow(int64(2), nil, 1.5, time.Time{}))
+		require.NoError(t, w.AddSheet("Broken links"))
+		require.NoError(t, w.WriteRow("Link"))
+		require.NoError(t, w.Close())
+
+		sheet := readPart(t, buf.Bytes(), "xl/worksheets/sheet1.xml")
+		assert.Contains(t, sheet, `<c r="A1" t="inlineStr"><is><t xml:space="preserve">ID</t></is></c>`)
+		assert.Contains(t, sheet, `<c r="A2"><v>1</v></c>`)
+		assert.Contains(t, sheet, `https://example.com/?a=1&amp;b=&lt;2&gt;`)
+		assert.Contains(t, sheet, `<c r="C2" t="b"><v>1</v></c>`)
+		assert.Contains(t, sheet, `<c r="D2" s="1"><v>46024.5</v></c>`)
+		assert.Contains(t, sheet, `<row r="3"><c r="A3"><v>2</v></c><c r="C3"><v>1.5</v></c></row>`)
+
+		assert.Contains(t, readPart(t, buf.Bytes(), "xl/worksheets/sheet2.xml"), "Link")
+		workbook := readPart(t, buf.Bytes(), "xl/workbook.xml")
+		assert.Contains(t, workbook, `<sheet name="URLs" sheetId="1" r:id="rId1"/>`)
+		assert.Contains(t, workbook, `<sheet name="Broken links" sheetId="2" r:id="rId2"/>`)
+		assert.Contains(t, readPart(t, buf.Bytes(), "[Content_Types].xml"), "/xl/worksheets/sheet2.xml")
+		readPart(t, buf.Bytes(), "xl/styles.xml")
+		readPart(t, buf.Bytes(), "_rels/.rels")
+	})
+
+	t.Run("dates keep the wall clock of their location", func(t *testing.T) {
+		noon := time.Date(2026, 1, 2, 12, 0, 0, 0, time.UTC)
+		berlin := time.FixedZone("CET", 60*60)
+
+		assert.Equal(t, 46024.5, dateSerial(noon))
+		assert.InDelta(t, 46024.5+1.0/24, dateSerial(noon.In(berlin)), 1e-9)
+	})
+
+	t.Run("long strings are cut to the cell limit", func(t *testing.T) {
+		var buf bytes.Buffer
+		w := NewWriter(&buf)
+		require.NoError(t, w.AddSheet("Long"))
+		require.NoError(t, w.WriteRow(strings.Repeat("é", MaxCellLength)))
+		require.NoError(t, w.Close())
+
+		sheet := readPart(t, buf.Bytes(), "xl/worksheets/sheet1.xml")
+		assert.Equal(t, MaxCellLength/2, strings.Count(sheet, "é"))
+	})
+
+	t.Run("invalid use", func(t *testing.T) {
+		w := NewWriter(io.Discard)
+		assert.Error(t, w.WriteRow("no sheet"))
+		assert.Error(t, w.AddSheet("a/b"))
+		assert.Error(t, w.AddSheet(strings.Repeat("x", 32)))
+		require.NoError(t, w.AddSheet("Sheet"))
+		assert.Error(t, w.AddSheet("sheet"))
+
+		assert.Error(t, NewWriter(io.Discard).Close())
+	})
+}
+
+func TestColumnName(t *testing.T) {
+	for i, want := range map[int]string{0: "A", 25: "Z", 26: "AA", 51: "AZ", 52: "BA", 701: "ZZ", 702: "AAA", 16383: "XFD"} {
+		assert.Equal(t, want, ColumnName(i))
+	}
+}
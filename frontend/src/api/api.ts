@@ -1,4 +1,6 @@
-const API_BASE_URL = 'http://localhost:8080/api';
+// Production builds are served next to the API, by nginx or by the Go binary itself
+const API_BASE_URL = process.env.REACT_APP_API_URL ||
+  (process.env.NODE_ENV === 'production' ? '/api' : 'http://localhost:8080/api');
 
 export interface UrlData {
   id: number;
@@ -13,8 +15,9 @@ export interface UrlData {
   external_links: number;
   broken_links: number;
   has_login_form: boolean;
-  status: 'queued' | 'running' | 'completed' | 'error';
+  status: 'queued' | 'running' | 'completed' | 'error' | 'cancelled';
   error_message?: string;
+  version: number;
   created_at: string;
   updated_at: string;
 }
@@ -25,6 +28,7 @@ export interface BrokenLink {
   link_url: string;
   status_code?: number;
   error_message?: string;
+  rate_limited?: boolean;
   created_at: string;
 }
 
@@ -66,6 +70,8 @@ export interface ApiResponse<T> {
     limit: number;
     total: number;
     pages: number;
+    // The total comes from counts kept by the server and may be slightly off
+    approximate?: boolean;
   };
 }
 
@@ -253,10 +259,15 @@ export const getUrlById = async (id: number): Promise<UrlWithBrokenLinks> => {
   }
 };
 
-export const deleteUrl = async (id: number): Promise<void> => {
+// Passing the version the URL was read at makes the request fail if it changed since
+const ifMatch = (version?: number): Record<string, string> =>
+  version ? { 'If-Match': `"${version}"` } : {};
+
+export const deleteUrl = async (id: number, version?: number): Promise<void> => {
   try {
     const response = await makeAuthenticatedRequest(`${API_BASE_URL}/urls/${id}`, {
       method: 'DELETE',
+      headers: ifMatch(version),
     });
     
     if (!response.ok) {
@@ -269,10 +280,11 @@ export const deleteUrl = async (id: number): Promise<void> => {
   }
 };
 
-export const reanalyzeUrl = async (id: number): Promise<void> => {
+export const reanalyzeUrl = async (id: number, version?: number): Promise<void> => {
   try {
     const response = await makeAuthenticatedRequest(`${API_BASE_URL}/urls/${id}/reanalyze`, {
       method: 'PUT',
+      headers: ifMatch(version),
     });
     
     if (!response.ok) {
@@ -194,14 +194,18 @@ const UrlDetails: React.FC = () => {
                       </td>
                       <td>
                         {link.status_code ? (
-                          <span className="status-code error">
+                          <span className={`status-code ${link.rate_limited ? 'rate-limited' : 'error'}`}>
                             {link.status_code}
                           </span>
                         ) : (
                           <span className="status-code unknown">N/A</span>
                         )}
                       </td>
-                      <td>{link.error_message || 'N/A'}</td>
+                      <td>
+                        {link.rate_limited
+                          ? 'Rate limited by the server, the link may still work'
+                          : link.error_message || 'N/A'}
+                      </td>
                       <td>{formatDate(link.created_at)}</td>
                     </tr>
                   ))}
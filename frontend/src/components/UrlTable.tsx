@@ -7,6 +7,7 @@ interface PaginationInfo {
   per_page: number;
   total: number;
   total_pages: number;
+  approximate?: boolean;
 }
 
 export interface UrlTableRef {
@@ -66,7 +67,8 @@ const UrlTable = forwardRef<UrlTableRef>((props, ref) => {
         page: response.pagination.page,
         per_page: limit || prev.per_page,
         total: response.pagination.total,
-        total_pages: Math.ceil(response.pagination.total / response.pagination.limit)
+        total_pages: Math.ceil(response.pagination.total / response.pagination.limit),
+        approximate: response.pagination.approximate
       }));
       
       // Update processing state and analysis progress
@@ -171,12 +173,12 @@ const UrlTable = forwardRef<UrlTableRef>((props, ref) => {
     }
   }, [pagination.per_page, loadUrls]);
 
-  const handleReanalyze = async (id: number) => {
+  const handleReanalyze = async (id: number, version?: number) => {
     try {
       setProcessingUrls(prev => new Set([...Array.from(prev), id]));
       setAnalysisProgress(prev => new Map([...Array.from(prev), [id, 5]]));
       
-      await reanalyzeUrl(id);
+      await reanalyzeUrl(id, version);
       
       // Immediate refresh to show the updated status
       setTimeout(() => {
@@ -195,19 +197,22 @@ const UrlTable = forwardRef<UrlTableRef>((props, ref) => {
         newMap.delete(id);
         return newMap;
       });
+      // The URL may have changed in another tab; show its current state
+      loadUrls(currentPage, pagination.per_page, true);
     }
   };
 
-  const handleDelete = async (id: number) => {
+  const handleDelete = async (id: number, version?: number) => {
     if (!window.confirm('Are you sure you want to delete this URL?')) {
       return;
     }
 
     try {
-      await deleteUrl(id);
+      await deleteUrl(id, version);
       loadUrls(currentPage, pagination.per_page, true);
     } catch (err) {
       console.error('Error deleting URL:', err);
+      loadUrls(currentPage, pagination.per_page, true);
     }
   };
 
@@ -324,6 +329,8 @@ const UrlTable = forwardRef<UrlTableRef>((props, ref) => {
             <span style={{ color: '#ef4444' }}>Error</span>
           </div>
         );
+      case 'cancelled':
+        return <span style={{ color: '#6b7280' }}>Cancelled</span>;
       default:
         return <span style={{ color: '#6b7280' }}>Unknown</span>;
     }
@@ -530,7 +537,7 @@ const UrlTable = forwardRef<UrlTableRef>((props, ref) => {
                           📊 Details
                         </Link>
                         <button
-                          onClick={() => handleReanalyze(url.id)}
+                          onClick={() => handleReanalyze(url.id, url.version)}
                           className="reanalyze-btn"
                           title="Reanalyze this URL"
                           disabled={processingUrls.has(url.id) || url.status === 'running'}
@@ -538,7 +545,7 @@ const UrlTable = forwardRef<UrlTableRef>((props, ref) => {
                           🔄 Reanalyze
                         </button>
                         <button
-                          onClick={() => handleDelete(url.id)}
+                          onClick={() => handleDelete(url.id, url.version)}
                           className="delete-btn"
                           title="Delete this URL"
                           disabled={processingUrls.has(url.id)}
@@ -574,7 +581,7 @@ const UrlTable = forwardRef<UrlTableRef>((props, ref) => {
             Previous
           </button>
           <span className="pagination-info">
-            Page {currentPage} of {pagination.total_pages} ({pagination.total} total URLs)
+            Page {currentPage} of {pagination.total_pages} ({pagination.approximate ? 'about ' : ''}{pagination.total} total URLs)
           </span>
           <button
             onClick={() => setCurrentPage(Math.min(pagination.total_pages, currentPage + 1))}
@@ -25,6 +25,7 @@ describe('UrlForm', () => {
     has_login_form: false,
     status: 'completed',
     error_message: undefined,
+    version: 1,
     created_at: '2023-01-01T00:00:00Z',
     updated_at: '2023-01-01T00:00:00Z'
   };
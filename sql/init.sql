@@ -1,9 +1,27 @@
+-- Records which migrations of backend/migrations/sql this database has had
+CREATE TABLE IF NOT EXISTS schema_migrations (
+    name VARCHAR(255) PRIMARY KEY,
+    applied_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
 -- Create users table
 CREATE TABLE IF NOT EXISTS users (
     id INT AUTO_INCREMENT PRIMARY KEY,
     username VARCHAR(50) UNIQUE NOT NULL,
     email VARCHAR(100) UNIQUE NOT NULL,
     password VARCHAR(255) NOT NULL,
+    is_admin BOOLEAN DEFAULT FALSE,
+    is_demo BOOLEAN DEFAULT FALSE, -- seeded demo account, only reachable with read-only tokens
+    max_concurrent_crawls INT NULL, -- overrides worker.max_crawls_per_user when set (0 = unlimited)
+    digest_frequency ENUM('off', 'daily', 'weekly') DEFAULT 'off', -- activity digest email schedule
+    digest_hour TINYINT DEFAULT 8, -- UTC hour the digest goes out
+    digest_last_sent_at TIMESTAMP NULL,
+    retention_days INT NULL, -- keeps crawl history for fewer days than retention.days when set
+    default_crawl_timeout_seconds INT NULL, -- crawl settings given to new URLs; NULL follows the server
+    default_user_agent VARCHAR(255) NULL,
+    default_check_broken_links BOOLEAN NOT NULL DEFAULT TRUE,
+    retention_override_days INT NULL, -- set by administrators; replaces both (0 = keep forever)
+    timezone VARCHAR(64) NOT NULL DEFAULT 'UTC', -- IANA zone exports and digests show times in
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
     updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP
 );
@@ -22,14 +40,60 @@ CREATE TABLE IF NOT EXISTS urls (
     external_links INT DEFAULT 0,
     broken_links INT DEFAULT 0,
     has_login_form BOOLEAN DEFAULT FALSE,
-    status ENUM('queued', 'running', 'completed', 'error') DEFAULT 'queued',
+    internal_nofollow_links INT DEFAULT 0,
+    external_nofollow_links INT DEFAULT 0,
+    sponsored_links INT DEFAULT 0,
+    ugc_links INT DEFAULT 0,
+    is_noindex BOOLEAN DEFAULT FALSE,
+    is_nofollow BOOLEAN DEFAULT FALSE,
+    status ENUM('queued', 'running', 'completed', 'error', 'cancelled') DEFAULT 'queued',
     error_message TEXT,
+    crawled_at TIMESTAMP NULL,
+    result_schema_version INT NOT NULL DEFAULT 0, -- analyzer.SchemaVersion of the stored analysis; 0 before versions were kept
+    progress_stage VARCHAR(20) NULL,
+    progress_links_discovered INT DEFAULT 0,
+    progress_links_to_check INT DEFAULT 0,
+    progress_links_checked INT DEFAULT 0,
+    progress_percent TINYINT DEFAULT 0,
+    progress_updated_at TIMESTAMP NULL,
+    share_token VARCHAR(64) NULL UNIQUE,
+    hreflang JSON NULL,
+    forms JSON NULL, -- every form with its method, action, field counts and findings
+    link_hygiene JSON NULL,
+    contact_links JSON NULL, -- mailto and tel link counts and malformed links
+    social_profiles JSON NULL, -- Twitter/X, LinkedIn, Facebook, Instagram and GitHub accounts linked from the page
+    fragments JSON NULL, -- #fragment links and those pointing to missing elements
+    safety JSON NULL,
+    privacy JSON NULL,
+    consent JSON NULL,
+    has_consent_banner BOOLEAN NULL,
+    performance JSON NULL,
+    dns JSON NULL,
+    hosting JSON NULL,
+    registration JSON NULL,
+    domain_expires_at TIMESTAMP NULL,
+    web_vitals JSON NULL,
+    broken_assets JSON NULL, -- images, scripts and stylesheets that failed their check, when crawler.check_assets is on
+    documents JSON NULL, -- linked PDFs and office documents, with mislabeled and oversized ones
+    internal_pages JSON NULL, -- same-host pages linked from the page, for the generated sitemap
+    uptime_next_check_at TIMESTAMP NULL, -- when the uptime monitor pings the URL next
+    crawl_timeout_seconds INT NULL, -- page timeout of the URL's crawls; NULL follows crawler.page_timeout
+    crawl_user_agent VARCHAR(255) NULL, -- NULL follows crawler.user_agent
+    check_broken_links BOOLEAN NOT NULL DEFAULT TRUE,
+    project VARCHAR(100) NULL, -- the project the URL is organized under, if any
+    version INT NOT NULL DEFAULT 1, -- raised by every status change and edit, for If-Match checks
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
     updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
     FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
-    INDEX idx_user_id (user_id),
+    INDEX idx_user_status_created (user_id, status, created_at), -- the URL list filtered by status
+    INDEX idx_user_created (user_id, created_at), -- the URL list in its default order
+    INDEX idx_user_url (user_id, url(255)), -- duplicate checks when adding a URL
+    INDEX idx_user_project (user_id, project),
     INDEX idx_status (status),
-    INDEX idx_created_at (created_at)
+    INDEX idx_status_schema_version (status, result_schema_version), -- finding outdated analyses to backfill
+    INDEX idx_created_at (created_at),
+    INDEX idx_user_domain_expires (user_id, domain_expires_at),
+    INDEX idx_uptime_next_check (uptime_next_check_at)
 );
 
 -- Create broken_links table for detailed broken link information
@@ -39,11 +103,329 @@ CREATE TABLE IF NOT EXISTS broken_links (
     link_url TEXT NOT NULL,
     status_code INT,
     error_message TEXT,
+    suggestions JSON NULL, -- working replacement URLs
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (url_id) REFERENCES urls(id) ON DELETE CASCADE,
+    INDEX idx_url_id (url_id)
+);
+
+-- Create link_checks table with the link check verdicts shared by every crawl, reused for crawler.link_cache_ttl
+CREATE TABLE IF NOT EXISTS link_checks (
+    url_hash BINARY(32) PRIMARY KEY, -- SHA-256 of the link
+    url TEXT NOT NULL,
+    broken BOOLEAN NOT NULL,
+    status_code INT NULL,
+    error_message TEXT NULL,
+    checked_at TIMESTAMP NOT NULL,
+    INDEX idx_checked_at (checked_at)
+);
+
+-- Create crawl_jobs table used as the shared crawl queue between API servers and workers
+CREATE TABLE IF NOT EXISTS crawl_jobs (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    url_id INT NOT NULL,
+    status ENUM('pending', 'paused', 'leased', 'done', 'failed') DEFAULT 'pending',
+    worker_id VARCHAR(191),
+    lease_expires_at TIMESTAMP NULL,
+    attempts INT DEFAULT 0,
+    force_fresh BOOLEAN DEFAULT FALSE,
+    priority TINYINT DEFAULT 0,
+    pause_requested BOOLEAN NOT NULL DEFAULT FALSE, -- the owner asked to pause the running crawl
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    FOREIGN KEY (url_id) REFERENCES urls(id) ON DELETE CASCADE,
+    INDEX idx_status_lease (status, lease_expires_at),
+    INDEX idx_url_status (url_id, status),
+    INDEX idx_status_priority (status, priority, id)
+);
+
+-- Link verdicts a paused crawl job had checked, taken up by the attempt that resumes it
+CREATE TABLE IF NOT EXISTS crawl_checkpoints (
+    job_id INT NOT NULL,
+    url_hash BINARY(32) NOT NULL, -- SHA-256 of the link
+    url TEXT NOT NULL,
+    broken BOOLEAN NOT NULL,
+    status_code INT NULL,
+    error_message TEXT NULL,
+    PRIMARY KEY (job_id, url_hash),
+    FOREIGN KEY (job_id) REFERENCES crawl_jobs(id) ON DELETE CASCADE
+);
+
+-- Create crawl_workers table holding worker heartbeats
+CREATE TABLE IF NOT EXISTS crawl_workers (
+    id VARCHAR(191) PRIMARY KEY,
+    hostname VARCHAR(255),
+    concurrency INT DEFAULT 0,
+    started_at TIMESTAMP NULL,
+    last_heartbeat_at TIMESTAMP NULL
+);
+
+-- Create crawl_runs table recording the outcome of every crawl for trend reporting
+CREATE TABLE IF NOT EXISTS crawl_runs (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    url_id INT,
+    user_id INT NOT NULL,
+    status ENUM('completed', 'error') NOT NULL,
+    broken_links INT DEFAULT 0,
+    error_message TEXT,
+    started_at TIMESTAMP NULL,
+    finished_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    archive_url VARCHAR(2048) NULL, -- Wayback Machine copy captured after the crawl
+    archive_error TEXT NULL,
+    FOREIGN KEY (url_id) REFERENCES urls(id) ON DELETE SET NULL,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    INDEX idx_user_finished (user_id, finished_at)
+);
+
+-- Create content_snapshots table with the normalized page text of recent crawls for change detection
+CREATE TABLE IF NOT EXISTS content_snapshots (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    url_id INT NOT NULL,
+    run_id INT NULL,
+    content MEDIUMTEXT NOT NULL,
+    content_hash CHAR(64) NOT NULL,
+    change_percent DECIMAL(5,2) NULL, -- NULL for the first snapshot of a URL
+    added_lines INT DEFAULT 0,
+    removed_lines INT DEFAULT 0,
+    created_at TIMESTAMP(3) DEFAULT CURRENT_TIMESTAMP(3),
+    archive_key VARCHAR(255) NULL, -- object holding the text once archived; content is then emptied
+    archived_at TIMESTAMP NULL,
+    FOREIGN KEY (url_id) REFERENCES urls(id) ON DELETE CASCADE,
+    FOREIGN KEY (run_id) REFERENCES crawl_runs(id) ON DELETE SET NULL,
+    INDEX idx_url_id (url_id, id),
+    INDEX idx_unarchived (archived_at, created_at)
+);
+
+-- Create link_exclusions table for links that should not be checked (per account or per URL)
+CREATE TABLE IF NOT EXISTS link_exclusions (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    user_id INT NOT NULL,
+    url_id INT NULL,
+    pattern VARCHAR(500) NOT NULL,
+    pattern_type ENUM('glob', 'regex') DEFAULT 'glob',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (url_id) REFERENCES urls(id) ON DELETE CASCADE,
+    INDEX idx_user_url (user_id, url_id)
+);
+
+-- Create check_rules table for user-defined CSS selector assertions (per account or per URL)
+CREATE TABLE IF NOT EXISTS check_rules (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    user_id INT NOT NULL,
+    url_id INT NULL,
+    name VARCHAR(100) NOT NULL,
+    selector VARCHAR(500) NOT NULL,
+    rule_type ENUM('exists', 'count', 'contains') NOT NULL,
+    min_count INT NULL,
+    max_count INT NULL,
+    text VARCHAR(500) NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (url_id) REFERENCES urls(id) ON DELETE CASCADE,
+    INDEX idx_user_url (user_id, url_id)
+);
+
+-- Create check_results table with the outcome of each rule in the latest crawl of a URL
+CREATE TABLE IF NOT EXISTS check_results (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    url_id INT NOT NULL,
+    rule_id INT NULL,
+    name VARCHAR(100) NOT NULL,
+    selector VARCHAR(500) NOT NULL,
+    rule_type VARCHAR(20) NOT NULL,
+    passed BOOLEAN NOT NULL,
+    matches INT DEFAULT 0,
+    message VARCHAR(500),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (url_id) REFERENCES urls(id) ON DELETE CASCADE,
+    FOREIGN KEY (rule_id) REFERENCES check_rules(id) ON DELETE SET NULL,
+    INDEX idx_url_id (url_id)
+);
+
+-- Create url_keywords table with the target keywords analyzed on each crawl of a URL
+CREATE TABLE IF NOT EXISTS url_keywords (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    url_id INT NOT NULL,
+    keyword VARCHAR(100) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (url_id) REFERENCES urls(id) ON DELETE CASCADE,
+    UNIQUE KEY unique_url_keyword (url_id, keyword)
+);
+
+-- Create url_tags table with the labels users organize their URLs with
+CREATE TABLE IF NOT EXISTS url_tags (
+    url_id INT NOT NULL,
+    tag VARCHAR(40) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (url_id, tag),
+    FOREIGN KEY (url_id) REFERENCES urls(id) ON DELETE CASCADE,
+    INDEX idx_tag (tag)
+);
+
+-- Create url_counts table with the number of URLs of each user in each status, kept up to date by the
+-- backend so the URL list can show its total without counting the rows
+CREATE TABLE IF NOT EXISTS url_counts (
+    user_id INT NOT NULL,
+    status ENUM('queued', 'running', 'completed', 'error', 'cancelled') NOT NULL,
+    total INT NOT NULL DEFAULT 0,
+    counted_at TIMESTAMP NOT NULL, -- when the URLs were last counted exactly
+    PRIMARY KEY (user_id, status),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+-- Create keyword_results table with keyword occurrences and density in the latest crawl of a URL
+CREATE TABLE IF NOT EXISTS keyword_results (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    url_id INT NOT NULL,
+    keyword VARCHAR(100) NOT NULL,
+    title_count INT DEFAULT 0,
+    headings_count INT DEFAULT 0,
+    meta_description_count INT DEFAULT 0,
+    body_count INT DEFAULT 0,
+    density DECIMAL(6,2) DEFAULT 0,
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
     FOREIGN KEY (url_id) REFERENCES urls(id) ON DELETE CASCADE,
     INDEX idx_url_id (url_id)
 );
 
+-- Create lighthouse_results table with the Lighthouse scores of a URL, refreshed after each crawl
+CREATE TABLE IF NOT EXISTS lighthouse_results (
+    url_id INT PRIMARY KEY,
+    strategy VARCHAR(10) NOT NULL,
+    performance_score TINYINT UNSIGNED NULL,
+    accessibility_score TINYINT UNSIGNED NULL,
+    best_practices_score TINYINT UNSIGNED NULL,
+    seo_score TINYINT UNSIGNED NULL,
+    metrics JSON NULL,
+    lighthouse_version VARCHAR(32) NULL,
+    fetched_at TIMESTAMP NULL, -- when the scores were measured; kept from the last successful run
+    error_message TEXT NULL, -- why the latest run failed
+    checked_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP, -- when the latest run finished
+    FOREIGN KEY (url_id) REFERENCES urls(id) ON DELETE CASCADE
+);
+
+-- Create crawl_logs table with the lifecycle of every crawl job (queued, started, finished, failed)
+CREATE TABLE IF NOT EXISTS crawl_logs (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    url_id INT NOT NULL,
+    job_id INT NULL,
+    level ENUM('info', 'warn', 'error') DEFAULT 'info',
+    event VARCHAR(32) NOT NULL,
+    message VARCHAR(1000) NOT NULL,
+    duration_ms INT NULL,
+    details JSON NULL,
+    created_at TIMESTAMP(3) DEFAULT CURRENT_TIMESTAMP(3),
+    FOREIGN KEY (url_id) REFERENCES urls(id) ON DELETE CASCADE,
+    INDEX idx_url_created (url_id, created_at)
+);
+
+-- Create uptime_checks table with the uptime monitor's pings of each URL
+CREATE TABLE IF NOT EXISTS uptime_checks (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    url_id INT NOT NULL,
+    status_code SMALLINT NULL, -- NULL when the request failed
+    response_ms INT NULL,
+    is_up BOOLEAN NOT NULL,
+    error_message VARCHAR(500) NULL,
+    checked_at TIMESTAMP(3) DEFAULT CURRENT_TIMESTAMP(3),
+    FOREIGN KEY (url_id) REFERENCES urls(id) ON DELETE CASCADE,
+    INDEX idx_url_checked (url_id, checked_at),
+    INDEX idx_checked (checked_at)
+);
+
+-- Create alert_rules table with user-defined alert conditions (per account or per URL) and their channels
+CREATE TABLE IF NOT EXISTS alert_rules (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    user_id INT NOT NULL,
+    url_id INT NULL,
+    name VARCHAR(100) NOT NULL,
+    expression VARCHAR(200) NOT NULL, -- e.g. broken_links > 5
+    channels JSON NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (url_id) REFERENCES urls(id) ON DELETE CASCADE,
+    INDEX idx_user_url (user_id, url_id)
+);
+
+-- Create alert_states table remembering whether each alert rule currently fires for each URL
+CREATE TABLE IF NOT EXISTS alert_states (
+    rule_id INT NOT NULL,
+    url_id INT NOT NULL,
+    firing BOOLEAN NOT NULL,
+    changed_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (rule_id, url_id),
+    FOREIGN KEY (rule_id) REFERENCES alert_rules(id) ON DELETE CASCADE,
+    FOREIGN KEY (url_id) REFERENCES urls(id) ON DELETE CASCADE
+);
+
+-- Create data_exports table with the account data archives users request (GDPR right of access)
+CREATE TABLE IF NOT EXISTS data_exports (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    user_id INT NOT NULL,
+    status ENUM('pending', 'ready', 'failed') DEFAULT 'pending',
+    token CHAR(32) NOT NULL UNIQUE, -- unguessable download token
+    content LONGBLOB NULL, -- the ZIP archive of exports made before storage_key
+    storage_key VARCHAR(255) NULL, -- object holding the ZIP archive once ready
+    size_bytes BIGINT NULL,
+    error_message TEXT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    completed_at TIMESTAMP NULL,
+    expires_at TIMESTAMP NULL,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    INDEX idx_user_created (user_id, created_at)
+);
+
+-- Create exports table with the URL exports built in the background into storage
+CREATE TABLE IF NOT EXISTS exports (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    user_id INT NOT NULL,
+    format ENUM('xlsx', 'csv', 'zip') NOT NULL,
+    status ENUM('pending', 'ready', 'failed') DEFAULT 'pending',
+    token CHAR(32) NOT NULL UNIQUE, -- unguessable download token
+    storage_key VARCHAR(255) NULL, -- object holding the file once ready
+    size_bytes BIGINT NULL,
+    error_message TEXT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    completed_at TIMESTAMP NULL,
+    expires_at TIMESTAMP NULL,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    INDEX idx_user_created (user_id, created_at)
+);
+
+-- Create url_views table with the saved filters of the URL list
+CREATE TABLE IF NOT EXISTS url_views (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    user_id INT NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    filters JSON NOT NULL, -- status, search, consent_banner and sort
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    UNIQUE KEY uniq_user_name (user_id, name)
+);
+
+-- Create service_tokens table with the service accounts integrations authenticate as; deleting a row revokes its token
+CREATE TABLE IF NOT EXISTS service_tokens (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    user_id INT NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    scopes VARCHAR(255) NOT NULL, -- comma separated, e.g. urls:read,stats:read
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    expires_at TIMESTAMP NULL,
+    last_used_at TIMESTAMP NULL,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    INDEX idx_user (user_id)
+);
+
+-- Create tenants table with the tenants served by the same processes, each with a database of its own; only used in the primary database
+CREATE TABLE IF NOT EXISTS tenants (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    name VARCHAR(32) NOT NULL UNIQUE,
+    database_name VARCHAR(64) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
 -- Insert default user for development
 INSERT IGNORE INTO users (username, email, password) VALUES 
 ('demo', 'demo@example.com', '$2a$10$92IXUNpkjO0rOQ5byMi.Ye4oKoEa3Ro9llC/.og/at2.uheWG/igi'); -- password: password